@@ -0,0 +1,50 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Registrar abuse contacts, as ICANN requires gTLD registrars to publish
+// them ("Registrar Abuse Contact Email:") and as some ccTLDs label them
+var (
+	abuseEmailPattern = regexp.MustCompile(`(?im)^\s*(?:registrar\s+)?abuse(?:\s+contact)?[\s-]*(?:e-?mail|mailbox)\s*:[ \t]*(\S+@[^\s@]+?)\.?\s*$`)
+	abusePhonePattern = regexp.MustCompile(`(?im)^\s*(?:registrar\s+)?abuse(?:\s+contact)?[\s-]*(?:phone|tel(?:ephone)?)\s*:[ \t]*(\+?[0-9][0-9 .()-]*[0-9])\s*$`)
+)
+
+// parseAbuseContact extracts the registrar abuse email and phone from raw
+// WHOIS text
+func parseAbuseContact(raw string) (email, phone string) {
+	if m := abuseEmailPattern.FindStringSubmatch(raw); m != nil {
+		email = strings.ToLower(m[1])
+	}
+	if m := abusePhonePattern.FindStringSubmatch(raw); m != nil {
+		phone = m[1]
+	}
+	return email, phone
+}
+
+// rdapAbuseContact returns the email and phone of the first entity with the
+// abuse role, normally nested under the registrar
+func rdapAbuseContact(entities []rdapEntity) (email, phone string) {
+	for _, entity := range entities {
+		if !containsString(entity.Roles, "abuse") {
+			continue
+		}
+		card := parseVCard(entity.VCardArray)
+		return strings.ToLower(card["email"]), strings.TrimPrefix(card["tel"], "tel:")
+	}
+	return "", ""
+}
+
+// abuseContact formats the registrar abuse email and phone for reports,
+// empty when neither is known
+func (d DomainInfo) abuseContact() string {
+	var parts []string
+	for _, part := range []string{d.AbuseEmail, d.AbusePhone} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestParseAbuseContact(t *testing.T) {
+	tests := []struct {
+		raw   string
+		email string
+		phone string
+	}{
+		{
+			"Registrar: Example Registrar, Inc.\nRegistrar Abuse Contact Email: Abuse@Registrar.example\nRegistrar Abuse Contact Phone: +1.4805058800\n",
+			"abuse@registrar.example", "+1.4805058800",
+		},
+		{"abuse-mailbox: abuse@nic.example\n", "abuse@nic.example", ""},
+		{"Abuse Email: report@registrar.example.\nAbuse Phone: +44 (0)20 7946 0000\n", "report@registrar.example", "+44 (0)20 7946 0000"},
+		{"Registrar Abuse Contact Email:\nRegistrar Abuse Contact Phone:\n", "", ""},
+		{"Registrant Email: owner@example.com\n", "", ""},
+	}
+
+	for _, tt := range tests {
+		email, phone := parseAbuseContact(tt.raw)
+		if email != tt.email || phone != tt.phone {
+			t.Errorf("parseAbuseContact(%q) = %q, %q; expected %q, %q", tt.raw, email, phone, tt.email, tt.phone)
+		}
+	}
+}
+
+func TestAbuseContact(t *testing.T) {
+	tests := []struct {
+		info     DomainInfo
+		expected string
+	}{
+		{DomainInfo{AbuseEmail: "abuse@registrar.example", AbusePhone: "+1.4805058800"}, "abuse@registrar.example, +1.4805058800"},
+		{DomainInfo{AbusePhone: "+1.4805058800"}, "+1.4805058800"},
+		{DomainInfo{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.info.abuseContact(); got != tt.expected {
+			t.Errorf("abuseContact() = %q; expected %q", got, tt.expected)
+		}
+	}
+}
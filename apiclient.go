@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxAPIRetries is how many times a throttled or failed API request
+	// is retried
+	maxAPIRetries = 3
+	// maxAPIRetryDelay caps the wait before a retry, whatever Retry-After
+	// asks for
+	maxAPIRetryDelay = time.Minute
+)
+
+// errAPIQuota is returned for requests past a provider's quota
+var errAPIQuota = errors.New("API quota spent")
+
+// APILimit is the request etiquette of an integration's API
+type APILimit struct {
+	// PerMinute is the most requests per minute the API accepts
+	PerMinute int `yaml:"per_minute,omitempty"`
+	// Quota is the most requests a scan may send, retries included; zero
+	// is unlimited
+	Quota int `yaml:"quota,omitempty"`
+}
+
+// defaultAPILimits are the limits of the free API tiers, by provider. Paid
+// plans raise them in config.yaml.
+var defaultAPILimits = map[string]APILimit{
+	"virustotal":     {PerMinute: 4, Quota: 500},
+	"securitytrails": {PerMinute: 60, Quota: 50},
+	"shodan":         {PerMinute: 60},
+	"censys":         {PerMinute: 24, Quota: 250},
+	"urlscan":        {PerMinute: 60},
+	"circl":          {PerMinute: 60},
+	"farsight":       {PerMinute: 60},
+}
+
+// APIUsage is what a scan spent of an integration's API
+type APIUsage struct {
+	Provider string `json:"provider"`
+	// Requests were sent to the API, retries included
+	Requests int `json:"requests"`
+	// Cached requests were answered from an earlier identical request
+	Cached int `json:"cached,omitempty"`
+	// Retries were sent again after a throttled or failed attempt
+	Retries int `json:"retries,omitempty"`
+	// RateLimited answers were HTTP 429
+	RateLimited int `json:"rate_limited,omitempty"`
+	// Refused requests were not sent because the quota was spent
+	Refused int  `json:"refused,omitempty"`
+	Quota   int  `json:"quota,omitempty"`
+	Spent   bool `json:"quota_spent,omitempty"`
+}
+
+// apiClient is the HTTP layer shared by the API integrations: each provider
+// gets its own rate limit and quota, GET answers are cached for the scan,
+// and throttled or failed requests are retried
+type apiClient struct {
+	base   http.RoundTripper
+	limits map[string]APILimit
+
+	mu        sync.Mutex
+	providers map[string]*apiProvider
+}
+
+// apiProvider is the state of one provider's API during a scan
+type apiProvider struct {
+	limiter *rate.Limiter
+
+	mu    sync.Mutex
+	usage APIUsage
+	cache map[string]cachedAPIResponse
+}
+
+// cachedAPIResponse is a successful GET answer kept for the scan
+type cachedAPIResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// newAPIClient merges the configured limits over the defaults. Requests go
+// through a copy of http.DefaultTransport, so -tor applies, that waits
+// timeout for each answer: waiting on a rate limit does not count.
+func newAPIClient(configured map[string]APILimit, timeout time.Duration) (*apiClient, error) {
+	limits := make(map[string]APILimit)
+	for provider, limit := range defaultAPILimits {
+		limits[provider] = limit
+	}
+	for provider, limit := range configured {
+		if limit.PerMinute < 0 || limit.Quota < 0 {
+			return nil, fmt.Errorf("api_limits.%s: limits must not be negative", provider)
+		}
+		limits[strings.ToLower(provider)] = limit
+	}
+	base := http.DefaultTransport
+	if transport, ok := base.(*http.Transport); ok {
+		transport = transport.Clone()
+		transport.ResponseHeaderTimeout = timeout
+		base = transport
+	}
+	return &apiClient{base: base, limits: limits, providers: make(map[string]*apiProvider)}, nil
+}
+
+// reset starts a new scan: usage, quotas and cached answers start over
+func (c *apiClient) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.providers = make(map[string]*apiProvider)
+}
+
+// provider returns the state of a provider's API, created on first use
+func (c *apiClient) provider(name string) *apiProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.providers[name]
+	if !ok {
+		limit := c.limits[name]
+		p = &apiProvider{
+			limiter: rate.NewLimiter(rate.Inf, 1),
+			usage:   APIUsage{Provider: name, Quota: limit.Quota},
+			cache:   make(map[string]cachedAPIResponse),
+		}
+		if limit.PerMinute > 0 {
+			p.limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(limit.PerMinute)), 1)
+		}
+		c.providers[name] = p
+	}
+	return p
+}
+
+// usage returns what the scan spent of each API used, by provider name
+func (c *apiClient) usage() []APIUsage {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var usage []APIUsage
+	for _, p := range c.providers {
+		p.mu.Lock()
+		usage = append(usage, p.usage)
+		p.mu.Unlock()
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Provider < usage[j].Provider })
+	return usage
+}
+
+// transport returns the RoundTripper of a provider's requests
+func (c *apiClient) transport(provider string) http.RoundTripper {
+	return &apiTransport{client: c, provider: provider}
+}
+
+// take spends one request of the quota and reports whether it was left.
+// The first refusal is reported once.
+func (p *apiProvider) take() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.usage.Quota > 0 && p.usage.Requests >= p.usage.Quota {
+		if !p.usage.Spent {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s quota of %d requests spent; its remaining lookups are skipped\n", ColorYellow, ColorReset, p.usage.Provider, p.usage.Quota)
+		}
+		p.usage.Spent = true
+		p.usage.Refused++
+		return false
+	}
+	p.usage.Requests++
+	return true
+}
+
+// cached returns the cached answer to a GET request
+func (p *apiProvider) cached(key string) (cachedAPIResponse, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.cache[key]
+	if ok {
+		p.usage.Cached++
+	}
+	return entry, ok
+}
+
+// apiTransport sends one provider's requests through the shared client
+type apiTransport struct {
+	client   *apiClient
+	provider string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *apiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.client.provider(t.provider)
+
+	var key string
+	if req.Method == http.MethodGet {
+		key = req.URL.String()
+		if entry, ok := p.cached(key); ok {
+			return entry.response(req), nil
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if !p.take() {
+			return nil, fmt.Errorf("%s: %w (%d requests)", t.provider, errAPIQuota, p.usage.Quota)
+		}
+		if err := p.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := t.client.base.RoundTrip(attemptReq)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			p.mu.Lock()
+			p.usage.RateLimited++
+			p.mu.Unlock()
+		}
+		if attempt == maxAPIRetries || !retryableAPIRequest(req, resp, err) {
+			if err != nil || key == "" || resp.StatusCode != http.StatusOK {
+				return resp, err
+			}
+			return p.store(key, req, resp)
+		}
+
+		delay := retryDelay(resp, attempt)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		p.mu.Lock()
+		p.usage.Retries++
+		p.mu.Unlock()
+	}
+}
+
+// store caches a successful GET answer and returns it
+func (p *apiProvider) store(key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	entry := cachedAPIResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body}
+	p.mu.Lock()
+	p.cache[key] = entry
+	p.mu.Unlock()
+	return entry.response(req), nil
+}
+
+// response rebuilds the cached answer for a request
+func (e cachedAPIResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.status, http.StatusText(e.status)),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// retryableAPIRequest reports whether an attempt is worth repeating: the API
+// throttled it or was briefly unavailable, or a request that can be sent
+// again failed in transit
+func retryableAPIRequest(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return req.Method == http.MethodGet && req.Context().Err() == nil
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before the next attempt: what the
+// API's Retry-After asks for, or an exponential backoff from one second
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	delay := time.Second << attempt
+	if resp != nil {
+		if after := resp.Header.Get("Retry-After"); after != "" {
+			if seconds, err := strconv.Atoi(after); err == nil && seconds >= 0 {
+				delay = time.Duration(seconds) * time.Second
+			} else if at, err := http.ParseTime(after); err == nil {
+				delay = time.Until(at)
+			}
+		}
+	}
+	return max(0, min(delay, maxAPIRetryDelay))
+}
+
+// formatAPIUsage renders the API usage of a scan for the summary, e.g.
+// "virustotal 37/500 (5 cached, 2 retried)"
+func formatAPIUsage(usage []APIUsage) string {
+	var parts []string
+	for _, u := range usage {
+		part := fmt.Sprintf("%s %d", u.Provider, u.Requests)
+		if u.Quota > 0 {
+			part += fmt.Sprintf("/%d", u.Quota)
+		}
+		var details []string
+		if u.Cached > 0 {
+			details = append(details, fmt.Sprintf("%d cached", u.Cached))
+		}
+		if u.Retries > 0 {
+			details = append(details, fmt.Sprintf("%d retried", u.Retries))
+		}
+		if u.RateLimited > 0 {
+			details = append(details, fmt.Sprintf("%d throttled", u.RateLimited))
+		}
+		if u.Spent {
+			details = append(details, fmt.Sprintf("quota spent, %d skipped", u.Refused))
+		}
+		if len(details) > 0 {
+			part += " (" + strings.Join(details, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
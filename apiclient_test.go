@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIClientRetriesAndCaches(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(map[string]APILimit{"test": {Quota: 10}}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: client.transport("test")}
+	for i := 0; i < 3; i++ {
+		resp, err := httpClient.Get(server.URL + "/domains/example.com")
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != `{"path":"/domains/example.com"}` {
+			t.Errorf("Request %d: HTTP %d %s", i, resp.StatusCode, body)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("Expected one throttled attempt and one answer, the server got %d requests", hits)
+	}
+	expected := []APIUsage{{Provider: "test", Requests: 2, Cached: 2, Retries: 1, RateLimited: 1, Quota: 10}}
+	if usage := client.usage(); !reflect.DeepEqual(usage, expected) {
+		t.Errorf("usage() = %+v, expected %+v", usage, expected)
+	}
+
+	client.reset()
+	if usage := client.usage(); len(usage) != 0 {
+		t.Errorf("reset() should clear the usage, got %+v", usage)
+	}
+}
+
+func TestAPIClientQuota(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(map[string]APILimit{"test": {Quota: 2}}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{Transport: client.transport("test")}
+	for i, domain := range []string{"example.com", "example.net", "example.org"} {
+		resp, err := httpClient.Get(server.URL + "/domains/" + domain)
+		if i < 2 {
+			if err != nil {
+				t.Fatalf("Request %d failed: %v", i, err)
+			}
+			resp.Body.Close()
+			continue
+		}
+		if !errors.Is(err, errAPIQuota) {
+			t.Errorf("Expected the quota to refuse the third request, got %v", err)
+		}
+	}
+	usage := client.usage()
+	if len(usage) != 1 || !usage[0].Spent || usage[0].Refused != 1 || usage[0].Requests != 2 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+	if summary := formatAPIUsage(usage); summary != "test 2/2 (quota spent, 1 skipped)" {
+		t.Errorf("formatAPIUsage() = %s", summary)
+	}
+
+	if _, err := newAPIClient(map[string]APILimit{"virustotal": {Quota: -1}}, time.Second); err == nil {
+		t.Error("Expected negative limits to be rejected")
+	}
+}
+
+func TestFinishResultAPIUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(nil, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A brand sweep spends urlscan quota on screenshots after the lookups
+	config := Config{APIClient: client}
+	startScan(&config, newScanTiming())
+	resp, err := (&http.Client{Transport: client.transport("urlscan")}).Get(server.URL + "/scan/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	var result Result
+	finishResult(&result, nil, &DomainInfo{Domain: "example.com"}, newScanTiming(), config)
+	if len(result.APIUsage) != 1 || result.APIUsage[0].Provider != "urlscan" || result.APIUsage[0].Requests != 1 {
+		t.Errorf("Expected the urlscan request in the API usage, got %+v", result.APIUsage)
+	}
+}
+
+func TestAPIClientDoesNotRetryPost(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(nil, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/api/v1/scan/", io.NopCloser(strings.NewReader(`{}`)))
+	resp, err := (&http.Client{Transport: client.transport("urlscan")}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || hits != 1 {
+		t.Errorf("A body that cannot be replayed must not be retried: HTTP %d after %d requests", resp.StatusCode, hits)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		expected   time.Duration
+	}{
+		{"", 0, time.Second},
+		{"", 2, 4 * time.Second},
+		{"7", 0, 7 * time.Second},
+		{"3600", 0, maxAPIRetryDelay},
+		{"soon", 1, 2 * time.Second},
+	}
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if test.retryAfter != "" {
+			resp.Header.Set("Retry-After", test.retryAfter)
+		}
+		if delay := retryDelay(resp, test.attempt); delay != test.expected {
+			t.Errorf("retryDelay(%q, %d) = %s, expected %s", test.retryAfter, test.attempt, delay, test.expected)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keychainService is the service name secrets are stored under in the OS
+// keychain
+const keychainService = "tldscanner"
+
+// runAuth implements `tldscanner auth set|delete|token|list`
+func runAuth(args []string) int {
+	fs := flag.NewFlagSet("auth", flag.ContinueOnError)
+	configPath := fs.String("config", defaultConfigPath(), "Path to the configuration file")
+	useKeychain := fs.Bool("keychain", false, "Store the secret in the OS keychain instead of the config file")
+	username := fs.String("username", "", "Account or API ID for providers that need one (e.g. Censys)")
+	maxActive := fs.Int("max-active-scans", 0, "With token: maximum queued and running scans of the tenant (0 for no limit)")
+	maxDaily := fs.Int("max-daily-scans", 0, "With token: maximum scans the tenant may submit in 24 hours (0 for no limit)")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s auth [OPTIONS] set|delete <provider>\n", os.Args[0])
+		fmt.Printf("       %s auth [OPTIONS] token|revoke <tenant>\n", os.Args[0])
+		fmt.Printf("       %s auth [OPTIONS] list\n\n", os.Args[0])
+		fmt.Printf("The secret for `set` is read from stdin. `token` issues a serve mode API\n")
+		fmt.Printf("token for a tenant, replacing its previous one, and prints it once.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	// Options may come before or after the action: auth -keychain set
+	// shodan and auth set -keychain shodan are the same
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	action := fs.Arg(0)
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return ExitUsage
+	}
+
+	cfg, err := loadFileConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if action == "list" {
+		for _, name := range cfg.providers() {
+			storage := "config file"
+			if cfg.Credentials[name].Keychain {
+				storage = "keychain"
+			}
+			fmt.Printf("%s (%s)\n", name, storage)
+		}
+		for _, name := range cfg.tenantNames() {
+			fmt.Printf("%s (tenant)\n", name)
+		}
+		return ExitMatches
+	}
+
+	if fs.NArg() != 1 || (action != "set" && action != "delete" && action != "token" && action != "revoke") {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	if action == "token" || action == "revoke" {
+		tenant := fs.Arg(0)
+		var token string
+		if action == "token" {
+			token = authToken(cfg, tenant, *maxActive, *maxDaily)
+		} else {
+			err = authRevoke(cfg, tenant)
+		}
+		if err == nil {
+			err = saveFileConfig(*configPath, cfg)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		if token != "" {
+			fmt.Fprintf(os.Stderr, "%s[INFO]%s API token for tenant %s (shown only once):\n", ColorBlue, ColorReset, tenant)
+			fmt.Println(token)
+		} else {
+			fmt.Printf("%s[INFO]%s Tenant %s removed from %s\n", ColorBlue, ColorReset, tenant, *configPath)
+		}
+		return ExitMatches
+	}
+	provider := strings.ToLower(fs.Arg(0))
+
+	switch action {
+	case "set":
+		err = authSet(cfg, provider, *username, *useKeychain)
+	case "delete":
+		err = authDelete(cfg, provider)
+	}
+	if err == nil {
+		err = saveFileConfig(*configPath, cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	fmt.Printf("%s[INFO]%s Credentials for %s updated in %s\n", ColorBlue, ColorReset, provider, *configPath)
+	return ExitMatches
+}
+
+func authSet(cfg *FileConfig, provider, username string, useKeychain bool) error {
+	if isTerminal(os.Stdin) {
+		fmt.Printf("API key for %s: ", provider)
+	}
+	secret, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && secret == "" {
+		return fmt.Errorf("failed to read secret from stdin: %w", err)
+	}
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return errors.New("empty secret")
+	}
+
+	cred := Credential{Username: username}
+	if useKeychain {
+		if err := keychainSet(provider, secret); err != nil {
+			return err
+		}
+		cred.Keychain = true
+	} else {
+		cred.APIKey = secret
+	}
+
+	if cfg.Credentials == nil {
+		cfg.Credentials = map[string]Credential{}
+	}
+	cfg.Credentials[provider] = cred
+	return nil
+}
+
+func authDelete(cfg *FileConfig, provider string) error {
+	cred, ok := cfg.Credentials[provider]
+	if !ok {
+		return fmt.Errorf("no credentials configured for %s", provider)
+	}
+	if cred.Keychain {
+		if err := keychainDelete(provider); err != nil {
+			return err
+		}
+	}
+	delete(cfg.Credentials, provider)
+	return nil
+}
+
+// authToken issues a new API token for a tenant, keeping its quotas unless
+// new ones are given, and returns it. Only its hash is stored.
+func authToken(cfg *FileConfig, tenant string, maxActive, maxDaily int) string {
+	if cfg.Tenants == nil {
+		cfg.Tenants = map[string]Tenant{}
+	}
+	settings := cfg.Tenants[tenant]
+	if maxActive > 0 {
+		settings.MaxActiveScans = maxActive
+	}
+	if maxDaily > 0 {
+		settings.MaxDailyScans = maxDaily
+	}
+	token := newToken()
+	settings.TokenSHA256 = hashToken(token)
+	cfg.Tenants[tenant] = settings
+	return token
+}
+
+// authRevoke removes a tenant and with it its token
+func authRevoke(cfg *FileConfig, tenant string) error {
+	if _, ok := cfg.Tenants[tenant]; !ok {
+		return fmt.Errorf("no tenant %s configured", tenant)
+	}
+	delete(cfg.Tenants, tenant)
+	return nil
+}
+
+// keychainSet stores a secret using the platform keychain tool: `security`
+// on macOS and `secret-tool` (libsecret) on Linux
+func keychainSet(provider, secret string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		// The secret goes to security's interactive mode on stdin so it
+		// never appears in the process list
+		return keychainRun(strings.NewReader(securityCommand("add-generic-password", "-U",
+			"-s", keychainService, "-a", provider, "-w", secret)), "security", "-i")
+	case "linux", "freebsd":
+		return keychainRun(strings.NewReader(secret), "secret-tool", "store",
+			"--label=tldscanner "+provider, "service", keychainService, "account", provider)
+	}
+	return fmt.Errorf("OS keychain is not supported on %s; store the key in the config file or %s", runtime.GOOS, credentialEnvVar(provider))
+}
+
+// keychainGet reads a secret previously stored with keychainSet
+func keychainGet(provider string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", provider, "-w")
+	case "linux", "freebsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", provider)
+	default:
+		return "", fmt.Errorf("OS keychain is not supported on %s", runtime.GOOS)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keychain lookup for %s failed: %w", provider, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keychainDelete(provider string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return keychainRun(nil, "security", "delete-generic-password", "-s", keychainService, "-a", provider)
+	case "linux", "freebsd":
+		return keychainRun(nil, "secret-tool", "clear", "service", keychainService, "account", provider)
+	}
+	return fmt.Errorf("OS keychain is not supported on %s", runtime.GOOS)
+}
+
+// securityCommand quotes args as one command line for `security -i`
+func securityCommand(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+	}
+	return strings.Join(quoted, " ") + "\n"
+}
+
+func keychainRun(stdin *strings.Reader, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %v %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
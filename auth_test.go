@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRunAuthOptionsAroundAction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if code := runAuth([]string{"-config", path, "-max-active-scans", "2", "token", "red-team"}); code != ExitMatches {
+		t.Fatalf("Expected options before the action to be accepted, got exit code %d", code)
+	}
+	if code := runAuth([]string{"token", "-config", path, "-max-daily-scans", "5", "blue-team"}); code != ExitMatches {
+		t.Fatalf("Expected options after the action to be accepted, got exit code %d", code)
+	}
+	cfg, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Tenants["red-team"].MaxActiveScans != 2 || cfg.Tenants["blue-team"].MaxDailyScans != 5 {
+		t.Errorf("Expected both tenants with their limits, got %+v", cfg.Tenants)
+	}
+	if code := runAuth([]string{"-config", path}); code != ExitUsage {
+		t.Errorf("Expected a missing action to be a usage error, got %d", code)
+	}
+}
+
+func TestSecurityCommand(t *testing.T) {
+	got := securityCommand("add-generic-password", "-w", `se"cr\et`)
+	want := `"add-generic-password" "-w" "se\"cr\\et"` + "\n"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
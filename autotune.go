@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// autoTuneStart is the conservative concurrency -auto-tune begins with
+	autoTuneStart = 2
+	// autoTuneWindow is the number of completed lookups between adjustments
+	autoTuneWindow = 10
+	// autoTuneMaxErrorRate is the error rate above which concurrency is halved
+	autoTuneMaxErrorRate = 0.25
+	// autoTuneGrowErrorRate is the error rate below which concurrency grows
+	autoTuneGrowErrorRate = 0.05
+)
+
+// concurrencyLimiter bounds the number of in-flight lookups. With auto-tune
+// enabled it adjusts the bound using additive increase / multiplicative
+// decrease based on the error and rate-limit responses seen in each window.
+type concurrencyLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int
+	max    int
+	active int
+
+	autoTune  bool
+	completed int
+	errors    int
+	throttled int
+
+	// onAdjust is called with the old and new limit after every change
+	onAdjust func(from, to int)
+}
+
+// newConcurrencyLimiter returns a fixed limiter of max slots, or an adaptive
+// one that starts small and may grow up to max when autoTune is set
+func newConcurrencyLimiter(max int, autoTune bool) *concurrencyLimiter {
+	if max < 1 {
+		max = 1
+	}
+	limit := max
+	if autoTune && autoTuneStart < max {
+		limit = autoTuneStart
+	}
+	c := &concurrencyLimiter{limit: limit, max: max, autoTune: autoTune}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// acquire blocks until a slot is free
+func (c *concurrencyLimiter) acquire() {
+	c.mu.Lock()
+	for c.active >= c.limit {
+		c.cond.Wait()
+	}
+	c.active++
+	c.mu.Unlock()
+}
+
+// release frees a slot and records the lookup outcome for auto-tuning:
+// errMsg is empty on success and for definitive answers such as an
+// unregistered domain, which say nothing about the load on the registry
+func (c *concurrencyLimiter) release(errMsg string) {
+	c.mu.Lock()
+	c.active--
+	if c.autoTune {
+		c.record(errMsg)
+	}
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// record must be called with c.mu held
+func (c *concurrencyLimiter) record(errMsg string) {
+	c.completed++
+	if errMsg != "" {
+		c.errors++
+		if isRateLimitError(errMsg) {
+			c.throttled++
+		}
+	}
+	if c.completed < autoTuneWindow {
+		return
+	}
+
+	errorRate := float64(c.errors) / float64(c.completed)
+	next := c.limit
+	switch {
+	case c.throttled > 0 || errorRate > autoTuneMaxErrorRate:
+		next = c.limit / 2
+		if next < 1 {
+			next = 1
+		}
+	case errorRate < autoTuneGrowErrorRate && c.limit < c.max:
+		next = c.limit + 1
+	}
+	c.completed, c.errors, c.throttled = 0, 0, 0
+
+	if next != c.limit {
+		from := c.limit
+		c.limit = next
+		if c.onAdjust != nil {
+			c.onAdjust(from, next)
+		}
+	}
+}
+
+// Limit returns the current concurrency bound
+func (c *concurrencyLimiter) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// rateLimitMarkers are substrings registries use when refusing queries
+var rateLimitMarkers = []string{
+	"limit exceeded", "rate limit", "too many", "quota", "try again later",
+	"connection reset", "connection refused",
+}
+
+// isRateLimitError reports whether an error message looks like the registry
+// is throttling or blocking us
+func isRateLimitError(errMsg string) bool {
+	errMsg = strings.ToLower(errMsg)
+	for _, marker := range rateLimitMarkers {
+		if strings.Contains(errMsg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAutoTune checks the auto-tune options
+func validateAutoTune(config Config) error {
+	if config.AutoTune && config.AutoTuneMax < 1 {
+		return fmt.Errorf("-auto-tune-max must be at least 1")
+	}
+	if !config.AutoTune && config.Threads < 1 {
+		return fmt.Errorf("-t must be at least 1")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestConcurrencyLimiterFixed(t *testing.T) {
+	c := newConcurrencyLimiter(5, false)
+	for i := 0; i < 50; i++ {
+		c.acquire()
+		c.release("timeout")
+	}
+	if c.Limit() != 5 {
+		t.Errorf("Fixed limiter changed its limit to %d", c.Limit())
+	}
+}
+
+func TestConcurrencyLimiterGrows(t *testing.T) {
+	c := newConcurrencyLimiter(10, true)
+	if c.Limit() != autoTuneStart {
+		t.Fatalf("Auto-tune should start at %d, got %d", autoTuneStart, c.Limit())
+	}
+	for i := 0; i < autoTuneWindow*3; i++ {
+		c.acquire()
+		c.release("")
+	}
+	if c.Limit() != autoTuneStart+3 {
+		t.Errorf("Limit after three clean windows = %d; expected %d", c.Limit(), autoTuneStart+3)
+	}
+}
+
+func TestConcurrencyLimiterBacksOff(t *testing.T) {
+	c := newConcurrencyLimiter(10, true)
+	c.limit = 8
+	var adjustments [][2]int
+	c.onAdjust = func(from, to int) { adjustments = append(adjustments, [2]int{from, to}) }
+
+	for i := 0; i < autoTuneWindow; i++ {
+		c.acquire()
+		if i == 0 {
+			c.release("whois query failed: rate limit exceeded")
+		} else {
+			c.release("")
+		}
+	}
+	if c.Limit() != 4 {
+		t.Errorf("Limit after throttling = %d; expected 4", c.Limit())
+	}
+	if len(adjustments) != 1 || adjustments[0] != [2]int{8, 4} {
+		t.Errorf("Unexpected adjustments: %v", adjustments)
+	}
+}
+
+func TestConcurrencyLimiterIgnoresUnregistered(t *testing.T) {
+	c := newConcurrencyLimiter(10, true)
+	unregistered := DomainInfo{Domain: "example.de", Error: "whois parsing failed: domain is not found", ErrorCode: ErrNXDomain}
+	for i := 0; i < autoTuneWindow*3; i++ {
+		c.acquire()
+		c.release(unregistered.transientError())
+	}
+	if c.Limit() != autoTuneStart+3 {
+		t.Errorf("Limit after three windows of unregistered domains = %d; expected %d", c.Limit(), autoTuneStart+3)
+	}
+
+	timeout := DomainInfo{Domain: "example.ru", Error: "i/o timeout", ErrorCode: ErrTimeout}
+	if timeout.transientError() == "" {
+		t.Error("Expected a timeout to count against auto-tuning")
+	}
+}
+
+func TestConcurrencyLimiterNeverBelowOne(t *testing.T) {
+	c := newConcurrencyLimiter(10, true)
+	for i := 0; i < autoTuneWindow*5; i++ {
+		c.acquire()
+		c.release("timeout")
+	}
+	if c.Limit() != 1 {
+		t.Errorf("Limit = %d; expected floor of 1", c.Limit())
+	}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	if !isRateLimitError("whoisparser: domain query limit exceeded") {
+		t.Error("Expected limit exceeded to be a rate-limit error")
+	}
+	if isRateLimitError("whois parsing failed: domain is not found") {
+		t.Error("Not-found should not be a rate-limit error")
+	}
+}
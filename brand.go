@@ -0,0 +1,321 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// BrandProfile configures a `brand` sweep
+type BrandProfile struct {
+	Name         string   `yaml:"name"`
+	Domain       string   `yaml:"domain"`
+	OwnedDomains []string `yaml:"owned_domains"`
+	Keywords     []string `yaml:"keywords"`
+	Affixes      []string `yaml:"affixes"`
+	Wordlist     string   `yaml:"wordlist"`
+	TLDs         []string `yaml:"tlds"`
+	Techniques   []string `yaml:"techniques"`
+	Screenshots  bool     `yaml:"screenshots"`
+	Report       string   `yaml:"report"`
+}
+
+// brandTechniques are the candidate techniques, all enabled by default
+var brandTechniques = []string{TechniqueTLD, TechniquePermutation, TechniqueHomoglyph}
+
+// loadBrandProfile reads and validates a brand profile, filling defaults
+func loadBrandProfile(path string) (*BrandProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read brand profile: %w", err)
+	}
+	profile := &BrandProfile{}
+	if err := yaml.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse brand profile %s: %w", path, err)
+	}
+
+	profile.Domain = strings.ToLower(strings.TrimSpace(profile.Domain))
+	if !strings.Contains(profile.Domain, ".") {
+		return nil, fmt.Errorf("brand profile %s: domain is required", path)
+	}
+	for i, domain := range profile.OwnedDomains {
+		profile.OwnedDomains[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+	if profile.Name == "" {
+		profile.Name = profile.Domain
+	}
+	if len(profile.Keywords) == 0 {
+		profile.Keywords = []string{extractBaseDomain(profile.Domain)}
+	}
+	if len(profile.Techniques) == 0 {
+		profile.Techniques = brandTechniques
+	}
+	for _, technique := range profile.Techniques {
+		if !containsString(brandTechniques, technique) {
+			return nil, fmt.Errorf("brand profile %s: unknown technique %q (valid: %s)", path, technique, strings.Join(brandTechniques, ", "))
+		}
+	}
+	if len(profile.TLDs) == 0 {
+		// The target's own suffix and .com, where most lookalikes land
+		profile.TLDs = []string{strings.TrimPrefix(profile.Domain, extractBaseDomain(profile.Domain)), ".com"}
+	}
+	for i, tld := range profile.TLDs {
+		normalized, ok := normalizeTLD(tld)
+		if !ok {
+			return nil, fmt.Errorf("brand profile %s: invalid TLD %q", path, tld)
+		}
+		profile.TLDs[i] = normalized
+	}
+	return profile, nil
+}
+
+// brandCandidates streams the lookalike domains of the profile's domain,
+// leaving out the brand and owned domains, and returns the technique
+// producing each. The variants are combined with the TLDs as they are
+// scanned, so large affix lists and TLD sets are not held in memory.
+func brandCandidates(profile *BrandProfile, config Config) (candidateSeq, func(domain string) string, error) {
+	name := extractBaseDomain(profile.Domain)
+	var variants []variant
+	if containsString(profile.Techniques, TechniquePermutation) {
+		variants = append(variants, permutations(name, profile.Affixes)...)
+	}
+	if containsString(profile.Techniques, TechniqueHomoglyph) {
+		variants = append(variants, homoglyphs(name)...)
+	}
+	// Variants are kept as punycode labels, the first technique producing
+	// a label winning
+	labels := make(map[string]string)
+	var names []string
+	for _, v := range variants {
+		domain, ok := normalizeDomain(v.Name + ".com")
+		label := strings.TrimSuffix(domain, ".com")
+		if _, seen := labels[label]; !ok || seen {
+			continue
+		}
+		labels[label] = v.Technique
+		names = append(names, label)
+	}
+	variantOf := func(domain string) (string, bool) {
+		label, tld, _ := strings.Cut(domain, ".")
+		technique, ok := labels[label]
+		return technique, ok && containsString(profile.TLDs, "."+tld)
+	}
+
+	var seqs []candidateSeq
+	if containsString(profile.Techniques, TechniqueTLD) {
+		tldDomains, err := candidateDomains(config)
+		if err != nil {
+			return candidateSeq{}, nil, err
+		}
+		// Variants listed in -domains-file are scanned once, as variants
+		seqs = append(seqs, tldDomains.without(func(domain string) bool {
+			_, variant := variantOf(domain)
+			return variant
+		}))
+	}
+	seqs = append(seqs, productCandidates(names, profile.TLDs))
+	candidates := concatCandidates(seqs...).without(func(domain string) bool {
+		return domain == profile.Domain || containsString(profile.OwnedDomains, domain)
+	})
+
+	technique := func(domain string) string {
+		if technique, ok := variantOf(domain); ok {
+			return technique
+		}
+		if containsString(profile.Techniques, TechniqueTLD) {
+			return TechniqueTLD
+		}
+		return ""
+	}
+	return candidates, technique, nil
+}
+
+// reportFormat picks the output format of a profile report from its
+// extension
+func reportFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(strings.TrimSuffix(path, ".gz"))) {
+	case ".json":
+		return "json"
+	case ".csv":
+		return "csv"
+	case ".html", ".htm":
+		return "html"
+	}
+	return "text"
+}
+
+// runBrand implements `tldscanner brand -profile <file>`: TLD variants,
+// typo permutations and homoglyphs of the brand's domain are pre-checked in
+// DNS, looked up over WHOIS/RDAP, probed over HTTP and reported by risk
+func runBrand(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("brand", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	profilePath := fs.String("profile", "", "Brand profile YAML file (required)")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s brand -profile <file> [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Runs the full brand-protection sweep configured by a YAML profile: TLD\n")
+		fmt.Printf("variants, typo permutations and homoglyphs, a DNS pre-check, WHOIS/RDAP,\n")
+		fmt.Printf("an HTTP probe with optional urlscan.io screenshots, and a report ranked by\n")
+		fmt.Printf("risk score. Scan options are the same as for a regular scan; -d is taken\n")
+		fmt.Printf("from the profile.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	applyImpliedFlags(&config)
+
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+
+	if *profilePath == "" {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s -profile is required. Use -h for help.\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	profile, err := loadBrandProfile(*profilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	config.Domain = profile.Domain
+	if profile.Wordlist != "" {
+		config.Wordlist = profile.Wordlist
+	}
+	if profile.Report != "" && config.Output == "" && config.OutputAll == "" {
+		config.Output = profile.Report
+		config.Format = reportFormat(profile.Report)
+	}
+	if config.Monitor {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s brand sweeps do not support -monitor\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	// Screenshots are taken for live lookalikes only, not for every match
+	profile.Screenshots = profile.Screenshots || config.URLScan
+	config.URLScan = profile.Screenshots
+	if err := resolveCredentials(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := startDiagnostics(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	config, err = withOutputRun(config, profile.Domain, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if config.pipedOutput() {
+		os.Stdout = os.Stderr
+	}
+	printBanner()
+
+	timing := newScanTiming()
+	startScan(&config, timing)
+	scanConfig := config
+	scanConfig.URLScan = false
+	targetInfo, err := lookupTarget(&scanConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	timing.stage("target_lookup")
+
+	candidates, technique, err := brandCandidates(profile, scanConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	timing.stage("candidates")
+	fmt.Printf("%s[INFO]%s Generated %d lookalike candidates of %s, prechecking them in DNS with %d threads and looking them up with %d threads...\n",
+		ColorBlue, ColorReset, candidates.total, profile.Domain, config.DNSThreads, config.Threads)
+
+	cp, err := openCheckpoint(scanConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	defer cp.Close()
+
+	result := Result{
+		SchemaVersion:  SchemaVersion,
+		Scanner:        currentBuildInfo(),
+		Brand:          profile.Name,
+		TargetDomain:   profile.Domain,
+		TargetOrg:      targetInfo.Organization,
+		TargetDNSSEC:   targetDNSSEC(config),
+		TargetExpiry:   targetInfo.ExpiryDate,
+		TargetUnlocked: targetTransferUnlocked(targetInfo),
+	}
+	scanConfig.OnFlush = partialFlusher(result, config)
+
+	// Only the candidates that exist in DNS are looked up over WHOIS
+	scanConfig.DNSPrecheck = true
+	allResults, matchingResults, signalResults, skipped := scanCandidates(candidates, targetInfo, cp, scanConfig)
+	scanDuration := timing.stage("lookups")
+
+	lookalikes := lookalikesOf(allResults, matchingResults)
+	for i := range lookalikes {
+		lookalikes[i].Technique = technique(lookalikes[i].Domain)
+	}
+	fmt.Printf("%s[INFO]%s Scoring %d registered lookalikes...\n", ColorBlue, ColorReset, len(lookalikes))
+	scoreLookalikes(lookalikes, targetInfo, profile.Keywords, profile.Screenshots, config)
+	timing.stage("risk_scoring")
+
+	result.MatchingDomains = matchingResults
+	result.SignalDomains = signalResults
+	result.Lookalikes = lookalikes
+	result.SkippedDomains = skipped.domains
+	result.Truncated = skipped.truncated
+	result.Aborted = skipped.aborted
+	result.ScanDuration = scanDuration.String()
+	result.TotalScanned = len(allResults)
+	result.TotalMatches = len(matchingResults)
+	result.TotalSignals = len(signalResults)
+	result.TotalLookalikes = len(lookalikes)
+	result.TotalSkipped = len(skipped.domains)
+	summarizeErrors(&result, allResults)
+	summarizeQuality(&result, allResults)
+	result.SuppressedDomains = ignoredDomains(allResults)
+	if config.SuggestRegister {
+		result.Registrations = suggestRegistrations(profile.Domain, unregisteredDomains(allResults, skipped), technique)
+	}
+	if config.SaveAll || config.Format == "list-all" {
+		result.AllDomains = allResults
+	}
+	finishResult(&result, allResults, targetInfo, timing, config)
+
+	outputStarted := time.Now()
+	writeOutput(result, config)
+	linkLatestRun(config)
+	exportTelemetry(result, outputStarted)
+	printSummary(result)
+
+	if code := exitCode(result, config.ErrorThreshold); code == ExitHighErrors {
+		return code
+	}
+	if result.TotalLookalikes == 0 {
+		return ExitNoMatches
+	}
+	return ExitMatches
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeBrandProfile(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "brand.yaml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadBrandProfile(t *testing.T) {
+	profile, err := loadBrandProfile(writeBrandProfile(t, "domain: Acme.co.uk\nowned_domains: [ACME.com]\n"))
+	if err != nil {
+		t.Fatalf("loadBrandProfile failed: %v", err)
+	}
+	if profile.Name != "acme.co.uk" || profile.Keywords[0] != "acme" {
+		t.Errorf("Unexpected defaults %+v", profile)
+	}
+	if len(profile.TLDs) != 2 || profile.TLDs[0] != ".co.uk" || profile.TLDs[1] != ".com" {
+		t.Errorf("Unexpected default TLDs %v", profile.TLDs)
+	}
+	if profile.OwnedDomains[0] != "acme.com" {
+		t.Errorf("Owned domains not normalized: %v", profile.OwnedDomains)
+	}
+
+	if _, err := loadBrandProfile(writeBrandProfile(t, "domain: acme.com\ntechniques: [bitsquat]\n")); err == nil {
+		t.Error("Expected an error for an unknown technique")
+	}
+	if _, err := loadBrandProfile(writeBrandProfile(t, "name: Acme\n")); err == nil {
+		t.Error("Expected an error for a missing domain")
+	}
+}
+
+func TestBrandCandidates(t *testing.T) {
+	profile := &BrandProfile{
+		Domain:       "acme.com",
+		OwnedDomains: []string{"acme-login.com"},
+		Affixes:      []string{"login"},
+		TLDs:         []string{".com"},
+		Techniques:   []string{TechniquePermutation, TechniqueHomoglyph},
+	}
+	candidates, technique, err := brandCandidates(profile, Config{})
+	if err != nil {
+		t.Fatalf("brandCandidates failed: %v", err)
+	}
+	domains := candidates.slice()
+	if len(domains) != candidates.total {
+		t.Errorf("Expected a total of %d candidates, got %d", len(domains), candidates.total)
+	}
+	seen := make(map[string]bool)
+	for _, domain := range domains {
+		if seen[domain] {
+			t.Errorf("Duplicate candidate %s", domain)
+		}
+		seen[domain] = true
+		if technique(domain) == "" {
+			t.Errorf("Every candidate needs a technique, %s has none", domain)
+		}
+	}
+	if seen["acme.com"] {
+		t.Error("The brand domain must not be a candidate")
+	}
+	if seen["acme-login.com"] {
+		t.Error("Owned domains must not be candidates")
+	}
+	if !seen["acm.com"] || technique("acm.com") != TechniquePermutation {
+		t.Errorf("Expected acm.com as a permutation, got %q", technique("acm.com"))
+	}
+	if !seen["xn--cme-5cd.com"] || technique("xn--cme-5cd.com") != TechniqueHomoglyph {
+		t.Errorf("Expected the Cyrillic a homoglyph as punycode, got %q", technique("xn--cme-5cd.com"))
+	}
+}
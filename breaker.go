@@ -0,0 +1,85 @@
+package main
+
+import "fmt"
+
+// errorBreakerWindow is the number of latest lookups -max-error-rate is
+// measured over. The rate is not checked before that many have completed.
+const errorBreakerWindow = 50
+
+// breakerError cancels the dispatch of lookups once the error breaker
+// trips; it is the cause of the scan context
+type breakerError struct {
+	reason string
+}
+
+func (e *breakerError) Error() string {
+	return "circuit breaker tripped: " + e.reason
+}
+
+// errorBreaker stops a scan whose lookups keep failing, as they do when the
+// egress IP is banned or the network is down, instead of spending the rest
+// of the wordlist on failures. It trips on the failed lookups of the last
+// errorBreakerWindow (-max-error-rate) or of the whole scan (-max-errors).
+// Unregistered domains are not failures. A nil breaker never trips.
+// Callers serialize calls.
+type errorBreaker struct {
+	maxRate   float64
+	maxErrors int
+
+	errors int
+	window [errorBreakerWindow]bool
+	next   int
+	filled int
+	failed int
+}
+
+// newErrorBreaker returns a breaker when -max-error-rate or -max-errors is
+// set
+func newErrorBreaker(maxRate float64, maxErrors int) *errorBreaker {
+	if maxRate <= 0 && maxErrors <= 0 {
+		return nil
+	}
+	return &errorBreaker{maxRate: maxRate, maxErrors: maxErrors}
+}
+
+// record adds the outcome of a lookup and returns why the breaker trips,
+// or "" while it holds
+func (b *errorBreaker) record(info DomainInfo) string {
+	if b == nil {
+		return ""
+	}
+	failed := info.Error != "" && info.errorCode() != ErrNXDomain
+	if failed {
+		b.errors++
+	}
+	if b.window[b.next] {
+		b.failed--
+	}
+	b.window[b.next] = failed
+	if failed {
+		b.failed++
+	}
+	b.next = (b.next + 1) % errorBreakerWindow
+	if b.filled < errorBreakerWindow {
+		b.filled++
+	}
+
+	if b.maxErrors > 0 && b.errors >= b.maxErrors {
+		return fmt.Sprintf("%d failed lookups reached -max-errors %d", b.errors, b.maxErrors)
+	}
+	if rate := float64(b.failed) / float64(b.filled); b.maxRate > 0 && b.filled == errorBreakerWindow && rate > b.maxRate {
+		return fmt.Sprintf("%.0f%% of the last %d lookups failed, above -max-error-rate %g", rate*100, errorBreakerWindow, b.maxRate)
+	}
+	return ""
+}
+
+// validateErrorBreaker checks -max-error-rate and -max-errors
+func validateErrorBreaker(config Config) error {
+	if config.MaxErrorRate < 0 || config.MaxErrorRate >= 1 {
+		return fmt.Errorf("-max-error-rate must be between 0 and 1 (exclusive), got %g", config.MaxErrorRate)
+	}
+	if config.MaxErrors < 0 {
+		return fmt.Errorf("-max-errors must not be negative")
+	}
+	return nil
+}
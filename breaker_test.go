@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorBreaker(t *testing.T) {
+	if newErrorBreaker(0, 0) != nil {
+		t.Fatal("Expected no breaker without -max-error-rate or -max-errors")
+	}
+
+	failed := DomainInfo{Domain: "example.de", Error: "dial tcp: connection refused", ErrorCode: ErrNetwork}
+	unregistered := DomainInfo{Domain: "example.fr", Error: "domain is not found", ErrorCode: ErrNXDomain}
+	ok := DomainInfo{Domain: "example.it"}
+
+	breaker := newErrorBreaker(0.5, 0)
+	for i := 0; i < errorBreakerWindow-1; i++ {
+		if reason := breaker.record(failed); reason != "" {
+			t.Fatalf("Tripped before the window filled: %s", reason)
+		}
+	}
+	if reason := breaker.record(failed); !strings.Contains(reason, "-max-error-rate 0.5") {
+		t.Errorf("Expected the full window of failures to trip, got %q", reason)
+	}
+
+	// Unregistered domains are no failures, and old failures leave the window
+	breaker = newErrorBreaker(0.5, 0)
+	for i := 0; i < errorBreakerWindow; i++ {
+		breaker.record(failed)
+	}
+	for i := 0; i < errorBreakerWindow/2; i++ {
+		breaker.record(unregistered)
+		breaker.record(ok)
+	}
+	if reason := breaker.record(ok); reason != "" {
+		t.Errorf("Expected the breaker to hold once failures left the window, got %q", reason)
+	}
+
+	breaker = newErrorBreaker(0, 2)
+	breaker.record(failed)
+	if reason := breaker.record(failed); !strings.Contains(reason, "-max-errors 2") {
+		t.Errorf("Expected -max-errors to trip, got %q", reason)
+	}
+}
+
+func TestScanDomainsErrorBreaker(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	var domains []string
+	for i := 0; i < 20; i++ {
+		domains = append(domains, fmt.Sprintf("example%d.invalid", i))
+	}
+	config := Config{Threads: 1, Format: "json", WhoisClient: mock, MaxErrors: 3}
+	target := &DomainInfo{Domain: "example.com", Organization: "Example Corp"}
+
+	all, _, _, skipped := scanDomains(domains, target, config)
+	if skipped.aborted == "" || skipped.truncated {
+		t.Fatalf("Expected the scan to be aborted, got %+v", skipped)
+	}
+	if len(all) < 3 || len(all) == len(domains) || len(all)+len(skipped.domains) != len(domains) {
+		t.Errorf("Expected the undispatched domains to be skipped, got %d scanned and %d skipped", len(all), len(skipped.domains))
+	}
+	if code := exitCode(Result{Aborted: skipped.aborted, TotalMatches: 1}, 0.5); code != ExitHighErrors {
+		t.Errorf("exitCode = %d, want %d", code, ExitHighErrors)
+	}
+}
+
+func TestValidateErrorBreaker(t *testing.T) {
+	for _, config := range []Config{{MaxErrorRate: 1}, {MaxErrorRate: -0.1}, {MaxErrors: -1}} {
+		if err := validateErrorBreaker(config); err == nil {
+			t.Errorf("Expected %+v to be rejected", config)
+		}
+	}
+	if err := validateErrorBreaker(Config{MaxErrorRate: 0.5, MaxErrors: 100}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// scanSkips are the domains a scan did not look up
+type scanSkips struct {
+	domains []string
+	// truncated is set when -max-runtime ran out before every domain was
+	// dispatched
+	truncated bool
+	// aborted is why the error breaker stopped the scan, if it did
+	aborted string
+	// nxdomains are the domains the DNS precheck found unregistered, kept
+	// for -suggest-registrations
+	nxdomains []string
+}
+
+// errQueryBudget is returned by lookups that would send a query past the
+// -max-queries or -max-queries-per-server budget
+var errQueryBudget = errors.New("query budget exhausted")
+
+// queryBudget caps the number of queries a scan sends, overall and per
+// server, for registry terms of use and paid API quotas. Every query sent is
+// charged: WHOIS failover and referral follow-ups, RDAP fallbacks and races,
+// and IANA lookups. A registry's WHOIS and RDAP servers are told apart by
+// top-level label, the same way queryWhois picks them; registrar servers
+// reached by referral by their host name.
+type queryBudget struct {
+	max       int
+	perServer int
+
+	mu      sync.Mutex
+	used    int
+	servers map[string]int
+}
+
+// newQueryBudget creates a budget; a limit of zero is unlimited
+func newQueryBudget(max, perServer int) *queryBudget {
+	return &queryBudget{max: max, perServer: perServer, servers: make(map[string]int)}
+}
+
+// take spends one query to server and reports whether the budget allowed
+// it. A nil budget allows everything.
+func (b *queryBudget) take(server string) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhaustedLocked(server) {
+		return false
+	}
+	b.used++
+	b.servers[server]++
+	return true
+}
+
+// exhausted reports whether no further query to server is allowed
+func (b *queryBudget) exhausted(server string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhaustedLocked(server)
+}
+
+func (b *queryBudget) exhaustedLocked(server string) bool {
+	return (b.max > 0 && b.used >= b.max) || (b.perServer > 0 && b.servers[server] >= b.perServer)
+}
+
+// validateBudget checks the query and runtime budget options
+func validateBudget(config Config) error {
+	if config.MaxRuntime < 0 {
+		return fmt.Errorf("-max-runtime must not be negative")
+	}
+	if config.MaxQueries < 0 {
+		return fmt.Errorf("-max-queries must not be negative")
+	}
+	if config.MaxServerQueries < 0 {
+		return fmt.Errorf("-max-queries-per-server must not be negative")
+	}
+	return nil
+}
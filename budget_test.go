@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/likexian/whois"
+)
+
+func TestQueryBudget(t *testing.T) {
+	budget := newQueryBudget(4, 2)
+	servers := []string{"com", "net", "com", "com", "org", "io"}
+	expected := []bool{true, true, true, false, true, false}
+	for i, server := range servers {
+		if got := budget.take(server); got != expected[i] {
+			t.Errorf("take(%s) = %v; expected %v", server, got, expected[i])
+		}
+	}
+	if !budget.exhausted("io") {
+		t.Error("Expected the spent budget to be exhausted")
+	}
+
+	var unset *queryBudget
+	if !unset.take("com") || unset.exhausted("com") {
+		t.Error("Expected a nil budget to allow every query")
+	}
+
+	unlimited := newQueryBudget(0, 0)
+	for i := 0; i < 100; i++ {
+		if !unlimited.take("example.com") {
+			t.Fatal("Unlimited budget refused a lookup")
+		}
+	}
+}
+
+func TestScanDomainsMaxRuntime(t *testing.T) {
+	config := Config{Threads: 2, Format: "json", MaxRuntime: time.Nanosecond}
+	allResults, _, _, skipped := scanDomains([]string{"example.net", "example.com"}, &DomainInfo{Organization: "Example Inc"}, config)
+
+	if len(allResults) != 0 {
+		t.Errorf("Expected no lookups past the deadline, got %d", len(allResults))
+	}
+	if !skipped.truncated || !reflect.DeepEqual(skipped.domains, []string{"example.com", "example.net"}) {
+		t.Errorf("Unexpected skips %+v", skipped)
+	}
+}
+
+// whoisDialer sends every WHOIS connection to a local server answering
+// with a fixed response and records the servers dialed and the queries
+// they were sent
+type whoisDialer struct {
+	addr    string
+	mu      sync.Mutex
+	dialed  []string
+	queries []string
+}
+
+func (d *whoisDialer) Dial(network, addr string) (net.Conn, error) {
+	d.mu.Lock()
+	d.dialed = append(d.dialed, addr)
+	d.mu.Unlock()
+	return net.Dial(network, d.addr)
+}
+
+func fakeWhoisDialer(t *testing.T, response string) *whoisDialer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	d := &whoisDialer{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			query, _ := bufio.NewReader(conn).ReadString('\n')
+			d.mu.Lock()
+			d.queries = append(d.queries, strings.TrimSpace(query))
+			d.mu.Unlock()
+			io.WriteString(conn, response)
+			conn.Close()
+		}
+	}()
+	return d
+}
+
+func TestQueryWhoisChargesEveryQuery(t *testing.T) {
+	// The registry answer refers to a registrar server; both are queries
+	dialer := fakeWhoisDialer(t, "Domain Name: EXAMPLE.TEST\nRegistrar WHOIS Server: whois.registrar.test\n")
+	client := whois.NewClient().SetDialer(dialer).SetTimeout(2 * time.Second).SetDisableReferral(true)
+	ianaServers.Store("test", "whois.nic.test")
+	defer ianaServers.Delete("test")
+
+	budget := newQueryBudget(3, 0)
+	if _, server, err := queryWhois(client, "example.test", "example.test", budget, nil); err != nil || server != "whois.registrar.test" {
+		t.Fatalf("Expected the registrar answer, got %s: %v", server, err)
+	}
+	// One query is left: the registry answers, the referral is not followed
+	if _, server, err := queryWhois(client, "example2.test", "example2.test", budget, nil); err != nil || server != "whois.nic.test" {
+		t.Errorf("Expected the registry answer without the referral, got %s: %v", server, err)
+	}
+	if _, _, err := queryWhois(client, "example3.test", "example3.test", budget, nil); !errors.Is(err, errQueryBudget) {
+		t.Errorf("Expected no query past the budget, got %v", err)
+	}
+	if len(dialer.dialed) != 3 {
+		t.Errorf("Expected 3 queries sent, got %v", dialer.dialed)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsTypeCAA is the CAA record type, which dnsmessage has no name for
+const dnsTypeCAA dnsmessage.Type = 257
+
+// Certificate issuance risk levels, from the CAA records of a domain
+const (
+	// CertIssuanceHigh: any CA may issue certificates, as no CAA record
+	// restricts issuance
+	CertIssuanceHigh = "high"
+	// CertIssuanceLow: only the CAs named by CAA records may issue
+	CertIssuanceLow = "low"
+	// CertIssuanceNone: CAA records forbid all issuance
+	CertIssuanceNone = "none"
+)
+
+// CAARecord is one CAA record (RFC 8659)
+type CAARecord struct {
+	Flags uint8
+	Tag   string
+	Value string
+}
+
+// String renders the record in zone file form
+func (r CAARecord) String() string {
+	return fmt.Sprintf("%d %s %q", r.Flags, r.Tag, r.Value)
+}
+
+// parseCAA decodes the data of a CAA record: flags, tag length, tag, value
+func parseCAA(data []byte) (CAARecord, error) {
+	if len(data) < 2 || len(data) < 2+int(data[1]) {
+		return CAARecord{}, fmt.Errorf("truncated CAA record")
+	}
+	tagEnd := 2 + int(data[1])
+	return CAARecord{
+		Flags: data[0],
+		Tag:   strings.ToLower(string(data[2:tagEnd])),
+		Value: string(data[tagEnd:]),
+	}, nil
+}
+
+// lookupCAA fetches the CAA records of domain from -dnssec-resolver
+func lookupCAA(domain string, config Config) ([]CAARecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+
+	msg, err := dnsQuery(ctx, domain, dnsTypeCAA, config)
+	if err != nil {
+		return nil, err
+	}
+	if msg.RCode != dnsmessage.RCodeSuccess && msg.RCode != dnsmessage.RCodeNameError {
+		return nil, fmt.Errorf("CAA query for %s: %s", domain, msg.RCode)
+	}
+	var records []CAARecord
+	for _, answer := range msg.Answers {
+		body, ok := answer.Body.(*dnsmessage.UnknownResource)
+		if !ok || answer.Header.Type != dnsTypeCAA {
+			continue
+		}
+		record, err := parseCAA(body.Data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// certIssuanceRisk rates how freely CAs may issue certificates under the
+// given CAA records. An issue or issuewild value naming no CA (";")
+// forbids issuance; issuewild only governs wildcard certificates, so
+// without an issue record any CA may still issue for the domain itself.
+func certIssuanceRisk(records []CAARecord) string {
+	var issue, named bool
+	for _, record := range records {
+		if record.Tag != "issue" && record.Tag != "issuewild" {
+			continue
+		}
+		if record.Tag == "issue" {
+			issue = true
+		}
+		if ca := strings.TrimSpace(strings.SplitN(record.Value, ";", 2)[0]); ca != "" {
+			named = true
+		}
+	}
+	switch {
+	case !issue:
+		return CertIssuanceHigh
+	case named:
+		return CertIssuanceLow
+	}
+	return CertIssuanceNone
+}
+
+// enrichCAA records the CAA records and certificate issuance risk of a
+// domain
+func enrichCAA(info *DomainInfo, config Config) error {
+	records, err := lookupCAA(info.Domain, config)
+	if err != nil {
+		return err
+	}
+	info.CAA = nil
+	for _, record := range records {
+		info.CAA = append(info.CAA, record.String())
+	}
+	info.CertIssuanceRisk = certIssuanceRisk(records)
+	return nil
+}
+
+// certIssuance describes the certificate issuance risk for reports
+func (d DomainInfo) certIssuance() string {
+	if len(d.CAA) == 0 {
+		return d.CertIssuanceRisk + " (no CAA records)"
+	}
+	return fmt.Sprintf("%s (%s)", d.CertIssuanceRisk, strings.Join(d.CAA, ", "))
+}
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestParseCAA(t *testing.T) {
+	record, err := parseCAA(append([]byte{128, 5}, "IssueLetsencrypt.org"...))
+	if err != nil {
+		t.Fatalf("parseCAA failed: %v", err)
+	}
+	if record.Flags != 128 || record.Tag != "issue" || record.Value != "Letsencrypt.org" {
+		t.Errorf("Unexpected record %+v", record)
+	}
+	if got := record.String(); got != `128 issue "Letsencrypt.org"` {
+		t.Errorf("Expected zone file form, got %q", got)
+	}
+	if _, err := parseCAA([]byte{0, 9, 'i'}); err == nil {
+		t.Error("Expected an error for a truncated record")
+	}
+}
+
+func TestCertIssuanceRisk(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []CAARecord
+		want    string
+	}{
+		{"no records", nil, CertIssuanceHigh},
+		{"iodef only", []CAARecord{{Tag: "iodef", Value: "mailto:security@example.com"}}, CertIssuanceHigh},
+		{"issuewild only", []CAARecord{{Tag: "issuewild", Value: "letsencrypt.org"}}, CertIssuanceHigh},
+		{"named CA", []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}}, CertIssuanceLow},
+		{"named CA with parameters", []CAARecord{{Tag: "issue", Value: "digicert.com; cansignhttpexchanges=yes"}}, CertIssuanceLow},
+		{"forbidden", []CAARecord{{Tag: "issue", Value: ";"}}, CertIssuanceNone},
+		{"wildcards only", []CAARecord{{Tag: "issue", Value: ";"}, {Tag: "issuewild", Value: "sectigo.com"}}, CertIssuanceLow},
+	}
+	for _, tt := range tests {
+		if got := certIssuanceRisk(tt.records); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestEnrichCAA(t *testing.T) {
+	resolver := newFakeResolver(t, map[string]fakeZone{
+		"example.net.": {caa: []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}, {Tag: "iodef", Value: "mailto:security@example.net"}}},
+		"example.org.": {},
+	})
+	config := Config{Timeout: 5, CAA: true, DNSSECResolver: resolver}
+
+	info := &DomainInfo{Domain: "example.net"}
+	if err := enrichCAA(info, config); err != nil {
+		t.Fatalf("enrichCAA failed: %v", err)
+	}
+	if info.CertIssuanceRisk != CertIssuanceLow || len(info.CAA) != 2 || info.CAA[0] != `0 issue "letsencrypt.org"` {
+		t.Errorf("Unexpected CAA enrichment: %q %v", info.CertIssuanceRisk, info.CAA)
+	}
+
+	info = &DomainInfo{Domain: "example.org"}
+	if err := enrichCAA(info, config); err != nil {
+		t.Fatalf("enrichCAA failed: %v", err)
+	}
+	if info.CertIssuanceRisk != CertIssuanceHigh {
+		t.Errorf("Expected a domain without CAA to be %q, got %q", CertIssuanceHigh, info.CertIssuanceRisk)
+	}
+	if got := info.certIssuance(); got != "high (no CAA records)" {
+		t.Errorf("Unexpected report line %q", got)
+	}
+}
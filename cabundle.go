@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// caBundleEnvVar names a CA bundle to trust without -ca-bundle, which the
+// subcommands such as update and takedown do not take
+const caBundleEnvVar = "TLDSCANNER_CA_BUNDLE"
+
+// rootCAs are the certificate authorities trusted by TLS connections: the
+// system's and those of -ca-bundle. nil, the system's only, without one.
+var rootCAs *x509.CertPool
+
+// validateCABundle checks that the -ca-bundle file can be read
+func validateCABundle(path string) error {
+	if path == "" {
+		return nil
+	}
+	if err := checkReadable(path); err != nil {
+		return fmt.Errorf("-ca-bundle %s: %v", path, err)
+	}
+	return nil
+}
+
+// loadCABundle returns the system's certificate authorities with those of
+// a PEM bundle added
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s holds no PEM certificates", path)
+	}
+	return pool, nil
+}
+
+// trustCABundle trusts the certificate authorities of a PEM bundle, such as
+// a TLS-inspecting proxy's, for every HTTPS request (RDAP, certificate
+// transparency, enrichment APIs, webhooks and notifications) and the TLS
+// connections to the -cache, -syslog and -neo4j servers. HTTP requests go
+// through the HTTPS_PROXY, HTTP_PROXY and NO_PROXY proxies either way.
+func trustCABundle(path string) error {
+	if path == "" {
+		return nil
+	}
+	pool, err := loadCABundle(path)
+	if err != nil {
+		return err
+	}
+	rootCAs = pool
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.RootCAs = pool
+	http.DefaultTransport = transport
+	return nil
+}
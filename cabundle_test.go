@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrustCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	defaultTransport := http.DefaultTransport
+	defer func() {
+		http.DefaultTransport = defaultTransport
+		rootCAs = nil
+	}()
+
+	if _, err := http.Get(server.URL); err == nil {
+		t.Fatal("The test server's certificate should not be trusted before loading the bundle")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := trustCABundle(bundle); err != nil {
+		t.Fatalf("trustCABundle failed: %v", err)
+	}
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("The bundle's certificate should be trusted: %v", err)
+	}
+	resp.Body.Close()
+	if http.DefaultTransport.(*http.Transport).Proxy == nil {
+		t.Error("Requests should still go through the proxy of the environment")
+	}
+	if rootCAs == nil {
+		t.Error("The bundle should be trusted by the other TLS connections too")
+	}
+}
+
+func TestLoadCABundleErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0644)
+	if _, err := loadCABundle(notPEM); err == nil {
+		t.Error("Expected a bundle without certificates to be rejected")
+	}
+	if err := validateCABundle(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("Expected a missing bundle to be rejected")
+	}
+	if err := trustCABundle(""); err != nil {
+		t.Errorf("No bundle should be a no-op: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisCachePrefix namespaces the cache keys in a shared Redis database
+const redisCachePrefix = "tldscanner:lookup:"
+
+// lookupCache stores WHOIS/RDAP lookup outcomes by domain for -cache-ttl.
+// The memory cache is shared by the scans of one process, such as serve
+// mode scans or monitor mode rescans; the Redis cache by every instance
+// pointed at the same database.
+type lookupCache interface {
+	get(domain string) (cacheEntry, bool)
+	set(domain string, entry cacheEntry)
+}
+
+// cacheEntry is a cached lookup: the domain's record or the error of a
+// lookup that would fail the same way if repeated
+type cacheEntry struct {
+	Info      *DomainInfo `json:"info,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	ErrorCode ErrorCode   `json:"error_code,omitempty"`
+}
+
+// cachedError is a lookup error served from the cache, keeping the code of
+// the original error
+type cachedError struct {
+	msg  string
+	code ErrorCode
+}
+
+func (e *cachedError) Error() string { return e.msg }
+
+// cacheable reports whether a lookup outcome may be reused: records and
+// unregistered domains are, transient failures are retried instead
+func cacheable(err error) bool {
+	switch errorCodeOf(err) {
+	case "", ErrNXDomain, ErrNoWhoisServer:
+		return true
+	}
+	return false
+}
+
+// cachedLookup returns the cached outcome for domain, if any
+func cachedLookup(cache lookupCache, domain string) (cacheEntry, bool) {
+	if cache == nil {
+		return cacheEntry{}, false
+	}
+	return cache.get(domain)
+}
+
+// cacheResults wraps a lookup function so its reusable outcomes are stored
+func cacheResults(cache lookupCache, lookup func(string, Config) (*DomainInfo, error)) func(string, Config) (*DomainInfo, error) {
+	return func(domain string, config Config) (*DomainInfo, error) {
+		info, err := lookup(domain, config)
+		if cacheable(err) {
+			entry := cacheEntry{Info: info}
+			if err != nil {
+				entry = cacheEntry{Error: err.Error(), ErrorCode: errorCodeOf(err)}
+			}
+			cache.set(domain, entry)
+		}
+		return info, err
+	}
+}
+
+// lookup returns the cached outcome as a lookup function would. The record
+// is copied so callers annotating it do not change the cache.
+func (e cacheEntry) lookup() (*DomainInfo, error) {
+	if e.Info == nil {
+		return nil, &cachedError{msg: e.Error, code: e.ErrorCode}
+	}
+	info := *e.Info
+	return &info, nil
+}
+
+// memoryCache is a lookupCache in process memory
+type memoryCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	entry   cacheEntry
+	expires time.Time
+}
+
+func newMemoryCache(ttl time.Duration) *memoryCache {
+	return &memoryCache{ttl: ttl, entries: make(map[string]memoryCacheEntry)}
+}
+
+func (c *memoryCache) get(domain string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.entries[domain]
+	if !ok || time.Now().After(cached.expires) {
+		delete(c.entries, domain)
+		return cacheEntry{}, false
+	}
+	return cached.entry, true
+}
+
+func (c *memoryCache) set(domain string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[domain] = memoryCacheEntry{entry: entry, expires: time.Now().Add(c.ttl)}
+}
+
+// redisCache is a lookupCache in Redis, shared by clustered instances.
+// Redis failures count as cache misses so scans carry on without the cache.
+type redisCache struct {
+	client *redisClient
+	ttl    time.Duration
+	warn   sync.Once
+}
+
+func (c *redisCache) get(domain string) (cacheEntry, bool) {
+	reply, err := c.client.do("GET", redisCachePrefix+domain)
+	if err != nil {
+		if err != errRedisNil {
+			c.warnOnce(err)
+		}
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if s, ok := reply.(string); !ok || json.Unmarshal([]byte(s), &entry) != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *redisCache) set(domain string, entry cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	ttl := strconv.FormatInt(c.ttl.Milliseconds(), 10)
+	if _, err := c.client.do("SET", redisCachePrefix+domain, string(data), "PX", ttl); err != nil {
+		c.warnOnce(err)
+	}
+}
+
+// warnOnce reports the first Redis failure of the process
+func (c *redisCache) warnOnce(err error) {
+	c.warn.Do(func() {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Lookup cache unavailable, querying directly: %v\n", ColorYellow, ColorReset, err)
+	})
+}
+
+// openLookupCache opens the -cache backend: "memory" or a redis:// URL. A
+// Redis server must answer a PING so a wrong URL fails before scanning.
+func openLookupCache(spec string, ttl, timeout time.Duration) (lookupCache, error) {
+	switch {
+	case spec == "":
+		return nil, nil
+	case spec == "memory":
+		return newMemoryCache(ttl), nil
+	}
+	client, err := newRedisClient(spec, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.do("PING"); err != nil {
+		return nil, fmt.Errorf("lookup cache: %w", err)
+	}
+	return &redisCache{client: client, ttl: ttl}, nil
+}
+
+// checkCache pings a Redis lookup cache for the readiness probe
+func checkCache(cache lookupCache) error {
+	redis, ok := cache.(*redisCache)
+	if !ok {
+		return nil
+	}
+	if _, err := redis.client.do("PING"); err != nil {
+		return fmt.Errorf("lookup cache: %w", err)
+	}
+	return nil
+}
+
+// validateCache checks the -cache and -cache-ttl options
+func validateCache(config Config) error {
+	if config.Cache == "" {
+		return nil
+	}
+	if config.CacheTTL <= 0 {
+		return fmt.Errorf("-cache-ttl must be positive")
+	}
+	if config.Cache != "memory" && !strings.HasPrefix(config.Cache, "redis://") && !strings.HasPrefix(config.Cache, "rediss://") {
+		return fmt.Errorf("invalid -cache %q: expected memory or a redis:// URL", config.Cache)
+	}
+	if config.Cache != "memory" {
+		_, err := newRedisClient(config.Cache, time.Second)
+		return err
+	}
+	return nil
+}
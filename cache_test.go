@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	whoisparser "github.com/likexian/whois-parser"
+)
+
+// fakeRedis answers PING, GET, SET (with NX) and PTTL from a map
+func fakeRedis(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var mu sync.Mutex
+	data := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+					args := make([]string, n)
+					for i := range args {
+						r.ReadString('\n')
+						arg, _ := r.ReadString('\n')
+						args[i] = strings.TrimSuffix(arg, "\r\n")
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "PING":
+						fmt.Fprint(conn, "+PONG\r\n")
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+						} else {
+							fmt.Fprint(conn, "$-1\r\n")
+						}
+					case "SET":
+						if _, ok := data[args[1]]; ok && args[len(args)-1] == "NX" {
+							fmt.Fprint(conn, "$-1\r\n")
+						} else {
+							data[args[1]] = args[2]
+							fmt.Fprint(conn, "+OK\r\n")
+						}
+					case "PTTL":
+						fmt.Fprint(conn, ":5\r\n")
+					default:
+						fmt.Fprint(conn, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCacheResults(t *testing.T) {
+	cache := newMemoryCache(time.Hour)
+	lookup := cacheResults(cache, func(domain string, config Config) (*DomainInfo, error) {
+		switch domain {
+		case "example.io":
+			return &DomainInfo{Domain: domain, Organization: "Example Inc"}, nil
+		case "example.de":
+			return nil, fmt.Errorf("whois parsing failed: %w", whoisparser.ErrNotFoundDomain)
+		}
+		return nil, errors.New("i/o timeout")
+	})
+	for _, domain := range []string{"example.io", "example.de", "example.fr"} {
+		lookup(domain, Config{})
+	}
+
+	entry, ok := cachedLookup(cache, "example.io")
+	info, err := entry.lookup()
+	if !ok || err != nil || info.Organization != "Example Inc" {
+		t.Errorf("Expected the record to be cached, got %+v, %v", info, err)
+	}
+	info.Organization = "changed"
+	if entry, _ := cachedLookup(cache, "example.io"); entry.Info.Organization != "Example Inc" {
+		t.Error("Expected callers not to change the cached record")
+	}
+
+	entry, ok = cachedLookup(cache, "example.de")
+	if _, err := entry.lookup(); !ok || errorCodeOf(err) != ErrNXDomain {
+		t.Errorf("Expected the unregistered domain to be cached as nxdomain, got %v", err)
+	}
+	if _, ok := cachedLookup(cache, "example.fr"); ok {
+		t.Error("Expected a timeout not to be cached")
+	}
+	if _, ok := cachedLookup(nil, "example.io"); ok {
+		t.Error("Expected no hits without a cache")
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	cache := newMemoryCache(time.Millisecond)
+	cache.set("example.io", cacheEntry{Info: &DomainInfo{Domain: "example.io"}})
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.get("example.io"); ok {
+		t.Error("Expected the entry to expire")
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	cache, err := openLookupCache("redis://"+fakeRedis(t)+"/0", time.Hour, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.set("example.io", cacheEntry{Info: &DomainInfo{Domain: "example.io", Organization: "Example Inc"}})
+	entry, ok := cache.get("example.io")
+	if !ok || entry.Info.Organization != "Example Inc" {
+		t.Errorf("Expected the record back from Redis, got %+v", entry)
+	}
+	if _, ok := cache.get("example.de"); ok {
+		t.Error("Expected a miss for an unknown domain")
+	}
+	if err := checkCache(cache); err != nil {
+		t.Errorf("Expected the readiness check to pass, got %v", err)
+	}
+
+	if _, err := openLookupCache("redis://127.0.0.1:1", time.Hour, time.Second); err == nil {
+		t.Error("Expected an unreachable Redis to fail")
+	}
+}
+
+func TestSharedRateLimit(t *testing.T) {
+	client, err := newRedisClient("redis://"+fakeRedis(t), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := newRateLimiter(1000, 1, "global")
+	limiter.shared = client
+	if _, err := client.do("SET", "tldscanner:rate:global", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The slot is held by another instance and never expires here
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limiter.waitShared(ctx, "example.io"); err == nil {
+		t.Error("Expected to wait for the slot held elsewhere")
+	}
+}
+
+func TestNewRedisClient(t *testing.T) {
+	testCases := []struct {
+		url      string
+		addr     string
+		password string
+		db       int
+		ok       bool
+	}{
+		{"redis://cache:6380/2", "cache:6380", "", 2, true},
+		{"redis://:secret@cache", "cache:6379", "secret", 0, true},
+		{"redis://secret@cache", "cache:6379", "secret", 0, true},
+		{"rediss://cache", "cache:6379", "", 0, true},
+		{"http://cache", "", "", 0, false},
+		{"redis://cache/db", "", "", 0, false},
+	}
+	for _, tc := range testCases {
+		c, err := newRedisClient(tc.url, time.Second)
+		if (err == nil) != tc.ok {
+			t.Errorf("newRedisClient(%q) error = %v", tc.url, err)
+			continue
+		}
+		if err == nil && (c.addr != tc.addr || c.password != tc.password || c.db != tc.db) {
+			t.Errorf("newRedisClient(%q) = %+v", tc.url, c)
+		}
+	}
+
+	if err := validateCache(Config{Cache: "memcached://x", CacheTTL: time.Hour}); err == nil {
+		t.Error("Expected an unknown cache backend to be rejected")
+	}
+	if err := validateCache(Config{Cache: "memory"}); err == nil {
+		t.Error("Expected a zero -cache-ttl to be rejected")
+	}
+}
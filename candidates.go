@@ -0,0 +1,92 @@
+package main
+
+// candidateSeq streams the domains of a scan to the pipeline one at a time,
+// so that name permutations under hundreds of TLDs never sit in memory as a
+// single list. Total is the number of domains it yields, for the progress
+// indicator.
+type candidateSeq struct {
+	total int
+	each  func(yield func(domain string) bool)
+	// metadata are the columns of a CSV domain list, by domain
+	metadata map[string]Metadata
+}
+
+// sliceCandidates streams the domains of a list
+func sliceCandidates(domains []string) candidateSeq {
+	return candidateSeq{
+		total: len(domains),
+		each: func(yield func(string) bool) {
+			for _, domain := range domains {
+				if !yield(domain) {
+					return
+				}
+			}
+		},
+	}
+}
+
+// productCandidates streams every name under every TLD, name by name
+func productCandidates(names, tlds []string) candidateSeq {
+	return candidateSeq{
+		total: len(names) * len(tlds),
+		each: func(yield func(string) bool) {
+			for _, name := range names {
+				for _, tld := range tlds {
+					if !yield(name + tld) {
+						return
+					}
+				}
+			}
+		},
+	}
+}
+
+// concatCandidates streams several sequences one after the other
+func concatCandidates(seqs ...candidateSeq) candidateSeq {
+	var total int
+	for _, seq := range seqs {
+		total += seq.total
+	}
+	return candidateSeq{
+		total: total,
+		each: func(yield func(string) bool) {
+			stopped := false
+			for _, seq := range seqs {
+				seq.each(func(domain string) bool {
+					stopped = !yield(domain)
+					return !stopped
+				})
+				if stopped {
+					return
+				}
+			}
+		},
+	}
+}
+
+// without streams the domains of s that exclude does not match. The
+// domains are counted in a first pass so the total stays exact.
+func (s candidateSeq) without(exclude func(domain string) bool) candidateSeq {
+	filtered := candidateSeq{
+		each: func(yield func(string) bool) {
+			s.each(func(domain string) bool {
+				return exclude(domain) || yield(domain)
+			})
+		},
+	}
+	filtered.each(func(string) bool {
+		filtered.total++
+		return true
+	})
+	return filtered
+}
+
+// slice collects the domains of s
+func (s candidateSeq) slice() []string {
+	domains := make([]string, 0, s.total)
+	s.each(func(domain string) bool {
+		domains = append(domains, domain)
+		return true
+	})
+	return domains
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCandidateSeq(t *testing.T) {
+	seq := concatCandidates(
+		sliceCandidates([]string{"example.io"}),
+		productCandidates([]string{"examp1e", "exarnple"}, []string{".com", ".net"}),
+	).without(func(domain string) bool { return strings.HasSuffix(domain, ".net") })
+
+	expected := []string{"example.io", "examp1e.com", "exarnple.com"}
+	if got := seq.slice(); !reflect.DeepEqual(got, expected) || seq.total != 3 {
+		t.Errorf("Expected %v (3), got %v (%d)", expected, got, seq.total)
+	}
+
+	var first []string
+	seq.each(func(domain string) bool {
+		first = append(first, domain)
+		return len(first) < 2
+	})
+	if len(first) != 2 {
+		t.Errorf("Expected the stream to stop after 2 domains, got %v", first)
+	}
+}
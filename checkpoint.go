@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// checkpointLine is one JSON line of a checkpoint file: the header naming
+// the scan, a finished domain with its position in the candidate stream,
+// or the marker of a completed chunk
+type checkpointLine struct {
+	Target    string      `json:"target,omitempty"`
+	ChunkSize int         `json:"chunk_size,omitempty"`
+	Chunk     *int        `json:"chunk,omitempty"`
+	Index     int         `json:"index,omitempty"`
+	Matched   bool        `json:"matched,omitempty"`
+	Info      *DomainInfo `json:"info,omitempty"`
+}
+
+// checkpoint records the finished domains of a scan so that an interrupted
+// scan resumes where it stopped. The candidates are numbered in stream
+// order and grouped in chunks of -chunk-size; a chunk is marked complete
+// once all its domains are done. On resume the domains of complete chunks
+// are restored from the file and the others are scanned again, so the
+// stream must come out in the same order (no -shuffle).
+type checkpoint struct {
+	mu        sync.Mutex
+	file      *os.File
+	chunkSize int
+	restored  map[int]checkpointLine // by index, in complete chunks
+	complete  map[int]bool
+	sent      map[int]int
+	finished  map[int]int
+	closed    map[int]bool
+	failed    map[int]bool
+}
+
+// openCheckpoint opens the -checkpoint file of a scan, restoring the
+// complete chunks of a previous run of the same scan; nil without one
+func openCheckpoint(config Config) (*checkpoint, error) {
+	if config.Checkpoint == "" {
+		return nil, nil
+	}
+	cp := &checkpoint{
+		chunkSize: config.ChunkSize,
+		restored:  make(map[int]checkpointLine),
+		complete:  make(map[int]bool),
+		sent:      make(map[int]int),
+		finished:  make(map[int]int),
+		closed:    make(map[int]bool),
+		failed:    make(map[int]bool),
+	}
+	if err := cp.load(config.Checkpoint, config.Domain); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(config.Checkpoint, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	cp.file = file
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		if err := cp.write(checkpointLine{Target: config.Domain, ChunkSize: cp.chunkSize}); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+	} else {
+		// Terminate a line cut short by a crash so the next one parses
+		if _, err := file.Write([]byte("\n")); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+	}
+	if len(cp.complete) > 0 {
+		fmt.Printf("%s[INFO]%s Resuming from %s: %d chunks (%d domains) already done\n",
+			ColorBlue, ColorReset, config.Checkpoint, len(cp.complete), len(cp.restored))
+	}
+	return cp, nil
+}
+
+// load reads the checkpoint of a previous run. Domains of incomplete chunks
+// are left out; a domain written twice keeps its last record.
+func (c *checkpoint) load(path, target string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	records := make(map[int]checkpointLine)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for first := true; scanner.Scan(); first = false {
+		var line checkpointLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			if first {
+				return fmt.Errorf("checkpoint %s is damaged; remove it to start over", path)
+			}
+			// A line cut short by a crash; its domain is scanned again
+			continue
+		}
+		switch {
+		case first:
+			if line.Target != target || line.ChunkSize != c.chunkSize {
+				return fmt.Errorf("checkpoint %s was written for %s with -chunk-size %d; remove it to start over", path, line.Target, line.ChunkSize)
+			}
+		case line.Chunk != nil:
+			c.complete[*line.Chunk] = true
+		case line.Info != nil:
+			records[line.Index] = line
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	for index, line := range records {
+		if c.complete[index/c.chunkSize] {
+			c.restored[index] = line
+		}
+	}
+	return nil
+}
+
+// resumed returns the record of a domain in a complete chunk, nil for a
+// domain that left the scan without a result, and whether the chunk is
+// complete
+func (c *checkpoint) resumed(index int) (*checkpointLine, bool) {
+	if c == nil || !c.complete[index/c.chunkSize] {
+		return nil, false
+	}
+	if line, ok := c.restored[index]; ok {
+		return &line, true
+	}
+	return nil, true
+}
+
+// generated counts a domain sent to the scan. Reaching the next chunk
+// closes the previous one.
+func (c *checkpoint) generated(index int) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	chunk := index / c.chunkSize
+	c.sent[chunk]++
+	if chunk > 0 && !c.closed[chunk-1] {
+		return c.close(chunk - 1)
+	}
+	return nil
+}
+
+// done closes the last chunk once every domain was generated
+func (c *checkpoint) done(total int) error {
+	if c == nil || total == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for chunk := 0; chunk <= (total-1)/c.chunkSize; chunk++ {
+		if !c.closed[chunk] {
+			if err := c.close(chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// finish records a domain leaving the scan, with its result when it has
+// one. A domain skipped by the query budget or -max-runtime keeps its
+// chunk incomplete so it is scanned again on resume.
+func (c *checkpoint) finish(index int, info *DomainInfo, matched, skipped bool) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	chunk := index / c.chunkSize
+	c.finished[chunk]++
+	if skipped {
+		c.failed[chunk] = true
+	}
+	if info != nil {
+		if err := c.write(checkpointLine{Index: index, Matched: matched, Info: info}); err != nil {
+			return err
+		}
+	}
+	return c.markComplete(chunk)
+}
+
+func (c *checkpoint) close(chunk int) error {
+	c.closed[chunk] = true
+	return c.markComplete(chunk)
+}
+
+// markComplete writes the marker of a closed chunk whose domains are all
+// done
+func (c *checkpoint) markComplete(chunk int) error {
+	if c.complete[chunk] || c.failed[chunk] || !c.closed[chunk] || c.finished[chunk] < c.sent[chunk] {
+		return nil
+	}
+	c.complete[chunk] = true
+	return c.write(checkpointLine{Chunk: &chunk})
+}
+
+func (c *checkpoint) write(line checkpointLine) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	_, err = c.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the checkpoint file; a nil checkpoint is a no-op
+func (c *checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
+
+// validateCheckpoint checks the checkpoint options
+func validateCheckpoint(config Config) error {
+	if config.ChunkSize < 1 {
+		return fmt.Errorf("-chunk-size must be at least 1")
+	}
+	if config.Checkpoint == "" {
+		return nil
+	}
+	if config.Monitor {
+		return fmt.Errorf("-checkpoint cannot be used in monitor mode")
+	}
+	if config.Shuffle {
+		return fmt.Errorf("-checkpoint needs the candidates in the same order on resume and cannot be used with -shuffle")
+	}
+	// The checkpoint holds every finished record in plaintext
+	if len(config.Encrypt) > 0 {
+		return fmt.Errorf("-checkpoint records the WHOIS data of finished domains unencrypted and cannot be used with -encrypt")
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.checkpoint")
+	config := Config{Domain: "example.com", Checkpoint: path, ChunkSize: 2}
+
+	cp, err := openCheckpoint(config)
+	if err != nil {
+		t.Fatalf("openCheckpoint failed: %v", err)
+	}
+	for index := 0; index < 4; index++ {
+		cp.generated(index)
+	}
+	cp.finish(0, &DomainInfo{Domain: "example.io", Organization: "Example Corp"}, true, false)
+	cp.finish(1, nil, false, false) // dropped by the DNS precheck
+	cp.finish(2, &DomainInfo{Domain: "example.de"}, false, false)
+	cp.Close() // interrupted before index 3 finished
+
+	// a crash in the middle of a line
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	file.WriteString(`{"index":3,"info":{"dom`)
+	file.Close()
+
+	cp, err = openCheckpoint(config)
+	if err != nil {
+		t.Fatalf("Reopening the checkpoint failed: %v", err)
+	}
+	defer cp.Close()
+	if line, complete := cp.resumed(0); !complete || line == nil || line.Info.Domain != "example.io" || !line.Matched {
+		t.Errorf("Expected example.io to be restored as a match, got %+v", line)
+	}
+	if line, complete := cp.resumed(1); !complete || line != nil {
+		t.Errorf("Expected the dropped domain to stay dropped, got %+v", line)
+	}
+	if _, complete := cp.resumed(2); complete {
+		t.Error("Expected the interrupted chunk to be scanned again")
+	}
+
+	// the next run completes the second chunk after the damaged line
+	cp.generated(2)
+	cp.generated(3)
+	cp.finish(2, &DomainInfo{Domain: "example.de"}, false, false)
+	cp.finish(3, &DomainInfo{Domain: "example.fr"}, false, false)
+	cp.done(4)
+	cp.Close()
+	cp, _ = openCheckpoint(config)
+	defer cp.Close()
+	if line, complete := cp.resumed(3); !complete || line == nil || line.Info.Domain != "example.fr" {
+		t.Errorf("Expected example.fr to be restored, got %+v", line)
+	}
+
+	config.Domain = "example.org"
+	if _, err := openCheckpoint(config); err == nil || !strings.Contains(err.Error(), "written for example.com") {
+		t.Errorf("Expected a checkpoint of another scan to be refused, got %v", err)
+	}
+}
+
+func TestScanCandidatesCheckpoint(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	config := Config{Threads: 4, Format: "json", WhoisClient: mock, Domain: "example.com",
+		Checkpoint: filepath.Join(t.TempDir(), "scan.checkpoint"), ChunkSize: 2}
+	target, err := getWhoisInfo("example.com", config)
+	if err != nil {
+		t.Fatalf("Target lookup failed: %v", err)
+	}
+	domains := []string{"example.io", "example.net", "examp1e.io", "example.dev"}
+
+	cp, _ := openCheckpoint(config)
+	all, matching, _, _ := scanCandidates(sliceCandidates(domains), target, cp, config)
+	cp.Close()
+	if len(all) != 4 || len(matching) != 1 {
+		t.Fatalf("Expected 4 results and 1 match, got %d and %d", len(all), len(matching))
+	}
+
+	resumed := newMockWhoisServer(t)
+	config.WhoisClient = resumed
+	cp, err = openCheckpoint(config)
+	if err != nil {
+		t.Fatalf("openCheckpoint failed: %v", err)
+	}
+	defer cp.Close()
+	all, matching, _, _ = scanCandidates(sliceCandidates(domains), target, cp, config)
+	if len(all) != 4 || len(matching) != 1 || matching[0].Domain != "example.io" {
+		t.Errorf("Expected the results to be restored, got %d results and %+v", len(all), matching)
+	}
+	if sent := resumed.sent(); len(sent) != 0 {
+		t.Errorf("Expected no queries for a completed scan, got %v", sent)
+	}
+}
+
+func TestValidateCheckpoint(t *testing.T) {
+	config := Config{Checkpoint: "scan.ckpt", ChunkSize: 100}
+	if err := validateCheckpoint(config); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	config.Encrypt = []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}
+	if err := validateCheckpoint(config); err == nil || !strings.Contains(err.Error(), "-encrypt") {
+		t.Errorf("Expected -checkpoint to be refused with -encrypt, got %v", err)
+	}
+}
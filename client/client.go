@@ -0,0 +1,330 @@
+// Package client drives a remote TLD Scanner started with `tldscanner serve`
+// over its REST and WebSocket API.
+//
+//	c := client.NewClient("http://scanner:8080", os.Getenv("TLDSCANNER_TOKEN"))
+//	scan, err := c.StartScan(ctx, client.ScanRequest{Domain: "example.com"})
+//	...
+//	err = c.StreamResults(ctx, scan.ID, func(event client.Event) error {
+//		if event.Matched {
+//			fmt.Println(event.Domain.Domain)
+//		}
+//		return nil
+//	})
+//	...
+//	result, err := c.GetResult(ctx, scan.ID)
+//
+// The types mirror the server's JSON. Following the result schema's
+// compatibility policy, fields added by newer servers are ignored; Result.Raw
+// holds the complete document for callers that need them.
+//
+// # Compatibility
+//
+// The module has no v1 release yet, so the package makes no stability
+// promise beyond tracking the server's JSON. TestAPICompatibility pins the
+// exported signatures and each field's type and JSON tag, so changing them
+// is a deliberate edit of the test rather than an accident.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Scan states
+const (
+	StatusQueued  = "queued"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Stream event types
+const (
+	EventStatus = "status"
+	EventDomain = "domain"
+	EventDone   = "done"
+)
+
+// ScanRequest is the body of a scan submission
+type ScanRequest struct {
+	Domain string `json:"domain"`
+	// Wordlist selects an embedded wordlist, e.g. "builtin:popular"
+	Wordlist string `json:"wordlist,omitempty"`
+	SaveAll  bool   `json:"save_all,omitempty"`
+}
+
+// Scan is the state of a submitted scan
+type Scan struct {
+	ID         string     `json:"id"`
+	Tenant     string     `json:"tenant,omitempty"`
+	Domain     string     `json:"domain"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Processed  int        `json:"processed"`
+	Total      int        `json:"total"`
+	Matches    int        `json:"matches"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the scan is done or failed
+func (s Scan) Finished() bool {
+	return s.Status == StatusDone || s.Status == StatusFailed
+}
+
+// Event is one message of a scan's live stream: a status event on connect,
+// a domain event per looked-up domain, and a done event
+type Event struct {
+	Type      string  `json:"type"`
+	Scan      *Scan   `json:"job,omitempty"`
+	Processed int     `json:"processed,omitempty"`
+	Total     int     `json:"total,omitempty"`
+	Matched   bool    `json:"matched,omitempty"`
+	Domain    *Domain `json:"domain,omitempty"`
+}
+
+// Domain holds the WHOIS data and verdict of one looked-up domain
+type Domain struct {
+	Domain        string    `json:"domain"`
+	UnicodeDomain string    `json:"unicode_domain,omitempty"`
+	Organization  string    `json:"organization"`
+	Registrar     string    `json:"registrar"`
+	CreatedDate   string    `json:"created_date"`
+	ExpiryDate    string    `json:"expiry_date"`
+	Status        string    `json:"status"`
+	NameServers   []string  `json:"name_servers"`
+	Emails        []string  `json:"emails,omitempty"`
+	MatchReason   string    `json:"match_reason,omitempty"`
+	WhoisServer   string    `json:"whois_server,omitempty"`
+	Source        string    `json:"source,omitempty"`
+	Technique     string    `json:"technique,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	RiskScore     int       `json:"risk_score,omitempty"`
+	ErrorCode     string    `json:"error_code,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Result is the result of a finished scan
+type Result struct {
+	SchemaVersion   string         `json:"schema_version"`
+	Brand           string         `json:"brand,omitempty"`
+	TargetDomain    string         `json:"target_domain"`
+	TargetOrg       string         `json:"target_organization"`
+	MatchingDomains []Domain       `json:"matching_domains"`
+	SignalDomains   []Domain       `json:"signal_domains,omitempty"`
+	Lookalikes      []Domain       `json:"lookalikes,omitempty"`
+	AllDomains      []Domain       `json:"all_domains,omitempty"`
+	SkippedDomains  []string       `json:"skipped_domains,omitempty"`
+	Truncated       bool           `json:"truncated,omitempty"`
+	ScanDuration    string         `json:"scan_duration"`
+	TotalScanned    int            `json:"total_scanned"`
+	TotalMatches    int            `json:"total_matches"`
+	TotalSignals    int            `json:"total_signals,omitempty"`
+	TotalLookalikes int            `json:"total_lookalikes,omitempty"`
+	TotalSkipped    int            `json:"total_skipped,omitempty"`
+	TotalErrors     int            `json:"total_errors"`
+	ErrorsByType    map[string]int `json:"errors_by_type,omitempty"`
+	ErrorsByTLD     map[string]int `json:"errors_by_tld,omitempty"`
+
+	// Raw is the result document as sent by the server
+	Raw json.RawMessage `json:"-"`
+}
+
+// APIError is an error answered by the server
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error describes the answer with its HTTP status
+func (e *APIError) Error() string {
+	return fmt.Sprintf("tldscanner API: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Client calls the API of one scanner instance
+type Client struct {
+	baseURL *url.URL
+	token   string
+	// HTTPClient sends the REST requests; http.DefaultClient by default
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the server at baseURL, e.g.
+// "http://127.0.0.1:8080". The token is the tenant's API token and may be
+// empty for servers without tenants. An invalid baseURL makes every call
+// fail.
+func NewClient(baseURL, token string) *Client {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/")
+	if err != nil {
+		u = nil
+	}
+	return &Client{baseURL: u, token: token, HTTPClient: http.DefaultClient}
+}
+
+// StartScan submits a scan and returns it queued
+func (c *Client) StartScan(ctx context.Context, req ScanRequest) (Scan, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Scan{}, err
+	}
+	var scan Scan
+	err = c.do(ctx, http.MethodPost, "scans", bytes.NewReader(body), &scan)
+	return scan, err
+}
+
+// ListScans returns the scans visible to the token, newest first
+func (c *Client) ListScans(ctx context.Context) ([]Scan, error) {
+	var scans []Scan
+	err := c.do(ctx, http.MethodGet, "scans", nil, &scans)
+	return scans, err
+}
+
+// GetScan returns the status and progress of a scan
+func (c *Client) GetScan(ctx context.Context, id string) (Scan, error) {
+	var scan Scan
+	err := c.do(ctx, http.MethodGet, "scans/"+url.PathEscape(id), nil, &scan)
+	return scan, err
+}
+
+// GetResult returns the result of a finished scan. It fails with a 409
+// APIError while the scan is still queued or running.
+func (c *Client) GetResult(ctx context.Context, id string) (Result, error) {
+	var raw json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "scans/"+url.PathEscape(id)+"/result", nil, &raw); err != nil {
+		return Result{}, err
+	}
+	var result Result
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return Result{}, err
+	}
+	result.Raw = raw
+	return result, nil
+}
+
+// Download returns the result of a finished scan rendered in an output
+// format such as "csv", "html" or "list"
+func (c *Client) Download(ctx context.Context, id, format string) ([]byte, error) {
+	resp, err := c.send(ctx, http.MethodGet, "scans/"+url.PathEscape(id)+"/result?format="+url.QueryEscape(format), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// StreamResults follows a scan live, calling handle for every event until
+// the done event, an error from handle, or the end of ctx. The first event
+// is the scan's status; a finished scan sends only that. Events are dropped
+// by the server for handlers that fall far behind, so handle should return
+// quickly.
+func (c *Client) StreamResults(ctx context.Context, id string, handle func(Event) error) error {
+	if c.baseURL == nil {
+		return errors.New("tldscanner API: invalid base URL")
+	}
+	location := c.baseURL.ResolveReference(&url.URL{Path: "scans/" + id + "/stream"})
+	origin := *location
+	if location.Scheme == "https" {
+		location.Scheme = "wss"
+	} else {
+		location.Scheme = "ws"
+	}
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		config.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		return fmt.Errorf("tldscanner API: streaming scan %s: %w", id, err)
+	}
+	defer ws.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		var event Event
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+		if event.Type == EventDone || (event.Type == EventStatus && event.Scan != nil && event.Scan.Finished()) {
+			return nil
+		}
+	}
+}
+
+// do sends a request and decodes the JSON answer into v
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// send sends a request and turns error answers into an APIError
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	if c.baseURL == nil {
+		return nil, errors.New("tldscanner API: invalid base URL")
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(ref).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		var answer struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&answer) == nil && answer.Error != "" {
+			apiErr.Message = answer.Error
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
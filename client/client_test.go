@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func fakeServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scans", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "missing or invalid API token"})
+			return
+		}
+		var req ScanRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(Scan{ID: "abc", Domain: req.Domain, Status: StatusQueued})
+	})
+	mux.HandleFunc("/scans/abc/result", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "list" {
+			w.Write([]byte("example.org\n"))
+			return
+		}
+		w.Write([]byte(`{"target_domain": "example.com", "total_matches": 1, "future_field": true}`))
+	})
+	mux.Handle("/scans/abc/stream", websocket.Handler(func(ws *websocket.Conn) {
+		if ws.Request().Header.Get("Authorization") != "Bearer t1" {
+			t.Error("Expected the token on the stream")
+		}
+		websocket.JSON.Send(ws, Event{Type: EventStatus, Scan: &Scan{ID: "abc", Status: StatusRunning}})
+		websocket.JSON.Send(ws, Event{Type: EventDomain, Matched: true, Domain: &Domain{Domain: "example.org"}})
+		websocket.JSON.Send(ws, Event{Type: EventDone, Scan: &Scan{ID: "abc", Status: StatusDone}})
+		// The client must stop at the done event rather than wait for more
+		var discard string
+		websocket.Message.Receive(ws, &discard)
+	}))
+	return httptest.NewServer(mux)
+}
+
+func TestClient(t *testing.T) {
+	server := fakeServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	var apiErr *APIError
+	if _, err := NewClient(server.URL, "").StartScan(ctx, ScanRequest{Domain: "example.com"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "missing or invalid API token" {
+		t.Errorf("Expected an APIError for a missing token, got %v", err)
+	}
+
+	c := NewClient(server.URL+"/", "t1")
+	scan, err := c.StartScan(ctx, ScanRequest{Domain: "example.com"})
+	if err != nil || scan.ID != "abc" || scan.Domain != "example.com" {
+		t.Fatalf("Unexpected scan %+v, %v", scan, err)
+	}
+
+	var matched []string
+	err = c.StreamResults(ctx, scan.ID, func(event Event) error {
+		if event.Matched {
+			matched = append(matched, event.Domain.Domain)
+		}
+		return nil
+	})
+	if err != nil || len(matched) != 1 || matched[0] != "example.org" {
+		t.Errorf("Unexpected stream %v, %v", matched, err)
+	}
+
+	result, err := c.GetResult(ctx, scan.ID)
+	if err != nil || result.TargetDomain != "example.com" || result.TotalMatches != 1 {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+	var raw map[string]interface{}
+	if json.Unmarshal(result.Raw, &raw); raw["future_field"] != true {
+		t.Errorf("Expected unknown fields to be kept in Raw, got %s", result.Raw)
+	}
+
+	if data, err := c.Download(ctx, scan.ID, "list"); err != nil || string(data) != "example.org\n" {
+		t.Errorf("Unexpected download %q, %v", data, err)
+	}
+	if _, err := c.GetScan(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 APIError, got %v", err)
+	}
+}
+
+// TestAPICompatibility pins the client API: the signatures below must keep
+// compiling, and every field keeps its Go type and its JSON tag including
+// omitempty
+func TestAPICompatibility(t *testing.T) {
+	var (
+		_ func(string, string) *Client                                    = NewClient
+		_ func(*Client, context.Context, ScanRequest) (Scan, error)       = (*Client).StartScan
+		_ func(*Client, context.Context) ([]Scan, error)                  = (*Client).ListScans
+		_ func(*Client, context.Context, string) (Scan, error)            = (*Client).GetScan
+		_ func(*Client, context.Context, string) (Result, error)          = (*Client).GetResult
+		_ func(*Client, context.Context, string, string) ([]byte, error)  = (*Client).Download
+		_ func(*Client, context.Context, string, func(Event) error) error = (*Client).StreamResults
+		_ func(Scan) bool                                                 = Scan.Finished
+		_ error                                                           = (*APIError)(nil)
+		_ *http.Client                                                    = (&Client{}).HTTPClient
+		_ [7]string                                                       = [...]string{StatusQueued, StatusRunning, StatusDone, StatusFailed, EventStatus, EventDomain, EventDone}
+	)
+
+	// Each field as "Name Type json-tag"
+	wantFields := map[reflect.Type][]string{
+		reflect.TypeOf(ScanRequest{}): {
+			"Domain string domain",
+			"Wordlist string wordlist,omitempty",
+			"SaveAll bool save_all,omitempty",
+		},
+		reflect.TypeOf(Scan{}): {
+			"ID string id",
+			"Tenant string tenant,omitempty",
+			"Domain string domain",
+			"Status string status",
+			"Error string error,omitempty",
+			"Processed int processed",
+			"Total int total",
+			"Matches int matches",
+			"CreatedAt time.Time created_at",
+			"FinishedAt *time.Time finished_at,omitempty",
+		},
+		reflect.TypeOf(Event{}): {
+			"Type string type",
+			"Scan *client.Scan job,omitempty",
+			"Processed int processed,omitempty",
+			"Total int total,omitempty",
+			"Matched bool matched,omitempty",
+			"Domain *client.Domain domain,omitempty",
+		},
+		reflect.TypeOf(Domain{}): {
+			"Domain string domain",
+			"UnicodeDomain string unicode_domain,omitempty",
+			"Organization string organization",
+			"Registrar string registrar",
+			"CreatedDate string created_date",
+			"ExpiryDate string expiry_date",
+			"Status string status",
+			"NameServers []string name_servers",
+			"Emails []string emails,omitempty",
+			"MatchReason string match_reason,omitempty",
+			"WhoisServer string whois_server,omitempty",
+			"Source string source,omitempty",
+			"Technique string technique,omitempty",
+			"Tags []string tags,omitempty",
+			"RiskScore int risk_score,omitempty",
+			"ErrorCode string error_code,omitempty",
+			"Error string error,omitempty",
+			"Timestamp time.Time timestamp",
+		},
+		reflect.TypeOf(Result{}): {
+			"SchemaVersion string schema_version",
+			"Brand string brand,omitempty",
+			"TargetDomain string target_domain",
+			"TargetOrg string target_organization",
+			"MatchingDomains []client.Domain matching_domains",
+			"SignalDomains []client.Domain signal_domains,omitempty",
+			"Lookalikes []client.Domain lookalikes,omitempty",
+			"AllDomains []client.Domain all_domains,omitempty",
+			"SkippedDomains []string skipped_domains,omitempty",
+			"Truncated bool truncated,omitempty",
+			"ScanDuration string scan_duration",
+			"TotalScanned int total_scanned",
+			"TotalMatches int total_matches",
+			"TotalSignals int total_signals,omitempty",
+			"TotalLookalikes int total_lookalikes,omitempty",
+			"TotalSkipped int total_skipped,omitempty",
+			"TotalErrors int total_errors",
+			"ErrorsByType map[string]int errors_by_type,omitempty",
+			"ErrorsByTLD map[string]int errors_by_tld,omitempty",
+			"Raw jsontext.Value -",
+		},
+	}
+	for typ, want := range wantFields {
+		have := make(map[string]string)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			have[field.Name] = fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag.Get("json"))
+		}
+		for _, field := range want {
+			name, _, _ := strings.Cut(field, " ")
+			if have[name] != field {
+				t.Errorf("%s.%s changed: want %q, have %q", typ.Name(), name, field, have[name])
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/vijay922/tldscanner/client"
+)
+
+// exampleServer answers like `tldscanner serve` with one finished scan
+func exampleServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scans", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "missing or invalid API token"})
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(client.Scan{ID: "a1", Domain: "example.com", Status: client.StatusQueued})
+	})
+	mux.HandleFunc("/scans/a1/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"target_domain": "example.com", "total_scanned": 120, "total_matches": 1,
+			"matching_domains": [{"domain": "example.de", "organization": "Example Corp"}]}`))
+	})
+	mux.Handle("/scans/a1/stream", websocket.Handler(func(ws *websocket.Conn) {
+		websocket.JSON.Send(ws, client.Event{Type: client.EventStatus, Scan: &client.Scan{ID: "a1", Status: client.StatusRunning}})
+		websocket.JSON.Send(ws, client.Event{Type: client.EventDomain, Processed: 1, Total: 2, Domain: &client.Domain{Domain: "example.net"}})
+		websocket.JSON.Send(ws, client.Event{Type: client.EventDomain, Processed: 2, Total: 2, Matched: true, Domain: &client.Domain{Domain: "example.de"}})
+		websocket.JSON.Send(ws, client.Event{Type: client.EventDone, Scan: &client.Scan{ID: "a1", Status: client.StatusDone}})
+	}))
+	return httptest.NewServer(mux)
+}
+
+func ExampleClient_StreamResults() {
+	server := exampleServer()
+	defer server.Close()
+	ctx := context.Background()
+
+	c := client.NewClient(server.URL, "secret")
+	scan, err := c.StartScan(ctx, client.ScanRequest{Domain: "example.com", Wordlist: "builtin:popular"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	err = c.StreamResults(ctx, scan.ID, func(event client.Event) error {
+		if event.Type == client.EventDomain && event.Matched {
+			fmt.Printf("%d/%d matched %s\n", event.Processed, event.Total, event.Domain.Domain)
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// 2/2 matched example.de
+}
+
+func ExampleClient_GetResult() {
+	server := exampleServer()
+	defer server.Close()
+
+	result, err := client.NewClient(server.URL, "secret").GetResult(context.Background(), "a1")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s: %d of %d domains match\n", result.TargetDomain, result.TotalMatches, result.TotalScanned)
+	for _, domain := range result.MatchingDomains {
+		fmt.Println(domain.Domain, domain.Organization)
+	}
+	// Output:
+	// example.com: 1 of 120 domains match
+	// example.de Example Corp
+}
+
+func ExampleAPIError() {
+	server := exampleServer()
+	defer server.Close()
+
+	_, err := client.NewClient(server.URL, "").StartScan(context.Background(), client.ScanRequest{Domain: "example.com"})
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		fmt.Println("rejected:", apiErr.Message)
+	}
+	// Output:
+	// rejected: missing or invalid API token
+}
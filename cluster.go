@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// clusterSimilarity is the edit-distance similarity from which two
+	// normalized organization names are taken for the same entity, so that
+	// typos and abbreviations in registrant records cluster together
+	clusterSimilarity = 0.85
+	// privacyCluster names the cluster of redacted and unpublished
+	// registrants
+	privacyCluster = "privacy-protected"
+	// maxSummaryHolders bounds the clusters listed in the scan summary
+	maxSummaryHolders = 5
+)
+
+// OrgCluster is one registrant organization holding registered domains of
+// the scan, with the spellings merged into it
+type OrgCluster struct {
+	Organization string   `json:"organization"`
+	Count        int      `json:"count"`
+	Target       bool     `json:"target,omitempty"`
+	Privacy      bool     `json:"privacy,omitempty"`
+	Variants     []string `json:"variants,omitempty"`
+	Domains      []string `json:"domains"`
+}
+
+// String renders the cluster as in "37 by Example Inc"
+func (c OrgCluster) String() string {
+	if c.Privacy {
+		return fmt.Sprintf("%d %s", c.Count, privacyCluster)
+	}
+	return fmt.Sprintf("%d by %s", c.Count, c.Organization)
+}
+
+// orgClusterBuilder accumulates one cluster while spellings are counted
+type orgClusterBuilder struct {
+	OrgCluster
+	key       string
+	spellings map[string]int
+}
+
+// clusterOrganizations groups the registered domains by registrant
+// organization. Names matching the target's (including aliases) form the
+// target cluster; redacted and missing names form the privacy cluster; the
+// others are compared in normalized form and merged when nearly equal.
+// Clusters are ordered by descending domain count.
+func clusterOrganizations(domains []DomainInfo, targetOrg string, config Config) []OrgCluster {
+	normalizer := config.OrgNormalizer
+	if normalizer == nil {
+		normalizer = newOrgNormalizer(OrgRules{})
+	}
+
+	var builders []*orgClusterBuilder
+	find := func(key string) *orgClusterBuilder {
+		for _, b := range builders {
+			if b.key == key || (!b.Target && !b.Privacy && nameSimilarity(b.key, key) >= clusterSimilarity) {
+				return b
+			}
+		}
+		b := &orgClusterBuilder{key: key, spellings: map[string]int{}}
+		builders = append(builders, b)
+		return b
+	}
+
+	for _, info := range registeredDomains(domains) {
+		org := strings.Join(strings.Fields(info.Organization), " ")
+		var b *orgClusterBuilder
+		switch {
+		case org == "" || privacyPattern.MatchString(org):
+			b = find("\x00privacy")
+			b.Privacy = true
+		case targetOrg != "" && orgsMatch(org, targetOrg, config):
+			b = find("\x00target")
+			b.Target = true
+		default:
+			key := normalizer.normalize(org)
+			if key == "" {
+				key = strings.ToLower(org)
+			}
+			b = find(key)
+		}
+		b.Count++
+		b.Domains = append(b.Domains, info.Domain)
+		if org != "" {
+			b.spellings[org]++
+		}
+	}
+
+	clusters := make([]OrgCluster, 0, len(builders))
+	for _, b := range builders {
+		cluster := b.OrgCluster
+		spellings := make([]string, 0, len(b.spellings))
+		for spelling := range b.spellings {
+			spellings = append(spellings, spelling)
+		}
+		// The most frequent spelling names the cluster
+		sort.Slice(spellings, func(i, j int) bool {
+			if b.spellings[spellings[i]] != b.spellings[spellings[j]] {
+				return b.spellings[spellings[i]] > b.spellings[spellings[j]]
+			}
+			return spellings[i] < spellings[j]
+		})
+		switch {
+		case cluster.Privacy:
+			cluster.Organization = privacyCluster
+			cluster.Variants = spellings
+		case cluster.Target:
+			cluster.Organization = targetOrg
+			cluster.Variants = spellings
+		default:
+			cluster.Organization = spellings[0]
+			cluster.Variants = spellings[1:]
+		}
+		sort.Strings(cluster.Domains)
+		clusters = append(clusters, cluster)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].Organization < clusters[j].Organization
+	})
+	return clusters
+}
+
+// nameSimilarity is 1 minus the edit distance of two names relative to the
+// longer one
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// holdersSummary lists the largest clusters on one line, e.g. "37 by
+// Example Inc, 5 by DropCatch LLC, 12 privacy-protected"
+func holdersSummary(clusters []OrgCluster) string {
+	var parts []string
+	for i, cluster := range clusters {
+		if i == maxSummaryHolders {
+			parts = append(parts, fmt.Sprintf("%d more", len(clusters)-i))
+			break
+		}
+		parts = append(parts, cluster.String())
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterOrganizations(t *testing.T) {
+	domains := []DomainInfo{
+		{Domain: "example.net", Organization: "Example Inc"},
+		{Domain: "example.org", Organization: "EXAMPLE, INC."},
+		{Domain: "example.ru", Organization: "Example Inc"},
+		{Domain: "example.de", Organization: "Eksampl GmbH"},
+		{Domain: "example.io", Organization: "DropCatch.com LLC"},
+		{Domain: "example.co", Organization: "DropCatch.com, LLC"},
+		{Domain: "example.me", Organization: "Dropcatch com Inc"},
+		{Domain: "example.shop", Organization: "REDACTED FOR PRIVACY"},
+		{Domain: "example.app", Organization: ""},
+		{Domain: "example.xyz", Organization: "Domains By Proxy, LLC"},
+		{Domain: "example.fr", Organization: "Unrelated Widgets SA"},
+		{Domain: "example.zz", Error: "no WHOIS server"},
+	}
+	config := Config{OrgNormalizer: newOrgNormalizer(OrgRules{Aliases: []string{"Eksampl"}})}
+	clusters := clusterOrganizations(domains, "Example Inc", config)
+
+	var got []string
+	for _, cluster := range clusters {
+		got = append(got, cluster.String())
+	}
+	want := []string{"4 by Example Inc", "3 by DropCatch.com LLC", "3 privacy-protected", "1 by Unrelated Widgets SA"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected clusters %v, got %v", want, got)
+	}
+	if !clusters[0].Target || !reflect.DeepEqual(clusters[0].Domains, []string{"example.de", "example.net", "example.org", "example.ru"}) {
+		t.Errorf("Unexpected target cluster %+v", clusters[0])
+	}
+	if !reflect.DeepEqual(clusters[1].Variants, []string{"DropCatch.com, LLC", "Dropcatch com Inc"}) {
+		t.Errorf("Expected the other spellings as variants, got %v", clusters[1].Variants)
+	}
+	if !clusters[2].Privacy {
+		t.Errorf("Expected the privacy cluster, got %+v", clusters[2])
+	}
+	if summary := holdersSummary(clusters); summary != "4 by Example Inc, 3 by DropCatch.com LLC, 3 privacy-protected, 1 by Unrelated Widgets SA" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"dropcatch", "dropcatch", 1},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"acme", "acne", 0.75},
+	}
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("nameSimilarity(%q, %q) = %.3f, expected %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"regexp"
+)
+
+// Colors for terminal output. They are variables so that color can be
+// switched off at startup for pipes, files and NO_COLOR users.
+var (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorPurple = "\033[35m"
+	ColorCyan   = "\033[36m"
+	ColorWhite  = "\033[37m"
+)
+
+// ansiPattern matches ANSI SGR escape sequences
+var ansiPattern = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// colorsEnabled decides whether ANSI colors should be used: not when disabled
+// by flag, when NO_COLOR is set (https://no-color.org), for dumb terminals,
+// when stdout is not a terminal, or when the console cannot render them
+func colorsEnabled(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(os.Stdout) && enableTerminalColors()
+}
+
+// isTerminal reports whether f is attached to a character device
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// disableColors blanks every color code so output is plain text
+func disableColors() {
+	ColorReset, ColorRed, ColorGreen, ColorYellow = "", "", "", ""
+	ColorBlue, ColorPurple, ColorCyan, ColorWhite = "", "", "", ""
+}
+
+// stripANSI removes color escape sequences from s
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// enableTerminalColors reports whether the terminal can render ANSI colors;
+// every supported non-Windows terminal does
+func enableTerminalColors() bool {
+	return true
+}
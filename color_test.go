@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestStripANSI(t *testing.T) {
+	input := "\033[36m=== TLD SCANNER RESULTS ===\033[0m\n\033[1;32mok\033[0m"
+	expected := "=== TLD SCANNER RESULTS ===\nok"
+	if got := stripANSI(input); got != expected {
+		t.Errorf("stripANSI() = %q; expected %q", got, expected)
+	}
+}
+
+func TestColorsEnabledNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if colorsEnabled(false) {
+		t.Error("Colors should be disabled when NO_COLOR is set")
+	}
+}
+
+func TestColorsEnabledFlag(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if colorsEnabled(true) {
+		t.Error("Colors should be disabled by -no-color")
+	}
+}
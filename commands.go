@@ -0,0 +1,26 @@
+package main
+
+// commands maps subcommand names to their entry points. Each receives the
+// arguments following the subcommand and returns the process exit code.
+var commands = map[string]func(args []string) int{
+	"auth":      runAuth,
+	"brand":     runBrand,
+	"dropwatch": runDropWatch,
+	"evidence":  runEvidence,
+	"history":   runHistory,
+	"import":    runImport,
+	"profile":   runProfile,
+	"retry":     runRetry,
+	"scan":      runScan,
+	"schema":    runSchema,
+	"search":    runSearch,
+	"serve":     runServe,
+	"service":   runService,
+	"summary":   runSummary,
+	"tag":       runTag,
+	"takedown":  runTakedown,
+	"update":    runUpdate,
+	"verify":    runVerify,
+	"version":   runVersion,
+	"wordlist":  runWordlist,
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completionShells are the shells `completion` writes scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completion is the command line the completion scripts offer: the
+// subcommands, the scan options, and the known values of some options
+type completion struct {
+	commands []string
+	flags    []completionFlag
+	// values are the choices of an option, by flag name
+	values map[string][]string
+}
+
+// completionFlag is a scan option as offered by the completion scripts
+type completionFlag struct {
+	name        string
+	description string
+	// takesValue is false for boolean options
+	takesValue bool
+}
+
+// The subcommand is registered here rather than in the commands map, which
+// it lists: the map would otherwise refer to itself.
+func init() {
+	commands["completion"] = runCompletion
+}
+
+// newCompletion collects the subcommands and the scan options. Options
+// without known values complete file names.
+func newCompletion() completion {
+	c := completion{values: map[string][]string{
+		"format": outputFormats,
+		"w":      nil,
+	}}
+	for name := range commands {
+		c.commands = append(c.commands, name)
+	}
+	sort.Strings(c.commands)
+	for _, name := range builtinWordlistNames() {
+		c.values["w"] = append(c.values["w"], builtinPrefix+name)
+	}
+
+	var config Config
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	fs.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		c.flags = append(c.flags, completionFlag{
+			name:        f.Name,
+			description: completionDescription(f.Usage),
+			takesValue:  !ok || !boolFlag.IsBoolFlag(),
+		})
+	})
+	return c
+}
+
+// completionDescription shortens a flag's usage to its first clause. The
+// periods of "e.g." and "i.e." do not end one.
+func completionDescription(usage string) string {
+	for _, sep := range []string{"; ", " ("} {
+		if i := strings.Index(usage, sep); i > 0 {
+			usage = usage[:i]
+		}
+	}
+	for start := 0; ; {
+		i := strings.Index(usage[start:], ". ")
+		if i < 0 {
+			break
+		}
+		end := start + i
+		if !strings.HasSuffix(usage[:end], "e.g") && !strings.HasSuffix(usage[:end], "i.e") {
+			usage = usage[:end]
+			break
+		}
+		start = end + 2
+	}
+	return strings.TrimSuffix(usage, ".")
+}
+
+// writeCompletion writes the completion script of a shell
+func writeCompletion(w io.Writer, shell string, c completion) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, c)
+	case "zsh":
+		writeZshCompletion(w, c)
+	case "fish":
+		writeFishCompletion(w, c)
+	case "powershell":
+		writePowerShellCompletion(w, c)
+	default:
+		return fmt.Errorf("unknown shell %q (valid: %s)", shell, strings.Join(completionShells, ", "))
+	}
+	return nil
+}
+
+// flagNames returns the options as typed, with their dash
+func (c completion) flagNames() []string {
+	names := make([]string, len(c.flags))
+	for i, f := range c.flags {
+		names[i] = "-" + f.name
+	}
+	return names
+}
+
+// valueFlags returns the names of the options with known values, sorted
+func (c completion) valueFlags() []string {
+	var names []string
+	for name := range c.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeBashCompletion writes a bash script. The word is taken from the
+// line rather than COMP_WORDS, which splits builtin:all and -format=json
+// at the colon and the equals sign.
+func writeBashCompletion(w io.Writer, c completion) {
+	fmt.Fprintf(w, "# bash completion for tldscanner\n")
+	fmt.Fprintf(w, "# Load with: source <(tldscanner completion bash)\n")
+	fmt.Fprintf(w, "_tldscanner() {\n")
+	fmt.Fprintf(w, "    local line=\"${COMP_LINE:0:COMP_POINT}\"\n")
+	fmt.Fprintf(w, "    local -a words\n")
+	fmt.Fprintf(w, "    read -ra words <<< \"$line\"\n")
+	fmt.Fprintf(w, "    local cur=\"\" prev=\"${words[${#words[@]}-1]}\" position=${#words[@]}\n")
+	fmt.Fprintf(w, "    if [[ \"$line\" != *[[:space:]] ]]; then\n")
+	fmt.Fprintf(w, "        cur=\"$prev\" prev=\"${words[${#words[@]}-2]}\" position=$((position - 1))\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    local prefix=\"\"\n")
+	fmt.Fprintf(w, "    if [[ \"$cur\" == -*=* ]]; then\n")
+	fmt.Fprintf(w, "        prev=\"${cur%%%%=*}\" prefix=\"${cur%%%%=*}=\" cur=\"${cur#*=}\"\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    local candidates\n")
+	fmt.Fprintf(w, "    case \"${prev#-}\" in\n")
+	for _, name := range c.valueFlags() {
+		fmt.Fprintf(w, "        %s|-%s) candidates=%q ;;\n", name, name, strings.Join(c.values[name], " "))
+	}
+	fmt.Fprintf(w, "        *)\n")
+	fmt.Fprintf(w, "            if [[ -n \"$prefix\" ]]; then\n")
+	fmt.Fprintf(w, "                return\n")
+	fmt.Fprintf(w, "            elif [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(w, "                candidates=%q\n", strings.Join(c.flagNames(), " "))
+	fmt.Fprintf(w, "            elif [[ $position -eq 1 ]]; then\n")
+	fmt.Fprintf(w, "                candidates=%q\n", strings.Join(c.commands, " "))
+	fmt.Fprintf(w, "            else\n")
+	fmt.Fprintf(w, "                return\n")
+	fmt.Fprintf(w, "            fi ;;\n")
+	fmt.Fprintf(w, "    esac\n")
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W \"$candidates\" -- \"$cur\"))\n")
+	fmt.Fprintf(w, "    # bash replaces only the text after the last word break\n")
+	fmt.Fprintf(w, "    local typed=\"$prefix$cur\" trim=\"\"\n")
+	fmt.Fprintf(w, "    [[ \"$typed\" == *[=:]* ]] && trim=\"${typed%%\"${typed##*[=:]}\"}\"\n")
+	fmt.Fprintf(w, "    local i\n")
+	fmt.Fprintf(w, "    for i in \"${!COMPREPLY[@]}\"; do\n")
+	fmt.Fprintf(w, "        COMPREPLY[$i]=\"${prefix}${COMPREPLY[$i]}\"\n")
+	fmt.Fprintf(w, "        COMPREPLY[$i]=\"${COMPREPLY[$i]#\"$trim\"}\"\n")
+	fmt.Fprintf(w, "    done\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -o default -F _tldscanner tldscanner\n")
+}
+
+// writeZshCompletion writes a zsh completion function
+func writeZshCompletion(w io.Writer, c completion) {
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `'\''`, `[`, `\[`, `]`, `\]`, `:`, `\:`)
+	fmt.Fprintf(w, "#compdef tldscanner\n")
+	fmt.Fprintf(w, "# Load with: source <(tldscanner completion zsh)\n")
+	fmt.Fprintf(w, "_tldscanner() {\n")
+	fmt.Fprintf(w, "    if (( CURRENT == 2 )) && [[ $PREFIX != -* ]]; then\n")
+	fmt.Fprintf(w, "        compadd -- %s\n", strings.Join(c.commands, " "))
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    _arguments \\\n")
+	for _, f := range c.flags {
+		spec := fmt.Sprintf("-%s[%s]", f.name, escape.Replace(f.description))
+		switch values, ok := c.values[f.name]; {
+		case f.name == "w":
+			// A wordlist is a builtin or a file
+			spec += fmt.Sprintf(":%s:{compadd -- %s; _files}", f.name, strings.Join(values, " "))
+		case ok:
+			spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(values, " "))
+		case f.takesValue:
+			spec += fmt.Sprintf(":%s:_files", f.name)
+		}
+		fmt.Fprintf(w, "        '%s' \\\n", spec)
+	}
+	fmt.Fprintf(w, "        '*:file:_files'\n")
+	fmt.Fprintf(w, "}\n")
+	// Autoloaded from fpath the file is the completion function itself;
+	// sourced, it registers the function
+	fmt.Fprintf(w, "if [[ $funcstack[1] == _tldscanner ]]; then\n")
+	fmt.Fprintf(w, "    _tldscanner \"$@\"\n")
+	fmt.Fprintf(w, "else\n")
+	fmt.Fprintf(w, "    compdef _tldscanner tldscanner\n")
+	fmt.Fprintf(w, "fi\n")
+}
+
+// writeFishCompletion writes fish completions. Go flags take a single dash,
+// which fish calls old-style options (-o).
+func writeFishCompletion(w io.Writer, c completion) {
+	quote := func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+	}
+	fmt.Fprintf(w, "# fish completion for tldscanner\n")
+	fmt.Fprintf(w, "# Load with: tldscanner completion fish | source\n")
+	fmt.Fprintf(w, "complete -c tldscanner -n __fish_use_subcommand -f -a %s\n", quote(strings.Join(c.commands, " ")))
+	for _, f := range c.flags {
+		line := fmt.Sprintf("complete -c tldscanner -o %s -d %s", f.name, quote(f.description))
+		if values, ok := c.values[f.name]; ok {
+			line += " -r -a " + quote(strings.Join(values, " "))
+			if f.name != "w" {
+				line += " -f"
+			}
+		} else if f.takesValue {
+			line += " -r -F"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// writePowerShellCompletion writes a PowerShell argument completer
+func writePowerShellCompletion(w io.Writer, c completion) {
+	list := func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = "'" + strings.ReplaceAll(item, "'", "''") + "'"
+		}
+		return "@(" + strings.Join(quoted, ", ") + ")"
+	}
+	var valueFlags []string
+	for _, f := range c.flags {
+		if f.takesValue {
+			valueFlags = append(valueFlags, "-"+f.name)
+		}
+	}
+	fmt.Fprintf(w, "# PowerShell completion for tldscanner\n")
+	fmt.Fprintf(w, "# Load with: tldscanner completion powershell | Out-String | Invoke-Expression\n")
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName tldscanner, tldscanner.exe -ScriptBlock {\n")
+	fmt.Fprintf(w, "    param($wordToComplete, $commandAst, $cursorPosition)\n")
+	fmt.Fprintf(w, "    $commands = %s\n", list(c.commands))
+	fmt.Fprintf(w, "    $flags = %s\n", list(c.flagNames()))
+	fmt.Fprintf(w, "    $valueFlags = %s\n", list(valueFlags))
+	fmt.Fprintf(w, "    $values = @{\n")
+	for _, name := range c.valueFlags() {
+		fmt.Fprintf(w, "        '-%s' = %s\n", name, list(c.values[name]))
+	}
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "    $elements = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })\n")
+	fmt.Fprintf(w, "    $position = $elements.Count\n")
+	fmt.Fprintf(w, "    if ($wordToComplete -ne '') { $position-- }\n")
+	fmt.Fprintf(w, "    $prev = if ($position -ge 1) { $elements[$position - 1] } else { '' }\n")
+	fmt.Fprintf(w, "    if ($values.ContainsKey($prev)) {\n")
+	fmt.Fprintf(w, "        $candidates = $values[$prev]\n")
+	fmt.Fprintf(w, "    } elseif ($valueFlags -contains $prev) {\n")
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    } elseif ($wordToComplete -like '-*') {\n")
+	fmt.Fprintf(w, "        $candidates = $flags\n")
+	fmt.Fprintf(w, "    } elseif ($position -eq 1) {\n")
+	fmt.Fprintf(w, "        $candidates = $commands\n")
+	fmt.Fprintf(w, "    } else {\n")
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	fmt.Fprintf(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n")
+	fmt.Fprintf(w, "    }\n")
+	fmt.Fprintf(w, "}\n")
+}
+
+// runCompletion implements `tldscanner completion bash|zsh|fish|powershell`
+func runCompletion(args []string) int {
+	fs := flag.NewFlagSet("completion", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s completion %s\n\n", os.Args[0], strings.Join(completionShells, "|"))
+		fmt.Printf("Prints a shell completion script for the subcommands, the scan options,\n")
+		fmt.Printf("the builtin wordlists and the output formats. Load it in the current\n")
+		fmt.Printf("shell, or save it where the shell loads completions from:\n\n")
+		fmt.Printf("  source <(%s completion bash)\n", os.Args[0])
+		fmt.Printf("  %s completion zsh > \"${fpath[1]}/_tldscanner\"\n", os.Args[0])
+		fmt.Printf("  %s completion fish > ~/.config/fish/completions/tldscanner.fish\n", os.Args[0])
+		fmt.Printf("  %s completion powershell | Out-String | Invoke-Expression\n", os.Args[0])
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if err := writeCompletion(os.Stdout, fs.Arg(0), newCompletion()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	return ExitMatches
+}
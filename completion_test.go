@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	c := newCompletion()
+	for _, shell := range completionShells {
+		var buf bytes.Buffer
+		if err := writeCompletion(&buf, shell, c); err != nil {
+			t.Fatalf("writeCompletion(%s) failed: %v", shell, err)
+		}
+		script := buf.String()
+		for _, expected := range []string{"completion", "wordlist", "format", "list-all", "builtin:popular", "flush-every"} {
+			if !strings.Contains(script, expected) {
+				t.Errorf("The %s script does not offer %s", shell, expected)
+			}
+		}
+	}
+	if err := writeCompletion(&bytes.Buffer{}, "tcsh", c); err == nil {
+		t.Error("Expected an unknown shell to be rejected")
+	}
+}
+
+func TestBashCompletion(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	var buf bytes.Buffer
+	writeBashCompletion(&buf, newCompletion())
+	script := filepath.Join(t.TempDir(), "tldscanner.bash")
+	if err := os.WriteFile(script, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"tldscanner sc", "scan schema"},
+		{"tldscanner -flush-e", "-flush-every"},
+		{"tldscanner -format j", "json"},
+		{"tldscanner -format=c", "csv cef"},
+		{"tldscanner -w builtin:c", "cctld"},
+		{"tldscanner -d example.com -o ", ""},
+	}
+	for _, test := range tests {
+		cmd := exec.Command("bash", "-c", `source "$1"; COMP_LINE="$2"; COMP_POINT=${#2}; _tldscanner; echo "${COMPREPLY[*]}"`,
+			"bash", script, test.line)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Completing %q failed: %v", test.line, err)
+		}
+		if got := strings.TrimSpace(string(out)); got != test.expected {
+			t.Errorf("Completing %q offered %q, expected %q", test.line, got, test.expected)
+		}
+	}
+}
+
+func TestCompletionDescription(t *testing.T) {
+	tests := map[string]string{
+		"Stop dispatching lookups after this long, e.g. 2h. Lookups in flight finish": "Stop dispatching lookups after this long, e.g. 2h",
+		"Write results to file (default: stdout)":                                     "Write results to file",
+		"Enable verbose output; print every lookup":                                   "Enable verbose output",
+	}
+	for usage, expected := range tests {
+		if got := completionDescription(usage); got != expected {
+			t.Errorf("completionDescription(%q) = %q, expected %q", usage, got, expected)
+		}
+	}
+}
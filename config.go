@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the persistent configuration file (config.yaml)
+type FileConfig struct {
+	Credentials   map[string]Credential `yaml:"credentials,omitempty"`
+	Organizations OrgRules              `yaml:"organizations,omitempty"`
+	WhoisPatterns map[string][]string   `yaml:"whois_patterns,omitempty"`
+	// WhoisQueries are registry query strings by TLD, with a {domain}
+	// placeholder
+	WhoisQueries map[string]string `yaml:"whois_queries,omitempty"`
+	// WhoisDisclaimers match the start of disclaimer paragraphs to strip
+	WhoisDisclaimers []string `yaml:"whois_disclaimers,omitempty"`
+	// WhoisLabels translate local-language WHOIS field labels to English,
+	// by TLD
+	WhoisLabels map[string]map[string]string `yaml:"whois_labels,omitempty"`
+	// Enrichers are external programs usable with -enrich, by name
+	Enrichers map[string]EnricherCommand `yaml:"enrichers,omitempty"`
+	// Tenants hold the serve mode API tokens and quotas, by tenant name
+	Tenants map[string]Tenant `yaml:"tenants,omitempty"`
+	// APILimits override the rate limits and quotas of the API
+	// integrations, by provider
+	APILimits map[string]APILimit `yaml:"api_limits,omitempty"`
+	// RegistrarAccounts are the settings of the -registrar-accounts, by
+	// registrar
+	RegistrarAccounts map[string]RegistrarAccount `yaml:"registrar_accounts,omitempty"`
+}
+
+// Credential holds the secret for one integration provider. When Keychain is
+// set the secret lives in the OS keychain instead of the file.
+type Credential struct {
+	APIKey   string `yaml:"api_key,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Keychain bool   `yaml:"keychain,omitempty"`
+}
+
+// defaultConfigPath returns the per-user configuration file location
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "tldscanner.yaml"
+	}
+	return filepath.Join(dir, "tldscanner", "config.yaml")
+}
+
+// loadFileConfig reads the configuration file. A missing file is not an
+// error and yields an empty configuration.
+func loadFileConfig(path string) (*FileConfig, error) {
+	cfg := &FileConfig{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// applyFileConfig loads the scan settings kept in the configuration file:
+// organization normalization rules (-transliterate enables transliteration
+// on top), raw WHOIS extraction patterns, per-TLD WHOIS query strings,
+// field label translations and disclaimer patterns, and the custom enrichers
+// selected with -enrich. The -script match hook and -filter expression are compiled,
+// the -sign key, -encrypt recipients and -ca-bundle read, the -cache backend
+// opened and the -otlp-endpoint exporter and -tor routing set up here too so
+// that their errors are reported before the scan starts.
+func applyFileConfig(config *Config) error {
+	fileConfig, err := loadFileConfig(config.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	rules := fileConfig.Organizations
+	rules.Transliterate = rules.Transliterate || config.Transliterate
+	config.OrgNormalizer = newOrgNormalizer(rules)
+
+	config.WhoisPatterns, err = compileWhoisPatterns(fileConfig.WhoisPatterns)
+	if err != nil {
+		return err
+	}
+	config.WhoisQueries, err = newWhoisQueries(fileConfig.WhoisQueries)
+	if err != nil {
+		return err
+	}
+	config.WhoisDisclaimers, err = compileDisclaimers(fileConfig.WhoisDisclaimers)
+	if err != nil {
+		return err
+	}
+	config.WhoisLabels, err = newWhoisLabels(fileConfig.WhoisLabels)
+	if err != nil {
+		return err
+	}
+
+	config.Enrichers, err = selectEnrichers(config.Enrich, fileConfig.Enrichers, time.Duration(config.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	config.MatchScript, err = loadMatchScript(config.Script)
+	if err != nil {
+		return err
+	}
+
+	config.KnownAssets, err = loadKnownDomains(config.KnownDomains)
+	if err != nil {
+		return err
+	}
+	config.IgnoreList, err = loadIgnoreList(config.IgnoreFile)
+	if err != nil {
+		return err
+	}
+
+	config.ResultFilter, err = compileFilter(config.Filter)
+	if err != nil {
+		return err
+	}
+
+	config.Signer, err = loadSigningKey(config.Sign)
+	if err != nil {
+		return err
+	}
+
+	config.Recipients, err = parseRecipients(config.Encrypt)
+	if err != nil {
+		return err
+	}
+
+	if err := trustCABundle(firstNonEmpty(config.CABundle, os.Getenv(caBundleEnvVar))); err != nil {
+		return err
+	}
+
+	config.LookupCache, err = openLookupCache(config.Cache, config.CacheTTL, time.Duration(config.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	if config.Tor {
+		if err := routeThroughTor(config); err != nil {
+			return err
+		}
+	}
+
+	config.APIClient, err = newAPIClient(fileConfig.APILimits, time.Duration(config.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+	if err := loadRegistrarAccounts(config, fileConfig.RegistrarAccounts); err != nil {
+		return err
+	}
+
+	config.Syslog, err = newSyslogSender(config.SyslogAddr, config.SyslogFormat, time.Duration(config.Timeout)*time.Second)
+	if err != nil {
+		return err
+	}
+
+	config.Neo4jSink, err = newNeo4jSink(config.Neo4j, *config)
+	if err != nil {
+		return err
+	}
+
+	config.OTLP, err = newOTLPExporter(firstNonEmpty(config.OTLPEndpoint, os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")), time.Duration(config.Timeout)*time.Second)
+	return err
+}
+
+// saveFileConfig writes the configuration file atomically with owner-only
+// permissions since it may contain API keys
+func saveFileConfig(path string, cfg *FileConfig) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place so readers never observe a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// credentialEnvVar is the environment variable that overrides a provider's
+// API key, e.g. TLDSCANNER_VIRUSTOTAL_API_KEY
+func credentialEnvVar(provider string) string {
+	name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(provider))
+	return "TLDSCANNER_" + name + "_API_KEY"
+}
+
+// lookupCredential resolves a provider's API key from, in order, the
+// environment, the configuration file and the OS keychain
+func (cfg *FileConfig) lookupCredential(provider string) (string, error) {
+	provider = strings.ToLower(provider)
+	if key := os.Getenv(credentialEnvVar(provider)); key != "" {
+		return key, nil
+	}
+
+	cred, ok := cfg.Credentials[provider]
+	if !ok {
+		return "", fmt.Errorf("no credentials configured for %s (run `tldscanner auth set %s` or set %s)",
+			provider, provider, credentialEnvVar(provider))
+	}
+	if cred.Keychain {
+		return keychainGet(provider)
+	}
+	return cred.APIKey, nil
+}
+
+// lookupUsername returns the account name stored with a provider's key,
+// overridable with TLDSCANNER_<PROVIDER>_USERNAME
+func (cfg *FileConfig) lookupUsername(provider string) string {
+	provider = strings.ToLower(provider)
+	if user := os.Getenv("TLDSCANNER_" + strings.ToUpper(provider) + "_USERNAME"); user != "" {
+		return user
+	}
+	return cfg.Credentials[provider].Username
+}
+
+// providers returns the configured provider names in sorted order
+func (cfg *FileConfig) providers() []string {
+	var names []string
+	for name := range cfg.Credentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tenantNames returns the serve mode tenant names in sorted order
+func (cfg *FileConfig) tenantNames() []string {
+	var names []string
+	for name := range cfg.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
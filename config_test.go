@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+
+	cfg, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("Missing config file should not be an error: %v", err)
+	}
+	cfg.Credentials = map[string]Credential{"virustotal": {APIKey: "vt-secret"}}
+	if err := saveFileConfig(path, cfg); err != nil {
+		t.Fatalf("saveFileConfig failed: %v", err)
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Config file not written: %v", err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("Config file permissions = %v; expected 0600", stat.Mode().Perm())
+	}
+
+	loaded, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig failed: %v", err)
+	}
+	if loaded.Credentials["virustotal"].APIKey != "vt-secret" {
+		t.Errorf("Credential not preserved: %+v", loaded.Credentials)
+	}
+}
+
+func TestLookupCredential(t *testing.T) {
+	cfg := &FileConfig{Credentials: map[string]Credential{
+		"securitytrails": {APIKey: "from-file"},
+	}}
+
+	key, err := cfg.lookupCredential("SecurityTrails")
+	if err != nil || key != "from-file" {
+		t.Errorf("lookupCredential() = %q, %v; expected file key", key, err)
+	}
+
+	t.Setenv("TLDSCANNER_SECURITYTRAILS_API_KEY", "from-env")
+	key, err = cfg.lookupCredential("securitytrails")
+	if err != nil || key != "from-env" {
+		t.Errorf("lookupCredential() = %q, %v; expected environment override", key, err)
+	}
+
+	if _, err := cfg.lookupCredential("shodan"); err == nil {
+		t.Error("Expected an error for an unconfigured provider")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// consoleRefresh is how often the live progress line is redrawn
+const consoleRefresh = 100 * time.Millisecond
+
+// consoleBacklog is the number of live lines queued for the terminal before
+// the collector waits for it
+const consoleBacklog = 1024
+
+// liveConsole writes the live output of a scan (matches, signals, verbose
+// lines and the progress line) from its own goroutine, so the collector
+// never waits on a slow terminal. The progress line is redrawn every
+// consoleRefresh rather than once per domain. A nil console writes nothing.
+type liveConsole struct {
+	out   io.Writer
+	lines chan string
+	done  chan struct{}
+
+	// The progress line, when shown, counts the domains scanned and matched
+	showProgress       bool
+	total              int
+	start              time.Time
+	processed, matches atomic.Int64
+}
+
+// newLiveConsole starts a console writing to out. With showProgress it
+// keeps a progress line of the total domains up to date.
+func newLiveConsole(out io.Writer, showProgress bool, total int, start time.Time) *liveConsole {
+	c := &liveConsole{
+		out:          out,
+		lines:        make(chan string, consoleBacklog),
+		done:         make(chan struct{}),
+		showProgress: showProgress,
+		total:        total,
+		start:        start,
+	}
+	go c.run()
+	return c
+}
+
+// printf queues a line for the terminal
+func (c *liveConsole) printf(format string, args ...interface{}) {
+	if c == nil {
+		return
+	}
+	c.lines <- fmt.Sprintf(format, args...)
+}
+
+// progress records the domains scanned and matched so far
+func (c *liveConsole) progress(processed, matches int) {
+	if c == nil {
+		return
+	}
+	c.processed.Store(int64(processed))
+	c.matches.Store(int64(matches))
+}
+
+// close writes the queued lines and the final progress line, and returns
+// once the terminal is up to date
+func (c *liveConsole) close() {
+	if c == nil {
+		return
+	}
+	close(c.lines)
+	<-c.done
+}
+
+func (c *liveConsole) run() {
+	defer close(c.done)
+	ticker := time.NewTicker(consoleRefresh)
+	defer ticker.Stop()
+	drawn := int64(0)
+	draw := func() {
+		if processed := c.processed.Load(); c.showProgress && processed != drawn {
+			drawn = processed
+			io.WriteString(c.out, c.progressLine(processed))
+		}
+	}
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				draw()
+				if c.showProgress {
+					fmt.Fprintln(c.out) // New line after progress
+				}
+				return
+			}
+			io.WriteString(c.out, line)
+		case <-ticker.C:
+			draw()
+		}
+	}
+}
+
+// progressLine renders the progress line, overwriting the previous one
+func (c *liveConsole) progressLine(processed int64) string {
+	eta := "ETA --"
+	if left := progressETA(int(processed), c.total, time.Since(c.start)); left > 0 {
+		eta = "ETA " + left.String()
+	}
+	// Trailing spaces clear a longer ETA left on the line
+	return fmt.Sprintf("\r%s[INFO]%s Progress: %d/%d domains scanned (%d matches, %s)   ",
+		ColorBlue, ColorReset, processed, c.total, c.matches.Load(), eta)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLiveConsole(t *testing.T) {
+	var out bytes.Buffer
+	console := newLiveConsole(&out, true, 3, time.Now())
+	console.printf("[+] MATCH: %s\n", "example.de")
+	for processed := 1; processed <= 3; processed++ {
+		console.progress(processed, 1)
+	}
+	console.close()
+
+	text := out.String()
+	if !strings.Contains(text, "[+] MATCH: example.de\n") {
+		t.Errorf("Expected the queued line, got %q", text)
+	}
+	if !strings.HasSuffix(text, "Progress: 3/3 domains scanned (1 matches, ETA --)   \n") {
+		t.Errorf("Expected the final progress line and a new line, got %q", text)
+	}
+	if strings.Count(text, "Progress:") > 2 {
+		t.Errorf("Progress should be redrawn on a timer, not per domain: %q", text)
+	}
+}
+
+func TestLiveConsoleWithoutProgress(t *testing.T) {
+	var out bytes.Buffer
+	console := newLiveConsole(&out, false, 10, time.Now())
+	console.progress(10, 0)
+	console.printf("[-] CHECKED: %s -> %s\n", "example.net", "Other Corp")
+	console.close()
+	if out.String() != "[-] CHECKED: example.net -> Other Corp\n" {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+
+	var none *liveConsole
+	none.printf("ignored")
+	none.progress(1, 1)
+	none.close()
+}
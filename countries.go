@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// countryTLDs are the TLDs companies operating in a country register
+// under, by ISO 3166-1 alpha-2 code: the ccTLD, its common commercial
+// second-level domains and the country's regional and city gTLDs
+var countryTLDs = map[string][]string{
+	"AE": {"ae", "abudhabi", "dubai"},
+	"AR": {"ar", "com.ar"},
+	"AT": {"at", "co.at", "wien", "tirol"},
+	"AU": {"au", "com.au", "net.au", "melbourne", "sydney"},
+	"BE": {"be", "brussels", "vlaanderen", "gent"},
+	"BG": {"bg"},
+	"BR": {"br", "com.br", "rio"},
+	"CA": {"ca", "quebec"},
+	"CH": {"ch", "swiss", "zuerich"},
+	"CL": {"cl"},
+	"CN": {"cn", "com.cn", "xn--fiqs8s", "xn--fiqz9s"},
+	"CO": {"co", "com.co"},
+	"CY": {"cy", "com.cy"},
+	"CZ": {"cz"},
+	"DE": {"de", "berlin", "hamburg", "koeln", "cologne", "bayern", "nrw", "ruhr", "saarland"},
+	"DK": {"dk"},
+	"EE": {"ee"},
+	"ES": {"es", "com.es", "barcelona", "madrid", "cat", "eus", "gal"},
+	"FI": {"fi"},
+	"FR": {"fr", "paris", "bzh", "alsace", "corsica"},
+	"GB": {"uk", "co.uk", "london", "wales", "cymru", "scot"},
+	"GR": {"gr", "com.gr", "xn--qxam"},
+	"HK": {"hk", "com.hk", "xn--j6w193g"},
+	"HR": {"hr"},
+	"HU": {"hu"},
+	"ID": {"id", "co.id"},
+	"IE": {"ie", "irish"},
+	"IL": {"il", "co.il"},
+	"IN": {"in", "co.in"},
+	"IS": {"is"},
+	"IT": {"it"},
+	"JP": {"jp", "co.jp", "tokyo", "osaka", "kyoto", "nagoya", "yokohama", "okinawa"},
+	"KR": {"kr", "co.kr", "seoul", "xn--3e0b707e"},
+	"LI": {"li"},
+	"LT": {"lt"},
+	"LU": {"lu"},
+	"LV": {"lv"},
+	"MT": {"mt", "com.mt"},
+	"MX": {"mx", "com.mx"},
+	"MY": {"my", "com.my"},
+	"NL": {"nl", "amsterdam", "frl"},
+	"NO": {"no"},
+	"NZ": {"nz", "co.nz"},
+	"PH": {"ph", "com.ph"},
+	"PL": {"pl", "com.pl"},
+	"PT": {"pt", "com.pt"},
+	"RO": {"ro"},
+	"RU": {"ru", "xn--p1ai", "moscow", "xn--80adxhks"},
+	"SA": {"sa", "com.sa"},
+	"SE": {"se", "stockholm"},
+	"SG": {"sg", "com.sg"},
+	"SI": {"si"},
+	"SK": {"sk"},
+	"TH": {"th", "co.th"},
+	"TR": {"tr", "com.tr", "istanbul", "ist"},
+	"TW": {"tw", "com.tw", "taipei", "xn--kpry57d"},
+	"UA": {"ua", "com.ua", "xn--j1amh"},
+	"US": {"us", "nyc", "boston", "miami", "vegas"},
+	"VN": {"vn", "com.vn"},
+	"ZA": {"za", "co.za", "capetown", "durban", "joburg"},
+}
+
+// euMembers are the member states of the European Union
+var euMembers = []string{
+	"AT", "BE", "BG", "HR", "CY", "CZ", "DK", "EE", "FI", "FR", "DE", "GR", "HU", "IE",
+	"IT", "LV", "LT", "LU", "MT", "NL", "PL", "PT", "RO", "SK", "SI", "ES", "SE",
+}
+
+// countryRegions are the groups -countries accepts besides country codes,
+// with the TLDs of the region itself and its member countries
+var countryRegions = map[string]struct {
+	tlds      []string
+	countries []string
+}{
+	"EU":  {[]string{"eu", "xn--e1a4c", "xn--qxa6a"}, euMembers},
+	"EEA": {[]string{"eu", "xn--e1a4c", "xn--qxa6a"}, append(append([]string{}, euMembers...), "IS", "LI", "NO")},
+}
+
+// countryAliases are common codes that differ from ISO 3166-1
+var countryAliases = map[string]string{
+	"UK": "GB",
+	"EL": "GR",
+}
+
+// expandCountries turns a -countries list such as "de,fr,nl,EU" into the
+// TLDs to scan, normalized like wordlist entries and without duplicates, in
+// the order the countries are listed
+func expandCountries(list string) ([]string, error) {
+	var tlds []string
+	seen := make(map[string]bool)
+	add := func(entries []string) {
+		for _, entry := range entries {
+			tld, _ := normalizeTLD(entry)
+			if !seen[tld] {
+				seen[tld] = true
+				tlds = append(tlds, tld)
+			}
+		}
+	}
+
+	for _, code := range strings.Split(list, ",") {
+		code = strings.ToUpper(strings.Trim(strings.TrimSpace(code), `"'`))
+		if code == "" {
+			continue
+		}
+		if alias, ok := countryAliases[code]; ok {
+			code = alias
+		}
+		if region, ok := countryRegions[code]; ok {
+			add(region.tlds)
+			for _, country := range region.countries {
+				add(countryTLDs[country])
+			}
+			continue
+		}
+		entries, ok := countryTLDs[code]
+		if !ok {
+			return nil, fmt.Errorf("unknown country %q in -countries (valid: ISO 3166-1 alpha-2 codes %s, or %s)", code, strings.Join(countryCodes(), ", "), strings.Join(regionNames(), ", "))
+		}
+		add(entries)
+	}
+	if len(tlds) == 0 {
+		return nil, fmt.Errorf("-countries lists no country")
+	}
+	return tlds, nil
+}
+
+// countryCodes returns the codes of countryTLDs, sorted
+func countryCodes() []string {
+	codes := make([]string, 0, len(countryTLDs))
+	for code := range countryTLDs {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// regionNames returns the names of countryRegions, sorted
+func regionNames() []string {
+	names := make([]string, 0, len(countryRegions))
+	for name := range countryRegions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateCountries checks -countries, which replaces the wordlist
+func validateCountries(config Config) error {
+	if config.Countries == "" {
+		return nil
+	}
+	if config.DomainsFile != "" {
+		return fmt.Errorf("-countries cannot be combined with -domains-file")
+	}
+	if config.Wordlist != defaultWordlist {
+		return fmt.Errorf("-countries replaces the wordlist and cannot be combined with -w")
+	}
+	_, err := expandCountries(config.Countries)
+	return err
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandCountries(t *testing.T) {
+	tlds, err := expandCountries(`de, "FR",uk,de`)
+	if err != nil {
+		t.Fatalf("expandCountries failed: %v", err)
+	}
+	expected := []string{
+		".de", ".berlin", ".hamburg", ".koeln", ".cologne", ".bayern", ".nrw", ".ruhr", ".saarland",
+		".fr", ".paris", ".bzh", ".alsace", ".corsica",
+		".uk", ".co.uk", ".london", ".wales", ".cymru", ".scot",
+	}
+	if !reflect.DeepEqual(tlds, expected) {
+		t.Errorf("expandCountries() = %v, expected %v", tlds, expected)
+	}
+
+	eu, err := expandCountries("nl,EU")
+	if err != nil {
+		t.Fatalf("expandCountries failed: %v", err)
+	}
+	if eu[0] != ".nl" || !containsString(eu, ".eu") || !containsString(eu, ".it") || !containsString(eu, ".berlin") {
+		t.Errorf("Expected the EU member and regional TLDs, got %v", eu)
+	}
+	if containsString(eu, ".uk") || containsString(eu, ".ch") {
+		t.Errorf("Expected no TLDs of non-members, got %v", eu)
+	}
+	for _, tld := range eu {
+		if _, ok := normalizeTLD(tld); !ok {
+			t.Errorf("Invalid TLD %q", tld)
+		}
+	}
+
+	for _, list := range []string{"de,xx", "", " , "} {
+		if _, err := expandCountries(list); err == nil {
+			t.Errorf("Expected an error for %q", list)
+		}
+	}
+}
+
+func TestCountryTLDsAreValid(t *testing.T) {
+	for code, tlds := range countryTLDs {
+		if len(code) != 2 || strings.ToUpper(code) != code {
+			t.Errorf("Invalid country code %q", code)
+		}
+		for _, tld := range tlds {
+			if normalized, ok := normalizeTLD(tld); !ok || normalized != "."+tld {
+				t.Errorf("%s: invalid TLD %q", code, tld)
+			}
+		}
+	}
+	for _, code := range euMembers {
+		if _, ok := countryTLDs[code]; !ok {
+			t.Errorf("No TLDs for EU member %s", code)
+		}
+	}
+}
+
+func TestValidateCountries(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{Wordlist: defaultWordlist}, false},
+		{Config{Wordlist: defaultWordlist, Countries: "de,EU"}, false},
+		{Config{Wordlist: defaultWordlist, Countries: "atlantis"}, true},
+		{Config{Wordlist: "builtin:cctld", Countries: "de"}, true},
+		{Config{Wordlist: defaultWordlist, Countries: "de", DomainsFile: "domains.txt"}, true},
+	}
+	for _, tt := range tests {
+		if err := validateCountries(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("validateCountries(%q) error = %v, wantErr %t", tt.config.Countries, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// whoisDateLayouts lists the date formats commonly returned by registries,
+// after cleanWhoisDate has translated month names to English abbreviations
+// and dropped commas. Slash dates are read day first; whoisMonthFirst
+// registries swap the two ambiguous layouts, which stay last.
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05-07",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"20060102",
+	"2006-Jan-02",
+	"02-Jan-2006 15:04:05",
+	"02-Jan-2006",
+	"2-Jan-2006",
+	"02 Jan 2006 15:04:05",
+	"2 Jan 2006",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+	"Mon Jan _2 15:04:05 2006",
+	"Mon 02 Jan 2006 15:04:05 MST",
+	"Mon Jan 2 2006",
+	"2006.01.02 15:04:05",
+	"2006.01.02",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+	"2.1.2006 15:04:05",
+	"2.1.2006",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"02-01-2006",
+	"02/01/2006 15:04:05",
+	"02/01/2006",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+}
+
+// whoisMonthFirst are the TLDs whose registries write slash dates month
+// first, as in 01/31/2024
+var whoisMonthFirst = map[string]bool{"us": true}
+
+// whoisZones are the offsets of the zone abbreviations registries write
+// after a time. time.Parse reads an abbreviation it does not know as UTC,
+// so dates in any other zone, including ambiguous ones such as IST or CST,
+// are rejected rather than shifted.
+var whoisZones = map[string]int{
+	"UTC": 0, "GMT": 0,
+	"CET": 1 * 3600, "CEST": 2 * 3600, "EET": 2 * 3600, "EEST": 3 * 3600, "MSK": 3 * 3600,
+	"JST": 9 * 3600, "KST": 9 * 3600,
+	"AEST": 10 * 3600, "AEDT": 11 * 3600,
+	"EST": -5 * 3600, "EDT": -4 * 3600, "PST": -8 * 3600, "PDT": -7 * 3600,
+	"CLT": -4 * 3600, "CLST": -3 * 3600, "BRT": -3 * 3600,
+}
+
+// whoisMonths maps the month names and abbreviations of English, German,
+// French, Spanish, Portuguese, Italian and Dutch registries to the English
+// abbreviation time.Parse reads
+var whoisMonths = map[string]string{
+	"january": "Jan", "januar": "Jan", "janvier": "Jan", "enero": "Jan", "janeiro": "Jan", "gennaio": "Jan", "januari": "Jan", "jan": "Jan", "janv": "Jan", "ene": "Jan", "gen": "Jan",
+	"february": "Feb", "februar": "Feb", "février": "Feb", "fevrier": "Feb", "febrero": "Feb", "fevereiro": "Feb", "febbraio": "Feb", "februari": "Feb", "feb": "Feb", "févr": "Feb", "fevr": "Feb", "fév": "Feb", "fev": "Feb",
+	"march": "Mar", "märz": "Mar", "maerz": "Mar", "mars": "Mar", "marzo": "Mar", "março": "Mar", "marco": "Mar", "maart": "Mar", "mar": "Mar", "mär": "Mar", "mrz": "Mar", "mrt": "Mar",
+	"april": "Apr", "avril": "Apr", "abril": "Apr", "aprile": "Apr", "apr": "Apr", "avr": "Apr", "abr": "Apr",
+	"may": "May", "mai": "May", "mayo": "May", "maio": "May", "maggio": "May", "mei": "May", "mag": "May",
+	"june": "Jun", "juni": "Jun", "juin": "Jun", "junio": "Jun", "junho": "Jun", "giugno": "Jun", "jun": "Jun", "giu": "Jun",
+	"july": "Jul", "juli": "Jul", "juillet": "Jul", "julio": "Jul", "julho": "Jul", "luglio": "Jul", "jul": "Jul", "juil": "Jul", "lug": "Jul",
+	"august": "Aug", "août": "Aug", "aout": "Aug", "agosto": "Aug", "augustus": "Aug", "aug": "Aug", "ago": "Aug",
+	"september": "Sep", "septembre": "Sep", "septiembre": "Sep", "setembro": "Sep", "settembre": "Sep", "sep": "Sep", "sept": "Sep", "set": "Sep",
+	"october": "Oct", "oktober": "Oct", "octobre": "Oct", "octubre": "Oct", "outubro": "Oct", "ottobre": "Oct", "oct": "Oct", "okt": "Oct", "out": "Oct", "ott": "Oct",
+	"november": "Nov", "novembre": "Nov", "noviembre": "Nov", "novembro": "Nov", "nov": "Nov",
+	"december": "Dec", "dezember": "Dec", "décembre": "Dec", "decembre": "Dec", "diciembre": "Dec", "dezembro": "Dec", "dicembre": "Dec", "dec": "Dec", "dez": "Dec", "déc": "Dec", "dic": "Dec",
+}
+
+var (
+	// whoisDateNote matches a trailing note, as in "(JST)", "(UTC+8)" or
+	// "(YYYY-MM-DD)", or a registry reference, as in "#585123"
+	whoisDateNote = regexp.MustCompile(`\s*(\(([^)]*)\)|#.*)$`)
+	// whoisNoteOffset matches the offset of a zone note, as in "UTC+8"
+	whoisNoteOffset = regexp.MustCompile(`^(?:UTC|GMT)\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+	// whoisDottedDate matches Korean style dates, as in "2024. 01. 31."
+	whoisDottedDate = regexp.MustCompile(`^(\d{4})\.\s*(\d{1,2})\.\s*(\d{1,2})\.?`)
+	// whoisDayDot matches the dot of a German day, as in "15. März 2020"
+	whoisDayDot = regexp.MustCompile(`(\d)\.\s+(\p{L})`)
+	// whoisDateWord matches the words of a date, months and weekdays
+	whoisDateWord = regexp.MustCompile(`\p{L}+\.?`)
+)
+
+// cleanWhoisDate rewrites a raw date into the shapes of whoisDateLayouts:
+// localized month names become English abbreviations, zone notes, registry
+// references, commas, a trailing dot and a trailing "UTC" or "GMT" are
+// dropped
+func cleanWhoisDate(s string) string {
+	s = strings.TrimSpace(s)
+	if m := whoisDateNote.FindStringSubmatchIndex(s); m != nil {
+		zone := ""
+		if m[4] >= 0 && strings.Contains(s[:m[0]], ":") {
+			zone = noteZone(s[m[4]:m[5]])
+		}
+		s = strings.TrimSpace(s[:m[0]]) + zone
+	}
+	if m := whoisDottedDate.FindStringSubmatch(s); m != nil {
+		s = fmt.Sprintf("%s.%02s.%02s", m[1], m[2], m[3]) + s[len(m[0]):]
+	}
+	s = whoisDayDot.ReplaceAllString(strings.ReplaceAll(s, ",", " "), "$1 $2")
+	s = whoisDateWord.ReplaceAllStringFunc(s, func(word string) string {
+		if month, ok := whoisMonths[strings.ToLower(strings.TrimSuffix(word, "."))]; ok {
+			return month
+		}
+		return word
+	})
+	s = strings.TrimSuffix(strings.Join(strings.Fields(s), " "), ".")
+	for _, zone := range []string{" UTC", " GMT"} {
+		s = strings.TrimSuffix(s, zone)
+	}
+	return s
+}
+
+// noteZone returns the zone of a time's note, " JST" or " +08:00", empty
+// when the note names no zone
+func noteZone(note string) string {
+	note = strings.ToUpper(strings.TrimSpace(note))
+	if _, ok := whoisZones[note]; ok {
+		return " " + note
+	}
+	if m := whoisNoteOffset.FindStringSubmatch(note); m != nil {
+		minutes := m[3]
+		if minutes == "" {
+			minutes = "00"
+		}
+		return fmt.Sprintf(" %s%02s:%s", m[1], m[2], minutes)
+	}
+	return ""
+}
+
+// parseWhoisDate parses a raw WHOIS date string using the known layouts
+func parseWhoisDate(s string) (time.Time, bool) {
+	return parseWhoisDateFor(s, "")
+}
+
+// parseWhoisDateFor parses a raw WHOIS date of domain, reading ambiguous
+// slash dates in the order of the domain's registry. Dates before 1970 or
+// after 2200 are rejected as misparsed, and so are times in a zone missing
+// from whoisZones.
+func parseWhoisDateFor(s, domain string) (time.Time, bool) {
+	s = cleanWhoisDate(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	layouts := whoisDateLayouts
+	if whoisMonthFirst[lastLabel(domain)] {
+		layouts = append([]string(nil), whoisDateLayouts...)
+		n := len(layouts)
+		layouts[n-4], layouts[n-3], layouts[n-2], layouts[n-1] = layouts[n-2], layouts[n-1], layouts[n-4], layouts[n-3]
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			if t.Year() < 1970 || t.Year() > 2200 {
+				return time.Time{}, false
+			}
+			return inWhoisZone(t)
+		}
+	}
+	return time.Time{}, false
+}
+
+// inWhoisZone places a time parsed with a zone abbreviation in that zone.
+// Times with a numeric offset or without a zone are returned as parsed.
+func inWhoisZone(t time.Time) (time.Time, bool) {
+	name, offset := t.Zone()
+	if offset != 0 || name == "" || name == "UTC" {
+		return t, true
+	}
+	zoneOffset, ok := whoisZones[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, zoneOffset)), true
+}
+
+// normalizeDates sets the RFC 3339 forms of a record's creation and expiry
+// dates, in UTC, keeping the raw registry strings
+func normalizeDates(info *DomainInfo) {
+	info.CreatedAt, info.ExpiresAt = nil, nil
+	if t, ok := parseWhoisDateFor(info.CreatedDate, info.Domain); ok {
+		t = t.UTC()
+		info.CreatedAt = &t
+	}
+	if t, ok := parseWhoisDateFor(info.ExpiryDate, info.Domain); ok {
+		t = t.UTC()
+		info.ExpiresAt = &t
+	}
+}
+
+// created returns the creation date of a record, parsing the raw one for
+// records saved before dates were normalized
+func (d DomainInfo) created() (time.Time, bool) {
+	if d.CreatedAt != nil {
+		return *d.CreatedAt, true
+	}
+	return parseWhoisDateFor(d.CreatedDate, d.Domain)
+}
+
+// expires returns the expiry date of a record like created
+func (d DomainInfo) expires() (time.Time, bool) {
+	if d.ExpiresAt != nil {
+		return *d.ExpiresAt, true
+	}
+	return parseWhoisDateFor(d.ExpiryDate, d.Domain)
+}
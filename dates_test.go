@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWhoisDateFormats(t *testing.T) {
+	tests := []struct {
+		input, domain, want string
+	}{
+		{"2020-01-15T10:30:00.123Z", "example.com", "2020-01-15T10:30:00.123Z"},
+		{"2020-01-15 10:30:00+03:00", "example.ee", "2020-01-15T07:30:00Z"},
+		{"2020-01-15 10:30:00 (JST)", "example.jp", "2020-01-15T01:30:00Z"},
+		{"2020-01-15 10:30:00 (UTC+8)", "example.cn", "2020-01-15T02:30:00Z"},
+		{"2020-01-15 (YYYY-MM-DD)", "example.com.tw", "2020-01-15T00:00:00Z"},
+		{"2020/01/15", "example.jp", "2020-01-15T00:00:00Z"},
+		{"2020. 1. 5.", "example.kr", "2020-01-05T00:00:00Z"},
+		{"20200115", "example.com.br", "2020-01-15T00:00:00Z"},
+		{"15.01.2020 10:30:00", "example.cz", "2020-01-15T10:30:00Z"},
+		{"15-Jan-2020", "example.uk", "2020-01-15T00:00:00Z"},
+		{"January 15, 2020", "example.com", "2020-01-15T00:00:00Z"},
+		{"15 janvier 2020", "example.fr", "2020-01-15T00:00:00Z"},
+		{"15. März 2020", "example.at", "2020-03-15T00:00:00Z"},
+		{"15-dic-2020", "example.es", "2020-12-15T00:00:00Z"},
+		{"Wed Jan 15 10:30:00 GMT 2020", "example.be", "2020-01-15T10:30:00Z"},
+		{"03/04/2020", "example.fr", "2020-04-03T00:00:00Z"},
+		{"03/04/2020", "example.us", "2020-03-04T00:00:00Z"},
+		{"12/31/2020", "example.fr", "2020-12-31T00:00:00Z"},
+		{"2020-01-15T10:30:00+0000", "example.com", "2020-01-15T10:30:00Z"},
+		{"2020-01-15 10:30:00+03", "example.ua", "2020-01-15T07:30:00Z"},
+		{"5.1.2020 10:30:00", "example.fi", "2020-01-05T10:30:00Z"},
+		{"2020-Jan-15.", "example.com.tr", "2020-01-15T00:00:00Z"},
+		{"Wed Jan 15 2020", "example.be", "2020-01-15T00:00:00Z"},
+		{"20200115 #585123", "example.com.br", "2020-01-15T00:00:00Z"},
+		{"2020-01-15 10:30:00 CLST", "example.cl", "2020-01-15T13:30:00Z"},
+	}
+	for _, tt := range tests {
+		got, ok := parseWhoisDateFor(tt.input, tt.domain)
+		if !ok {
+			t.Errorf("parseWhoisDateFor(%q, %s) failed", tt.input, tt.domain)
+			continue
+		}
+		if formatted := got.UTC().Format(time.RFC3339Nano); formatted != tt.want {
+			t.Errorf("parseWhoisDateFor(%q, %s) = %s, want %s", tt.input, tt.domain, formatted, tt.want)
+		}
+	}
+
+	for _, input := range []string{"before Aug-1996", "0001-01-01T00:00:00Z", "n/a", "2020-01-15 10:30:00 XYZT"} {
+		if got, ok := parseWhoisDate(input); ok {
+			t.Errorf("parseWhoisDate(%q) = %s, want a failure", input, got)
+		}
+	}
+}
+
+func TestNormalizeDates(t *testing.T) {
+	info := DomainInfo{Domain: "example.fr", CreatedDate: "15 janvier 2020", ExpiryDate: "unknown"}
+	normalizeDates(&info)
+	if info.CreatedAt == nil || !info.CreatedAt.Equal(time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)) || info.ExpiresAt != nil {
+		t.Errorf("Unexpected normalized dates %v, %v", info.CreatedAt, info.ExpiresAt)
+	}
+	if info.CreatedDate != "15 janvier 2020" {
+		t.Errorf("The raw creation date should be kept, got %q", info.CreatedDate)
+	}
+	if created, ok := info.created(); !ok || created.Year() != 2020 {
+		t.Errorf("created() = %s, %t", created, ok)
+	}
+
+	// Records saved before normalization parse their raw dates
+	saved := DomainInfo{Domain: "example.de", ExpiryDate: "2030-06-01"}
+	if expires, ok := saved.expires(); !ok || expires.Year() != 2030 {
+		t.Errorf("expires() = %s, %t", expires, ok)
+	}
+}
+
+func TestSortByNormalizedDates(t *testing.T) {
+	order, err := parseSortOrder("created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	domains := []DomainInfo{
+		{Domain: "example.jp", CreatedDate: "2021/03/01"},
+		{Domain: "example.fr", CreatedDate: "1 février 2021"},
+		{Domain: "example.uk", CreatedDate: "15-Jan-2021"},
+	}
+	sorted := order.sortDomains(domains)
+	if sorted[0].Domain != "example.uk" || sorted[1].Domain != "example.fr" || sorted[2].Domain != "example.jp" {
+		t.Errorf("Unexpected order %s, %s, %s", sorted[0].Domain, sorted[1].Domain, sorted[2].Domain)
+	}
+}
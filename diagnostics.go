@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+)
+
+// startDiagnostics starts the runtime diagnostics asked for: net/http/pprof
+// on -pprof and goroutine and heap snapshots every -pprof-snapshot. Both run
+// until the process exits.
+func startDiagnostics(config Config) error {
+	if config.Pprof != "" {
+		ln, err := startPprof(config.Pprof)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Serving pprof on http://%s/debug/pprof/\n", ColorBlue, ColorReset, ln.Addr())
+	}
+	if config.PprofSnapshot > 0 {
+		if err := os.MkdirAll(config.PprofDir, 0755); err != nil {
+			return fmt.Errorf("failed to create pprof snapshot directory: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Writing goroutine and heap snapshots to %s every %s\n", ColorBlue, ColorReset, config.PprofDir, config.PprofSnapshot)
+		go func() {
+			for now := range time.Tick(config.PprofSnapshot) {
+				if err := snapshotProfiles(config.PprofDir, now); err != nil {
+					fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// startPprof serves the net/http/pprof handlers on addr. The handlers get a
+// mux of their own so they are never exposed on the serve mode API.
+func startPprof(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for pprof: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go http.Serve(ln, mux)
+	return ln, nil
+}
+
+// snapshotProfiles writes the stacks of every goroutine, with what each one
+// is blocked on, and a heap profile into dir, named by the time taken
+func snapshotProfiles(dir string, now time.Time) error {
+	stamp := now.Format("20060102-150405")
+	goroutines, err := os.Create(filepath.Join(dir, "goroutine-"+stamp+".txt"))
+	if err != nil {
+		return fmt.Errorf("failed to write goroutine snapshot: %w", err)
+	}
+	defer goroutines.Close()
+	if err := runtimepprof.Lookup("goroutine").WriteTo(goroutines, 2); err != nil {
+		return fmt.Errorf("failed to write goroutine snapshot: %w", err)
+	}
+
+	heap, err := os.Create(filepath.Join(dir, "heap-"+stamp+".pb.gz"))
+	if err != nil {
+		return fmt.Errorf("failed to write heap snapshot: %w", err)
+	}
+	defer heap.Close()
+	// Collect first so the profile reflects live objects
+	runtime.GC()
+	if err := runtimepprof.WriteHeapProfile(heap); err != nil {
+		return fmt.Errorf("failed to write heap snapshot: %w", err)
+	}
+	return nil
+}
+
+// validateDiagnostics checks the diagnostics options
+func validateDiagnostics(config Config) error {
+	if config.PprofSnapshot < 0 {
+		return fmt.Errorf("-pprof-snapshot must not be negative")
+	}
+	if config.PprofSnapshot > 0 && config.PprofDir == "" {
+		return fmt.Errorf("-pprof-snapshot needs a -pprof-dir")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartPprof(t *testing.T) {
+	ln, err := startPprof("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine profile") {
+		t.Errorf("Expected a goroutine profile, got %d %.100s", resp.StatusCode, body)
+	}
+
+	if _, err := startPprof(ln.Addr().String()); err == nil {
+		t.Error("Expected an address in use to fail")
+	}
+}
+
+func TestSnapshotProfiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	if err := snapshotProfiles(dir, now); err != nil {
+		t.Fatal(err)
+	}
+	goroutines, err := os.ReadFile(filepath.Join(dir, "goroutine-20260304-100000.txt"))
+	if err != nil || !strings.Contains(string(goroutines), "TestSnapshotProfiles") {
+		t.Errorf("Expected the goroutine stacks, got %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "heap-20260304-100000.pb.gz")); err != nil || info.Size() == 0 {
+		t.Errorf("Expected a heap profile, got %v", err)
+	}
+}
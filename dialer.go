@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*s = append(*s, v)
+		}
+	}
+	return nil
+}
+
+// sourceIndex rotates WHOIS connections across the configured source addresses
+var sourceIndex uint64
+
+// newDialer returns the dialer for the next WHOIS connection. When source
+// addresses are configured each call binds the next one in round-robin order,
+// which also restricts the connection to that address family.
+func newDialer(sourceIPs []string, timeout time.Duration) proxy.Dialer {
+	dialer := &net.Dialer{Timeout: timeout}
+	if len(sourceIPs) == 0 {
+		return dialer
+	}
+
+	i := atomic.AddUint64(&sourceIndex, 1) - 1
+	ip := net.ParseIP(sourceIPs[i%uint64(len(sourceIPs))])
+	dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	return dialer
+}
+
+// validateSourceIPs checks that every -source-ip is a valid address assigned
+// to a local interface
+func validateSourceIPs(sourceIPs []string) error {
+	if len(sourceIPs) == 0 {
+		return nil
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return fmt.Errorf("failed to list local addresses: %w", err)
+	}
+	local := map[string]bool{}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			local[ipNet.IP.String()] = true
+		}
+	}
+
+	var problems []string
+	for _, s := range sourceIPs {
+		ip := net.ParseIP(s)
+		switch {
+		case ip == nil:
+			problems = append(problems, fmt.Sprintf("%q is not an IP address", s))
+		case !local[ip.String()]:
+			problems = append(problems, fmt.Sprintf("%s is not assigned to a local interface", s))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid -source-ip: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
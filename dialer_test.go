@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStringListSet(t *testing.T) {
+	var list stringList
+	list.Set("192.0.2.1")
+	list.Set("2001:db8::1, 192.0.2.2")
+
+	expected := stringList{"192.0.2.1", "2001:db8::1", "192.0.2.2"}
+	if !reflect.DeepEqual(list, expected) {
+		t.Errorf("stringList = %v; expected %v", list, expected)
+	}
+	if list.String() != "192.0.2.1,2001:db8::1,192.0.2.2" {
+		t.Errorf("String() = %q", list.String())
+	}
+}
+
+func TestNewDialerRotatesSourceAddresses(t *testing.T) {
+	sources := []string{"127.0.0.1", "::1"}
+	seen := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		dialer := newDialer(sources, time.Second).(*net.Dialer)
+		addr, ok := dialer.LocalAddr.(*net.TCPAddr)
+		if !ok {
+			t.Fatalf("Dialer has no local address bound")
+		}
+		seen[addr.IP.String()] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected rotation across both sources, saw %v", seen)
+	}
+
+	if dialer := newDialer(nil, time.Second).(*net.Dialer); dialer.LocalAddr != nil {
+		t.Error("Dialer without sources should not bind a local address")
+	}
+}
+
+func TestValidateSourceIPs(t *testing.T) {
+	if err := validateSourceIPs(nil); err != nil {
+		t.Errorf("No source addresses should be valid: %v", err)
+	}
+	if err := validateSourceIPs([]string{"127.0.0.1"}); err != nil {
+		t.Errorf("Loopback should be a local address: %v", err)
+	}
+	if err := validateSourceIPs([]string{"not-an-ip"}); err == nil {
+		t.Error("Expected error for an invalid address")
+	}
+	if err := validateSourceIPs([]string{"192.0.2.123"}); err == nil {
+		t.Error("Expected error for an address not assigned locally")
+	}
+}
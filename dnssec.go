@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSSEC states recorded on domains
+const (
+	// DNSSECSigned: a DS record is published at the parent and the zone's
+	// keys validate
+	DNSSECSigned = "signed"
+	// DNSSECUnsigned: no DS record at the parent
+	DNSSECUnsigned = "unsigned"
+	// DNSSECBroken: a DS record is published but validation fails, which
+	// makes the domain unresolvable for validating resolvers
+	DNSSECBroken = "broken"
+)
+
+// DS and DNSKEY record types, which dnsmessage has no names for
+const (
+	dnsTypeDS     dnsmessage.Type = 43
+	dnsTypeDNSKEY dnsmessage.Type = 48
+)
+
+// errResolverNotValidating is returned when -dnssec-resolver answers for a
+// signed zone without setting the authenticated data bit
+var errResolverNotValidating = errors.New("the -dnssec-resolver does not validate DNSSEC")
+
+// checkDNSSEC reports the DNSSEC state of domain as seen by the validating
+// resolver -dnssec-resolver
+func checkDNSSEC(domain string, config Config) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+
+	ds, err := dnsQuery(ctx, domain, dnsTypeDS, config)
+	if err != nil {
+		return "", err
+	}
+	switch ds.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeServerFailure:
+		// A validating resolver fails queries it cannot validate
+		return DNSSECBroken, nil
+	default:
+		return "", fmt.Errorf("DS query for %s: %s", domain, ds.RCode)
+	}
+	if !hasAnswer(ds, dnsTypeDS) {
+		return DNSSECUnsigned, nil
+	}
+	if !ds.AuthenticData {
+		return "", errResolverNotValidating
+	}
+
+	keys, err := dnsQuery(ctx, domain, dnsTypeDNSKEY, config)
+	if err != nil {
+		return "", err
+	}
+	if keys.RCode != dnsmessage.RCodeSuccess || !keys.AuthenticData || !hasAnswer(keys, dnsTypeDNSKEY) {
+		return DNSSECBroken, nil
+	}
+	return DNSSECSigned, nil
+}
+
+// hasAnswer reports whether msg answers with a record of type qtype
+func hasAnswer(msg *dnsmessage.Message, qtype dnsmessage.Type) bool {
+	for _, answer := range msg.Answers {
+		if answer.Header.Type == qtype {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsQuery sends one recursive query with the DNSSEC OK bit over TCP to
+// -dnssec-resolver, through Tor with -tor
+func dnsQuery(ctx context.Context, name string, qtype dnsmessage.Type, config Config) (*dnsmessage.Message, error) {
+	qname, err := dnsmessage.NewName(strings.TrimSuffix(name, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, true); err != nil {
+		return nil, err
+	}
+	query := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: uint16(rand.Intn(1 << 16)), RecursionDesired: true, AuthenticData: true},
+		Questions:   []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+		Additionals: []dnsmessage.Resource{{Header: opt, Body: &dnsmessage.OPTResource{}}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if config.TorDialer != nil {
+		conn, err = config.TorDialer.DialContext(ctx, "tcp", config.DNSSECResolver)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", config.DNSSECResolver)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach DNSSEC resolver: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// DNS over TCP prefixes each message with its length
+	if _, err := conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...)); err != nil {
+		return nil, err
+	}
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	answer := make([]byte, length)
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return nil, err
+	}
+	var resp dnsmessage.Message
+	if err := resp.Unpack(answer); err != nil {
+		return nil, fmt.Errorf("invalid DNS response: %w", err)
+	}
+	if resp.ID != query.ID {
+		return nil, fmt.Errorf("DNS response ID mismatch")
+	}
+	return &resp, nil
+}
+
+// enrichDNSSEC records the DNSSEC state of a domain
+func enrichDNSSEC(info *DomainInfo, config Config) error {
+	status, err := checkDNSSEC(info.Domain, config)
+	if err != nil {
+		return err
+	}
+	info.DNSSEC = status
+	return nil
+}
+
+// targetDNSSEC checks the target domain with -dnssec, warning when the
+// check fails
+func targetDNSSEC(config Config) string {
+	if !config.DNSSEC {
+		return ""
+	}
+	status, err := checkDNSSEC(config.Domain, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s DNSSEC check of %s failed: %v\n", ColorYellow, ColorReset, config.Domain, err)
+	}
+	return status
+}
+
+// validateDNSSEC checks the -dnssec-resolver address used by -dnssec and
+// -caa
+func validateDNSSEC(config Config) error {
+	if !config.DNSSEC && !config.CAA {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(config.DNSSECResolver); err != nil {
+		return fmt.Errorf("invalid -dnssec-resolver %q: expected host:port", config.DNSSECResolver)
+	}
+	return nil
+}
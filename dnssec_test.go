@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeZone is how the fake resolver answers for one domain
+type fakeZone struct {
+	ds, dnskey bool
+	rcode      dnsmessage.RCode
+	validated  bool
+	caa        []CAARecord
+}
+
+// newFakeResolver serves DNS over TCP, answering DS, DNSKEY and CAA queries
+// from zones. DS and DNSKEY answers carry no record data; only their types
+// are checked.
+func newFakeResolver(t *testing.T, zones map[string]fakeZone) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				var length uint16
+				if binary.Read(conn, binary.BigEndian, &length) != nil {
+					return
+				}
+				packed := make([]byte, length)
+				if _, err := io.ReadFull(conn, packed); err != nil {
+					return
+				}
+				var query dnsmessage.Message
+				if query.Unpack(packed) != nil {
+					return
+				}
+				question := query.Questions[0]
+				zone := zones[question.Name.String()]
+				resp := dnsmessage.Message{
+					Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: zone.rcode, AuthenticData: zone.validated},
+					Questions: query.Questions,
+				}
+				answer := func(data []byte) {
+					resp.Answers = append(resp.Answers, dnsmessage.Resource{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: question.Type, Class: dnsmessage.ClassINET, TTL: 300},
+						Body:   &dnsmessage.UnknownResource{Type: question.Type, Data: data},
+					})
+				}
+				if (question.Type == dnsTypeDS && zone.ds) || (question.Type == dnsTypeDNSKEY && zone.dnskey) {
+					answer([]byte{0})
+				}
+				if question.Type == dnsTypeCAA {
+					for _, record := range zone.caa {
+						answer(append([]byte{record.Flags, byte(len(record.Tag))}, record.Tag+record.Value...))
+					}
+				}
+				packed, err := resp.Pack()
+				if err != nil {
+					return
+				}
+				conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(packed))), packed...))
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCheckDNSSEC(t *testing.T) {
+	resolver := newFakeResolver(t, map[string]fakeZone{
+		"signed.example.":   {ds: true, dnskey: true, validated: true},
+		"unsigned.example.": {},
+		"bogus.example.":    {rcode: dnsmessage.RCodeServerFailure},
+		"nokeys.example.":   {ds: true, validated: true},
+	})
+	config := Config{Timeout: 5, DNSSEC: true, DNSSECResolver: resolver}
+
+	tests := map[string]string{
+		"signed.example":   DNSSECSigned,
+		"unsigned.example": DNSSECUnsigned,
+		"bogus.example":    DNSSECBroken,
+		"nokeys.example":   DNSSECBroken,
+	}
+	for domain, want := range tests {
+		got, err := checkDNSSEC(domain, config)
+		if err != nil {
+			t.Errorf("checkDNSSEC(%s) failed: %v", domain, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Expected %s to be %q, got %q", domain, want, got)
+		}
+	}
+}
+
+func TestCheckDNSSECNonValidatingResolver(t *testing.T) {
+	resolver := newFakeResolver(t, map[string]fakeZone{
+		"signed.example.": {ds: true, dnskey: true},
+	})
+	_, err := checkDNSSEC("signed.example", Config{Timeout: 5, DNSSECResolver: resolver})
+	if !errors.Is(err, errResolverNotValidating) {
+		t.Errorf("Expected errResolverNotValidating, got %v", err)
+	}
+}
+
+func TestEnrichDNSSEC(t *testing.T) {
+	resolver := newFakeResolver(t, map[string]fakeZone{
+		"example.net.": {ds: true, dnskey: true, validated: true},
+	})
+	info := &DomainInfo{Domain: "example.net"}
+	if err := enrichDNSSEC(info, Config{Timeout: 5, DNSSECResolver: resolver}); err != nil {
+		t.Fatalf("enrichDNSSEC failed: %v", err)
+	}
+	if info.DNSSEC != DNSSECSigned {
+		t.Errorf("Expected DNSSEC %q, got %q", DNSSECSigned, info.DNSSEC)
+	}
+}
+
+func TestValidateDNSSEC(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{DNSSEC: true, DNSSECResolver: "1.1.1.1:53"}, false},
+		{Config{DNSSEC: true, DNSSECResolver: "1.1.1.1"}, true},
+		{Config{DNSSECResolver: "1.1.1.1"}, false},
+	}
+	for _, tt := range tests {
+		if err := validateDNSSEC(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("validateDNSSEC(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
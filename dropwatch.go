@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dropWatchBucket holds a dropWatchState per watched domain
+var dropWatchBucket = []byte("dropwatch")
+
+// Drop phases of a watched domain, in the order a lapsing domain goes
+// through them. The registry deletes a domain five days into pendingDelete;
+// from then on it is available.
+const (
+	phaseRegistered = "registered"
+	phaseExpired    = "expired"
+	phaseRedemption = "redemptionPeriod"
+	phaseRestore    = "pendingRestore"
+	phaseDelete     = "pendingDelete"
+	phaseAvailable  = "available"
+	phaseRenewed    = "renewed"
+)
+
+// dropAlertPhases are the phases whose start raises an alert
+var dropAlertPhases = []string{phaseRedemption, phaseDelete, phaseAvailable}
+
+// dropWatchState is the last phase seen for a watched domain
+type dropWatchState struct {
+	Phase  string    `json:"phase"`
+	Since  time.Time `json:"since"`
+	Expiry string    `json:"expiry,omitempty"`
+}
+
+// dropWatchDomains returns the domains of a result worth watching for a
+// drop: matches and lookalikes scored at least minRisk that expire within
+// window of now or have already expired
+func dropWatchDomains(result Result, minRisk int, window time.Duration, now time.Time) []DomainInfo {
+	expiring := func(info DomainInfo) bool {
+		expires, ok := info.expires()
+		return ok && expires.Before(now.Add(window))
+	}
+	var domains []DomainInfo
+	for _, info := range result.MatchingDomains {
+		if expiring(info) {
+			domains = append(domains, info)
+		}
+	}
+	for _, info := range result.Lookalikes {
+		if info.RiskScore >= minRisk && expiring(info) {
+			domains = append(domains, info)
+		}
+	}
+	return domains
+}
+
+// dropPhase classifies a fresh lookup of a watched domain. A domain whose
+// expiry moved beyond window was renewed and is no longer worth watching.
+func dropPhase(info *DomainInfo, err error, window time.Duration, now time.Time) string {
+	if err != nil {
+		if errorCodeOf(err) == ErrNXDomain {
+			return phaseAvailable
+		}
+		return ""
+	}
+	codes := parseEPPStatus(info.Status)
+	for _, phase := range []string{phaseDelete, phaseRestore, phaseRedemption} {
+		if containsString(codes, phase) {
+			return phase
+		}
+	}
+	expires, ok := info.expires()
+	switch {
+	case !ok:
+		return phaseRegistered
+	case expires.Before(now):
+		return phaseExpired
+	case !expires.Before(now.Add(window)):
+		return phaseRenewed
+	}
+	return phaseRegistered
+}
+
+// updateDropPhase records the phase of a domain and returns the phase
+// recorded before, empty for a domain not watched yet
+func (h *historyStore) updateDropPhase(domain, phase, expiry string, now time.Time) (string, error) {
+	var previous string
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(dropWatchBucket)
+		if err != nil {
+			return err
+		}
+		state := dropWatchState{Phase: phase, Since: now.UTC(), Expiry: expiry}
+		if data := bucket.Get([]byte(domain)); data != nil {
+			var old dropWatchState
+			if err := json.Unmarshal(data, &old); err != nil {
+				return err
+			}
+			previous = old.Phase
+			if old.Phase == phase {
+				state.Since = old.Since
+			}
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(domain), data)
+	})
+	return previous, err
+}
+
+// dropWatchCycle looks every domain up once and returns the alerts for the
+// domains entering an alerting phase along with the domains still worth
+// watching. Domains that were renewed or dropped are not watched further.
+// Failed lookups keep the domain watched without a phase change.
+func dropWatchCycle(ctx context.Context, store *historyStore, domains []DomainInfo, lookup func(string, Config) (*DomainInfo, error), limiter *rateLimiter, window time.Duration, config Config) ([]Alert, []DomainInfo, error) {
+	var alerts []Alert
+	var watching []DomainInfo
+	for _, watched := range domains {
+		if err := limiter.Wait(ctx, watched.Domain); err != nil {
+			return alerts, append(watching, watched), err
+		}
+		now := time.Now()
+		info, err := lookup(watched.Domain, config)
+		phase := dropPhase(info, err, window, now)
+		if phase == "" {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s: %v\n", ColorYellow, ColorReset, watched.Domain, err)
+			watching = append(watching, watched)
+			continue
+		}
+		expiry := watched.ExpiryDate
+		if info != nil {
+			expiry = info.ExpiryDate
+		}
+		previous, err := store.updateDropPhase(watched.Domain, phase, expiry, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to record the drop phase: %w", err)
+		}
+		if previous != phase && containsString(dropAlertPhases, phase) {
+			alerts = append(alerts, Alert{
+				Kind:    AlertDropPhase,
+				Domain:  watched.Domain,
+				Changes: []FieldChange{{Field: "drop_phase", Old: previous, New: phase}},
+			})
+		}
+		if config.Verbose {
+			fmt.Printf("%s[INFO]%s %s: %s\n", ColorBlue, ColorReset, watched.Domain, phase)
+		}
+		if phase != phaseRenewed && phase != phaseAvailable {
+			watching = append(watching, watched)
+		}
+	}
+	return alerts, watching, nil
+}
+
+// runDropWatch implements `tldscanner dropwatch <results.json>`: the
+// matches and high-risk lookalikes of a result that are about to expire
+// are looked up every -interval, alerting when one enters redemption or
+// pendingDelete or is deleted, so it can be recovered when it drops
+func runDropWatch(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("dropwatch", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	minRisk := fs.Int("min-risk", 50, "Risk score (0-100) from which lookalikes are watched; matches always are")
+	days := fs.Int("days", 60, "Watch domains expiring within this many days, or already expired")
+	once := fs.Bool("once", false, "Look the domains up once and exit, e.g. from cron")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s dropwatch [OPTIONS] <results.json>\n\n", os.Args[0])
+		fmt.Printf("Watches the matches and high-risk lookalikes of a scan result that are\n")
+		fmt.Printf("about to expire. Each domain is looked up every -interval (24h by\n")
+		fmt.Printf("default) and an alert is raised when it enters redemptionPeriod or\n")
+		fmt.Printf("pendingDelete or is deleted, through the configured notifications. The\n")
+		fmt.Printf("phases are kept in the -history-db database.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+
+	result, err := readResult(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	config.Domain = result.TargetDomain
+	if *days <= 0 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s -days must be positive\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	if config.Interval < time.Minute {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s -interval must be at least 1m\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := resolveCredentials(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	window := time.Duration(*days) * 24 * time.Hour
+	domains := dropWatchDomains(result, *minRisk, window, time.Now())
+	if len(domains) == 0 {
+		fmt.Printf("%s[INFO]%s No matches or lookalikes of %s expire within %d days\n", ColorBlue, ColorReset, result.TargetDomain, *days)
+		return ExitMatches
+	}
+
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	limiter := newRateLimiter(config.RateLimit, config.Burst, config.RateScope)
+	limiter.policies = !config.NoRegistryPolicy
+
+	for {
+		fmt.Printf("%s[INFO]%s Checking %d expiring domains of %s...\n", ColorBlue, ColorReset, len(domains), result.TargetDomain)
+		alerts, watching, err := dropWatchCycle(ctx, store, domains, getWhoisInfo, limiter, window, config)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		for _, alert := range alerts {
+			fmt.Fprintf(os.Stderr, "%s[ALERT]%s %s\n", ColorRed, ColorReset, alert)
+		}
+		sendNotifications(config, Notification{
+			Result:  Result{TargetDomain: result.TargetDomain, TargetOrg: result.TargetOrg},
+			Alerts:  alerts,
+			Monitor: true,
+		})
+		domains = watching
+
+		if *once || ctx.Err() != nil {
+			return ExitMatches
+		}
+		if len(domains) == 0 {
+			fmt.Printf("%s[INFO]%s Every watched domain was renewed or dropped\n", ColorBlue, ColorReset)
+			return ExitMatches
+		}
+		fmt.Printf("%s[INFO]%s Next check at %s\n", ColorBlue, ColorReset, time.Now().Add(config.Interval).Format("2006-01-02 15:04:05"))
+		select {
+		case <-ctx.Done():
+			fmt.Printf("%s[INFO]%s Drop watch stopped\n", ColorBlue, ColorReset)
+			return ExitMatches
+		case <-time.After(config.Interval):
+		}
+	}
+}
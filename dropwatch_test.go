@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDropWatchDomains(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	result := Result{
+		MatchingDomains: []DomainInfo{
+			{Domain: "example.io", ExpiryDate: "2026-11-01T00:00:00Z"},
+			{Domain: "example.net", ExpiryDate: "2028-01-01T00:00:00Z"},
+			{Domain: "example.shop"},
+		},
+		Lookalikes: []DomainInfo{
+			{Domain: "examp1e.com", RiskScore: 80, ExpiryDate: "2026-10-01"},
+			{Domain: "example-login.com", RiskScore: 20, ExpiryDate: "2026-10-20"},
+		},
+	}
+	var got []string
+	for _, info := range dropWatchDomains(result, 50, 60*24*time.Hour, now) {
+		got = append(got, info.Domain)
+	}
+	if !reflect.DeepEqual(got, []string{"example.io", "examp1e.com"}) {
+		t.Errorf("dropWatchDomains() = %v", got)
+	}
+}
+
+func TestDropPhase(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	window := 60 * 24 * time.Hour
+	tests := []struct {
+		info     *DomainInfo
+		err      error
+		expected string
+	}{
+		{&DomainInfo{Status: "clienttransferprohibited", ExpiryDate: "2026-11-01"}, nil, phaseRegistered},
+		{&DomainInfo{Status: "autoRenewPeriod", ExpiryDate: "2026-10-01"}, nil, phaseExpired},
+		{&DomainInfo{Status: "redemption period", ExpiryDate: "2026-10-01"}, nil, phaseRedemption},
+		{&DomainInfo{Status: "pendingDelete, redemptionPeriod"}, nil, phaseDelete},
+		{&DomainInfo{ExpiryDate: "2027-10-01"}, nil, phaseRenewed},
+		{nil, errRDAPNotFound, phaseAvailable},
+		{nil, errors.New("connection reset"), ""},
+	}
+	for _, tt := range tests {
+		if got := dropPhase(tt.info, tt.err, window, now); got != tt.expected {
+			t.Errorf("dropPhase(%+v, %v) = %q, expected %q", tt.info, tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestDropWatchCycle(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	statuses := map[string]string{"examp1e.com": "redemptionPeriod", "example.io": "ok"}
+	lookup := func(domain string, config Config) (*DomainInfo, error) {
+		status, ok := statuses[domain]
+		if !ok {
+			return nil, errRDAPNotFound
+		}
+		return &DomainInfo{Domain: domain, Status: status, ExpiryDate: "2026-01-01"}, nil
+	}
+	domains := []DomainInfo{{Domain: "examp1e.com"}, {Domain: "example.io"}}
+	limiter := newRateLimiter(0, 1, "global")
+	window := 60 * 24 * time.Hour
+
+	alerts, watching, err := dropWatchCycle(context.Background(), store, domains, lookup, limiter, window, Config{})
+	if err != nil {
+		t.Fatalf("dropWatchCycle failed: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].String() != "examp1e.com entered redemptionPeriod" || len(watching) != 2 {
+		t.Fatalf("Expected a redemption alert, got %v watching %v", alerts, watching)
+	}
+
+	// The same phase does not alert again; the drop does, and ends the watch
+	if alerts, _, _ = dropWatchCycle(context.Background(), store, domains, lookup, limiter, window, Config{}); len(alerts) != 0 {
+		t.Errorf("Expected no repeated alert, got %v", alerts)
+	}
+	delete(statuses, "examp1e.com")
+	alerts, watching, err = dropWatchCycle(context.Background(), store, domains, lookup, limiter, window, Config{})
+	if err != nil || len(alerts) != 1 || alerts[0].String() != "examp1e.com was deleted and can be registered" {
+		t.Errorf("Expected a drop alert, got %v: %v", alerts, err)
+	}
+	if len(watching) != 1 || watching[0].Domain != "example.io" {
+		t.Errorf("Expected only example.io to stay watched, got %v", watching)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// encryptedExt is added to output files encrypted with -encrypt
+const encryptedExt = ".age"
+
+// parseRecipients parses the -encrypt age X25519 recipients (age1...)
+func parseRecipients(values []string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, value := range values {
+		recipient, err := age.ParseX25519Recipient(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid -encrypt recipient %q: %w", value, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// validateEncrypt checks the -encrypt recipients and that there are output
+// files to encrypt
+func validateEncrypt(config Config) error {
+	if _, err := parseRecipients(config.Encrypt); err != nil {
+		return err
+	}
+	if len(config.Encrypt) > 0 && config.Output == "" && config.OutputAll == "" {
+		return fmt.Errorf("-encrypt requires -o or -oA; output written to stdout is not encrypted")
+	}
+	if len(config.Encrypt) == 0 && strings.HasSuffix(config.Output, encryptedExt) {
+		return fmt.Errorf("-o %s names an encrypted file; add -encrypt <recipient>", config.Output)
+	}
+	return nil
+}
+
+// encryptWriter wraps w so that everything written to it is encrypted to
+// the -encrypt recipients. Closing it finishes the age stream, not w.
+func encryptWriter(w io.Writer, recipients []age.Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no -encrypt recipient for encrypted output")
+	}
+	return age.Encrypt(w, recipients...)
+}
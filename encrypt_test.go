@@ -0,0 +1,99 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestWriteEncryptedOutput(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Config{Encrypt: stringList{identity.Recipient().String()}, Compress: true}
+	config.Recipients, err = parseRecipients(config.Encrypt)
+	if err != nil {
+		t.Fatalf("parseRecipients failed: %v", err)
+	}
+
+	path := outputName(filepath.Join(t.TempDir(), "results.json"), config)
+	if filepath.Base(path) != "results.json.gz.age" {
+		t.Fatalf("Expected results.json.gz.age, got %s", filepath.Base(path))
+	}
+	if err := writeOutputFile(path, []byte(`{"target_domain":"example.com"}`), config.Recipients); err != nil {
+		t.Fatalf("writeOutputFile failed: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := age.Decrypt(file, identity)
+	if err != nil {
+		t.Fatalf("Expected the file to decrypt: %v", err)
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatalf("Expected a gzip stream inside: %v", err)
+	}
+	data, _ := io.ReadAll(zr)
+	if string(data) != `{"target_domain":"example.com"}` {
+		t.Errorf("Unexpected decrypted output %q", data)
+	}
+
+	other, _ := age.GenerateX25519Identity()
+	file.Seek(0, io.SeekStart)
+	if _, err := age.Decrypt(file, other); err == nil {
+		t.Error("Expected another identity to fail")
+	}
+	if _, err := readResult(path); err == nil {
+		t.Error("Expected readResult to refuse an encrypted file")
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	recipient := stringList{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}
+	tests := []struct {
+		file   string
+		config Config
+		want   string
+	}{
+		{"results.json", Config{}, "results.json"},
+		{"results.json", Config{Compress: true}, "results.json.gz"},
+		{"results.json", Config{Encrypt: recipient}, "results.json.age"},
+		{"results.json.gz", Config{Encrypt: recipient}, "results.json.gz.age"},
+		{"results.json.age", Config{Compress: true, Encrypt: recipient}, "results.json.gz.age"},
+		{"", Config{Encrypt: recipient}, ""},
+	}
+	for _, tt := range tests {
+		if got := outputName(tt.file, tt.config); got != tt.want {
+			t.Errorf("outputName(%q) = %q, expected %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestValidateEncrypt(t *testing.T) {
+	recipient := "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"no encryption", Config{}, false},
+		{"with -o", Config{Encrypt: stringList{recipient}, Output: "results.json"}, false},
+		{"stdout", Config{Encrypt: stringList{recipient}}, true},
+		{"bad recipient", Config{Encrypt: stringList{"age1nope"}, Output: "results.json"}, true},
+		{"age file without recipient", Config{Output: "results.json.age"}, true},
+	}
+	for _, tt := range tests {
+		if err := validateEncrypt(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateEncrypt() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
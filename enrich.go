@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// enrichmentProviders returns the names of the integrations enabled in
+// config that need a secret from the credential store.
+func enrichmentProviders(config Config) []string {
+	var providers []string
+	if config.SecurityTrails {
+		providers = append(providers, "securitytrails")
+	}
+	if config.VirusTotal {
+		providers = append(providers, "virustotal")
+	}
+	if config.Exposure != "" {
+		providers = append(providers, config.Exposure)
+	}
+	if config.PassiveDNS != "" && !containsString(providers, config.PassiveDNS) {
+		providers = append(providers, config.PassiveDNS)
+	}
+	if config.URLScan {
+		providers = append(providers, "urlscan")
+	}
+	if config.SMTPUser != "" {
+		providers = append(providers, "smtp")
+	}
+	if config.PagerDuty {
+		providers = append(providers, "pagerduty")
+	}
+	if config.Opsgenie {
+		providers = append(providers, "opsgenie")
+	}
+	if config.JiraProject != "" {
+		providers = append(providers, "jira")
+	}
+	if config.GitHubRepo != "" {
+		providers = append(providers, "github")
+	}
+	if config.Neo4j != "" {
+		providers = append(providers, "neo4j")
+	}
+	accounts, _ := parseRegistrarAccounts(config.RegistrarAccounts)
+	providers = append(providers, accounts...)
+	return providers
+}
+
+// resolveCredentials loads the API key of every enabled integration so that
+// missing keys are reported before the scan starts
+func resolveCredentials(config *Config) error {
+	providers := enrichmentProviders(*config)
+	if len(providers) == 0 {
+		return nil
+	}
+
+	fileConfig, err := loadFileConfig(config.ConfigFile)
+	if err != nil {
+		return err
+	}
+	config.APIKeys = map[string]string{}
+	config.APIUsernames = map[string]string{}
+	for _, provider := range providers {
+		key, err := fileConfig.lookupCredential(provider)
+		if err != nil {
+			return err
+		}
+		config.APIKeys[provider] = key
+		config.APIUsernames[provider] = fileConfig.lookupUsername(provider)
+	}
+	if config.Exposure == "censys" && config.APIUsernames["censys"] == "" {
+		return fmt.Errorf("censys needs an API ID (run `tldscanner auth -username <api-id> set censys`)")
+	}
+	if config.PassiveDNS == "circl" && config.APIUsernames["circl"] == "" {
+		return fmt.Errorf("circl needs an account name (run `tldscanner auth -username <user> set circl`)")
+	}
+	return nil
+}
+
+// enrichmentHTTPClient returns the HTTP client of an enrichment provider's
+// API, rate limited, cached and held to its quota by the shared API client
+func enrichmentHTTPClient(config Config, provider string) *http.Client {
+	if config.APIClient == nil {
+		return &http.Client{Timeout: time.Duration(config.Timeout) * time.Second}
+	}
+	return &http.Client{Transport: config.APIClient.transport(provider)}
+}
+
+// domainResolves reports whether domain has an address record, i.e. could
+// serve a live site
+func domainResolves(domain string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, domain)
+	return err == nil && len(addrs) > 0
+}
+
+// enrichDomain runs every enabled built-in provider and custom enricher
+// against a matched domain. Failures are recorded on the domain rather than
+// failing it.
+func enrichDomain(info *DomainInfo, config Config) {
+	for _, enricher := range append(builtinEnrichers(config), config.Enrichers...) {
+		start := time.Now()
+		err := enricher.Enrich(context.Background(), info)
+		if err != nil {
+			info.EnrichmentErrors = append(info.EnrichmentErrors, fmt.Sprintf("%s: %v", enricher.Name(), err))
+		}
+		config.Telemetry.span("enrich."+enricher.Name(), start, time.Now(), err, otlpString("tldscanner.domain", info.Domain))
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Enricher adds data from one source to a matched domain. Enrichers
+// registered with RegisterEnricher, or defined as commands under enrichers
+// in config.yaml, run on every match when named in -enrich.
+type Enricher interface {
+	Name() string
+	Enrich(ctx context.Context, info *DomainInfo) error
+}
+
+// enricherRegistry holds the enrichers compiled into the binary
+var enricherRegistry = map[string]Enricher{}
+
+// RegisterEnricher makes a custom enricher available to -enrich. It is meant
+// to be called from the init function of a file added to the build, and
+// panics when the name is taken.
+func RegisterEnricher(e Enricher) {
+	name := strings.ToLower(e.Name())
+	if _, taken := enricherRegistry[name]; taken {
+		panic(fmt.Sprintf("enricher %q registered twice", name))
+	}
+	enricherRegistry[name] = e
+}
+
+// EnrichmentData holds the data custom enrichers attach to a domain, keyed
+// by enricher name
+type EnrichmentData map[string]interface{}
+
+// EnricherCommand configures an enricher run as an external program in
+// config.yaml. The program receives the domain as JSON on stdin; any JSON
+// it prints on stdout is stored under extra.<name> of the domain.
+type EnricherCommand struct {
+	Command []string      `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// commandEnricher runs an EnricherCommand for each domain
+type commandEnricher struct {
+	name    string
+	command []string
+	timeout time.Duration
+}
+
+func (e *commandEnricher) Name() string {
+	return e.name
+}
+
+func (e *commandEnricher) Enrich(ctx context.Context, info *DomainInfo) error {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	input, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, truncate(msg, 200))
+		}
+		return err
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(output, &data); err != nil {
+		return fmt.Errorf("invalid JSON output: %w", err)
+	}
+	if info.Extra == nil {
+		info.Extra = make(EnrichmentData)
+	}
+	info.Extra[e.name] = data
+	return nil
+}
+
+// funcEnricher adapts a built-in provider to the Enricher interface
+type funcEnricher struct {
+	name   string
+	enrich func(info *DomainInfo) error
+}
+
+func (e funcEnricher) Name() string {
+	return e.name
+}
+
+func (e funcEnricher) Enrich(ctx context.Context, info *DomainInfo) error {
+	return e.enrich(info)
+}
+
+// builtinEnrichers returns the built-in providers enabled in config
+func builtinEnrichers(config Config) []Enricher {
+	var enabled []Enricher
+	add := func(name string, enrich func(*DomainInfo, Config) error) {
+		enabled = append(enabled, funcEnricher{name: name, enrich: func(info *DomainInfo) error {
+			return enrich(info, config)
+		}})
+	}
+	if config.SecurityTrails {
+		add("securitytrails", enrichSecurityTrails)
+	}
+	if config.VirusTotal {
+		add("virustotal", enrichVirusTotal)
+	}
+	if config.Exposure != "" {
+		add(config.Exposure, enrichExposure)
+	}
+	if config.PassiveDNS != "" {
+		add("passive-dns", enrichPassiveDNS)
+	}
+	if config.URLScan {
+		add("urlscan", enrichURLScan)
+	}
+	if config.DNSSEC {
+		add("dnssec", enrichDNSSEC)
+	}
+	if config.CAA {
+		add("caa", enrichCAA)
+	}
+	if config.SMTPProbe {
+		add("smtp-probe", enrichMail)
+	}
+	return enabled
+}
+
+// selectEnrichers resolves the -enrich names against the compiled-in
+// enrichers and the commands defined in the configuration file
+func selectEnrichers(names string, commands map[string]EnricherCommand, timeout time.Duration) ([]Enricher, error) {
+	var selected []Enricher
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if e, ok := enricherRegistry[name]; ok {
+			selected = append(selected, e)
+			continue
+		}
+		command, ok := commands[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown enricher %q (available: %s)", name, strings.Join(enricherNames(commands), ", "))
+		}
+		if len(command.Command) == 0 {
+			return nil, fmt.Errorf("enricher %q has no command", name)
+		}
+		if command.Timeout <= 0 {
+			command.Timeout = timeout
+		}
+		selected = append(selected, &commandEnricher{name: name, command: command.Command, timeout: command.Timeout})
+	}
+	return selected, nil
+}
+
+// enricherNames lists every custom enricher name in sorted order
+func enricherNames(commands map[string]EnricherCommand) []string {
+	var names []string
+	for name := range enricherRegistry {
+		names = append(names, name)
+	}
+	for name := range commands {
+		if _, ok := enricherRegistry[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	sort.Strings(names)
+	return names
+}
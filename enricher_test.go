@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type staticEnricher struct{}
+
+func (staticEnricher) Name() string { return "Static" }
+
+func (staticEnricher) Enrich(ctx context.Context, info *DomainInfo) error {
+	info.Extra = EnrichmentData{"static": true}
+	return nil
+}
+
+func TestSelectEnrichers(t *testing.T) {
+	RegisterEnricher(staticEnricher{})
+	defer delete(enricherRegistry, "static")
+
+	commands := map[string]EnricherCommand{"ownership": {Command: []string{"ownership-lookup"}}}
+	selected, err := selectEnrichers("static, ownership", commands, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || selected[0].Name() != "Static" {
+		t.Fatalf("Unexpected enrichers %v", selected)
+	}
+	if command := selected[1].(*commandEnricher); command.timeout != 5*time.Second {
+		t.Errorf("Expected the scan timeout as default, got %v", command.timeout)
+	}
+
+	if _, err := selectEnrichers("missing", commands, time.Second); err == nil || !strings.Contains(err.Error(), "ownership, static") {
+		t.Errorf("Expected an unknown enricher error listing the available ones, got %v", err)
+	}
+	if _, err := selectEnrichers("empty", map[string]EnricherCommand{"empty": {}}, time.Second); err == nil {
+		t.Error("Expected an error for an enricher without a command")
+	}
+	if selected, err := selectEnrichers("", nil, time.Second); err != nil || len(selected) != 0 {
+		t.Errorf("selectEnrichers(\"\") = %v, %v", selected, err)
+	}
+}
+
+func TestCommandEnricher(t *testing.T) {
+	enricher := &commandEnricher{
+		name:    "ownership",
+		command: []string{"sh", "-c", `grep -q '"domain":"example.io"' && echo '{"owner": "Example Inc"}'`},
+		timeout: 5 * time.Second,
+	}
+	info := &DomainInfo{Domain: "example.io"}
+	if err := enricher.Enrich(context.Background(), info); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(info.Extra, EnrichmentData{"ownership": map[string]interface{}{"owner": "Example Inc"}}) {
+		t.Errorf("Unexpected extra data %v", info.Extra)
+	}
+
+	enricher.command = []string{"sh", "-c", "echo quota exceeded >&2; exit 1"}
+	if err := enricher.Enrich(context.Background(), info); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("Expected the command's stderr in the error, got %v", err)
+	}
+
+	enricher.command = []string{"sh", "-c", "echo not json"}
+	if err := enricher.Enrich(context.Background(), info); err == nil {
+		t.Error("Expected an error for invalid JSON output")
+	}
+}
+
+func TestEnrichDomainRecordsErrors(t *testing.T) {
+	config := Config{Enrichers: []Enricher{funcEnricher{name: "broken", enrich: func(*DomainInfo) error {
+		return errors.New("unavailable")
+	}}}}
+	info := &DomainInfo{Domain: "example.io"}
+	enrichDomain(info, config)
+	if !reflect.DeepEqual(info.EnrichmentErrors, []string{"broken: unavailable"}) {
+		t.Errorf("Unexpected enrichment errors %v", info.EnrichmentErrors)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+)
+
+// eppStatusCodes are the EPP domain status codes (RFC 5731, RFC 3915)
+var eppStatusCodes = []string{
+	"ok", "inactive",
+	"clientDeleteProhibited", "clientHold", "clientRenewProhibited", "clientTransferProhibited", "clientUpdateProhibited",
+	"serverDeleteProhibited", "serverHold", "serverRenewProhibited", "serverTransferProhibited", "serverUpdateProhibited",
+	"pendingCreate", "pendingDelete", "pendingRenew", "pendingRestore", "pendingTransfer", "pendingUpdate",
+	"addPeriod", "autoRenewPeriod", "renewPeriod", "transferPeriod", "redemptionPeriod",
+}
+
+// eppStatusByKey maps a status with case, spaces, dashes and underscores
+// removed to its EPP code. RDAP statuses (RFC 8056) are the EPP codes
+// spelled out in words, except "active" for ok.
+var eppStatusByKey = func() map[string]string {
+	codes := map[string]string{"active": "ok"}
+	for _, code := range eppStatusCodes {
+		codes[strings.ToLower(code)] = code
+	}
+	return codes
+}()
+
+// parseEPPStatus extracts the EPP status codes from the comma-separated
+// status of a WHOIS or RDAP record, e.g. "clientTransferProhibited
+// https://icann.org/epp#clientTransferProhibited, client hold". Unknown
+// statuses are left out.
+func parseEPPStatus(status string) []string {
+	var codes []string
+	for _, entry := range strings.Split(status, ",") {
+		var words []string
+		for _, word := range strings.Fields(entry) {
+			// Registries follow the code with an ICANN link or a comment
+			if strings.HasPrefix(word, "http") || strings.HasPrefix(word, "(") {
+				break
+			}
+			words = append(words, word)
+		}
+		key := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(strings.Join(words, "")))
+		if code, ok := eppStatusByKey[key]; ok && !containsString(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// transferLocked reports whether either the registrar or the registry
+// prohibits transfers
+func transferLocked(codes []string) bool {
+	return containsString(codes, "clientTransferProhibited") || containsString(codes, "serverTransferProhibited")
+}
+
+// assessEPPStatus parses the EPP status of a looked-up domain and flags
+// the ones needing attention: a domain owned by the target that can be
+// transferred away, or one owned by someone else that is about to be
+// deleted and can be caught when it drops. Records without a status are
+// not flagged.
+func assessEPPStatus(info *DomainInfo, owned bool) {
+	info.EPPStatus = parseEPPStatus(info.Status)
+	if len(info.EPPStatus) == 0 {
+		return
+	}
+	info.TransferUnlocked = owned && !transferLocked(info.EPPStatus)
+	info.DropCatch = !owned && containsString(info.EPPStatus, "pendingDelete")
+}
+
+// targetTransferUnlocked reports whether the target's own record has a
+// status without a transfer lock
+func targetTransferUnlocked(target *DomainInfo) bool {
+	codes := parseEPPStatus(target.Status)
+	return len(codes) > 0 && !transferLocked(codes)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEPPStatus(t *testing.T) {
+	tests := []struct {
+		status   string
+		expected []string
+	}{
+		// whoisparser keeps the first word, lowercased
+		{"clienttransferprohibited, clientdeleteprohibited", []string{"clientTransferProhibited", "clientDeleteProhibited"}},
+		// Raw WHOIS lines extracted by pattern
+		{"clientTransferProhibited https://icann.org/epp#clientTransferProhibited, serverHold (https://icann.org/epp#serverHold)", []string{"clientTransferProhibited", "serverHold"}},
+		// RDAP
+		{"active, client transfer prohibited, pending delete, redemption period", []string{"ok", "clientTransferProhibited", "pendingDelete", "redemptionPeriod"}},
+		{"ok, OK", []string{"ok"}},
+		{"registered, not delegated", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := parseEPPStatus(tt.status); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseEPPStatus(%q) = %v, expected %v", tt.status, got, tt.expected)
+		}
+	}
+}
+
+func TestAssessEPPStatus(t *testing.T) {
+	tests := []struct {
+		status    string
+		owned     bool
+		unlocked  bool
+		dropCatch bool
+	}{
+		{"clientTransferProhibited", true, false, false},
+		{"serverTransferProhibited, clientUpdateProhibited", true, false, false},
+		{"ok", true, true, false},
+		{"", true, false, false},
+		{"pendingDelete, redemptionPeriod", false, false, true},
+		{"redemption period", false, false, false},
+		{"pendingDelete", true, true, false},
+	}
+	for _, tt := range tests {
+		info := DomainInfo{Domain: "example.net", Status: tt.status}
+		assessEPPStatus(&info, tt.owned)
+		if info.TransferUnlocked != tt.unlocked || info.DropCatch != tt.dropCatch {
+			t.Errorf("assessEPPStatus(%q, owned %t): unlocked %t, drop catch %t; expected %t, %t",
+				tt.status, tt.owned, info.TransferUnlocked, info.DropCatch, tt.unlocked, tt.dropCatch)
+		}
+	}
+}
+
+func TestTargetTransferUnlocked(t *testing.T) {
+	if !targetTransferUnlocked(&DomainInfo{Status: "ok"}) {
+		t.Error("Expected a target with status ok to be unlocked")
+	}
+	if targetTransferUnlocked(&DomainInfo{Status: "clienttransferprohibited"}) || targetTransferUnlocked(&DomainInfo{}) {
+		t.Error("Expected locked and status-less targets not to be flagged")
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// waybackCDXURL is the Wayback Machine capture index, overridable in tests
+var waybackCDXURL = "https://web.archive.org/cdx/search/cdx"
+
+// maxArchiveCaptures caps the Wayback Machine captures listed in an
+// evidence package, oldest first since the first captures show when the
+// domain started being used
+const maxArchiveCaptures = 200
+
+// ArchiveCapture is a Wayback Machine capture of a domain's website
+type ArchiveCapture struct {
+	CapturedAt time.Time `json:"captured_at"`
+	Original   string    `json:"original"`
+	StatusCode string    `json:"status_code,omitempty"`
+	URL        string    `json:"url"`
+}
+
+// DomainEvidence is the evidence package of a domain, written as
+// evidence.json next to the WHOIS snapshots and screenshots it lists
+type DomainEvidence struct {
+	Domain       string           `json:"domain"`
+	CollectedAt  time.Time        `json:"collected_at"`
+	WhoisServer  string           `json:"whois_server,omitempty"`
+	DNS          *DNSRecords      `json:"dns,omitempty"`
+	Certificates []CTEntry        `json:"certificates,omitempty"`
+	Captures     []ArchiveCapture `json:"archive_captures,omitempty"`
+	Timeline     []TimelineEntry  `json:"timeline,omitempty"`
+	Screenshots  []string         `json:"screenshots,omitempty"`
+	// Errors lists the evidence that could not be collected
+	Errors []string `json:"errors,omitempty"`
+
+	// Whois is the raw WHOIS response, saved as whois.txt
+	Whois string `json:"-"`
+	// Snapshots are the recorded history, saved as history.json
+	Snapshots []Snapshot `json:"-"`
+}
+
+// evidenceFile is one file of an evidence package
+type evidenceFile struct {
+	name string
+	data []byte
+}
+
+// lookupArchiveCaptures lists the Wayback Machine captures of domain, at
+// most one a month, oldest first
+func lookupArchiveCaptures(client *http.Client, domain string) ([]ArchiveCapture, error) {
+	query := url.Values{
+		"url":      {domain},
+		"output":   {"json"},
+		"fl":       {"timestamp,original,statuscode"},
+		"collapse": {"timestamp:6"},
+		"limit":    {fmt.Sprint(maxArchiveCaptures)},
+	}
+	resp, err := client.Get(waybackCDXURL + "?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("wayback: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wayback returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("wayback: %w", err)
+	}
+	// A domain never captured gets an empty body rather than []
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	// The first row names the fields
+	var rows [][]string
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("wayback: %w", err)
+	}
+	var captures []ArchiveCapture
+	for i, row := range rows {
+		if i == 0 || len(row) < 3 {
+			continue
+		}
+		capturedAt, err := time.Parse("20060102150405", row[0])
+		if err != nil {
+			continue
+		}
+		captures = append(captures, ArchiveCapture{
+			CapturedAt: capturedAt,
+			Original:   row[1],
+			StatusCode: strings.Trim(row[2], "-"),
+			URL:        "https://web.archive.org/web/" + row[0] + "/" + row[1],
+		})
+		if len(captures) == maxArchiveCaptures {
+			break
+		}
+	}
+	return captures, nil
+}
+
+// screenshotName names the screenshot of a snapshot in the package after
+// its scan date and urlscan.io scan
+func screenshotName(snapshot Snapshot) string {
+	return "screenshots/" + snapshot.ScannedAt.UTC().Format("20060102") + "-" + snapshot.Info.URLScan.UUID + ".png"
+}
+
+// collectDomainEvidence gathers the recorded history of a domain along
+// with a fresh WHOIS snapshot, the DNS records, the certificate
+// transparency entries, the Wayback Machine captures and the urlscan.io
+// screenshots taken by past scans. Evidence that cannot be collected is
+// noted in Errors rather than failing the package.
+func collectDomainEvidence(domain string, snapshots []Snapshot, config Config) (*DomainEvidence, []evidenceFile) {
+	evidence := &DomainEvidence{
+		Domain:      domain,
+		CollectedAt: time.Now().UTC(),
+		Snapshots:   snapshots,
+		Timeline:    buildTimeline(domain, snapshots).Timeline,
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	client := &http.Client{Timeout: timeout}
+	note := func(what string, err error) {
+		evidence.Errors = append(evidence.Errors, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	raw, server, err := queryWhois(newWhoisClient(config), domain, config.WhoisQueries.query(domain), nil, nil)
+	if err != nil {
+		note("whois", err)
+	} else {
+		evidence.Whois, evidence.WhoisServer = raw, server
+	}
+	if evidence.DNS, err = lookupDNSRecords(domain, timeout); err != nil {
+		note("dns", err)
+	}
+	if evidence.Certificates, err = lookupCTEntries(client, domain); err != nil {
+		note("certificate transparency", err)
+	}
+	if evidence.Captures, err = lookupArchiveCaptures(client, domain); err != nil {
+		note("archive.org", err)
+	}
+
+	var screenshots []evidenceFile
+	seen := make(map[string]bool)
+	for _, snapshot := range snapshots {
+		scan := snapshot.Info.URLScan
+		if scan == nil || scan.ScreenshotURL == "" || seen[scan.UUID] {
+			continue
+		}
+		seen[scan.UUID] = true
+		data, err := fetchScreenshot(client, scan.ScreenshotURL)
+		if err != nil {
+			note("screenshot", err)
+			continue
+		}
+		name := screenshotName(snapshot)
+		evidence.Screenshots = append(evidence.Screenshots, name)
+		screenshots = append(screenshots, evidenceFile{name: name, data: data})
+	}
+	return evidence, screenshots
+}
+
+// fetchScreenshot downloads a urlscan.io screenshot
+func fetchScreenshot(client *http.Client, src string) ([]byte, error) {
+	resp, err := client.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP %d", src, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+}
+
+// evidenceFiles lays out the files of an evidence package, ending with the
+// SHA256SUMS manifest of the others and, with a signer, its signature
+func evidenceFiles(evidence *DomainEvidence, screenshots []evidenceFile, config Config) ([]evidenceFile, error) {
+	data, err := json.MarshalIndent(evidence, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files := []evidenceFile{{name: "evidence.json", data: data}}
+	if evidence.Whois != "" {
+		files = append(files, evidenceFile{name: "whois.txt", data: []byte(evidence.Whois)})
+	}
+	if len(evidence.Snapshots) > 0 {
+		data, err := json.MarshalIndent(evidence.Snapshots, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, evidenceFile{name: "history.json", data: data})
+	}
+	files = append(files, screenshots...)
+
+	var manifest bytes.Buffer
+	for _, file := range files {
+		sum := sha256.Sum256(file.data)
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(sum[:]), file.name)
+	}
+	files = append(files, evidenceFile{name: bundleManifest, data: manifest.Bytes()})
+	if config.Signer != nil {
+		sig, err := signData(config.Signer, manifest.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign %s: %w", bundleManifest, err)
+		}
+		files = append(files, evidenceFile{name: bundleManifest + signatureExt, data: sig})
+	}
+	return files, nil
+}
+
+// writeEvidenceZip writes files into a ZIP under a directory named after
+// the archive, so it unpacks to a bundle `tldscanner verify` can check
+func writeEvidenceZip(zipPath string, files []evidenceFile, modified time.Time) error {
+	dir := strings.TrimSuffix(path.Base(strings.ReplaceAll(zipPath, `\`, "/")), ".zip")
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: dir + "/" + file.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(zipPath, buf.Bytes(), 0644)
+}
+
+// runEvidence implements `tldscanner evidence <domain>`: a dated ZIP of
+// everything known about a domain, for a UDRP complaint or an incident
+// record
+func runEvidence(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("evidence", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s evidence <domain> [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Packages the evidence on a domain for a UDRP complaint: the WHOIS\n")
+		fmt.Printf("snapshots recorded in the -history-db database and a fresh one, DNS\n")
+		fmt.Printf("records, certificate transparency entries, Wayback Machine captures and\n")
+		fmt.Printf("the urlscan.io screenshots of past scans. The package is written to the\n")
+		fmt.Printf("-o file, evidence-<domain>-<date>.zip by default, and signed with -sign.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	// Options may come before or after the domain
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	domain := strings.ToLower(strings.TrimSpace(fs.Arg(0)))
+	if fs.NArg() > 0 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return ExitUsage
+		}
+	}
+	if domain == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+	if config.Output == "" {
+		config.Output = "evidence-" + domain + "-" + time.Now().Format("2006-01-02") + ".zip"
+	}
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	var snapshots []Snapshot
+	if _, err := os.Stat(config.HistoryDB); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s No history database at %s (record scans with -history)\n", ColorYellow, ColorReset, config.HistoryDB)
+	} else {
+		store, err := openHistory(config.HistoryDB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		snapshots, err = store.snapshots(domain)
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		if len(snapshots) == 0 {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s No history recorded for %s\n", ColorYellow, ColorReset, domain)
+		}
+	}
+
+	fmt.Printf("%s[INFO]%s Collecting evidence for %s...\n", ColorBlue, ColorReset, domain)
+	evidence, screenshots := collectDomainEvidence(domain, snapshots, config)
+	for _, problem := range evidence.Errors {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s\n", ColorYellow, ColorReset, problem)
+	}
+	files, err := evidenceFiles(evidence, screenshots, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := writeEvidenceZip(config.Output, files, evidence.CollectedAt); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s failed to write %s: %v\n", ColorRed, ColorReset, config.Output, err)
+		return ExitUsage
+	}
+
+	fmt.Printf("%s[INFO]%s Evidence package written to %s: %d WHOIS snapshots, %d certificates, %d archive captures, %d screenshots\n",
+		ColorBlue, ColorReset, config.Output, len(snapshots), len(evidence.Certificates), len(evidence.Captures), len(evidence.Screenshots))
+	return ExitMatches
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupArchiveCaptures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("url") {
+		case "examp1e.com":
+			if r.URL.Query().Get("output") != "json" || r.URL.Query().Get("collapse") != "timestamp:6" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`[["timestamp","original","statuscode"],
+				["20250301101500","http://examp1e.com/","200"],
+				["20260115080000","https://examp1e.com/login","-"]]`))
+		case "never.com":
+			// Domains without captures get an empty body
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	defer func(old string) { waybackCDXURL = old }(waybackCDXURL)
+	waybackCDXURL = server.URL
+
+	captures, err := lookupArchiveCaptures(server.Client(), "examp1e.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(captures) != 2 {
+		t.Fatalf("Expected 2 captures, got %+v", captures)
+	}
+	first := captures[0]
+	if !first.CapturedAt.Equal(time.Date(2025, 3, 1, 10, 15, 0, 0, time.UTC)) || first.StatusCode != "200" ||
+		first.URL != "https://web.archive.org/web/20250301101500/http://examp1e.com/" {
+		t.Errorf("Unexpected first capture %+v", first)
+	}
+	if captures[1].StatusCode != "" {
+		t.Errorf("Expected a missing status code to be empty, got %q", captures[1].StatusCode)
+	}
+
+	if captures, err := lookupArchiveCaptures(server.Client(), "never.com"); err != nil || captures != nil {
+		t.Errorf("Expected no captures, got %v, %v", captures, err)
+	}
+	if _, err := lookupArchiveCaptures(server.Client(), "down.com"); err == nil {
+		t.Error("Expected an error for an unavailable index")
+	}
+}
+
+func TestWriteEvidenceZip(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	scannedAt := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := Snapshot{ScannedAt: scannedAt, Target: "example.com", Info: DomainInfo{
+		Domain:  "examp1e.com",
+		URLScan: &URLScanSubmission{UUID: "abc", ScreenshotURL: "https://urlscan.io/screenshots/abc.png"},
+	}}
+	evidence := &DomainEvidence{
+		Domain:      "examp1e.com",
+		CollectedAt: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		Whois:       "Domain Name: EXAMP1E.COM\n",
+		Snapshots:   []Snapshot{snapshot},
+		Screenshots: []string{screenshotName(snapshot)},
+	}
+	screenshots := []evidenceFile{{name: screenshotName(snapshot), data: []byte("png")}}
+	files, err := evidenceFiles(evidence, screenshots, Config{Signer: key})
+	if err != nil {
+		t.Fatalf("evidenceFiles failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	zipPath := filepath.Join(dir, "evidence-examp1e.com-2026-10-16.zip")
+	if err := writeEvidenceZip(zipPath, files, evidence.CollectedAt); err != nil {
+		t.Fatalf("writeEvidenceZip failed: %v", err)
+	}
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer archive.Close()
+
+	// Unpack the package and check it the way `tldscanner verify` does
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Open %s failed: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		path := filepath.Join(dir, filepath.FromSlash(file.Name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	expected := "evidence-examp1e.com-2026-10-16/evidence.json evidence-examp1e.com-2026-10-16/whois.txt " +
+		"evidence-examp1e.com-2026-10-16/history.json evidence-examp1e.com-2026-10-16/screenshots/20260901-abc.png " +
+		"evidence-examp1e.com-2026-10-16/SHA256SUMS evidence-examp1e.com-2026-10-16/SHA256SUMS.sig"
+	if got := strings.Join(names, " "); got != expected {
+		t.Errorf("Unexpected entries %s", got)
+	}
+	failed, err := verifyManifest(filepath.Join(dir, "evidence-examp1e.com-2026-10-16", bundleManifest), key.Public())
+	if err != nil || len(failed) != 0 {
+		t.Errorf("Expected the package to verify, got %v, %v", failed, err)
+	}
+}
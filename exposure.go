@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exposureProviders are the valid values of -exposure
+var exposureProviders = []string{"shodan", "censys"}
+
+// Provider endpoints, variables so tests can point them at a local server
+var (
+	shodanBaseURL = "https://api.shodan.io"
+	censysBaseURL = "https://search.censys.io/api/v2"
+)
+
+// maxExposureIPs bounds the host lookups spent on a single domain
+const maxExposureIPs = 4
+
+// maxBannerLength truncates service banners kept in reports
+const maxBannerLength = 200
+
+// Exposure describes what a matched domain's hosts expose to the internet
+type Exposure struct {
+	Provider string           `json:"provider"`
+	IPs      []string         `json:"ips"`
+	Services []ExposedService `json:"services,omitempty"`
+}
+
+// ExposedService is one open port seen by the exposure provider
+type ExposedService struct {
+	IP          string           `json:"ip"`
+	Port        int              `json:"port"`
+	Transport   string           `json:"transport,omitempty"`
+	Product     string           `json:"product,omitempty"`
+	Banner      string           `json:"banner,omitempty"`
+	Certificate *CertificateInfo `json:"certificate,omitempty"`
+}
+
+// CertificateInfo summarizes a TLS certificate served on an exposed port
+type CertificateInfo struct {
+	Subject  string `json:"subject,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+	NotAfter string `json:"not_after,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// validateExposure checks the -exposure provider name
+func validateExposure(provider string) error {
+	if provider == "" || containsString(exposureProviders, provider) {
+		return nil
+	}
+	return fmt.Errorf("unknown exposure provider %q (valid: %s)", provider, strings.Join(exposureProviders, ", "))
+}
+
+// enrichExposure resolves info's domain and records the services its
+// addresses expose according to the configured provider
+func enrichExposure(info *DomainInfo, config Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, info.Domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			// Registered but not resolving: nothing exposed
+			return nil
+		}
+		return err
+	}
+
+	exposure := &Exposure{Provider: config.Exposure}
+	for _, addr := range addrs {
+		if len(exposure.IPs) == maxExposureIPs {
+			break
+		}
+		exposure.IPs = append(exposure.IPs, addr.IP.String())
+	}
+
+	client := enrichmentHTTPClient(config, config.Exposure)
+	var errs []error
+	for _, ip := range exposure.IPs {
+		var services []ExposedService
+		var err error
+		switch config.Exposure {
+		case "shodan":
+			services, err = shodanHost(client, config.APIKeys["shodan"], ip)
+		case "censys":
+			services, err = censysHost(client, config.APIUsernames["censys"], config.APIKeys["censys"], ip)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ip, err))
+			continue
+		}
+		exposure.Services = append(exposure.Services, services...)
+	}
+
+	sort.SliceStable(exposure.Services, func(i, j int) bool {
+		if exposure.Services[i].IP != exposure.Services[j].IP {
+			return exposure.Services[i].IP < exposure.Services[j].IP
+		}
+		return exposure.Services[i].Port < exposure.Services[j].Port
+	})
+	info.Exposure = exposure
+	return errors.Join(errs...)
+}
+
+// exposureGet performs a provider request, treating 404 as a host with no
+// recorded services
+func exposureGet(client *http.Client, req *http.Request, v interface{}) (bool, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// shodanHostResponse is the /shodan/host/{ip} response
+type shodanHostResponse struct {
+	Data []struct {
+		Port      int    `json:"port"`
+		Transport string `json:"transport"`
+		Product   string `json:"product"`
+		Data      string `json:"data"`
+		SSL       *struct {
+			Cert struct {
+				Subject     map[string]string `json:"subject"`
+				Issuer      map[string]string `json:"issuer"`
+				Expires     string            `json:"expires"`
+				Fingerprint struct {
+					SHA256 string `json:"sha256"`
+				} `json:"fingerprint"`
+			} `json:"cert"`
+		} `json:"ssl"`
+	} `json:"data"`
+}
+
+// shodanHost returns the services Shodan has indexed for ip
+func shodanHost(client *http.Client, key, ip string) ([]ExposedService, error) {
+	req, err := http.NewRequest(http.MethodGet, shodanBaseURL+"/shodan/host/"+ip+"?key="+url.QueryEscape(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var host shodanHostResponse
+	if found, err := exposureGet(client, req, &host); !found {
+		if err != nil && key != "" {
+			// net/http errors quote the URL, which contains the API key
+			err = fmt.Errorf("%s", strings.NewReplacer(key, "<key>", url.QueryEscape(key), "<key>").Replace(err.Error()))
+		}
+		return nil, err
+	}
+
+	var services []ExposedService
+	for _, data := range host.Data {
+		service := ExposedService{
+			IP:        ip,
+			Port:      data.Port,
+			Transport: data.Transport,
+			Product:   data.Product,
+			Banner:    bannerSummary(data.Data),
+		}
+		if data.SSL != nil {
+			cert := data.SSL.Cert
+			service.Certificate = &CertificateInfo{
+				Subject:  cert.Subject["CN"],
+				Issuer:   firstNonEmpty(cert.Issuer["O"], cert.Issuer["CN"]),
+				NotAfter: cert.Expires,
+				SHA256:   cert.Fingerprint.SHA256,
+			}
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// censysHostResponse is the /hosts/{ip} response
+type censysHostResponse struct {
+	Result struct {
+		Services []struct {
+			Port              int    `json:"port"`
+			ServiceName       string `json:"service_name"`
+			TransportProtocol string `json:"transport_protocol"`
+			Banner            string `json:"banner"`
+			Software          []struct {
+				Product string `json:"product"`
+			} `json:"software"`
+			TLS *struct {
+				Certificates struct {
+					LeafData struct {
+						Subject struct {
+							CommonName []string `json:"common_name"`
+						} `json:"subject"`
+						Issuer struct {
+							Organization []string `json:"organization"`
+						} `json:"issuer"`
+						Fingerprint string `json:"fingerprint"`
+					} `json:"leaf_data"`
+				} `json:"certificates"`
+			} `json:"tls"`
+		} `json:"services"`
+	} `json:"result"`
+}
+
+// censysHost returns the services Censys has indexed for ip. Censys
+// authenticates with the API ID as username and the secret as password.
+func censysHost(client *http.Client, apiID, secret, ip string) ([]ExposedService, error) {
+	req, err := http.NewRequest(http.MethodGet, censysBaseURL+"/hosts/"+ip, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(apiID, secret)
+
+	var host censysHostResponse
+	if found, err := exposureGet(client, req, &host); !found {
+		return nil, err
+	}
+
+	var services []ExposedService
+	for _, svc := range host.Result.Services {
+		service := ExposedService{
+			IP:        ip,
+			Port:      svc.Port,
+			Transport: strings.ToLower(svc.TransportProtocol),
+			Product:   svc.ServiceName,
+			Banner:    bannerSummary(svc.Banner),
+		}
+		if len(svc.Software) > 0 && svc.Software[0].Product != "" {
+			service.Product = svc.Software[0].Product
+		}
+		if svc.TLS != nil {
+			leaf := svc.TLS.Certificates.LeafData
+			cert := &CertificateInfo{SHA256: leaf.Fingerprint}
+			if len(leaf.Subject.CommonName) > 0 {
+				cert.Subject = leaf.Subject.CommonName[0]
+			}
+			if len(leaf.Issuer.Organization) > 0 {
+				cert.Issuer = leaf.Issuer.Organization[0]
+			}
+			service.Certificate = cert
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// bannerSummary keeps the first line of a banner, truncated for reports
+func bannerSummary(banner string) string {
+	banner = strings.TrimSpace(banner)
+	if i := strings.IndexAny(banner, "\r\n"); i >= 0 {
+		banner = banner[:i]
+	}
+	if len(banner) > maxBannerLength {
+		banner = banner[:maxBannerLength] + "..."
+	}
+	return banner
+}
+
+// String renders a service as ip:port/transport product
+func (s ExposedService) String() string {
+	out := fmt.Sprintf("%s:%d", s.IP, s.Port)
+	if s.Transport != "" {
+		out += "/" + s.Transport
+	}
+	if s.Product != "" {
+		out += " " + s.Product
+	}
+	if s.Certificate != nil && s.Certificate.Subject != "" {
+		out += fmt.Sprintf(" [cert %s by %s]", s.Certificate.Subject, firstNonEmpty(s.Certificate.Issuer, "unknown"))
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShodanHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "shodan-key" {
+			http.Error(w, `{"error":"Invalid API key"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/shodan/host/192.0.2.10":
+			w.Write([]byte(`{"data":[
+				{"port":443,"transport":"tcp","product":"nginx","data":"HTTP/1.1 200 OK\r\nServer: nginx",
+				 "ssl":{"cert":{"subject":{"CN":"login.example.net"},"issuer":{"O":"Let's Encrypt","CN":"R3"},"expires":"20261231235959Z","fingerprint":{"sha256":"abc"}}}},
+				{"port":25,"transport":"tcp","product":"Postfix smtpd","data":"220 mx ESMTP"}
+			]}`))
+		default:
+			http.Error(w, `{"error":"No information available for that IP."}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oldURL := shodanBaseURL
+	shodanBaseURL = server.URL
+	defer func() { shodanBaseURL = oldURL }()
+
+	services, err := shodanHost(server.Client(), "shodan-key", "192.0.2.10")
+	if err != nil {
+		t.Fatalf("shodanHost failed: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 services, got %d", len(services))
+	}
+	if got := services[0].String(); got != "192.0.2.10:443/tcp nginx [cert login.example.net by Let's Encrypt]" {
+		t.Errorf("services[0] = %q", got)
+	}
+	if services[0].Banner != "HTTP/1.1 200 OK" {
+		t.Errorf("Banner = %q; expected first line only", services[0].Banner)
+	}
+
+	if services, err := shodanHost(server.Client(), "shodan-key", "192.0.2.99"); err != nil || len(services) != 0 {
+		t.Errorf("Unindexed host: %v, %v; expected no services and no error", services, err)
+	}
+	if _, err := shodanHost(server.Client(), "wrong", "192.0.2.10"); err == nil {
+		t.Error("Expected an error with a bad API key")
+	}
+}
+
+func TestShodanHostHidesKey(t *testing.T) {
+	oldURL := shodanBaseURL
+	shodanBaseURL = "http://127.0.0.1:1"
+	defer func() { shodanBaseURL = oldURL }()
+
+	_, err := shodanHost(&http.Client{Timeout: time.Second}, "secret-key", "192.0.2.1")
+	if err == nil || strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("Expected an error without the API key, got %v", err)
+	}
+}
+
+func TestCensysHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "censys-id" || secret != "censys-secret" {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"result":{"services":[
+			{"port":8443,"service_name":"HTTP","transport_protocol":"TCP","software":[{"product":"apache"}],
+			 "tls":{"certificates":{"leaf_data":{"subject":{"common_name":["example.net"]},"issuer":{"organization":["DigiCert Inc"]},"fingerprint":"def"}}}}
+		]}}`))
+	}))
+	defer server.Close()
+
+	oldURL := censysBaseURL
+	censysBaseURL = server.URL
+	defer func() { censysBaseURL = oldURL }()
+
+	services, err := censysHost(server.Client(), "censys-id", "censys-secret", "192.0.2.10")
+	if err != nil {
+		t.Fatalf("censysHost failed: %v", err)
+	}
+	if len(services) != 1 || services[0].String() != "192.0.2.10:8443/tcp apache [cert example.net by DigiCert Inc]" {
+		t.Errorf("Unexpected services: %v", services)
+	}
+}
+
+func TestValidateExposure(t *testing.T) {
+	for _, provider := range []string{"", "shodan", "censys"} {
+		if err := validateExposure(provider); err != nil {
+			t.Errorf("validateExposure(%q) failed: %v", provider, err)
+		}
+	}
+	if err := validateExposure("zoomeye"); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
+
+func TestBannerSummary(t *testing.T) {
+	long := strings.Repeat("x", maxBannerLength+10)
+	if got := bannerSummary(long); len(got) != maxBannerLength+3 {
+		t.Errorf("bannerSummary() length = %d; expected %d", len(got), maxBannerLength+3)
+	}
+	if got := bannerSummary("  SSH-2.0-OpenSSH_9.6\r\n"); got != "SSH-2.0-OpenSSH_9.6" {
+		t.Errorf("bannerSummary() = %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	linkTagPattern = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	relAttrPattern = regexp.MustCompile(`(?is)\brel\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+	hrefPattern    = regexp.MustCompile(`(?is)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// attrValue returns the value of the attribute matched by pattern in tag
+func attrValue(pattern *regexp.Regexp, tag string) string {
+	m := pattern.FindStringSubmatch(tag)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(m[1] + m[2] + m[3])
+}
+
+// faviconURL returns the favicon a page declares with <link rel="icon">,
+// resolved against the page URL, or /favicon.ico when it declares none
+func faviconURL(page *url.URL, body []byte) string {
+	for _, tag := range linkTagPattern.FindAllString(string(body), -1) {
+		isIcon := false
+		for _, rel := range strings.Fields(strings.ToLower(attrValue(relAttrPattern, tag))) {
+			if rel == "icon" {
+				isIcon = true
+			}
+		}
+		href := attrValue(hrefPattern, tag)
+		if !isIcon || href == "" || strings.HasPrefix(href, "data:") {
+			continue
+		}
+		if ref, err := url.Parse(href); err == nil {
+			return page.ResolveReference(ref).String()
+		}
+	}
+	return page.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()
+}
+
+// faviconHash hashes an icon the way Shodan's http.favicon.hash does:
+// MurmurHash3 of the base64 encoding, wrapped at 76 characters, so matches
+// can be pivoted on in Shodan
+func faviconHash(icon []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(icon)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76] + "\n")
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded + "\n")
+	return int32(murmur3([]byte(wrapped.String())))
+}
+
+// murmur3 is the 32-bit x86 MurmurHash3 with seed 0
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	blocks := len(data) / 4 * 4
+	for i := 0; i < blocks; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	switch tail := data[blocks:]; len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// fetchFavicon downloads an icon and returns its hash
+func fetchFavicon(client *http.Client, iconURL string) (int32, error) {
+	resp, err := client.Get(iconURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	icon, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if err != nil {
+		return 0, err
+	}
+	if len(icon) == 0 {
+		return 0, fmt.Errorf("empty favicon")
+	}
+	return faviconHash(icon), nil
+}
+
+// targetFavicon hashes the favicon of the target's site; ok is false when
+// the site or its icon could not be fetched
+func targetFavicon(targetPage *HTTPProbe, timeout time.Duration) (hash int32, ok bool) {
+	if !targetPage.Live() || targetPage.Favicon == "" {
+		return 0, false
+	}
+	hash, err := fetchFavicon(&http.Client{Timeout: timeout, Transport: probeTransport}, targetPage.Favicon)
+	return hash, err == nil
+}
+
+// compareFavicon hashes the favicon of a live lookalike and flags it when
+// the icon is identical to the target's. Lookalikes redirecting to the
+// target's own site serve its icon legitimately and are skipped.
+func compareFavicon(info *DomainInfo, targetPage *HTTPProbe, targetHash int32, timeout time.Duration) {
+	if !info.HTTP.Live() || info.HTTP.Favicon == "" || urlHost(info.HTTP.URL) == urlHost(targetPage.URL) {
+		return
+	}
+	hash, err := fetchFavicon(&http.Client{Timeout: timeout, Transport: probeTransport}, info.HTTP.Favicon)
+	if err != nil {
+		return
+	}
+	info.HTTP.FaviconHash = hash
+	info.FaviconMatch = hash == targetHash
+}
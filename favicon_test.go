@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMurmur3(t *testing.T) {
+	tests := map[string]uint32{
+		"":      0,
+		"hello": 613153351,
+		"The quick brown fox jumps over the lazy dog": 0x2e4ff723,
+	}
+	for input, want := range tests {
+		if got := murmur3([]byte(input)); got != want {
+			t.Errorf("murmur3(%q) = %d, expected %d", input, got, want)
+		}
+	}
+}
+
+func TestFaviconURL(t *testing.T) {
+	page, _ := url.Parse("https://example.net/login/index.html")
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`<html><head><title>x</title></head></html>`, "https://example.net/favicon.ico"},
+		{`<link rel="stylesheet" href="/app.css"><link rel="shortcut icon" href="img/fav.png">`, "https://example.net/login/img/fav.png"},
+		{`<LINK HREF='//cdn.example.org/icon.ico' REL=icon>`, "https://cdn.example.org/icon.ico"},
+		{`<link rel="icon" href="data:image/png;base64,AAAA"><link rel="apple-touch-icon" href="/apple.png">`, "https://example.net/favicon.ico"},
+	}
+	for _, tt := range tests {
+		if got := faviconURL(page, []byte(tt.body)); got != tt.want {
+			t.Errorf("faviconURL(%q) = %q, expected %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestCompareFavicon(t *testing.T) {
+	icon := []byte("\x00\x00\x01\x00fake icon")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/favicon.ico":
+			w.Write(icon)
+		case "/other.ico":
+			w.Write([]byte("another icon"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	clone := &DomainInfo{Domain: "clone.example", HTTP: &HTTPProbe{URL: server.URL, StatusCode: 200, Favicon: server.URL + "/favicon.ico"}}
+	targetPage := &HTTPProbe{URL: "https://example.com/", StatusCode: 200}
+	compareFavicon(clone, targetPage, faviconHash(icon), 5*time.Second)
+	if !clone.FaviconMatch || clone.HTTP.FaviconHash != faviconHash(icon) {
+		t.Errorf("Expected an identical favicon to be flagged, got %+v", clone.HTTP)
+	}
+
+	other := &DomainInfo{Domain: "other.example", HTTP: &HTTPProbe{URL: server.URL, StatusCode: 200, Favicon: server.URL + "/other.ico"}}
+	compareFavicon(other, targetPage, faviconHash(icon), 5*time.Second)
+	if other.FaviconMatch || other.HTTP.FaviconHash == 0 {
+		t.Errorf("Expected a different favicon to be hashed but not flagged, got %+v", other.HTTP)
+	}
+
+	missing := &DomainInfo{Domain: "missing.example", HTTP: &HTTPProbe{URL: server.URL, StatusCode: 200, Favicon: server.URL + "/missing.ico"}}
+	compareFavicon(missing, targetPage, faviconHash(icon), 5*time.Second)
+	if missing.FaviconMatch || missing.HTTP.FaviconHash != 0 {
+		t.Errorf("Expected a missing favicon to be ignored, got %+v", missing.HTTP)
+	}
+
+	redirect := &DomainInfo{Domain: "redirect.example", HTTP: &HTTPProbe{URL: server.URL, StatusCode: 200, Favicon: server.URL + "/favicon.ico"}}
+	compareFavicon(redirect, &HTTPProbe{URL: server.URL + "/", StatusCode: 200}, faviconHash(icon), 5*time.Second)
+	if redirect.FaviconMatch {
+		t.Error("Expected a lookalike redirecting to the target's site not to be flagged")
+	}
+}
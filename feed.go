@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// feedVersion is the version of the -format feed layout, separate from the
+// full result's SchemaVersion
+const feedVersion = "1"
+
+// feedFields are the opt-in fields of -feed-fields. None identifies the
+// target or a registrant: organizations, contacts, registrant history,
+// match reasons and risk factor details are never shared.
+var feedFields = []string{"registrar", "created_date", "expiry_date", "status", "name_servers", "ns_provider", "ip", "country", "technique", "parked", "tld", "metadata"}
+
+// Feed is a shareable list of suspicious domains for community blocklists
+// and threat intelligence platforms
+type Feed struct {
+	Feed      string      `json:"feed"`
+	Version   string      `json:"version"`
+	Generated time.Time   `json:"generated"`
+	Fields    []string    `json:"fields,omitempty"`
+	Entries   []FeedEntry `json:"entries"`
+}
+
+// FeedEntry is one suspicious domain of a feed. Domain, risk score,
+// first-seen time and indicators are always present; the other fields only
+// when selected with -feed-fields.
+type FeedEntry struct {
+	Domain    string    `json:"domain"`
+	RiskScore int       `json:"risk_score"`
+	FirstSeen time.Time `json:"first_seen"`
+	// Indicators are the names of the risk factors and signals, without
+	// their details, which may name the target
+	Indicators  []string `json:"indicators,omitempty"`
+	Registrar   string   `json:"registrar,omitempty"`
+	CreatedDate string   `json:"created_date,omitempty"`
+	ExpiryDate  string   `json:"expiry_date,omitempty"`
+	Status      string   `json:"status,omitempty"`
+	NameServers []string `json:"name_servers,omitempty"`
+	NSProvider  string   `json:"ns_provider,omitempty"`
+	IPs         []string `json:"ip,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	Technique   string   `json:"technique,omitempty"`
+	Parked      bool     `json:"parked,omitempty"`
+	TLD         string   `json:"tld,omitempty"`
+	Metadata    Metadata `json:"metadata,omitempty"`
+}
+
+// parseFeedFields parses the comma-separated -feed-fields
+func parseFeedFields(value string) ([]string, error) {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if !containsString(feedFields, field) {
+			return nil, fmt.Errorf("unknown -feed-fields field %q (valid: %s)", field, strings.Join(feedFields, ", "))
+		}
+		if !containsString(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// validateFeed checks -feed-fields
+func validateFeed(config Config) error {
+	_, err := parseFeedFields(config.FeedFields)
+	return err
+}
+
+// feedDomains returns the lookalikes, scored with -risk or by brand sweeps,
+// and the signal domains of a result, once each and never one owned by the
+// target, riskiest first
+func feedDomains(result Result) []DomainInfo {
+	owned := make(map[string]bool)
+	for _, info := range result.MatchingDomains {
+		owned[info.Domain] = true
+	}
+	seen := make(map[string]bool)
+	var domains []DomainInfo
+	for _, list := range [][]DomainInfo{result.Lookalikes, result.SignalDomains} {
+		for _, info := range list {
+			if owned[info.Domain] || seen[info.Domain] || info.Ignored != "" || info.Ownership == ownershipKnown || result.TargetDomain == info.Domain {
+				continue
+			}
+			seen[info.Domain] = true
+			domains = append(domains, info)
+		}
+	}
+	sort.SliceStable(domains, func(i, j int) bool {
+		if domains[i].RiskScore != domains[j].RiskScore {
+			return domains[i].RiskScore > domains[j].RiskScore
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+	return domains
+}
+
+// newFeedEntry keeps the shareable parts of a domain's record
+func newFeedEntry(info DomainInfo, fields []string) FeedEntry {
+	entry := FeedEntry{Domain: info.Domain, RiskScore: info.RiskScore, FirstSeen: info.Timestamp.UTC()}
+	for _, factor := range info.RiskFactors {
+		entry.Indicators = append(entry.Indicators, factor.Name)
+	}
+	for _, signal := range info.Signals {
+		if !containsString(entry.Indicators, signal.Name) {
+			entry.Indicators = append(entry.Indicators, signal.Name)
+		}
+	}
+	for _, field := range fields {
+		switch field {
+		case "registrar":
+			entry.Registrar = info.Registrar
+		case "created_date":
+			entry.CreatedDate = info.CreatedDate
+		case "expiry_date":
+			entry.ExpiryDate = info.ExpiryDate
+		case "status":
+			entry.Status = info.Status
+		case "name_servers":
+			entry.NameServers = info.NameServers
+		case "ns_provider":
+			entry.NSProvider = info.NSProvider
+		case "ip":
+			entry.IPs = feedIPs(info)
+		case "country":
+			entry.Country = info.Country
+		case "technique":
+			entry.Technique = info.Technique
+		case "parked":
+			entry.Parked = info.Parked
+		case "tld":
+			entry.TLD = lastLabel(info.Domain)
+		case "metadata":
+			entry.Metadata = info.Metadata
+		}
+	}
+	return entry
+}
+
+// feedIPs returns the addresses of a domain's DNS records, looked up with
+// -securitytrails
+func feedIPs(info DomainInfo) []string {
+	if info.DNS == nil {
+		return nil
+	}
+	return append(append([]string(nil), info.DNS.A...), info.DNS.AAAA...)
+}
+
+// renderFeed renders the shareable feed of a result as JSON
+func renderFeed(result Result, fields []string, now time.Time) ([]byte, error) {
+	feed := Feed{Feed: "tldscanner", Version: feedVersion, Generated: now.UTC(), Fields: fields, Entries: []FeedEntry{}}
+	for _, info := range feedDomains(result) {
+		feed.Entries = append(feed.Entries, newFeedEntry(info, fields))
+	}
+	data, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// outputFeed writes the shareable feed of a result
+func outputFeed(result Result, outputFile, fieldList string, recipients []age.Recipient) {
+	fields, _ := parseFeedFields(fieldList)
+	data, err := renderFeed(result, fields, time.Now())
+	if err != nil {
+		log.Printf("Error marshaling feed: %v", err)
+		return
+	}
+	saveOutput(data, outputFile, recipients)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderFeed(t *testing.T) {
+	seen := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	phish := DomainInfo{
+		Domain:       "examp1e-login.com",
+		Organization: "John Doe",
+		Emails:       []string{"john@mail.example"},
+		Registrar:    "NameCheap, Inc.",
+		Country:      "RU",
+		Technique:    "homoglyph",
+		RiskScore:    85,
+		RiskFactors: []RiskFactor{
+			{Name: "cloned_content", Points: 40, Detail: "93% similar to the target's homepage"},
+			{Name: "brand_keywords", Points: 25, Detail: "Example Corp"},
+		},
+		DNS:       &DNSRecords{A: []string{"203.0.113.7"}},
+		Timestamp: seen,
+	}
+	pivot := DomainInfo{
+		Domain:    "example-shop.net",
+		Signals:   []Signal{{Name: "registrar_pivot", Score: 0.6, Detail: "same registrar as example.com"}},
+		Timestamp: seen,
+	}
+	result := Result{
+		TargetDomain:    "example.com",
+		TargetOrg:       "Example Corp",
+		MatchingDomains: []DomainInfo{{Domain: "example.de", Organization: "Example Corp"}},
+		SignalDomains:   []DomainInfo{pivot, phish},
+		Lookalikes:      []DomainInfo{phish, {Domain: "example.de"}, {Domain: "example.org", Ownership: ownershipKnown}},
+	}
+
+	data, err := renderFeed(result, nil, seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, private := range []string{"example.com", "Example Corp", "John Doe", "john@", "similar", "NameCheap", "203.0.113.7", "example.de", "example.org"} {
+		if strings.Contains(string(data), private) {
+			t.Errorf("The feed shares %q:\n%s", private, data)
+		}
+	}
+	var feed Feed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Entries) != 2 || feed.Entries[0].Domain != "examp1e-login.com" || feed.Entries[1].Domain != "example-shop.net" {
+		t.Fatalf("Expected the two suspicious domains, riskiest first, got %+v", feed.Entries)
+	}
+	first := feed.Entries[0]
+	if first.RiskScore != 85 || !first.FirstSeen.Equal(seen) || strings.Join(first.Indicators, ",") != "cloned_content,brand_keywords" {
+		t.Errorf("Unexpected entry: %+v", first)
+	}
+	if indicators := feed.Entries[1].Indicators; len(indicators) != 1 || indicators[0] != "registrar_pivot" {
+		t.Errorf("Signals should be shared as indicators, got %v", indicators)
+	}
+
+	fields, err := parseFeedFields("registrar, ip,tld,registrar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = renderFeed(result, fields, seen)
+	json.Unmarshal(data, &feed)
+	entry := feed.Entries[0]
+	if entry.Registrar != "NameCheap, Inc." || strings.Join(entry.IPs, ",") != "203.0.113.7" || entry.TLD != "com" || entry.Country != "" {
+		t.Errorf("Expected only the selected fields, got %+v", entry)
+	}
+	if strings.Join(feed.Fields, ",") != "registrar,ip,tld" {
+		t.Errorf("Fields = %v", feed.Fields)
+	}
+}
+
+func TestParseFeedFields(t *testing.T) {
+	if _, err := parseFeedFields("registrar,organization"); err == nil {
+		t.Error("Expected fields identifying a registrant to be rejected")
+	}
+	if fields, err := parseFeedFields(""); err != nil || len(fields) != 0 {
+		t.Errorf("parseFeedFields(\"\") = %v, %v", fields, err)
+	}
+}
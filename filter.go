@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// resultFilter is a compiled -filter expression. Expressions combine
+// conditions on the JSON fields of a domain with &&, || and !, e.g.
+//
+//	registrar contains "GoDaddy" && created_after "2024-01-01"
+//	risk_score >= 50 || (tld == "ru" && !(organization matches "^Example"))
+//
+// Conditions are FIELD OP VALUE with the operators ==, !=, <, <=, >, >=,
+// contains, startswith, endswith and matches (a regular expression), or
+// one of the date shorthands created_after, created_before, expires_after
+// and expires_before followed by a date. String comparisons ignore case;
+// a list field satisfies a condition when any element does.
+type resultFilter struct {
+	expr string
+	root filterNode
+}
+
+// filterNode is one node of a parsed filter expression
+type filterNode interface {
+	eval(fields map[string]interface{}) bool
+}
+
+type filterAnd struct{ left, right filterNode }
+type filterOr struct{ left, right filterNode }
+type filterNot struct{ operand filterNode }
+
+// filterCond compares one field against a literal value
+type filterCond struct {
+	path  []string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (n filterAnd) eval(fields map[string]interface{}) bool {
+	return n.left.eval(fields) && n.right.eval(fields)
+}
+
+func (n filterOr) eval(fields map[string]interface{}) bool {
+	return n.left.eval(fields) || n.right.eval(fields)
+}
+
+func (n filterNot) eval(fields map[string]interface{}) bool {
+	return !n.operand.eval(fields)
+}
+
+// filterFieldAliases are short names accepted for DomainInfo fields
+var filterFieldAliases = map[string]string{
+	"org":         "organization",
+	"created":     "created_at",
+	"expiry":      "expires_at",
+	"expires":     "expires_at",
+	"ns":          "name_servers",
+	"nameservers": "name_servers",
+	"risk":        "risk_score",
+	"similarity":  "content_similarity",
+}
+
+// filterDateShorthands map the date shorthands to a field and operator
+var filterDateShorthands = map[string][2]string{
+	"created_after":  {"created_at", ">"},
+	"created_before": {"created_at", "<"},
+	"expires_after":  {"expires_at", ">"},
+	"expires_before": {"expires_at", "<"},
+}
+
+// filterOperators are the comparison operators of conditions
+var filterOperators = []string{"==", "!=", "<", "<=", ">", ">=", "contains", "startswith", "endswith", "matches"}
+
+// filterableFields returns the JSON field names of DomainInfo, plus the
+// derived tld and error_code fields
+func filterableFields() map[string]bool {
+	fields := map[string]bool{"tld": true, "error_code": true}
+	t := reflect.TypeOf(DomainInfo{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// compileFilter parses a -filter expression; an empty expression compiles
+// to nil, which keeps every domain
+func compileFilter(expr string) (*resultFilter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -filter: %w", err)
+	}
+	p := &filterParser{tokens: tokens, fields: filterableFields()}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid -filter: %w", err)
+	}
+	return &resultFilter{expr: expr, root: root}, nil
+}
+
+// validateFilter checks that the -filter expression compiles
+func validateFilter(expr string) error {
+	_, err := compileFilter(expr)
+	return err
+}
+
+// filterToken is a lexical token: an operator or parenthesis, a word, or a
+// quoted string
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				value.WriteRune(runes[j])
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, filterToken{text: value.String(), quoted: true})
+			i = j + 1
+		case strings.ContainsRune("()", r):
+			tokens = append(tokens, filterToken{text: string(r)})
+			i++
+		case strings.ContainsRune("&|!=<>", r):
+			op := string(r)
+			if i+1 < len(runes) {
+				if two := string(runes[i : i+2]); two == "&&" || two == "||" || two == "==" || two == "!=" || two == "<=" || two == ">=" {
+					op = two
+				}
+			}
+			if op == "&" || op == "|" || op == "=" {
+				return nil, fmt.Errorf("unknown operator %q", op)
+			}
+			tokens = append(tokens, filterToken{text: op})
+			i += len(op)
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()&|!=<>\"'", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, filterToken{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser over the tokens, with ! binding
+// tighter than && and && tighter than ||
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+	fields map[string]bool
+}
+
+// accept consumes the next token when it is one of the given operators or
+// keywords
+func (p *filterParser) accept(words ...string) bool {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(p.tokens[p.pos].text, word) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *filterParser) next() (filterToken, error) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("||", "or") {
+		var right filterNode
+		right, err = p.parseAnd()
+		left = filterOr{left, right}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("&&", "and") {
+		var right filterNode
+		right, err = p.parseUnary()
+		left = filterAnd{left, right}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.accept("!", "not") {
+		operand, err := p.parseUnary()
+		return filterNot{operand}, err
+	}
+	if p.accept("(") {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return node, nil
+	}
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (filterNode, error) {
+	field, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if field.quoted {
+		return nil, fmt.Errorf("expected a field name, got %q", field.text)
+	}
+	name := strings.ToLower(field.text)
+
+	var op string
+	if shorthand, ok := filterDateShorthands[name]; ok {
+		name, op = shorthand[0], shorthand[1]
+	} else {
+		if alias, ok := filterFieldAliases[name]; ok {
+			name = alias
+		}
+		opToken, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		op = strings.ToLower(opToken.text)
+		if opToken.quoted || !containsString(filterOperators, op) {
+			return nil, fmt.Errorf("unknown operator %q after %s", opToken.text, field.text)
+		}
+	}
+	path := strings.Split(name, ".")
+	if !p.fields[path[0]] {
+		return nil, fmt.Errorf("unknown field %q", field.text)
+	}
+
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if !value.quoted && (value.text == "(" || value.text == ")" || containsString([]string{"&&", "||", "!"}, value.text)) {
+		return nil, fmt.Errorf("expected a value after %s %s", field.text, op)
+	}
+	cond := filterCond{path: path, op: op, value: value.text}
+	if op == "matches" {
+		if cond.re, err = regexp.Compile("(?i)" + value.text); err != nil {
+			return nil, err
+		}
+	}
+	return cond, nil
+}
+
+func (c filterCond) eval(fields map[string]interface{}) bool {
+	var v interface{} = fields
+	for _, key := range c.path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			v = nil
+			break
+		}
+		v = m[key]
+	}
+	if list, ok := v.([]interface{}); ok {
+		for _, item := range list {
+			if c.compare(item) {
+				return true
+			}
+		}
+		return false
+	}
+	return c.compare(v)
+}
+
+// compare applies the condition to one scalar value; missing values compare
+// as the empty string
+func (c filterCond) compare(v interface{}) bool {
+	var s string
+	switch v := v.(type) {
+	case nil:
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		s = string(data)
+	}
+	a, b := strings.ToLower(s), strings.ToLower(c.value)
+
+	switch c.op {
+	case "contains":
+		return strings.Contains(a, b)
+	case "startswith":
+		return strings.HasPrefix(a, b)
+	case "endswith":
+		return strings.HasSuffix(a, b)
+	case "matches":
+		return c.re.MatchString(s)
+	}
+
+	// Dates compare as dates and numbers as numbers; equality falls back
+	// to the text
+	cmp, ok := 0, false
+	if x, okX := parseWhoisDate(s); okX {
+		if y, okY := parseWhoisDate(c.value); okY {
+			cmp, ok = x.Compare(y), true
+		}
+	}
+	if !ok {
+		x, errX := strconv.ParseFloat(s, 64)
+		y, errY := strconv.ParseFloat(c.value, 64)
+		if errX == nil && errY == nil {
+			ok = true
+			switch {
+			case x < y:
+				cmp = -1
+			case x > y:
+				cmp = 1
+			}
+		}
+	}
+	switch c.op {
+	case "==":
+		return (ok && cmp == 0) || (!ok && a == b)
+	case "!=":
+		return !((ok && cmp == 0) || (!ok && a == b))
+	case "<":
+		return ok && cmp < 0
+	case "<=":
+		return ok && cmp <= 0
+	case ">":
+		return ok && cmp > 0
+	case ">=":
+		return ok && cmp >= 0
+	}
+	return false
+}
+
+// keep reports whether a domain passes the filter
+func (f *resultFilter) keep(info DomainInfo) bool {
+	if f == nil {
+		return true
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return false
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	fields["tld"] = lastLabel(info.Domain)
+	fields["error_code"] = string(info.errorCode())
+	// Records saved before dates were normalized are parsed here
+	if created, ok := info.created(); ok {
+		fields["created_at"] = created.Format(time.RFC3339)
+	}
+	if expires, ok := info.expires(); ok {
+		fields["expires_at"] = expires.Format(time.RFC3339)
+	}
+	return f.root.eval(fields)
+}
+
+// filterDomains returns the domains passing the filter
+func (f *resultFilter) filterDomains(domains []DomainInfo) []DomainInfo {
+	if f == nil || domains == nil {
+		return domains
+	}
+	kept := []DomainInfo{}
+	for _, info := range domains {
+		if f.keep(info) {
+			kept = append(kept, info)
+		}
+	}
+	return kept
+}
+
+// apply narrows the domain lists of a result to the domains passing the
+// filter and updates their totals. The scan totals (scanned, skipped,
+// errors) still describe the whole scan.
+func (f *resultFilter) apply(result Result) Result {
+	if f == nil {
+		return result
+	}
+	result.Filter = f.expr
+	result.MatchingDomains = f.filterDomains(result.MatchingDomains)
+	result.SignalDomains = f.filterDomains(result.SignalDomains)
+	result.Lookalikes = f.filterDomains(result.Lookalikes)
+	result.AllDomains = f.filterDomains(result.AllDomains)
+	result.TotalMatches = len(result.MatchingDomains)
+	result.TotalShadow = countShadow(result.MatchingDomains)
+	result.TotalSignals = len(result.SignalDomains)
+	result.TotalLookalikes = len(result.Lookalikes)
+	return result
+}
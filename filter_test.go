@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResultFilterKeep(t *testing.T) {
+	info := DomainInfo{
+		Domain:       "example.ru",
+		Organization: "Example Inc",
+		Registrar:    "GoDaddy.com, LLC",
+		CreatedDate:  "2024-03-15T10:00:00Z",
+		ExpiryDate:   "2025-03-15",
+		NameServers:  []string{"ns1.parking.example", "ns2.parking.example"},
+		RiskScore:    55,
+		HTTP:         &HTTPProbe{URL: "https://example.ru/", StatusCode: 200},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`registrar contains "GoDaddy" && created_after "2024-01-01"`, true},
+		{`registrar contains "godaddy" && created_after "2024-06-01"`, false},
+		{`created_before 2024-06-01 and expires_after 2025-01-01`, true},
+		{`expires_before "2025-01-01" || tld == "ru"`, true},
+		{`!(tld == "ru")`, false},
+		{`not tld == com`, true},
+		{`org == "example inc"`, true},
+		{`organization != "Example Inc"`, false},
+		{`risk >= 50 && risk_score < 60`, true},
+		{`risk_score > 55`, false},
+		{`ns endswith ".parking.example"`, true},
+		{`name_servers startswith "ns3."`, false},
+		{`registrar matches "^go(daddy|dady)"`, true},
+		{`http.status_code == 200`, true},
+		{`http.title contains "login"`, false},
+		{`dnssec == ""`, true},
+		{`error_code == ""`, true},
+		{`(tld == "com" || tld == "ru") && registrar contains 'Daddy'`, true},
+		{`registrar == "GoDaddy.com, LLC" || risk_score > 90 && tld == "com"`, true},
+	}
+	for _, tt := range tests {
+		filter, err := compileFilter(tt.expr)
+		if err != nil {
+			t.Errorf("compileFilter(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := filter.keep(info); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func TestCompileFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{`registrar contains`, "unexpected end"},
+		{`registar contains "GoDaddy"`, `unknown field "registar"`},
+		{`registrar like "GoDaddy"`, `unknown operator "like"`},
+		{`registrar = "GoDaddy"`, `unknown operator "="`},
+		{`(tld == "ru"`, "missing )"},
+		{`tld == "ru" tld == "com"`, `unexpected "tld"`},
+		{`tld == "ru`, "unterminated string"},
+		{`registrar matches "("`, "missing closing )"},
+	}
+	for _, tt := range tests {
+		_, err := compileFilter(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("compileFilter(%q): expected error containing %q, got %v", tt.expr, tt.want, err)
+		}
+	}
+	if filter, err := compileFilter("  "); filter != nil || err != nil {
+		t.Errorf("Expected no filter for an empty expression, got %v, %v", filter, err)
+	}
+}
+
+func TestResultFilterApply(t *testing.T) {
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.net"}, {Domain: "example.ru"}},
+		Lookalikes:      []DomainInfo{{Domain: "examp1e.ru", RiskScore: 80}},
+		AllDomains:      []DomainInfo{{Domain: "example.net"}, {Domain: "example.ru"}, {Domain: "example.de"}},
+		TotalScanned:    3,
+		TotalMatches:    2,
+		TotalLookalikes: 1,
+	}
+	filter, err := compileFilter(`tld == "ru"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := filter.apply(result)
+	if got.TotalMatches != 1 || got.MatchingDomains[0].Domain != "example.ru" {
+		t.Errorf("Expected only example.ru to match, got %+v", got.MatchingDomains)
+	}
+	if got.TotalLookalikes != 1 || len(got.AllDomains) != 1 {
+		t.Errorf("Expected 1 lookalike and 1 domain, got %d and %d", got.TotalLookalikes, len(got.AllDomains))
+	}
+	if got.TotalScanned != 3 || got.Filter != `tld == "ru"` {
+		t.Errorf("Expected the scan totals and the filter to be kept, got %d and %q", got.TotalScanned, got.Filter)
+	}
+	if len(result.MatchingDomains) != 2 {
+		t.Errorf("Expected the original result to be left unchanged")
+	}
+
+	var none *resultFilter
+	if kept := none.apply(result); kept.TotalMatches != 2 || kept.Filter != "" {
+		t.Errorf("Expected a nil filter to keep everything, got %+v", kept)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// outputQuiet silences the "Results saved" line of each file written, for
+// the periodic writes of -flush-every
+var outputQuiet bool
+
+// validateFlushEvery checks -flush-every, which rewrites the output files
+// and so needs some
+func validateFlushEvery(config Config) error {
+	if config.FlushEvery < 0 {
+		return fmt.Errorf("-flush-every must not be negative")
+	}
+	if config.FlushEvery > 0 && config.Output == "" && config.OutputAll == "" && config.OutputDir == "" {
+		return fmt.Errorf("-flush-every requires -o, -oA or -out-dir")
+	}
+	return nil
+}
+
+// partialFlusher returns the OnFlush callback of -flush-every: base, the
+// result under construction, is completed with the lookups so far and
+// written to the output files, marked partial. It returns nil without
+// -flush-every or output files.
+func partialFlusher(base Result, config Config) func(all, matching, signals []DomainInfo) {
+	if config.FlushEvery <= 0 || (config.Output == "" && config.OutputAll == "") {
+		return nil
+	}
+	started := time.Now()
+	return func(all, matching, signals []DomainInfo) {
+		result := partialResult(base, all, matching, signals, config)
+		result.ScanDuration = time.Since(started).Round(time.Second).String()
+		outputQuiet = true
+		writeOutput(result, config)
+		outputQuiet = false
+		if config.Verbose {
+			fmt.Fprintf(os.Stderr, "%s[INFO]%s Flushed partial results: %d scanned, %d matches\n", ColorBlue, ColorReset, result.TotalScanned, result.TotalMatches)
+		}
+	}
+}
+
+// partialResult completes base with the lookups so far. The slices are
+// copied, since the scan keeps appending to them; risk scoring, statistics
+// and tagging are left to the final result.
+func partialResult(base Result, all, matching, signals []DomainInfo, config Config) Result {
+	result := base
+	result.Partial = true
+	all = append([]DomainInfo(nil), all...)
+	result.MatchingDomains = append([]DomainInfo{}, matching...)
+	result.SignalDomains = append([]DomainInfo(nil), signals...)
+	sortResults(all, result.MatchingDomains, result.SignalDomains)
+	result.TotalScanned = len(all)
+	result.TotalMatches = len(result.MatchingDomains)
+	result.TotalSignals = len(result.SignalDomains)
+	summarizeErrors(&result, all)
+	summarizeQuality(&result, all)
+	result.SuppressedDomains = ignoredDomains(all)
+	if config.SaveAll || config.Format == "list-all" {
+		result.AllDomains = all
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestValidateFlushEvery(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{FlushEvery: 5 * time.Minute, Output: "results.json"}, true},
+		{Config{FlushEvery: 5 * time.Minute, OutputAll: "results"}, true},
+		{Config{FlushEvery: 5 * time.Minute}, false},
+		{Config{FlushEvery: -time.Minute, Output: "results.json"}, false},
+	}
+	for _, test := range tests {
+		if err := validateFlushEvery(test.config); (err == nil) != test.valid {
+			t.Errorf("validateFlushEvery(%s, -o %q, -oA %q) = %v", test.config.FlushEvery, test.config.Output, test.config.OutputAll, err)
+		}
+	}
+}
+
+func TestPartialFlusher(t *testing.T) {
+	if partialFlusher(Result{}, Config{}) != nil {
+		t.Error("partialFlusher should be nil without -flush-every")
+	}
+
+	output := filepath.Join(t.TempDir(), "results.json")
+	config := Config{FlushEvery: time.Minute, Output: output, Format: "json"}
+	flush := partialFlusher(Result{SchemaVersion: SchemaVersion, TargetDomain: "example.com"}, config)
+
+	all := []DomainInfo{
+		{Domain: "example.net", Organization: "Example Corp"},
+		{Domain: "example.de", Organization: "Example Corp"},
+		{Domain: "example.ru", Error: "timeout"},
+	}
+	matching := []DomainInfo{all[0], all[1]}
+	flush(all, matching, nil)
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("The partial result was not written: %v", err)
+	}
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Partial || result.TotalScanned != 3 || result.TotalMatches != 2 || result.TotalErrors != 1 {
+		t.Errorf("Unexpected partial result: %+v", result)
+	}
+	if result.MatchingDomains[0].Domain != "example.de" {
+		t.Errorf("Partial matches should be sorted, got %s first", result.MatchingDomains[0].Domain)
+	}
+	if matching[0].Domain != "example.net" {
+		t.Error("Sorting the partial result must not reorder the scan's own slices")
+	}
+}
+
+func TestWriteOutputFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "results.json")
+	for _, content := range []string{`{"partial":true}`, `{}`} {
+		if err := writeOutputFile(output, []byte(content), nil); err != nil {
+			t.Fatalf("writeOutputFile failed: %v", err)
+		}
+		data, _ := os.ReadFile(output)
+		if string(data) != content {
+			t.Errorf("Expected %s, got %s", content, data)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Temporary files were left behind: %v", entries)
+	}
+	if info, _ := os.Stat(output); info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %s", info.Mode().Perm())
+	}
+}
@@ -1,14 +1,21 @@
-module tldscanner
+module github.com/vijay922/tldscanner
 
 go 1.21
 
 require (
+	filippo.io/age v1.2.1
 	github.com/likexian/whois v1.15.1
 	github.com/likexian/whois-parser v1.24.9
+	go.etcd.io/bbolt v1.3.9
+	go.starlark.net v0.0.0-20230525235612-a134d8f9ddca
+	golang.org/x/net v0.21.0
+	golang.org/x/text v0.16.0
+	golang.org/x/time v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/likexian/gokit v0.25.13 // indirect
-	golang.org/x/net v0.10.0 // indirect
-	golang.org/x/text v0.9.0 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
 )
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Node types of the registrant graph
+const (
+	graphDomain       = "domain"
+	graphOrganization = "organization"
+	graphRegistrar    = "registrar"
+	graphEmail        = "email"
+	graphNameServer   = "name_server"
+	graphIP           = "ip"
+)
+
+// maltegoEntities are the Maltego entity types of the node types
+var maltegoEntities = map[string]string{
+	graphDomain:       "maltego.Domain",
+	graphOrganization: "maltego.Organization",
+	graphRegistrar:    "maltego.Company",
+	graphEmail:        "maltego.EmailAddress",
+	graphNameServer:   "maltego.NSRecord",
+	graphIP:           "maltego.IPv4Address",
+}
+
+// graphNode is a domain or a piece of registration data or infrastructure.
+// Role, Risk and Metadata only apply to domains.
+type graphNode struct {
+	ID       string
+	Type     string
+	Label    string
+	Role     string
+	Risk     int
+	Metadata string
+}
+
+// graphEdge links a domain to its registrant organization, registrar,
+// contact emails, name servers or addresses
+type graphEdge struct {
+	Source   string
+	Target   string
+	Relation string
+}
+
+// registrantGraph links the registered domains of a scan to the
+// organizations, registrars, emails, name servers and IPs they were found
+// with, so lookalikes sharing infrastructure end up connected
+type registrantGraph struct {
+	Nodes []graphNode
+	Edges []graphEdge
+}
+
+// buildGraph builds the registrant graph of a result: the target, matches,
+// signals, lookalikes and, with -all, every registered domain. Redacted and
+// privacy-service organizations are left out, as they link unrelated
+// domains.
+func buildGraph(result Result) registrantGraph {
+	nodes := make(map[string]graphNode)
+	edges := make(map[graphEdge]bool)
+	node := func(kind, label string) string {
+		id := kind + ":" + label
+		if _, ok := nodes[id]; !ok {
+			nodes[id] = graphNode{ID: id, Type: kind, Label: label}
+		}
+		return id
+	}
+	link := func(domain, kind, label, relation string) {
+		if label = strings.TrimSpace(label); label != "" {
+			edges[graphEdge{Source: domain, Target: node(kind, label), Relation: relation}] = true
+		}
+	}
+	organization := func(domain, org string) {
+		if org != "" && !privacyPattern.MatchString(org) {
+			link(domain, graphOrganization, org, "registered_by")
+		}
+	}
+
+	if result.TargetDomain != "" {
+		id := graphDomain + ":" + result.TargetDomain
+		nodes[id] = graphNode{ID: id, Type: graphDomain, Label: result.TargetDomain, Role: "target"}
+		organization(id, result.TargetOrg)
+	}
+	for _, list := range []struct {
+		role    string
+		domains []DomainInfo
+	}{
+		{"match", result.MatchingDomains},
+		{"signal", result.SignalDomains},
+		{"lookalike", result.Lookalikes},
+		{"", registeredDomains(result.AllDomains)},
+	} {
+		for _, info := range list.domains {
+			id := graphDomain + ":" + info.Domain
+			if _, seen := nodes[id]; seen || info.Error != "" {
+				continue
+			}
+			nodes[id] = graphNode{ID: id, Type: graphDomain, Label: info.Domain, Role: list.role, Risk: info.RiskScore, Metadata: formatMetadata(info.Metadata, ";")}
+			organization(id, info.Organization)
+			link(id, graphRegistrar, info.Registrar, "registered_with")
+			for _, email := range info.Emails {
+				link(id, graphEmail, strings.ToLower(email), "contact")
+			}
+			for _, ns := range info.NameServers {
+				link(id, graphNameServer, strings.ToLower(strings.TrimSuffix(ns, ".")), "name_server")
+			}
+			for _, ip := range domainIPs(info) {
+				link(id, graphIP, ip, "resolves_to")
+			}
+		}
+	}
+
+	var graph registrantGraph
+	for _, n := range nodes {
+		graph.Nodes = append(graph.Nodes, n)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	for e := range edges {
+		graph.Edges = append(graph.Edges, e)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Target < b.Target
+	})
+	return graph
+}
+
+// domainIPs collects the addresses recorded for a domain by the DNS,
+// exposure and passive DNS enrichments
+func domainIPs(info DomainInfo) []string {
+	var ips []string
+	add := func(ip string) {
+		if net.ParseIP(ip) != nil && !containsString(ips, ip) {
+			ips = append(ips, ip)
+		}
+	}
+	if info.DNS != nil {
+		for _, ip := range append(append([]string{}, info.DNS.A...), info.DNS.AAAA...) {
+			add(ip)
+		}
+	}
+	if info.Exposure != nil {
+		for _, ip := range info.Exposure.IPs {
+			add(ip)
+		}
+	}
+	for _, record := range info.PassiveDNS {
+		if record.Type == "A" || record.Type == "AAAA" {
+			add(record.Value)
+		}
+	}
+	return ips
+}
+
+// outputGraph writes the registrant graph as GraphML, DOT or Maltego CSV
+func outputGraph(result Result, outputFile, format string, recipients []age.Recipient) {
+	saveOutput(renderGraph(result, format), outputFile, recipients)
+}
+
+func renderGraph(result Result, format string) []byte {
+	graph := buildGraph(result)
+	switch format {
+	case "dot":
+		return graph.dot()
+	case "maltego":
+		return graph.maltego()
+	}
+	return graph.graphML()
+}
+
+// graphML renders the graph as GraphML, with the node type, label, role,
+// risk score and metadata and the edge relation as attributes
+func (g registrantGraph) graphML() []byte {
+	var out bytes.Buffer
+	escape := func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	out.WriteString(xml.Header)
+	out.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	out.WriteString(`  <key id="type" for="node" attr.name="type" attr.type="string"/>` + "\n")
+	out.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	out.WriteString(`  <key id="role" for="node" attr.name="role" attr.type="string"/>` + "\n")
+	out.WriteString(`  <key id="risk_score" for="node" attr.name="risk_score" attr.type="int"/>` + "\n")
+	out.WriteString(`  <key id="metadata" for="node" attr.name="metadata" attr.type="string"/>` + "\n")
+	out.WriteString(`  <key id="relation" for="edge" attr.name="relation" attr.type="string"/>` + "\n")
+	out.WriteString(`  <graph id="tldscanner" edgedefault="directed">` + "\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&out, "    <node id=\"%s\">\n", escape(n.ID))
+		fmt.Fprintf(&out, "      <data key=\"type\">%s</data>\n", n.Type)
+		fmt.Fprintf(&out, "      <data key=\"label\">%s</data>\n", escape(n.Label))
+		if n.Role != "" {
+			fmt.Fprintf(&out, "      <data key=\"role\">%s</data>\n", n.Role)
+		}
+		if n.Risk > 0 {
+			fmt.Fprintf(&out, "      <data key=\"risk_score\">%d</data>\n", n.Risk)
+		}
+		if n.Metadata != "" {
+			fmt.Fprintf(&out, "      <data key=\"metadata\">%s</data>\n", escape(n.Metadata))
+		}
+		out.WriteString("    </node>\n")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&out, "    <edge source=\"%s\" target=\"%s\">\n", escape(e.Source), escape(e.Target))
+		fmt.Fprintf(&out, "      <data key=\"relation\">%s</data>\n", e.Relation)
+		out.WriteString("    </edge>\n")
+	}
+	out.WriteString("  </graph>\n</graphml>\n")
+	return out.Bytes()
+}
+
+// dotShapes tell the node types apart in Graphviz renderings
+var dotShapes = map[string]string{
+	graphDomain:       "box",
+	graphOrganization: "house",
+	graphRegistrar:    "component",
+	graphEmail:        "note",
+	graphNameServer:   "hexagon",
+	graphIP:           "ellipse",
+}
+
+// dot renders the graph in the Graphviz DOT language. The target is drawn
+// bold, matches green and lookalikes red; metadata is shown as a tooltip.
+func (g registrantGraph) dot() []byte {
+	var out bytes.Buffer
+	out.WriteString("digraph tldscanner {\n  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf("label=%s, shape=%s", strconv.Quote(n.Label), dotShapes[n.Type])
+		switch n.Role {
+		case "target":
+			attrs += ", style=bold"
+		case "match":
+			attrs += ", color=darkgreen"
+		case "signal":
+			attrs += ", color=purple"
+		case "lookalike":
+			attrs += ", color=red"
+		}
+		if n.Metadata != "" {
+			attrs += ", tooltip=" + strconv.Quote(n.Metadata)
+		}
+		fmt.Fprintf(&out, "  %s [%s];\n", strconv.Quote(n.ID), attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&out, "  %s -> %s [label=%s];\n", strconv.Quote(e.Source), strconv.Quote(e.Target), strconv.Quote(e.Relation))
+	}
+	out.WriteString("}\n")
+	return out.Bytes()
+}
+
+// maltego renders the graph as a CSV of links for Maltego's table import,
+// one row per edge with the entity types of both ends
+func (g registrantGraph) maltego() []byte {
+	types := make(map[string]graphNode, len(g.Nodes))
+	for _, n := range g.Nodes {
+		types[n.ID] = n
+	}
+	entity := func(n graphNode) string {
+		if n.Type == graphIP && strings.Contains(n.Label, ":") {
+			return "maltego.IPv6Address"
+		}
+		return maltegoEntities[n.Type]
+	}
+
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	writer.Write([]string{"source_type", "source", "relation", "target_type", "target"})
+	for _, e := range g.Edges {
+		source, target := types[e.Source], types[e.Target]
+		writer.Write([]string{entity(source), source.Label, e.Relation, entity(target), target.Label})
+	}
+	writer.Flush()
+	return out.Bytes()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func testGraphResult() Result {
+	return Result{
+		TargetDomain: "example.com",
+		TargetOrg:    "Example Corp",
+		MatchingDomains: []DomainInfo{
+			{Domain: "example.net", Organization: "Example Corp", NameServers: []string{"NS1.EXAMPLE.COM."}},
+		},
+		Lookalikes: []DomainInfo{
+			{Domain: "examp1e.com", Organization: "Bad & Co", Registrar: "Cheap Names LLC", Emails: []string{"Owner@Mail.test"}, NameServers: []string{"ns1.evil-dns.test"}, RiskScore: 80,
+				DNS: &DNSRecords{A: []string{"192.0.2.7"}}, PassiveDNS: []PassiveDNSRecord{{Type: "A", Value: "192.0.2.7"}, {Type: "NS", Value: "ns1.evil-dns.test"}}},
+			{Domain: "exarnple.com", Organization: "Redacted for Privacy", NameServers: []string{"ns1.evil-dns.test"}, RiskScore: 60},
+		},
+		AllDomains: []DomainInfo{{Domain: "example.org", Error: "no match for domain"}, {Domain: "example.net"}},
+	}
+}
+
+func TestBuildGraph(t *testing.T) {
+	graph := buildGraph(testGraphResult())
+
+	edges := make(map[string]bool)
+	for _, e := range graph.Edges {
+		edges[e.Source+" "+e.Relation+" "+e.Target] = true
+	}
+	for _, want := range []string{
+		"domain:example.com registered_by organization:Example Corp",
+		"domain:example.net registered_by organization:Example Corp",
+		"domain:example.net name_server name_server:ns1.example.com",
+		"domain:examp1e.com registered_by organization:Bad & Co",
+		"domain:examp1e.com registered_with registrar:Cheap Names LLC",
+		"domain:examp1e.com contact email:owner@mail.test",
+		"domain:examp1e.com resolves_to ip:192.0.2.7",
+		"domain:examp1e.com name_server name_server:ns1.evil-dns.test",
+		"domain:exarnple.com name_server name_server:ns1.evil-dns.test",
+	} {
+		if !edges[want] {
+			t.Errorf("Missing edge %q in %v", want, graph.Edges)
+		}
+	}
+	if len(graph.Edges) != 9 {
+		t.Errorf("Expected 9 edges without the privacy organization and duplicates, got %d", len(graph.Edges))
+	}
+	for _, n := range graph.Nodes {
+		if n.Label == "example.org" {
+			t.Error("Expected failed lookups to be left out")
+		}
+		if n.Label == "examp1e.com" && (n.Role != "lookalike" || n.Risk != 80) {
+			t.Errorf("Unexpected lookalike node %+v", n)
+		}
+		if n.Label == "example.net" && n.Role != "match" {
+			t.Errorf("Expected the match to keep its role, got %+v", n)
+		}
+	}
+}
+
+func TestRenderGraph(t *testing.T) {
+	result := testGraphResult()
+
+	var doc struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(renderGraph(result, "graphml"), &doc); err != nil {
+		t.Fatalf("GraphML does not parse: %v", err)
+	}
+	if len(doc.Nodes) != 11 || len(doc.Edges) != 9 {
+		t.Errorf("Expected 11 nodes and 9 edges, got %d and %d", len(doc.Nodes), len(doc.Edges))
+	}
+
+	dot := string(renderGraph(result, "dot"))
+	if !strings.HasPrefix(dot, "digraph tldscanner {") || !strings.Contains(dot, `"domain:examp1e.com" -> "ip:192.0.2.7" [label="resolves_to"];`) {
+		t.Errorf("Unexpected DOT output:\n%s", dot)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(renderGraph(result, "maltego"))).ReadAll()
+	if err != nil {
+		t.Fatalf("Maltego CSV does not parse: %v", err)
+	}
+	if len(rows) != 10 || strings.Join(rows[0], ",") != "source_type,source,relation,target_type,target" {
+		t.Fatalf("Unexpected Maltego CSV %v", rows)
+	}
+	found := false
+	for _, row := range rows[1:] {
+		if strings.Join(row, ",") == "maltego.Domain,examp1e.com,contact,maltego.EmailAddress,owner@mail.test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the contact link in %v", rows)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// healthTimeout bounds each readiness check
+const healthTimeout = 5 * time.Second
+
+// healthCacheTTL is how long readiness results are reused, so frequent
+// probes do not open a WHOIS connection each time
+const healthCacheTTL = 10 * time.Second
+
+// healthWhoisAddr is dialed to check WHOIS egress; every scan starts by
+// asking IANA for TLD servers
+var healthWhoisAddr = net.JoinHostPort(ianaWhoisServer, "43")
+
+// HealthStatus is the body of /healthz and /readyz
+type HealthStatus struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// healthCheck is one dependency a ready scanner needs
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+// healthServer answers liveness and readiness probes
+type healthServer struct {
+	checks []healthCheck
+
+	mu      sync.Mutex
+	checked time.Time
+	status  HealthStatus
+}
+
+// newHealthServer sets up the readiness checks for config: WHOIS egress
+// always, the history database when scans are recorded in it, and a Redis
+// lookup cache
+func newHealthServer(config Config) *healthServer {
+	checks := []healthCheck{{name: "whois", check: func() error { return checkWhoisEgress(config) }}}
+	if config.History || config.Monitor {
+		checks = append(checks, healthCheck{name: "history", check: func() error { return checkHistory(config.HistoryDB) }})
+	}
+	if _, ok := config.LookupCache.(*redisCache); ok {
+		checks = append(checks, healthCheck{name: "cache", check: func() error { return checkCache(config.LookupCache) }})
+	}
+	return &healthServer{checks: checks}
+}
+
+// register adds /healthz and /readyz to mux
+func (h *healthServer) register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.handleHealth)
+	mux.HandleFunc("/readyz", h.handleReady)
+}
+
+// handleHealth reports liveness: the process is up and serving
+func (h *healthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthStatus{Status: "ok"})
+}
+
+// handleReady reports readiness: every dependency check passed
+func (h *healthServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	status := h.ready()
+	code := http.StatusOK
+	if status.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, status)
+}
+
+// ready runs the checks, or returns their result from the last
+// healthCacheTTL
+func (h *healthServer) ready() HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.checked.IsZero() && time.Since(h.checked) < healthCacheTTL {
+		return h.status
+	}
+
+	status := HealthStatus{Status: "ok", Checks: make(map[string]string)}
+	for _, c := range h.checks {
+		if err := c.check(); err != nil {
+			status.Status = "unavailable"
+			status.Checks[c.name] = err.Error()
+		} else {
+			status.Checks[c.name] = "ok"
+		}
+	}
+	h.status, h.checked = status, time.Now()
+	return status
+}
+
+// checkWhoisEgress opens a connection to the IANA WHOIS server through the
+// configured source addresses
+func checkWhoisEgress(config Config) error {
+	conn, err := newDialer(config.SourceIPs, healthTimeout).Dial("tcp", healthWhoisAddr)
+	if err != nil {
+		return fmt.Errorf("WHOIS egress: %w", err)
+	}
+	return conn.Close()
+}
+
+// checkHistory opens the history database and starts a read transaction.
+// A scan recording its results holds the database briefly; openHistory
+// waits for it.
+func checkHistory(path string) error {
+	store, err := openHistory(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if err := store.db.View(func(tx *bolt.Tx) error { return nil }); err != nil {
+		return fmt.Errorf("history database %s: %w", path, err)
+	}
+	return nil
+}
+
+// serveHealth answers /healthz and /readyz, and /status with the monitor
+// schedule, on -health-listen in the background until ctx is done. A listener that cannot start is reported
+// but does not stop monitoring.
+func serveHealth(ctx context.Context, config Config, monitor *monitorState) {
+	mux := http.NewServeMux()
+	newHealthServer(config).register(mux)
+	mux.HandleFunc("/status", monitor.handleStatus)
+	server := &http.Server{Addr: config.HealthListen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s Health endpoints: %v\n", ColorRed, ColorReset, err)
+		}
+	}()
+	fmt.Printf("%s[INFO]%s Serving /healthz, /readyz and /status on %s\n", ColorBlue, ColorReset, config.HealthListen)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	defer func(addr string) { healthWhoisAddr = addr }(healthWhoisAddr)
+	healthWhoisAddr = listener.Addr().String()
+
+	mux := http.NewServeMux()
+	health := newHealthServer(Config{Monitor: true, HistoryDB: filepath.Join(t.TempDir(), "history.db")})
+	health.register(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	probe := func(path string) (int, HealthStatus) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var status HealthStatus
+		json.NewDecoder(resp.Body).Decode(&status)
+		return resp.StatusCode, status
+	}
+
+	if code, status := probe("/healthz"); code != http.StatusOK || status.Status != "ok" {
+		t.Errorf("Unexpected /healthz %d %+v", code, status)
+	}
+	code, status := probe("/readyz")
+	if code != http.StatusOK || status.Checks["whois"] != "ok" || status.Checks["history"] != "ok" {
+		t.Errorf("Unexpected /readyz %d %+v", code, status)
+	}
+
+	// Results are cached, so the closed listener only shows once they expire
+	listener.Close()
+	if code, _ := probe("/readyz"); code != http.StatusOK {
+		t.Errorf("Expected the cached readiness, got %d", code)
+	}
+	health.checked = health.checked.Add(-healthCacheTTL)
+	code, status = probe("/readyz")
+	if code != http.StatusServiceUnavailable || status.Status != "unavailable" || status.Checks["whois"] == "ok" {
+		t.Errorf("Expected WHOIS egress to fail, got %d %+v", code, status)
+	}
+}
+
+func TestNewHealthServerChecks(t *testing.T) {
+	if checks := newHealthServer(Config{}).checks; len(checks) != 1 || checks[0].name != "whois" {
+		t.Errorf("Expected only the WHOIS check without history, got %+v", checks)
+	}
+	if checks := newHealthServer(Config{History: true}).checks; len(checks) != 2 {
+		t.Errorf("Expected a history check with -history, got %+v", checks)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bucket layout: domains/<domain>/<timestamp> holds one Snapshot per scan
+// that observed the domain; scans/<timestamp> holds a ScanRecord per run;
+// index holds the full-text index search uses (see historyIndexBucket).
+var (
+	historyDomainsBucket = []byte("domains")
+	historyScansBucket   = []byte("scans")
+)
+
+// historyKeyLayout formats snapshot keys so byte order is time order
+const historyKeyLayout = "2006-01-02T15:04:05.000000000Z"
+
+// Snapshot is one observation of a domain's WHOIS state
+type Snapshot struct {
+	ScannedAt time.Time  `json:"scanned_at"`
+	Target    string     `json:"target"`
+	Info      DomainInfo `json:"info"`
+}
+
+// ScanRecord summarizes a scan stored in the history database
+type ScanRecord struct {
+	ScannedAt    time.Time `json:"scanned_at"`
+	Target       string    `json:"target"`
+	TotalScanned int       `json:"total_scanned"`
+	TotalMatches int       `json:"total_matches"`
+	Changed      int       `json:"changed"`
+}
+
+// FieldChange is a difference in one tracked field between two snapshots
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// historyStore is the embedded database of past scan results
+type historyStore struct {
+	db *bolt.DB
+}
+
+// defaultHistoryPath returns the per-user history database location
+func defaultHistoryPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "tldscanner-history.db"
+	}
+	return filepath.Join(dir, "tldscanner", "history.db")
+}
+
+// openHistory opens or creates the history database at path
+func openHistory(path string) (*historyStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %w", path, err)
+	}
+	return &historyStore{db: db}, nil
+}
+
+// Close releases the database file
+func (h *historyStore) Close() error {
+	return h.db.Close()
+}
+
+// record stores a snapshot of every successfully looked up domain and
+// returns how many differ from their previous snapshot
+func (h *historyStore) record(result Result, domains []DomainInfo, scannedAt time.Time) (int, error) {
+	target := result.TargetDomain
+	key := []byte(scannedAt.UTC().Format(historyKeyLayout))
+	changed := 0
+
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		// Databases recorded before search existed are indexed in full by
+		// the first search instead
+		index := tx.Bucket(historyIndexBucket)
+		if index == nil && tx.Bucket(historyDomainsBucket) == nil {
+			var err error
+			if index, err = tx.CreateBucket(historyIndexBucket); err != nil {
+				return err
+			}
+		}
+		root, err := tx.CreateBucketIfNotExists(historyDomainsBucket)
+		if err != nil {
+			return err
+		}
+		for _, info := range domains {
+			if info.Error != "" {
+				continue
+			}
+			bucket, err := root.CreateBucketIfNotExists([]byte(info.Domain))
+			if err != nil {
+				return err
+			}
+			if _, last := bucket.Cursor().Last(); last != nil {
+				var previous Snapshot
+				if err := json.Unmarshal(last, &previous); err == nil && len(diffSnapshots(previous.Info, info)) > 0 {
+					changed++
+				}
+			}
+			snapshot := Snapshot{ScannedAt: scannedAt.UTC(), Target: target, Info: info}
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(key, data); err != nil {
+				return err
+			}
+			if index != nil {
+				if err := indexSnapshot(index, snapshot, key); err != nil {
+					return err
+				}
+			}
+		}
+
+		scans, err := tx.CreateBucketIfNotExists(historyScansBucket)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(ScanRecord{
+			ScannedAt:    scannedAt.UTC(),
+			Target:       target,
+			TotalScanned: result.TotalScanned,
+			TotalMatches: result.TotalMatches,
+			Changed:      changed,
+		})
+		if err != nil {
+			return err
+		}
+		return scans.Put(key, data)
+	})
+	return changed, err
+}
+
+// snapshots returns every stored snapshot of domain, oldest first
+func (h *historyStore) snapshots(domain string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	err := h.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(historyDomainsBucket)
+		if root == nil {
+			return nil
+		}
+		bucket := root.Bucket([]byte(strings.ToLower(domain)))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, v []byte) error {
+			var snapshot Snapshot
+			if err := json.Unmarshal(v, &snapshot); err != nil {
+				return err
+			}
+			snapshots = append(snapshots, snapshot)
+			return nil
+		})
+	})
+	return snapshots, err
+}
+
+// lastScan returns when the most recent scan of target started, or the
+// zero time if none was recorded
+func (h *historyStore) lastScan(target string) (time.Time, error) {
+	var last time.Time
+	err := h.db.View(func(tx *bolt.Tx) error {
+		scans := tx.Bucket(historyScansBucket)
+		if scans == nil {
+			return nil
+		}
+		c := scans.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var record ScanRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if strings.EqualFold(record.Target, target) {
+				last = record.ScannedAt
+				return nil
+			}
+		}
+		return nil
+	})
+	return last, err
+}
+
+// latest returns the most recent snapshot of domain, or nil if it has
+// never been recorded
+func (h *historyStore) latest(domain string) (*Snapshot, error) {
+	var snapshot *Snapshot
+	err := h.db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(historyDomainsBucket)
+		if root == nil {
+			return nil
+		}
+		bucket := root.Bucket([]byte(strings.ToLower(domain)))
+		if bucket == nil {
+			return nil
+		}
+		if _, v := bucket.Cursor().Last(); v != nil {
+			snapshot = &Snapshot{}
+			return json.Unmarshal(v, snapshot)
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// diffSnapshots compares the WHOIS fields tracked across scans
+func diffSnapshots(old, new DomainInfo) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"organization", old.Organization, new.Organization},
+		{"registrar", old.Registrar, new.Registrar},
+		{"created_date", old.CreatedDate, new.CreatedDate},
+		{"expiry_date", old.ExpiryDate, new.ExpiryDate},
+		{"status", old.Status, new.Status},
+		{"name_servers", sortedJoin(old.NameServers), sortedJoin(new.NameServers)},
+		{"emails", sortedJoin(old.Emails), sortedJoin(new.Emails)},
+	}
+
+	var changes []FieldChange
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	return changes
+}
+
+// sortedJoin joins values case-insensitively sorted so reordering alone is
+// not reported as a change
+func sortedJoin(values []string) string {
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = strings.ToLower(strings.TrimSuffix(v, "."))
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, ", ")
+}
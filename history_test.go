@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistoryStoreRecord(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	first := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	second := first.Add(30 * 24 * time.Hour)
+	result := Result{TargetDomain: "example.com", TotalScanned: 2}
+
+	changed, err := store.record(result, []DomainInfo{
+		{Domain: "example.io", Registrar: "Gandi", NameServers: []string{"ns1.gandi.net", "ns2.gandi.net"}},
+		{Domain: "example.zz", Error: "whois query failed"},
+	}, first)
+	if err != nil || changed != 0 {
+		t.Fatalf("First record() = %d, %v; expected 0 changes", changed, err)
+	}
+
+	changed, err = store.record(result, []DomainInfo{
+		{Domain: "example.io", Registrar: "MarkMonitor", NameServers: []string{"ns2.gandi.net", "ns1.gandi.net"}},
+	}, second)
+	if err != nil || changed != 1 {
+		t.Fatalf("Second record() = %d, %v; expected 1 change", changed, err)
+	}
+
+	snapshots, err := store.snapshots("Example.IO")
+	if err != nil {
+		t.Fatalf("snapshots failed: %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].ScannedAt.Equal(first) || !snapshots[1].ScannedAt.Equal(second) {
+		t.Fatalf("Unexpected snapshots: %+v", snapshots)
+	}
+
+	// Reordered name servers are not a change
+	expected := []FieldChange{{Field: "registrar", Old: "Gandi", New: "MarkMonitor"}}
+	if changes := diffSnapshots(snapshots[0].Info, snapshots[1].Info); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("diffSnapshots() = %v; expected %v", changes, expected)
+	}
+
+	// Failed lookups are not stored
+	if snapshots, _ := store.snapshots("example.zz"); len(snapshots) != 0 {
+		t.Errorf("Expected no snapshots for a failed lookup, got %d", len(snapshots))
+	}
+}
+
+func TestHistoryStoreLastScan(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	if last, err := store.lastScan("example.com"); err != nil || !last.IsZero() {
+		t.Errorf("lastScan() on an empty store = %v, %v; expected zero time", last, err)
+	}
+
+	first := time.Date(2026, 10, 1, 3, 0, 0, 0, time.UTC)
+	store.record(Result{TargetDomain: "example.com"}, nil, first)
+	store.record(Result{TargetDomain: "other.com"}, nil, first.Add(time.Hour))
+
+	if last, err := store.lastScan("example.com"); err != nil || !last.Equal(first) {
+		t.Errorf("lastScan() = %v, %v; expected %v", last, err, first)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// TimelineEntry is one snapshot in a domain's history with the changes
+// since the previous snapshot
+type TimelineEntry struct {
+	ScannedAt    time.Time     `json:"scanned_at"`
+	Organization string        `json:"organization"`
+	Registrar    string        `json:"registrar"`
+	NameServers  []string      `json:"name_servers"`
+	ExpiryDate   string        `json:"expiry_date"`
+	Changes      []FieldChange `json:"changes,omitempty"`
+}
+
+// DomainTimeline is the `history` subcommand's JSON output
+type DomainTimeline struct {
+	Domain   string          `json:"domain"`
+	Timeline []TimelineEntry `json:"timeline"`
+}
+
+// runHistory implements `tldscanner history <domain>`
+func runHistory(args []string) int {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultHistoryPath(), "Path to the history database")
+	jsonOutput := fs.Bool("json", false, "Output the timeline as JSON")
+	all := fs.Bool("all", false, "Include snapshots without changes in text output")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s history [OPTIONS] <domain>\n\n", os.Args[0])
+		fmt.Printf("Prints the observed WHOIS states of a domain recorded by scans run with\n")
+		fmt.Printf("-history, with the differences between consecutive snapshots.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	domain := strings.ToLower(fs.Arg(0))
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s No history database at %s (record scans with -history)\n", ColorRed, ColorReset, *dbPath)
+		return ExitUsage
+	}
+	store, err := openHistory(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	defer store.Close()
+
+	snapshots, err := store.snapshots(domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if len(snapshots) == 0 {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s No history recorded for %s\n", ColorYellow, ColorReset, domain)
+		return ExitNoMatches
+	}
+
+	timeline := buildTimeline(domain, snapshots)
+	if *jsonOutput {
+		data, err := json.MarshalIndent(timeline, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		fmt.Println(string(data))
+		return ExitMatches
+	}
+
+	fmt.Print(formatTimeline(timeline, *all))
+	return ExitMatches
+}
+
+// buildTimeline pairs every snapshot with its changes from the previous one
+func buildTimeline(domain string, snapshots []Snapshot) DomainTimeline {
+	timeline := DomainTimeline{Domain: domain}
+	for i, snapshot := range snapshots {
+		entry := TimelineEntry{
+			ScannedAt:    snapshot.ScannedAt,
+			Organization: snapshot.Info.Organization,
+			Registrar:    snapshot.Info.Registrar,
+			NameServers:  snapshot.Info.NameServers,
+			ExpiryDate:   snapshot.Info.ExpiryDate,
+		}
+		if i > 0 {
+			entry.Changes = diffSnapshots(snapshots[i-1].Info, snapshot.Info)
+		}
+		timeline.Timeline = append(timeline.Timeline, entry)
+	}
+	return timeline
+}
+
+// formatTimeline renders the first observed state in full, then the
+// changes of every later snapshot. Unchanged snapshots are listed only
+// with showAll.
+func formatTimeline(timeline DomainTimeline, showAll bool) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%s=== HISTORY: %s ===%s\n", ColorCyan, timeline.Domain, ColorReset))
+
+	for i, entry := range timeline.Timeline {
+		date := entry.ScannedAt.Local().Format("2006-01-02 15:04")
+		if i == 0 {
+			output.WriteString(fmt.Sprintf("%s  first seen\n", date))
+			output.WriteString(fmt.Sprintf("    Organization: %s\n", entry.Organization))
+			output.WriteString(fmt.Sprintf("    Registrar: %s\n", entry.Registrar))
+			output.WriteString(fmt.Sprintf("    Name Servers: %s\n", strings.Join(entry.NameServers, ", ")))
+			output.WriteString(fmt.Sprintf("    Expires: %s\n", entry.ExpiryDate))
+			continue
+		}
+		if len(entry.Changes) == 0 {
+			if showAll {
+				output.WriteString(fmt.Sprintf("%s  no changes\n", date))
+			}
+			continue
+		}
+		output.WriteString(fmt.Sprintf("%s  %d change(s)\n", date, len(entry.Changes)))
+		for _, change := range entry.Changes {
+			output.WriteString(fmt.Sprintf("    %s: %s%s%s -> %s%s%s\n", change.Field,
+				ColorRed, change.Old, ColorReset, ColorGreen, change.New, ColorReset))
+		}
+	}
+
+	last := timeline.Timeline[len(timeline.Timeline)-1]
+	output.WriteString(fmt.Sprintf("%d snapshots, last seen %s\n", len(timeline.Timeline), last.ScannedAt.Local().Format("2006-01-02 15:04")))
+	return output.String()
+}
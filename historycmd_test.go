@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshots := []Snapshot{
+		{ScannedAt: start, Info: DomainInfo{Organization: "Example Corp", Registrar: "Gandi", ExpiryDate: "2027-01-01"}},
+		{ScannedAt: start.Add(24 * time.Hour), Info: DomainInfo{Organization: "Example Corp", Registrar: "Gandi", ExpiryDate: "2027-01-01"}},
+		{ScannedAt: start.Add(48 * time.Hour), Info: DomainInfo{Organization: "Example Corp", Registrar: "MarkMonitor", ExpiryDate: "2028-01-01"}},
+	}
+
+	timeline := buildTimeline("example.io", snapshots)
+	if len(timeline.Timeline) != 3 {
+		t.Fatalf("Expected 3 timeline entries, got %d", len(timeline.Timeline))
+	}
+	if len(timeline.Timeline[1].Changes) != 0 || len(timeline.Timeline[2].Changes) != 2 {
+		t.Errorf("Unexpected changes: %+v", timeline.Timeline)
+	}
+
+	text := stripANSI(formatTimeline(timeline, false))
+	if !strings.Contains(text, "registrar: Gandi -> MarkMonitor") || !strings.Contains(text, "expiry_date: 2027-01-01 -> 2028-01-01") {
+		t.Errorf("Timeline text is missing changes:\n%s", text)
+	}
+	if strings.Contains(text, "no changes") {
+		t.Errorf("Unchanged snapshots should be hidden without -all:\n%s", text)
+	}
+	if !strings.Contains(formatTimeline(timeline, true), "no changes") {
+		t.Error("Unchanged snapshots should be listed with -all")
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// defaultICSReminders are the -ics-reminders lead times
+const defaultICSReminders = "30d,7d,1d"
+
+// icsDomain is one domain with an upcoming expiry in the calendar
+type icsDomain struct {
+	info    DomainInfo
+	expires time.Time
+	target  bool
+}
+
+// parseLeadTimes parses -ics-reminders: comma-separated lead times in days
+// ("30d"), weeks ("2w") or Go durations ("12h")
+func parseLeadTimes(s string) ([]time.Duration, error) {
+	var leads []time.Duration
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		var lead time.Duration
+		var err error
+		switch unit := field[len(field)-1]; unit {
+		case 'd', 'w':
+			var n int
+			n, err = strconv.Atoi(field[:len(field)-1])
+			lead = time.Duration(n) * 24 * time.Hour
+			if unit == 'w' {
+				lead *= 7
+			}
+		default:
+			lead, err = time.ParseDuration(field)
+		}
+		if err != nil || lead <= 0 {
+			return nil, fmt.Errorf("invalid reminder lead time %q: expected e.g. 30d, 2w or 12h", field)
+		}
+		leads = append(leads, lead)
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i] > leads[j] })
+	return leads, nil
+}
+
+// validateICS checks the calendar export options
+func validateICS(config Config) error {
+	_, err := parseLeadTimes(config.ICSReminders)
+	return err
+}
+
+// outputICS writes the upcoming expirations as an iCalendar file
+func outputICS(result Result, outputFile string, reminders string, recipients []age.Recipient) {
+	leads, _ := parseLeadTimes(reminders)
+	saveOutput(renderICS(result, leads, time.Now()), outputFile, recipients)
+}
+
+// icsDomains returns the target and matching domains expiring after now,
+// soonest first
+func icsDomains(result Result, now time.Time) []icsDomain {
+	var domains []icsDomain
+	if expires, ok := parseWhoisDateFor(result.TargetExpiry, result.TargetDomain); ok && expires.After(now) {
+		domains = append(domains, icsDomain{
+			info:    DomainInfo{Domain: result.TargetDomain, Organization: result.TargetOrg, ExpiryDate: result.TargetExpiry, Metadata: result.Metadata},
+			expires: expires,
+			target:  true,
+		})
+	}
+	for _, info := range result.MatchingDomains {
+		if expires, ok := info.expires(); ok && expires.After(now) {
+			domains = append(domains, icsDomain{info: info, expires: expires})
+		}
+	}
+	sort.SliceStable(domains, func(i, j int) bool { return domains[i].expires.Before(domains[j].expires) })
+	return domains
+}
+
+// renderICS renders one all-day event per upcoming expiry with a display
+// alarm at each lead time (RFC 5545). Event UIDs are derived from the
+// domain, so importing a later export moves renewed domains' events instead
+// of duplicating them.
+func renderICS(result Result, leads []time.Duration, now time.Time) []byte {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(foldICSLine(fmt.Sprintf(format, args...)))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//tldscanner//Domain Expiry Calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:%s", escapeICS("Domain expiry: "+result.TargetDomain))
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, domain := range icsDomains(result, now) {
+		info := domain.info
+		day := domain.expires.UTC()
+		kind := "Matching domain"
+		if domain.target {
+			kind = "Target domain"
+		}
+		details := []string{kind + " of " + firstNonEmpty(result.TargetOrg, result.TargetDomain)}
+		if info.Organization != "" {
+			details = append(details, "Organization: "+info.Organization)
+		}
+		if info.Registrar != "" {
+			details = append(details, "Registrar: "+info.Registrar)
+		}
+		details = append(details, "Expires: "+info.ExpiryDate)
+		if len(info.Metadata) > 0 {
+			details = append(details, "Metadata: "+formatMetadata(info.Metadata, ", "))
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:%s-expiry@tldscanner", info.Domain)
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART;VALUE=DATE:%s", day.Format("20060102"))
+		line("DTEND;VALUE=DATE:%s", day.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:%s", escapeICS("Domain expires: "+info.displayName()))
+		line("DESCRIPTION:%s", escapeICS(strings.Join(details, "\n")))
+		line("CATEGORIES:Domain renewal")
+		line("TRANSP:TRANSPARENT")
+		for _, lead := range leads {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("TRIGGER:%s", icsDuration(lead))
+			line("DESCRIPTION:%s", escapeICS(fmt.Sprintf("%s expires in %s", info.Domain, describeLead(lead))))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// icsDuration renders a lead time as a negative iCalendar duration,
+// e.g. -P30D or -PT12H
+func icsDuration(lead time.Duration) string {
+	if lead%(24*time.Hour) == 0 {
+		return fmt.Sprintf("-P%dD", lead/(24*time.Hour))
+	}
+	if lead%time.Hour == 0 {
+		return fmt.Sprintf("-PT%dH", lead/time.Hour)
+	}
+	return fmt.Sprintf("-PT%dM", lead/time.Minute)
+}
+
+// describeLead renders a lead time for humans: "30 days", "1 day", "12h0m0s"
+func describeLead(lead time.Duration) string {
+	if lead%(24*time.Hour) != 0 {
+		return lead.String()
+	}
+	if days := lead / (24 * time.Hour); days != 1 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return "1 day"
+}
+
+// escapeICS escapes a TEXT value
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine terminates a content line with CRLF, folding it into lines of
+// at most 75 octets without splitting UTF-8 sequences
+func foldICSLine(s string) string {
+	var b strings.Builder
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space
+		limit = 74
+	}
+	b.WriteString(s + "\r\n")
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestParseLeadTimes(t *testing.T) {
+	leads, err := parseLeadTimes("1d, 30d,2w,12h")
+	if err != nil {
+		t.Fatalf("parseLeadTimes failed: %v", err)
+	}
+	expected := []time.Duration{30 * 24 * time.Hour, 14 * 24 * time.Hour, 24 * time.Hour, 12 * time.Hour}
+	if !reflect.DeepEqual(leads, expected) {
+		t.Errorf("parseLeadTimes() = %v, expected %v", leads, expected)
+	}
+	if leads, err := parseLeadTimes(""); err != nil || len(leads) != 0 {
+		t.Errorf("Expected no reminders, got %v: %v", leads, err)
+	}
+	for _, s := range []string{"30", "xd", "0d", "-1w", "soon"} {
+		if _, err := parseLeadTimes(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestRenderICS(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	result := Result{
+		TargetDomain: "example.com",
+		TargetOrg:    "Example Corp",
+		TargetExpiry: "2027-03-01T05:00:00Z",
+		MatchingDomains: []DomainInfo{
+			{Domain: "example.io", Organization: "Example Corp", Registrar: "Gandi SAS, Paris", ExpiryDate: "2026-12-24T00:00:00Z"},
+			{Domain: "example.net", Organization: "Example Corp", ExpiryDate: "2026-01-01T00:00:00Z"},
+			{Domain: "example.shop", Organization: "Example Corp"},
+		},
+	}
+	ics := string(renderICS(result, []time.Duration{30 * 24 * time.Hour, 12 * time.Hour}, now))
+
+	if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Fatalf("Expected a CRLF terminated calendar, got:\n%s", ics)
+	}
+	// Expired and undated domains are left out; the rest soonest first
+	if strings.Count(ics, "BEGIN:VEVENT") != 2 || strings.Index(ics, "UID:example.io-expiry") > strings.Index(ics, "UID:example.com-expiry") {
+		t.Errorf("Expected example.io then example.com, got:\n%s", ics)
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	for _, want := range []string{
+		"DTSTAMP:20261016T093000Z\r\n",
+		"DTSTART;VALUE=DATE:20261224\r\nDTEND;VALUE=DATE:20261225\r\n",
+		"SUMMARY:Domain expires: example.io\r\n",
+		`DESCRIPTION:Matching domain of Example Corp\nOrganization: Example Corp\nR`,
+		"TRIGGER:-P30D\r\n",
+		"TRIGGER:-PT12H\r\n",
+		"DESCRIPTION:example.io expires in 30 days\r\n",
+		`Registrar: Gandi SAS\, Paris`,
+		"DTSTART;VALUE=DATE:20270301\r\n",
+		"DESCRIPTION:Target domain of Example Corp\\nOrganization: Example Corp\\nExpires: 2027-03-01T05:00:00Z\r\n",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Errorf("Expected %q in:\n%s", want, ics)
+		}
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := foldICSLine(line)
+	parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n ")
+	if strings.Join(parts, "") != line {
+		t.Errorf("Unfolding %q does not give back the line", folded)
+	}
+	for _, part := range parts {
+		if len(part) > 74 || !utf8.ValidString(part) {
+			t.Errorf("Bad fold %q", part)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ignoreList is the parsed -ignore-file: domains and organization
+// patterns whose domains are never reported as matches, signals or
+// lookalikes
+type ignoreList struct {
+	domains map[string]bool
+	orgs    []ignoreOrg
+}
+
+// ignoreOrg is an organization pattern of the ignore list: a
+// case-insensitive substring, or a regular expression written /like this/
+type ignoreOrg struct {
+	entry   string
+	pattern *regexp.Regexp
+}
+
+// loadIgnoreList reads an ignore file. Each line is a domain, or an
+// organization pattern prefixed with "org:"; # starts a comment. No path
+// means no ignore list.
+func loadIgnoreList(path string) (*ignoreList, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ignore file: %w", err)
+	}
+	defer file.Close()
+
+	list := &ignoreList{domains: make(map[string]bool)}
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if org, ok := strings.CutPrefix(line, "org:"); ok {
+			pattern, err := compileIgnoreOrg(strings.TrimSpace(org))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+			}
+			list.orgs = append(list.orgs, ignoreOrg{entry: line, pattern: pattern})
+			continue
+		}
+		domain, ok := normalizeDomain(line)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: invalid domain %q", path, n, line)
+		}
+		list.domains[domain] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	return list, nil
+}
+
+// compileIgnoreOrg compiles an organization pattern case-insensitively.
+// Plain text matches anywhere in the organization.
+func compileIgnoreOrg(org string) (*regexp.Regexp, error) {
+	if org == "" {
+		return nil, fmt.Errorf("empty organization pattern")
+	}
+	if len(org) > 2 && strings.HasPrefix(org, "/") && strings.HasSuffix(org, "/") {
+		pattern, err := regexp.Compile("(?i)" + org[1:len(org)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid organization pattern %s: %w", org, err)
+		}
+		return pattern, nil
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(org)), nil
+}
+
+// match returns the ignore entry covering a looked-up domain, empty when
+// none does. Organizations are compared in their original spelling and
+// transliterated.
+func (l *ignoreList) match(info *DomainInfo) string {
+	if l == nil {
+		return ""
+	}
+	if l.domains[info.Domain] {
+		return info.Domain
+	}
+	for _, org := range l.orgs {
+		for _, name := range []string{info.Organization, info.OrganizationLatin} {
+			if name != "" && org.pattern.MatchString(name) {
+				return org.entry
+			}
+		}
+	}
+	return ""
+}
+
+// ignoredDomains lists the domains of a scan the ignore list suppressed
+func ignoredDomains(domains []DomainInfo) []string {
+	var ignored []string
+	for _, info := range domains {
+		if info.Ignored != "" {
+			ignored = append(ignored, info.Domain)
+		}
+	}
+	sort.Strings(ignored)
+	return ignored
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeIgnoreFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ignore.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+func TestIgnoreList(t *testing.T) {
+	list, err := loadIgnoreList(writeIgnoreFile(t, `# Partner sites
+Example.SHOP
+
+# Resellers and namesakes
+org: Domain Reseller
+org:/^example (gmbh|kk)$/
+`))
+	if err != nil {
+		t.Fatalf("loadIgnoreList failed: %v", err)
+	}
+
+	tests := []struct {
+		info     DomainInfo
+		expected string
+	}{
+		{DomainInfo{Domain: "example.shop", Organization: "Example Corp"}, "example.shop"},
+		{DomainInfo{Domain: "example.biz", Organization: "Big DOMAIN RESELLER Ltd"}, "org: Domain Reseller"},
+		{DomainInfo{Domain: "example.de", Organization: "Example GmbH"}, "org:/^example (gmbh|kk)$/"},
+		{DomainInfo{Domain: "example.jp", Organization: "株式会社エグザンプル", OrganizationLatin: "Example KK"}, "org:/^example (gmbh|kk)$/"},
+		{DomainInfo{Domain: "example.io", Organization: "Example GmbH & Co"}, ""},
+		{DomainInfo{Domain: "example.net"}, ""},
+	}
+	for _, tt := range tests {
+		if got := list.match(&tt.info); got != tt.expected {
+			t.Errorf("match(%s, %q) = %q, expected %q", tt.info.Domain, tt.info.Organization, got, tt.expected)
+		}
+	}
+
+	var none *ignoreList
+	if none.match(&DomainInfo{Domain: "example.shop"}) != "" {
+		t.Error("Expected a nil list to ignore nothing")
+	}
+}
+
+func TestLoadIgnoreListErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		"example.shop\nnot a domain\n": ":2: invalid domain",
+		"org:\n":                       ":1: empty organization pattern",
+		"org:/(unclosed/\n":            ":1: invalid organization pattern",
+	} {
+		if _, err := loadIgnoreList(writeIgnoreFile(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q for %q, got %v", want, content, err)
+		}
+	}
+	if list, err := loadIgnoreList(""); list != nil || err != nil {
+		t.Errorf("Expected no list without a path, got %v, %v", list, err)
+	}
+}
+
+func TestScanDomainsIgnoreList(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	list, err := loadIgnoreList(writeIgnoreFile(t, "example.io\n"))
+	if err != nil {
+		t.Fatalf("loadIgnoreList failed: %v", err)
+	}
+	config := Config{Threads: 2, Format: "json", WhoisClient: mock, IgnoreList: list}
+	target := &DomainInfo{Domain: "example.com", Organization: "Example Corp"}
+
+	all, matching, _, _ := scanDomains([]string{"example.io", "example.net"}, target, config)
+	if len(matching) != 0 {
+		t.Errorf("Expected the ignored match to be dropped, got %+v", matching)
+	}
+	if got := ignoredDomains(all); !reflect.DeepEqual(got, []string{"example.io"}) {
+		t.Errorf("ignoredDomains() = %v", got)
+	}
+	for _, info := range all {
+		if info.Domain == "example.io" && info.MatchReason != "" {
+			t.Errorf("Expected the match reason to be cleared so monitor raises no alert, got %q", info.MatchReason)
+		}
+	}
+	if lookalikes := lookalikesOf(all, matching); len(lookalikes) != 1 || lookalikes[0].Domain != "example.net" {
+		t.Errorf("Expected ignored domains not to be lookalikes, got %+v", lookalikes)
+	}
+}
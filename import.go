@@ -0,0 +1,433 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// importTools are the recon tools whose output `tldscanner import` reads
+var importTools = []string{"amass", "subfinder", "dnstwist"}
+
+// importedDomain is a registrable domain reported by recon tools
+type importedDomain struct {
+	domain    string
+	tools     []string
+	technique string
+}
+
+// amassFQDN finds the names in amass 4 text output:
+// "www.example.com (FQDN) --> a_record --> 192.0.2.1 (IPAddress)"
+var amassFQDN = regexp.MustCompile(`(\S+) \(FQDN\)`)
+
+// registrableDomain returns the domain a host name is registered under,
+// "" for names that are not under a public suffix
+func registrableDomain(host string) string {
+	domain, ok := normalizeDomain(strings.TrimSuffix(host, "."))
+	if !ok {
+		return ""
+	}
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return ""
+	}
+	return registrable
+}
+
+// detectImportTool guesses the tool that wrote a file from its extension
+// and first line: dnstwist writes CSV or a JSON array, amass JSON lines
+// with "name" or "(FQDN)" text lines, subfinder one host per line or JSON
+// lines with "host"
+func detectImportTool(path string, data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	first, _, _ := bytes.Cut(trimmed, []byte("\n"))
+	switch {
+	case strings.EqualFold(filepath.Ext(path), ".csv"), bytes.HasPrefix(trimmed, []byte("[")):
+		return "dnstwist"
+	case bytes.HasPrefix(first, []byte("{")):
+		var line map[string]json.RawMessage
+		if json.Unmarshal(first, &line) == nil && line["host"] != nil {
+			return "subfinder"
+		}
+		return "amass"
+	case bytes.Contains(first, []byte("(FQDN)")):
+		return "amass"
+	}
+	return "subfinder"
+}
+
+// parseImport returns the host names or domains of a tool's output, with
+// the technique dnstwist generated each with
+func parseImport(tool string, data []byte) (map[string]string, error) {
+	switch tool {
+	case "amass":
+		return parseAmass(data)
+	case "subfinder":
+		return parseSubfinder(data)
+	case "dnstwist":
+		return parseDnstwist(data)
+	}
+	return nil, fmt.Errorf("unknown tool %q (valid: %s)", tool, strings.Join(importTools, ", "))
+}
+
+// parseAmass reads amass enum -json output, or its text output
+func parseAmass(data []byte) (map[string]string, error) {
+	hosts := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "{"):
+			var record struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			hosts[record.Name] = ""
+		case strings.Contains(line, "(FQDN)"):
+			for _, match := range amassFQDN.FindAllStringSubmatch(line, -1) {
+				hosts[match[1]] = ""
+			}
+		default:
+			hosts[strings.Fields(line)[0]] = ""
+		}
+	}
+	return hosts, nil
+}
+
+// parseSubfinder reads subfinder output: one host per line, "host,source"
+// lines with -cs, or JSON lines with -oJ
+func parseSubfinder(data []byte) (map[string]string, error) {
+	hosts := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			var record struct {
+				Host string `json:"host"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			line = record.Host
+		}
+		host, _, _ := strings.Cut(line, ",")
+		hosts[host] = ""
+	}
+	return hosts, nil
+}
+
+// parseDnstwist reads dnstwist --format csv or json output. Permutations
+// without DNS records, which dnstwist found unregistered, and the original
+// domain are left out.
+func parseDnstwist(data []byte) (map[string]string, error) {
+	var rows []map[string]string
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		var records []map[string]interface{}
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("invalid dnstwist JSON: %w", err)
+		}
+		for _, record := range records {
+			row := make(map[string]string)
+			for key, value := range record {
+				if value != nil {
+					row[key] = strings.Trim(fmt.Sprint(value), "[]")
+				}
+			}
+			rows = append(rows, row)
+		}
+	} else {
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid dnstwist CSV: %w", err)
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		header := records[0]
+		for _, record := range records[1:] {
+			row := make(map[string]string)
+			for i, value := range record {
+				if i < len(header) {
+					// Older versions name the columns domain-name, dns-a...
+					row[strings.ReplaceAll(header[i], "-", "_")] = value
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	domains := make(map[string]string)
+	for _, row := range rows {
+		domain := firstNonEmpty(row["domain"], row["domain_name"])
+		if domain == "" || row["fuzzer"] == "*original" || !dnstwistRegistered(row) {
+			continue
+		}
+		domains[domain] = dnstwistTechnique(row["fuzzer"])
+	}
+	return domains, nil
+}
+
+// dnstwistRegistered reports whether dnstwist resolved any DNS record for
+// a permutation
+func dnstwistRegistered(row map[string]string) bool {
+	for key, value := range row {
+		if strings.HasPrefix(key, "dns_") && strings.TrimSpace(value) != "" && value != "!ServFail" {
+			return true
+		}
+	}
+	return false
+}
+
+// dnstwistTechnique maps a dnstwist fuzzer to the technique of a candidate
+func dnstwistTechnique(fuzzer string) string {
+	switch fuzzer {
+	case "homoglyph":
+		return TechniqueHomoglyph
+	case "tld-swap":
+		return TechniqueTLD
+	}
+	return TechniquePermutation
+}
+
+// importFiles reads the registrable domains of recon tool outputs, merged
+// across files. tool is "auto" to detect the tool of each file.
+func importFiles(paths []string, tool string) ([]importedDomain, error) {
+	byDomain := make(map[string]*importedDomain)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		fileTool := tool
+		if fileTool == "auto" {
+			fileTool = detectImportTool(path, data)
+		}
+		hosts, err := parseImport(fileTool, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		count := 0
+		for host, technique := range hosts {
+			domain := registrableDomain(host)
+			if domain == "" {
+				continue
+			}
+			imported := byDomain[domain]
+			if imported == nil {
+				imported = &importedDomain{domain: domain}
+				byDomain[domain] = imported
+				count++
+			}
+			if !containsString(imported.tools, fileTool) {
+				imported.tools = append(imported.tools, fileTool)
+				sort.Strings(imported.tools)
+			}
+			if imported.technique == "" {
+				imported.technique = technique
+			}
+		}
+		fmt.Printf("%s[INFO]%s Imported %d new domains from %s (%s, %d names)\n", ColorBlue, ColorReset, count, path, fileTool, len(hosts))
+	}
+
+	var domains []importedDomain
+	for _, imported := range byDomain {
+		domains = append(domains, *imported)
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].domain < domains[j].domain })
+	return domains, nil
+}
+
+// annotateImported records the tools that reported each domain, and the
+// technique they derived it with when the scan did not set one
+func annotateImported(domains []DomainInfo, imported map[string]importedDomain) {
+	for i := range domains {
+		if source, ok := imported[domains[i].Domain]; ok {
+			domains[i].ImportedFrom = source.tools
+			if domains[i].Technique == "" {
+				domains[i].Technique = source.technique
+			}
+		}
+	}
+}
+
+// withoutDomains returns the domains whose name is not in drop
+func withoutDomains(domains []DomainInfo, drop map[string]importedDomain) []DomainInfo {
+	var kept []DomainInfo
+	for _, info := range domains {
+		if _, ok := drop[info.Domain]; !ok {
+			kept = append(kept, info)
+		}
+	}
+	return kept
+}
+
+// mergeImported merges the scan of imported domains into an earlier result
+// of the same target: their outcomes replace the earlier ones
+func mergeImported(result *Result, scanned Result, allResults []DomainInfo, imported map[string]importedDomain) {
+	result.MatchingDomains = withoutDomains(result.MatchingDomains, imported)
+	result.SignalDomains = withoutDomains(result.SignalDomains, imported)
+	if scanned.Lookalikes != nil {
+		result.Lookalikes = append(withoutDomains(result.Lookalikes, imported), scanned.Lookalikes...)
+		sortByRisk(result.Lookalikes)
+		result.TotalLookalikes = len(result.Lookalikes)
+	}
+	skipped := scanSkips{domains: scanned.SkippedDomains, truncated: scanned.Truncated, aborted: scanned.Aborted}
+	for _, domain := range result.SkippedDomains {
+		if _, ok := imported[domain]; !ok {
+			skipped.domains = append(skipped.domains, domain)
+		}
+	}
+	mergeRetried(result, allResults, scanned.MatchingDomains, scanned.SignalDomains, skipped)
+	result.SuppressedDomains = append(result.SuppressedDomains, scanned.SuppressedDomains...)
+}
+
+// runImport implements `tldscanner import <file>...`: the domains found by
+// other recon tools are looked up like scan candidates, recorded in the
+// history database and reported, or merged into an earlier result
+func runImport(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	tool := fs.String("tool", "auto", "Tool that wrote the files: auto, "+strings.Join(importTools, ", "))
+	into := fs.String("into", "", "JSON result (saved with -all) to merge the imported domains into, updated in place unless -o is given")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s import [OPTIONS] <amass.json|subfinder.txt|dnstwist.csv>...\n\n", os.Args[0])
+		fmt.Printf("Imports the domains found by amass, subfinder or dnstwist: their host\n")
+		fmt.Printf("names are reduced to registrable domains, looked up and matched like scan\n")
+		fmt.Printf("candidates, recorded in the history database and reported in any -format.\n")
+		fmt.Printf("With -into they are merged into an earlier result of the same target.\n")
+		fmt.Printf("Scan options are the same as for a regular scan.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	applyImpliedFlags(&config)
+
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+	if *tool != "auto" && !containsString(importTools, *tool) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s unknown tool %q (valid: auto, %s)\n", ColorRed, ColorReset, *tool, strings.Join(importTools, ", "))
+		return ExitUsage
+	}
+
+	var earlier Result
+	if *into != "" {
+		var err error
+		if earlier, err = readResult(*into); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		config.Domain = earlier.TargetDomain
+		config.Targets = nil
+		for _, target := range earlier.GroupTargets {
+			config.Targets = append(config.Targets, target.Domain)
+		}
+		if config.Output == "" && config.OutputAll == "" {
+			config.Output = *into
+			config.Format = "json"
+		}
+	}
+	if config.Domain == "" {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s -d or -into is required to match the imported domains against a target\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	if config.Monitor || config.Portfolio != "" || config.DomainsFile != "" {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s import does not support -monitor, -portfolio or -domains-file\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	// The imported domains are the point of aggregation, so they are always
+	// recorded
+	config.History = true
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := resolveCredentials(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := startDiagnostics(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if config.pipedOutput() {
+		os.Stdout = os.Stderr
+	}
+	printBanner()
+
+	domains, err := importFiles(fs.Args(), *tool)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	imported := make(map[string]importedDomain)
+	for _, domain := range domains {
+		// The targets themselves are never lookalikes
+		if !config.isTarget(domain.domain) {
+			imported[domain.domain] = domain
+			config.ImportDomains = append(config.ImportDomains, domain.domain)
+		}
+	}
+	if len(imported) == 0 {
+		fmt.Printf("%s[INFO]%s No domains to import besides the targets\n", ColorBlue, ColorReset)
+		return ExitNoMatches
+	}
+
+	startTime := time.Now()
+	config, err = withOutputRun(config, config.Domain, startTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	result, allResults, err := scan(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	for _, domains := range [][]DomainInfo{allResults, result.AllDomains, result.MatchingDomains, result.SignalDomains, result.Lookalikes} {
+		annotateImported(domains, imported)
+	}
+	if *into != "" {
+		mergeImported(&earlier, result, allResults, imported)
+		earlier.Timing = result.Timing
+		classifyOwnership(&earlier, config.KnownAssets)
+		result = earlier
+	}
+
+	recordHistory(result, allResults, startTime, config)
+	outputStarted := time.Now()
+	writeOutput(result, config)
+	linkLatestRun(config)
+	exportTelemetry(result, outputStarted)
+	printSummary(result)
+	return exitCode(result, config.ErrorThreshold)
+}
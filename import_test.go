@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectImportTool(t *testing.T) {
+	tests := []struct {
+		path, data, want string
+	}{
+		{"out.csv", "fuzzer,domain,dns_a\n", "dnstwist"},
+		{"out.json", `[{"fuzzer":"*original","domain":"example.com"}]`, "dnstwist"},
+		{"amass.json", `{"name":"www.example.com","domain":"example.com"}` + "\n", "amass"},
+		{"amass.txt", "www.example.com (FQDN) --> a_record --> 192.0.2.1 (IPAddress)\n", "amass"},
+		{"subs.json", `{"host":"www.example.com","input":"example.com","source":"crtsh"}` + "\n", "subfinder"},
+		{"subs.txt", "www.example.com\nmail.example.com\n", "subfinder"},
+	}
+	for _, tt := range tests {
+		if got := detectImportTool(tt.path, []byte(tt.data)); got != tt.want {
+			t.Errorf("detectImportTool(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseDnstwist(t *testing.T) {
+	csv := "fuzzer,domain,dns_a,dns_mx\n" +
+		"*original,example.com,192.0.2.1,\n" +
+		"homoglyph,examp1e.com,192.0.2.2,\n" +
+		"tld-swap,example.net,,mx.example.net\n" +
+		"addition,examplea.com,,\n"
+	domains, err := parseDnstwist([]byte(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 2 || domains["examp1e.com"] != TechniqueHomoglyph || domains["example.net"] != TechniqueTLD {
+		t.Errorf("Expected the registered permutations only, got %v", domains)
+	}
+
+	json := `[{"fuzzer":"bitsquatting","domain":"exbmple.com","dns_a":["192.0.2.3"]},{"fuzzer":"insertion","domain":"exaample.com"}]`
+	if domains, err = parseDnstwist([]byte(json)); err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 1 || domains["exbmple.com"] != TechniquePermutation {
+		t.Errorf("Unexpected JSON permutations %v", domains)
+	}
+}
+
+func TestImportFiles(t *testing.T) {
+	dir := t.TempDir()
+	amass := filepath.Join(dir, "amass.json")
+	subfinder := filepath.Join(dir, "subs.txt")
+	if err := os.WriteFile(amass, []byte(`{"name":"www.example.co.uk"}`+"\n"+`{"name":"shop.example-store.com"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(subfinder, []byte("mail.example.co.uk\nco.uk\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := importFiles([]string{amass, subfinder}, "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 2 || domains[0].domain != "example-store.com" || domains[1].domain != "example.co.uk" {
+		t.Fatalf("Expected the registrable domains, got %+v", domains)
+	}
+	if strings.Join(domains[1].tools, ",") != "amass,subfinder" {
+		t.Errorf("Expected both tools for example.co.uk, got %v", domains[1].tools)
+	}
+
+	if _, err := importFiles([]string{amass}, "nmap"); err == nil {
+		t.Error("Expected an error for an unknown tool")
+	}
+}
+
+func TestMergeImported(t *testing.T) {
+	result := Result{
+		TargetDomain:    "example.com",
+		AllDomains:      []DomainInfo{{Domain: "example.de"}, {Domain: "examp1e.com", Error: "timeout"}},
+		MatchingDomains: []DomainInfo{{Domain: "example.de"}},
+		SkippedDomains:  []string{"example.fr"},
+		TotalScanned:    2,
+		TotalMatches:    1,
+		TotalErrors:     1,
+	}
+	scanned := Result{
+		MatchingDomains: []DomainInfo{{Domain: "examp1e.com", ImportedFrom: []string{"dnstwist"}}},
+		Lookalikes:      []DomainInfo{},
+	}
+	allResults := []DomainInfo{{Domain: "examp1e.com", ImportedFrom: []string{"dnstwist"}}, {Domain: "example-store.com"}}
+	imported := map[string]importedDomain{"examp1e.com": {domain: "examp1e.com"}, "example-store.com": {domain: "example-store.com"}}
+
+	mergeImported(&result, scanned, allResults, imported)
+	if result.TotalMatches != 2 || result.TotalErrors != 0 || len(result.AllDomains) != 3 {
+		t.Errorf("Unexpected totals %d matches, %d errors, %d domains", result.TotalMatches, result.TotalErrors, len(result.AllDomains))
+	}
+	if strings.Join(result.SkippedDomains, ",") != "example.fr" {
+		t.Errorf("Expected the earlier skipped domains to be kept, got %v", result.SkippedDomains)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// githubAPIURL is the GitHub REST API, a variable so tests can point it at a
+// local server
+var githubAPIURL = "https://api.github.com"
+
+var (
+	jiraProjectPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	githubRepoPattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+)
+
+// issueLabel is on every issue tldscanner opens, so its issues can be
+// found again
+const issueLabel = "tldscanner"
+
+// issueLabels returns issueLabel followed by the -issue-labels labels
+func issueLabels(list string) []string {
+	labels := []string{issueLabel}
+	for _, label := range strings.Split(list, ",") {
+		if label = strings.TrimSpace(label); label != "" && !containsString(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// issueTitle is the title of the issue opened for a finding
+func issueTitle(target string, info DomainInfo) string {
+	return fmt.Sprintf("Lookalike of %s registered: %s", target, info.Domain)
+}
+
+// issueDescription describes a finding as a bulleted list of its fields,
+// which reads the same in Jira wiki markup and Markdown
+func issueDescription(info DomainInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s is registered to %s and resolves to a live host.\n\n", info.Domain, firstNonEmpty(info.Organization, "an unknown registrant"))
+	details := pagingDetails(info)
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value := details[key]; value != "" && value != "0" {
+			fmt.Fprintf(&b, "* %s: %s\n", key, value)
+		}
+	}
+	return b.String()
+}
+
+// jiraNotifier opens a Jira ticket per new high-risk finding. Each ticket
+// is labelled with the finding's deduplication key, which is searched for
+// before opening another.
+type jiraNotifier struct {
+	baseURL    string
+	project    string
+	issueType  string
+	labels     []string
+	username   string
+	token      string
+	thresholds pageThresholds
+	timeout    time.Duration
+}
+
+func (j *jiraNotifier) Name() string {
+	return "Jira"
+}
+
+// authorization uses basic auth with the account email on Jira Cloud and a
+// personal access token on Jira Data Center, which has no username
+func (j *jiraNotifier) authorization() string {
+	if j.username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(j.username+":"+j.token))
+	}
+	return "Bearer " + j.token
+}
+
+func (j *jiraNotifier) Notify(n Notification) error {
+	client := &http.Client{Timeout: j.timeout}
+	headers := map[string]string{"Authorization": j.authorization()}
+	base := strings.TrimSuffix(j.baseURL, "/")
+	for _, info := range pageableDomains(n, j.thresholds, j.timeout) {
+		key := pagingDedupKey(n.Result.TargetDomain, info.Domain)
+
+		var found struct {
+			Total int `json:"total"`
+		}
+		query := url.Values{
+			"jql":        {fmt.Sprintf("project = %q AND labels = %q", j.project, key)},
+			"maxResults": {"1"},
+			"fields":     {"key"},
+		}
+		if err := doJSON(client, http.MethodGet, base+"/rest/api/2/search?"+query.Encode(), headers, nil, &found); err != nil {
+			return fmt.Errorf("%s: %w", info.Domain, err)
+		}
+		if found.Total > 0 {
+			continue
+		}
+
+		issue := map[string]interface{}{
+			"fields": map[string]interface{}{
+				"project":     map[string]string{"key": j.project},
+				"issuetype":   map[string]string{"name": j.issueType},
+				"summary":     truncate(issueTitle(n.Result.TargetDomain, info), 255),
+				"description": issueDescription(info),
+				"labels":      append(append([]string{}, j.labels...), key),
+			},
+		}
+		if err := postJSON(client, base+"/rest/api/2/issue", headers, issue); err != nil {
+			return fmt.Errorf("%s: %w", info.Domain, err)
+		}
+	}
+	return nil
+}
+
+// githubIssue is the part of a GitHub issue the notifier reads
+type githubIssue struct {
+	Body string `json:"body"`
+}
+
+// githubNotifier opens a GitHub issue per new high-risk finding. The
+// finding's deduplication key is hidden in the issue body; issues labelled
+// issueLabel, open or closed, are listed once per notification to find
+// the findings already reported.
+type githubNotifier struct {
+	repo       string
+	labels     []string
+	token      string
+	thresholds pageThresholds
+	timeout    time.Duration
+}
+
+func (g *githubNotifier) Name() string {
+	return "GitHub"
+}
+
+func (g *githubNotifier) Notify(n Notification) error {
+	domains := pageableDomains(n, g.thresholds, g.timeout)
+	if len(domains) == 0 {
+		return nil
+	}
+
+	client := &http.Client{Timeout: g.timeout}
+	headers := map[string]string{
+		"Authorization":        "Bearer " + g.token,
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	endpoint := githubAPIURL + "/repos/" + g.repo + "/issues"
+	reported, err := g.reportedKeys(client, endpoint, headers)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range domains {
+		key := pagingDedupKey(n.Result.TargetDomain, info.Domain)
+		if reported[key] {
+			continue
+		}
+		issue := map[string]interface{}{
+			"title":  issueTitle(n.Result.TargetDomain, info),
+			"body":   issueDescription(info) + "\n<!-- " + key + " -->\n",
+			"labels": g.labels,
+		}
+		if err := postJSON(client, endpoint, headers, issue); err != nil {
+			return fmt.Errorf("%s: %w", info.Domain, err)
+		}
+	}
+	return nil
+}
+
+// reportedKeys returns the deduplication keys found in the repository's
+// issueLabel issues
+func (g *githubNotifier) reportedKeys(client *http.Client, endpoint string, headers map[string]string) (map[string]bool, error) {
+	reported := map[string]bool{}
+	for page := 1; ; page++ {
+		var issues []githubIssue
+		query := url.Values{
+			"labels":   {issueLabel},
+			"state":    {"all"},
+			"per_page": {"100"},
+			"page":     {fmt.Sprint(page)},
+		}
+		if err := doJSON(client, http.MethodGet, endpoint+"?"+query.Encode(), headers, nil, &issues); err != nil {
+			return nil, fmt.Errorf("failed to list issues: %w", err)
+		}
+		for _, issue := range issues {
+			start := strings.Index(issue.Body, "<!-- tldscanner:")
+			if start < 0 {
+				continue
+			}
+			rest := issue.Body[start+len("<!-- "):]
+			if end := strings.Index(rest, " -->"); end >= 0 {
+				reported[rest[:end]] = true
+			}
+		}
+		if len(issues) < 100 {
+			return reported, nil
+		}
+	}
+}
+
+// validateIssues checks the issue tracker options
+func validateIssues(config Config) error {
+	if config.JiraProject != "" {
+		if !jiraProjectPattern.MatchString(config.JiraProject) {
+			return fmt.Errorf("invalid Jira project key %q (e.g. SEC)", config.JiraProject)
+		}
+		u, err := url.Parse(config.JiraURL)
+		if config.JiraURL == "" || err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("-jira requires -jira-url, the base URL of the Jira site (e.g. https://example.atlassian.net)")
+		}
+		if strings.TrimSpace(config.JiraIssueType) == "" {
+			return fmt.Errorf("-jira-issue-type must not be empty")
+		}
+	}
+	if config.GitHubRepo != "" && !githubRepoPattern.MatchString(config.GitHubRepo) {
+		return fmt.Errorf("invalid GitHub repository %q (expected owner/repo)", config.GitHubRepo)
+	}
+	for _, label := range issueLabels(config.IssueLabels) {
+		if strings.ContainsAny(label, " \t") {
+			return fmt.Errorf("invalid issue label %q: labels cannot contain spaces", label)
+		}
+	}
+	return nil
+}
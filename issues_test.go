@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFindingNotification is a monitor cycle with one new live match
+func newFindingNotification() Notification {
+	return Notification{
+		Monitor: true,
+		Result:  Result{TargetDomain: "example.com", MatchingDomains: []DomainInfo{{Domain: "localhost", Registrar: "NameCheap"}}},
+		Alerts:  []Alert{{Kind: AlertNewMatch, Domain: "localhost"}},
+	}
+}
+
+func TestIssueLabels(t *testing.T) {
+	if got := issueLabels(" phishing, tldscanner,,brand "); !reflect.DeepEqual(got, []string{"tldscanner", "phishing", "brand"}) {
+		t.Errorf("issueLabels() = %v", got)
+	}
+}
+
+func TestJiraNotifier(t *testing.T) {
+	var searches []string
+	var issues []map[string]interface{}
+	existing := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Basic bWVAZXhhbXBsZS5jb206dG9rZW4=" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/2/search":
+			searches = append(searches, r.URL.Query().Get("jql"))
+			json.NewEncoder(w).Encode(map[string]int{"total": existing})
+		case "/rest/api/2/issue":
+			var issue map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&issue)
+			issues = append(issues, issue)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	notifier := &jiraNotifier{
+		baseURL:   server.URL + "/",
+		project:   "SEC",
+		issueType: "Task",
+		labels:    []string{"tldscanner", "phishing"},
+		username:  "me@example.com",
+		token:     "token",
+		timeout:   5 * time.Second,
+	}
+	if err := notifier.Notify(newFindingNotification()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(searches) != 1 || searches[0] != `project = "SEC" AND labels = "tldscanner:example.com:localhost"` {
+		t.Errorf("Unexpected searches %q", searches)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(issues))
+	}
+	fields := issues[0]["fields"].(map[string]interface{})
+	if fields["summary"] != "Lookalike of example.com registered: localhost" ||
+		!reflect.DeepEqual(fields["labels"], []interface{}{"tldscanner", "phishing", "tldscanner:example.com:localhost"}) ||
+		!strings.Contains(fields["description"].(string), "* registrar: NameCheap\n") {
+		t.Errorf("Unexpected issue: %v", fields)
+	}
+
+	// A finding already ticketed is not ticketed again
+	existing = 1
+	if err := notifier.Notify(newFindingNotification()); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Errorf("Expected no new issue, got %d", len(issues))
+	}
+}
+
+func TestGitHubNotifier(t *testing.T) {
+	var issues []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/security/issues" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			if r.URL.Query().Get("labels") != "tldscanner" || r.URL.Query().Get("state") != "all" {
+				t.Errorf("Unexpected listing query %q", r.URL.RawQuery)
+			}
+			listed := []githubIssue{{Body: "unrelated"}}
+			for _, issue := range issues {
+				listed = append(listed, githubIssue{Body: issue["body"].(string)})
+			}
+			json.NewEncoder(w).Encode(listed)
+			return
+		}
+		var issue map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&issue)
+		issues = append(issues, issue)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	oldURL := githubAPIURL
+	githubAPIURL = server.URL
+	defer func() { githubAPIURL = oldURL }()
+
+	notifier := &githubNotifier{repo: "acme/security", labels: []string{"tldscanner"}, token: "token", timeout: 5 * time.Second}
+	for i := 0; i < 2; i++ {
+		if err := notifier.Notify(newFindingNotification()); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+	if len(issues) != 1 {
+		t.Fatalf("Expected 1 issue after two notifications, got %d", len(issues))
+	}
+	if issues[0]["title"] != "Lookalike of example.com registered: localhost" ||
+		!strings.HasSuffix(issues[0]["body"].(string), "<!-- tldscanner:example.com:localhost -->\n") {
+		t.Errorf("Unexpected issue: %v", issues[0])
+	}
+}
+
+func TestValidateIssues(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{}, false},
+		{Config{JiraProject: "SEC", JiraURL: "https://example.atlassian.net", JiraIssueType: "Task", IssueLabels: "phishing"}, false},
+		{Config{JiraProject: "SEC", JiraIssueType: "Task"}, true},
+		{Config{JiraProject: "sec", JiraURL: "https://example.atlassian.net", JiraIssueType: "Task"}, true},
+		{Config{JiraProject: "SEC", JiraURL: "example.atlassian.net", JiraIssueType: "Task"}, true},
+		{Config{GitHubRepo: "acme/security"}, false},
+		{Config{GitHubRepo: "acme"}, true},
+		{Config{GitHubRepo: "acme/security", IssueLabels: "needs triage"}, true},
+	}
+	for _, tt := range tests {
+		if err := validateIssues(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("validateIssues(%+v) error = %v, wantErr %t", tt.config, err, tt.wantErr)
+		}
+	}
+}
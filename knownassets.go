@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+)
+
+// Ownership classes of a domain against the -known-domains inventory
+const (
+	// ownershipKnown is a domain listed in the inventory
+	ownershipKnown = "known_owned"
+	// ownershipShadow matches the target organization but is missing from
+	// the inventory: registered outside the team managing the portfolio
+	ownershipShadow = "shadow"
+	// ownershipThirdParty is registered by someone else
+	ownershipThirdParty = "third_party"
+)
+
+// loadKnownDomains reads the -known-domains inventory, one domain per
+// line like -domains-file. No path means no inventory.
+func loadKnownDomains(path string) (map[string]bool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	domains, _, err := readDomainList(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known domains: %w", err)
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("known domains file %s lists no domains", path)
+	}
+	known := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		known[domain] = true
+	}
+	return known, nil
+}
+
+// ownershipOf classifies a registered domain: listed in the inventory,
+// matching the target without being listed, or someone else's
+func ownershipOf(domain string, matched bool, known map[string]bool) string {
+	switch {
+	case known[domain]:
+		return ownershipKnown
+	case matched:
+		return ownershipShadow
+	}
+	return ownershipThirdParty
+}
+
+// classifyOwnership labels the matches, signals, lookalikes and registered
+// domains of a result against the inventory and counts the shadow
+// registrations. Without an inventory nothing is labeled.
+func classifyOwnership(result *Result, known map[string]bool) {
+	if known == nil {
+		return
+	}
+	matched := make(map[string]bool, len(result.MatchingDomains))
+	for i := range result.MatchingDomains {
+		info := &result.MatchingDomains[i]
+		info.Ownership = ownershipOf(info.Domain, true, known)
+		matched[info.Domain] = true
+	}
+	result.TotalShadow = countShadow(result.MatchingDomains)
+	for _, list := range [][]DomainInfo{result.SignalDomains, result.Lookalikes, result.AllDomains} {
+		for i := range list {
+			if list[i].Error == "" {
+				list[i].Ownership = ownershipOf(list[i].Domain, matched[list[i].Domain], known)
+			}
+		}
+	}
+}
+
+// countShadow counts the shadow registrations among matches
+func countShadow(matches []DomainInfo) int {
+	count := 0
+	for _, info := range matches {
+		if info.Ownership == ownershipShadow {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKnownDomains(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "owned.txt")
+	os.WriteFile(path, []byte("# Official domains\nexample.com\nEXAMPLE.NET\n\nexample.co.uk  managed by the UK team\n"), 0644)
+
+	known, err := loadKnownDomains(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, domain := range []string{"example.com", "example.net", "example.co.uk"} {
+		if !known[domain] {
+			t.Errorf("Expected %s in the inventory", domain)
+		}
+	}
+	if len(known) != 3 {
+		t.Errorf("Expected 3 domains, got %v", known)
+	}
+
+	if known, err := loadKnownDomains(""); known != nil || err != nil {
+		t.Errorf("Expected no inventory without a path, got %v, %v", known, err)
+	}
+	empty := filepath.Join(dir, "empty.txt")
+	os.WriteFile(empty, []byte("# nothing yet\n"), 0644)
+	if _, err := loadKnownDomains(empty); err == nil {
+		t.Error("Expected an error for an empty inventory")
+	}
+}
+
+func TestClassifyOwnership(t *testing.T) {
+	known := map[string]bool{"example.net": true, "example.org": true}
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.net"}, {Domain: "example.shop"}},
+		SignalDomains:   []DomainInfo{{Domain: "example.xyz"}},
+		Lookalikes:      []DomainInfo{{Domain: "examp1e.com"}, {Domain: "example.org"}},
+		AllDomains:      []DomainInfo{{Domain: "example.shop"}, {Domain: "example.io", Error: "timeout"}},
+	}
+	classifyOwnership(&result, known)
+
+	expected := map[string]string{
+		"example.net":  ownershipKnown,
+		"example.shop": ownershipShadow,
+		"example.xyz":  ownershipThirdParty,
+		"examp1e.com":  ownershipThirdParty,
+		"example.org":  ownershipKnown,
+	}
+	for _, list := range [][]DomainInfo{result.MatchingDomains, result.SignalDomains, result.Lookalikes, result.AllDomains} {
+		for _, info := range list {
+			if info.Ownership != expected[info.Domain] {
+				t.Errorf("%s: ownership %q, expected %q", info.Domain, info.Ownership, expected[info.Domain])
+			}
+		}
+	}
+	if result.TotalShadow != 1 {
+		t.Errorf("Expected 1 shadow registration, got %d", result.TotalShadow)
+	}
+
+	// Without an inventory nothing is labeled
+	unlabeled := Result{MatchingDomains: []DomainInfo{{Domain: "example.shop"}}}
+	classifyOwnership(&unlabeled, nil)
+	if unlabeled.MatchingDomains[0].Ownership != "" || unlabeled.TotalShadow != 0 {
+		t.Errorf("Expected no labels without an inventory, got %+v", unlabeled)
+	}
+}
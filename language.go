@@ -0,0 +1,160 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// minLanguageHits is the least number of common words a page needs for its
+// language to be detected from its text
+const minLanguageHits = 5
+
+var (
+	htmlLangPattern = regexp.MustCompile(`(?is)<html[^>]*\slang\s*=\s*["']?([a-z0-9_-]+)`)
+	metaLangPattern = regexp.MustCompile(`(?is)<meta[^>]*http-equiv\s*=\s*["']?content-language["']?[^>]*\scontent\s*=\s*["']?([a-z0-9_-]+)`)
+)
+
+// languageWords are frequent short words telling the languages written in
+// Latin script apart
+var languageWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "with", "for", "your", "is", "are", "this", "that", "you", "our"},
+	"es": {"el", "la", "los", "las", "del", "y", "con", "para", "por", "que", "su", "una", "tu", "nuestro"},
+	"pt": {"o", "os", "da", "do", "das", "dos", "e", "com", "para", "não", "uma", "você", "seu", "sua"},
+	"fr": {"le", "les", "des", "du", "et", "avec", "pour", "vous", "votre", "est", "une", "sur", "dans", "nous"},
+	"de": {"der", "die", "das", "und", "mit", "für", "ist", "sie", "ihr", "nicht", "ein", "eine", "auf", "zu"},
+	"it": {"il", "gli", "della", "di", "e", "con", "per", "che", "è", "una", "sono", "non", "tuo", "nel"},
+	"nl": {"het", "een", "en", "van", "met", "voor", "is", "uw", "niet", "op", "zijn", "je", "ons"},
+	"tr": {"ve", "bir", "bu", "için", "ile", "da", "de", "çok", "daha", "sizin", "veya", "olan"},
+	"pl": {"i", "w", "z", "na", "nie", "się", "do", "jest", "że", "dla", "oraz", "twoje"},
+}
+
+// pageLanguage returns the language of a fetched page as a BCP 47 tag, e.g.
+// "es" or "es-MX", empty when it cannot be told. The language detected from
+// the visible text wins over the declared one, which kits often copy from
+// the cloned site; the declared region is kept when both agree.
+func pageLanguage(header http.Header, body []byte) string {
+	declared := declaredLanguage(header, body)
+	detected := detectLanguage(pageWords(body))
+	if detected == "" || strings.HasPrefix(declared, detected+"-") {
+		return firstNonEmpty(declared, detected)
+	}
+	return detected
+}
+
+// declaredLanguage returns the language a page declares in its html lang
+// attribute, a content-language meta tag or the Content-Language header
+func declaredLanguage(header http.Header, body []byte) string {
+	for _, pattern := range []*regexp.Regexp{htmlLangPattern, metaLangPattern} {
+		if m := pattern.FindSubmatch(body); m != nil {
+			if tag := normalizeLanguageTag(string(m[1])); tag != "" {
+				return tag
+			}
+		}
+	}
+	first, _, _ := strings.Cut(header.Get("Content-Language"), ",")
+	return normalizeLanguageTag(first)
+}
+
+// normalizeLanguageTag reduces a language tag to its language and region,
+// e.g. zh_hant_tw becomes zh-TW; empty for tags without a language
+func normalizeLanguageTag(tag string) string {
+	parts := strings.FieldsFunc(strings.TrimSpace(tag), func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 || len(parts[0]) < 2 || len(parts[0]) > 3 || !isLetters(parts[0]) {
+		return ""
+	}
+	language := strings.ToLower(parts[0])
+	for _, part := range parts[1:] {
+		if (len(part) == 2 && isLetters(part)) || (len(part) == 3 && strings.Trim(part, "0123456789") == "") {
+			return language + "-" + strings.ToUpper(part)
+		}
+	}
+	return language
+}
+
+// isLetters reports whether s consists of ASCII letters only
+func isLetters(s string) bool {
+	return strings.Trim(strings.ToLower(s), "abcdefghijklmnopqrstuvwxyz") == ""
+}
+
+// detectLanguage guesses the language of a page's words: from the script
+// when most letters are not Latin, else from the most frequent common
+// words. Empty when there is too little text.
+func detectLanguage(words []string) string {
+	if lang := scriptLanguage(words); lang != "" {
+		return lang
+	}
+	hits := make(map[string]int)
+	for _, word := range words {
+		for lang, common := range languageWords {
+			if containsString(common, word) {
+				hits[lang]++
+			}
+		}
+	}
+	best, bestHits, tied := "", 0, false
+	for lang, n := range hits {
+		switch {
+		case n > bestHits:
+			best, bestHits, tied = lang, n, false
+		case n == bestHits:
+			tied = true
+		}
+	}
+	if bestHits < minLanguageHits || tied {
+		return ""
+	}
+	return best
+}
+
+// scriptLanguage returns the language of a page written mostly in a script
+// used by one language, or the most common language of the script
+func scriptLanguage(words []string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, word := range words {
+		for _, r := range word {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			letters++
+			switch {
+			case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+				counts["ja"]++
+			case unicode.Is(unicode.Han, r):
+				counts["zh"]++
+			case unicode.Is(unicode.Hangul, r):
+				counts["ko"]++
+			case unicode.Is(unicode.Arabic, r):
+				counts["ar"]++
+			case unicode.Is(unicode.Hebrew, r):
+				counts["he"]++
+			case unicode.Is(unicode.Greek, r):
+				counts["el"]++
+			case unicode.Is(unicode.Thai, r):
+				counts["th"]++
+			case strings.ContainsRune("іїєґ", r):
+				counts["uk"]++
+			case unicode.Is(unicode.Cyrillic, r):
+				counts["ru"]++
+			}
+		}
+	}
+	// Japanese mixes kanji with kana, Ukrainian shares most Cyrillic letters
+	// with Russian: their own letters decide
+	if counts["ja"] > 0 && counts["ja"]*10 >= counts["zh"] {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	if counts["uk"] > 0 {
+		counts["uk"] += counts["ru"]
+		delete(counts, "ru")
+	}
+	for lang, n := range counts {
+		if n*2 > letters {
+			return lang
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPageLanguage(t *testing.T) {
+	spanish := `<p>Inicie sesión en su cuenta para ver los movimientos y las ofertas del mes con nuestro equipo, para que su banca sea una experiencia segura.</p>`
+	tests := []struct {
+		name     string
+		header   http.Header
+		body     string
+		expected string
+	}{
+		{"declared region kept", nil, `<html lang="es-mx">` + spanish, "es-MX"},
+		{"text beats copied declaration", nil, `<html lang="en">` + spanish, "es"},
+		{"meta tag", nil, `<meta http-equiv="Content-Language" content="pt_BR"><p>Oi</p>`, "pt-BR"},
+		{"header", http.Header{"Content-Language": {"de-DE, en"}}, `<p>Hallo</p>`, "de-DE"},
+		{"script", nil, `<p>ログインしてください。アカウント情報を確認します。</p>`, "ja"},
+		{"chinese", nil, `<p>请登录您的账户以查看最新优惠</p>`, "zh"},
+		{"ukrainian", nil, `<p>Увійдіть до свого облікового запису</p>`, "uk"},
+		{"too little text", nil, `<p>Coming soon</p>`, ""},
+	}
+	for _, tt := range tests {
+		header := tt.header
+		if header == nil {
+			header = http.Header{}
+		}
+		if got := pageLanguage(header, []byte(tt.body)); got != tt.expected {
+			t.Errorf("%s: pageLanguage() = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestNormalizeLanguageTag(t *testing.T) {
+	for tag, expected := range map[string]string{
+		"EN":         "en",
+		"zh_hant_tw": "zh-TW",
+		"es-419":     "es-419",
+		"x-klingon":  "",
+		"":           "",
+	} {
+		if got := normalizeLanguageTag(tag); got != expected {
+			t.Errorf("normalizeLanguageTag(%q) = %q, expected %q", tag, got, expected)
+		}
+	}
+}
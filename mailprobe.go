@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// smtpPort is the port mail exchangers are probed on, overridable in tests
+var smtpPort = "25"
+
+// maxMailHosts bounds the mail exchangers probed per domain
+const maxMailHosts = 3
+
+// MailProbe is the outcome of probing a domain's mail exchangers. Only the
+// greeting is read; no mail is sent.
+type MailProbe struct {
+	MX []string `json:"mx,omitempty"`
+	// ImplicitMX is set when the domain has no MX records, so mail goes to
+	// the domain's own address (RFC 5321)
+	ImplicitMX bool `json:"implicit_mx,omitempty"`
+	// NullMX is set when the domain declares it accepts no mail (RFC 7505)
+	NullMX bool   `json:"null_mx,omitempty"`
+	Host   string `json:"host,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	Banner string `json:"banner,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// enrichMail records whether a domain's mail exchangers accept SMTP
+// connections
+func enrichMail(info *DomainInfo, config Config) error {
+	timeout := time.Duration(config.Timeout) * time.Second
+	probe, err := lookupMailHosts(info.Domain, timeout)
+	if err != nil {
+		return err
+	}
+	if !probe.NullMX {
+		probeMailHosts(probe, config)
+	}
+	info.SMTP = probe
+	info.MailCapable = probe.Code == 220
+	return nil
+}
+
+// lookupMailHosts returns the domain's mail exchangers in preference order,
+// the domain itself when it has no MX records
+func lookupMailHosts(domain string, timeout time.Duration) (*MailProbe, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	records, err := net.DefaultResolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return &MailProbe{MX: []string{domain}, ImplicitMX: true}, nil
+		}
+		return nil, err
+	}
+	return mailHosts(records), nil
+}
+
+// mailHosts orders MX records by preference. A single "." exchanger is a
+// null MX.
+func mailHosts(records []*net.MX) *MailProbe {
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Pref < records[j].Pref })
+	probe := &MailProbe{}
+	for _, mx := range records {
+		if host := strings.TrimSuffix(mx.Host, "."); host != "" {
+			probe.MX = append(probe.MX, host)
+		}
+	}
+	probe.NullMX = len(records) == 1 && len(probe.MX) == 0
+	return probe
+}
+
+// probeMailHosts reads the SMTP greeting of the first mail exchangers until
+// one answers, then quits
+func probeMailHosts(probe *MailProbe, config Config) {
+	var failures []string
+	for i, host := range probe.MX {
+		if i == maxMailHosts {
+			break
+		}
+		code, banner, err := readSMTPGreeting(host, config)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", host, err))
+			continue
+		}
+		probe.Host, probe.Code, probe.Banner, probe.Error = host, code, truncate(banner, maxBannerLength), ""
+		return
+	}
+	probe.Error = strings.Join(failures, "; ")
+}
+
+// readSMTPGreeting connects to host's SMTP port, through Tor with -tor,
+// and returns the greeting's reply code and text
+func readSMTPGreeting(host string, config Config) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+
+	addr := net.JoinHostPort(host, smtpPort)
+	var conn net.Conn
+	var err error
+	if config.TorDialer != nil {
+		conn, err = config.TorDialer.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return 0, "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	text := textproto.NewConn(conn)
+	code, banner, err := text.ReadResponse(0)
+	if err != nil {
+		return 0, "", err
+	}
+	text.PrintfLine("QUIT")
+	return code, strings.Join(strings.Fields(banner), " "), nil
+}
+
+// mailReachability describes the mail probe of a domain for the text report
+func (d DomainInfo) mailReachability() string {
+	probe := d.SMTP
+	switch {
+	case probe.NullMX:
+		return "accepts no mail (null MX)"
+	case d.MailCapable:
+		return fmt.Sprintf("reachable, %s answered %q", probe.Host, probe.Banner)
+	case probe.Code != 0:
+		return fmt.Sprintf("%s refused connections (%d %s)", probe.Host, probe.Code, probe.Banner)
+	}
+	return fmt.Sprintf("unreachable (%s)", firstNonEmpty(probe.Error, "no mail exchanger"))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newFakeSMTPServer answers every connection with greeting and records the
+// commands it receives
+func newFakeSMTPServer(t *testing.T, greeting string) (string, chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	commands := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(greeting))
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			commands <- strings.TrimSpace(line)
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port, commands
+}
+
+func TestProbeMailHosts(t *testing.T) {
+	port, commands := newFakeSMTPServer(t, "220-mx.examp1e.com ESMTP\r\n220 ready\r\n")
+	saved := smtpPort
+	smtpPort = port
+	defer func() { smtpPort = saved }()
+
+	probe := &MailProbe{MX: []string{"127.0.0.1"}}
+	probeMailHosts(probe, Config{Timeout: 5})
+	if probe.Code != 220 || probe.Host != "127.0.0.1" || probe.Banner != "mx.examp1e.com ESMTP ready" {
+		t.Fatalf("Unexpected probe %+v", probe)
+	}
+	if cmd := <-commands; cmd != "QUIT" {
+		t.Errorf("Expected the probe to quit without sending mail, got %q", cmd)
+	}
+
+	info := DomainInfo{SMTP: probe, MailCapable: true}
+	if got := info.mailReachability(); got != `reachable, 127.0.0.1 answered "mx.examp1e.com ESMTP ready"` {
+		t.Errorf("mailReachability() = %q", got)
+	}
+}
+
+func TestProbeMailHostsRefused(t *testing.T) {
+	port, _ := newFakeSMTPServer(t, "554 no service\r\n")
+	saved := smtpPort
+	smtpPort = port
+	defer func() { smtpPort = saved }()
+
+	probe := &MailProbe{MX: []string{"127.0.0.1"}}
+	probeMailHosts(probe, Config{Timeout: 5})
+	if probe.Code != 554 {
+		t.Fatalf("Expected the refusal to be recorded, got %+v", probe)
+	}
+	if got := (DomainInfo{SMTP: probe}).mailReachability(); got != "127.0.0.1 refused connections (554 no service)" {
+		t.Errorf("mailReachability() = %q", got)
+	}
+}
+
+func TestMailHosts(t *testing.T) {
+	probe := mailHosts([]*net.MX{{Host: "mx2.examp1e.com.", Pref: 20}, {Host: "mx1.examp1e.com.", Pref: 10}})
+	if !reflect.DeepEqual(probe.MX, []string{"mx1.examp1e.com", "mx2.examp1e.com"}) || probe.NullMX {
+		t.Errorf("Expected exchangers in preference order, got %+v", probe)
+	}
+	if probe := mailHosts([]*net.MX{{Host: ".", Pref: 0}}); !probe.NullMX {
+		t.Errorf("Expected a null MX, got %+v", probe)
+	}
+	if got := (DomainInfo{SMTP: &MailProbe{NullMX: true}}).mailReachability(); got != "accepts no mail (null MX)" {
+		t.Errorf("mailReachability() = %q", got)
+	}
+}
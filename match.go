@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Signal is a weighted piece of evidence that a candidate may belong to the
+// target organization without being a hard organization match
+type Signal struct {
+	Name   string  `json:"name"`
+	Score  float64 `json:"score"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// registrarPivotSignal scores a candidate that shares the target's registrar
+// and was created within windowDays of the target. The closer the creation
+// dates, the higher the score.
+func registrarPivotSignal(target, candidate *DomainInfo, windowDays int) (Signal, bool) {
+	if windowDays <= 0 || target.Registrar == "" || candidate.Registrar == "" {
+		return Signal{}, false
+	}
+	if !strings.EqualFold(strings.TrimSpace(target.Registrar), strings.TrimSpace(candidate.Registrar)) {
+		return Signal{}, false
+	}
+
+	targetCreated, ok := target.created()
+	if !ok {
+		return Signal{}, false
+	}
+	candidateCreated, ok := candidate.created()
+	if !ok {
+		return Signal{}, false
+	}
+
+	days := math.Abs(candidateCreated.Sub(targetCreated).Hours() / 24)
+	if days > float64(windowDays) {
+		return Signal{}, false
+	}
+
+	return Signal{
+		Name:   "registrar_pivot",
+		Score:  math.Round((1-days/float64(windowDays))*100) / 100,
+		Detail: fmt.Sprintf("same registrar %q, created %.0f days from target", candidate.Registrar, days),
+	}, true
+}
+
+// mailDomainSet builds the set of mail domains that identify the target: the
+// target domain itself plus any comma-separated extras supplied by the user
+func mailDomainSet(targetDomain, extra string) map[string]bool {
+	domains := map[string]bool{strings.ToLower(targetDomain): true}
+	for _, d := range strings.Split(extra, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d != "" {
+			domains[d] = true
+		}
+	}
+	return domains
+}
+
+// emailDomainMatch returns the first contact email of info whose domain (or a
+// parent of it) is one of the target's mail domains
+func emailDomainMatch(info *DomainInfo, mailDomains map[string]bool) (string, bool) {
+	if len(mailDomains) == 0 {
+		return "", false
+	}
+	for _, email := range info.Emails {
+		at := strings.LastIndex(email, "@")
+		if at < 0 {
+			continue
+		}
+		domain := strings.ToLower(email[at+1:])
+		for domain != "" {
+			if mailDomains[domain] {
+				return email, true
+			}
+			dot := strings.Index(domain, ".")
+			if dot < 0 {
+				break
+			}
+			domain = domain[dot+1:]
+		}
+	}
+	return "", false
+}
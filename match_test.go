@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseWhoisDate(t *testing.T) {
+	tests := []struct {
+		input string
+		ok    bool
+	}{
+		{"2020-01-15T10:30:00Z", true},
+		{"2020-01-15", true},
+		{"15-Jan-2020", true},
+		{"2020.01.15", true},
+		{"", false},
+		{"not a date", false},
+	}
+
+	for _, test := range tests {
+		_, ok := parseWhoisDate(test.input)
+		if ok != test.ok {
+			t.Errorf("parseWhoisDate(%q) ok = %v; expected %v", test.input, ok, test.ok)
+		}
+	}
+}
+
+func TestRegistrarPivotSignal(t *testing.T) {
+	target := &DomainInfo{Domain: "example.com", Registrar: "MarkMonitor Inc.", CreatedDate: "2020-01-01"}
+
+	testCases := []struct {
+		name      string
+		candidate DomainInfo
+		expected  bool
+	}{
+		{"Same registrar, close date", DomainInfo{Registrar: "markmonitor inc.", CreatedDate: "2020-01-31"}, true},
+		{"Same registrar, distant date", DomainInfo{Registrar: "MarkMonitor Inc.", CreatedDate: "2023-01-01"}, false},
+		{"Different registrar", DomainInfo{Registrar: "GoDaddy", CreatedDate: "2020-01-02"}, false},
+		{"Unparseable date", DomainInfo{Registrar: "MarkMonitor Inc.", CreatedDate: "soon"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			signal, ok := registrarPivotSignal(target, &tc.candidate, 180)
+			if ok != tc.expected {
+				t.Fatalf("registrarPivotSignal() ok = %v; expected %v", ok, tc.expected)
+			}
+			if ok && (signal.Score <= 0 || signal.Score > 1) {
+				t.Errorf("Signal score %v out of range", signal.Score)
+			}
+		})
+	}
+}
+
+func TestEmailDomainMatch(t *testing.T) {
+	mailDomains := mailDomainSet("example.com", "example-mail.net, ")
+
+	testCases := []struct {
+		name     string
+		emails   []string
+		expected string
+	}{
+		{"Target domain", []string{"hostmaster@example.com"}, "hostmaster@example.com"},
+		{"Mail subdomain", []string{"dns@corp.example.com"}, "dns@corp.example.com"},
+		{"Extra mail domain", []string{"privacy@proxy.org", "admin@example-mail.net"}, "admin@example-mail.net"},
+		{"Lookalike domain", []string{"admin@notexample.com"}, ""},
+		{"No emails", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			email, ok := emailDomainMatch(&DomainInfo{Emails: tc.emails}, mailDomains)
+			if email != tc.expected || ok != (tc.expected != "") {
+				t.Errorf("emailDomainMatch() = %q, %v; expected %q", email, ok, tc.expected)
+			}
+		})
+	}
+}
+
+func TestEmailDomainMatchDisabled(t *testing.T) {
+	if _, ok := emailDomainMatch(&DomainInfo{Emails: []string{"a@example.com"}}, nil); ok {
+		t.Error("Expected no match with an empty mail domain set")
+	}
+}
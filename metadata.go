@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Metadata are free-form key/value pairs a domain or portfolio brand is
+// listed with, such as its business unit or asset ID, passed through to
+// the outputs so findings can be correlated by downstream systems
+type Metadata map[string]string
+
+// isCSVList reports whether a domain list is a CSV file whose extra
+// columns carry metadata
+func isCSVList(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(filename, ".gz")), ".csv")
+}
+
+// readDomainRecords loads a -domains-file list like readDomainList. A .csv
+// list also returns the metadata of each domain, by domain.
+func readDomainRecords(filename string) ([]string, map[string]Metadata, wordlistSkips, error) {
+	if !isCSVList(filename) {
+		domains, skipped, err := readDomainList(filename)
+		return domains, nil, skipped, err
+	}
+	var metadata map[string]Metadata
+	domains, skipped, err := readList(filename, func(r io.Reader) ([]string, wordlistSkips, error) {
+		var domains []string
+		var skipped wordlistSkips
+		var err error
+		domains, metadata, skipped, err = parseDomainCSV(r)
+		return domains, skipped, err
+	})
+	return domains, metadata, skipped, err
+}
+
+// parseDomainCSV reads a domain list CSV. The header row names the
+// columns: "domain" holds the domain, every other named column is metadata
+// passed through to the outputs, e.g. business_unit or asset_id. Empty
+// cells are left out of a domain's metadata.
+func parseDomainCSV(r io.Reader) ([]string, map[string]Metadata, wordlistSkips, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []string{}, nil, wordlistSkips{}, nil
+	}
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error reading list: %w", err)
+	}
+	column := -1
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if strings.EqualFold(header[i], "domain") {
+			column = i
+		}
+	}
+	if column < 0 {
+		return nil, nil, nil, fmt.Errorf("CSV domain list needs a header row with a domain column")
+	}
+
+	domains := []string{}
+	metadata := make(map[string]Metadata)
+	skipped := wordlistSkips{}
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error reading list: %w", err)
+		}
+		if column >= len(record) {
+			skipped[skipInvalid]++
+			continue
+		}
+		domain, ok := normalizeDomain(strings.TrimSpace(record[column]))
+		if !ok {
+			skipped[skipInvalid]++
+			continue
+		}
+		if seen[domain] {
+			skipped[skipDuplicate]++
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+		if values := metadataColumns(header, record, column); values != nil {
+			metadata[domain] = values
+		}
+	}
+	return domains, metadata, skipped, nil
+}
+
+// metadataColumns returns the non-empty cells of a CSV record under a
+// named header column, skipping the column at skip; nil when there are none
+func metadataColumns(header, record []string, skip int) Metadata {
+	var values Metadata
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if i == skip || i >= len(header) || header[i] == "" || value == "" {
+			continue
+		}
+		if values == nil {
+			values = make(Metadata)
+		}
+		values[header[i]] = value
+	}
+	return values
+}
+
+// carryMetadata copies the metadata of the domains of from onto the same
+// domains of the lists looked up again
+func carryMetadata(from []DomainInfo, lists ...[]DomainInfo) {
+	metadata := make(map[string]Metadata)
+	for _, info := range from {
+		if info.Metadata != nil {
+			metadata[info.Domain] = info.Metadata
+		}
+	}
+	for _, list := range lists {
+		for i := range list {
+			if list[i].Metadata == nil {
+				list[i].Metadata = metadata[list[i].Domain]
+			}
+		}
+	}
+}
+
+// formatMetadata renders metadata as key=value pairs sorted by key and
+// joined with sep, empty without metadata
+func formatMetadata(metadata Metadata, sep string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + metadata[key]
+	}
+	return strings.Join(pairs, sep)
+}
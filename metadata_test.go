@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadDomainRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.csv")
+	content := `asset_id,Domain,business_unit,
+# retail brands
+A-1,Example.de,Retail,
+A-2,example.fr,,
+A-3,example.de,Retail,
+,not-a-domain,Retail,
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	domains, metadata, skipped, err := readDomainRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(domains, ",") != "example.de,example.fr" || skipped.total() != 2 {
+		t.Errorf("Unexpected domains %v, skipped %s", domains, skipped)
+	}
+	want := map[string]Metadata{
+		"example.de": {"asset_id": "A-1", "business_unit": "Retail"},
+		"example.fr": {"asset_id": "A-2"},
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("Expected %v, got %v", want, metadata)
+	}
+}
+
+func TestReadDomainRecordsErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "domains.csv")
+	if err := os.WriteFile(path, []byte("example.de,Retail\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := readDomainRecords(path); err == nil {
+		t.Error("Expected an error for a CSV list without a domain column")
+	}
+
+	// Plain lists carry no metadata
+	path = filepath.Join(t.TempDir(), "domains.txt")
+	if err := os.WriteFile(path, []byte("example.de\ttimeout\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	domains, metadata, _, err := readDomainRecords(path)
+	if err != nil || len(domains) != 1 || metadata != nil {
+		t.Errorf("Unexpected plain list %v, %v, %v", domains, metadata, err)
+	}
+}
+
+func TestLoadPortfolioMetadata(t *testing.T) {
+	path := writePortfolio(t, "domain,aliases,tags,cost_center,owner\nexample.com,,,CC-7,brand-team\nacme.com\n")
+	brands, err := loadPortfolio(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(brands[0].Metadata, Metadata{"cost_center": "CC-7", "owner": "brand-team"}) || brands[1].Metadata != nil {
+		t.Errorf("Unexpected metadata %+v", brands)
+	}
+}
+
+func TestCarryMetadata(t *testing.T) {
+	earlier := []DomainInfo{{Domain: "example.de", Metadata: Metadata{"asset_id": "A-1"}}, {Domain: "example.fr"}}
+	retried := []DomainInfo{{Domain: "example.de"}, {Domain: "example.fr"}}
+	carryMetadata(earlier, retried)
+	if retried[0].Metadata["asset_id"] != "A-1" || retried[1].Metadata != nil {
+		t.Errorf("Unexpected metadata %+v", retried)
+	}
+}
+
+func TestMetadataOutputs(t *testing.T) {
+	result := Result{
+		TargetDomain: "example.com",
+		Metadata:     Metadata{"owner": "brand-team"},
+		MatchingDomains: []DomainInfo{
+			{Domain: "example.de", Organization: "Example Corp", MatchReason: "organization", Metadata: Metadata{"business_unit": "Retail", "asset_id": "A-1"}},
+		},
+	}
+	if got := formatMetadata(result.MatchingDomains[0].Metadata, ";"); got != "asset_id=A-1;business_unit=Retail" {
+		t.Errorf("formatMetadata = %q", got)
+	}
+
+	csv, err := renderCSV(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(csv), ",asset_id=A-1;business_unit=Retail,") {
+		t.Errorf("Expected the metadata column in CSV output:\n%s", csv)
+	}
+	grep := string(renderGrep(result))
+	if !strings.Contains(grep, "\tmatch\tmetadata\n") || !strings.Contains(grep, "\torganization\tasset_id=A-1;business_unit=Retail\n") {
+		t.Errorf("Expected the metadata field in grepable output:\n%s", grep)
+	}
+	if text := renderText(result, false); !strings.Contains(text, "Target Metadata: owner=brand-team") || !strings.Contains(text, "Metadata: asset_id=A-1, business_unit=Retail") {
+		t.Errorf("Expected the metadata in text output:\n%s", text)
+	}
+	cef := string(renderSIEM(result, "cef", time.Now()))
+	if !strings.Contains(cef, "cs6Label=metadata cs6=asset_id\\=A-1;business_unit\\=Retail") {
+		t.Errorf("Expected the metadata in CEF output:\n%s", cef)
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Alert kinds raised by monitor mode
+const (
+	AlertNewMatch     = "new_match"
+	AlertRemovedMatch = "removed_match"
+	AlertChanged      = "changed"
+	AlertTLDLaunch    = "tld_launch"
+	AlertDropPhase    = "drop_phase"
+	AlertTargetDrift  = "target_drift"
+	AlertFlagged      = "flagged_country"
+)
+
+// alertFields are the tracked fields whose change on a previously seen
+// match raises an alert, e.g. a defensive registration transferred away
+var alertFields = []string{"organization", "registrar", "name_servers", "status"}
+
+// Alert is a notable difference between a monitor cycle and the state
+// recorded by the previous one
+type Alert struct {
+	Kind    string        `json:"kind"`
+	Domain  string        `json:"domain"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// String renders the alert as a single line
+func (a Alert) String() string {
+	switch a.Kind {
+	case AlertNewMatch:
+		return fmt.Sprintf("new match %s", a.Domain)
+	case AlertRemovedMatch:
+		return fmt.Sprintf("%s no longer matches", a.Domain)
+	case AlertTLDLaunch:
+		return fmt.Sprintf("%s registered under newly delegated .%s", a.Domain, lastLabel(a.Domain))
+	case AlertTargetDrift:
+		return fmt.Sprintf("target %s drifted from its baseline: %s", a.Domain, a.changes())
+	case AlertFlagged:
+		if len(a.Changes) > 0 {
+			return fmt.Sprintf("%s is registered from flagged country %s", a.Domain, a.Changes[0].New)
+		}
+	case AlertDropPhase:
+		if len(a.Changes) > 0 && a.Changes[0].New == phaseAvailable {
+			return fmt.Sprintf("%s was deleted and can be registered", a.Domain)
+		}
+		if len(a.Changes) > 0 {
+			return fmt.Sprintf("%s entered %s", a.Domain, a.Changes[0].New)
+		}
+	}
+	return fmt.Sprintf("%s changed: %s", a.Domain, a.changes())
+}
+
+func (a Alert) changes() string {
+	var changes []string
+	for _, change := range a.Changes {
+		changes = append(changes, fmt.Sprintf("%s %q -> %q", change.Field, change.Old, change.New))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// validateMonitor checks the monitor mode options
+func validateMonitor(config Config) error {
+	if config.Monitor && (config.Wordlist == stdinWordlist || config.DomainsFile == stdinWordlist) {
+		return fmt.Errorf("lists cannot be read from stdin in monitor mode, stdin is only read once")
+	}
+	if config.HealthListen != "" && !config.Monitor {
+		return fmt.Errorf("-health-listen requires -monitor")
+	}
+	if config.Rebaseline && !config.Monitor {
+		return fmt.Errorf("-rebaseline requires -monitor")
+	}
+	if config.Schedule != "" {
+		_, err := parseCron(config.Schedule)
+		return err
+	}
+	if config.Monitor && config.Interval < time.Minute {
+		return fmt.Errorf("-interval must be at least 1m")
+	}
+	return nil
+}
+
+// monitorSchedule returns the -schedule cron expression, or -interval when
+// no expression is set
+func monitorSchedule(config Config) schedule {
+	if config.Schedule != "" {
+		if cron, err := parseCron(config.Schedule); err == nil {
+			return cron
+		}
+	}
+	return intervalSchedule(config.Interval)
+}
+
+// lastScanTime returns when the target was last scanned according to the
+// history database
+func lastScanTime(config Config) (time.Time, error) {
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer store.Close()
+	return store.lastScan(config.Domain)
+}
+
+// runMonitor scans on the -schedule or every -interval until interrupted,
+// alerting on changes against the history database.
+//
+// Scans never overlap: runs that fall due while a scan is in progress are
+// skipped. A run missed while the scanner was not running (judged by the
+// last scan in the history database) is caught up once at startup.
+func runMonitor(config Config) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sched := monitorSchedule(config)
+	last, err := lastScanTime(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	every := "every " + config.Interval.String()
+	when := every
+	if config.Schedule != "" {
+		every = config.Schedule
+		when = fmt.Sprintf("on schedule %q", config.Schedule)
+	}
+	fmt.Printf("%s[INFO]%s Monitoring %s %s (history: %s)\n", ColorBlue, ColorReset, config.Domain, when, config.HistoryDB)
+	if config.Slices > 1 {
+		fmt.Printf("%s[INFO]%s Scanning 1/%d of the TLDs per cycle; every TLD is scanned once every %s\n", ColorBlue, ColorReset,
+			config.Slices, fullCoverage(sched, sched.next(time.Now()), config.Slices))
+	}
+	state := &monitorState{}
+	if config.HealthListen != "" {
+		serveHealth(ctx, config, state)
+	}
+
+	for {
+		next := sched.next(last)
+		if next.IsZero() {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s Schedule %q has no next run\n", ColorRed, ColorReset, config.Schedule)
+			return ExitUsage
+		}
+		status := MonitorStatus{Target: config.Domain, Schedule: every, NextScan: &next}
+		if lastScan := last; !lastScan.IsZero() {
+			status.LastScan = &lastScan
+		}
+		slice := ""
+		if config.Slices > 1 {
+			if upcoming, err := upcomingSlice(config); err != nil {
+				fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+			} else {
+				status.Slice, status.Slices = upcoming+1, config.Slices
+				status.FullCoverage = fullCoverage(sched, next, config.Slices).String()
+				slice = fmt.Sprintf(" (slice %d/%d)", status.Slice, status.Slices)
+			}
+		}
+		state.update(status)
+		if wait := time.Until(next); wait > 0 {
+			fmt.Printf("%s[INFO]%s Next scan at %s%s\n", ColorBlue, ColorReset, next.Format("2006-01-02 15:04:05"), slice)
+			select {
+			case <-ctx.Done():
+				fmt.Printf("%s[INFO]%s Monitoring stopped\n", ColorBlue, ColorReset)
+				return ExitMatches
+			case <-time.After(wait):
+			}
+		} else if !last.IsZero() {
+			fmt.Printf("%s[INFO]%s Catching up the scan due at %s\n", ColorBlue, ColorReset, next.Format("2006-01-02 15:04:05"))
+		}
+
+		start := time.Now()
+		if err := monitorCycle(config); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		} else {
+			config.Rebaseline = false
+		}
+		last = time.Now()
+		if skipped := sched.next(start); !skipped.IsZero() && skipped.Before(last) {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s Scan overran the run due at %s; skipped to avoid overlap\n",
+				ColorYellow, ColorReset, skipped.Format("2006-01-02 15:04:05"))
+		}
+	}
+}
+
+// monitorCycle runs one scan, reports its alerts and records it. With
+// -watchlist the newly delegated TLDs are added to the scan first. The TLDs
+// new since the last cycle are reported, and with -only-new-tlds they are
+// the only ones scanned; with -slices the cycle scans the slice of the TLDs
+// after the one of the last cycle. The target itself is compared with its
+// baseline (see checkTargetBaseline).
+func monitorCycle(config Config) error {
+	startTime := time.Now()
+	if config.Watchlist {
+		watched, err := refreshWatchlist(config)
+		if err != nil {
+			return err
+		}
+		config.WatchedTLDs = watched
+	}
+	var tlds []string
+	slice := 0
+	if config.DomainsFile == "" {
+		current, added, last, err := monitorTLDs(config)
+		if err != nil {
+			return err
+		}
+		tlds, config.ScanTLDs = current, current
+		if config.OnlyNewTLDs && last != nil {
+			if len(added) == 0 {
+				fmt.Printf("%s[INFO]%s No TLDs new since the last scan; skipping this cycle (-only-new-tlds)\n", ColorBlue, ColorReset)
+				return nil
+			}
+			config.ScanTLDs = added
+		}
+		if config.Slices > 1 {
+			slice = nextSlice(last, config.Slices)
+			config.ScanTLDs = sliceTLDs(current, slice, config.Slices)
+			fmt.Printf("%s[INFO]%s Scanning slice %d/%d: %d of %d TLDs\n", ColorBlue, ColorReset,
+				slice+1, config.Slices, len(config.ScanTLDs), len(current))
+		}
+	}
+	config, err := withOutputRun(config, config.Domain, startTime)
+	if err != nil {
+		return err
+	}
+	result, allResults, err := scan(config)
+	if err != nil {
+		return err
+	}
+
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	alerts, err := detectAlerts(store, allResults)
+	if err != nil {
+		return fmt.Errorf("failed to compare with history: %w", err)
+	}
+	launches, err := launchAlerts(store, allResults, config.WatchedTLDs)
+	if err != nil {
+		return fmt.Errorf("failed to compare with history: %w", err)
+	}
+	alerts = suppressAlerts(append(alerts, launches...), result.SuppressedDomains)
+	if result.target != nil && result.TargetError == "" {
+		state := captureTarget(config.Domain, result.target, time.Duration(config.Timeout)*time.Second)
+		drift, alert, err := store.checkTargetBaseline(config.Domain, state, config.Rebaseline, startTime)
+		if err != nil {
+			return fmt.Errorf("failed to compare with the target baseline: %w", err)
+		}
+		result.TargetDrift = drift
+		if alert {
+			alerts = append([]Alert{{Kind: AlertTargetDrift, Domain: config.Domain, Changes: drift}}, alerts...)
+		}
+	}
+	if _, err := store.record(result, allResults, startTime); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+	if tlds != nil {
+		if err := store.saveTLDSet(config.Domain, tldSet{ScannedAt: startTime, TLDs: tlds, Slice: slice, Slices: config.Slices}); err != nil {
+			return fmt.Errorf("failed to record history: %w", err)
+		}
+	}
+
+	outputStarted := time.Now()
+	writeOutput(result, config)
+	linkLatestRun(config)
+	exportTelemetry(result, outputStarted)
+	printSummary(result)
+	printAlerts(alerts)
+	sendNotifications(config, Notification{Result: result, Alerts: alerts, Monitor: true})
+	config.Syslog.summary(result)
+	config.Neo4jSink.export(result)
+	return nil
+}
+
+// detectAlerts compares scanned domains with their latest recorded
+// snapshot. Domains whose lookup failed are skipped so a transient error
+// is not reported as a removed match.
+func detectAlerts(store *historyStore, domains []DomainInfo) ([]Alert, error) {
+	var alerts []Alert
+	for _, info := range domains {
+		if info.Error != "" {
+			continue
+		}
+		previous, err := store.latest(info.Domain)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := info.MatchReason != ""
+		wasMatched := previous != nil && previous.Info.MatchReason != ""
+		switch {
+		case matched && !wasMatched:
+			alerts = append(alerts, Alert{Kind: AlertNewMatch, Domain: info.Domain})
+		case !matched && wasMatched:
+			alerts = append(alerts, Alert{Kind: AlertRemovedMatch, Domain: info.Domain})
+		}
+
+		// A lookalike is flagged once, when first seen from the country
+		if country := info.flaggedCountry(); country != "" && (previous == nil || previous.Info.Country != country) {
+			var old string
+			if previous != nil {
+				old = previous.Info.Country
+			}
+			alerts = append(alerts, Alert{Kind: AlertFlagged, Domain: info.Domain, Changes: []FieldChange{{Field: "country", Old: old, New: country}}})
+		}
+
+		if wasMatched {
+			var changes []FieldChange
+			for _, change := range diffSnapshots(previous.Info, info) {
+				if containsString(alertFields, change.Field) {
+					changes = append(changes, change)
+				}
+			}
+			if len(changes) > 0 {
+				alerts = append(alerts, Alert{Kind: AlertChanged, Domain: info.Domain, Changes: changes})
+			}
+		}
+	}
+	return alerts, nil
+}
+
+// printAlerts writes alerts to stderr so they stand out from the results
+func printAlerts(alerts []Alert) {
+	if len(alerts) == 0 {
+		fmt.Printf("%s[INFO]%s No changes since the previous scan\n", ColorBlue, ColorReset)
+		return
+	}
+	for _, alert := range alerts {
+		color := ColorYellow
+		if alert.Kind == AlertChanged || alert.Kind == AlertTLDLaunch || alert.Kind == AlertTargetDrift || alert.Kind == AlertFlagged {
+			color = ColorRed
+		}
+		fmt.Fprintf(os.Stderr, "%s[ALERT]%s %s\n", color, ColorReset, alert)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectAlerts(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	result := Result{TargetDomain: "example.com"}
+	previous := []DomainInfo{
+		{Domain: "example.io", Organization: "Example Corp", Registrar: "MarkMonitor", MatchReason: "organization"},
+		{Domain: "example.de", Organization: "Example Corp", Registrar: "MarkMonitor", MatchReason: "organization", ExpiryDate: "2027-01-01"},
+		{Domain: "example.ai", Organization: "Someone Else"},
+		{Domain: "example.fr", Organization: "Example Corp", MatchReason: "organization"},
+	}
+	if _, err := store.record(result, previous, time.Now().Add(-24*time.Hour)); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+
+	current := []DomainInfo{
+		// Transferred away from the defensive registrant
+		{Domain: "example.io", Organization: "Unknown LLC", Registrar: "NameCheap"},
+		// Renewed: expiry changes are not alert-worthy
+		{Domain: "example.de", Organization: "Example Corp", Registrar: "MarkMonitor", MatchReason: "organization", ExpiryDate: "2028-01-01"},
+		{Domain: "example.ai", Organization: "Example Corp", MatchReason: "organization"},
+		// Lookup failure is not a removal
+		{Domain: "example.fr", Error: "timeout"},
+	}
+	alerts, err := detectAlerts(store, current)
+	if err != nil {
+		t.Fatalf("detectAlerts failed: %v", err)
+	}
+
+	expected := []Alert{
+		{Kind: AlertRemovedMatch, Domain: "example.io"},
+		{Kind: AlertChanged, Domain: "example.io", Changes: []FieldChange{
+			{Field: "organization", Old: "Example Corp", New: "Unknown LLC"},
+			{Field: "registrar", Old: "MarkMonitor", New: "NameCheap"},
+		}},
+		{Kind: AlertNewMatch, Domain: "example.ai"},
+	}
+	if !reflect.DeepEqual(alerts, expected) {
+		t.Errorf("detectAlerts() = %+v; expected %+v", alerts, expected)
+	}
+
+	if got := expected[1].String(); got != `example.io changed: organization "Example Corp" -> "Unknown LLC", registrar "MarkMonitor" -> "NameCheap"` {
+		t.Errorf("Alert.String() = %s", got)
+	}
+}
+
+func TestValidateMonitor(t *testing.T) {
+	if err := validateMonitor(Config{Monitor: true, Interval: time.Hour, Wordlist: stdinWordlist}); err == nil {
+		t.Error("Expected -w - to be rejected in monitor mode")
+	}
+	if err := validateMonitor(Config{Monitor: true, Interval: time.Second}); err == nil {
+		t.Error("Expected an interval under a minute to be rejected")
+	}
+	if err := validateMonitor(Config{HealthListen: ":8081", Interval: time.Hour}); err == nil {
+		t.Error("Expected -health-listen without -monitor to be rejected")
+	}
+	if err := validateMonitor(Config{Monitor: true, Interval: time.Hour, Wordlist: defaultWordlist}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
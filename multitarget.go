@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GroupTarget is another target domain of a multi-target scan with the
+// organization its WHOIS record names
+type GroupTarget struct {
+	Domain       string `json:"domain"`
+	Organization string `json:"organization,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// targetFlag is the repeatable -d flag. The first domain is the target the
+// candidates and reports are named after; the others join its group.
+type targetFlag struct {
+	config *Config
+}
+
+func (f targetFlag) String() string {
+	if f.config == nil || f.config.Domain == "" {
+		return ""
+	}
+	return strings.Join(f.config.targetDomains(), ",")
+}
+
+func (f targetFlag) Set(value string) error {
+	for _, domain := range strings.Split(value, ",") {
+		domain = strings.TrimSpace(domain)
+		switch {
+		case domain == "":
+		case f.config.Domain == "":
+			f.config.Domain = domain
+		default:
+			f.config.Targets = append(f.config.Targets, domain)
+		}
+	}
+	return nil
+}
+
+// targetDomains returns the target domain followed by the rest of its group
+func (c Config) targetDomains() []string {
+	return append([]string{c.Domain}, c.Targets...)
+}
+
+// isTarget reports whether domain is one of the target domains
+func (c Config) isTarget(domain string) bool {
+	for _, target := range c.targetDomains() {
+		if strings.EqualFold(domain, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupGroup looks up the other targets of a multi-target scan. Their
+// organizations join the match set as aliases of the target's and their
+// domains join the mail domains, so a conglomerate whose ccTLD domains are
+// registered by different subsidiaries is matched as one.
+func lookupGroup(config *Config) []GroupTarget {
+	var group []GroupTarget
+	var orgs []string
+	for _, domain := range config.Targets {
+		target := GroupTarget{Domain: domain}
+		info, err := getWhoisInfo(domain, *config)
+		switch {
+		case err != nil:
+			target.Error = err.Error()
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to look up group target %s: %v\n", ColorYellow, ColorReset, domain, err)
+		case info.Organization == "" || privacyPattern.MatchString(info.Organization):
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s No usable organization for group target %s\n", ColorYellow, ColorReset, domain)
+		default:
+			target.Organization = info.Organization
+			orgs = append(orgs, info.Organization)
+			fmt.Printf("%s[INFO]%s Group organization: %s%s%s (%s)\n", ColorBlue, ColorReset, ColorGreen, info.Organization, ColorReset, domain)
+		}
+		group = append(group, target)
+	}
+	if len(orgs) > 0 {
+		config.OrgNormalizer = config.OrgNormalizer.withAliases(orgs)
+	}
+	mailDomains := config.Targets
+	if config.MailDomains != "" {
+		mailDomains = append([]string{config.MailDomains}, mailDomains...)
+	}
+	config.MailDomains = strings.Join(mailDomains, ",")
+	return group
+}
+
+// validateTargets checks the -d domains of a multi-target scan
+func validateTargets(config Config) error {
+	if len(config.Targets) == 0 {
+		return nil
+	}
+	if config.Portfolio != "" {
+		return fmt.Errorf("-portfolio scans its own brands and cannot be used with several -d targets")
+	}
+	seen := make(map[string]bool)
+	for _, domain := range config.targetDomains() {
+		if seen[strings.ToLower(domain)] {
+			return fmt.Errorf("-d %s is given twice", domain)
+		}
+		seen[strings.ToLower(domain)] = true
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTargetFlag(t *testing.T) {
+	var config Config
+	fs := flag.NewFlagSet("tldscanner", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	if err := fs.Parse([]string{"-d", "example.com", "-d", "exemplo.com.br, example.de"}); err != nil {
+		t.Fatal(err)
+	}
+	if config.Domain != "example.com" {
+		t.Errorf("The first -d should be the target, got %q", config.Domain)
+	}
+	if expected := []string{"exemplo.com.br", "example.de"}; !reflect.DeepEqual(config.Targets, expected) {
+		t.Errorf("Expected group targets %v, got %v", expected, config.Targets)
+	}
+	if !config.isTarget("EXAMPLE.DE") || config.isTarget("example.net") {
+		t.Error("isTarget should match every target domain case-insensitively")
+	}
+}
+
+func TestCandidateDomainsOfGroup(t *testing.T) {
+	wordlist := filepath.Join(t.TempDir(), "tlds.txt")
+	if err := os.WriteFile(wordlist, []byte(".com\n.com.br\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{Domain: "example.com", Targets: []string{"exemplo.com.br", "example.de"}, Wordlist: wordlist}
+	domains, err := candidateDomains(config)
+	if err != nil {
+		t.Fatalf("candidateDomains failed: %v", err)
+	}
+	expected := []string{"example.com", "example.com.br", "exemplo.com", "exemplo.com.br"}
+	if !reflect.DeepEqual(domains.slice(), expected) {
+		t.Errorf("Expected %v, got %v", expected, domains.slice())
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"single target", Config{Domain: "example.com"}, false},
+		{"group", Config{Domain: "example.com", Targets: []string{"exemplo.com.br"}}, false},
+		{"duplicate", Config{Domain: "example.com", Targets: []string{"Example.com"}}, true},
+		{"portfolio", Config{Portfolio: "brands.csv", Domain: "example.com", Targets: []string{"exemplo.com.br"}}, true},
+	}
+	for _, test := range tests {
+		if err := validateTargets(test.config); (err != nil) != test.wantErr {
+			t.Errorf("%s: validateTargets() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,566 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// neo4jBatchSize bounds the rows sent with one Cypher statement
+const neo4jBatchSize = 1000
+
+// boltVersions are the Bolt protocol versions proposed in the handshake,
+// 4.4 down to 4.1, which Neo4j 4.1 and later all speak
+var boltVersions = [4]uint32{0x0404, 0x0304, 0x0204, 0x0104}
+
+// Bolt message signatures
+const (
+	boltHello    = 0x01
+	boltGoodbye  = 0x02
+	boltRun      = 0x10
+	boltBegin    = 0x11
+	boltCommit   = 0x12
+	boltPull     = 0x3F
+	boltSuccess  = 0x70
+	boltRecord   = 0x71
+	boltIgnored  = 0x7E
+	boltFailure  = 0x7F
+	boltMaxChunk = 0xFFFF
+)
+
+// neo4jLabels are the node labels of the graph node types
+var neo4jLabels = map[string]string{
+	graphDomain:       "Domain",
+	graphOrganization: "Organization",
+	graphRegistrar:    "Registrar",
+	graphEmail:        "Email",
+	graphNameServer:   "NameServer",
+	graphIP:           "IP",
+}
+
+// neo4jSink upserts the registrant graph of each scan into Neo4j over Bolt
+type neo4jSink struct {
+	addr     string
+	useTLS   bool
+	host     string
+	username string
+	password string
+	database string
+	timeout  time.Duration
+	tor      *torDialer
+}
+
+// newNeo4jSink parses a bolt://[user@]host[:port] URL; bolt+s:// and
+// neo4j+s:// connect over TLS. The user defaults to the neo4j credential's
+// username, then to "neo4j". Returns nil without a URL.
+func newNeo4jSink(rawURL string, config Config) (*neo4jSink, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid -neo4j URL %q (expected bolt://[user@]host[:port])", rawURL)
+	}
+	sink := &neo4jSink{
+		host:     u.Hostname(),
+		username: firstNonEmpty(u.User.Username(), config.APIUsernames["neo4j"], "neo4j"),
+		password: config.APIKeys["neo4j"],
+		database: config.Neo4jDB,
+		timeout:  time.Duration(config.Timeout) * time.Second,
+		tor:      config.TorDialer,
+	}
+	switch u.Scheme {
+	case "bolt", "neo4j":
+	case "bolt+s", "neo4j+s":
+		sink.useTLS = true
+	default:
+		return nil, fmt.Errorf("unsupported -neo4j scheme %q (valid: bolt, bolt+s, neo4j, neo4j+s)", u.Scheme)
+	}
+	sink.addr = net.JoinHostPort(u.Hostname(), firstNonEmpty(u.Port(), "7687"))
+	return sink, nil
+}
+
+// validateNeo4j checks the -neo4j URL
+func validateNeo4j(config Config) error {
+	_, err := newNeo4jSink(config.Neo4j, config)
+	return err
+}
+
+// neo4jStatement is one Cypher statement with the rows it unwinds
+type neo4jStatement struct {
+	query string
+	rows  []interface{}
+}
+
+// neo4jStatements turns the graph into MERGE statements: nodes by label,
+// keyed on their name, then relationships by type. Domains keep their
+// role and risk score from the latest scan mentioning them, and every
+// node and relationship records when it was last seen.
+func neo4jStatements(graph registrantGraph) []neo4jStatement {
+	types := make(map[string]string, len(graph.Nodes))
+	nodes := make(map[string][]interface{})
+	for _, n := range graph.Nodes {
+		types[n.ID] = n.Type
+		props := map[string]interface{}{}
+		if n.Role != "" {
+			props["role"] = n.Role
+		}
+		if n.Risk > 0 {
+			props["risk_score"] = int64(n.Risk)
+		}
+		nodes[n.Type] = append(nodes[n.Type], map[string]interface{}{"name": n.Label, "props": props})
+	}
+	edges := make(map[[2]string][]interface{})
+	for _, e := range graph.Edges {
+		key := [2]string{types[e.Target], strings.ToUpper(e.Relation)}
+		edges[key] = append(edges[key], map[string]interface{}{
+			"source": strings.TrimPrefix(e.Source, graphDomain+":"),
+			"target": strings.TrimPrefix(e.Target, types[e.Target]+":"),
+		})
+	}
+
+	var statements []neo4jStatement
+	add := func(query string, rows []interface{}) {
+		for len(rows) > 0 {
+			n := min(len(rows), neo4jBatchSize)
+			statements = append(statements, neo4jStatement{query: query, rows: rows[:n]})
+			rows = rows[n:]
+		}
+	}
+	for _, kind := range sortedKeys(nodes) {
+		add(fmt.Sprintf("UNWIND $rows AS row MERGE (n:%s {name: row.name}) SET n += row.props, n.last_seen = datetime($seen)", neo4jLabels[kind]), nodes[kind])
+	}
+	keys := make([][2]string, 0, len(edges))
+	for key := range edges {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i][0]+keys[i][1] < keys[j][0]+keys[j][1] })
+	for _, key := range keys {
+		add(fmt.Sprintf("UNWIND $rows AS row MATCH (a:Domain {name: row.source}) MATCH (b:%s {name: row.target}) MERGE (a)-[r:%s]->(b) SET r.last_seen = datetime($seen)", neo4jLabels[key[0]], key[1]), edges[key])
+	}
+	return statements
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// export upserts the result's graph in one transaction. Failures are
+// reported and do not fail the scan. A nil sink exports nothing.
+func (s *neo4jSink) export(result Result) {
+	if s == nil {
+		return
+	}
+	graph := buildGraph(result)
+	if err := s.write(neo4jStatements(graph), time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Neo4j export failed: %v\n", ColorYellow, ColorReset, err)
+		return
+	}
+	fmt.Printf("%s[INFO]%s Exported %d nodes and %d relationships to Neo4j\n", ColorBlue, ColorReset, len(graph.Nodes), len(graph.Edges))
+}
+
+// write runs the statements in one explicit transaction
+func (s *neo4jSink) write(statements []neo4jStatement, seen time.Time) error {
+	conn, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	begin := map[string]interface{}{}
+	if s.database != "" {
+		begin["db"] = s.database
+	}
+	if _, err := conn.request(boltBegin, begin); err != nil {
+		return err
+	}
+	stamp := seen.UTC().Format(time.RFC3339)
+	for _, statement := range statements {
+		params := map[string]interface{}{"rows": statement.rows, "seen": stamp}
+		if _, err := conn.request(boltRun, statement.query, params, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if _, err := conn.request(boltPull, map[string]interface{}{"n": int64(-1)}); err != nil {
+			return err
+		}
+	}
+	if _, err := conn.request(boltCommit); err != nil {
+		return err
+	}
+	conn.send(boltGoodbye)
+	return nil
+}
+
+// boltConn is an authenticated Bolt connection
+type boltConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+}
+
+// connect dials the server, through Tor with -tor, negotiates the protocol
+// version and logs in
+func (s *neo4jSink) connect() (*boltConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	var conn net.Conn
+	var err error
+	if s.tor != nil {
+		conn, err = s.tor.DialContext(ctx, "tcp", s.addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", s.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+	if s.useTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12, RootCAs: rootCAs})
+	}
+	conn.SetDeadline(time.Now().Add(s.timeout))
+
+	handshake := binary.BigEndian.AppendUint32(nil, 0x6060B017)
+	for _, v := range boltVersions {
+		handshake = binary.BigEndian.AppendUint32(handshake, v)
+	}
+	var agreed uint32
+	if _, err = conn.Write(handshake); err == nil {
+		err = binary.Read(conn, binary.BigEndian, &agreed)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("bolt handshake failed: %w", err)
+	}
+	if agreed == 0 {
+		conn.Close()
+		return nil, fmt.Errorf("the server speaks none of Bolt 4.1-4.4")
+	}
+
+	c := &boltConn{conn: conn, r: bufio.NewReader(conn), timeout: s.timeout}
+	hello := map[string]interface{}{
+		"user_agent":  "tldscanner/" + version,
+		"scheme":      "basic",
+		"principal":   s.username,
+		"credentials": s.password,
+	}
+	if _, err := c.request(boltHello, hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// request sends a message and reads the replies up to its summary,
+// returning the SUCCESS metadata. Records are discarded. Each request gets
+// the full timeout.
+func (c *boltConn) request(signature byte, fields ...interface{}) (map[string]interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if err := c.send(signature, fields...); err != nil {
+		return nil, err
+	}
+	for {
+		reply, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch reply.signature {
+		case boltRecord:
+			continue
+		case boltSuccess:
+			meta, _ := reply.field(0).(map[string]interface{})
+			return meta, nil
+		case boltFailure:
+			meta, _ := reply.field(0).(map[string]interface{})
+			return nil, fmt.Errorf("neo4j: %v: %v", meta["code"], meta["message"])
+		case boltIgnored:
+			return nil, fmt.Errorf("neo4j: request ignored")
+		}
+		return nil, fmt.Errorf("neo4j: unexpected message 0x%02X", reply.signature)
+	}
+}
+
+// send writes a message as a PackStream structure split into chunks
+func (c *boltConn) send(signature byte, fields ...interface{}) error {
+	msg, err := packValue(nil, boltStruct{signature: signature, fields: fields})
+	if err != nil {
+		return err
+	}
+	var out []byte
+	for len(msg) > 0 {
+		n := min(len(msg), boltMaxChunk)
+		out = binary.BigEndian.AppendUint16(out, uint16(n))
+		out = append(out, msg[:n]...)
+		msg = msg[n:]
+	}
+	_, err = c.conn.Write(append(out, 0, 0))
+	return err
+}
+
+// receive reads one chunked message
+func (c *boltConn) receive() (boltStruct, error) {
+	var msg []byte
+	for {
+		var size uint16
+		if err := binary.Read(c.r, binary.BigEndian, &size); err != nil {
+			return boltStruct{}, err
+		}
+		if size == 0 {
+			if len(msg) == 0 {
+				continue // NOOP keep-alive
+			}
+			break
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(c.r, chunk); err != nil {
+			return boltStruct{}, err
+		}
+		msg = append(msg, chunk...)
+	}
+	v, _, err := unpackValue(msg)
+	if err != nil {
+		return boltStruct{}, err
+	}
+	s, ok := v.(boltStruct)
+	if !ok {
+		return boltStruct{}, fmt.Errorf("neo4j: malformed message")
+	}
+	return s, nil
+}
+
+// Close closes the connection
+func (c *boltConn) Close() error {
+	return c.conn.Close()
+}
+
+// boltStruct is a PackStream structure: a message or a graph value
+type boltStruct struct {
+	signature byte
+	fields    []interface{}
+}
+
+func (s boltStruct) field(i int) interface{} {
+	if i < len(s.fields) {
+		return s.fields[i]
+	}
+	return nil
+}
+
+// packValue appends the PackStream encoding of v: nil, bool, int64,
+// float64, string, list, string-keyed map or structure
+func packValue(b []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xC0), nil
+	case bool:
+		if v {
+			return append(b, 0xC3), nil
+		}
+		return append(b, 0xC2), nil
+	case int:
+		return packValue(b, int64(v))
+	case int64:
+		switch {
+		case v >= -16 && v <= 127:
+			return append(b, byte(int8(v))), nil
+		case v >= math.MinInt8 && v <= math.MaxInt8:
+			return append(b, 0xC8, byte(int8(v))), nil
+		case v >= math.MinInt16 && v <= math.MaxInt16:
+			return binary.BigEndian.AppendUint16(append(b, 0xC9), uint16(int16(v))), nil
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			return binary.BigEndian.AppendUint32(append(b, 0xCA), uint32(int32(v))), nil
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xCB), uint64(v)), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xC1), math.Float64bits(v)), nil
+	case string:
+		b = packHeader(b, len(v), 0x80, 0xD0)
+		return append(b, v...), nil
+	case []string:
+		b = packHeader(b, len(v), 0x90, 0xD4)
+		for _, item := range v {
+			b, _ = packValue(b, item)
+		}
+		return b, nil
+	case []interface{}:
+		b = packHeader(b, len(v), 0x90, 0xD4)
+		var err error
+		for _, item := range v {
+			if b, err = packValue(b, item); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = packHeader(b, len(v), 0xA0, 0xD8)
+		var err error
+		for _, key := range sortedKeys(v) {
+			b, _ = packValue(b, key)
+			if b, err = packValue(b, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case boltStruct:
+		b = append(b, 0xB0|byte(len(v.fields)), v.signature)
+		var err error
+		for _, field := range v.fields {
+			if b, err = packValue(b, field); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("packstream: unsupported type %T", v)
+}
+
+// packHeader appends the marker of a string, list or map of n entries:
+// tiny markers hold sizes below 16, then 8, 16 and 32-bit sizes follow
+func packHeader(b []byte, n int, tiny, sized byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, tiny|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, sized, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, sized+1), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, sized+2), uint32(n))
+}
+
+// unpackValue decodes one PackStream value, returning it and the rest of
+// the data
+func unpackValue(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	marker, b := b[0], b[1:]
+	size := func(n int) (int, []byte, error) {
+		if len(b) < n {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		switch n {
+		case 1:
+			return int(b[0]), b[1:], nil
+		case 2:
+			return int(binary.BigEndian.Uint16(b)), b[2:], nil
+		}
+		return int(binary.BigEndian.Uint32(b)), b[4:], nil
+	}
+	widths := map[byte]int{0: 1, 1: 2, 2: 4}
+
+	switch {
+	case marker < 0x80:
+		return int64(marker), b, nil
+	case marker >= 0xF0:
+		return int64(int8(marker)), b, nil
+	case marker == 0xC0:
+		return nil, b, nil
+	case marker == 0xC2, marker == 0xC3:
+		return marker == 0xC3, b, nil
+	case marker == 0xC1:
+		if len(b) < 8 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), b[8:], nil
+	case marker >= 0xC8 && marker <= 0xCB:
+		n := 1 << (marker - 0xC8)
+		if len(b) < n {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		var v int64
+		switch n {
+		case 1:
+			v = int64(int8(b[0]))
+		case 2:
+			v = int64(int16(binary.BigEndian.Uint16(b)))
+		case 4:
+			v = int64(int32(binary.BigEndian.Uint32(b)))
+		default:
+			v = int64(binary.BigEndian.Uint64(b))
+		}
+		return v, b[n:], nil
+	case marker >= 0xCC && marker <= 0xCE, marker >= 0xD0 && marker <= 0xD2, marker&0xF0 == 0x80:
+		var n int
+		var err error
+		switch {
+		case marker&0xF0 == 0x80:
+			n = int(marker & 0x0F)
+		case marker <= 0xCE:
+			n, b, err = size(widths[marker-0xCC])
+		default:
+			n, b, err = size(widths[marker-0xD0])
+		}
+		if err != nil || len(b) < n {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return string(b[:n]), b[n:], nil
+	case marker&0xF0 == 0x90, marker >= 0xD4 && marker <= 0xD6:
+		n := int(marker & 0x0F)
+		var err error
+		if marker >= 0xD4 {
+			if n, b, err = size(widths[marker-0xD4]); err != nil {
+				return nil, nil, err
+			}
+		}
+		list := make([]interface{}, 0, min(n, len(b)))
+		for i := 0; i < n; i++ {
+			var item interface{}
+			if item, b, err = unpackValue(b); err != nil {
+				return nil, nil, err
+			}
+			list = append(list, item)
+		}
+		return list, b, nil
+	case marker&0xF0 == 0xA0, marker >= 0xD8 && marker <= 0xDA:
+		n := int(marker & 0x0F)
+		var err error
+		if marker >= 0xD8 {
+			if n, b, err = size(widths[marker-0xD8]); err != nil {
+				return nil, nil, err
+			}
+		}
+		m := make(map[string]interface{}, min(n, len(b)))
+		for i := 0; i < n; i++ {
+			var key, value interface{}
+			if key, b, err = unpackValue(b); err != nil {
+				return nil, nil, err
+			}
+			if value, b, err = unpackValue(b); err != nil {
+				return nil, nil, err
+			}
+			m[fmt.Sprint(key)] = value
+		}
+		return m, b, nil
+	case marker&0xF0 == 0xB0:
+		if len(b) < 1 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		s := boltStruct{signature: b[0]}
+		b = b[1:]
+		for i := 0; i < int(marker&0x0F); i++ {
+			var field interface{}
+			var err error
+			if field, b, err = unpackValue(b); err != nil {
+				return nil, nil, err
+			}
+			s.fields = append(s.fields, field)
+		}
+		return s, b, nil
+	}
+	return nil, nil, fmt.Errorf("packstream: unknown marker 0x%02X", marker)
+}
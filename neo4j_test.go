@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBoltServer accepts one connection, agrees on Bolt 4.4 and answers
+// every message with SUCCESS, or FAILURE for RUN when fail is set. The
+// received messages are sent on the returned channel.
+func fakeBoltServer(t *testing.T, fail bool) (string, chan boltStruct) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan boltStruct, 100)
+	go func() {
+		defer close(messages)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handshake := make([]byte, 20)
+		if _, err := io.ReadFull(conn, handshake); err != nil || binary.BigEndian.Uint32(handshake) != 0x6060B017 {
+			return
+		}
+		conn.Write(binary.BigEndian.AppendUint32(nil, 0x0404))
+		server := &boltConn{conn: conn, r: bufio.NewReader(conn), timeout: 5 * time.Second}
+		for {
+			msg, err := server.receive()
+			if err != nil || msg.signature == boltGoodbye {
+				return
+			}
+			messages <- msg
+			if fail && msg.signature == boltRun {
+				server.send(boltFailure, map[string]interface{}{"code": "Neo.ClientError.Security.Forbidden", "message": "write access denied"})
+				continue
+			}
+			server.send(boltSuccess, map[string]interface{}{})
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestNeo4jExport(t *testing.T) {
+	addr, messages := fakeBoltServer(t, false)
+	sink, err := newNeo4jSink("bolt://analyst@"+addr, Config{Timeout: 5, Neo4jDB: "threatintel", APIKeys: map[string]string{"neo4j": "s3cret"}})
+	if err != nil {
+		t.Fatalf("newNeo4jSink failed: %v", err)
+	}
+
+	statements := neo4jStatements(buildGraph(testGraphResult()))
+	if err := sink.write(statements, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	var received []boltStruct
+	for msg := range messages {
+		received = append(received, msg)
+	}
+
+	// HELLO, BEGIN, RUN and PULL per statement, COMMIT
+	if len(received) != 3+2*len(statements) {
+		t.Fatalf("Expected %d messages, got %d", 3+2*len(statements), len(received))
+	}
+	hello := received[0].field(0).(map[string]interface{})
+	if received[0].signature != boltHello || hello["principal"] != "analyst" || hello["credentials"] != "s3cret" || hello["scheme"] != "basic" {
+		t.Errorf("Unexpected HELLO %v", hello)
+	}
+	if begin := received[1].field(0).(map[string]interface{}); received[1].signature != boltBegin || begin["db"] != "threatintel" {
+		t.Errorf("Expected BEGIN on the configured database, got %v", received[1])
+	}
+	if received[len(received)-1].signature != boltCommit {
+		t.Errorf("Expected the transaction to be committed, got %v", received[len(received)-1])
+	}
+
+	var queries []string
+	domains := 0
+	for _, msg := range received {
+		if msg.signature != boltRun {
+			continue
+		}
+		query := msg.field(0).(string)
+		queries = append(queries, query)
+		params := msg.field(1).(map[string]interface{})
+		if params["seen"] != "2026-10-16T09:00:00Z" {
+			t.Errorf("Unexpected seen parameter %v", params["seen"])
+		}
+		if strings.Contains(query, "MERGE (n:Domain ") {
+			domains = len(params["rows"].([]interface{}))
+		}
+	}
+	if domains != 4 {
+		t.Errorf("Expected 4 domain rows, got %d", domains)
+	}
+	joined := strings.Join(queries, "\n")
+	for _, want := range []string{"MERGE (n:Registrar ", "MERGE (n:IP ", "(b:NameServer {name: row.target}) MERGE (a)-[r:NAME_SERVER]->(b)", "MERGE (a)-[r:REGISTERED_WITH]->(b)"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected a statement with %q in:\n%s", want, joined)
+		}
+	}
+}
+
+func TestNeo4jExportFailure(t *testing.T) {
+	addr, _ := fakeBoltServer(t, true)
+	sink, _ := newNeo4jSink("bolt://"+addr, Config{Timeout: 5})
+	err := sink.write(neo4jStatements(buildGraph(testGraphResult())), time.Now())
+	if err == nil || !strings.Contains(err.Error(), "write access denied") {
+		t.Errorf("Expected the server's failure to be reported, got %v", err)
+	}
+}
+
+func TestNewNeo4jSink(t *testing.T) {
+	sink, err := newNeo4jSink("bolt+s://graph.example.com", Config{Timeout: 5, APIUsernames: map[string]string{"neo4j": "scanner"}})
+	if err != nil || sink.addr != "graph.example.com:7687" || !sink.useTLS || sink.username != "scanner" {
+		t.Errorf("Unexpected sink %+v, %v", sink, err)
+	}
+	if _, err := newNeo4jSink("http://graph.example.com", Config{}); err == nil {
+		t.Error("Expected an error for a non-Bolt URL")
+	}
+	if sink, err := newNeo4jSink("", Config{}); sink != nil || err != nil {
+		t.Errorf("Expected no sink without a URL, got %v, %v", sink, err)
+	}
+}
+
+func TestPackStreamRoundTrip(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	list := make([]interface{}, 20)
+	for i := range list {
+		list[i] = int64(i * 1000)
+	}
+	value := map[string]interface{}{
+		"null":  nil,
+		"true":  true,
+		"tiny":  int64(-16),
+		"int8":  int64(-100),
+		"int16": int64(30000),
+		"int32": int64(-2000000),
+		"int64": int64(1 << 40),
+		"float": 1.5,
+		"long":  long,
+		"list":  list,
+	}
+	data, err := packValue(nil, value)
+	if err != nil {
+		t.Fatalf("packValue failed: %v", err)
+	}
+	decoded, rest, err := unpackValue(data)
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("unpackValue failed: %v (%d bytes left)", err, len(rest))
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Errorf("Round trip changed the value:\n%v\n%v", decoded, value)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// tldSetsBucket holds the tldSet of each monitored target's last scan
+var tldSetsBucket = []byte("tld_sets")
+
+// tldSet is the list of TLDs a monitor cycle combined the target with.
+// With -slices the cycle scanned only slice (from 0) of slices of them.
+type tldSet struct {
+	ScannedAt time.Time `json:"scanned_at"`
+	TLDs      []string  `json:"tlds"`
+	Slice     int       `json:"slice,omitempty"`
+	Slices    int       `json:"slices,omitempty"`
+}
+
+// lastTLDSet returns the TLDs of the target's last monitor cycle, nil
+// before the first
+func (h *historyStore) lastTLDSet(target string) (*tldSet, error) {
+	var set *tldSet
+	err := h.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(tldSetsBucket)
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(strings.ToLower(target)))
+		if data == nil {
+			return nil
+		}
+		set = &tldSet{}
+		return json.Unmarshal(data, set)
+	})
+	return set, err
+}
+
+// saveTLDSet records the TLDs of the target's monitor cycle
+func (h *historyStore) saveTLDSet(target string, set tldSet) error {
+	set.ScannedAt = set.ScannedAt.UTC()
+	data, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(tldSetsBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(strings.ToLower(target)), data)
+	})
+}
+
+// newTLDs returns the TLDs of current missing from previous, in the order
+// of current
+func newTLDs(previous, current []string) []string {
+	seen := make(map[string]bool, len(previous))
+	for _, tld := range previous {
+		seen[tld] = true
+	}
+	var added []string
+	for _, tld := range current {
+		if !seen[tld] {
+			seen[tld] = true
+			added = append(added, tld)
+		}
+	}
+	return added
+}
+
+// monitorTLDs returns the TLDs of a monitor cycle, from the wordlist or
+// -countries with the watched TLDs, those new since the target's last cycle
+// and the TLD set of that cycle. Before the first cycle every TLD is new and
+// last is nil.
+func monitorTLDs(config Config) (current, added []string, last *tldSet, err error) {
+	current, err = loadTLDs(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(config.WatchedTLDs) > 0 {
+		current = withWatchedTLDs(current, config.WatchedTLDs)
+	}
+
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer store.Close()
+	last, err = store.lastTLDSet(config.Domain)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read the last TLD set: %w", err)
+	}
+	if last == nil {
+		return current, current, nil, nil
+	}
+	added = newTLDs(last.TLDs, current)
+	if len(added) > 0 {
+		fmt.Printf("%s[INFO]%s %d TLDs new since the scan of %s: %s\n", ColorBlue, ColorReset,
+			len(added), last.ScannedAt.Local().Format("2006-01-02 15:04"), strings.Join(added, " "))
+	}
+	return current, added, last, nil
+}
+
+// validateOnlyNewTLDs checks -only-new-tlds
+func validateOnlyNewTLDs(config Config) error {
+	if !config.OnlyNewTLDs {
+		return nil
+	}
+	if !config.Monitor {
+		return fmt.Errorf("-only-new-tlds requires -monitor")
+	}
+	if config.DomainsFile != "" {
+		return fmt.Errorf("-only-new-tlds cannot be combined with -domains-file, which has no TLD list")
+	}
+	return nil
+}
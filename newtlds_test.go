@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTLDs(t *testing.T) {
+	added := newTLDs([]string{"com", "net", "org"}, []string{"com", "app", "net", "dev", "app"})
+	if strings.Join(added, ",") != "app,dev" {
+		t.Errorf("newTLDs = %v, want [app dev]", added)
+	}
+	if added := newTLDs([]string{"com", "net"}, []string{"net"}); len(added) != 0 {
+		t.Errorf("Removed TLDs should not be reported, got %v", added)
+	}
+}
+
+func TestTLDSetRoundTrip(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	if set, err := store.lastTLDSet("example.com"); err != nil || set != nil {
+		t.Fatalf("Expected no TLD set before the first cycle, got %+v, %v", set, err)
+	}
+	scanned := time.Date(2026, 10, 15, 3, 0, 0, 0, time.UTC)
+	if err := store.saveTLDSet("Example.com", tldSet{ScannedAt: scanned, TLDs: []string{"com", "net"}}); err != nil {
+		t.Fatalf("saveTLDSet failed: %v", err)
+	}
+	set, err := store.lastTLDSet("example.com")
+	if err != nil || set == nil {
+		t.Fatalf("lastTLDSet = %+v, %v", set, err)
+	}
+	if strings.Join(set.TLDs, ",") != "com,net" || !set.ScannedAt.Equal(scanned) {
+		t.Errorf("Unexpected TLD set: %+v", set)
+	}
+}
+
+func TestValidateOnlyNewTLDs(t *testing.T) {
+	if err := validateOnlyNewTLDs(Config{OnlyNewTLDs: true}); err == nil {
+		t.Error("Expected -only-new-tlds without -monitor to be rejected")
+	}
+	if err := validateOnlyNewTLDs(Config{OnlyNewTLDs: true, Monitor: true, DomainsFile: "domains.txt"}); err == nil {
+		t.Error("Expected -only-new-tlds with -domains-file to be rejected")
+	}
+	if err := validateOnlyNewTLDs(Config{OnlyNewTLDs: true, Monitor: true}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
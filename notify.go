@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// Notification summarizes a scan or monitor cycle for notifiers. Alerts is
+// empty outside monitor mode.
+type Notification struct {
+	Result  Result
+	Alerts  []Alert
+	Monitor bool
+}
+
+// empty reports whether there is nothing worth sending: no matches after a
+// one-off scan, or no alerts after a monitor cycle
+func (n Notification) empty() bool {
+	if n.Monitor {
+		return len(n.Alerts) == 0
+	}
+	return len(n.Result.MatchingDomains) == 0
+}
+
+// notifier delivers scan summaries to an external channel
+type notifier interface {
+	Name() string
+	Notify(n Notification) error
+}
+
+// configuredNotifiers returns the notifiers enabled in config
+func configuredNotifiers(config Config) []notifier {
+	var notifiers []notifier
+	if config.SMTPServer != "" {
+		notifiers = append(notifiers, newSMTPNotifier(config))
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	if config.TeamsWebhook != "" {
+		notifiers = append(notifiers, &teamsNotifier{webhook: config.TeamsWebhook, timeout: timeout})
+	}
+	if config.TelegramToken != "" {
+		notifiers = append(notifiers, &telegramNotifier{token: config.TelegramToken, chat: config.TelegramChat, timeout: timeout})
+	}
+	thresholds := pageThresholds{risk: config.PageMinRisk, signal: config.PageMinSignal}
+	if config.PagerDuty {
+		notifiers = append(notifiers, &pagerDutyNotifier{routingKey: config.APIKeys["pagerduty"], thresholds: thresholds, timeout: timeout})
+	}
+	if config.Opsgenie {
+		notifiers = append(notifiers, &opsgenieNotifier{
+			apiKey:     config.APIKeys["opsgenie"],
+			url:        opsgenieAlertsURLs[config.OpsgenieRegion],
+			thresholds: thresholds,
+			timeout:    timeout,
+		})
+	}
+	if config.JiraProject != "" {
+		notifiers = append(notifiers, &jiraNotifier{
+			baseURL:    config.JiraURL,
+			project:    config.JiraProject,
+			issueType:  config.JiraIssueType,
+			labels:     issueLabels(config.IssueLabels),
+			username:   config.APIUsernames["jira"],
+			token:      config.APIKeys["jira"],
+			thresholds: thresholds,
+			timeout:    timeout,
+		})
+	}
+	if config.GitHubRepo != "" {
+		notifiers = append(notifiers, &githubNotifier{
+			repo:       config.GitHubRepo,
+			labels:     issueLabels(config.IssueLabels),
+			token:      config.APIKeys["github"],
+			thresholds: thresholds,
+			timeout:    timeout,
+		})
+	}
+	return notifiers
+}
+
+// sendNotifications delivers n through every configured notifier. Delivery
+// failures are reported but do not fail the scan.
+func sendNotifications(config Config, n Notification) {
+	if n.empty() {
+		return
+	}
+	for _, nt := range configuredNotifiers(config) {
+		if err := nt.Notify(n); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s notification failed: %v\n", ColorYellow, ColorReset, nt.Name(), err)
+		} else if config.liveOutput() {
+			fmt.Printf("%s[INFO]%s %s notification sent\n", ColorBlue, ColorReset, nt.Name())
+		}
+	}
+}
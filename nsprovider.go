@@ -0,0 +1,128 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Name server provider kinds. Managed DNS and self-hosted name servers
+// take deliberate setup, so a lookalike using them is likely in active
+// use; registrar defaults and parking are what a domain gets untouched.
+const (
+	nsManagedDNS       = "managed_dns"
+	nsRegistrarDefault = "registrar_default"
+	nsParking          = "parking"
+	nsSelfHosted       = "self_hosted"
+	nsOther            = "other"
+)
+
+// nsProviderKindLabels describe the kinds in the text report
+var nsProviderKindLabels = map[string]string{
+	nsManagedDNS:       "managed DNS",
+	nsRegistrarDefault: "registrar default",
+	nsParking:          "parking",
+	nsSelfHosted:       "self-hosted",
+	nsOther:            "unrecognized",
+}
+
+// nsFingerprint recognizes the name server host names of a provider
+type nsFingerprint struct {
+	provider string
+	kind     string
+	pattern  *regexp.Regexp
+}
+
+// nsFingerprints is the built-in table of name server providers, matched
+// against lowercased host names without the trailing dot
+var nsFingerprints = []nsFingerprint{
+	{"Cloudflare", nsManagedDNS, regexp.MustCompile(`\.ns\.cloudflare\.com$`)},
+	{"Amazon Route 53", nsManagedDNS, regexp.MustCompile(`\.awsdns-\d+\.(com|net|org|co\.uk)$`)},
+	{"Azure DNS", nsManagedDNS, regexp.MustCompile(`\.azure-dns\.(com|net|org|info)$`)},
+	{"Google Cloud DNS", nsManagedDNS, regexp.MustCompile(`^ns-cloud-[a-z]\d+\.googledomains\.com$`)},
+	{"NS1", nsManagedDNS, regexp.MustCompile(`\.nsone\.net$`)},
+	{"Akamai", nsManagedDNS, regexp.MustCompile(`\.akam\.net$`)},
+	{"UltraDNS", nsManagedDNS, regexp.MustCompile(`\.ultradns\.(com|net|org|biz|info|co\.uk)$`)},
+	{"DNS Made Easy", nsManagedDNS, regexp.MustCompile(`\.dnsmadeeasy\.com$`)},
+	{"DNSimple", nsManagedDNS, regexp.MustCompile(`\.dnsimple\.com$`)},
+	{"DigitalOcean", nsManagedDNS, regexp.MustCompile(`^ns\d\.digitalocean\.com$`)},
+	{"Hetzner", nsManagedDNS, regexp.MustCompile(`\.ns\.hetzner\.(com|de)$`)},
+	{"Vercel", nsManagedDNS, regexp.MustCompile(`\.vercel-dns\.com$`)},
+	{"Wix", nsManagedDNS, regexp.MustCompile(`\.wixdns\.net$`)},
+	{"GoDaddy", nsRegistrarDefault, regexp.MustCompile(`\.domaincontrol\.com$`)},
+	{"Namecheap", nsRegistrarDefault, regexp.MustCompile(`\.registrar-servers\.com$`)},
+	{"Gandi", nsRegistrarDefault, regexp.MustCompile(`\.gandi\.net$`)},
+	{"OVHcloud", nsRegistrarDefault, regexp.MustCompile(`\.ovh\.(net|ca)$`)},
+	{"IONOS", nsRegistrarDefault, regexp.MustCompile(`\.ui-dns\.(com|de|org|biz)$`)},
+	{"Hostinger", nsRegistrarDefault, regexp.MustCompile(`\.dns-parking\.com$`)},
+	{"Porkbun", nsRegistrarDefault, regexp.MustCompile(`\.porkbun\.com$`)},
+	{"Name.com", nsRegistrarDefault, regexp.MustCompile(`\.name\.com$`)},
+	{"Dynadot", nsRegistrarDefault, regexp.MustCompile(`\.dyna-ns\.net$`)},
+	{"NameSilo", nsRegistrarDefault, regexp.MustCompile(`\.dnsowl\.com$`)},
+	{"Hover", nsRegistrarDefault, regexp.MustCompile(`\.hover\.com$`)},
+	{"Network Solutions", nsRegistrarDefault, regexp.MustCompile(`\.worldnic\.com$`)},
+	{"Squarespace", nsRegistrarDefault, regexp.MustCompile(`^ns-?\d+\.googledomains\.com$`)},
+	{"Alibaba Cloud", nsRegistrarDefault, regexp.MustCompile(`\.hichina\.com$`)},
+	{"MarkMonitor", nsRegistrarDefault, regexp.MustCompile(`\.markmonitor\.com$`)},
+	{"CSC", nsRegistrarDefault, regexp.MustCompile(`\.cscdns\.(net|uk)$`)},
+	{"Sedo", nsParking, regexp.MustCompile(`\.sedoparking\.com$`)},
+	{"Bodis", nsParking, regexp.MustCompile(`\.bodis\.com$`)},
+	{"ParkingCrew", nsParking, regexp.MustCompile(`\.parkingcrew\.net$`)},
+	{"Above.com", nsParking, regexp.MustCompile(`\.above\.com$`)},
+	{"Afternic", nsParking, regexp.MustCompile(`\.afternic\.com$`)},
+	{"Dan.com", nsParking, regexp.MustCompile(`\.dan\.com$`)},
+	{"HugeDomains", nsParking, regexp.MustCompile(`\.hugedomains\.com$`)},
+}
+
+// classifyNameServers sets the provider of a domain's name servers: the
+// first one recognized by the fingerprint table or the parking list, the
+// domain itself for name servers under it, or else the parent domain of
+// the first name server
+func classifyNameServers(info *DomainInfo) {
+	info.NSProvider, info.NSProviderType = "", ""
+	var hosts []string
+	for _, ns := range info.NameServers {
+		if host := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(ns), ".")); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return
+	}
+	for _, host := range hosts {
+		for _, fingerprint := range nsFingerprints {
+			if fingerprint.pattern.MatchString(host) {
+				info.NSProvider, info.NSProviderType = fingerprint.provider, fingerprint.kind
+				return
+			}
+		}
+	}
+	if ns := parkingNameServer(hosts); ns != "" {
+		info.NSProvider, info.NSProviderType = nsParent(ns), nsParking
+		return
+	}
+	for _, host := range hosts {
+		if strings.HasSuffix(host, "."+info.Domain) {
+			info.NSProvider, info.NSProviderType = info.Domain, nsSelfHosted
+			return
+		}
+	}
+	info.NSProvider, info.NSProviderType = nsParent(hosts[0]), nsOther
+}
+
+// nsParent strips the first label of a name server host name, e.g.
+// ns1.example-dns.net becomes example-dns.net
+func nsParent(host string) string {
+	if _, parent, ok := strings.Cut(host, "."); ok && strings.Contains(parent, ".") {
+		return parent
+	}
+	return host
+}
+
+// nsProviderLabel renders a domain's provider for the text report, empty
+// without one
+func (d DomainInfo) nsProviderLabel() string {
+	if d.NSProvider == "" {
+		return ""
+	}
+	return d.NSProvider + " (" + nsProviderKindLabels[d.NSProviderType] + ")"
+}
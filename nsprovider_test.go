@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestClassifyNameServers(t *testing.T) {
+	tests := []struct {
+		domain      string
+		nameServers []string
+		provider    string
+		kind        string
+	}{
+		{"examp1e.com", []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"}, "Cloudflare", nsManagedDNS},
+		{"examp1e.com", []string{"NS-1234.AWSDNS-12.ORG.", "ns-567.awsdns-34.co.uk"}, "Amazon Route 53", nsManagedDNS},
+		{"examp1e.com", []string{"ns-cloud-a1.googledomains.com"}, "Google Cloud DNS", nsManagedDNS},
+		{"example.shop", []string{"ns45.domaincontrol.com", "ns46.domaincontrol.com"}, "GoDaddy", nsRegistrarDefault},
+		{"example.shop", []string{"dns1.registrar-servers.com"}, "Namecheap", nsRegistrarDefault},
+		{"example.xyz", []string{"ns1.sedoparking.com"}, "Sedo", nsParking},
+		// Parking providers outside the table come from the parking list
+		{"example.xyz", []string{"ns1.ztomy.com"}, "ztomy.com", nsParking},
+		// The first recognized name server wins over unknown ones
+		{"example.xyz", []string{"ns1.unknown-dns.net", "kim.ns.cloudflare.com"}, "Cloudflare", nsManagedDNS},
+		{"examp1e.com", []string{"ns1.examp1e.com", "ns2.examp1e.com"}, "examp1e.com", nsSelfHosted},
+		{"examp1e.com", []string{"ns1.unknown-dns.net"}, "unknown-dns.net", nsOther},
+		{"examp1e.com", nil, "", ""},
+	}
+	for _, tt := range tests {
+		info := DomainInfo{Domain: tt.domain, NameServers: tt.nameServers}
+		classifyNameServers(&info)
+		if info.NSProvider != tt.provider || info.NSProviderType != tt.kind {
+			t.Errorf("classifyNameServers(%v) = %q, %q; expected %q, %q", tt.nameServers, info.NSProvider, info.NSProviderType, tt.provider, tt.kind)
+		}
+	}
+}
+
+func TestNSProviderLabel(t *testing.T) {
+	if got := (DomainInfo{NSProvider: "GoDaddy", NSProviderType: nsRegistrarDefault}).nsProviderLabel(); got != "GoDaddy (registrar default)" {
+		t.Errorf("nsProviderLabel() = %q", got)
+	}
+	if got := (DomainInfo{}).nsProviderLabel(); got != "" {
+		t.Errorf("Expected no label without a provider, got %q", got)
+	}
+}
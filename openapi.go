@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// openAPISpec returns the OpenAPI 3.1 description of the serve mode API.
+// Its schemas are derived from the same types the handlers encode, so the
+// document follows the API as fields are added. With auth, every operation
+// requires a tenant token.
+func openAPISpec(auth bool) map[string]interface{} {
+	ref := func(name string) map[string]interface{} {
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	jsonContent := func(schema map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+	}
+	response := func(description string, schema map[string]interface{}) map[string]interface{} {
+		resp := map[string]interface{}{"description": description}
+		if schema != nil {
+			resp["content"] = jsonContent(schema)
+		}
+		return resp
+	}
+	errorResponse := func(description string) map[string]interface{} {
+		return response(description, ref("Error"))
+	}
+	idParam := map[string]interface{}{
+		"name": "id", "in": "path", "required": true,
+		"description": "Scan ID returned on submission",
+		"schema":      map[string]interface{}{"type": "string"},
+	}
+
+	var formats []string
+	for format := range downloadFormats {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	resultContent := jsonContent(ref("Result"))
+	for _, format := range formats {
+		if format != "json" {
+			resultContent[downloadFormats[format]] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+		}
+	}
+
+	scanResponses := func(responses map[string]interface{}) map[string]interface{} {
+		if auth {
+			responses["401"] = errorResponse("Missing or invalid API token")
+		}
+		return responses
+	}
+	paths := map[string]interface{}{
+		"/scans": map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": "startScan",
+				"summary":     "Submit a scan",
+				"requestBody": map[string]interface{}{"required": true, "content": jsonContent(ref("ScanRequest"))},
+				"responses": scanResponses(map[string]interface{}{
+					"202": response("The queued scan", ref("ScanJob")),
+					"400": errorResponse("Invalid scan request"),
+					"429": errorResponse("Scan quota of the tenant exceeded"),
+				}),
+			},
+			"get": map[string]interface{}{
+				"operationId": "listScans",
+				"summary":     "List submitted scans, newest first",
+				"responses": scanResponses(map[string]interface{}{
+					"200": response("The scans", map[string]interface{}{"type": "array", "items": ref("ScanJob")}),
+				}),
+			},
+		},
+		"/scans/{id}": map[string]interface{}{
+			"parameters": []interface{}{idParam},
+			"get": map[string]interface{}{
+				"operationId": "getScan",
+				"summary":     "Scan status and progress",
+				"responses": scanResponses(map[string]interface{}{
+					"200": response("The scan", ref("ScanJob")),
+					"404": errorResponse("No such scan"),
+				}),
+			},
+		},
+		"/scans/{id}/result": map[string]interface{}{
+			"parameters": []interface{}{idParam},
+			"get": map[string]interface{}{
+				"operationId": "getResult",
+				"summary":     "Result of a finished scan",
+				"parameters": []interface{}{map[string]interface{}{
+					"name": "format", "in": "query",
+					"description": "Download the result as a file in this output format instead",
+					"schema":      map[string]interface{}{"type": "string", "enum": formats},
+				}},
+				"responses": scanResponses(map[string]interface{}{
+					"200": map[string]interface{}{"description": "The result", "content": resultContent},
+					"400": errorResponse("Unknown format"),
+					"404": errorResponse("No such scan"),
+					"409": errorResponse("The scan has not finished"),
+				}),
+			},
+		},
+		"/scans/{id}/stream": map[string]interface{}{
+			"parameters": []interface{}{idParam},
+			"get": map[string]interface{}{
+				"operationId": "streamScan",
+				"summary":     "WebSocket stream of scan events",
+				"description": "Upgrades to a WebSocket that sends one ScanEvent JSON message per " +
+					"event: a status event on connect, a domain event per lookup and a done event.",
+				"responses": scanResponses(map[string]interface{}{
+					"101": response("Switching to the WebSocket stream of ScanEvent messages", nil),
+					"404": errorResponse("No such scan"),
+				}),
+			},
+		},
+		"/healthz": map[string]interface{}{
+			"get": map[string]interface{}{
+				"operationId": "healthz",
+				"summary":     "Liveness probe",
+				"security":    []interface{}{},
+				"responses":   map[string]interface{}{"200": response("The process is running", nil)},
+			},
+		},
+		"/readyz": map[string]interface{}{
+			"get": map[string]interface{}{
+				"operationId": "readyz",
+				"summary":     "Readiness probe",
+				"security":    []interface{}{},
+				"responses": map[string]interface{}{
+					"200": response("Ready", ref("HealthStatus")),
+					"503": response("A check failed", ref("HealthStatus")),
+				},
+			},
+		},
+	}
+
+	schemas := map[string]interface{}{
+		"Error": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+			"required":   []string{"error"},
+		},
+	}
+	for name, v := range map[string]interface{}{
+		"ScanRequest":  ScanRequest{},
+		"ScanJob":      ScanJob{},
+		"ScanEvent":    ScanEvent{},
+		"Result":       Result{},
+		"HealthStatus": HealthStatus{},
+	} {
+		schemas[name] = schemaForType(reflect.TypeOf(v))
+	}
+	components := map[string]interface{}{"schemas": schemas}
+
+	spec := map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "TLD Scanner API",
+			"version":     SchemaVersion,
+			"description": "Submit TLD scans, follow them live and fetch their results.",
+		},
+		"paths":      paths,
+		"components": components,
+	}
+	if auth {
+		components["securitySchemes"] = map[string]interface{}{
+			"bearerToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			"queryToken":  map[string]interface{}{"type": "apiKey", "in": "query", "name": "access_token"},
+		}
+		spec["security"] = []interface{}{
+			map[string]interface{}{"bearerToken": []string{}},
+			map[string]interface{}{"queryToken": []string{}},
+		}
+	}
+	return spec
+}
+
+// handleOpenAPI serves the API description; like the probes it needs no
+// token, so clients can be generated before one is issued
+func (s *scanServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, openAPISpec(s.auth != nil))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	spec := openAPISpec(false)
+	if spec["openapi"] != "3.1.0" {
+		t.Errorf("Unexpected OpenAPI version %v", spec["openapi"])
+	}
+	paths := spec["paths"].(map[string]interface{})
+	for _, path := range []string{"/scans", "/scans/{id}", "/scans/{id}/result", "/scans/{id}/stream", "/healthz", "/readyz"} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("Spec is missing path %s", path)
+		}
+	}
+	if _, ok := spec["security"]; ok {
+		t.Error("Expected no security requirement without tenants")
+	}
+
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	job := schemas["ScanJob"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, name := range []string{"id", "status", "processed", "created_at"} {
+		if _, ok := job[name]; !ok {
+			t.Errorf("ScanJob schema is missing %q", name)
+		}
+	}
+
+	// Every schema reference must resolve, or generated clients fail
+	data, _ := json.Marshal(spec)
+	var refs []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				refs = append(refs, ref)
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var decoded interface{}
+	json.Unmarshal(data, &decoded)
+	walk(decoded)
+	for _, ref := range refs {
+		if _, ok := schemas[ref[len("#/components/schemas/"):]]; !ok {
+			t.Errorf("Unresolved reference %s", ref)
+		}
+	}
+}
+
+func TestOpenAPISpecServed(t *testing.T) {
+	s := newScanServer(Config{}, 1)
+	s.auth, _ = newTenantAuth(map[string]Tenant{"red": {TokenSHA256: hashToken("t1")}})
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected the spec without a token, got %d", rec.Code)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	schemes := spec["components"].(map[string]interface{})["securitySchemes"].(map[string]interface{})
+	if _, ok := schemes["bearerToken"]; !ok || spec["security"] == nil {
+		t.Errorf("Expected token security with tenants, got %v", schemes)
+	}
+}
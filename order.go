@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortKeys are the valid keys of -sort
+var sortKeys = []string{"created", "expiry", "risk", "tld", "org"}
+
+// resultOrder is a parsed -sort option: the key domains are ordered by and
+// the direction
+type resultOrder struct {
+	key  string
+	desc bool
+}
+
+// parseSortOrder parses a -sort option of the form key[:asc|:desc]. Risk
+// sorts descending by default, the other keys ascending. An empty option
+// parses to nil, which keeps the scan's order.
+func parseSortOrder(spec string) (*resultOrder, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	key, direction, _ := strings.Cut(strings.ToLower(spec), ":")
+	if !containsString(sortKeys, key) {
+		return nil, fmt.Errorf("unknown -sort key %q (valid: %s)", key, strings.Join(sortKeys, ", "))
+	}
+	order := &resultOrder{key: key, desc: key == "risk"}
+	switch direction {
+	case "":
+	case "asc":
+		order.desc = false
+	case "desc":
+		order.desc = true
+	default:
+		return nil, fmt.Errorf("unknown -sort direction %q (valid: asc, desc)", direction)
+	}
+	return order, nil
+}
+
+// validateSort checks the -sort option
+func validateSort(spec string) error {
+	_, err := parseSortOrder(spec)
+	return err
+}
+
+// compare orders two domains by the sort key, ascending. ok is false when
+// either domain lacks the key; such domains sort last in both directions.
+func (o *resultOrder) compare(a, b DomainInfo) (cmp int, okA, okB bool) {
+	switch o.key {
+	case "created", "expiry":
+		date := DomainInfo.expires
+		if o.key == "created" {
+			date = DomainInfo.created
+		}
+		x, okA := date(a)
+		y, okB := date(b)
+		return x.Compare(y), okA, okB
+	case "risk":
+		return a.RiskScore - b.RiskScore, true, true
+	case "tld":
+		return strings.Compare(lastLabel(a.Domain), lastLabel(b.Domain)), true, true
+	case "org":
+		x, y := strings.ToLower(a.Organization), strings.ToLower(b.Organization)
+		return strings.Compare(x, y), x != "", y != ""
+	}
+	return 0, true, true
+}
+
+// sortDomains returns a copy of domains in the order, ties broken by
+// domain name
+func (o *resultOrder) sortDomains(domains []DomainInfo) []DomainInfo {
+	if o == nil || len(domains) < 2 {
+		return domains
+	}
+	sorted := append([]DomainInfo(nil), domains...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		cmp, okI, okJ := o.compare(sorted[i], sorted[j])
+		switch {
+		case okI != okJ:
+			return okI
+		case !okI || cmp == 0:
+			return sorted[i].Domain < sorted[j].Domain
+		case o.desc:
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return sorted
+}
+
+// apply orders every domain list of a result
+func (o *resultOrder) apply(result Result) Result {
+	if o == nil {
+		return result
+	}
+	result.MatchingDomains = o.sortDomains(result.MatchingDomains)
+	result.SignalDomains = o.sortDomains(result.SignalDomains)
+	result.Lookalikes = o.sortDomains(result.Lookalikes)
+	result.AllDomains = o.sortDomains(result.AllDomains)
+	return result
+}
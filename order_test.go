@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSortOrder(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    *resultOrder
+		wantErr string
+	}{
+		{"", nil, ""},
+		{"created", &resultOrder{key: "created"}, ""},
+		{"Expiry:DESC", &resultOrder{key: "expiry", desc: true}, ""},
+		{"risk", &resultOrder{key: "risk", desc: true}, ""},
+		{"risk:asc", &resultOrder{key: "risk"}, ""},
+		{"name", nil, `unknown -sort key "name"`},
+		{"tld:up", nil, `unknown -sort direction "up"`},
+	}
+	for _, tt := range tests {
+		got, err := parseSortOrder(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseSortOrder(%q): expected error containing %q, got %v", tt.spec, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSortOrder(%q) = %+v, %v, expected %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestSortDomains(t *testing.T) {
+	domains := []DomainInfo{
+		{Domain: "example.net", Organization: "zeta llc", CreatedDate: "2021-05-01", ExpiryDate: "2026-05-01", RiskScore: 10},
+		{Domain: "example.de", Organization: "", CreatedDate: "", ExpiryDate: "02-Jan-2025", RiskScore: 70},
+		{Domain: "example.ru", Organization: "Acme", CreatedDate: "2024-01-15T00:00:00Z", ExpiryDate: "2025-01-15", RiskScore: 70},
+		{Domain: "example.co", Organization: "Beta", CreatedDate: "2019-12-31", ExpiryDate: "", RiskScore: 40},
+	}
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"created", []string{"example.co", "example.net", "example.ru", "example.de"}},
+		{"created:desc", []string{"example.ru", "example.net", "example.co", "example.de"}},
+		{"expiry", []string{"example.de", "example.ru", "example.net", "example.co"}},
+		{"risk", []string{"example.de", "example.ru", "example.co", "example.net"}},
+		{"risk:asc", []string{"example.net", "example.co", "example.de", "example.ru"}},
+		{"tld", []string{"example.co", "example.de", "example.net", "example.ru"}},
+		{"org:desc", []string{"example.net", "example.co", "example.ru", "example.de"}},
+	}
+	for _, tt := range tests {
+		order, err := parseSortOrder(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, info := range order.sortDomains(domains) {
+			got = append(got, info.Domain)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("-sort %s: expected %v, got %v", tt.spec, tt.want, got)
+		}
+	}
+	if domains[0].Domain != "example.net" {
+		t.Errorf("Expected the input order to be left unchanged, got %s first", domains[0].Domain)
+	}
+}
+
+func TestResultOrderApply(t *testing.T) {
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.ru"}, {Domain: "example.de"}},
+		AllDomains:      []DomainInfo{{Domain: "example.ru"}, {Domain: "example.net"}, {Domain: "example.de"}},
+	}
+	order, _ := parseSortOrder("tld:desc")
+	got := order.apply(result)
+	if got.MatchingDomains[0].Domain != "example.ru" || got.AllDomains[1].Domain != "example.net" || got.AllDomains[2].Domain != "example.de" {
+		t.Errorf("Unexpected order %+v", got)
+	}
+
+	var none *resultOrder
+	if kept := none.apply(result); !reflect.DeepEqual(kept, result) {
+		t.Errorf("Expected no -sort to keep the scan order, got %+v", kept)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// defaultLegalSuffixes are the company-form words stripped from either end
+// of organization names before comparison ("Example Inc", "ООО Эксампл")
+var defaultLegalSuffixes = []string{
+	"inc", "incorporated", "llc", "l.l.c.", "llp", "lp", "ltd", "limited", "corp", "corporation",
+	"co", "company", "plc", "gmbh", "ag", "kg", "ug", "s.a.", "sa", "s.a.s.", "sas", "sarl",
+	"s.l.", "sl", "s.r.l.", "srl", "s.p.a.", "spa", "b.v.", "bv", "n.v.", "nv", "oy", "ab",
+	"a/s", "as", "asa", "k.k.", "kk", "pty", "pte", "bhd", "sdn",
+	"ооо", "оао", "зао", "пао", "ао", "тов", "ooo", "oao", "zao", "pao", "tov",
+	"株式会社", "有限会社", "合同会社",
+}
+
+// OrgRules configures organization name normalization in config.yaml
+type OrgRules struct {
+	// LegalSuffixes replaces the default legal suffix list when set
+	LegalSuffixes []string `yaml:"legal_suffixes,omitempty"`
+	// ExtraSuffixes are stripped in addition to the legal suffixes
+	ExtraSuffixes   []string `yaml:"extra_suffixes,omitempty"`
+	KeepPunctuation bool     `yaml:"keep_punctuation,omitempty"`
+	KeepDiacritics  bool     `yaml:"keep_diacritics,omitempty"`
+	// Aliases are other spellings of the target organization, e.g. its
+	// registered name in a local script
+	Aliases []string `yaml:"aliases,omitempty"`
+	// Transliterate compares Cyrillic and Greek names by their Latin form
+	Transliterate bool `yaml:"transliterate,omitempty"`
+}
+
+// orgNormalizer turns organization names into a comparable form, so that
+// "EXAMPLE, INC." and "Example Inc" compare equal
+type orgNormalizer struct {
+	rules    OrgRules
+	suffixes [][]string
+}
+
+// newOrgNormalizer builds a normalizer from the configured rules
+func newOrgNormalizer(rules OrgRules) *orgNormalizer {
+	n := &orgNormalizer{rules: rules}
+	suffixes := rules.LegalSuffixes
+	if len(suffixes) == 0 {
+		suffixes = defaultLegalSuffixes
+	}
+	for _, suffix := range append(append([]string{}, suffixes...), rules.ExtraSuffixes...) {
+		if words := n.words(suffix); len(words) > 0 {
+			n.suffixes = append(n.suffixes, words)
+		}
+	}
+	return n
+}
+
+// withAliases returns a normalizer with the same rules that also accepts
+// the given aliases of the target organization
+func (n *orgNormalizer) withAliases(aliases []string) *orgNormalizer {
+	if len(aliases) == 0 && n != nil {
+		return n
+	}
+	var rules OrgRules
+	if n != nil {
+		rules = n.rules
+	}
+	rules.Aliases = append(append([]string{}, rules.Aliases...), aliases...)
+	return newOrgNormalizer(rules)
+}
+
+// words folds case and, unless configured otherwise, diacritics and
+// punctuation, and splits the result into words
+func (n *orgNormalizer) words(s string) []string {
+	if !n.rules.KeepDiacritics {
+		var b strings.Builder
+		for _, r := range norm.NFKD.String(s) {
+			if !unicode.Is(unicode.Mn, r) {
+				b.WriteRune(r)
+			}
+		}
+		s = b.String()
+	}
+	s = strings.ToLower(s)
+	if !n.rules.KeepPunctuation {
+		s = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				return r
+			}
+			return ' '
+		}, s)
+	}
+	return strings.Fields(s)
+}
+
+// normalize returns the comparable form of an organization name with
+// leading and trailing legal forms removed. A name made only of legal form
+// words is kept whole.
+func (n *orgNormalizer) normalize(org string) string {
+	words := n.words(org)
+	for stripped := true; stripped; {
+		stripped = false
+		for _, suffix := range n.suffixes {
+			if len(words) <= len(suffix) {
+				continue
+			}
+			if wordsEqual(words[len(words)-len(suffix):], suffix) {
+				words = words[:len(words)-len(suffix)]
+				stripped = true
+			} else if wordsEqual(words[:len(suffix)], suffix) {
+				words = words[len(suffix):]
+				stripped = true
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// forms returns the normalized forms an organization name is compared by:
+// the name itself and, when enabled, its transliteration
+func (n *orgNormalizer) forms(org string) []string {
+	forms := []string{n.normalize(org)}
+	if n.rules.Transliterate && hasTransliterableLetters(org) {
+		forms = append(forms, n.normalize(transliterate(org)))
+	}
+	return forms
+}
+
+// match compares a candidate organization against the target's and returns
+// how they matched: "organization", "organization_alias" for a configured
+// alias or "transliteration", or "" when they differ. Empty names never
+// match.
+func (n *orgNormalizer) match(org, target string) string {
+	candidate := n.forms(org)
+	if candidate[0] == "" {
+		return ""
+	}
+	if candidate[0] == n.normalize(target) {
+		return "organization"
+	}
+
+	for _, alias := range n.rules.Aliases {
+		if candidate[0] == n.normalize(alias) {
+			return "organization_alias"
+		}
+	}
+	if !n.rules.Transliterate {
+		return ""
+	}
+	for _, name := range append([]string{target}, n.rules.Aliases...) {
+		for _, a := range candidate {
+			for _, b := range n.forms(name) {
+				if a != "" && a == b {
+					return "transliteration"
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// equal reports whether two organization names are the same after
+// normalization
+func (n *orgNormalizer) equal(a, b string) bool {
+	return n.match(a, b) != ""
+}
+
+func wordsEqual(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// orgMatchReason compares organizations with the configured normalization,
+// or only case-insensitively with -exact-org, and returns the match reason
+func orgMatchReason(org, target string, config Config) string {
+	if config.ExactOrg {
+		if org != "" && strings.EqualFold(strings.TrimSpace(org), strings.TrimSpace(target)) {
+			return "organization"
+		}
+		return ""
+	}
+	normalizer := config.OrgNormalizer
+	if normalizer == nil {
+		normalizer = newOrgNormalizer(OrgRules{})
+	}
+	return normalizer.match(org, target)
+}
+
+// orgsMatch reports whether two organizations are the same
+func orgsMatch(org, target string, config Config) bool {
+	return orgMatchReason(org, target, config) != ""
+}
@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func TestOrgNormalize(t *testing.T) {
+	normalizer := newOrgNormalizer(OrgRules{})
+	testCases := []struct {
+		org      string
+		expected string
+	}{
+		{"EXAMPLE, INC.", "example"},
+		{"Example Inc", "example"},
+		{"Example   Holdings Co., Ltd.", "example holdings"},
+		{"Société Générale S.A.", "societe generale"},
+		{"Müller GmbH & Co. KG", "muller"},
+		{"Example L.L.C.", "example"},
+		{"Limited", "limited"},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		if got := normalizer.normalize(tc.org); got != tc.expected {
+			t.Errorf("normalize(%q) = %q; expected %q", tc.org, got, tc.expected)
+		}
+	}
+}
+
+func TestOrgNormalizeRules(t *testing.T) {
+	normalizer := newOrgNormalizer(OrgRules{ExtraSuffixes: []string{"Holdings"}})
+	if !normalizer.equal("Example Holdings, Inc.", "EXAMPLE INC") {
+		t.Error("Expected extra suffixes to be stripped")
+	}
+
+	normalizer = newOrgNormalizer(OrgRules{LegalSuffixes: []string{"gmbh"}})
+	if normalizer.equal("Example Inc", "Example") {
+		t.Error("Expected the configured suffix list to replace the defaults")
+	}
+
+	normalizer = newOrgNormalizer(OrgRules{KeepDiacritics: true})
+	if normalizer.equal("Müller GmbH", "Muller GmbH") {
+		t.Error("Expected diacritics to be kept")
+	}
+}
+
+func TestOrgsMatch(t *testing.T) {
+	if !orgsMatch("EXAMPLE, INC.", "Example Inc", Config{}) {
+		t.Error("Expected normalized organizations to match")
+	}
+	if orgsMatch("EXAMPLE, INC.", "Example Inc", Config{ExactOrg: true}) {
+		t.Error("Expected -exact-org to compare without normalization")
+	}
+	if orgsMatch("", "", Config{}) {
+		t.Error("Empty organizations must not match")
+	}
+}
+
+func TestOrgMatchAliasesAndTransliteration(t *testing.T) {
+	rules := OrgRules{Aliases: []string{"Eksampl LLC", "株式会社エクサンプル"}}
+	normalizer := newOrgNormalizer(rules)
+	if reason := normalizer.match("株式会社エクサンプル", "Example Inc"); reason != "organization_alias" {
+		t.Errorf("Expected an alias match, got %q", reason)
+	}
+	if reason := normalizer.match(`ООО "Эксампл"`, "Example Inc"); reason != "" {
+		t.Errorf("Expected no match without transliteration, got %q", reason)
+	}
+
+	rules.Transliterate = true
+	normalizer = newOrgNormalizer(rules)
+	if reason := normalizer.match(`ООО "Эксампл"`, "Example Inc"); reason != "transliteration" {
+		t.Errorf("Expected a transliteration match, got %q", reason)
+	}
+	if reason := normalizer.match("Example Inc", "EXAMPLE, INC."); reason != "organization" {
+		t.Errorf("Expected a direct match, got %q", reason)
+	}
+}
+
+func TestOrgNormalizerWithAliases(t *testing.T) {
+	base := newOrgNormalizer(OrgRules{Aliases: []string{"Eksampl LLC"}})
+	brand := base.withAliases([]string{"Example Holdings"})
+	if reason := brand.match("Example Holdings GmbH", "Example Inc"); reason != "organization_alias" {
+		t.Errorf("Expected the brand alias to match, got %q", reason)
+	}
+	if reason := brand.match("Eksampl LLC", "Example Inc"); reason != "organization_alias" {
+		t.Errorf("Expected the configured alias to still match, got %q", reason)
+	}
+	if reason := base.match("Example Holdings", "Example Inc"); reason != "" {
+		t.Errorf("Expected the base normalizer to be unchanged, got %q", reason)
+	}
+	if base.withAliases(nil) != base {
+		t.Error("Expected no aliases to reuse the normalizer")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	whoisparser "github.com/likexian/whois-parser"
+)
+
+// maxOrgHints caps the candidate organizations offered by the prompt
+const maxOrgHints = 9
+
+// rawOrgPattern matches raw WHOIS lines naming the holder of a domain in
+// formats where the parser finds no registrant organization
+var rawOrgPattern = regexp.MustCompile(`(?im)^[ \t]*(?:registrant(?: name| contact name| organi[sz]ation)?|holder|owner|org-name|organi[sz]ation|descr)[ \t.]*:[ \t]*(\S.*?)[ \t]*$`)
+
+// organizationHints collects the fields of a WHOIS record that may name the
+// target's organization when the registrant organization is missing: the
+// registrant name, the admin and tech organizations and the holder lines
+// of the raw text. Redacted values are left out.
+func organizationHints(result whoisparser.WhoisInfo, raw string) []string {
+	var hints []string
+	add := func(hint string) {
+		hint = strings.TrimSpace(hint)
+		if hint == "" || len(hints) == maxOrgHints || privacyPattern.MatchString(hint) {
+			return
+		}
+		for _, existing := range hints {
+			if strings.EqualFold(existing, hint) {
+				return
+			}
+		}
+		hints = append(hints, hint)
+	}
+	if result.Registrant != nil {
+		add(result.Registrant.Name)
+	}
+	for _, contact := range []*whoisparser.Contact{result.Administrative, result.Technical} {
+		if contact != nil {
+			add(contact.Organization)
+		}
+	}
+	for _, match := range rawOrgPattern.FindAllStringSubmatch(raw, -1) {
+		add(match[1])
+	}
+	return hints
+}
+
+// canPrompt reports whether the target organization can be asked for: a
+// one-off scan run from a terminal, not monitor, serve or portfolio scans
+func (c Config) canPrompt() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout) && !c.Monitor && c.OnDomain == nil && c.Portfolio == ""
+}
+
+// promptOrganization asks for the organization to match, offering the
+// record's candidate fields by number. An empty answer returns "".
+func promptOrganization(in io.Reader, out io.Writer, domain string, hints []string) string {
+	fmt.Fprintf(out, "%s[?]%s No organization found for %s.", ColorYellow, ColorReset, domain)
+	if len(hints) > 0 {
+		fmt.Fprintln(out, " The WHOIS record names:")
+		for i, hint := range hints {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, hint)
+		}
+		fmt.Fprint(out, "Pick a number or type the organization to match (empty to match by name servers): ")
+	} else {
+		fmt.Fprint(out, "\nType the organization to match (empty to match by name servers): ")
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		answer := strings.TrimSpace(scanner.Text())
+		n, err := strconv.Atoi(answer)
+		if err != nil {
+			return answer
+		}
+		if n >= 1 && n <= len(hints) {
+			return hints[n-1]
+		}
+		fmt.Fprintf(out, "Pick a number from 1 to %d or type the organization: ", len(hints))
+	}
+	return ""
+}
+
+// nameServerMatch reports whether a domain is served by the target's own
+// DNS: a name server under the target domain, or exactly the target's set
+// of name servers. A registrar default or parking set is shared by too
+// many unrelated domains to count.
+func nameServerMatch(target, info *DomainInfo) bool {
+	targetHosts := nameServerHosts(target.NameServers)
+	hosts := nameServerHosts(info.NameServers)
+	if len(targetHosts) == 0 || len(hosts) == 0 {
+		return false
+	}
+	for host := range hosts {
+		if strings.HasSuffix(host, "."+target.Domain) {
+			return true
+		}
+	}
+	if target.NSProviderType == nsRegistrarDefault || target.NSProviderType == nsParking || len(hosts) != len(targetHosts) {
+		return false
+	}
+	for host := range hosts {
+		if !targetHosts[host] {
+			return false
+		}
+	}
+	return true
+}
+
+// nameServerHosts returns the lowercased host names of name servers
+// without the trailing dot
+func nameServerHosts(nameServers []string) map[string]bool {
+	hosts := make(map[string]bool)
+	for _, ns := range nameServers {
+		if host := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(ns), ".")); host != "" {
+			hosts[host] = true
+		}
+	}
+	return hosts
+}
+
+// usableNameServers reports whether the target's name servers identify it
+// well enough for -ns-match
+func usableNameServers(target *DomainInfo) bool {
+	return target.NSProviderType != "" && target.NSProviderType != nsRegistrarDefault && target.NSProviderType != nsParking
+}
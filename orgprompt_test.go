@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	whoisparser "github.com/likexian/whois-parser"
+)
+
+func TestOrganizationHints(t *testing.T) {
+	result := whoisparser.WhoisInfo{
+		Registrant:     &whoisparser.Contact{Name: "Jane Doe", Organization: ""},
+		Administrative: &whoisparser.Contact{Organization: "Example Holdings"},
+		Technical:      &whoisparser.Contact{Organization: "REDACTED FOR PRIVACY"},
+	}
+	raw := "domain:   example.nl\nholder:   Example B.V.\nRegistrant Organization: example holdings\nowner: \n"
+	hints := organizationHints(result, raw)
+	expected := []string{"Jane Doe", "Example Holdings", "Example B.V."}
+	if !reflect.DeepEqual(hints, expected) {
+		t.Errorf("organizationHints() = %v, expected %v", hints, expected)
+	}
+}
+
+func TestPromptOrganization(t *testing.T) {
+	hints := []string{"Jane Doe", "Example Holdings"}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2\n", "Example Holdings"},
+		{"Example Inc\n", "Example Inc"},
+		{"7\n1\n", "Jane Doe"},
+		{"\n", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if org := promptOrganization(strings.NewReader(test.input), &out, "example.com", hints); org != test.expected {
+			t.Errorf("promptOrganization(%q) = %q, expected %q", test.input, org, test.expected)
+		}
+		if !strings.Contains(out.String(), "2) Example Holdings") {
+			t.Errorf("The prompt should list the hints, got %q", out.String())
+		}
+	}
+}
+
+func TestNameServerMatch(t *testing.T) {
+	managed := &DomainInfo{Domain: "example.com", NameServers: []string{"ada.ns.cloudflare.com", "bob.ns.cloudflare.com"}}
+	classifyNameServers(managed)
+	registrar := &DomainInfo{Domain: "example.com", NameServers: []string{"ns01.domaincontrol.com", "ns02.domaincontrol.com"}}
+	classifyNameServers(registrar)
+
+	tests := []struct {
+		name     string
+		target   *DomainInfo
+		servers  []string
+		expected bool
+	}{
+		{"same managed set", managed, []string{"BOB.NS.CLOUDFLARE.COM.", "ada.ns.cloudflare.com"}, true},
+		{"other managed set", managed, []string{"ada.ns.cloudflare.com", "carl.ns.cloudflare.com"}, false},
+		{"subset", managed, []string{"ada.ns.cloudflare.com"}, false},
+		{"self-hosted by the target", registrar, []string{"ns1.example.com"}, true},
+		{"registrar default set", registrar, []string{"ns01.domaincontrol.com", "ns02.domaincontrol.com"}, false},
+		{"no name servers", managed, nil, false},
+	}
+	for _, test := range tests {
+		info := &DomainInfo{Domain: "example.net", NameServers: test.servers}
+		if result := nameServerMatch(test.target, info); result != test.expected {
+			t.Errorf("%s: nameServerMatch() = %v, expected %v", test.name, result, test.expected)
+		}
+	}
+	if !usableNameServers(managed) || usableNameServers(registrar) {
+		t.Error("Only managed DNS should be usable for name server matching")
+	}
+}
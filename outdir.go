@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// outputRunLayout names the directory of each scan under -out-dir; it
+// sorts by time and has no characters Windows rejects
+const outputRunLayout = "20060102T150405Z"
+
+// outputLatestLink is the symlink in a target's directory to its newest scan
+const outputLatestLink = "latest"
+
+// validateOutputDir checks -out-dir, which names the result files itself
+func validateOutputDir(config Config) error {
+	if config.OutputDir != "" && config.OutputAll != "" {
+		return fmt.Errorf("-out-dir cannot be combined with -oA; it writes every file format itself")
+	}
+	return nil
+}
+
+// outputRunDir returns the directory of a scan under -out-dir:
+// <out-dir>/<target>/<timestamp>
+func outputRunDir(root, target string, started time.Time) string {
+	target = strings.NewReplacer("/", "_", `\`, "_").Replace(strings.ToLower(target))
+	if target == "" || target == "." || target == ".." {
+		target = "_"
+	}
+	return filepath.Join(root, target, started.UTC().Format(outputRunLayout))
+}
+
+// withOutputRun creates the run directory of a target's scan and points
+// -oA at results.* inside it. Without -out-dir the config is unchanged.
+func withOutputRun(config Config, target string, started time.Time) (Config, error) {
+	if config.OutputDir == "" {
+		return config, nil
+	}
+	dir := outputRunDir(config.OutputDir, target, started)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return config, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	config.OutputAll = filepath.Join(dir, "results")
+	return config, nil
+}
+
+// linkLatestRun points the latest link of the target's directory at the
+// run directory of config once its results are written. The link is
+// replaced atomically, so readers always find a complete scan behind it.
+func linkLatestRun(config Config) {
+	if config.OutputDir == "" || config.OutputAll == "" {
+		return
+	}
+	runDir := filepath.Dir(config.OutputAll)
+	link := filepath.Join(filepath.Dir(runDir), outputLatestLink)
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	err := os.Symlink(filepath.Base(runDir), tmp)
+	if err == nil {
+		if err = os.Rename(tmp, link); err != nil {
+			os.Remove(tmp)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to update %s: %v\n", ColorYellow, ColorReset, link, err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutputRunDir(t *testing.T) {
+	started := time.Date(2026, 10, 16, 9, 30, 5, 0, time.FixedZone("CEST", 2*3600))
+	tests := []struct {
+		target   string
+		expected string
+	}{
+		{"Example.com", filepath.Join("out", "example.com", "20261016T073005Z")},
+		{"../etc", filepath.Join("out", ".._etc", "20261016T073005Z")},
+		{"..", filepath.Join("out", "_", "20261016T073005Z")},
+	}
+	for _, test := range tests {
+		if dir := outputRunDir("out", test.target, started); dir != test.expected {
+			t.Errorf("outputRunDir(%q) = %s, expected %s", test.target, dir, test.expected)
+		}
+	}
+}
+
+func TestOutputRunLatest(t *testing.T) {
+	root := t.TempDir()
+	var last Config
+	for i, started := range []time.Time{
+		time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC),
+	} {
+		config, err := withOutputRun(Config{OutputDir: root, Output: "report.json"}, "example.com", started)
+		if err != nil {
+			t.Fatalf("withOutputRun failed: %v", err)
+		}
+		expected := filepath.Join(root, "example.com", started.Format(outputRunLayout), "results")
+		if config.OutputAll != expected || config.Output != "report.json" {
+			t.Errorf("Scan %d: -oA %s, expected %s", i, config.OutputAll, expected)
+		}
+		linkLatestRun(config)
+		last = config
+	}
+
+	target, err := os.Readlink(filepath.Join(root, "example.com", outputLatestLink))
+	if err != nil {
+		t.Skipf("Symlinks unavailable: %v", err)
+	}
+	if target != filepath.Base(filepath.Dir(last.OutputAll)) {
+		t.Errorf("latest points at %s, expected the newest scan", target)
+	}
+	if _, err := os.Lstat(filepath.Join(root, "example.com", outputLatestLink+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("The temporary link was left behind: %v", err)
+	}
+
+	if config, _ := withOutputRun(Config{OutputAll: "results"}, "example.com", time.Now()); config.OutputAll != "results" {
+		t.Error("Without -out-dir the config should be unchanged")
+	}
+}
+
+func TestValidateOutputDir(t *testing.T) {
+	if err := validateOutputDir(Config{OutputDir: "out", Output: "report.json"}); err != nil {
+		t.Errorf("-out-dir with -o should be valid: %v", err)
+	}
+	if err := validateOutputDir(Config{OutputDir: "out", OutputAll: "results"}); err == nil {
+		t.Error("-out-dir with -oA should be rejected")
+	}
+}
@@ -0,0 +1,650 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"filippo.io/age"
+)
+
+// outputFormats lists the values accepted by -format
+var outputFormats = []string{"text", "json", "csv", "html", "ics", "cef", "leef", "template", "grep", "list", "list-all", "graphml", "dot", "maltego", "feed", "register"}
+
+// resultsWriter receives output written without -o. It stays on the real
+// stdout when progress messages are moved to stderr for list output.
+var resultsWriter io.Writer = os.Stdout
+
+// pipedOutput reports whether the output is a domain list or SIEM events
+// written to stdout for another tool, in which case progress messages go to
+// stderr
+func (c Config) pipedOutput() bool {
+	return c.Output == "" && c.OutputAll == "" && containsString([]string{"list", "list-all", "cef", "leef"}, c.Format)
+}
+
+// validateFormat checks the output format options before any scanning starts
+func validateFormat(config Config) error {
+	if !containsString(outputFormats, config.Format) {
+		return fmt.Errorf("unknown output format %q (valid: %s)", config.Format, strings.Join(outputFormats, ", "))
+	}
+	if config.Format == "template" {
+		if config.Template == "" {
+			return fmt.Errorf("-format template requires -template <file>")
+		}
+		if _, err := loadTemplate(config.Template); err != nil {
+			return err
+		}
+	}
+	if config.Format == "register" && !config.SuggestRegister {
+		return fmt.Errorf("-format register requires -suggest-registrations")
+	}
+	if config.Compress && config.Output == "" && config.OutputAll == "" {
+		return fmt.Errorf("-compress requires -o or -oA")
+	}
+	return nil
+}
+
+// writeOutput renders the result in the configured output format, and in
+// every file format when -oA was given. Only the domains passing -filter
+// are written, in the -sort order. With -sign the files are signed once
+// written. With -encrypt the files are encrypted.
+func writeOutput(result Result, config Config) {
+	recipients := config.Recipients
+	defer signOutputs(config, outputFiles(config, ".json", ".csv", ".txt", ".html"))
+	order, _ := parseSortOrder(config.Sort)
+	result = order.apply(config.ResultFilter.apply(result))
+	if config.OutputAll != "" {
+		outputJSON(result, outputName(config.OutputAll+".json", config), recipients)
+		outputCSV(result, outputName(config.OutputAll+".csv", config), recipients)
+		outputText(result, outputName(config.OutputAll+".txt", config), config.Verbose, recipients)
+		outputHTML(result, outputName(config.OutputAll+".html", config), recipients)
+		if config.Output == "" {
+			return
+		}
+	}
+
+	output := outputName(config.Output, config)
+	switch config.Format {
+	case "json":
+		outputJSON(result, output, recipients)
+	case "csv":
+		outputCSV(result, output, recipients)
+	case "html":
+		outputHTML(result, output, recipients)
+	case "ics":
+		outputICS(result, output, config.ICSReminders, recipients)
+	case "cef", "leef":
+		outputSIEM(result, output, config.Format, recipients)
+	case "template":
+		outputTemplate(result, config.Template, output, recipients)
+	case "grep":
+		outputGrep(result, output, recipients)
+	case "list":
+		outputList(result.MatchingDomains, output, recipients)
+	case "list-all":
+		outputList(registeredDomains(result.AllDomains), output, recipients)
+	case "graphml", "dot", "maltego":
+		outputGraph(result, output, config.Format, recipients)
+	case "feed":
+		outputFeed(result, output, config.FeedFields, recipients)
+	case "register":
+		outputRegister(result, output, recipients)
+	default:
+		outputText(result, output, config.Verbose, recipients)
+	}
+}
+
+// downloadFormats lists the formats a finished scan can be downloaded in from
+// serve mode, with their content types
+var downloadFormats = map[string]string{
+	"json":     "application/json",
+	"csv":      "text/csv; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+	"ics":      "text/calendar; charset=utf-8",
+	"cef":      "text/plain; charset=utf-8",
+	"leef":     "text/plain; charset=utf-8",
+	"text":     "text/plain; charset=utf-8",
+	"grep":     "text/plain; charset=utf-8",
+	"list":     "text/plain; charset=utf-8",
+	"list-all": "text/plain; charset=utf-8",
+	"graphml":  "application/graphml+xml",
+	"dot":      "text/vnd.graphviz; charset=utf-8",
+	"maltego":  "text/csv; charset=utf-8",
+	"feed":     "application/json",
+	"register": "text/csv; charset=utf-8",
+}
+
+// renderFormat renders the result in one of the downloadFormats, without
+// colors
+func renderFormat(result Result, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return renderJSON(result)
+	case "csv":
+		return renderCSV(result)
+	case "html":
+		return renderHTML(result)
+	case "ics":
+		leads, _ := parseLeadTimes(defaultICSReminders)
+		return renderICS(result, leads, time.Now()), nil
+	case "cef", "leef":
+		return renderSIEM(result, format, time.Now()), nil
+	case "text":
+		return []byte(stripANSI(renderText(result, true))), nil
+	case "grep":
+		return renderGrep(result), nil
+	case "list":
+		return renderList(result.MatchingDomains), nil
+	case "list-all":
+		return renderList(registeredDomains(result.AllDomains)), nil
+	case "graphml", "dot", "maltego":
+		return renderGraph(result, format), nil
+	case "feed":
+		return renderFeed(result, nil, time.Now())
+	case "register":
+		return renderRegister(result)
+	}
+	return nil, fmt.Errorf("unknown download format %q", format)
+}
+
+// outputName adds the .gz extension to an output file when -compress is
+// set and the .age extension when -encrypt is. Files already named .gz or
+// .age are compressed or encrypted regardless.
+func outputName(outputFile string, config Config) string {
+	if outputFile == "" {
+		return outputFile
+	}
+	name := strings.TrimSuffix(outputFile, encryptedExt)
+	if config.Compress && !strings.HasSuffix(name, ".gz") {
+		name += ".gz"
+	}
+	if len(config.Encrypt) > 0 || strings.HasSuffix(outputFile, encryptedExt) {
+		name += encryptedExt
+	}
+	return name
+}
+
+// saveOutput writes rendered output to outputFile, or to stdout when no file
+// was given. Files ending in .gz are written as a gzip stream and files
+// ending in .age are encrypted to the -encrypt recipients.
+func saveOutput(data []byte, outputFile string, recipients []age.Recipient) {
+	if outputFile == "" {
+		resultsWriter.Write(data)
+		return
+	}
+	if err := writeOutputFile(outputFile, data, recipients); err != nil {
+		log.Printf("Error writing to file: %v", err)
+		return
+	}
+	if !outputQuiet {
+		fmt.Printf("%s[INFO]%s Results saved to %s\n", ColorBlue, ColorReset, outputFile)
+	}
+}
+
+// writeOutputFile writes an output file, compressed and encrypted to
+// recipients as its extension asks. Regular files are replaced atomically, so a reader or a
+// crash never sees one half written; devices and pipes such as /dev/stdout
+// are written in place.
+func writeOutputFile(outputFile string, data []byte, recipients []age.Recipient) error {
+	if info, err := os.Stat(outputFile); err == nil && !info.Mode().IsRegular() {
+		file, err := os.OpenFile(outputFile, os.O_WRONLY|os.O_TRUNC, 0)
+		if err != nil {
+			return err
+		}
+		if err := encodeOutputFile(file, outputFile, data, recipients); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(outputFile), "."+filepath.Base(outputFile)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := encodeOutputFile(tmp, outputFile, data, recipients); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), outputFile)
+}
+
+// encodeOutputFile writes data to w, compressed and encrypted to recipients
+// as the extension of outputFile asks
+func encodeOutputFile(w io.Writer, outputFile string, data []byte, recipients []age.Recipient) error {
+	encrypted := strings.HasSuffix(outputFile, encryptedExt)
+	compressed := strings.HasSuffix(strings.TrimSuffix(outputFile, encryptedExt), ".gz")
+
+	// Compress before encrypting: ciphertext does not compress. The
+	// writers are closed innermost first.
+	var closers []io.Closer
+	if encrypted {
+		aw, err := encryptWriter(w, recipients)
+		if err != nil {
+			return err
+		}
+		w = aw
+		closers = append([]io.Closer{aw}, closers...)
+	}
+	if compressed {
+		zw := gzip.NewWriter(w)
+		w = zw
+		closers = append([]io.Closer{zw}, closers...)
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templateFuncs are the helpers available to user-supplied report templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func loadTemplate(filename string) (*template.Template, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := template.New(filename).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// outputTemplate renders the result through a user-supplied Go text/template
+// which receives the Result struct as its data
+func outputTemplate(result Result, templateFile, outputFile string, recipients []age.Recipient) {
+	tmpl, err := loadTemplate(templateFile)
+	if err != nil {
+		log.Printf("Error loading template: %v", err)
+		return
+	}
+
+	var output strings.Builder
+	if err := tmpl.Execute(&output, result); err != nil {
+		log.Printf("Error executing template: %v", err)
+		return
+	}
+
+	saveOutput([]byte(output.String()), outputFile, recipients)
+}
+
+// reportDomains returns the domains a per-domain format should list: every
+// scanned domain when -all was used, otherwise matches followed by signals
+func reportDomains(result Result) []DomainInfo {
+	if len(result.AllDomains) > 0 {
+		return result.AllDomains
+	}
+	domains := append([]DomainInfo{}, result.MatchingDomains...)
+	domains = append(domains, result.SignalDomains...)
+	return append(domains, result.Lookalikes...)
+}
+
+// outputList writes one domain per line, for piping into tools such as
+// httpx, nuclei or dnsx
+func outputList(domains []DomainInfo, outputFile string, recipients []age.Recipient) {
+	saveOutput(renderList(domains), outputFile, recipients)
+}
+
+func renderList(domains []DomainInfo) []byte {
+	var output strings.Builder
+	for _, domain := range domains {
+		output.WriteString(domain.Domain + "\n")
+	}
+	return []byte(output.String())
+}
+
+// registeredDomains drops the candidates whose lookup failed or found no
+// registration
+func registeredDomains(domains []DomainInfo) []DomainInfo {
+	var registered []DomainInfo
+	for _, domain := range domains {
+		if domain.Error == "" {
+			registered = append(registered, domain)
+		}
+	}
+	return registered
+}
+
+// grepField makes a value safe for a tab-separated column
+func grepField(s string) string {
+	s = strings.TrimSpace(strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s))
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// outputGrep writes one tab-separated line per domain in the order
+// domain, status, organization, registrar, match (nmap -oG style), and
+// metadata when the domain list had any
+func outputGrep(result Result, outputFile string, recipients []age.Recipient) {
+	saveOutput(renderGrep(result), outputFile, recipients)
+}
+
+func renderGrep(result Result) []byte {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("# TLD Scanner grepable output: target=%s organization=%s\n",
+		result.TargetDomain, grepField(result.TargetOrg)))
+	domains := reportDomains(result)
+	withMetadata := false
+	for _, domain := range domains {
+		withMetadata = withMetadata || len(domain.Metadata) > 0
+	}
+	if withMetadata {
+		output.WriteString("# domain\tstatus\torganization\tregistrar\tmatch\tmetadata\n")
+	} else {
+		output.WriteString("# domain\tstatus\torganization\tregistrar\tmatch\n")
+	}
+
+	for _, domain := range domains {
+		status := domain.Status
+		if domain.Error != "" {
+			status = "error"
+		}
+		match := domain.MatchReason
+		if match == "" && len(domain.Signals) > 0 {
+			match = "signal:" + domain.Signals[0].Name
+		}
+		fields := []string{
+			domain.Domain,
+			grepField(status),
+			grepField(domain.Organization),
+			grepField(domain.Registrar),
+			grepField(match),
+		}
+		if withMetadata {
+			fields = append(fields, grepField(formatMetadata(domain.Metadata, ";")))
+		}
+		output.WriteString(strings.Join(fields, "\t") + "\n")
+	}
+	return []byte(output.String())
+}
+
+// csvHeader is the column layout of CSV output
+var csvHeader = []string{
+	"domain", "status", "organization", "registrar", "created_date", "expiry_date",
+	"name_servers", "ns_provider", "ns_provider_type", "emails", "match_reason", "matched_email", "ownership", "tags", "note", "metadata", "signals", "whois_server", "source", "unicode_domain", "vt_detections", "urlscan_result", "technique", "http_status", "risk_score", "abuse_email", "abuse_phone", "dnssec", "cert_issuance_risk", "mail_capable", "favicon_match", "content_similarity", "parked", "phishing_indicators", "language", "epp_status", "transfer_unlocked", "drop_catch", "created_at", "expires_at", "country", "error_code", "error",
+}
+
+// vtDetections formats the VirusTotal detection count, empty when the
+// domain was not checked
+func vtDetections(domain DomainInfo) string {
+	if domain.VirusTotal == nil {
+		return ""
+	}
+	return strconv.Itoa(domain.VirusTotal.Detections())
+}
+
+// urlscanResult returns the urlscan.io result link, empty when the domain
+// was not submitted
+func urlscanResult(domain DomainInfo) string {
+	if domain.URLScan == nil {
+		return ""
+	}
+	return domain.URLScan.ResultURL
+}
+
+// httpStatus returns the HTTP probe status code, empty when the domain was
+// not probed or did not answer
+func httpStatus(domain DomainInfo) string {
+	if !domain.HTTP.Live() {
+		return ""
+	}
+	return strconv.Itoa(domain.HTTP.StatusCode)
+}
+
+// riskScore returns the lookalike risk score, empty for unscored domains
+func riskScore(domain DomainInfo) string {
+	if domain.RiskScore == 0 {
+		return ""
+	}
+	return strconv.Itoa(domain.RiskScore)
+}
+
+// faviconMatch returns "true" for domains serving the target's favicon
+func faviconMatch(domain DomainInfo) string {
+	if !domain.FaviconMatch {
+		return ""
+	}
+	return "true"
+}
+
+// contentSimilarity returns the similarity to the target's homepage, empty
+// for domains not compared
+func contentSimilarity(domain DomainInfo) string {
+	if domain.ContentSimilarity == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(domain.ContentSimilarity, 'f', 2, 64)
+}
+
+// parked returns "true" for domains classified as parked
+func parked(domain DomainInfo) string {
+	if !domain.Parked {
+		return ""
+	}
+	return "true"
+}
+
+// rfc3339 formats a normalized date, empty when the raw one could not be
+// parsed
+func rfc3339(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// csvFlag returns "true" for a set flag and an empty cell otherwise
+func csvFlag(set bool) string {
+	if !set {
+		return ""
+	}
+	return "true"
+}
+
+// outputCSV writes one row per domain with multi-value fields joined by ";"
+func outputCSV(result Result, outputFile string, recipients []age.Recipient) {
+	data, err := renderCSV(result)
+	if err != nil {
+		log.Printf("Error writing CSV: %v", err)
+		return
+	}
+	saveOutput(data, outputFile, recipients)
+}
+
+func renderCSV(result Result) ([]byte, error) {
+	var output strings.Builder
+	writer := csv.NewWriter(&output)
+	writer.Write(csvHeader)
+
+	for _, domain := range reportDomains(result) {
+		var signals []string
+		for _, signal := range domain.Signals {
+			signals = append(signals, fmt.Sprintf("%s:%.2f", signal.Name, signal.Score))
+		}
+		writer.Write([]string{
+			domain.Domain,
+			domain.Status,
+			domain.Organization,
+			domain.Registrar,
+			domain.CreatedDate,
+			domain.ExpiryDate,
+			strings.Join(domain.NameServers, ";"),
+			domain.NSProvider,
+			domain.NSProviderType,
+			strings.Join(domain.Emails, ";"),
+			domain.MatchReason,
+			domain.MatchedEmail,
+			domain.Ownership,
+			strings.Join(domain.Tags, ";"),
+			domain.Note,
+			formatMetadata(domain.Metadata, ";"),
+			strings.Join(signals, ";"),
+			domain.WhoisServer,
+			domain.Source,
+			domain.UnicodeDomain,
+			vtDetections(domain),
+			urlscanResult(domain),
+			domain.Technique,
+			httpStatus(domain),
+			riskScore(domain),
+			domain.AbuseEmail,
+			domain.AbusePhone,
+			domain.DNSSEC,
+			domain.CertIssuanceRisk,
+			csvFlag(domain.MailCapable),
+			faviconMatch(domain),
+			contentSimilarity(domain),
+			parked(domain),
+			strings.Join(domain.Phishing, ";"),
+			domain.Language,
+			strings.Join(domain.EPPStatus, ";"),
+			csvFlag(domain.TransferUnlocked),
+			csvFlag(domain.DropCatch),
+			rfc3339(domain.CreatedAt),
+			rfc3339(domain.ExpiresAt),
+			domain.Country,
+			string(domain.errorCode()),
+			domain.Error,
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return []byte(output.String()), nil
+}
+
+// htmlReport is the self-contained HTML report layout
+var htmlReport = htmltemplate.Must(htmltemplate.New("report").Funcs(htmltemplate.FuncMap{
+	"join":     strings.Join,
+	"metadata": formatMetadata,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>TLD Scanner - {{if .Brand}}{{.Brand}}{{else}}{{.TargetDomain}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; font-size: 14px; }
+th { background: #f4f4f4; }
+tr.match td:first-child { border-left: 4px solid #2e7d32; }
+tr.signal td:first-child { border-left: 4px solid #7b1fa2; }
+tr.phishing td:first-child { border-left: 4px solid #b71c1c; }
+span.phishing { color: #b71c1c; font-weight: bold; }
+span.metadata { color: #555; font-size: 12px; }
+tr.error { color: #b71c1c; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 4px 16px; }
+dt { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>TLD Scanner Results</h1>
+<dl>
+{{if .Brand}}<dt>Brand</dt><dd>{{.Brand}}</dd>
+{{end}}<dt>Target Domain</dt><dd>{{.TargetDomain}}</dd>
+<dt>Target Organization</dt><dd>{{.TargetOrg}}</dd>
+{{with .Metadata}}<dt>Metadata</dt><dd>{{metadata . ", "}}</dd>
+{{end}}<dt>Scan Duration</dt><dd>{{.ScanDuration}}</dd>
+<dt>Total Scanned</dt><dd>{{.TotalScanned}}</dd>
+<dt>Total Matches</dt><dd>{{.TotalMatches}}</dd>
+{{if .Lookalikes}}<dt>Total Lookalikes</dt><dd>{{.TotalLookalikes}}</dd>
+{{end}}<dt>Total Errors</dt><dd>{{.TotalErrors}}</dd>
+</dl>
+{{if .Lookalikes}}<h2>Lookalikes by Risk</h2>
+<table>
+<tr><th>Risk</th><th>Domain</th><th>Technique</th><th>Registrar</th><th>Created</th><th>DNS Provider</th><th>HTTP</th><th>Risk Factors</th></tr>
+{{range .Lookalikes}}<tr{{if .Phishing}} class="phishing"{{end}}>
+<td>{{.RiskScore}}</td>
+<td>{{.Domain}}{{if .UnicodeDomain}} ({{.UnicodeDomain}}){{end}}{{if .Phishing}} <span class="phishing" title="{{join .Phishing ", "}}">(phishing)</span>{{end}}{{if .Parked}} <span title="{{join .ParkingSignals ", "}}">(parked)</span>{{end}}{{if .TransferUnlocked}} <span title="{{join .EPPStatus ", "}}">(transfer unlocked)</span>{{end}}{{if .DropCatch}} <span title="{{join .EPPStatus ", "}}">(drop catch)</span>{{end}}{{if eq .Ownership "shadow"}} <span title="missing from the known-domains inventory">(shadow)</span>{{end}}{{if .MailCapable}} <span title="{{.SMTP.Host}}: {{.SMTP.Banner}}">(mail)</span>{{end}}{{with .URLScan}} (<a href="{{.ResultURL}}">urlscan</a>, <a href="{{.ScreenshotURL}}">screenshot</a>){{end}}{{with .Metadata}} <span class="metadata">({{metadata . ", "}})</span>{{end}}</td>
+<td>{{.Technique}}</td>
+<td>{{.Registrar}}</td>
+<td>{{.CreatedDate}}</td>
+<td title="{{join .NameServers ", "}}">{{.NSProvider}}{{with .NSProviderType}} ({{.}}){{end}}</td>
+<td>{{with .HTTP}}{{if .StatusCode}}<a href="{{.URL}}">{{.StatusCode}}</a> {{.Title}}{{else}}-{{end}}{{end}}{{with .Language}} <span title="page language">({{.}})</span>{{end}}</td>
+<td>{{range .RiskFactors}}<span title="{{.Detail}}">{{.Name}} +{{.Points}}</span> {{end}}</td>
+</tr>
+{{end}}</table>{{end}}
+<h2>Matching Domains</h2>
+{{if .MatchingDomains}}{{template "table" .MatchingDomains}}{{else}}<p>No matching domains found.</p>{{end}}
+{{range .MatchingDomains}}{{if .Exposure}}<h3>Exposure: {{.Domain}}{{if .UnicodeDomain}} ({{.UnicodeDomain}}){{end}} ({{.Exposure.Provider}})</h3>
+<p>Addresses: {{join .Exposure.IPs ", "}}</p>
+{{if .Exposure.Services}}<table>
+<tr><th>Address</th><th>Port</th><th>Product</th><th>Banner</th><th>Certificate</th></tr>
+{{range .Exposure.Services}}<tr>
+<td>{{.IP}}</td>
+<td>{{.Port}}{{if .Transport}}/{{.Transport}}{{end}}</td>
+<td>{{.Product}}</td>
+<td>{{.Banner}}</td>
+<td>{{with .Certificate}}{{.Subject}}{{if .Issuer}} ({{.Issuer}}){{end}}{{end}}</td>
+</tr>
+{{end}}</table>{{else}}<p>No exposed services recorded.</p>{{end}}{{end}}{{end}}
+{{if .SignalDomains}}<h2>Scored Signals</h2>
+{{template "table" .SignalDomains}}{{end}}
+{{if .AllDomains}}<h2>All Scanned Domains</h2>
+{{template "table" .AllDomains}}{{end}}
+</body>
+</html>
+{{define "table"}}<table>
+<tr><th>Domain</th><th>Organization</th><th>Registrar</th><th>Created</th><th>Expires</th><th>Name Servers</th><th>Match</th></tr>
+{{range .}}<tr class="{{if .Error}}error{{else if .MatchReason}}match{{else if .Signals}}signal{{end}}">
+<td>{{.Domain}}{{if .UnicodeDomain}} ({{.UnicodeDomain}}){{end}}{{if .Parked}} <span title="{{join .ParkingSignals ", "}}">(parked)</span>{{end}}{{if .TransferUnlocked}} <span title="{{join .EPPStatus ", "}}">(transfer unlocked)</span>{{end}}{{if .DropCatch}} <span title="{{join .EPPStatus ", "}}">(drop catch)</span>{{end}}{{if eq .Ownership "shadow"}} <span title="missing from the known-domains inventory">(shadow)</span>{{end}}{{if .MailCapable}} <span title="{{.SMTP.Host}}: {{.SMTP.Banner}}">(mail)</span>{{end}}{{with .URLScan}} (<a href="{{.ResultURL}}">urlscan</a>, <a href="{{.ScreenshotURL}}">screenshot</a>){{end}}{{with .Metadata}} <span class="metadata">({{metadata . ", "}})</span>{{end}}</td>
+<td>{{.Organization}}</td>
+<td>{{.Registrar}}</td>
+<td>{{.CreatedDate}}</td>
+<td>{{.ExpiryDate}}</td>
+<td>{{join .NameServers ", "}}{{with .NSProvider}} ({{.}}){{end}}</td>
+<td>{{if .Error}}error: {{.Error}}{{else if .MatchReason}}{{.MatchReason}}{{if .MatchedEmail}} ({{.MatchedEmail}}){{end}}{{else}}{{range .Signals}}{{.Name}} {{printf "%.2f" .Score}} {{end}}{{end}}</td>
+</tr>
+{{end}}</table>{{end}}
+`))
+
+// outputHTML writes a standalone HTML report
+func outputHTML(result Result, outputFile string, recipients []age.Recipient) {
+	data, err := renderHTML(result)
+	if err != nil {
+		log.Printf("Error rendering HTML: %v", err)
+		return
+	}
+	saveOutput(data, outputFile, recipients)
+}
+
+func renderHTML(result Result) ([]byte, error) {
+	var output strings.Builder
+	if err := htmlReport.Execute(&output, result); err != nil {
+		return nil, err
+	}
+	return []byte(output.String()), nil
+}
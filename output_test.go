@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateFormat(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.tmpl")
+	bad := filepath.Join(dir, "bad.tmpl")
+	os.WriteFile(good, []byte("{{.TargetDomain}}"), 0644)
+	os.WriteFile(bad, []byte("{{.TargetDomain"), 0644)
+
+	testCases := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"Text", Config{Format: "text"}, false},
+		{"JSON", Config{Format: "json"}, false},
+		{"Unknown", Config{Format: "xml"}, true},
+		{"Template missing file", Config{Format: "template"}, true},
+		{"Template valid", Config{Format: "template", Template: good}, false},
+		{"Template invalid", Config{Format: "template", Template: bad}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateFormat(tc.config)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateFormat() error = %v; wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestOutputTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmplFile := filepath.Join(dir, "report.tmpl")
+	outFile := filepath.Join(dir, "report.txt")
+	tmpl := "{{.TargetOrg}}:{{range .MatchingDomains}} {{.Domain}}[{{join .NameServers \",\"}}]{{end}}"
+	if err := os.WriteFile(tmplFile, []byte(tmpl), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	result := Result{
+		TargetOrg: "Example Corp",
+		MatchingDomains: []DomainInfo{
+			{Domain: "example.net", NameServers: []string{"ns1", "ns2"}},
+		},
+	}
+	outputTemplate(result, tmplFile, outFile, nil)
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "Example Corp: example.net[ns1,ns2]" {
+		t.Errorf("outputTemplate() wrote %q", got)
+	}
+}
+
+func TestOutputGrep(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "results.gnmap")
+	result := Result{
+		TargetDomain: "example.com",
+		TargetOrg:    "Example Corp",
+		AllDomains: []DomainInfo{
+			{Domain: "example.net", Status: "ok", Organization: "Example Corp", Registrar: "Mark\tMonitor", MatchReason: "organization"},
+			{Domain: "example.org", Error: "timeout"},
+		},
+	}
+	outputGrep(result, outFile, nil)
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d: %q", len(lines), lines)
+	}
+	expected := []string{
+		"example.net\tok\tExample Corp\tMark Monitor\torganization",
+		"example.org\terror\t-\t-\t-",
+	}
+	for i, want := range expected {
+		if lines[i+2] != want {
+			t.Errorf("Line %d = %q; expected %q", i+2, lines[i+2], want)
+		}
+	}
+}
+
+func TestOutputList(t *testing.T) {
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.net"}},
+		AllDomains: []DomainInfo{
+			{Domain: "example.net"},
+			{Domain: "example.org", Registrar: "MarkMonitor"},
+			{Domain: "example.dev", Error: "not found"},
+		},
+	}
+
+	var buf bytes.Buffer
+	defer func(w io.Writer) { resultsWriter = w }(resultsWriter)
+	resultsWriter = &buf
+
+	writeOutput(result, Config{Format: "list"})
+	if buf.String() != "example.net\n" {
+		t.Errorf("list output = %q", buf.String())
+	}
+
+	buf.Reset()
+	writeOutput(result, Config{Format: "list-all"})
+	if buf.String() != "example.net\nexample.org\n" {
+		t.Errorf("list-all output = %q", buf.String())
+	}
+
+	if !(Config{Format: "list"}).pipedOutput() || (Config{Format: "list", Output: "out.txt"}).pipedOutput() {
+		t.Error("Only list output to stdout should be piped")
+	}
+}
+
+func TestWriteOutputAllFormats(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "results")
+	result := Result{
+		TargetDomain:    "example.com",
+		TargetOrg:       "Example Corp",
+		MatchingDomains: []DomainInfo{{Domain: "example.net", Organization: "Example Corp", MatchReason: "organization"}},
+	}
+	writeOutput(result, Config{Format: "text", OutputAll: base})
+
+	for _, ext := range []string{".json", ".csv", ".txt", ".html"} {
+		data, err := os.ReadFile(base + ext)
+		if err != nil {
+			t.Errorf("Expected %s to be written: %v", ext, err)
+			continue
+		}
+		if !strings.Contains(string(data), "example.net") {
+			t.Errorf("%s output does not mention the matching domain", ext)
+		}
+	}
+}
+
+func TestWriteOutputCompressed(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "results")
+	result := Result{TargetDomain: "example.com", MatchingDomains: []DomainInfo{{Domain: "example.net"}}}
+	writeOutput(result, Config{Format: "json", Output: base + ".json", OutputAll: base, Compress: true})
+
+	for _, name := range []string{base + ".json.gz", base + ".csv.gz", base + ".txt.gz"} {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Errorf("Expected %s to be written: %v", name, err)
+			continue
+		}
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Errorf("%s is not gzip: %v", name, err)
+			file.Close()
+			continue
+		}
+		data, _ := io.ReadAll(zr)
+		file.Close()
+		if !strings.Contains(string(data), "example.net") {
+			t.Errorf("%s does not mention the matching domain", name)
+		}
+	}
+
+	if err := validateFormat(Config{Format: "json", Compress: true}); err == nil {
+		t.Error("Expected -compress without an output file to fail")
+	}
+}
+
+func TestOutputCSV(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "results.csv")
+	result := Result{
+		MatchingDomains: []DomainInfo{
+			{Domain: "example.net", Organization: "Example, Corp", NameServers: []string{"ns1", "ns2"}},
+		},
+	}
+	outputCSV(result, outFile, nil)
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected header and one row, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[1], `example.net,,"Example, Corp",,,,ns1;ns2,`) {
+		t.Errorf("Unexpected CSV row: %q", lines[1])
+	}
+}
+
+func TestOutputTextFileHasNoEscapes(t *testing.T) {
+	outFile := filepath.Join(t.TempDir(), "results.txt")
+	outputText(Result{TargetDomain: "example.com"}, outFile, false, nil)
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if strings.Contains(string(data), "\033[") {
+		t.Error("Text output file should not contain ANSI escape sequences")
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paging endpoints, variables so tests can point them at a local server
+var (
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	opsgenieAlertsURLs = map[string]string{
+		"us": "https://api.opsgenie.com/v2/alerts",
+		"eu": "https://api.eu.opsgenie.com/v2/alerts",
+	}
+)
+
+// newlyRegisteredWindow is how recent a creation date must be for a match
+// found outside monitor mode to count as newly registered
+const newlyRegisteredWindow = 30 * 24 * time.Hour
+
+// pageThresholds are the scores above which findings other than matches
+// page: the risk score of a lookalike and the strongest signal of a signal
+// domain
+type pageThresholds struct {
+	risk   int
+	signal float64
+}
+
+// pageableDomains returns the high-severity findings worth waking someone
+// for: newly registered domains that resolve to a live host and are either
+// matches, lookalikes scored at or above the risk threshold, or signal
+// domains with a signal at or above the signal threshold. For matches in
+// monitor mode "new" means a new_match alert; otherwise a recent creation
+// date.
+func pageableDomains(n Notification, thresholds pageThresholds, timeout time.Duration) []DomainInfo {
+	newMatches := map[string]bool{}
+	for _, alert := range n.Alerts {
+		if alert.Kind == AlertNewMatch {
+			newMatches[alert.Domain] = true
+		}
+	}
+	recent := func(info DomainInfo) bool {
+		created, ok := info.created()
+		return ok && time.Since(created) <= newlyRegisteredWindow
+	}
+
+	var candidates []DomainInfo
+	for _, info := range n.Result.MatchingDomains {
+		if (n.Monitor && newMatches[info.Domain]) || (!n.Monitor && recent(info)) {
+			candidates = append(candidates, info)
+		}
+	}
+	for _, info := range n.Result.Lookalikes {
+		if thresholds.risk > 0 && info.RiskScore >= thresholds.risk && recent(info) {
+			candidates = append(candidates, info)
+		}
+	}
+	for _, info := range n.Result.SignalDomains {
+		if thresholds.signal > 0 && strongestSignal(info) >= thresholds.signal && recent(info) {
+			candidates = append(candidates, info)
+		}
+	}
+
+	// A signal domain may also be a scored lookalike; it pages once
+	seen := map[string]bool{}
+	var domains []DomainInfo
+	for _, info := range candidates {
+		if !seen[info.Domain] && domainResolves(info.Domain, timeout) {
+			seen[info.Domain] = true
+			domains = append(domains, info)
+		}
+	}
+	return domains
+}
+
+// strongestSignal returns the highest signal score of a domain
+func strongestSignal(info DomainInfo) float64 {
+	strongest := 0.0
+	for _, signal := range info.Signals {
+		strongest = math.Max(strongest, signal.Score)
+	}
+	return strongest
+}
+
+// pagingDedupKey identifies a finding so each domain pages only once while
+// its incident or alert is open
+func pagingDedupKey(target, domain string) string {
+	return "tldscanner:" + strings.ToLower(target) + ":" + strings.ToLower(domain)
+}
+
+// pagingSummary is the one-line incident title
+func pagingSummary(target string, info DomainInfo) string {
+	return fmt.Sprintf("Live lookalike of %s registered: %s (%s)", target, info.Domain, firstNonEmpty(info.Registrar, "unknown registrar"))
+}
+
+// pagingDetails are the finding's fields attached to the incident
+func pagingDetails(info DomainInfo) map[string]string {
+	return map[string]string{
+		"domain":       info.Domain,
+		"organization": info.Organization,
+		"registrar":    info.Registrar,
+		"created_date": info.CreatedDate,
+		"name_servers": strings.Join(info.NameServers, ", "),
+		"match_reason": info.MatchReason,
+		"risk_score":   strconv.Itoa(info.RiskScore),
+		"signal_score": strconv.FormatFloat(strongestSignal(info), 'f', -1, 64),
+	}
+}
+
+// postJSON sends v as JSON and expects a 2xx response
+func postJSON(client *http.Client, url string, headers map[string]string, v interface{}) error {
+	return doJSON(client, http.MethodPost, url, headers, v, nil)
+}
+
+// doJSON sends in (if not nil) as JSON, expects a 2xx response and decodes
+// it into out (if not nil)
+func doJSON(client *http.Client, method, url string, headers map[string]string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(out)
+}
+
+// pagerDutyNotifier triggers PagerDuty Events API v2 incidents
+type pagerDutyNotifier struct {
+	routingKey string
+	thresholds pageThresholds
+	timeout    time.Duration
+}
+
+func (p *pagerDutyNotifier) Name() string {
+	return "PagerDuty"
+}
+
+func (p *pagerDutyNotifier) Notify(n Notification) error {
+	client := &http.Client{Timeout: p.timeout}
+	for _, info := range pageableDomains(n, p.thresholds, p.timeout) {
+		event := map[string]interface{}{
+			"routing_key":  p.routingKey,
+			"event_action": "trigger",
+			"dedup_key":    pagingDedupKey(n.Result.TargetDomain, info.Domain),
+			"payload": map[string]interface{}{
+				"summary":        pagingSummary(n.Result.TargetDomain, info),
+				"source":         "tldscanner",
+				"severity":       "critical",
+				"component":      n.Result.TargetDomain,
+				"custom_details": pagingDetails(info),
+			},
+		}
+		if err := postJSON(client, pagerDutyEventsURL, nil, event); err != nil {
+			return fmt.Errorf("%s: %w", info.Domain, err)
+		}
+	}
+	return nil
+}
+
+// opsgenieNotifier creates Opsgenie alerts; the alias deduplicates them
+type opsgenieNotifier struct {
+	apiKey     string
+	url        string
+	thresholds pageThresholds
+	timeout    time.Duration
+}
+
+func (o *opsgenieNotifier) Name() string {
+	return "Opsgenie"
+}
+
+func (o *opsgenieNotifier) Notify(n Notification) error {
+	client := &http.Client{Timeout: o.timeout}
+	headers := map[string]string{"Authorization": "GenieKey " + o.apiKey}
+	for _, info := range pageableDomains(n, o.thresholds, o.timeout) {
+		alert := map[string]interface{}{
+			"message":     truncate(pagingSummary(n.Result.TargetDomain, info), 130),
+			"alias":       pagingDedupKey(n.Result.TargetDomain, info.Domain),
+			"description": fmt.Sprintf("%s is registered to %s and resolves to a live host.", info.Domain, firstNonEmpty(info.Organization, "an unknown registrant")),
+			"priority":    "P1",
+			"source":      "tldscanner",
+			"tags":        []string{"tldscanner", "lookalike"},
+			"details":     pagingDetails(info),
+		}
+		if err := postJSON(client, o.url, headers, alert); err != nil {
+			return fmt.Errorf("%s: %w", info.Domain, err)
+		}
+	}
+	return nil
+}
+
+// validatePaging checks the paging options
+func validatePaging(config Config) error {
+	if _, ok := opsgenieAlertsURLs[config.OpsgenieRegion]; !ok {
+		return fmt.Errorf("unknown Opsgenie region %q (valid: us, eu)", config.OpsgenieRegion)
+	}
+	if config.PageMinRisk < 0 || config.PageMinRisk > 100 {
+		return fmt.Errorf("-page-min-risk must be between 0 and 100")
+	}
+	if config.PageMinSignal < 0 || config.PageMinSignal > 1 {
+		return fmt.Errorf("-page-min-signal must be between 0 and 1")
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes, marking the cut with "..."
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPageableDomains(t *testing.T) {
+	// localhost always resolves, standing in for a live site
+	recent := time.Now().AddDate(0, 0, -3).Format("2006-01-02")
+	n := Notification{Result: Result{MatchingDomains: []DomainInfo{
+		{Domain: "localhost", CreatedDate: recent},
+		{Domain: "localhost", CreatedDate: "2010-01-01"},
+		{Domain: "example.invalid", CreatedDate: recent},
+	}}}
+	if domains := pageableDomains(n, pageThresholds{}, 5*time.Second); len(domains) != 1 || domains[0].CreatedDate != recent {
+		t.Errorf("pageableDomains() = %+v; expected only the recent live match", domains)
+	}
+
+	monitor := Notification{
+		Monitor: true,
+		Result:  Result{MatchingDomains: []DomainInfo{{Domain: "localhost", CreatedDate: "2010-01-01"}}},
+		Alerts:  []Alert{{Kind: AlertNewMatch, Domain: "localhost"}},
+	}
+	if domains := pageableDomains(monitor, pageThresholds{}, 5*time.Second); len(domains) != 1 {
+		t.Errorf("pageableDomains() = %+v; expected the new_match alert to count as new", domains)
+	}
+
+	findings := Notification{Result: Result{
+		Lookalikes: []DomainInfo{
+			{Domain: "localhost", CreatedDate: recent, RiskScore: 85},
+			{Domain: "localhost.", CreatedDate: recent, RiskScore: 40},
+		},
+		SignalDomains: []DomainInfo{
+			{Domain: "localhost", CreatedDate: recent, Signals: []Signal{{Name: "registrar_pivot", Score: 0.9}}},
+			{Domain: "localhost.", CreatedDate: recent, Signals: []Signal{{Name: "registrar_pivot", Score: 0.3}}},
+		},
+	}}
+	if domains := pageableDomains(findings, pageThresholds{risk: 70, signal: 0.8}, 5*time.Second); len(domains) != 1 || domains[0].RiskScore != 85 {
+		t.Errorf("pageableDomains() = %+v; expected the high-risk lookalike to page once", domains)
+	}
+	if domains := pageableDomains(findings, pageThresholds{signal: 0.8}, 5*time.Second); len(domains) != 1 || len(domains[0].Signals) != 1 {
+		t.Errorf("pageableDomains() = %+v; expected the strong signal domain to page", domains)
+	}
+	if domains := pageableDomains(findings, pageThresholds{}, 5*time.Second); len(domains) != 0 {
+		t.Errorf("pageableDomains() = %+v; expected no lookalikes or signals to page without thresholds", domains)
+	}
+}
+
+func TestPagerDutyNotifier(t *testing.T) {
+	var events []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&event)
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	oldURL := pagerDutyEventsURL
+	pagerDutyEventsURL = server.URL
+	defer func() { pagerDutyEventsURL = oldURL }()
+
+	n := Notification{
+		Monitor: true,
+		Result:  Result{TargetDomain: "example.com", MatchingDomains: []DomainInfo{{Domain: "localhost", Registrar: "NameCheap"}}},
+		Alerts:  []Alert{{Kind: AlertNewMatch, Domain: "localhost"}},
+	}
+	notifier := &pagerDutyNotifier{routingKey: "rk", timeout: 5 * time.Second}
+	if err := notifier.Notify(n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0]["dedup_key"] != "tldscanner:example.com:localhost" || events[0]["routing_key"] != "rk" {
+		t.Errorf("Unexpected event: %v", events[0])
+	}
+}
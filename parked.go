@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Parking signal weights. A domain is parked from parkedThreshold on: a
+// parking provider's name servers or addresses are enough on their own, a
+// for-sale page or wildcard DNS only together.
+const (
+	parkingWeightNS       = 2
+	parkingWeightIP       = 2
+	parkingWeightPage     = 1
+	parkingWeightWildcard = 1
+	parkedThreshold       = 2
+)
+
+// parkingNameServers are the name server domains of domain parking and
+// aftermarket providers
+var parkingNameServers = []string{
+	"above.com",
+	"afternic.com",
+	"bodis.com",
+	"dan.com",
+	"fabulous.com",
+	"hugedomains.com",
+	"namefind.com",
+	"parkingcrew.net",
+	"parklogic.com",
+	"sedoparking.com",
+	"undeveloped.com",
+	"ztomy.com",
+}
+
+// parkingNetworks are the address ranges parking providers serve their
+// landing pages from
+var parkingNetworks = mustParseCIDRs(
+	"91.195.240.0/23",   // Sedo
+	"185.53.176.0/22",   // ParkingCrew
+	"199.59.240.0/22",   // Bodis
+	"103.224.182.0/24",  // Above.com
+	"103.224.212.0/24",  // Above.com
+	"34.102.136.180/32", // GoDaddy parked pages
+)
+
+// parkingPagePattern matches the for-sale and pay-per-click wording of
+// parking pages
+var parkingPagePattern = regexp.MustCompile(`(?i)(?:this|the) domain(?: name)?(?: [a-z0-9.-]+)? (?:is|may be) for sale|buy this domain|this domain (?:is|has been) parked|parked free|make an offer on this domain|inquire about (?:this|the) domain|related searches`)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// parkingEvidence holds the DNS lookups made to classify a domain as parked
+// beyond its WHOIS name servers and HTTP probe
+type parkingEvidence struct {
+	// Addresses are the addresses the domain resolves to
+	Addresses []string
+	// Wildcard is set when a random subdomain resolves too
+	Wildcard bool
+}
+
+// lookupParkingEvidence resolves a domain and a random subdomain of it
+func lookupParkingEvidence(domain string, timeout time.Duration) parkingEvidence {
+	var evidence parkingEvidence
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if addrs, err := net.DefaultResolver.LookupHost(ctx, domain); err == nil {
+		evidence.Addresses = addrs
+	}
+
+	label := make([]byte, 8)
+	rand.Read(label)
+	if addrs, err := net.DefaultResolver.LookupHost(ctx, "tldscanner-"+hex.EncodeToString(label)+"."+domain); err == nil && len(addrs) > 0 {
+		evidence.Wildcard = true
+	}
+	return evidence
+}
+
+// parkingNameServer returns the first name server of a parking provider
+func parkingNameServer(nameServers []string) string {
+	for _, ns := range nameServers {
+		host := strings.ToLower(strings.TrimSuffix(ns, "."))
+		for _, provider := range parkingNameServers {
+			if host == provider || strings.HasSuffix(host, "."+provider) {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+// parkingAddress returns the first address in a parking provider's range
+func parkingAddress(addresses []string) string {
+	for _, addr := range addresses {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		for _, network := range parkingNetworks {
+			if network.Contains(ip) {
+				return addr
+			}
+		}
+	}
+	return ""
+}
+
+// classifyParking combines the parking signals of a registered domain, its
+// name servers, the evidence looked up and the for-sale markers of its
+// probed page, and marks it parked when they add up to parkedThreshold
+func classifyParking(info *DomainInfo, evidence parkingEvidence) {
+	var signals []string
+	weight := 0
+	if ns := parkingNameServer(info.NameServers); ns != "" {
+		signals = append(signals, "parking_ns:"+ns)
+		weight += parkingWeightNS
+	}
+	if addr := parkingAddress(evidence.Addresses); addr != "" {
+		signals = append(signals, "parking_ip:"+addr)
+		weight += parkingWeightIP
+	}
+	if info.HTTP != nil && info.HTTP.parkingMarker != "" {
+		signals = append(signals, fmt.Sprintf("for_sale_page:%q", info.HTTP.parkingMarker))
+		weight += parkingWeightPage
+	}
+	if evidence.Wildcard {
+		signals = append(signals, "wildcard_dns")
+		weight += parkingWeightWildcard
+	}
+
+	info.Parked = weight >= parkedThreshold
+	info.ParkingSignals = signals
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClassifyParking(t *testing.T) {
+	forSale := &HTTPProbe{StatusCode: 200, parkingMarker: "this domain is for sale"}
+	tests := []struct {
+		name        string
+		info        DomainInfo
+		evidence    parkingEvidence
+		wantParked  bool
+		wantSignals []string
+	}{
+		{
+			"parking name servers",
+			DomainInfo{NameServers: []string{"NS1.SEDOPARKING.COM.", "ns2.sedoparking.com"}},
+			parkingEvidence{},
+			true,
+			[]string{"parking_ns:ns1.sedoparking.com"},
+		},
+		{
+			"parking address",
+			DomainInfo{NameServers: []string{"ns1.example-dns.net"}},
+			parkingEvidence{Addresses: []string{"2001:db8::1", "185.53.178.14"}},
+			true,
+			[]string{"parking_ip:185.53.178.14"},
+		},
+		{
+			"for-sale page with wildcard DNS",
+			DomainInfo{HTTP: forSale},
+			parkingEvidence{Addresses: []string{"192.0.2.10"}, Wildcard: true},
+			true,
+			[]string{`for_sale_page:"this domain is for sale"`, "wildcard_dns"},
+		},
+		{
+			"for-sale page alone",
+			DomainInfo{HTTP: forSale},
+			parkingEvidence{Addresses: []string{"192.0.2.10"}},
+			false,
+			[]string{`for_sale_page:"this domain is for sale"`},
+		},
+		{
+			"hosted",
+			DomainInfo{NameServers: []string{"ns1.cloudflare.com"}, HTTP: &HTTPProbe{StatusCode: 200}},
+			parkingEvidence{Addresses: []string{"192.0.2.10"}},
+			false,
+			nil,
+		},
+		{
+			"provider name as a label only",
+			DomainInfo{NameServers: []string{"ns1.notbodis.com"}},
+			parkingEvidence{},
+			false,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		info := tt.info
+		classifyParking(&info, tt.evidence)
+		if info.Parked != tt.wantParked || !reflect.DeepEqual(info.ParkingSignals, tt.wantSignals) {
+			t.Errorf("%s: got parked %t %v, expected %t %v", tt.name, info.Parked, info.ParkingSignals, tt.wantParked, tt.wantSignals)
+		}
+	}
+}
+
+func TestProbeURLParkingMarker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><title>examp1e.shop</title><body><h1>The domain examp1e.shop may be FOR SALE</h1><p>Related Searches</p></body></html>"))
+	}))
+	defer server.Close()
+
+	probe := probeURL(&http.Client{Timeout: 5 * time.Second}, server.URL, nil)
+	if probe.parkingMarker != "the domain examp1e.shop may be for sale" {
+		t.Errorf("Expected a parking marker, got %q", probe.parkingMarker)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// passiveDNSProviders are the valid values of -passive-dns
+var passiveDNSProviders = []string{"circl", "farsight", "securitytrails"}
+
+// Provider endpoints, variables so tests can point them at a local server
+var (
+	circlPDNSURL     = "https://www.circl.lu/pdns/query"
+	farsightDNSDBURL = "https://api.dnsdb.info/dnsdb/v2"
+)
+
+const (
+	// maxPassiveDNSRecords bounds the resolutions kept per domain, newest
+	// first
+	maxPassiveDNSRecords = 100
+	// maxPassiveDNSShown bounds the resolutions listed in the text report
+	// without -v
+	maxPassiveDNSShown = 10
+)
+
+// securityTrailsPDNSTypes are the record types whose history is fetched
+// from SecurityTrails, one API call each, with the field holding the value
+var securityTrailsPDNSTypes = []struct{ recordType, field string }{
+	{"a", "ip"},
+	{"aaaa", "ipv6"},
+	{"ns", "nameserver"},
+}
+
+// PassiveDNSRecord is one historical resolution of a domain: the period
+// over which a sensor saw it answer with Value
+type PassiveDNSRecord struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	FirstSeen string `json:"first_seen,omitempty"`
+	LastSeen  string `json:"last_seen,omitempty"`
+	Count     int    `json:"count,omitempty"`
+}
+
+// String renders the record for the text report
+func (r PassiveDNSRecord) String() string {
+	return fmt.Sprintf("%s..%s %s %s", r.FirstSeen, r.LastSeen, r.Type, r.Value)
+}
+
+// passiveDNSProvider looks up the historical resolutions of a domain
+type passiveDNSProvider interface {
+	resolutions(client *http.Client, domain string) ([]PassiveDNSRecord, error)
+}
+
+// validatePassiveDNS checks the -passive-dns provider name
+func validatePassiveDNS(provider string) error {
+	if provider == "" || containsString(passiveDNSProviders, provider) {
+		return nil
+	}
+	return fmt.Errorf("unknown passive DNS provider %q (valid: %s)", provider, strings.Join(passiveDNSProviders, ", "))
+}
+
+// newPassiveDNSProvider returns the -passive-dns provider with its
+// credentials
+func newPassiveDNSProvider(config Config) passiveDNSProvider {
+	switch config.PassiveDNS {
+	case "circl":
+		return circlPDNS{user: config.APIUsernames["circl"], password: config.APIKeys["circl"]}
+	case "farsight":
+		return farsightDNSDB{key: config.APIKeys["farsight"]}
+	case "securitytrails":
+		return securityTrailsPDNS{key: config.APIKeys["securitytrails"]}
+	}
+	return nil
+}
+
+// enrichPassiveDNS records the historical resolutions of info's domain,
+// newest first
+func enrichPassiveDNS(info *DomainInfo, config Config) error {
+	provider := newPassiveDNSProvider(config)
+	if provider == nil {
+		return fmt.Errorf("unknown passive DNS provider %q", config.PassiveDNS)
+	}
+	records, err := provider.resolutions(enrichmentHTTPClient(config, config.PassiveDNS), info.Domain)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].FirstSeen != records[j].FirstSeen {
+			return records[i].FirstSeen > records[j].FirstSeen
+		}
+		return records[i].LastSeen > records[j].LastSeen
+	})
+	if len(records) > maxPassiveDNSRecords {
+		records = records[:maxPassiveDNSRecords]
+	}
+	info.PassiveDNS = records
+	return nil
+}
+
+// passiveDNSGet performs a provider request and returns the response body,
+// nil when the provider has no data for the domain
+func passiveDNSGet(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// eachJSONLine decodes every non-empty line of newline-delimited JSON
+func eachJSONLine(body []byte, decode func(line []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if err := decode(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// epochDate formats a Unix timestamp in seconds as YYYY-MM-DD
+func epochDate(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format("2006-01-02")
+}
+
+// circlPDNS queries CIRCL Passive DNS, which answers in the Passive DNS
+// Common Output Format, one JSON object per line. CIRCL authenticates with
+// the account name as username and the password as key.
+type circlPDNS struct {
+	user, password string
+}
+
+func (c circlPDNS) resolutions(client *http.Client, domain string) ([]PassiveDNSRecord, error) {
+	req, err := http.NewRequest(http.MethodGet, circlPDNSURL+"/"+url.PathEscape(domain), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.user, c.password)
+
+	body, err := passiveDNSGet(client, req)
+	if err != nil {
+		return nil, err
+	}
+	var records []PassiveDNSRecord
+	err = eachJSONLine(body, func(line []byte) error {
+		var entry struct {
+			RRType    string `json:"rrtype"`
+			RData     string `json:"rdata"`
+			TimeFirst int64  `json:"time_first"`
+			TimeLast  int64  `json:"time_last"`
+			Count     int    `json:"count"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		records = append(records, PassiveDNSRecord{
+			Type:      strings.ToUpper(entry.RRType),
+			Value:     strings.TrimSuffix(entry.RData, "."),
+			FirstSeen: epochDate(entry.TimeFirst),
+			LastSeen:  epochDate(entry.TimeLast),
+			Count:     entry.Count,
+		})
+		return nil
+	})
+	return records, err
+}
+
+// farsightDNSDB queries Farsight DNSDB API v2, which streams results as
+// newline-delimited JSON wrapped in begin/succeeded conditions
+type farsightDNSDB struct {
+	key string
+}
+
+func (f farsightDNSDB) resolutions(client *http.Client, domain string) ([]PassiveDNSRecord, error) {
+	req, err := http.NewRequest(http.MethodGet, farsightDNSDBURL+"/lookup/rrset/name/"+url.PathEscape(domain)+"?limit="+fmt.Sprint(maxPassiveDNSRecords), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-Key", f.key)
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	body, err := passiveDNSGet(client, req)
+	if err != nil {
+		return nil, err
+	}
+	var records []PassiveDNSRecord
+	err = eachJSONLine(body, func(line []byte) error {
+		var entry struct {
+			Cond string `json:"cond"`
+			Msg  string `json:"msg"`
+			Obj  *struct {
+				RRType        string   `json:"rrtype"`
+				RData         []string `json:"rdata"`
+				TimeFirst     int64    `json:"time_first"`
+				TimeLast      int64    `json:"time_last"`
+				ZoneTimeFirst int64    `json:"zone_time_first"`
+				ZoneTimeLast  int64    `json:"zone_time_last"`
+				Count         int      `json:"count"`
+			} `json:"obj"`
+		}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if entry.Cond == "failed" {
+			return fmt.Errorf("DNSDB: %s", entry.Msg)
+		}
+		obj := entry.Obj
+		if obj == nil {
+			return nil
+		}
+		first, last := obj.TimeFirst, obj.TimeLast
+		if first == 0 {
+			// Seen in a zone file rather than by a sensor
+			first, last = obj.ZoneTimeFirst, obj.ZoneTimeLast
+		}
+		for _, rdata := range obj.RData {
+			records = append(records, PassiveDNSRecord{
+				Type:      strings.ToUpper(obj.RRType),
+				Value:     strings.TrimSuffix(rdata, "."),
+				FirstSeen: epochDate(first),
+				LastSeen:  epochDate(last),
+				Count:     obj.Count,
+			})
+		}
+		return nil
+	})
+	return records, err
+}
+
+// securityTrailsPDNS reads the DNS history of SecurityTrails
+type securityTrailsPDNS struct {
+	key string
+}
+
+// securityTrailsDNSHistory is the /history/{domain}/dns/{type} response
+type securityTrailsDNSHistory struct {
+	Records []struct {
+		FirstSeen string                   `json:"first_seen"`
+		LastSeen  string                   `json:"last_seen"`
+		Values    []map[string]interface{} `json:"values"`
+	} `json:"records"`
+}
+
+func (s securityTrailsPDNS) resolutions(client *http.Client, domain string) ([]PassiveDNSRecord, error) {
+	var records []PassiveDNSRecord
+	for _, t := range securityTrailsPDNSTypes {
+		var history securityTrailsDNSHistory
+		if err := securityTrailsGet(client, s.key, "/history/"+domain+"/dns/"+t.recordType, &history); err != nil {
+			return nil, fmt.Errorf("%s history: %w", t.recordType, err)
+		}
+		for _, record := range history.Records {
+			for _, value := range record.Values {
+				v, ok := value[t.field].(string)
+				if !ok || v == "" {
+					continue
+				}
+				records = append(records, PassiveDNSRecord{
+					Type:      strings.ToUpper(t.recordType),
+					Value:     strings.TrimSuffix(v, "."),
+					FirstSeen: record.FirstSeen,
+					LastSeen:  record.LastSeen,
+				})
+			}
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCirclPDNS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "analyst" || password != "circl-pass" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/example.net" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"count": 12, "origin": "https://www.circl.lu/pdns/", "time_first": 1577836800, "rrtype": "A", "rrname": "example.net", "rdata": "192.0.2.1", "time_last": 1704067200}
+{"count": 3, "time_first": 1714521600, "rrtype": "NS", "rrname": "example.net", "rdata": "ns1.attacker.example.", "time_last": 1717200000}
+`))
+	}))
+	defer server.Close()
+	defer func(old string) { circlPDNSURL = old }(circlPDNSURL)
+	circlPDNSURL = server.URL
+
+	records, err := circlPDNS{user: "analyst", password: "circl-pass"}.resolutions(server.Client(), "example.net")
+	if err != nil {
+		t.Fatalf("resolutions failed: %v", err)
+	}
+	want := []PassiveDNSRecord{
+		{Type: "A", Value: "192.0.2.1", FirstSeen: "2020-01-01", LastSeen: "2024-01-01", Count: 12},
+		{Type: "NS", Value: "ns1.attacker.example", FirstSeen: "2024-05-01", LastSeen: "2024-06-01", Count: 3},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %+v, got %+v", want, records)
+	}
+
+	if records, err := (circlPDNS{user: "analyst", password: "circl-pass"}).resolutions(server.Client(), "unseen.example"); err != nil || len(records) != 0 {
+		t.Errorf("Unseen domain: %v, %v; expected no records and no error", records, err)
+	}
+	if _, err := (circlPDNS{user: "analyst", password: "wrong"}).resolutions(server.Client(), "example.net"); err == nil {
+		t.Error("Expected an error with bad credentials")
+	}
+}
+
+func TestFarsightDNSDB(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "dnsdb-key" {
+			http.Error(w, "Error: API key not valid", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/lookup/rrset/name/example.net":
+			w.Write([]byte(`{"cond":"begin"}
+{"obj":{"count":40,"time_first":1577836800,"time_last":1704067200,"rrname":"example.net.","rrtype":"A","bailiwick":"example.net.","rdata":["192.0.2.1","192.0.2.2"]}}
+{"obj":{"count":1,"zone_time_first":1714521600,"zone_time_last":1717200000,"rrname":"example.net.","rrtype":"NS","bailiwick":"net.","rdata":["ns1.attacker.example."]}}
+{"cond":"succeeded"}
+`))
+		default:
+			w.Write([]byte("{\"cond\":\"begin\"}\n{\"cond\":\"failed\",\"msg\":\"quota exceeded\"}\n"))
+		}
+	}))
+	defer server.Close()
+	defer func(old string) { farsightDNSDBURL = old }(farsightDNSDBURL)
+	farsightDNSDBURL = server.URL
+
+	records, err := farsightDNSDB{key: "dnsdb-key"}.resolutions(server.Client(), "example.net")
+	if err != nil {
+		t.Fatalf("resolutions failed: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected one record per rdata value, got %+v", records)
+	}
+	if records[1].Value != "192.0.2.2" || records[1].FirstSeen != "2020-01-01" || records[1].Count != 40 {
+		t.Errorf("Unexpected sensor record %+v", records[1])
+	}
+	if records[2].Value != "ns1.attacker.example" || records[2].FirstSeen != "2024-05-01" {
+		t.Errorf("Expected zone file times for the NS record, got %+v", records[2])
+	}
+
+	if _, err := (farsightDNSDB{key: "dnsdb-key"}).resolutions(server.Client(), "other.example"); err == nil {
+		t.Error("Expected a failed stream to be an error")
+	}
+}
+
+func TestEnrichPassiveDNSSecurityTrails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("APIKEY") != "st-key" {
+			http.Error(w, `{"message":"Invalid authentication credentials"}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/history/example.net/dns/a":
+			w.Write([]byte(`{"records":[
+				{"first_seen":"2024-05-01","last_seen":"2024-06-01","values":[{"ip":"203.0.113.7","ip_count":3}],"organizations":["Bulletproof Hosting"]},
+				{"first_seen":"2019-01-01","last_seen":"2024-04-30","values":[{"ip":"192.0.2.1"}]}
+			]}`))
+		case "/history/example.net/dns/ns":
+			w.Write([]byte(`{"records":[{"first_seen":"2019-01-01","last_seen":"2024-06-01","values":[{"nameserver":"ns1.registrar.example"}]}]}`))
+		default:
+			w.Write([]byte(`{"records":[]}`))
+		}
+	}))
+	defer server.Close()
+	defer func(old string) { securityTrailsBaseURL = old }(securityTrailsBaseURL)
+	securityTrailsBaseURL = server.URL
+
+	info := &DomainInfo{Domain: "example.net"}
+	config := Config{Timeout: 5, PassiveDNS: "securitytrails", APIKeys: map[string]string{"securitytrails": "st-key"}}
+	if err := enrichPassiveDNS(info, config); err != nil {
+		t.Fatalf("enrichPassiveDNS failed: %v", err)
+	}
+	if len(info.PassiveDNS) != 3 {
+		t.Fatalf("Expected 3 records, got %+v", info.PassiveDNS)
+	}
+	// Newest first: the recent move to new infrastructure leads
+	if got := info.PassiveDNS[0].String(); got != "2024-05-01..2024-06-01 A 203.0.113.7" {
+		t.Errorf("Expected the newest resolution first, got %q", got)
+	}
+}
+
+func TestValidatePassiveDNS(t *testing.T) {
+	for provider, wantErr := range map[string]bool{"": false, "circl": false, "farsight": false, "securitytrails": false, "dnsdb": true} {
+		if err := validatePassiveDNS(provider); (err != nil) != wantErr {
+			t.Errorf("validatePassiveDNS(%q) error = %v, wantErr %v", provider, err, wantErr)
+		}
+	}
+}
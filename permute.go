@@ -0,0 +1,105 @@
+package main
+
+import "strings"
+
+// Techniques that produce a lookalike candidate
+const (
+	TechniqueTLD         = "tld"
+	TechniquePermutation = "permutation"
+	TechniqueHomoglyph   = "homoglyph"
+)
+
+// variant is a lookalike second-level name and the technique producing it
+type variant struct {
+	Name      string
+	Technique string
+}
+
+// keyboardNeighbors maps each key to its neighbors on a QWERTY keyboard
+var keyboardNeighbors = map[byte]string{
+	'1': "2q", '2': "13qw", '3': "24we", '4': "35er", '5': "46rt", '6': "57ty", '7': "68yu", '8': "79ui", '9': "80io", '0': "9op",
+	'q': "12wa", 'w': "23qeas", 'e': "34wrsd", 'r': "45etdf", 't': "56ryfg", 'y': "67tugh", 'u': "78yihj", 'i': "89uojk", 'o': "90ipkl", 'p': "0ol",
+	'a': "qwsz", 's': "weadzx", 'd': "erfsxc", 'f': "rtgdcv", 'g': "tyhfvb", 'h': "yujgbn", 'j': "uikhnm", 'k': "iolmj", 'l': "opk",
+	'z': "asx", 'x': "zsdc", 'c': "xdfv", 'v': "cfgb", 'b': "vghn", 'n': "bhjm", 'm': "njk",
+}
+
+// asciiHomoglyphs are character sequences that read alike in most fonts
+var asciiHomoglyphs = [][2]string{
+	{"m", "rn"}, {"rn", "m"}, {"w", "vv"}, {"d", "cl"}, {"l", "1"}, {"l", "i"},
+	{"i", "1"}, {"i", "l"}, {"o", "0"}, {"0", "o"}, {"1", "l"}, {"g", "q"},
+}
+
+// unicodeHomoglyphs map Latin letters to Cyrillic and Greek letters that
+// render the same; the resulting names become IDN (punycode) domains
+var unicodeHomoglyphs = map[rune][]rune{
+	'a': {'а'}, 'c': {'с', 'ϲ'}, 'e': {'е'}, 'i': {'і'}, 'j': {'ј'}, 'o': {'о', 'ο'},
+	'p': {'р'}, 's': {'ѕ'}, 'x': {'х'}, 'y': {'у'}, 'h': {'һ'}, 'k': {'κ'},
+}
+
+// permutations returns typo variants of name: omitted, repeated, swapped
+// and mistyped characters, inserted hyphens, and name combined with each
+// affix ("login" gives example-login, examplelogin, login-example, ...)
+func permutations(name string, affixes []string) []variant {
+	var names []string
+	for i := 0; i < len(name); i++ {
+		// Omission and repetition
+		names = append(names, name[:i]+name[i+1:], name[:i+1]+name[i:])
+		if i+1 < len(name) && name[i] != name[i+1] {
+			names = append(names, name[:i]+name[i+1:i+2]+name[i:i+1]+name[i+2:])
+		}
+		for _, key := range keyboardNeighbors[name[i]] {
+			names = append(names, name[:i]+string(key)+name[i+1:])
+		}
+		if i > 0 && name[i] != '-' && name[i-1] != '-' {
+			names = append(names, name[:i]+"-"+name[i:])
+		}
+	}
+	for _, affix := range affixes {
+		affix = strings.ToLower(strings.TrimSpace(affix))
+		if affix == "" {
+			continue
+		}
+		names = append(names, name+"-"+affix, name+affix, affix+"-"+name, affix+name)
+	}
+	return uniqueVariants(name, names, TechniquePermutation)
+}
+
+// homoglyphs returns variants of name with one character, or character
+// pair, replaced by a visually similar one
+func homoglyphs(name string) []variant {
+	var names []string
+	for _, pair := range asciiHomoglyphs {
+		for i := strings.Index(name, pair[0]); i >= 0; {
+			names = append(names, name[:i]+pair[1]+name[i+len(pair[0]):])
+			next := strings.Index(name[i+1:], pair[0])
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+	}
+
+	runes := []rune(name)
+	for i, r := range runes {
+		for _, glyph := range unicodeHomoglyphs[r] {
+			replaced := append([]rune{}, runes...)
+			replaced[i] = glyph
+			names = append(names, string(replaced))
+		}
+	}
+	return uniqueVariants(name, names, TechniqueHomoglyph)
+}
+
+// uniqueVariants drops duplicates, empty names and the original name
+func uniqueVariants(original string, names []string, technique string) []variant {
+	seen := map[string]bool{original: true, "": true}
+	var variants []variant
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		variants = append(variants, variant{Name: name, Technique: technique})
+	}
+	return variants
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func variantNames(variants []variant) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range variants {
+		names[v.Name] = true
+	}
+	return names
+}
+
+func TestPermutations(t *testing.T) {
+	names := variantNames(permutations("acme", []string{"login"}))
+	for _, want := range []string{"ame", "accme", "amce", "scme", "ac-me", "acme-login", "loginacme"} {
+		if !names[want] {
+			t.Errorf("Expected permutation %q", want)
+		}
+	}
+	if names["acme"] {
+		t.Error("The original name must not be a permutation")
+	}
+}
+
+func TestHomoglyphs(t *testing.T) {
+	names := variantNames(homoglyphs("modem"))
+	for _, want := range []string{"rnodem", "modern", "m0dem", "moclem", "mоdem"} {
+		if !names[want] {
+			t.Errorf("Expected homoglyph %q", want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Phishing indicators found on probed lookalike pages
+const (
+	phishPasswordField = "password_field"
+	phishLoginForm     = "login_form"
+	phishCardFields    = "card_fields"
+	phishExternalForm  = "external_form_action"
+	phishTelegram      = "telegram_exfiltration"
+	phishObfuscated    = "obfuscated_script"
+	phishUrgency       = "urgency_wording"
+)
+
+// phishingMarker recognizes one indicator in the lowercased page HTML
+type phishingMarker struct {
+	indicator string
+	pattern   *regexp.Regexp
+}
+
+// phishingMarkers are the page markers of login and credential-harvesting
+// kits. external_form_action needs the page URL and is checked separately.
+var phishingMarkers = []phishingMarker{
+	{phishPasswordField, regexp.MustCompile(`<input[^>]*type\s*=\s*["']?password`)},
+	{phishLoginForm, regexp.MustCompile(`(?s)<form.*(sign[ -]?in|log[ -]?in|logon|anmelden|connexion|iniciar sesi)`)},
+	{phishCardFields, regexp.MustCompile(`<input[^>]*(cc-number|cc-csc|card[-_ ]?number|cvv|cvc)`)},
+	{phishTelegram, regexp.MustCompile(`api\.telegram\.org/bot`)},
+	{phishObfuscated, regexp.MustCompile(`(eval|document\.write)\s*\(\s*(unescape|atob|decodeuricomponent)\s*\(`)},
+	{phishUrgency, regexp.MustCompile(`verify your (account|identity)|account (has been |was )?(suspended|locked|limited|disabled)|unusual (sign-in )?activity|confirm your (identity|account|payment)|update your (payment|billing)`)},
+}
+
+// formActionPattern captures the action URLs of a page's forms
+var formActionPattern = regexp.MustCompile(`<form[^>]*\saction\s*=\s*["']?([^"'\s>]+)`)
+
+// phishingIndicators returns the phishing indicators found in a page
+// fetched from pageURL, nil for none
+func phishingIndicators(body []byte, pageURL *url.URL) []string {
+	page := strings.ToLower(string(body))
+	var indicators []string
+	for _, marker := range phishingMarkers {
+		if marker.pattern.MatchString(page) {
+			indicators = append(indicators, marker.indicator)
+		}
+	}
+	if externalFormAction(page, pageURL) {
+		indicators = append(indicators, phishExternalForm)
+	}
+	return indicators
+}
+
+// externalFormAction reports whether a form of the page posts to another
+// site or to an email address
+func externalFormAction(page string, pageURL *url.URL) bool {
+	if pageURL == nil {
+		return false
+	}
+	host := strings.ToLower(pageURL.Hostname())
+	for _, m := range formActionPattern.FindAllStringSubmatch(page, -1) {
+		action, err := pageURL.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		if action.Scheme == "mailto" {
+			return true
+		}
+		if action.Scheme != "http" && action.Scheme != "https" {
+			continue
+		}
+		other := action.Hostname()
+		if other != host && !strings.HasSuffix(other, "."+host) && !strings.HasSuffix(host, "."+other) {
+			return true
+		}
+	}
+	return false
+}
+
+// brandKeywords are the keywords looked for on lookalike pages: the target's
+// name and the -keywords list
+func brandKeywords(config Config) []string {
+	keywords := []string{extractBaseDomain(config.Domain)}
+	for _, keyword := range strings.Split(config.Keywords, ",") {
+		if keyword = strings.TrimSpace(keyword); keyword != "" && !containsString(keywords, keyword) {
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
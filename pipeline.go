@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// The scan runs as a pipeline of stages connected by bounded queues, each
+// with its own worker pool, so DNS prechecks can run far hotter than the
+// rate-limited WHOIS lookups:
+//
+//	generate -> dns-precheck -> whois -> enrich -> collect (score, output)
+//
+// A full queue blocks the stage feeding it, which bounds memory however
+// many candidates are scanned.
+
+// Reasons a domain leaves the pipeline without a result
+const (
+	dropBudget   = "budget"   // the query budget ran out
+	dropRuntime  = "runtime"  // -max-runtime was reached
+	dropBreaker  = "breaker"  // the error breaker tripped
+	dropNXDomain = "nxdomain" // the DNS precheck found no such domain
+)
+
+// scanItem is a domain travelling through the scan stages. Index is its
+// position in the candidate stream; restored domains come with the result
+// of a previous run from the checkpoint.
+type scanItem struct {
+	domain   string
+	index    int
+	info     *DomainInfo
+	matched  bool
+	dropped  string
+	restored bool
+}
+
+// runStage runs fn on every item read from in with the given number of
+// workers and passes the items on over a queue of the given size, closed
+// once in is drained. Dropped and restored items pass through untouched. A
+// stage always has at least one worker.
+func runStage(in <-chan *scanItem, workers, queue int, fn func(*scanItem)) <-chan *scanItem {
+	out := make(chan *scanItem, queue)
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				if item.dropped == "" && !item.restored {
+					fn(item)
+				}
+				out <- item
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// generateStage queues the candidates to scan. Domains under a registry
+// whose query budget is already spent are dropped without a lookup, and
+// the domains of chunks completed by a previous run are restored from the
+// checkpoint instead of being scanned again.
+func generateStage(candidates candidateSeq, budget *queryBudget, cp *checkpoint, queue int) <-chan *scanItem {
+	out := make(chan *scanItem, queue)
+	go func() {
+		defer close(out)
+		index := 0
+		candidates.each(func(domain string) bool {
+			item := &scanItem{domain: domain, index: index}
+			index++
+			if line, complete := cp.resumed(item.index); complete {
+				item.restored = true
+				if line == nil {
+					item.dropped = dropNXDomain
+				} else {
+					item.info, item.matched = line.Info, line.Matched
+				}
+			} else {
+				if err := cp.generated(item.index); err != nil {
+					fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to write checkpoint: %v\n", ColorYellow, ColorReset, err)
+				}
+				if budget.exhausted(lastLabel(domain)) {
+					item.dropped = dropBudget
+				}
+			}
+			out <- item
+			return true
+		})
+		if err := cp.done(index); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to write checkpoint: %v\n", ColorYellow, ColorReset, err)
+		}
+	}()
+	return out
+}
+
+// existsInDNS reports whether a domain may be registered. Only NXDOMAIN
+// counts as absent; timeouts and server failures keep the domain.
+func existsInDNS(domain string, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := net.DefaultResolver.LookupNS(ctx, domain)
+	var dnsErr *net.DNSError
+	return err == nil || !errors.As(err, &dnsErr) || !dnsErr.IsNotFound
+}
+
+// stageThreads returns the worker count of a stage, following -t when the
+// stage's own flag is unset
+func (c Config) stageThreads(threads int) int {
+	if threads > 0 {
+		return threads
+	}
+	return c.Threads
+}
+
+// validatePipeline checks the stage concurrency options
+func validatePipeline(config Config) error {
+	if config.Threads > maxThreads {
+		return fmt.Errorf("-t %d is above %d; registries ban sources opening that many connections, lower it or use -auto-tune", config.Threads, maxThreads)
+	}
+	if config.DNSThreads < 1 {
+		return fmt.Errorf("-dns-threads must be at least 1")
+	}
+	if config.DNSThreads > maxDNSThreads {
+		return fmt.Errorf("-dns-threads %d is above %d", config.DNSThreads, maxDNSThreads)
+	}
+	if config.EnrichThreads > maxThreads || config.ScoreThreads > maxThreads {
+		return fmt.Errorf("-enrich-threads and -score-threads cannot be above %d", maxThreads)
+	}
+	if config.EnrichThreads < 0 || config.ScoreThreads < 0 {
+		return fmt.Errorf("-enrich-threads and -score-threads cannot be negative")
+	}
+	if config.QueueSize < 1 {
+		return fmt.Errorf("-queue-size must be at least 1")
+	}
+	return nil
+}
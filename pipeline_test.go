@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunStage(t *testing.T) {
+	budget := newQueryBudget(0, 1)
+	budget.take("net")
+	items := generateStage(sliceCandidates([]string{"example.com", "example.net", "example.org"}), budget, nil, 1)
+
+	var running, peak int32
+	items = runStage(items, 2, 1, func(item *scanItem) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		item.matched = true
+	})
+
+	var processed, dropped []string
+	for item := range items {
+		if item.dropped != "" {
+			if item.matched {
+				t.Errorf("Expected dropped %s to skip the stage", item.domain)
+			}
+			dropped = append(dropped, item.domain)
+			continue
+		}
+		processed = append(processed, item.domain)
+	}
+	sort.Strings(processed)
+	if len(processed) != 2 || processed[0] != "example.com" || processed[1] != "example.org" {
+		t.Errorf("Unexpected processed domains %v", processed)
+	}
+	if len(dropped) != 1 || dropped[0] != "example.net" {
+		t.Errorf("Expected example.net to be dropped by the spent budget, got %v", dropped)
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent workers, saw %d", peak)
+	}
+}
+
+func TestStageThreads(t *testing.T) {
+	config := Config{Threads: 10}
+	if got := config.stageThreads(0); got != 10 {
+		t.Errorf("Expected an unset stage to follow -t, got %d", got)
+	}
+	if got := config.stageThreads(3); got != 3 {
+		t.Errorf("stageThreads(3) = %d", got)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// PortfolioBrand is one row of a -portfolio CSV: a brand's domain, other
+// spellings of its organization, free-form tags, e.g. the customer, and the
+// metadata of named columns after the tags
+type PortfolioBrand struct {
+	Domain   string
+	Aliases  []string
+	Tags     []string
+	Metadata Metadata
+}
+
+// PortfolioResult is the combined result of a -portfolio run. Each entry of
+// Brands is a complete Result.
+type PortfolioResult struct {
+	SchemaVersion string             `json:"schema_version"`
+	Scanner       *BuildInfo         `json:"scanner,omitempty"`
+	Brands        []Result           `json:"brands"`
+	FailedBrands  []PortfolioFailure `json:"failed_brands,omitempty"`
+	ScanDuration  string             `json:"scan_duration"`
+	TotalBrands   int                `json:"total_brands"`
+	TotalMatches  int                `json:"total_matches"`
+	TotalErrors   int                `json:"total_errors"`
+}
+
+// PortfolioFailure records a brand whose scan could not run, typically
+// because its target domain has no usable WHOIS record
+type PortfolioFailure struct {
+	Domain   string   `json:"domain"`
+	Tags     []string `json:"tags,omitempty"`
+	Metadata Metadata `json:"metadata,omitempty"`
+	Error    string   `json:"error"`
+}
+
+// portfolioFormats are the output formats a portfolio report supports
+var portfolioFormats = []string{"text", "json"}
+
+// splitList splits a ";"-separated CSV cell, dropping empty entries
+func splitList(cell string) []string {
+	var items []string
+	for _, item := range strings.Split(cell, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadPortfolio reads a portfolio CSV with the columns domain, org aliases
+// and tags; aliases and tags are ";"-separated and optional. A header row
+// starting with "domain" and lines starting with # are skipped. With a
+// header row, the columns after tags are metadata named by the header.
+func loadPortfolio(path string) ([]PortfolioBrand, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read portfolio: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var brands []PortfolioBrand
+	var header []string
+	seen := make(map[string]bool)
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse portfolio %s: %w", path, err)
+		}
+		domain := strings.ToLower(strings.TrimSpace(record[0]))
+		if row == 1 && domain == "domain" {
+			header = record
+			for i := range header {
+				header[i] = strings.TrimSpace(header[i])
+			}
+			continue
+		}
+		if domain == "" && len(record) == 1 {
+			continue
+		}
+		if !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("portfolio %s row %d: %q is not a domain", path, row, record[0])
+		}
+		if seen[domain] {
+			return nil, fmt.Errorf("portfolio %s row %d: %s is listed twice", path, row, domain)
+		}
+		seen[domain] = true
+
+		brand := PortfolioBrand{Domain: domain}
+		if len(record) > 1 {
+			brand.Aliases = splitList(record[1])
+		}
+		if len(record) > 2 {
+			brand.Tags = splitList(record[2])
+		}
+		if len(record) > 3 && len(header) > 3 {
+			brand.Metadata = metadataColumns(header[3:], record[3:], -1)
+		}
+		brands = append(brands, brand)
+	}
+	if len(brands) == 0 {
+		return nil, fmt.Errorf("portfolio %s lists no brands", path)
+	}
+	return brands, nil
+}
+
+// validatePortfolio checks the options -portfolio can be combined with
+func validatePortfolio(config Config) error {
+	if config.Portfolio == "" {
+		return nil
+	}
+	if config.Domain != "" {
+		return fmt.Errorf("-portfolio cannot be combined with -d; list the domain in the portfolio")
+	}
+	if config.Monitor {
+		return fmt.Errorf("-portfolio does not support -monitor")
+	}
+	if !containsString(portfolioFormats, config.Format) {
+		return fmt.Errorf("-portfolio supports the formats %s", strings.Join(portfolioFormats, " and "))
+	}
+	return nil
+}
+
+// runPortfolio scans every brand of the -portfolio CSV in turn with the
+// other options, then writes one combined report. A brand that fails to
+// scan is recorded and the run goes on with the next.
+func runPortfolio(config Config) int {
+	brands, err := loadPortfolio(config.Portfolio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	startTime := time.Now()
+	portfolio := PortfolioResult{SchemaVersion: SchemaVersion, Scanner: currentBuildInfo(), TotalBrands: len(brands)}
+	code := ExitNoMatches
+	for i, brand := range brands {
+		fmt.Printf("%s[INFO]%s Brand %d/%d: %s\n", ColorBlue, ColorReset, i+1, len(brands), brand.Domain)
+		brandConfig := config
+		brandConfig.Domain = brand.Domain
+		brandConfig.OrgNormalizer = config.OrgNormalizer.withAliases(brand.Aliases)
+
+		// Brands are written to -out-dir only; -o and -oA get the
+		// combined report
+		brandStart := time.Now()
+		brandConfig.Output, brandConfig.OutputAll = "", ""
+		brandConfig, err := withOutputRun(brandConfig, brand.Domain, brandStart)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		result, allResults, err := scan(brandConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %s: %v\n", ColorRed, ColorReset, brand.Domain, err)
+			portfolio.FailedBrands = append(portfolio.FailedBrands, PortfolioFailure{Domain: brand.Domain, Tags: brand.Tags, Metadata: brand.Metadata, Error: err.Error()})
+			continue
+		}
+		result.Tags = brand.Tags
+		result.Metadata = brand.Metadata
+		if config.History {
+			recordHistory(result, allResults, brandStart, brandConfig)
+		}
+		if brandConfig.OutputDir != "" {
+			writeOutput(result, brandConfig)
+			linkLatestRun(brandConfig)
+		}
+		exportTelemetry(result, time.Time{})
+		sendNotifications(brandConfig, Notification{Result: result})
+		config.Syslog.summary(result)
+
+		portfolio.Brands = append(portfolio.Brands, result)
+		portfolio.TotalMatches += result.TotalMatches
+		portfolio.TotalErrors += result.TotalErrors
+		switch brandCode := exitCode(result, config.ErrorThreshold); {
+		case brandCode == ExitHighErrors:
+			code = ExitHighErrors
+		case brandCode == ExitMatches && code == ExitNoMatches:
+			code = ExitMatches
+		}
+	}
+	portfolio.ScanDuration = time.Since(startTime).Round(time.Millisecond).String()
+
+	writePortfolioOutput(portfolio, config)
+	fmt.Printf("\n%s[INFO]%s Portfolio complete: %d brands, %d matches, %d failed, %s\n", ColorBlue, ColorReset,
+		len(brands), portfolio.TotalMatches, len(portfolio.FailedBrands), portfolio.ScanDuration)
+
+	if len(portfolio.Brands) == 0 {
+		return ExitUsage
+	}
+	return code
+}
+
+// writePortfolioOutput writes the combined report in the configured format,
+// and as JSON and text when -oA was given. Only the domains passing -filter
+// are written, in the -sort order, encrypted with -encrypt and signed with
+// -sign.
+func writePortfolioOutput(portfolio PortfolioResult, config Config) {
+	recipients := config.Recipients
+	defer signOutputs(config, outputFiles(config, ".json", ".txt"))
+	order, _ := parseSortOrder(config.Sort)
+	if config.ResultFilter != nil || order != nil {
+		brands := make([]Result, len(portfolio.Brands))
+		portfolio.TotalMatches = 0
+		for i, result := range portfolio.Brands {
+			brands[i] = order.apply(config.ResultFilter.apply(result))
+			portfolio.TotalMatches += brands[i].TotalMatches
+		}
+		portfolio.Brands = brands
+	}
+
+	if config.OutputAll != "" {
+		outputPortfolioJSON(portfolio, outputName(config.OutputAll+".json", config), recipients)
+		outputPortfolioText(portfolio, outputName(config.OutputAll+".txt", config), config.Verbose, recipients)
+		if config.Output == "" {
+			return
+		}
+	}
+
+	output := outputName(config.Output, config)
+	if config.Format == "json" {
+		outputPortfolioJSON(portfolio, output, recipients)
+	} else {
+		outputPortfolioText(portfolio, output, config.Verbose, recipients)
+	}
+}
+
+func outputPortfolioJSON(portfolio PortfolioResult, outputFile string, recipients []age.Recipient) {
+	data, err := json.MarshalIndent(portfolio, "", "  ")
+	if err != nil {
+		log.Printf("Error marshaling JSON: %v", err)
+		return
+	}
+	saveOutput(append(data, '\n'), outputFile, recipients)
+}
+
+func outputPortfolioText(portfolio PortfolioResult, outputFile string, verbose bool, recipients []age.Recipient) {
+	text := renderPortfolioText(portfolio, verbose)
+	if outputFile != "" {
+		text = stripANSI(text)
+	}
+	saveOutput([]byte(text), outputFile, recipients)
+}
+
+// renderPortfolioText renders an overview of every brand followed by one
+// section per brand in the regular text report layout
+func renderPortfolioText(portfolio PortfolioResult, verbose bool) string {
+	var output strings.Builder
+
+	output.WriteString(fmt.Sprintf("\n%s=== PORTFOLIO RESULTS ===%s\n", ColorCyan, ColorReset))
+	output.WriteString(fmt.Sprintf("Brands: %d\n", portfolio.TotalBrands))
+	output.WriteString(fmt.Sprintf("Total Matches: %d\n", portfolio.TotalMatches))
+	output.WriteString(fmt.Sprintf("Total Errors: %d\n", portfolio.TotalErrors))
+	output.WriteString(fmt.Sprintf("Scan Duration: %s\n\n", portfolio.ScanDuration))
+	for _, result := range portfolio.Brands {
+		output.WriteString(fmt.Sprintf("  %-30s %4d matches %4d lookalikes%s\n", result.TargetDomain, result.TotalMatches,
+			result.TotalLookalikes, formatTags(result.Tags)))
+	}
+	for _, failure := range portfolio.FailedBrands {
+		output.WriteString(fmt.Sprintf("  %-30s %sfailed: %s%s%s\n", failure.Domain, ColorRed, failure.Error, ColorReset, formatTags(failure.Tags)))
+	}
+
+	for i, result := range portfolio.Brands {
+		output.WriteString(fmt.Sprintf("\n%s=== BRAND %d/%d: %s%s ===%s", ColorPurple, i+1, len(portfolio.Brands),
+			result.TargetDomain, formatTags(result.Tags), ColorReset))
+		output.WriteString(renderText(result, verbose))
+	}
+	return output.String()
+}
+
+// formatTags renders tags as a bracketed suffix, empty without tags
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(tags, ", ") + "]"
+}
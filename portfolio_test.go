@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writePortfolio(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "brands.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPortfolio(t *testing.T) {
+	path := writePortfolio(t, `domain,aliases,tags
+# customer A
+Example.com, Example Inc;Example Holdings ,customer-a;finance
+acme.com
+
+acme.org,,customer-b
+`)
+	brands, err := loadPortfolio(path)
+	if err != nil {
+		t.Fatalf("loadPortfolio failed: %v", err)
+	}
+	want := []PortfolioBrand{
+		{Domain: "example.com", Aliases: []string{"Example Inc", "Example Holdings"}, Tags: []string{"customer-a", "finance"}},
+		{Domain: "acme.com"},
+		{Domain: "acme.org", Tags: []string{"customer-b"}},
+	}
+	if !reflect.DeepEqual(brands, want) {
+		t.Errorf("Expected %+v, got %+v", want, brands)
+	}
+}
+
+func TestLoadPortfolioErrors(t *testing.T) {
+	tests := map[string]string{
+		"not a domain": "example\n",
+		"duplicate":    "example.com\nEXAMPLE.COM,Example Inc\n",
+		"empty":        "domain,aliases,tags\n",
+	}
+	for name, content := range tests {
+		if _, err := loadPortfolio(writePortfolio(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestValidatePortfolio(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{Format: "csv"}, false},
+		{Config{Portfolio: "brands.csv", Format: "text"}, false},
+		{Config{Portfolio: "brands.csv", Format: "json"}, false},
+		{Config{Portfolio: "brands.csv", Format: "csv"}, true},
+		{Config{Portfolio: "brands.csv", Format: "text", Domain: "example.com"}, true},
+		{Config{Portfolio: "brands.csv", Format: "text", Monitor: true}, true},
+	}
+	for _, tt := range tests {
+		if err := validatePortfolio(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("validatePortfolio(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRenderPortfolioText(t *testing.T) {
+	portfolio := PortfolioResult{
+		Brands: []Result{
+			{TargetDomain: "example.com", TargetOrg: "Example Inc", Tags: []string{"customer-a"}, TotalMatches: 2,
+				MatchingDomains: []DomainInfo{{Domain: "example.net"}, {Domain: "example.org"}}},
+			{TargetDomain: "acme.com", TargetOrg: "Acme Corp"},
+		},
+		FailedBrands: []PortfolioFailure{{Domain: "broken.example", Error: "no organization found"}},
+		TotalBrands:  3,
+		TotalMatches: 2,
+	}
+	text := stripANSI(renderPortfolioText(portfolio, false))
+	for _, want := range []string{
+		"Brands: 3",
+		"failed: no organization found",
+		"=== BRAND 1/2: example.com [customer-a] ===",
+		"=== BRAND 2/2: acme.com ===",
+		"[+] example.org",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the report to contain %q:\n%s", want, text)
+		}
+	}
+}
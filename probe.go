@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/tls"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxProbeBody bounds how much of a probed page is read
+const maxProbeBody = 1 << 20
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// probeTransport is shared by all probes. Each lookalike is fetched once, so
+// connections are not kept alive for reuse and no sockets pile up.
+var probeTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	// Lookalikes often serve self-signed or mismatched certificates; the
+	// page is only inspected, never trusted
+	TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+	DisableKeepAlives: true,
+	IdleConnTimeout:   30 * time.Second,
+}
+
+// HTTPProbe is the outcome of fetching a lookalike domain's web page
+type HTTPProbe struct {
+	URL         string   `json:"url"`
+	StatusCode  int      `json:"status_code,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	KeywordHits []string `json:"keyword_hits,omitempty"`
+	Favicon     string   `json:"favicon,omitempty"`
+	FaviconHash int32    `json:"favicon_hash,omitempty"`
+	Error       string   `json:"error,omitempty"`
+
+	// shingles fingerprint the page text for compareContent
+	shingles shingleSet
+	// parkingMarker is the for-sale wording found on the page, if any
+	parkingMarker string
+	// phishing are the login and credential-harvesting markers on the page
+	phishing []string
+	// language is the language of the page, if it could be told
+	language string
+}
+
+// Live reports whether the domain served an HTTP response
+func (p *HTTPProbe) Live() bool {
+	return p != nil && p.StatusCode > 0
+}
+
+// probeHTTP fetches the domain over HTTPS, then HTTP, following redirects,
+// and records the page title, favicon URL and which brand keywords the page
+// mentions
+func probeHTTP(domain string, keywords []string, timeout time.Duration) *HTTPProbe {
+	client := &http.Client{Timeout: timeout, Transport: probeTransport}
+
+	probe := probeURL(client, "https://"+domain, keywords)
+	if !probe.Live() {
+		if plain := probeURL(client, "http://"+domain, keywords); plain.Live() {
+			return plain
+		}
+	}
+	return probe
+}
+
+func probeURL(client *http.Client, url string, keywords []string) *HTTPProbe {
+	probe := &HTTPProbe{URL: url}
+	resp, err := client.Get(url)
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	defer resp.Body.Close()
+
+	probe.URL = resp.Request.URL.String()
+	probe.StatusCode = resp.StatusCode
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+	if m := titlePattern.FindSubmatch(body); m != nil {
+		probe.Title = truncate(strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " "), 200)
+	}
+	probe.Favicon = faviconURL(resp.Request.URL, body)
+	probe.shingles = pageShingles(body)
+	if m := parkingPagePattern.Find(body); m != nil {
+		probe.parkingMarker = strings.ToLower(string(m))
+	}
+	probe.phishing = phishingIndicators(body, resp.Request.URL)
+	probe.language = pageLanguage(resp.Header, body)
+
+	page := strings.ToLower(string(body))
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(page, strings.ToLower(keyword)) {
+			probe.KeywordHits = append(probe.KeywordHits, keyword)
+		}
+	}
+	return probe
+}
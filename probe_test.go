@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProbeURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		w.Write([]byte("<html><head><title>\n  Acme &amp; Co Sign In\n</title></head><body>Welcome to ACME Bank</body></html>"))
+	}))
+	defer server.Close()
+
+	probe := probeURL(&http.Client{Timeout: 5 * time.Second}, server.URL, []string{"acme", "widgets"})
+	if !probe.Live() || probe.StatusCode != http.StatusOK {
+		t.Fatalf("Expected a live probe, got %+v", probe)
+	}
+	if probe.URL != server.URL+"/login" {
+		t.Errorf("Expected the final URL after redirects, got %s", probe.URL)
+	}
+	if probe.Title != "Acme & Co Sign In" {
+		t.Errorf("Unexpected title %q", probe.Title)
+	}
+	if !reflect.DeepEqual(probe.KeywordHits, []string{"acme"}) {
+		t.Errorf("Unexpected keyword hits %v", probe.KeywordHits)
+	}
+}
+
+func TestProbeURLUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	probe := probeURL(&http.Client{Timeout: time.Second}, server.URL, nil)
+	if probe.Live() || probe.Error == "" {
+		t.Errorf("Expected a failed probe, got %+v", probe)
+	}
+}
+
+func TestProbeClosesConnections(t *testing.T) {
+	closed := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		closed <- r.Close
+	}))
+	defer server.Close()
+
+	probe := probeURL(&http.Client{Timeout: 5 * time.Second, Transport: probeTransport}, server.URL, nil)
+	if !probe.Live() {
+		t.Fatalf("Expected a live probe, got %+v", probe)
+	}
+	if !<-closed {
+		t.Error("Expected the probe connection not to be kept alive")
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// profileNamePattern matches the names profiles are saved under
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// profileSecretFlags are the options whose values are credentials. They are
+// saved like any other option, with a warning.
+var profileSecretFlags = []string{"teams-webhook", "telegram-token"}
+
+// ScanProfile is a named set of scan options, saved by `profile save` and
+// re-run by `scan -profile`
+type ScanProfile struct {
+	Name  string    `yaml:"name"`
+	Saved time.Time `yaml:"saved"`
+	// Flags are the option values by flag name, without the dash
+	Flags map[string]string `yaml:"flags"`
+}
+
+// defaultProfileDir returns the per-user directory of saved profiles
+func defaultProfileDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "profiles"
+	}
+	return filepath.Join(dir, "tldscanner", "profiles")
+}
+
+// profileName returns the name of a profile given by name or path
+func profileName(nameOrPath string) string {
+	name := filepath.Base(nameOrPath)
+	for _, ext := range []string{".yaml", ".yml"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// profilePath resolves a profile name to its file in dir. A path to a YAML
+// file, such as a profile shared in a team repository, is used as is.
+func profilePath(dir, name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) || strings.Contains(name, "/") ||
+		strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		return name, nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, dots, dashes and underscores", name)
+	}
+	return filepath.Join(dir, name+".yaml"), nil
+}
+
+// newScanProfile captures the options set on the command line of fs
+func newScanProfile(name string, fs *flag.FlagSet, now time.Time) *ScanProfile {
+	profile := &ScanProfile{Name: name, Saved: now.UTC(), Flags: make(map[string]string)}
+	fs.Visit(func(f *flag.Flag) {
+		profile.Flags[f.Name] = f.Value.String()
+	})
+	return profile
+}
+
+// secrets returns the credential options the profile holds
+func (p *ScanProfile) secrets() []string {
+	var secrets []string
+	for _, name := range profileSecretFlags {
+		if p.Flags[name] != "" {
+			secrets = append(secrets, "-"+name)
+		}
+	}
+	return secrets
+}
+
+// apply sets the profile's options on fs, before the command line is
+// parsed so options given there override them
+func (p *ScanProfile) apply(fs *flag.FlagSet) error {
+	for _, name := range p.flagNames() {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("profile %s: unknown option -%s", p.Name, name)
+		}
+		if err := fs.Set(name, p.Flags[name]); err != nil {
+			return fmt.Errorf("profile %s: -%s: %v", p.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// flagNames returns the names of the profile's options, sorted
+func (p *ScanProfile) flagNames() []string {
+	names := make([]string, 0, len(p.Flags))
+	for name := range p.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commandLine renders the profile as the equivalent scan command line
+func (p *ScanProfile) commandLine() string {
+	args := []string{"tldscanner"}
+	for _, name := range p.flagNames() {
+		value := p.Flags[name]
+		if value == "true" {
+			args = append(args, "-"+name)
+			continue
+		}
+		if value == "" || strings.ContainsAny(value, " \t\"'$*?|&;<>()") {
+			value = "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+		}
+		args = append(args, "-"+name+"="+value)
+	}
+	return strings.Join(args, " ")
+}
+
+// loadScanProfile reads a saved profile
+func loadScanProfile(path string) (*ScanProfile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no profile %s (list them with `tldscanner profile list`)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	profile := &ScanProfile{}
+	if err := yaml.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	if profile.Name == "" {
+		profile.Name = profileName(path)
+	}
+	return profile, nil
+}
+
+// saveScanProfile writes a profile, replacing any saved under its name
+func saveScanProfile(path string, profile *ScanProfile) error {
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// listScanProfiles returns the profiles saved in dir, by name
+func listScanProfiles(dir string) ([]*ScanProfile, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var profiles []*ScanProfile
+	for _, path := range paths {
+		profile, err := loadScanProfile(path)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+// runProfile implements `tldscanner profile save|list|show|delete`
+func runProfile(args []string) int {
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	dir := fs.String("dir", defaultProfileDir(), "Directory of saved profiles")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s profile [-dir <dir>] save <name> [SCAN OPTIONS]\n", os.Args[0])
+		fmt.Printf("       %s profile [-dir <dir>] show|delete <name>\n", os.Args[0])
+		fmt.Printf("       %s profile [-dir <dir>] list\n\n", os.Args[0])
+		fmt.Printf("`save` records the scan options that follow the name (target, wordlist,\n")
+		fmt.Printf("filters, output sinks, notification channels) under the name; run them\n")
+		fmt.Printf("again with `%s scan -profile <name>`. A name may also be the path to a\n", os.Args[0])
+		fmt.Printf("profile YAML file, e.g. one shared in a team repository.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	action := fs.Arg(0)
+	if action == "list" {
+		profiles, err := listScanProfiles(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		for _, profile := range profiles {
+			fmt.Printf("%-20s %-24s saved %s\n", profile.Name, profile.Flags["d"], profile.Saved.Format("2006-01-02"))
+		}
+		return ExitMatches
+	}
+	if fs.NArg() < 2 || (action != "save" && action != "show" && action != "delete") || (action != "save" && fs.NArg() > 2) {
+		fs.Usage()
+		return ExitUsage
+	}
+	name := fs.Arg(1)
+	path, err := profilePath(*dir, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	switch action {
+	case "save":
+		var config Config
+		scanFlags := flag.NewFlagSet("profile save", flag.ContinueOnError)
+		registerFlags(scanFlags, &config)
+		if err := scanFlags.Parse(fs.Args()[2:]); err != nil {
+			return ExitUsage
+		}
+		if scanFlags.NArg() > 0 {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s unexpected argument %q after the scan options\n", ColorRed, ColorReset, scanFlags.Arg(0))
+			return ExitUsage
+		}
+		profile := newScanProfile(profileName(name), scanFlags, time.Now())
+		if len(profile.Flags) == 0 {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s no scan options to save, e.g. profile save %s -d example.com -risk\n", ColorRed, ColorReset, name)
+			return ExitUsage
+		}
+		if err := saveScanProfile(path, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		if secrets := profile.secrets(); len(secrets) > 0 {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s holds the secret of %s; share it only with those allowed to use them\n", ColorYellow, ColorReset, path, strings.Join(secrets, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Saved profile %s to %s\n", ColorBlue, ColorReset, profile.Name, path)
+	case "show":
+		profile, err := loadScanProfile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		fmt.Println(profile.commandLine())
+	case "delete":
+		if err := os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("no profile %s", path)
+			}
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Deleted profile %s\n", ColorBlue, ColorReset, name)
+	}
+	return ExitMatches
+}
+
+// runScan implements `tldscanner scan [-profile <name>] [OPTIONS]`: the
+// saved options of the profile are applied first, so options given on the
+// command line override them. Repeatable options such as -d add to the
+// profile's.
+func runScan(args []string) int {
+	var probe Config
+	probeFlags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	registerFlags(probeFlags, &probe)
+	profileName := probeFlags.String("profile", "", "Name or path of a saved profile to run")
+	profileDir := probeFlags.String("profile-dir", defaultProfileDir(), "Directory of saved profiles")
+	probeFlags.Usage = func() {
+		fmt.Printf("Usage: %s scan [-profile <name>] [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Runs a scan from the options saved with `%s profile save`. Options given\n", os.Args[0])
+		fmt.Printf("on the command line override the profile's.\n\nOptions:\n")
+		probeFlags.PrintDefaults()
+	}
+	if err := probeFlags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+
+	var config Config
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	fs.String("profile", "", "")
+	fs.String("profile-dir", "", "")
+	if *profileName != "" {
+		path, err := profilePath(*profileDir, *profileName)
+		if err == nil {
+			var profile *ScanProfile
+			if profile, err = loadScanProfile(path); err == nil {
+				err = profile.apply(fs)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	applyImpliedFlags(&config)
+	return runScanConfig(config)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanProfileRoundTrip(t *testing.T) {
+	var config Config
+	fs := flag.NewFlagSet("profile save", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	args := []string{"-d", "example.com", "-d", "example.de", "-risk", "-rdap-fallback=false",
+		"-filter", `registrar contains "GoDaddy"`, "-telegram-chat", "@brandwatch", "-telegram-token", "123:abc"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	profile := newScanProfile("brandwatch", fs, time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC))
+	expected := map[string]string{
+		"d":              "example.com,example.de",
+		"risk":           "true",
+		"rdap-fallback":  "false",
+		"filter":         `registrar contains "GoDaddy"`,
+		"telegram-chat":  "@brandwatch",
+		"telegram-token": "123:abc",
+	}
+	if !reflect.DeepEqual(profile.Flags, expected) {
+		t.Errorf("newScanProfile() flags = %v, expected %v", profile.Flags, expected)
+	}
+	if secrets := profile.secrets(); !reflect.DeepEqual(secrets, []string{"-telegram-token"}) {
+		t.Errorf("secrets() = %v", secrets)
+	}
+
+	path := filepath.Join(t.TempDir(), "brandwatch.yaml")
+	if err := saveScanProfile(path, profile); err != nil {
+		t.Fatalf("saveScanProfile failed: %v", err)
+	}
+	loaded, err := loadScanProfile(path)
+	if err != nil {
+		t.Fatalf("loadScanProfile failed: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, profile) {
+		t.Errorf("Loaded profile %+v, expected %+v", loaded, profile)
+	}
+
+	// Options on the command line override the profile's
+	var rerun Config
+	rerunFlags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	registerFlags(rerunFlags, &rerun)
+	if err := loaded.apply(rerunFlags); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if err := rerunFlags.Parse([]string{"-telegram-chat", "@oncall"}); err != nil {
+		t.Fatal(err)
+	}
+	if rerun.Domain != "example.com" || !reflect.DeepEqual(rerun.Targets, []string{"example.de"}) ||
+		!rerun.Risk || rerun.RDAPFallback || rerun.Filter != `registrar contains "GoDaddy"` || rerun.TelegramChat != "@oncall" {
+		t.Errorf("Unexpected config from the profile: %+v", rerun)
+	}
+
+	loaded.Flags["no-such-option"] = "1"
+	if err := loaded.apply(flag.NewFlagSet("scan", flag.ContinueOnError)); err == nil {
+		t.Error("apply should reject unknown options")
+	}
+}
+
+func TestScanProfileCommandLine(t *testing.T) {
+	profile := &ScanProfile{Name: "brandwatch", Flags: map[string]string{
+		"d":      "example.com",
+		"risk":   "true",
+		"filter": `tld == "ru"`,
+		"all":    "false",
+	}}
+	expected := `tldscanner -all=false -d=example.com -filter='tld == "ru"' -risk`
+	if got := profile.commandLine(); got != expected {
+		t.Errorf("commandLine() = %s, expected %s", got, expected)
+	}
+}
+
+func TestProfilePath(t *testing.T) {
+	dir := filepath.Join("home", "profiles")
+	tests := []struct {
+		name     string
+		expected string
+		valid    bool
+	}{
+		{"brandwatch", filepath.Join(dir, "brandwatch.yaml"), true},
+		{"team/brandwatch.yaml", "team/brandwatch.yaml", true},
+		{"shared.yml", "shared.yml", true},
+		{"-brandwatch", "", false},
+		{"brand watch", "", false},
+	}
+	for _, test := range tests {
+		path, err := profilePath(dir, test.name)
+		if (err == nil) != test.valid || path != test.expected {
+			t.Errorf("profilePath(%q) = %q, %v", test.name, path, err)
+		}
+	}
+	if name := profileName("team/brandwatch.yaml"); name != "brandwatch" {
+		t.Errorf("profileName() = %q", name)
+	}
+}
+
+func TestListScanProfiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"weekly", "brandwatch"} {
+		profile := &ScanProfile{Name: name, Flags: map[string]string{"d": name + ".example"}}
+		if err := saveScanProfile(filepath.Join(dir, name+".yaml"), profile); err != nil {
+			t.Fatal(err)
+		}
+	}
+	profiles, err := listScanProfiles(dir)
+	if err != nil {
+		t.Fatalf("listScanProfiles failed: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "brandwatch" || profiles[1].Flags["d"] != "weekly.example" {
+		t.Errorf("Unexpected profiles: %+v", profiles)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+// progressJSONInterval is the least time between two -progress-json events
+const progressJSONInterval = time.Second
+
+// progressWriter receives the -progress-json events; a variable so tests can
+// capture them
+var progressWriter io.Writer = os.Stderr
+
+// ProgressEvent is one -progress-json line
+type ProgressEvent struct {
+	Done    int   `json:"done"`
+	Total   int   `json:"total"`
+	Matches int   `json:"matches"`
+	Errors  int   `json:"errors"`
+	ETA     int64 `json:"eta_s"`
+}
+
+// progressReporter writes the scan progress as JSON lines, at most one per
+// progressJSONInterval plus a final one. A nil reporter writes nothing.
+// Callers serialize calls.
+type progressReporter struct {
+	total int
+	start time.Time
+	last  time.Time
+}
+
+// newProgressReporter returns a reporter when -progress-json is set
+func newProgressReporter(enabled bool, total int, start time.Time) *progressReporter {
+	if !enabled {
+		return nil
+	}
+	return &progressReporter{total: total, start: start}
+}
+
+// update reports the progress when the interval has passed since the last
+// event
+func (p *progressReporter) update(done, matches, errors int) {
+	if p == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(p.last) < progressJSONInterval {
+		return
+	}
+	p.last = now
+	p.write(done, matches, errors)
+}
+
+// finish reports the final progress
+func (p *progressReporter) finish(done, matches, errors int) {
+	if p == nil {
+		return
+	}
+	p.write(done, matches, errors)
+}
+
+func (p *progressReporter) write(done, matches, errors int) {
+	data, err := json.Marshal(ProgressEvent{
+		Done:    done,
+		Total:   p.total,
+		Matches: matches,
+		Errors:  errors,
+		ETA:     int64(progressETA(done, p.total, time.Since(p.start)) / time.Second),
+	})
+	if err != nil {
+		return
+	}
+	progressWriter.Write(append(data, '\n'))
+}
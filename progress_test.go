@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(w io.Writer) { progressWriter = w }(progressWriter)
+	progressWriter = &buf
+
+	p := newProgressReporter(true, 100, time.Now().Add(-10*time.Second))
+	p.update(20, 1, 2)
+	p.update(21, 1, 2) // within the interval, dropped
+	p.finish(100, 3, 5)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 events, got %d: %q", len(lines), buf.String())
+	}
+	var first, last ProgressEvent
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if first.Done != 20 || first.Total != 100 || first.Matches != 1 || first.Errors != 2 || first.ETA != 40 {
+		t.Errorf("Unexpected first event %+v", first)
+	}
+	if last.Done != 100 || last.Matches != 3 || last.Errors != 5 || last.ETA != 0 {
+		t.Errorf("Unexpected final event %+v", last)
+	}
+	if !strings.Contains(lines[0], `"eta_s":40`) {
+		t.Errorf("Expected the eta_s field, got %s", lines[0])
+	}
+
+	buf.Reset()
+	disabled := newProgressReporter(false, 100, time.Now())
+	disabled.update(1, 0, 0)
+	disabled.finish(1, 0, 0)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no events without -progress-json, got %q", buf.String())
+	}
+}
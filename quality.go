@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// whoisFields are the fields a WHOIS record is expected to hold, by their
+// JSON names
+var whoisFields = []string{"organization", "country", "registrar", "created_date", "expiry_date", "status", "name_servers", "emails"}
+
+// incompleteBelow is the completeness under which a record counts as not
+// read rather than merely sparse
+const incompleteBelow = 50
+
+// TLDQuality summarizes how completely the records of one TLD were parsed
+type TLDQuality struct {
+	TLD          string `json:"tld"`
+	Domains      int    `json:"domains"`
+	Completeness int    `json:"completeness"` // average, in percent
+	// Incomplete counts the records below incompleteBelow percent
+	Incomplete int `json:"incomplete,omitempty"`
+	// Missing counts the records lacking each field
+	Missing map[string]int `json:"missing,omitempty"`
+}
+
+// missingWhoisFields lists the whoisFields a record lacks. A redacted
+// value counts as present: the parser read it.
+func missingWhoisFields(info DomainInfo) []string {
+	present := map[string]bool{
+		"organization": info.Organization != "",
+		"country":      info.Country != "",
+		"registrar":    info.Registrar != "",
+		"created_date": info.CreatedDate != "",
+		"expiry_date":  info.ExpiryDate != "",
+		"status":       info.Status != "",
+		"name_servers": len(info.NameServers) > 0,
+		"emails":       len(info.Emails) > 0,
+	}
+	var missing []string
+	for _, field := range whoisFields {
+		if !present[field] {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// completeness returns the percentage of whoisFields a record holds
+func completeness(missing []string) int {
+	return (len(whoisFields) - len(missing)) * 100 / len(whoisFields)
+}
+
+// scoreCompleteness sets the completeness and missing fields of a domain
+// whose lookup succeeded, so a sparse record is not mistaken for one
+// without a match
+func scoreCompleteness(info *DomainInfo) {
+	if info.Error != "" {
+		return
+	}
+	info.MissingFields = missingWhoisFields(*info)
+	info.Completeness = completeness(info.MissingFields)
+}
+
+// summarizeQuality sets the WHOIS completeness of result by TLD, over the
+// domains whose lookup succeeded, least complete TLDs first
+func summarizeQuality(result *Result, domains []DomainInfo) {
+	byTLD := make(map[string]*TLDQuality)
+	total := make(map[string]int)
+	for _, info := range domains {
+		if info.Error != "" {
+			continue
+		}
+		tld := "." + lastLabel(info.Domain)
+		quality := byTLD[tld]
+		if quality == nil {
+			quality = &TLDQuality{TLD: tld, Missing: make(map[string]int)}
+			byTLD[tld] = quality
+		}
+		missing := missingWhoisFields(info)
+		score := completeness(missing)
+		quality.Domains++
+		total[tld] += score
+		if score < incompleteBelow {
+			quality.Incomplete++
+		}
+		for _, field := range missing {
+			quality.Missing[field]++
+		}
+	}
+
+	result.WhoisQuality = nil
+	for tld, quality := range byTLD {
+		quality.Completeness = total[tld] / quality.Domains
+		result.WhoisQuality = append(result.WhoisQuality, *quality)
+	}
+	sort.Slice(result.WhoisQuality, func(i, j int) bool {
+		a, b := result.WhoisQuality[i], result.WhoisQuality[j]
+		if a.Completeness != b.Completeness {
+			return a.Completeness < b.Completeness
+		}
+		return a.TLD < b.TLD
+	})
+}
+
+// qualityLines renders the TLDs with incomplete records for the summary,
+// at most limit of them
+func qualityLines(qualities []TLDQuality, limit int) []string {
+	var lines []string
+	for _, quality := range qualities {
+		if quality.Incomplete == 0 {
+			continue
+		}
+		if len(lines) == limit {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%-13s %6d  %d%% complete, %d incomplete; missing %s", quality.TLD, quality.Domains,
+			quality.Completeness, quality.Incomplete, formatCounts(quality.Missing, 3)))
+	}
+	return lines
+}
+
+// printQuality prints the TLDs whose records the parser could not read
+func printQuality(qualities []TLDQuality) {
+	lines := qualityLines(qualities, 10)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Printf("WHOIS Completeness:\n  %s\n", strings.Join(lines, "\n  "))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreCompleteness(t *testing.T) {
+	full := DomainInfo{
+		Domain: "example.de", Organization: "REDACTED FOR PRIVACY", Country: "DE", Registrar: "DENIC",
+		CreatedDate: "2001-01-01", ExpiryDate: "2027-01-01", Status: "connect",
+		NameServers: []string{"ns1.example.de"}, Emails: []string{"hostmaster@example.de"},
+	}
+	scoreCompleteness(&full)
+	if full.Completeness != 100 || len(full.MissingFields) != 0 {
+		t.Errorf("Expected a complete record, got %d%% missing %v", full.Completeness, full.MissingFields)
+	}
+
+	sparse := DomainInfo{Domain: "example.xx", Registrar: "XX Registry", NameServers: []string{"ns1.example.xx"}}
+	scoreCompleteness(&sparse)
+	if sparse.Completeness != 25 || strings.Join(sparse.MissingFields, ",") != "organization,country,created_date,expiry_date,status,emails" {
+		t.Errorf("Unexpected completeness %d%% missing %v", sparse.Completeness, sparse.MissingFields)
+	}
+
+	failed := DomainInfo{Domain: "example.yy", Error: "timeout"}
+	scoreCompleteness(&failed)
+	if failed.Completeness != 0 || failed.MissingFields != nil {
+		t.Errorf("Failed lookups should not be scored, got %+v", failed)
+	}
+}
+
+func TestSummarizeQuality(t *testing.T) {
+	domains := []DomainInfo{
+		{Domain: "example.xx", Registrar: "XX Registry", NameServers: []string{"ns1.example.xx"}},
+		{Domain: "example2.xx", Organization: "Other Corp", Country: "XX", Registrar: "XX Registry", CreatedDate: "2020-01-01", ExpiryDate: "2027-01-01", Status: "ok"},
+		{Domain: "example3.xx", Error: "timeout"},
+		{Domain: "example.de", Organization: "Example GmbH", Country: "DE", Registrar: "DENIC", CreatedDate: "2001-01-01",
+			ExpiryDate: "2027-01-01", Status: "connect", NameServers: []string{"ns1.example.de"}, Emails: []string{"a@example.de"}},
+	}
+	var result Result
+	summarizeQuality(&result, domains)
+	if len(result.WhoisQuality) != 2 {
+		t.Fatalf("Expected two TLDs, got %+v", result.WhoisQuality)
+	}
+	xx := result.WhoisQuality[0]
+	if xx.TLD != ".xx" || xx.Domains != 2 || xx.Completeness != 50 || xx.Incomplete != 1 || xx.Missing["emails"] != 2 || xx.Missing["registrar"] != 0 {
+		t.Errorf("Unexpected .xx quality: %+v", xx)
+	}
+	if de := result.WhoisQuality[1]; de.TLD != ".de" || de.Completeness != 100 || len(de.Missing) != 0 {
+		t.Errorf("Unexpected .de quality: %+v", de)
+	}
+
+	lines := qualityLines(result.WhoisQuality, 10)
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], ".xx") || !strings.Contains(lines[0], "1 incomplete") {
+		t.Errorf("Expected only the TLD with incomplete records, got %q", lines)
+	}
+}
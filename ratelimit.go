@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitScopes lists the values accepted by -rate-scope
+var rateLimitScopes = []string{"global", "tld"}
+
+// rateLimiter is a token-bucket limiter for WHOIS queries. With the "tld"
+// scope every TLD (and therefore every registry WHOIS server) gets its own
+// bucket, so a slow registry does not hold back queries to the others.
+type rateLimiter struct {
+	limit  rate.Limit
+	burst  int
+	perTLD bool
+	jitter jitterRange
+	// shared spaces queries out across every instance using the same Redis
+	// lookup cache, one query per interval and bucket
+	shared *redisClient
+	// policies enforces registryPolicies on top of the configured limit,
+	// one bucket per registry
+	policies bool
+
+	mu         sync.Mutex
+	global     *rate.Limiter
+	buckets    map[string]*rate.Limiter
+	registries map[string]*rate.Limiter
+}
+
+// newRateLimiter creates a limiter allowing one query every intervalMs
+// milliseconds with bursts of up to burst queries. An interval of zero
+// disables limiting.
+func newRateLimiter(intervalMs, burst int, scope string) *rateLimiter {
+	limit := rate.Inf
+	if intervalMs > 0 {
+		limit = rate.Every(time.Duration(intervalMs) * time.Millisecond)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		limit:      limit,
+		burst:      burst,
+		perTLD:     scope == "tld",
+		global:     rate.NewLimiter(limit, burst),
+		buckets:    make(map[string]*rate.Limiter),
+		registries: make(map[string]*rate.Limiter),
+	}
+}
+
+// bucket returns the token bucket responsible for domain
+func (l *rateLimiter) bucket(domain string) *rate.Limiter {
+	if !l.perTLD {
+		return l.global
+	}
+
+	tld := domainTLD(domain)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.buckets[tld]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[tld] = limiter
+	}
+	return limiter
+}
+
+// registryBucket returns the token bucket enforcing the policy of the
+// registry serving domain, or nil when there is none to enforce
+func (l *rateLimiter) registryBucket(domain string) *rate.Limiter {
+	if !l.policies {
+		return nil
+	}
+	policy, ok := registryPolicyFor(domain)
+	if !ok {
+		return nil
+	}
+
+	tld := lastLabel(domain)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.registries[tld]
+	if !ok {
+		limiter = rate.NewLimiter(policy.limit(), 1)
+		l.registries[tld] = limiter
+	}
+	return limiter
+}
+
+// Wait blocks until a query for domain is allowed or ctx is done. Queries to
+// a registry with a policy also wait for its bucket. With jitter configured,
+// a random delay follows the token so queries do not arrive at a
+// predictable cadence.
+func (l *rateLimiter) Wait(ctx context.Context, domain string) error {
+	if registry := l.registryBucket(domain); registry != nil {
+		if err := registry.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if err := l.bucket(domain).Wait(ctx); err != nil {
+		return err
+	}
+	if l.shared != nil && l.limit != rate.Inf {
+		if err := l.waitShared(ctx, domain); err != nil {
+			return err
+		}
+	}
+	delay := l.jitter.random()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// waitShared claims the bucket's next query slot in Redis: a key that
+// expires after one interval. Instances that find it taken wait for it to
+// expire. Without Redis the local bucket alone limits the queries.
+func (l *rateLimiter) waitShared(ctx context.Context, domain string) error {
+	key := "tldscanner:rate:global"
+	if l.perTLD {
+		key = "tldscanner:rate:" + domainTLD(domain)
+	}
+	interval := strconv.FormatInt(time.Duration(float64(time.Second)/float64(l.limit)).Milliseconds(), 10)
+	for {
+		_, err := l.shared.do("SET", key, "1", "PX", interval, "NX")
+		if err != errRedisNil {
+			// Claimed, or Redis is unavailable
+			return nil
+		}
+		wait := time.Millisecond
+		if reply, err := l.shared.do("PTTL", key); err == nil {
+			if ms, ok := reply.(int64); ok && ms > 0 {
+				wait = time.Duration(ms) * time.Millisecond
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// jitterRange is a uniform random delay between min and max
+type jitterRange struct {
+	min, max time.Duration
+}
+
+// random returns a delay within the range, zero for an empty range
+func (j jitterRange) random() time.Duration {
+	if j.max <= 0 {
+		return 0
+	}
+	if j.max == j.min {
+		return j.min
+	}
+	return j.min + time.Duration(rand.Int63n(int64(j.max-j.min)+1))
+}
+
+// parseJitter parses a -jitter value: a single duration ("100ms") or a
+// range ("50-250ms", "50ms-1s"). A bare number takes the unit of the upper
+// bound. An empty value disables jitter.
+func parseJitter(s string) (jitterRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return jitterRange{}, nil
+	}
+
+	lo, hi, isRange := strings.Cut(s, "-")
+	max, err := time.ParseDuration(strings.TrimSpace(hi))
+	if !isRange {
+		max, err = time.ParseDuration(strings.TrimSpace(lo))
+	}
+	if err != nil {
+		return jitterRange{}, fmt.Errorf("invalid jitter %q: %w", s, err)
+	}
+	if !isRange {
+		return jitterRange{min: max, max: max}, validJitter(s, max, max)
+	}
+
+	lo = strings.TrimSpace(lo)
+	if strings.Trim(lo, "0123456789.") == "" {
+		// "50-250ms": the lower bound borrows the upper bound's unit
+		lo += strings.TrimLeft(strings.TrimSpace(hi), "0123456789.")
+	}
+	min, err := time.ParseDuration(lo)
+	if err != nil {
+		return jitterRange{}, fmt.Errorf("invalid jitter %q: %w", s, err)
+	}
+	return jitterRange{min: min, max: max}, validJitter(s, min, max)
+}
+
+func validJitter(s string, min, max time.Duration) error {
+	if min < 0 || max < min {
+		return fmt.Errorf("invalid jitter %q: expected 0 <= min <= max", s)
+	}
+	return nil
+}
+
+// domainTLD returns everything after the first label, e.g. "co.uk" for
+// "example.co.uk"
+func domainTLD(domain string) string {
+	if i := strings.Index(domain, "."); i >= 0 {
+		return strings.ToLower(domain[i+1:])
+	}
+	return strings.ToLower(domain)
+}
+
+// validateRateLimit checks the rate limiting options
+func validateRateLimit(config Config) error {
+	if config.RateLimit < 0 {
+		return fmt.Errorf("-r must not be negative")
+	}
+	if config.Burst < 1 {
+		return fmt.Errorf("-burst must be at least 1")
+	}
+	if !containsString(rateLimitScopes, config.RateScope) {
+		return fmt.Errorf("unknown rate limit scope %q (valid: %s)", config.RateScope, strings.Join(rateLimitScopes, ", "))
+	}
+	_, err := parseJitter(config.Jitter)
+	return err
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDomainTLD(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"example.com", "com"},
+		{"example.co.uk", "co.uk"},
+		{"Example.DE", "de"},
+		{"localhost", "localhost"},
+	}
+
+	for _, test := range tests {
+		if result := domainTLD(test.input); result != test.expected {
+			t.Errorf("domainTLD(%s) = %s; expected %s", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestRateLimiterScopes(t *testing.T) {
+	global := newRateLimiter(100, 1, "global")
+	if global.bucket("example.com") != global.bucket("example.net") {
+		t.Error("Global scope should share one bucket")
+	}
+
+	perTLD := newRateLimiter(100, 1, "tld")
+	if perTLD.bucket("example.com") == perTLD.bucket("example.net") {
+		t.Error("TLD scope should use separate buckets per TLD")
+	}
+	if perTLD.bucket("example.com") != perTLD.bucket("other.com") {
+		t.Error("TLD scope should reuse the bucket for the same TLD")
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	limiter := newRateLimiter(1000, 3, "global")
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "example.com"); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Burst of 3 should not block, took %v", elapsed)
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	limiter := newRateLimiter(0, 1, "global")
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		limiter.Wait(context.Background(), "example.com")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Zero interval should disable limiting, took %v", elapsed)
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	if err := validateRateLimit(Config{RateLimit: 100, Burst: 1, RateScope: "tld"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := validateRateLimit(Config{RateLimit: 100, Burst: 0, RateScope: "global"}); err == nil {
+		t.Error("Expected error for zero burst")
+	}
+	if err := validateRateLimit(Config{RateLimit: 100, Burst: 1, RateScope: "worker"}); err == nil {
+		t.Error("Expected error for unknown scope")
+	}
+}
+
+func TestParseJitter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected jitterRange
+		valid    bool
+	}{
+		{"", jitterRange{}, true},
+		{"100ms", jitterRange{100 * time.Millisecond, 100 * time.Millisecond}, true},
+		{"50-250ms", jitterRange{50 * time.Millisecond, 250 * time.Millisecond}, true},
+		{"500ms-2s", jitterRange{500 * time.Millisecond, 2 * time.Second}, true},
+		{"1.5-2s", jitterRange{1500 * time.Millisecond, 2 * time.Second}, true},
+		{"250-50ms", jitterRange{}, false},
+		{"fast", jitterRange{}, false},
+		{"50-", jitterRange{}, false},
+	}
+
+	for _, test := range tests {
+		result, err := parseJitter(test.input)
+		if (err == nil) != test.valid {
+			t.Errorf("parseJitter(%q) error = %v; expected valid=%v", test.input, err, test.valid)
+			continue
+		}
+		if test.valid && result != test.expected {
+			t.Errorf("parseJitter(%q) = %v; expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestJitterRangeRandom(t *testing.T) {
+	j := jitterRange{min: 50 * time.Millisecond, max: 250 * time.Millisecond}
+	for i := 0; i < 100; i++ {
+		if d := j.random(); d < j.min || d > j.max {
+			t.Fatalf("random() = %v; expected within [%v, %v]", d, j.min, j.max)
+		}
+	}
+	if d := (jitterRange{}).random(); d != 0 {
+		t.Errorf("Empty range random() = %v; expected 0", d)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rdapBootstrapURL is the IANA RDAP bootstrap registry for domain names
+var rdapBootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+// errRDAPNotFound is returned when the RDAP server has no record of a domain
+var errRDAPNotFound = errors.New("rdap: domain not found")
+
+// rdapBootstrapTTL is how long a fetched bootstrap registry is reused;
+// rdapBootstrapRetry is how long a failed fetch is reported before retrying,
+// so an IANA outage does not cost one request per domain
+const (
+	rdapBootstrapTTL   = 24 * time.Hour
+	rdapBootstrapRetry = time.Minute
+)
+
+// rdapBootstrapCache holds the last bootstrap fetch, shared by the scans
+// of a process such as serve and monitor mode scans
+var rdapBootstrapCache struct {
+	mu      sync.Mutex
+	servers map[string]string
+	err     error
+	expires time.Time
+}
+
+// rdapBootstrap is the layout of the IANA bootstrap file (RFC 9224)
+type rdapBootstrap struct {
+	Services [][][]string `json:"services"`
+}
+
+// rdapDomain is the subset of an RDAP domain object (RFC 9083) we use
+type rdapDomain struct {
+	LDHName     string       `json:"ldhName"`
+	Status      []string     `json:"status"`
+	Events      []rdapEvent  `json:"events"`
+	Nameservers []rdapNS     `json:"nameservers"`
+	Entities    []rdapEntity `json:"entities"`
+	Links       []rdapLink   `json:"links"`
+}
+
+type rdapEvent struct {
+	Action string `json:"eventAction"`
+	Date   string `json:"eventDate"`
+}
+
+type rdapLink struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+	Type string `json:"type"`
+}
+
+type rdapNS struct {
+	LDHName string `json:"ldhName"`
+}
+
+type rdapEntity struct {
+	Roles      []string        `json:"roles"`
+	VCardArray json.RawMessage `json:"vcardArray"`
+	Entities   []rdapEntity    `json:"entities"`
+}
+
+// rdapHTTPClient returns the HTTP client used for RDAP queries
+func rdapHTTPClient(config Config) *http.Client {
+	client := &http.Client{Timeout: time.Duration(config.Timeout) * time.Second}
+	if config.Trace.debugging() {
+		client.Transport = &tracingTransport{base: http.DefaultTransport, trace: config.Trace}
+	}
+	return client
+}
+
+// loadRDAPServers returns the bootstrap registry, fetching it again once
+// it is older than rdapBootstrapTTL or shortly after a failed fetch
+func loadRDAPServers(client *http.Client) (map[string]string, error) {
+	cache := &rdapBootstrapCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if time.Now().Before(cache.expires) {
+		if cache.servers != nil {
+			return cache.servers, nil
+		}
+		return nil, cache.err
+	}
+
+	var bootstrap rdapBootstrap
+	if err := getJSON(context.Background(), client, rdapBootstrapURL, &bootstrap); err != nil {
+		cache.err = err
+		cache.expires = time.Now().Add(rdapBootstrapRetry)
+		// A registry fetched earlier is better than none
+		if cache.servers != nil {
+			return cache.servers, nil
+		}
+		return nil, err
+	}
+	cache.servers, cache.err = parseRDAPBootstrap(bootstrap), nil
+	cache.expires = time.Now().Add(rdapBootstrapTTL)
+	return cache.servers, nil
+}
+
+// parseRDAPBootstrap maps every TLD to the first base URL serving it
+func parseRDAPBootstrap(bootstrap rdapBootstrap) map[string]string {
+	servers := map[string]string{}
+	for _, service := range bootstrap.Services {
+		if len(service) < 2 || len(service[1]) == 0 {
+			continue
+		}
+		base := service[1][0]
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		for _, tld := range service[0] {
+			servers[strings.ToLower(tld)] = base
+		}
+	}
+	return servers
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errRDAPNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(v)
+}
+
+// getRDAPInfo looks a domain up over RDAP using the IANA bootstrap registry
+func getRDAPInfo(domain string, config Config) (*DomainInfo, error) {
+	return getRDAPInfoContext(context.Background(), domain, config)
+}
+
+// getRDAPInfoContext is getRDAPInfo with a context cancelling the query
+func getRDAPInfoContext(ctx context.Context, domain string, config Config) (*DomainInfo, error) {
+	client := rdapHTTPClient(config)
+	servers, err := loadRDAPServers(client)
+	if err != nil {
+		return nil, fmt.Errorf("rdap bootstrap failed: %w", err)
+	}
+
+	base, ok := servers[lastLabel(domain)]
+	if !ok {
+		return nil, fmt.Errorf("no rdap server for .%s", lastLabel(domain))
+	}
+
+	if !config.Budget.take(lastLabel(domain)) {
+		return nil, fmt.Errorf("rdap query failed: %w", errQueryBudget)
+	}
+	var record rdapDomain
+	if err := getJSON(ctx, client, base+"domain/"+domain, &record); err != nil {
+		return nil, fmt.Errorf("rdap query failed: %w", err)
+	}
+
+	info := domainInfoFromRDAP(domain, record)
+	info.WhoisServer = base
+	return info, nil
+}
+
+// hasRDAPServer reports whether the bootstrap registry lists an RDAP
+// server for domain's TLD
+func hasRDAPServer(domain string, config Config) bool {
+	servers, err := loadRDAPServers(rdapHTTPClient(config))
+	return err == nil && servers[lastLabel(domain)] != ""
+}
+
+// raceLookup queries WHOIS and RDAP concurrently and returns the first
+// successful result, cancelling the RDAP request if WHOIS wins. A WHOIS
+// query cannot be interrupted and is left to finish within its timeout.
+// TLDs without an RDAP server use the sequential WHOIS-then-RDAP path.
+func raceLookup(domain string, config Config) (*DomainInfo, error) {
+	if !hasRDAPServer(domain, config) {
+		return getWhoisInfo(domain, config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type outcome struct {
+		info *DomainInfo
+		err  error
+	}
+	whoisDone := make(chan outcome, 1)
+	rdapDone := make(chan outcome, 1)
+	go func() {
+		info, err := lookupWhois(domain, config)
+		whoisDone <- outcome{info, err}
+	}()
+	go func() {
+		info, err := getRDAPInfoContext(ctx, domain, config)
+		rdapDone <- outcome{info, err}
+	}()
+
+	var whoisErr, rdapErr error
+	for whoisDone != nil || rdapDone != nil {
+		select {
+		case r := <-whoisDone:
+			if r.err == nil {
+				config.Trace.logf("WHOIS won the race")
+				completeThinRecord(r.info, config)
+				return r.info, nil
+			}
+			whoisErr, whoisDone = r.err, nil
+		case r := <-rdapDone:
+			if r.err == nil {
+				config.Trace.logf("RDAP won the race")
+				completeThinRecord(r.info, config)
+				return r.info, nil
+			}
+			rdapErr, rdapDone = r.err, nil
+		}
+	}
+	return nil, fmt.Errorf("%w; rdap: %v", whoisErr, rdapErr)
+}
+
+// domainInfoFromRDAP maps an RDAP domain object onto DomainInfo
+func domainInfoFromRDAP(domain string, record rdapDomain) *DomainInfo {
+	info := &DomainInfo{
+		Domain:    domain,
+		Status:    strings.Join(record.Status, ", "),
+		Source:    "rdap",
+		Timestamp: time.Now(),
+	}
+
+	for _, event := range record.Events {
+		switch event.Action {
+		case "registration":
+			info.CreatedDate = event.Date
+		case "expiration":
+			info.ExpiryDate = event.Date
+		}
+	}
+	for _, ns := range record.Nameservers {
+		if ns.LDHName != "" {
+			info.NameServers = append(info.NameServers, strings.ToLower(ns.LDHName))
+		}
+	}
+
+	entities := flattenRDAPEntities(record.Entities)
+	info.AbuseEmail, info.AbusePhone = rdapAbuseContact(entities)
+	for _, entity := range entities {
+		card := parseVCard(entity.VCardArray)
+		for _, role := range entity.Roles {
+			switch role {
+			case "registrar":
+				if info.Registrar == "" {
+					info.Registrar = firstNonEmpty(card["fn"], card["org"])
+				}
+			case "registrant":
+				if info.Organization == "" {
+					info.Organization = firstNonEmpty(card["org"], card["fn"])
+				}
+				if info.Country == "" {
+					info.Country = vcardCountry(entity.VCardArray)
+				}
+			}
+			if role == "registrant" || role == "administrative" || role == "technical" {
+				if email := strings.ToLower(card["email"]); email != "" && !containsString(info.Emails, email) {
+					info.Emails = append(info.Emails, email)
+				}
+			}
+		}
+	}
+
+	return info
+}
+
+// flattenRDAPEntities returns entities and their nested entities depth-first
+func flattenRDAPEntities(entities []rdapEntity) []rdapEntity {
+	var all []rdapEntity
+	for _, entity := range entities {
+		all = append(all, entity)
+		all = append(all, flattenRDAPEntities(entity.Entities)...)
+	}
+	return all
+}
+
+// parseVCard extracts the first text value of each property from a jCard
+// (RFC 7095) array: ["vcard", [["fn", {}, "text", "Example"], ...]]
+func parseVCard(raw json.RawMessage) map[string]string {
+	card := map[string]string{}
+	var vcard []json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &vcard) != nil || len(vcard) < 2 {
+		return card
+	}
+
+	var properties [][]json.RawMessage
+	if json.Unmarshal(vcard[1], &properties) != nil {
+		return card
+	}
+	for _, property := range properties {
+		if len(property) < 4 {
+			continue
+		}
+		var name, value string
+		if json.Unmarshal(property[0], &name) != nil || json.Unmarshal(property[3], &value) != nil {
+			continue
+		}
+		if _, seen := card[name]; !seen && value != "" {
+			card[name] = strings.TrimSpace(value)
+		}
+	}
+	return card
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const rdapFixture = `{
+  "objectClassName": "domain",
+  "ldhName": "EXAMPLE.NET",
+  "status": ["client transfer prohibited"],
+  "events": [
+    {"eventAction": "registration", "eventDate": "2019-03-04T10:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2026-03-04T10:00:00Z"}
+  ],
+  "nameservers": [{"ldhName": "NS1.EXAMPLE.NET"}, {"ldhName": "ns2.example.net"}],
+  "entities": [
+    {"roles": ["registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Registrar LLC"]]],
+     "entities": [{"roles": ["abuse"], "vcardArray": ["vcard", [["email", {}, "text", "Abuse@Registrar.example"], ["tel", {"type": "voice"}, "uri", "tel:+1.5555551234"]]]}]},
+    {"roles": ["registrant"], "vcardArray": ["vcard", [["fn", {}, "text", "Domain Admin"], ["org", {}, "text", "Example Corp"], ["email", {}, "text", "Hostmaster@Example.com"]]],
+     "entities": [{"roles": ["technical"], "vcardArray": ["vcard", [["email", {}, "text", "noc@example.com"]]]}]}
+  ]
+}`
+
+func TestDomainInfoFromRDAP(t *testing.T) {
+	var record rdapDomain
+	if err := json.Unmarshal([]byte(rdapFixture), &record); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	info := domainInfoFromRDAP("example.net", record)
+
+	if info.Organization != "Example Corp" {
+		t.Errorf("Organization = %q; expected Example Corp", info.Organization)
+	}
+	if info.Registrar != "Example Registrar LLC" {
+		t.Errorf("Registrar = %q; expected Example Registrar LLC", info.Registrar)
+	}
+	if info.CreatedDate != "2019-03-04T10:00:00Z" || info.ExpiryDate != "2026-03-04T10:00:00Z" {
+		t.Errorf("Dates = %q / %q", info.CreatedDate, info.ExpiryDate)
+	}
+	if !reflect.DeepEqual(info.NameServers, []string{"ns1.example.net", "ns2.example.net"}) {
+		t.Errorf("NameServers = %v", info.NameServers)
+	}
+	if !reflect.DeepEqual(info.Emails, []string{"hostmaster@example.com", "noc@example.com"}) {
+		t.Errorf("Emails = %v", info.Emails)
+	}
+	if info.AbuseEmail != "abuse@registrar.example" || info.AbusePhone != "+1.5555551234" {
+		t.Errorf("Abuse contact = %q / %q", info.AbuseEmail, info.AbusePhone)
+	}
+	if info.Source != "rdap" {
+		t.Errorf("Source = %q; expected rdap", info.Source)
+	}
+}
+
+func TestGetRDAPInfo(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dns.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"services": [][][]string{{{"net"}, {server.URL + "/rdap"}}},
+			})
+		case "/rdap/domain/example.net":
+			w.Write([]byte(rdapFixture))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Reset the per-run bootstrap cache around the test
+	oldURL := rdapBootstrapURL
+	rdapBootstrapURL = server.URL + "/dns.json"
+	resetRDAPBootstrap()
+	defer func() {
+		rdapBootstrapURL = oldURL
+		resetRDAPBootstrap()
+	}()
+
+	info, err := getRDAPInfo("example.net", Config{Timeout: 5})
+	if err != nil {
+		t.Fatalf("getRDAPInfo failed: %v", err)
+	}
+	if info.Organization != "Example Corp" || info.WhoisServer != server.URL+"/rdap/" {
+		t.Errorf("Unexpected info: %+v", info)
+	}
+
+	if _, err := getRDAPInfo("missing.net", Config{Timeout: 5}); err == nil {
+		t.Error("Expected error for unknown domain")
+	}
+	if _, err := getRDAPInfo("example.zz", Config{Timeout: 5}); err == nil {
+		t.Error("Expected error for TLD without RDAP service")
+	}
+
+	if !hasRDAPServer("example.net", Config{Timeout: 5}) || hasRDAPServer("example.zz", Config{Timeout: 5}) {
+		t.Error("hasRDAPServer() should follow the bootstrap registry")
+	}
+}
+
+// resetRDAPBootstrap forgets the cached bootstrap registry
+func resetRDAPBootstrap() {
+	rdapBootstrapCache.mu.Lock()
+	defer rdapBootstrapCache.mu.Unlock()
+	rdapBootstrapCache.servers, rdapBootstrapCache.err = nil, nil
+	rdapBootstrapCache.expires = time.Time{}
+}
+
+func TestLoadRDAPServersRetriesFailures(t *testing.T) {
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"services": [][][]string{{{"net"}, {"https://rdap.example/"}}},
+		})
+	}))
+	defer server.Close()
+
+	oldURL := rdapBootstrapURL
+	rdapBootstrapURL = server.URL
+	resetRDAPBootstrap()
+	defer func() {
+		rdapBootstrapURL = oldURL
+		resetRDAPBootstrap()
+	}()
+
+	if _, err := loadRDAPServers(server.Client()); err == nil {
+		t.Fatal("Expected the failed fetch to be reported")
+	}
+	fail = false
+	if _, err := loadRDAPServers(server.Client()); err == nil {
+		t.Error("Expected the failure to be reused until the retry delay passes")
+	}
+
+	// Once the retry delay has passed the registry is fetched again
+	rdapBootstrapCache.expires = time.Now()
+	servers, err := loadRDAPServers(server.Client())
+	if err != nil || servers["net"] != "https://rdap.example/" {
+		t.Errorf("Expected the registry after a retry, got %v, %v", servers, err)
+	}
+
+	// A later failed refresh keeps serving the registry fetched before
+	fail = true
+	rdapBootstrapCache.expires = time.Now()
+	if servers, err := loadRDAPServers(server.Client()); err != nil || servers["net"] == "" {
+		t.Errorf("Expected the previous registry on a failed refresh, got %v, %v", servers, err)
+	}
+}
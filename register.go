@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// Registration is an unregistered high-value variant of the target: under
+// a popular TLD, or a homoglyph, suggested for defensive registration
+type Registration struct {
+	// Domain is the ASCII (punycode) form registrars take
+	Domain        string `json:"domain"`
+	UnicodeDomain string `json:"unicode_domain,omitempty"`
+	Variant       string `json:"variant"`
+	TLD           string `json:"tld"`
+	Technique     string `json:"technique,omitempty"`
+	Rationale     string `json:"rationale"`
+}
+
+// registerHeader is the header of -format register
+var registerHeader = []string{"domain", "unicode_domain", "variant", "tld", "technique", "rationale"}
+
+// unregisteredDomains returns the scanned domains found unregistered, in
+// WHOIS or by the DNS precheck
+func unregisteredDomains(domains []DomainInfo, skipped scanSkips) []string {
+	unregistered := append([]string(nil), skipped.nxdomains...)
+	for _, info := range domains {
+		if info.errorCode() == ErrNXDomain {
+			unregistered = append(unregistered, info.Domain)
+		}
+	}
+	return unregistered
+}
+
+// suggestRegistrations keeps the unregistered domains worth registering
+// before someone else does: those under a popular TLD and the homoglyphs
+// of the target, which read as the target itself. technique returns how a
+// domain was derived from the target, "" when unknown.
+func suggestRegistrations(target string, unregistered []string, technique func(domain string) string) []Registration {
+	popular := make(map[string]bool)
+	for _, tld := range popularTLDs() {
+		popular[tld] = true
+	}
+
+	var suggestions []Registration
+	seen := make(map[string]bool)
+	for _, domain := range unregistered {
+		variant, tld, _ := strings.Cut(domain, ".")
+		tld = "." + tld
+		how := technique(domain)
+		if seen[domain] || (!popular[tld] && how != TechniqueHomoglyph) {
+			continue
+		}
+		seen[domain] = true
+
+		var reasons []string
+		switch how {
+		case TechniqueHomoglyph:
+			reasons = append(reasons, "homoglyph that reads as "+target)
+		case TechniquePermutation:
+			reasons = append(reasons, "typo of "+target)
+		case TechniqueTLD:
+			reasons = append(reasons, "the target's name under another TLD")
+		}
+		if popular[tld] {
+			reasons = append(reasons, "popular TLD "+tld)
+		}
+		registration := Registration{
+			Domain:        domain,
+			UnicodeDomain: unicodeDomain(domain),
+			Variant:       variant,
+			TLD:           tld,
+			Technique:     how,
+			Rationale:     strings.Join(reasons, "; "),
+		}
+		if unicode := registration.UnicodeDomain; unicode != "" {
+			registration.Variant, _, _ = strings.Cut(unicode, ".")
+		}
+		suggestions = append(suggestions, registration)
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].Domain < suggestions[j].Domain })
+	return suggestions
+}
+
+// renderRegister renders the suggested registrations as CSV for the bulk
+// registration upload of a registrar
+func renderRegister(result Result) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(registerHeader)
+	for _, r := range result.Registrations {
+		writer.Write([]string{r.Domain, r.UnicodeDomain, r.Variant, r.TLD, r.Technique, r.Rationale})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// outputRegister writes the suggested registrations
+func outputRegister(result Result, outputFile string, recipients []age.Recipient) {
+	data, err := renderRegister(result)
+	if err != nil {
+		log.Printf("Error writing registrations: %v", err)
+		return
+	}
+	saveOutput(data, outputFile, recipients)
+}
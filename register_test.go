@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnregisteredDomains(t *testing.T) {
+	domains := []DomainInfo{
+		{Domain: "example.de", Organization: "Example GmbH"},
+		{Domain: "example.io", Error: "domain is not found", ErrorCode: ErrNXDomain},
+		{Domain: "example.xyz", Error: "timeout", ErrorCode: ErrTimeout},
+	}
+	got := unregisteredDomains(domains, scanSkips{nxdomains: []string{"example.top"}})
+	if strings.Join(got, ",") != "example.top,example.io" {
+		t.Errorf("Unexpected unregistered domains %v", got)
+	}
+}
+
+func TestSuggestRegistrations(t *testing.T) {
+	homoglyph := "xn--exmple-4nf.academy" // exаmple.academy with a Cyrillic а
+	techniques := map[string]string{
+		"example.io":      TechniqueTLD,
+		"example.academy": TechniqueTLD,
+		"exmaple.com":     TechniquePermutation,
+		homoglyph:         TechniqueHomoglyph,
+	}
+	unregistered := []string{"example.academy", homoglyph, "example.io", "exmaple.com", "example.io"}
+	got := suggestRegistrations("example.com", unregistered, func(domain string) string { return techniques[domain] })
+
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 suggestions, the unpopular TLD and the duplicate left out, got %+v", got)
+	}
+	if r := got[0]; r.Domain != "example.io" || r.Variant != "example" || r.TLD != ".io" || r.Rationale != "the target's name under another TLD; popular TLD .io" {
+		t.Errorf("Unexpected TLD suggestion %+v", r)
+	}
+	if r := got[1]; r.Domain != "exmaple.com" || r.Rationale != "typo of example.com; popular TLD .com" {
+		t.Errorf("Unexpected typo suggestion %+v", r)
+	}
+	if r := got[2]; r.Domain != homoglyph || r.UnicodeDomain != "exаmple.academy" || r.Variant != "exаmple" || r.Rationale != "homoglyph that reads as example.com" {
+		t.Errorf("Unexpected homoglyph suggestion %+v", r)
+	}
+}
+
+func TestRenderRegister(t *testing.T) {
+	result := Result{Registrations: []Registration{
+		{Domain: "example.io", Variant: "example", TLD: ".io", Technique: TechniqueTLD, Rationale: "the target's name under another TLD; popular TLD .io"},
+	}}
+	data, err := renderRegister(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "domain,unicode_domain,variant,tld,technique,rationale\n" +
+		"example.io,,example,.io,tld,the target's name under another TLD; popular TLD .io\n"
+	if string(data) != want {
+		t.Errorf("Unexpected CSV:\n%s", data)
+	}
+}
+
+func TestValidateRegisterFormat(t *testing.T) {
+	if err := validateFormat(Config{Format: "register"}); err == nil {
+		t.Error("Expected -format register without -suggest-registrations to be rejected")
+	}
+	if err := validateFormat(Config{Format: "register", SuggestRegister: true}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// riskFlaggedCountry is the risk score weight of a lookalike registered
+// from a -flag-countries jurisdiction
+const riskFlaggedCountry = 15
+
+// countryNames map the country names registries publish instead of an
+// ISO 3166-1 alpha-2 code to the code, lowercased
+var countryNames = map[string]string{
+	"afghanistan":                            "AF",
+	"belarus":                                "BY",
+	"brazil":                                 "BR",
+	"canada":                                 "CA",
+	"china":                                  "CN",
+	"people's republic of china":             "CN",
+	"cuba":                                   "CU",
+	"france":                                 "FR",
+	"germany":                                "DE",
+	"hong kong":                              "HK",
+	"india":                                  "IN",
+	"iran":                                   "IR",
+	"iran, islamic republic of":              "IR",
+	"islamic republic of iran":               "IR",
+	"iraq":                                   "IQ",
+	"japan":                                  "JP",
+	"korea, democratic people's republic of": "KP",
+	"democratic people's republic of korea":  "KP",
+	"north korea":                            "KP",
+	"korea, republic of":                     "KR",
+	"republic of korea":                      "KR",
+	"south korea":                            "KR",
+	"myanmar":                                "MM",
+	"netherlands":                            "NL",
+	"the netherlands":                        "NL",
+	"nicaragua":                              "NI",
+	"panama":                                 "PA",
+	"russia":                                 "RU",
+	"russian federation":                     "RU",
+	"seychelles":                             "SC",
+	"singapore":                              "SG",
+	"syria":                                  "SY",
+	"syrian arab republic":                   "SY",
+	"turkey":                                 "TR",
+	"türkiye":                                "TR",
+	"ukraine":                                "UA",
+	"united kingdom":                         "GB",
+	"great britain":                          "GB",
+	"united states":                          "US",
+	"united states of america":               "US",
+	"usa":                                    "US",
+	"venezuela":                              "VE",
+}
+
+// normalizeCountry returns the ISO 3166-1 alpha-2 code of a published
+// registrant country, or the value as published when it is not recognized
+func normalizeCountry(country string) string {
+	country = strings.TrimSpace(country)
+	if len(country) == 2 {
+		code := strings.ToUpper(country)
+		if alias, ok := countryAliases[code]; ok {
+			return alias
+		}
+		return code
+	}
+	if code, ok := countryNames[strings.ToLower(country)]; ok {
+		return code
+	}
+	return country
+}
+
+// vcardCountry returns the country of the first address of a jCard, from
+// its "cc" parameter (RFC 8605) or the country name component
+func vcardCountry(raw json.RawMessage) string {
+	var vcard []json.RawMessage
+	if len(raw) == 0 || json.Unmarshal(raw, &vcard) != nil || len(vcard) < 2 {
+		return ""
+	}
+	var properties [][]json.RawMessage
+	if json.Unmarshal(vcard[1], &properties) != nil {
+		return ""
+	}
+	for _, property := range properties {
+		var name string
+		if len(property) < 4 || json.Unmarshal(property[0], &name) != nil || name != "adr" {
+			continue
+		}
+		var params struct {
+			CC string `json:"cc"`
+		}
+		if json.Unmarshal(property[1], &params) == nil && params.CC != "" {
+			return normalizeCountry(params.CC)
+		}
+		var components []interface{}
+		if json.Unmarshal(property[3], &components) == nil && len(components) == 7 {
+			if country, ok := components[6].(string); ok && country != "" {
+				return normalizeCountry(country)
+			}
+		}
+	}
+	return ""
+}
+
+// flaggedCountries parses the -flag-countries list into a set of codes
+func flaggedCountries(list string) map[string]bool {
+	if list == "" {
+		return nil
+	}
+	countries := make(map[string]bool)
+	for _, code := range strings.Split(list, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			countries[normalizeCountry(code)] = true
+		}
+	}
+	return countries
+}
+
+// countrySignal flags a domain whose registrant is in one of the flagged
+// countries
+func countrySignal(info *DomainInfo, flagged map[string]bool) (Signal, bool) {
+	if info.Country == "" || !flagged[info.Country] {
+		return Signal{}, false
+	}
+	return Signal{Name: "flagged_country", Score: 1, Detail: "registrant in " + info.Country}, true
+}
+
+// flaggedCountry returns the country of a domain flagged by -flag-countries
+func (d DomainInfo) flaggedCountry() string {
+	for _, signal := range d.Signals {
+		if signal.Name == "flagged_country" {
+			return d.Country
+		}
+	}
+	return ""
+}
+
+// validateFlagCountries checks that -flag-countries lists country codes
+func validateFlagCountries(list string) error {
+	var invalid []string
+	for code := range flaggedCountries(list) {
+		if len(code) != 2 {
+			invalid = append(invalid, code)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("-flag-countries takes ISO 3166-1 alpha-2 codes such as ru,kp,ir, not %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
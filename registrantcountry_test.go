@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		country  string
+		expected string
+	}{
+		{"ru", "RU"},
+		{" KP ", "KP"},
+		{"UK", "GB"},
+		{"Russian Federation", "RU"},
+		{"IRAN, ISLAMIC REPUBLIC OF", "IR"},
+		{"Atlantis", "Atlantis"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if result := normalizeCountry(test.country); result != test.expected {
+			t.Errorf("normalizeCountry(%q) = %q, expected %q", test.country, result, test.expected)
+		}
+	}
+}
+
+func TestVcardCountry(t *testing.T) {
+	tests := []struct {
+		vcard    string
+		expected string
+	}{
+		{`["vcard",[["version",{},"text","4.0"],["adr",{"cc":"ru"},"text",["","","","","","",""]]]]`, "RU"},
+		{`["vcard",[["adr",{},"text",["","","Tverskaya 1","Moscow","","101000","Russian Federation"]]]]`, "RU"},
+		{`["vcard",[["fn",{},"text","Example"]]]`, ""},
+		{`not json`, ""},
+		{``, ""},
+	}
+	for _, test := range tests {
+		if result := vcardCountry(json.RawMessage(test.vcard)); result != test.expected {
+			t.Errorf("vcardCountry(%s) = %q, expected %q", test.vcard, result, test.expected)
+		}
+	}
+}
+
+func TestCountrySignal(t *testing.T) {
+	flagged := flaggedCountries("ru, kp,ir")
+	if !reflect.DeepEqual(flagged, map[string]bool{"RU": true, "KP": true, "IR": true}) {
+		t.Errorf("flaggedCountries() = %v", flagged)
+	}
+
+	info := &DomainInfo{Domain: "examp1e.ru", Country: "RU"}
+	signal, ok := countrySignal(info, flagged)
+	if !ok || signal.Name != "flagged_country" || signal.Detail != "registrant in RU" {
+		t.Errorf("countrySignal() = %+v, %v", signal, ok)
+	}
+	if _, ok := countrySignal(&DomainInfo{Country: "DE"}, flagged); ok {
+		t.Error("An unflagged country should not signal")
+	}
+	if _, ok := countrySignal(info, nil); ok {
+		t.Error("No country should signal without -flag-countries")
+	}
+
+	info.Signals = append(info.Signals, signal)
+	if country := info.flaggedCountry(); country != "RU" {
+		t.Errorf("flaggedCountry() = %q, expected RU", country)
+	}
+}
+
+func TestValidateFlagCountries(t *testing.T) {
+	for _, list := range []string{"", "ru,kp,ir", "UK, Russia"} {
+		if err := validateFlagCountries(list); err != nil {
+			t.Errorf("validateFlagCountries(%q) failed: %v", list, err)
+		}
+	}
+	if err := validateFlagCountries("ru,Atlantis,rus"); err == nil {
+		t.Error("validateFlagCountries should reject values that are not country codes")
+	}
+}
+
+func TestDetectFlaggedCountryAlerts(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	signal := []Signal{{Name: "flagged_country", Score: 1, Detail: "registrant in RU"}}
+	previous := []DomainInfo{
+		{Domain: "examp1e.com", Country: "RU", Signals: signal},
+		{Domain: "example.net", Country: "DE"},
+	}
+	if _, err := store.record(Result{TargetDomain: "example.com"}, previous, time.Now().Add(-24*time.Hour)); err != nil {
+		t.Fatalf("record failed: %v", err)
+	}
+
+	current := []DomainInfo{
+		// Already alerted on
+		{Domain: "examp1e.com", Country: "RU", Signals: signal},
+		{Domain: "example.net", Country: "RU", Signals: signal},
+		{Domain: "example.org", Country: "RU", Signals: signal},
+	}
+	alerts, err := detectAlerts(store, current)
+	if err != nil {
+		t.Fatalf("detectAlerts failed: %v", err)
+	}
+	expected := []Alert{
+		{Kind: AlertFlagged, Domain: "example.net", Changes: []FieldChange{{Field: "country", Old: "DE", New: "RU"}}},
+		{Kind: AlertFlagged, Domain: "example.org", Changes: []FieldChange{{Field: "country", New: "RU"}}},
+	}
+	if !reflect.DeepEqual(alerts, expected) {
+		t.Errorf("detectAlerts() = %+v; expected %+v", alerts, expected)
+	}
+	if got := expected[1].String(); got != "example.org is registered from flagged country RU" {
+		t.Errorf("Alert.String() = %s", got)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// registrarProviders are the valid values of -registrar-accounts
+var registrarProviders = []string{"cloudflare", "namecheap", "markmonitor"}
+
+// Registrar API endpoints, variables so tests can point them at a local
+// server
+var (
+	cloudflareAPIURL = "https://api.cloudflare.com/client/v4"
+	namecheapAPIURL  = "https://api.namecheap.com/xml.response"
+)
+
+// maxRegistrarPages bounds the pages read from a registrar account
+const maxRegistrarPages = 1000
+
+// RegistrarAccount holds the settings of a registrar account that are not
+// secrets; the API key is stored with `auth set <provider>`
+type RegistrarAccount struct {
+	// ClientIP is the allow-listed address Namecheap requires with every
+	// request
+	ClientIP string `yaml:"client_ip,omitempty"`
+	// URL is the MarkMonitor portfolio export endpoint of the account
+	URL string `yaml:"url,omitempty"`
+}
+
+// registrarAccount lists the domains held in an account at a registrar
+type registrarAccount interface {
+	domains(client *http.Client) ([]string, error)
+}
+
+// parseRegistrarAccounts parses the comma-separated -registrar-accounts
+func parseRegistrarAccounts(value string) ([]string, error) {
+	var providers []string
+	for _, provider := range strings.Split(value, ",") {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" || containsString(providers, provider) {
+			continue
+		}
+		if !containsString(registrarProviders, provider) {
+			return nil, fmt.Errorf("unknown registrar %q in -registrar-accounts (valid: %s)", provider, strings.Join(registrarProviders, ", "))
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// validateRegistrarAccounts checks -registrar-accounts
+func validateRegistrarAccounts(config Config) error {
+	_, err := parseRegistrarAccounts(config.RegistrarAccounts)
+	return err
+}
+
+// newRegistrarAccount returns the account of a -registrar-accounts
+// provider with its credentials and settings
+func newRegistrarAccount(provider string, config Config, settings RegistrarAccount) (registrarAccount, error) {
+	key, user := config.APIKeys[provider], config.APIUsernames[provider]
+	switch provider {
+	case "cloudflare":
+		if user == "" {
+			return nil, fmt.Errorf("cloudflare needs an account ID (run `tldscanner auth -username <account-id> set cloudflare`)")
+		}
+		return cloudflareRegistrar{token: key, accountID: user}, nil
+	case "namecheap":
+		if user == "" {
+			return nil, fmt.Errorf("namecheap needs an API user (run `tldscanner auth -username <api-user> set namecheap`)")
+		}
+		if settings.ClientIP == "" {
+			return nil, fmt.Errorf("namecheap needs the allow-listed client_ip in registrar_accounts.namecheap of the configuration file")
+		}
+		return namecheapRegistrar{user: user, key: key, clientIP: settings.ClientIP}, nil
+	case "markmonitor":
+		if settings.URL == "" {
+			return nil, fmt.Errorf("markmonitor needs the portfolio export url in registrar_accounts.markmonitor of the configuration file")
+		}
+		return markMonitorRegistrar{key: key, url: settings.URL}, nil
+	}
+	return nil, fmt.Errorf("unknown registrar %q", provider)
+}
+
+// loadRegistrarAccounts adds the domains held in the -registrar-accounts
+// to the known domains inventory, so they are reported as known_owned on
+// the registrar's word rather than by matching
+func loadRegistrarAccounts(config *Config, settings map[string]RegistrarAccount) error {
+	providers, err := parseRegistrarAccounts(config.RegistrarAccounts)
+	if err != nil {
+		return err
+	}
+	for _, provider := range providers {
+		account, err := newRegistrarAccount(provider, *config, settings[provider])
+		if err != nil {
+			return err
+		}
+		domains, err := account.domains(enrichmentHTTPClient(*config, provider))
+		if err != nil {
+			return fmt.Errorf("failed to list the domains of the %s account: %w", provider, err)
+		}
+		if config.KnownAssets == nil {
+			config.KnownAssets = make(map[string]bool)
+		}
+		count := 0
+		for _, domain := range domains {
+			if domain, ok := normalizeDomain(domain); ok {
+				config.KnownAssets[domain] = true
+				count++
+			}
+		}
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Loaded %d domains from the %s account\n", ColorBlue, ColorReset, count, provider)
+	}
+	return nil
+}
+
+// cloudflareRegistrar lists the domains of a Cloudflare Registrar account
+type cloudflareRegistrar struct {
+	token, accountID string
+}
+
+func (c cloudflareRegistrar) domains(client *http.Client) ([]string, error) {
+	var domains []string
+	for page := 1; page <= maxRegistrarPages; page++ {
+		endpoint := fmt.Sprintf("%s/accounts/%s/registrar/domains?page=%d&per_page=50", cloudflareAPIURL, url.PathEscape(c.accountID), page)
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		body, err := passiveDNSGet(client, req)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Success bool `json:"success"`
+			Errors  []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+			Result []struct {
+				Name string `json:"name"`
+			} `json:"result"`
+			ResultInfo struct {
+				TotalPages int `json:"total_pages"`
+			} `json:"result_info"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+		if !resp.Success {
+			var messages []string
+			for _, e := range resp.Errors {
+				messages = append(messages, e.Message)
+			}
+			return nil, fmt.Errorf("cloudflare: %s", strings.Join(messages, "; "))
+		}
+		for _, domain := range resp.Result {
+			domains = append(domains, domain.Name)
+		}
+		if page >= resp.ResultInfo.TotalPages {
+			break
+		}
+	}
+	return domains, nil
+}
+
+// namecheapRegistrar lists the domains of a Namecheap account through the
+// XML API
+type namecheapRegistrar struct {
+	user, key, clientIP string
+}
+
+// namecheapPageSize is the largest page namecheap.domains.getList returns
+const namecheapPageSize = 100
+
+func (n namecheapRegistrar) domains(client *http.Client) ([]string, error) {
+	var domains []string
+	for page := 1; page <= maxRegistrarPages; page++ {
+		query := url.Values{
+			"ApiUser":  {n.user},
+			"ApiKey":   {n.key},
+			"UserName": {n.user},
+			"ClientIp": {n.clientIP},
+			"Command":  {"namecheap.domains.getList"},
+			"Page":     {strconv.Itoa(page)},
+			"PageSize": {strconv.Itoa(namecheapPageSize)},
+		}
+		req, err := http.NewRequest(http.MethodGet, namecheapAPIURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		body, err := passiveDNSGet(client, req)
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Status  string   `xml:"Status,attr"`
+			Errors  []string `xml:"Errors>Error"`
+			Domains []struct {
+				Name string `xml:"Name,attr"`
+			} `xml:"CommandResponse>DomainGetListResult>Domain"`
+			TotalItems int `xml:"CommandResponse>Paging>TotalItems"`
+		}
+		if err := xml.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+		if resp.Status != "OK" {
+			return nil, fmt.Errorf("namecheap: %s", strings.Join(resp.Errors, "; "))
+		}
+		for _, domain := range resp.Domains {
+			domains = append(domains, domain.Name)
+		}
+		if page*namecheapPageSize >= resp.TotalItems {
+			break
+		}
+	}
+	return domains, nil
+}
+
+// markMonitorRegistrar reads a MarkMonitor portfolio export. MarkMonitor's
+// API is provisioned per contract, so the account's export URL is
+// configured; it answers a JSON list of domain names, or of objects with a
+// "domain" or "name" field.
+type markMonitorRegistrar struct {
+	key, url string
+}
+
+func (m markMonitorRegistrar) domains(client *http.Client) ([]string, error) {
+	req, err := http.NewRequest(http.MethodGet, m.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.key)
+	req.Header.Set("Accept", "application/json")
+	body, err := passiveDNSGet(client, req)
+	if err != nil {
+		return nil, err
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("invalid portfolio export: %w", err)
+	}
+	var domains []string
+	for _, entry := range entries {
+		var name string
+		if json.Unmarshal(entry, &name) != nil {
+			var record struct {
+				Domain string `json:"domain"`
+				Name   string `json:"name"`
+			}
+			if err := json.Unmarshal(entry, &record); err != nil {
+				return nil, fmt.Errorf("invalid portfolio export entry %s", entry)
+			}
+			name = firstNonEmpty(record.Domain, record.Name)
+		}
+		domains = append(domains, name)
+	}
+	return domains, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoadRegistrarAccounts(t *testing.T) {
+	cloudflare := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/accounts/acct-1/registrar/domains" || r.Header.Get("Authorization") != "Bearer cf-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		page := r.URL.Query().Get("page")
+		fmt.Fprintf(w, `{"success": true, "result": [{"name": "example-%s.com"}], "result_info": {"page": %s, "total_pages": 2}}`, page, page)
+	}))
+	defer cloudflare.Close()
+	namecheap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("ApiKey") != "nc-key" || q.Get("ClientIp") != "198.51.100.7" || q.Get("Command") != "namecheap.domains.getList" {
+			fmt.Fprint(w, `<ApiResponse Status="ERROR"><Errors><Error Number="1011102">API Key is invalid</Error></Errors></ApiResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<ApiResponse Status="OK"><CommandResponse Type="namecheap.domains.getList"><DomainGetListResult>
+<Domain ID="1" Name="Example.NET" /><Domain ID="2" Name="example.shop" /></DomainGetListResult>
+<Paging><TotalItems>2</TotalItems><CurrentPage>1</CurrentPage><PageSize>100</PageSize></Paging></CommandResponse></ApiResponse>`)
+	}))
+	defer namecheap.Close()
+	markmonitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `["example.de", {"domain": "example.fr"}, {"name": "example.it"}]`)
+	}))
+	defer markmonitor.Close()
+
+	defer func(cf, nc string) { cloudflareAPIURL, namecheapAPIURL = cf, nc }(cloudflareAPIURL, namecheapAPIURL)
+	cloudflareAPIURL, namecheapAPIURL = cloudflare.URL, namecheap.URL
+
+	config := Config{
+		RegistrarAccounts: "cloudflare, namecheap,markmonitor",
+		KnownAssets:       map[string]bool{"example.com": true},
+		APIKeys:           map[string]string{"cloudflare": "cf-token", "namecheap": "nc-key", "markmonitor": "mm-key"},
+		APIUsernames:      map[string]string{"cloudflare": "acct-1", "namecheap": "acme"},
+	}
+	settings := map[string]RegistrarAccount{
+		"namecheap":   {ClientIP: "198.51.100.7"},
+		"markmonitor": {URL: markmonitor.URL},
+	}
+	if err := loadRegistrarAccounts(&config, settings); err != nil {
+		t.Fatalf("loadRegistrarAccounts failed: %v", err)
+	}
+	for _, domain := range []string{"example.com", "example-1.com", "example-2.com", "example.net", "example.shop", "example.de", "example.fr", "example.it"} {
+		if !config.KnownAssets[domain] {
+			t.Errorf("Expected %s to be known owned, got %v", domain, config.KnownAssets)
+		}
+	}
+
+	config.APIKeys["namecheap"] = "wrong"
+	config.RegistrarAccounts = "namecheap"
+	if err := loadRegistrarAccounts(&config, settings); err == nil {
+		t.Error("Expected the API error to be reported")
+	}
+}
+
+func TestRegistrarAccountSettings(t *testing.T) {
+	if _, err := parseRegistrarAccounts("cloudflare,godaddy"); err == nil {
+		t.Error("Expected an unknown registrar to be rejected")
+	}
+	for _, provider := range registrarProviders {
+		if _, err := newRegistrarAccount(provider, Config{}, RegistrarAccount{}); err == nil {
+			t.Errorf("Expected %s without its settings to be rejected", provider)
+		}
+	}
+	providers := enrichmentProviders(Config{RegistrarAccounts: "namecheap"})
+	if len(providers) != 1 || providers[0] != "namecheap" {
+		t.Errorf("Expected the registrar's key to be resolved, got %v", providers)
+	}
+}
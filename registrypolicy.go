@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// registryPolicy is the query etiquette a registry's WHOIS server expects.
+// Exceeding it gets the source address banned for a while.
+type registryPolicy struct {
+	// PerMinute is the most queries per minute the registry tolerates
+	PerMinute int
+	// Delay is the least time between two queries
+	Delay time.Duration
+	// Ban describes how the registry treats clients over the limit
+	Ban string
+}
+
+// registryPolicies are the bundled policies of registries known to ban
+// aggressive clients, by top-level label. The limits are conservative: one
+// scanner staying within them does not trip the registry's throttling.
+var registryPolicies = map[string]registryPolicy{
+	"at": {PerMinute: 20, Delay: 3 * time.Second, Ban: "refuses queries for the rest of the hour"},
+	"au": {PerMinute: 20, Delay: 3 * time.Second, Ban: "answers \"BLOCKED\" for the rest of the day"},
+	"be": {PerMinute: 20, Delay: 3 * time.Second, Ban: "answers \"Excessive querying\" and blocks the address for 30 minutes"},
+	"br": {PerMinute: 10, Delay: 6 * time.Second, Ban: "answers \"query rate limit exceeded\" for an hour"},
+	"ch": {PerMinute: 10, Delay: 6 * time.Second, Ban: "answers \"Requests of this client are not permitted\" for a day"},
+	"cn": {PerMinute: 20, Delay: 3 * time.Second, Ban: "drops connections for hours"},
+	"de": {PerMinute: 30, Delay: 2 * time.Second, Ban: "answers \"access control limit exceeded\" for the rest of the hour"},
+	"es": {PerMinute: 10, Delay: 6 * time.Second, Ban: "refuses connections for the rest of the day"},
+	"eu": {PerMinute: 20, Delay: 3 * time.Second, Ban: "answers \"Excessive querying\" and blocks the address for 30 minutes"},
+	"fr": {PerMinute: 30, Delay: 2 * time.Second, Ban: "answers \"Too many requests\" for the rest of the hour"},
+	"it": {PerMinute: 10, Delay: 6 * time.Second, Ban: "answers \"exceeded max number of queries allowed\" for the rest of the day"},
+	"jp": {PerMinute: 20, Delay: 3 * time.Second, Ban: "blocks the address for hours"},
+	"nl": {PerMinute: 15, Delay: 4 * time.Second, Ban: "answers \"maximum query limit exceeded\" for the rest of the day"},
+	"pl": {PerMinute: 20, Delay: 3 * time.Second, Ban: "answers \"request limit exceeded\" for the rest of the hour"},
+	"ru": {PerMinute: 15, Delay: 4 * time.Second, Ban: "answers \"You have exceeded allowed connection rate\" and blocks the address for hours"},
+	"se": {PerMinute: 20, Delay: 3 * time.Second, Ban: "refuses connections for the rest of the hour"},
+	"uk": {PerMinute: 30, Delay: 2 * time.Second, Ban: "answers \"query rate exceeded\" and blocks the address for the day"},
+}
+
+// limit returns the token rate satisfying both the per-minute cap and the
+// least delay of the policy
+func (p registryPolicy) limit() rate.Limit {
+	interval := p.Delay
+	if p.PerMinute > 0 {
+		if perQuery := time.Minute / time.Duration(p.PerMinute); perQuery > interval {
+			interval = perQuery
+		}
+	}
+	if interval <= 0 {
+		return rate.Inf
+	}
+	return rate.Every(interval)
+}
+
+// registryPolicyFor returns the policy of the registry serving domain
+func registryPolicyFor(domain string) (registryPolicy, bool) {
+	policy, ok := registryPolicies[lastLabel(domain)]
+	return policy, ok
+}
+
+// describeRegistryPolicies lists the policies that will slow down a scan of
+// the candidates, one line per registry in TLD order
+func describeRegistryPolicies(candidates candidateSeq) []string {
+	seen := make(map[string]bool)
+	var lines []string
+	candidates.each(func(domain string) bool {
+		tld := lastLabel(domain)
+		policy, ok := registryPolicies[tld]
+		if !ok || seen[tld] {
+			return true
+		}
+		seen[tld] = true
+		lines = append(lines, fmt.Sprintf(".%s: at most %d queries/min, %s apart (%s)", tld, policy.PerMinute, policy.Delay, policy.Ban))
+		return true
+	})
+	sort.Strings(lines)
+	return lines
+}
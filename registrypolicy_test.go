@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRegistryPolicyLimit(t *testing.T) {
+	tests := []struct {
+		policy registryPolicy
+		want   rate.Limit
+	}{
+		{registryPolicy{PerMinute: 30, Delay: 2 * time.Second}, rate.Every(2 * time.Second)},
+		{registryPolicy{PerMinute: 30, Delay: 5 * time.Second}, rate.Every(5 * time.Second)},
+		{registryPolicy{PerMinute: 10}, rate.Every(6 * time.Second)},
+		{registryPolicy{}, rate.Inf},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.limit(); got != tt.want {
+			t.Errorf("limit(%+v) = %v, expected %v", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterEnforcesRegistryPolicies(t *testing.T) {
+	limiter := newRateLimiter(0, 1, "global")
+	if limiter.registryBucket("example.de") != nil {
+		t.Fatal("Expected no registry bucket with policies off")
+	}
+
+	limiter.policies = true
+	de := limiter.registryBucket("example.de")
+	if de == nil || de.Limit() != registryPolicies["de"].limit() {
+		t.Fatalf("Expected the .de policy bucket, got %v", de)
+	}
+	if limiter.registryBucket("other.de") != de {
+		t.Error("Expected one bucket per registry")
+	}
+	if !de.Allow() || de.Allow() {
+		t.Error("Expected a single query before the policy delay")
+	}
+	if limiter.registryBucket("example.com") != nil {
+		t.Error("Expected no bucket for a registry without a policy")
+	}
+}
+
+func TestDescribeRegistryPolicies(t *testing.T) {
+	got := describeRegistryPolicies(sliceCandidates([]string{"example.it", "example.com", "example.de", "example2.de"}))
+	expected := []string{
+		`.de: at most 30 queries/min, 2s apart (answers "access control limit exceeded" for the rest of the hour)`,
+		`.it: at most 10 queries/min, 6s apart (answers "exceeded max number of queries allowed" for the rest of the day)`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("describeRegistryPolicies() = %q, expected %q", got, expected)
+	}
+}
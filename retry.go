@@ -0,0 +1,219 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// readResult loads a result file written with -format json, compressed or
+// not. Encrypted results must be decrypted first.
+func readResult(path string) (Result, error) {
+	var result Result
+	if strings.HasSuffix(path, encryptedExt) {
+		return result, fmt.Errorf("%s is encrypted; decrypt it with age -d first", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return result, fmt.Errorf("failed to open result file: %w", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return result, fmt.Errorf("failed to read compressed result file: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return result, fmt.Errorf("failed to parse result file %s: %w", path, err)
+	}
+	if result.TargetDomain == "" {
+		return result, fmt.Errorf("%s is not a scan result file", path)
+	}
+	return result, nil
+}
+
+// retryDomains returns the domains of a result worth looking up again: the
+// ones skipped by the query budget and the failed ones with a retryable
+// error code, or every failed one with allErrors
+func retryDomains(result Result, allErrors bool) []string {
+	domains := append([]string{}, result.SkippedDomains...)
+	for _, info := range result.AllDomains {
+		code := info.errorCode()
+		if code != "" && (allErrors || code.Retryable()) {
+			domains = append(domains, info.Domain)
+		}
+	}
+	return domains
+}
+
+// mergeRetried replaces the failed entries of result with their retried
+// outcomes, adds the previously skipped domains and recomputes the totals
+func mergeRetried(result *Result, allResults, matchingResults, signalResults []DomainInfo, skipped scanSkips) {
+	retried := make(map[string]DomainInfo, len(allResults))
+	for _, info := range allResults {
+		retried[info.Domain] = info
+	}
+	for i, info := range result.AllDomains {
+		if r, ok := retried[info.Domain]; ok {
+			result.AllDomains[i] = r
+			delete(retried, info.Domain)
+		}
+	}
+	for _, info := range allResults {
+		if _, added := retried[info.Domain]; added {
+			result.AllDomains = append(result.AllDomains, info)
+		}
+	}
+	result.MatchingDomains = append(result.MatchingDomains, matchingResults...)
+	result.SignalDomains = append(result.SignalDomains, signalResults...)
+	sortResults(result.AllDomains, result.MatchingDomains, result.SignalDomains)
+
+	result.SchemaVersion = SchemaVersion
+	result.Scanner = currentBuildInfo()
+	result.SkippedDomains = skipped.domains
+	result.Truncated = skipped.truncated
+	result.Aborted = skipped.aborted
+	result.TotalScanned += len(retried)
+	result.TotalMatches = len(result.MatchingDomains)
+	result.TotalSignals = len(result.SignalDomains)
+	result.TotalSkipped = len(skipped.domains)
+	summarizeErrors(result, result.AllDomains)
+	summarizeQuality(result, result.AllDomains)
+}
+
+// runRetry implements `tldscanner retry <results.json>`: only the domains
+// that failed in a previous scan are looked up again and their outcomes are
+// merged into the result, which is written back in place unless -o is given
+func runRetry(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("retry", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	allErrors := fs.Bool("all-errors", false, "Also retry domains that failed with nxdomain, no_whois_server or parse_error")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s retry [OPTIONS] <results.json>\n\n", os.Args[0])
+		fmt.Printf("Looks up again the domains that failed in a previous scan and merges the\n")
+		fmt.Printf("outcomes into the result. The result must have been saved as JSON with\n")
+		fmt.Printf("-all. Without -o the result file is updated in place. Scan options are\n")
+		fmt.Printf("the same as for a regular scan; -d is taken from the result.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	applyImpliedFlags(&config)
+
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+
+	path := fs.Arg(0)
+	result, err := readResult(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if len(result.AllDomains) == 0 && result.TotalErrors > 0 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %s has no all_domains; retry needs a result saved with -all\n", ColorRed, ColorReset, path)
+		return ExitUsage
+	}
+
+	config.Domain = result.TargetDomain
+	config.Targets = nil
+	for _, target := range result.GroupTargets {
+		config.Targets = append(config.Targets, target.Domain)
+	}
+	if config.Output == "" && config.OutputAll == "" {
+		if config.Filter != "" {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s -filter would drop domains from %s; write the filtered result elsewhere with -o\n", ColorRed, ColorReset, path)
+			return ExitUsage
+		}
+		config.Output = path
+		config.Format = "json"
+	}
+	if config.Monitor {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s retry does not support -monitor\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := resolveCredentials(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := startDiagnostics(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if config.pipedOutput() {
+		os.Stdout = os.Stderr
+	}
+	printBanner()
+
+	domains := retryDomains(result, *allErrors)
+	if len(domains) == 0 {
+		fmt.Printf("%s[INFO]%s No failed domains to retry in %s\n", ColorBlue, ColorReset, path)
+		return exitCode(result, config.ErrorThreshold)
+	}
+
+	timing := newScanTiming()
+	startScan(&config, timing)
+	lookupGroup(&config)
+	targetInfo, err := lookupTarget(&config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	timing.stage("target_lookup")
+
+	fmt.Printf("%s[INFO]%s Retrying %d of %d failed and skipped domains with %d threads...\n", ColorBlue, ColorReset, len(domains), result.TotalErrors+result.TotalSkipped, config.Threads)
+	allResults, matchingResults, signalResults, skipped := scanDomains(domains, targetInfo, config)
+	timing.stage("lookups")
+	// A retry reads no domain list, so the metadata is carried over
+	carryMetadata(result.AllDomains, allResults, matchingResults, signalResults)
+	fmt.Printf("%s[INFO]%s Recovered %d domains, %d new matches\n", ColorBlue, ColorReset, len(allResults)-countErrors(allResults), len(matchingResults))
+
+	if config.Risk {
+		lookalikes := lookalikesOf(allResults, matchingResults)
+		scoreLookalikes(lookalikes, targetInfo, brandKeywords(config), false, config)
+		result.Lookalikes = append(result.Lookalikes, lookalikes...)
+		sortByRisk(result.Lookalikes)
+		result.TotalLookalikes = len(result.Lookalikes)
+		timing.stage("risk_scoring")
+	}
+	mergeRetried(&result, allResults, matchingResults, signalResults, skipped)
+	finishResult(&result, allResults, targetInfo, timing, config)
+
+	if config.History {
+		recordHistory(result, allResults, timing.StartedAt, config)
+	}
+	outputStarted := time.Now()
+	writeOutput(result, config)
+	exportTelemetry(result, outputStarted)
+	printSummary(result)
+
+	return exitCode(result, config.ErrorThreshold)
+}
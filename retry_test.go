@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRetryDomains(t *testing.T) {
+	result := Result{AllDomains: []DomainInfo{
+		{Domain: "example.com", Organization: "Example Inc"},
+		{Domain: "example.de", ErrorCode: ErrTimeout, Error: "i/o timeout"},
+		{Domain: "example.io", ErrorCode: ErrNXDomain, Error: "domain is not found"},
+		{Domain: "example.ru", Error: "rate limit exceeded"},
+	}}
+
+	if got := retryDomains(result, false); !reflect.DeepEqual(got, []string{"example.de", "example.ru"}) {
+		t.Errorf("retryDomains = %v", got)
+	}
+	if got := retryDomains(result, true); len(got) != 3 {
+		t.Errorf("retryDomains with all errors = %v", got)
+	}
+
+	result.SkippedDomains = []string{"example.fr"}
+	if got := retryDomains(result, false); !reflect.DeepEqual(got, []string{"example.fr", "example.de", "example.ru"}) {
+		t.Errorf("retryDomains with skipped domains = %v", got)
+	}
+}
+
+func TestMergeRetried(t *testing.T) {
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.com", Organization: "Example Inc"}},
+		AllDomains: []DomainInfo{
+			{Domain: "example.com", Organization: "Example Inc"},
+			{Domain: "example.de", ErrorCode: ErrTimeout, Error: "i/o timeout"},
+			{Domain: "example.ru", ErrorCode: ErrTimeout, Error: "i/o timeout"},
+		},
+		TotalMatches: 1,
+		TotalErrors:  2,
+	}
+	retried := []DomainInfo{
+		{Domain: "example.de", Organization: "Example Inc"},
+		{Domain: "example.ru", ErrorCode: ErrRateLimited, Error: "rate limit exceeded"},
+	}
+	mergeRetried(&result, retried, retried[:1], nil, scanSkips{})
+
+	if result.TotalMatches != 2 || result.MatchingDomains[1].Domain != "example.de" {
+		t.Errorf("Unexpected matches %+v", result.MatchingDomains)
+	}
+	if result.AllDomains[1].Organization != "Example Inc" || result.AllDomains[1].Error != "" {
+		t.Errorf("Retried domain not merged: %+v", result.AllDomains[1])
+	}
+	if result.TotalErrors != 1 || !reflect.DeepEqual(result.ErrorsByType, map[string]int{"rate_limited": 1}) {
+		t.Errorf("Unexpected errors %d %v", result.TotalErrors, result.ErrorsByType)
+	}
+}
+
+func TestMergeRetriedSkipped(t *testing.T) {
+	result := Result{
+		AllDomains:     []DomainInfo{{Domain: "example.com", Organization: "Example Inc"}},
+		SkippedDomains: []string{"example.fr", "example.it"},
+		TotalScanned:   1,
+		TotalSkipped:   2,
+	}
+	mergeRetried(&result, []DomainInfo{{Domain: "example.fr"}}, nil, nil, scanSkips{domains: []string{"example.it"}})
+
+	if len(result.AllDomains) != 2 || result.AllDomains[1].Domain != "example.fr" {
+		t.Errorf("Skipped domain not added: %+v", result.AllDomains)
+	}
+	if result.TotalScanned != 2 || result.TotalSkipped != 1 || !reflect.DeepEqual(result.SkippedDomains, []string{"example.it"}) {
+		t.Errorf("Unexpected totals: scanned %d, skipped %v", result.TotalScanned, result.SkippedDomains)
+	}
+}
+
+func TestReadResult(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.json")
+	data, _ := json.Marshal(Result{TargetDomain: "example.com", TotalErrors: 1})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := readResult(path)
+	if err != nil || result.TargetDomain != "example.com" {
+		t.Errorf("readResult = %+v, %v", result, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"domain": "example.com"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readResult(path); err == nil {
+		t.Error("Expected an error for a file that is not a result")
+	}
+}
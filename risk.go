@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// waybackAvailableURL is the Wayback Machine availability API, overridable
+// in tests
+var waybackAvailableURL = "https://archive.org/wayback/available"
+
+// recentRegistration is the age below which a registration counts as recent
+const recentRegistration = 90 * 24 * time.Hour
+
+// Risk factor weights. Without cloned_content and phishing_indicators they
+// add up to 100; a near-clone of the target's homepage or a page harvesting
+// credentials scores high on its own, and scores are capped at 100.
+const (
+	riskClone        = 40
+	riskPhishing     = 30
+	riskRecent       = 25
+	riskNoArchive    = 10
+	riskLive         = 10
+	riskKeywords     = 25
+	riskMX           = 15
+	riskPrivacy      = 10
+	riskForeignOwner = 5
+)
+
+// privacyPattern matches registrant organizations of WHOIS privacy and
+// proxy services and redacted records
+var privacyPattern = regexp.MustCompile(`(?i)privacy|proxy|redacted|whoisguard|withheld|not disclosed|data protected|identity protect|domains by proxy|gdpr`)
+
+// RiskFactor is one piece of evidence contributing to a risk score
+type RiskFactor struct {
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// riskEvidence holds the lookups made for scoring beyond WHOIS and the HTTP
+// probe
+type riskEvidence struct {
+	MX []string
+	// Archived is nil when the Wayback Machine could not be queried
+	Archived *bool
+}
+
+// assessRisk sets the risk factors and 0-100 risk score of a lookalike not
+// owned by the target
+func assessRisk(info *DomainInfo, target *DomainInfo, evidence riskEvidence, config Config, now time.Time) {
+	var factors []RiskFactor
+	add := func(name string, points int, detail string) {
+		factors = append(factors, RiskFactor{Name: name, Points: points, Detail: detail})
+	}
+
+	if created, ok := info.created(); ok && now.Sub(created) < recentRegistration {
+		add("recent_registration", riskRecent, fmt.Sprintf("registered %d days ago", int(now.Sub(created).Hours()/24)))
+	}
+	if evidence.Archived != nil && !*evidence.Archived {
+		add("no_archive_history", riskNoArchive, "never captured by the Wayback Machine")
+	}
+	if info.ContentSimilarity >= cloneSimilarity {
+		add("cloned_content", riskClone, fmt.Sprintf("%.0f%% similar to the target's homepage", info.ContentSimilarity*100))
+	}
+	if info.HTTP.Live() {
+		add("live_http", riskLive, fmt.Sprintf("HTTP %d %s", info.HTTP.StatusCode, info.HTTP.URL))
+		if len(info.HTTP.KeywordHits) > 0 {
+			add("brand_keywords", riskKeywords, strings.Join(info.HTTP.KeywordHits, ", "))
+		}
+		if len(info.Phishing) > 0 {
+			add("phishing_indicators", riskPhishing, strings.Join(info.Phishing, ", "))
+		}
+	}
+	if len(evidence.MX) > 0 {
+		detail := strings.Join(evidence.MX, ", ")
+		if info.MailCapable {
+			detail += ", accepting SMTP connections"
+		}
+		add("mx_present", riskMX, detail)
+	}
+	if info.Organization == "" || privacyPattern.MatchString(info.Organization) {
+		add("privacy_protected", riskPrivacy, firstNonEmpty(info.Organization, "registrant not published"))
+	} else if target != nil && !orgsMatch(info.Organization, target.Organization, config) {
+		add("foreign_registrant", riskForeignOwner, info.Organization)
+	}
+	if flaggedCountries(config.FlagCountries)[info.Country] {
+		add("flagged_country", riskFlaggedCountry, "registrant in "+info.Country)
+	}
+
+	score := 0
+	for _, factor := range factors {
+		score += factor.Points
+	}
+	if score > 100 {
+		score = 100
+	}
+	info.RiskFactors = factors
+	info.RiskScore = score
+}
+
+// gatherRiskEvidence looks up the MX records and Wayback Machine history of
+// a domain
+func gatherRiskEvidence(domain string, timeout time.Duration) riskEvidence {
+	var evidence riskEvidence
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if records, err := net.DefaultResolver.LookupMX(ctx, domain); err == nil {
+		for _, mx := range records {
+			if host := strings.TrimSuffix(mx.Host, "."); host != "" {
+				evidence.MX = append(evidence.MX, host)
+			}
+		}
+	}
+
+	if archived, err := waybackArchived(domain, timeout); err == nil {
+		evidence.Archived = &archived
+	}
+	return evidence
+}
+
+// waybackArchived reports whether the Wayback Machine has any capture of
+// the domain
+func waybackArchived(domain string, timeout time.Duration) (bool, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(waybackAvailableURL + "?url=" + url.QueryEscape(domain))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var availability struct {
+		ArchivedSnapshots map[string]json.RawMessage `json:"archived_snapshots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&availability); err != nil {
+		return false, err
+	}
+	return len(availability.ArchivedSnapshots) > 0, nil
+}
+
+// lookalikesOf returns the registered candidates not owned by the target
+// and not ignored by -ignore-file
+func lookalikesOf(allResults, matchingResults []DomainInfo) []DomainInfo {
+	owned := make(map[string]bool)
+	for _, info := range matchingResults {
+		owned[info.Domain] = true
+	}
+	var lookalikes []DomainInfo
+	for _, info := range registeredDomains(allResults) {
+		if !owned[info.Domain] && info.Ignored == "" {
+			lookalikes = append(lookalikes, info)
+		}
+	}
+	return lookalikes
+}
+
+// scoreLookalikes probes every lookalike over HTTP, compares the favicons
+// and page text of live ones with the target's homepage, classifies parked
+// ones from their DNS and page, takes urlscan.io
+// screenshots of live ones when enabled, scores them and sorts them by
+// descending risk
+func scoreLookalikes(lookalikes []DomainInfo, target *DomainInfo, keywords []string, screenshots bool, config Config) {
+	timeout := time.Duration(config.Timeout) * time.Second
+	sem := make(chan struct{}, config.stageThreads(config.ScoreThreads))
+	var wg sync.WaitGroup
+	var targetPage *HTTPProbe
+	if target != nil {
+		targetPage = probeHTTP(target.Domain, nil, timeout)
+	}
+	targetHash, haveFavicon := targetFavicon(targetPage, timeout)
+
+	for i := range lookalikes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(info *DomainInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			started := time.Now()
+			defer func() { config.Stats.add(statsScore, time.Since(started)) }()
+			info.HTTP = probeHTTP(info.Domain, keywords, timeout)
+			info.Phishing = info.HTTP.phishing
+			info.Language = info.HTTP.language
+			if haveFavicon {
+				compareFavicon(info, targetPage, targetHash, timeout)
+			}
+			compareContent(info, targetPage)
+			classifyParking(info, lookupParkingEvidence(info.Domain, timeout))
+			if screenshots && info.HTTP.Live() {
+				if err := enrichURLScan(info, config); err != nil {
+					info.EnrichmentErrors = append(info.EnrichmentErrors, fmt.Sprintf("urlscan: %v", err))
+				}
+			}
+			if config.DNSSEC {
+				if err := enrichDNSSEC(info, config); err != nil {
+					info.EnrichmentErrors = append(info.EnrichmentErrors, fmt.Sprintf("dnssec: %v", err))
+				}
+			}
+			if config.CAA {
+				if err := enrichCAA(info, config); err != nil {
+					info.EnrichmentErrors = append(info.EnrichmentErrors, fmt.Sprintf("caa: %v", err))
+				}
+			}
+			if config.SMTPProbe {
+				if err := enrichMail(info, config); err != nil {
+					info.EnrichmentErrors = append(info.EnrichmentErrors, fmt.Sprintf("smtp-probe: %v", err))
+				}
+			}
+			assessRisk(info, target, gatherRiskEvidence(info.Domain, timeout), config, time.Now())
+		}(&lookalikes[i])
+	}
+	wg.Wait()
+
+	sortByRisk(lookalikes)
+}
+
+// sortByRisk orders domains by descending risk score, then by name
+func sortByRisk(domains []DomainInfo) {
+	sort.SliceStable(domains, func(i, j int) bool {
+		if domains[i].RiskScore != domains[j].RiskScore {
+			return domains[i].RiskScore > domains[j].RiskScore
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAssessRisk(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	target := &DomainInfo{Organization: "Acme Corp"}
+	archived, unarchived := true, false
+
+	phish := DomainInfo{
+		Organization: "REDACTED FOR PRIVACY",
+		CreatedDate:  "2024-05-20T00:00:00Z",
+		HTTP:         &HTTPProbe{URL: "https://acme-login.com/", StatusCode: 200, KeywordHits: []string{"acme"}},
+	}
+	assessRisk(&phish, target, riskEvidence{MX: []string{"mx.acme-login.com"}, Archived: &unarchived}, Config{}, now)
+	if phish.RiskScore != 95 {
+		t.Errorf("Expected 95, got %d: %+v", phish.RiskScore, phish.RiskFactors)
+	}
+
+	parked := DomainInfo{Organization: "Other Holdings", CreatedDate: "2010-01-01"}
+	assessRisk(&parked, target, riskEvidence{Archived: &archived}, Config{}, now)
+	if parked.RiskScore != riskForeignOwner || len(parked.RiskFactors) != 1 || parked.RiskFactors[0].Name != "foreign_registrant" {
+		t.Errorf("Expected only foreign_registrant, got %+v", parked.RiskFactors)
+	}
+
+	clone := DomainInfo{Organization: "Acme Corp", CreatedDate: "2010-01-01", ContentSimilarity: 0.93}
+	assessRisk(&clone, target, riskEvidence{Archived: &archived}, Config{}, now)
+	if clone.RiskScore != riskClone || clone.RiskFactors[0].Name != "cloned_content" {
+		t.Errorf("Expected only cloned_content, got %+v", clone.RiskFactors)
+	}
+
+	unknown := DomainInfo{Organization: "Other Holdings"}
+	assessRisk(&unknown, target, riskEvidence{}, Config{}, now)
+	for _, factor := range unknown.RiskFactors {
+		if factor.Name == "no_archive_history" {
+			t.Error("An unavailable archive lookup must not count as no history")
+		}
+	}
+}
+
+func TestWaybackArchived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("url") == "old.example" {
+			w.Write([]byte(`{"url":"old.example","archived_snapshots":{"closest":{"available":true,"status":"200"}}}`))
+			return
+		}
+		w.Write([]byte(`{"url":"new.example","archived_snapshots":{}}`))
+	}))
+	defer server.Close()
+	defer func(old string) { waybackAvailableURL = old }(waybackAvailableURL)
+	waybackAvailableURL = server.URL
+
+	for domain, want := range map[string]bool{"old.example": true, "new.example": false} {
+		archived, err := waybackArchived(domain, 5*time.Second)
+		if err != nil || archived != want {
+			t.Errorf("waybackArchived(%s) = %v, %v; expected %v", domain, archived, err, want)
+		}
+	}
+}
+
+func TestLookalikesOf(t *testing.T) {
+	all := []DomainInfo{{Domain: "acme.net"}, {Domain: "acme.io"}, {Domain: "acme.dev", Error: "not found"}}
+	lookalikes := lookalikesOf(all, []DomainInfo{{Domain: "acme.net"}})
+	if len(lookalikes) != 1 || lookalikes[0].Domain != "acme.io" {
+		t.Errorf("Expected only acme.io, got %v", lookalikes)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/likexian/whois"
+	whoisparser "github.com/likexian/whois-parser"
+)
+
+// ErrorCode classifies why a domain lookup failed
+type ErrorCode string
+
+// Lookup error codes
+const (
+	ErrTimeout       ErrorCode = "timeout"
+	ErrRateLimited   ErrorCode = "rate_limited"
+	ErrNXDomain      ErrorCode = "nxdomain"
+	ErrNoWhoisServer ErrorCode = "no_whois_server"
+	ErrParse         ErrorCode = "parse_error"
+	ErrNetwork       ErrorCode = "network"
+	ErrOther         ErrorCode = "other"
+)
+
+// Retryable reports whether a lookup failing with this code may succeed
+// when repeated later
+func (c ErrorCode) Retryable() bool {
+	switch c {
+	case ErrTimeout, ErrRateLimited, ErrNetwork, ErrOther:
+		return true
+	}
+	return false
+}
+
+// errorCodeOf classifies a lookup error by its cause, falling back to the
+// message for errors without a known cause. Combined WHOIS and RDAP
+// fallback errors are classified by the WHOIS cause.
+func errorCodeOf(err error) ErrorCode {
+	var netErr net.Error
+	var opErr *net.OpError
+	var cached *cachedError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &cached):
+		return cached.code
+	case errors.Is(err, whoisparser.ErrNotFoundDomain), errors.Is(err, errRDAPNotFound):
+		return ErrNXDomain
+	case errors.Is(err, whoisparser.ErrDomainLimitExceed):
+		return ErrRateLimited
+	case errors.Is(err, whois.ErrWhoisServerNotFound):
+		return ErrNoWhoisServer
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case errors.Is(err, whoisparser.ErrDomainDataInvalid):
+		return ErrParse
+	case errors.As(err, &opErr):
+		return ErrNetwork
+	}
+	return classifyError(err.Error())
+}
+
+// classifyError maps a lookup error message to its code, for errors only
+// known by their text such as those read back from result files
+func classifyError(errMsg string) ErrorCode {
+	lower := strings.ToLower(errMsg)
+	switch {
+	case errMsg == "":
+		return ""
+	case strings.Contains(lower, "domain is not found"), strings.Contains(lower, "rdap: domain not found"):
+		return ErrNXDomain
+	case strings.Contains(lower, "timeout"), strings.Contains(lower, "deadline exceeded"):
+		return ErrTimeout
+	case strings.Contains(lower, "connection refused"), strings.Contains(lower, "connection reset"),
+		strings.Contains(lower, "no such host"), strings.Contains(lower, "network is unreachable"):
+		return ErrNetwork
+	case isRateLimitError(errMsg):
+		return ErrRateLimited
+	case strings.Contains(lower, "no whois server"):
+		return ErrNoWhoisServer
+	case strings.Contains(lower, "parsing failed"):
+		return ErrParse
+	}
+	return ErrOther
+}
+
+// errorCode returns the domain's error code, classifying the message of
+// results recorded before error codes existed
+func (d DomainInfo) errorCode() ErrorCode {
+	if d.ErrorCode != "" || d.Error == "" {
+		return d.ErrorCode
+	}
+	return classifyError(d.Error)
+}
+
+// transientError returns the error message of a lookup that failed for a
+// reason that may go away, such as a timeout or throttling, and "" for
+// successes and definitive answers like an unregistered domain. Auto-tuning
+// backs off on these only.
+func (d DomainInfo) transientError() string {
+	if d.Error == "" || !d.errorCode().Retryable() {
+		return ""
+	}
+	return d.Error
+}
+
+// summarizeErrors sets the error totals of result: overall, by error code
+// and by TLD
+func summarizeErrors(result *Result, domains []DomainInfo) {
+	result.TotalErrors = countErrors(domains)
+	if result.TotalErrors == 0 {
+		return
+	}
+	result.ErrorsByType = map[string]int{}
+	result.ErrorsByTLD = map[string]int{}
+	for _, domain := range domains {
+		if domain.Error == "" {
+			continue
+		}
+		result.ErrorsByType[string(domain.errorCode())]++
+		result.ErrorsByTLD["."+lastLabel(domain.Domain)]++
+	}
+}
+
+// failedLookups returns the number of lookups that failed for operational
+// reasons such as timeouts, rate limits, connection or parse errors.
+// Unregistered domains are the normal outcome for most candidates and do
+// not count.
+func (r Result) failedLookups() int {
+	return r.TotalErrors - r.ErrorsByType[string(ErrNXDomain)]
+}
+
+// formatCounts renders counts as "timeout 120, rate_limited 80", largest
+// first, keeping at most limit entries (0 for all)
+func formatCounts(counts map[string]int, limit int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	var parts []string
+	for i, key := range keys {
+		if limit > 0 && i == limit {
+			parts = append(parts, fmt.Sprintf("%d more", len(keys)-limit))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", key, counts[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// errorsLog appends each failed domain to the -errors-file as soon as it
+// fails, so that a crashed or interrupted scan still leaves a list to feed
+// back with -domains-file
+type errorsLog struct {
+	file *os.File
+}
+
+// openErrorsLog opens the -errors-file for appending; no path gives a nil
+// log that records nothing
+func openErrorsLog(path string) (*errorsLog, error) {
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open errors file: %w", err)
+	}
+	return &errorsLog{file: file}, nil
+}
+
+// record writes a failed domain and its error code as one unbuffered line.
+// Unregistered domains did not fail and are not written.
+func (l *errorsLog) record(info DomainInfo) error {
+	if l == nil || info.errorCode() == ErrNXDomain {
+		return nil
+	}
+	_, err := fmt.Fprintf(l.file, "%s\t%s\n", info.Domain, info.errorCode())
+	return err
+}
+
+// Close closes the errors file
+func (l *errorsLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	whoisparser "github.com/likexian/whois-parser"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		errMsg   string
+		expected ErrorCode
+	}{
+		{"", ""},
+		{"whois parsing failed (server whois.nic.io): whoisparser: domain is not found", ErrNXDomain},
+		{"whois query failed: whois.nic.ru: dial tcp 1.2.3.4:43: i/o timeout", ErrTimeout},
+		{"whois query failed: context deadline exceeded", ErrTimeout},
+		{"whois query failed: dial tcp 1.2.3.4:43: connect: connection refused", ErrNetwork},
+		{"whois parsing failed (server whois.nic.de): whoisparser: domain query limit exceeded", ErrRateLimited},
+		{"whois query failed: whois: no whois server found for domain: example.zz", ErrNoWhoisServer},
+		{"whois parsing failed (server whois.nic.xx): whoisparser: domain whois data is invalid; rdap fallback: no rdap server for .xx", ErrParse},
+		{"something else", ErrOther},
+	}
+	for _, tc := range testCases {
+		if got := classifyError(tc.errMsg); got != tc.expected {
+			t.Errorf("classifyError(%q) = %q; expected %q", tc.errMsg, got, tc.expected)
+		}
+	}
+}
+
+func TestErrorCodeOf(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected ErrorCode
+	}{
+		{nil, ""},
+		{fmt.Errorf("whois parsing failed: %w", whoisparser.ErrNotFoundDomain), ErrNXDomain},
+		{fmt.Errorf("rdap fallback: %w", errRDAPNotFound), ErrNXDomain},
+		{fmt.Errorf("whois parsing failed: %w", whoisparser.ErrDomainLimitExceed), ErrRateLimited},
+		{fmt.Errorf("whois query failed: %w", context.DeadlineExceeded), ErrTimeout},
+		{fmt.Errorf("whois parsing failed: %w", whoisparser.ErrDomainDataInvalid), ErrParse},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrNetwork},
+		{errors.New("429 too many requests"), ErrRateLimited},
+	}
+	for _, tc := range testCases {
+		if got := errorCodeOf(tc.err); got != tc.expected {
+			t.Errorf("errorCodeOf(%v) = %q; expected %q", tc.err, got, tc.expected)
+		}
+	}
+
+	if ErrNXDomain.Retryable() || ErrParse.Retryable() || !ErrTimeout.Retryable() || !ErrRateLimited.Retryable() {
+		t.Error("Unexpected Retryable result")
+	}
+}
+
+func TestSummarizeErrors(t *testing.T) {
+	domains := []DomainInfo{
+		{Domain: "example.ru", Error: "read failed", ErrorCode: ErrTimeout},
+		{Domain: "example.co.uk", Error: "i/o timeout"},
+		{Domain: "example2.ru", Error: "rate limit exceeded"},
+		{Domain: "example.com"},
+	}
+	var result Result
+	summarizeErrors(&result, domains)
+
+	if result.TotalErrors != 3 {
+		t.Errorf("Expected 3 errors, got %d", result.TotalErrors)
+	}
+	if !reflect.DeepEqual(result.ErrorsByType, map[string]int{"timeout": 2, "rate_limited": 1}) {
+		t.Errorf("Unexpected errors by type %v", result.ErrorsByType)
+	}
+	if !reflect.DeepEqual(result.ErrorsByTLD, map[string]int{".ru": 2, ".uk": 1}) {
+		t.Errorf("Unexpected errors by TLD %v", result.ErrorsByTLD)
+	}
+	if got := formatCounts(result.ErrorsByTLD, 1); got != ".ru 2, 1 more" {
+		t.Errorf("formatCounts = %q", got)
+	}
+}
+
+func TestUnregisteredDomainsAreNotFailures(t *testing.T) {
+	domains := []DomainInfo{{Domain: "example.com", Organization: "Example Inc"}, {Domain: "example.ru", Error: "i/o timeout"}}
+	for _, tld := range []string{"de", "fr", "io", "co", "me", "shop", "xyz", "app"} {
+		domains = append(domains, DomainInfo{Domain: "example." + tld, Error: "whois parsing failed: domain is not found", ErrorCode: ErrNXDomain})
+	}
+	result := Result{TotalScanned: len(domains), TotalMatches: 1}
+	summarizeErrors(&result, domains)
+
+	if result.failedLookups() != 1 {
+		t.Errorf("Expected only the timeout to count as failed, got %d", result.failedLookups())
+	}
+	if code := exitCode(result, 0.5); code != ExitMatches {
+		t.Errorf("Expected a mostly unregistered scan to exit %d, got %d", ExitMatches, code)
+	}
+}
+
+func TestErrorsLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failed.txt")
+	failures, err := openErrorsLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failures.record(DomainInfo{Domain: "example.de", ErrorCode: ErrTimeout, Error: "i/o timeout"})
+	failures.record(DomainInfo{Domain: "example.ru", Error: "rate limit exceeded"})
+	failures.record(DomainInfo{Domain: "example.fr", ErrorCode: ErrNXDomain, Error: "whois parsing failed: domain is not found"})
+	failures.Close()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "example.de\ttimeout\nexample.ru\trate_limited\n" {
+		t.Errorf("Unexpected errors file %q", data)
+	}
+	domains, _, err := parseDomainList(strings.NewReader(string(data)))
+	if err != nil || !reflect.DeepEqual(domains, []string{"example.de", "example.ru"}) {
+		t.Errorf("parseDomainList = %v, %v", domains, err)
+	}
+
+	var none *errorsLog
+	if err := none.record(DomainInfo{Domain: "example.de"}); err != nil {
+		t.Errorf("nil log returned %v", err)
+	}
+}
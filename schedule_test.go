@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	base := time.Date(2026, 10, 16, 12, 30, 0, 0, time.Local) // a Friday
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"0 3 * * *", time.Date(2026, 10, 17, 3, 0, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2026, 10, 16, 12, 45, 0, 0, time.Local)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 19, 9, 0, 0, 0, time.Local)},
+		{"0 0 1,15 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)},
+		// Both day fields restricted: either may match
+		{"0 0 13 * 6", time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, test := range tests {
+		sched, err := parseCron(test.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q) failed: %v", test.expr, err)
+			continue
+		}
+		if next := sched.next(base); !next.Equal(test.expected) {
+			t.Errorf("parseCron(%q).next() = %v; expected %v", test.expr, next, test.expected)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "0 3 * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "0 0 31 2 *", "0 0 30,31 2 *"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) should fail", expr)
+		}
+	}
+}
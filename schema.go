@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the JSON Result layout.
+//
+// Compatibility policy: the minor version is bumped when fields are added;
+// consumers must ignore unknown fields. The major version is bumped when a
+// field is renamed, removed or changes type.
+const SchemaVersion = "1.46"
+
+// jsonSchema returns the JSON Schema (draft 2020-12) describing Result
+func jsonSchema() map[string]interface{} {
+	schema := schemaForType(reflect.TypeOf(Result{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = fmt.Sprintf("https://github.com/vijay922/tldscanner/schema/result-%s.json", SchemaVersion)
+	schema["title"] = "TLD Scanner Result"
+	return schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaForType derives a JSON Schema fragment from a Go type using the same
+// field names and omitempty rules as encoding/json. Nil slices, maps and
+// pointers encode as null, so their fragments also allow null.
+func schemaForType(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		return nullable(schemaForType(t.Elem()))
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Array {
+			return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+		}
+		return nullable(map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())})
+	case reflect.Map:
+		return nullable(map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())})
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaForType(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// nullable widens a schema fragment to also accept null
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+	}
+	return schema
+}
+
+// runSchema implements `tldscanner schema`
+func runSchema(args []string) int {
+	data, err := json.MarshalIndent(jsonSchema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	fmt.Println(string(data))
+	return ExitMatches
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestJSONSchemaCoversResult(t *testing.T) {
+	schema := jsonSchema()
+
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Schema has no properties")
+	}
+
+	// Every key produced by encoding/json must be described by the schema
+	data, err := json.Marshal(Result{MatchingDomains: []DomainInfo{{}}})
+	if err != nil {
+		t.Fatalf("Failed to marshal Result: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Failed to unmarshal Result: %v", err)
+	}
+	for name := range fields {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("Schema is missing property %q", name)
+		}
+	}
+
+	domains := properties["matching_domains"].(map[string]interface{})
+	items := domains["items"].(map[string]interface{})
+	timestamp := items["properties"].(map[string]interface{})["timestamp"].(map[string]interface{})
+	if timestamp["format"] != "date-time" {
+		t.Errorf("timestamp should be described as date-time, got %v", timestamp)
+	}
+}
+
+func TestJSONSchemaRequired(t *testing.T) {
+	required := jsonSchema()["required"].([]string)
+	if !containsString(required, "schema_version") {
+		t.Error("schema_version should be required")
+	}
+	if containsString(required, "all_domains") {
+		t.Error("omitempty fields should not be required")
+	}
+}
+
+// validateSchema checks a decoded JSON value against the subset of JSON
+// Schema that jsonSchema produces
+func validateSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	var types []string
+	switch typ := schema["type"].(type) {
+	case string:
+		types = []string{typ}
+	case []string:
+		types = typ
+	}
+	if len(types) > 0 {
+		actual := "null"
+		switch v := value.(type) {
+		case bool:
+			actual = "boolean"
+		case string:
+			actual = "string"
+		case float64:
+			actual = "number"
+			if v == float64(int64(v)) {
+				actual = "integer"
+			}
+		case []interface{}:
+			actual = "array"
+		case map[string]interface{}:
+			actual = "object"
+		}
+		if !containsString(types, actual) && !(actual == "integer" && containsString(types, "number")) {
+			return []string{fmt.Sprintf("%s: expected %v, got %s", path, types, actual)}
+		}
+	}
+
+	var errs []string
+	switch v := value.(type) {
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				errs = append(errs, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]string); ok {
+			for _, name := range required {
+				if _, ok := v[name]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: missing required %q", path, name))
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		for name, field := range v {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				errs = append(errs, validateSchema(property, field, path+"."+name)...)
+			} else if additional != nil {
+				errs = append(errs, validateSchema(additional, field, path+"."+name)...)
+			}
+		}
+	}
+	return errs
+}
+
+func TestJSONSchemaValidatesResults(t *testing.T) {
+	results := map[string]Result{
+		// A scan that found nothing leaves its slices and maps nil
+		"empty": {SchemaVersion: SchemaVersion, TargetDomain: "example.com", TargetOrg: "Example Inc"},
+		"matches": {
+			SchemaVersion:   SchemaVersion,
+			TargetDomain:    "example.com",
+			MatchingDomains: []DomainInfo{{Domain: "example.io", Organization: "Example Inc", NameServers: []string{"ns1.example.com"}}},
+			TotalScanned:    1,
+			TotalMatches:    1,
+		},
+	}
+	for name, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatalf("Failed to marshal %s result: %v", name, err)
+		}
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range validateSchema(jsonSchema(), value, "$") {
+			t.Errorf("%s result: %s", name, err)
+		}
+	}
+}
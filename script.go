@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"go.starlark.net/starlark"
+)
+
+// maxScriptSteps bounds the work of one match() call so that a runaway
+// script cannot stall the scan
+const maxScriptSteps = 1000000
+
+// matchScript is a Starlark script loaded with -script. It must define
+//
+//	def match(domain, target): ...
+//
+// which receives the looked-up domain and the target as dicts with the JSON
+// field names and returns None to keep the built-in decision, a bool to
+// decide the match, or a dict with any of match (bool), score (number),
+// tags (list of strings) and reason (string).
+type matchScript struct {
+	path  string
+	match starlark.Callable
+}
+
+// scriptDecision is the outcome of a match() call
+type scriptDecision struct {
+	// Match is nil when the script leaves the decision to the built-in
+	// matching
+	Match  *bool
+	Score  float64
+	Tags   []string
+	Reason string
+}
+
+// loadMatchScript runs the script's top level once and looks up its match
+// function. The globals are frozen so match() can run on many lookups at
+// once.
+func loadMatchScript(path string) (*matchScript, error) {
+	if path == "" {
+		return nil, nil
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	thread := &starlark.Thread{Name: "load", Print: scriptPrint}
+	globals, err := starlark.ExecFile(thread, path, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: %w", path, err)
+	}
+	globals.Freeze()
+
+	match, ok := globals["match"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("script %s does not define a match(domain, target) function", path)
+	}
+	return &matchScript{path: path, match: match}, nil
+}
+
+func scriptPrint(thread *starlark.Thread, msg string) {
+	fmt.Fprintf(os.Stderr, "%s[SCRIPT]%s %s\n", ColorCyan, ColorReset, msg)
+}
+
+// decide calls match() for a looked-up domain
+func (s *matchScript) decide(info, target *DomainInfo) (scriptDecision, error) {
+	var decision scriptDecision
+	domainValue, err := toStarlark(info)
+	if err != nil {
+		return decision, err
+	}
+	targetValue, err := toStarlark(target)
+	if err != nil {
+		return decision, err
+	}
+
+	thread := &starlark.Thread{Name: info.Domain, Print: scriptPrint}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	value, err := starlark.Call(thread, s.match, starlark.Tuple{domainValue, targetValue}, nil)
+	if err != nil {
+		return decision, err
+	}
+
+	switch v := value.(type) {
+	case starlark.NoneType:
+		return decision, nil
+	case starlark.Bool:
+		match := bool(v)
+		decision.Match = &match
+		return decision, nil
+	case *starlark.Dict:
+		return dictDecision(v)
+	}
+	return decision, fmt.Errorf("match() returned %s, expected None, bool or dict", value.Type())
+}
+
+// dictDecision reads the match, score, tags and reason keys of a returned
+// dict
+func dictDecision(d *starlark.Dict) (scriptDecision, error) {
+	var decision scriptDecision
+	for _, item := range d.Items() {
+		key, _ := starlark.AsString(item[0])
+		value := item[1]
+		switch key {
+		case "match":
+			if value == starlark.None {
+				continue
+			}
+			match := bool(value.Truth())
+			decision.Match = &match
+		case "score":
+			score, ok := starlark.AsFloat(value)
+			if !ok {
+				return decision, fmt.Errorf("match() score must be a number, got %s", value.Type())
+			}
+			decision.Score = score
+		case "reason":
+			reason, ok := starlark.AsString(value)
+			if !ok {
+				return decision, fmt.Errorf("match() reason must be a string, got %s", value.Type())
+			}
+			decision.Reason = reason
+		case "tags":
+			list, ok := value.(*starlark.List)
+			if !ok {
+				return decision, fmt.Errorf("match() tags must be a list, got %s", value.Type())
+			}
+			for i := 0; i < list.Len(); i++ {
+				tag, ok := starlark.AsString(list.Index(i))
+				if !ok {
+					return decision, fmt.Errorf("match() tags must be strings, got %s", list.Index(i).Type())
+				}
+				decision.Tags = append(decision.Tags, tag)
+			}
+		default:
+			return decision, fmt.Errorf("match() returned unknown key %q", key)
+		}
+	}
+	return decision, nil
+}
+
+// applyScript lets the -script hook override the built-in match decision
+// of a domain and attach its score and tags. It returns whether the domain
+// matches.
+func applyScript(script *matchScript, info, target *DomainInfo, matched bool) bool {
+	decision, err := script.decide(info, target)
+	if err != nil {
+		info.EnrichmentErrors = append(info.EnrichmentErrors, fmt.Sprintf("script: %v", err))
+		return matched
+	}
+
+	info.Tags = append(info.Tags, decision.Tags...)
+	if decision.Score != 0 {
+		info.Signals = append(info.Signals, Signal{Name: "script", Score: decision.Score, Detail: decision.Reason})
+		sort.SliceStable(info.Signals, func(i, j int) bool {
+			return info.Signals[i].Score > info.Signals[j].Score
+		})
+	}
+	if decision.Match == nil || *decision.Match == matched {
+		return matched
+	}
+	if *decision.Match {
+		info.MatchReason = "script"
+		if decision.Reason != "" {
+			info.MatchReason = "script: " + decision.Reason
+		}
+	} else {
+		info.MatchReason = ""
+		info.MatchedEmail = ""
+	}
+	return *decision.Match
+}
+
+// toStarlark converts a value to Starlark dicts, lists and scalars through
+// its JSON form, so scripts see the same field names as JSON consumers
+func toStarlark(v interface{}) (starlark.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return jsonToStarlark(decoded), nil
+}
+
+func jsonToStarlark(v interface{}) starlark.Value {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		d := starlark.NewDict(len(v))
+		for _, key := range keys {
+			d.SetKey(starlark.String(key), jsonToStarlark(v[key]))
+		}
+		return d
+	case []interface{}:
+		elems := make([]starlark.Value, len(v))
+		for i, elem := range v {
+			elems[i] = jsonToStarlark(elem)
+		}
+		return starlark.NewList(elems)
+	case string:
+		return starlark.String(v)
+	case bool:
+		return starlark.Bool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return starlark.MakeInt64(int64(v))
+		}
+		return starlark.Float(v)
+	}
+	return starlark.None
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.starlark.net/starlark"
+)
+
+const testMatchScript = `
+def match(domain, target):
+    if domain["domain"].endswith(".ru"):
+        return False
+    if domain["registrar"] == "Brand Registrar LLC":
+        return {"match": True, "reason": "brand registrar", "tags": ["registrar"]}
+    if "login" in domain["domain"]:
+        return {"score": 0.9, "reason": "credential phishing keyword", "tags": ["phishing"]}
+    return None
+`
+
+func TestMatchScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "match.star")
+	if err := os.WriteFile(path, []byte(testMatchScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script, err := loadMatchScript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &DomainInfo{Domain: "example.com", Organization: "Example Inc"}
+
+	ru := &DomainInfo{Domain: "example.ru", Organization: "Example Inc", MatchReason: "organization"}
+	if applyScript(script, ru, target, true) || ru.MatchReason != "" {
+		t.Errorf("Expected the script to reject example.ru, got reason %q", ru.MatchReason)
+	}
+
+	registrar := &DomainInfo{Domain: "example.io", Registrar: "Brand Registrar LLC"}
+	if !applyScript(script, registrar, target, false) || registrar.MatchReason != "script: brand registrar" {
+		t.Errorf("Expected a script match, got reason %q", registrar.MatchReason)
+	}
+	if !reflect.DeepEqual(registrar.Tags, []string{"registrar"}) {
+		t.Errorf("Unexpected tags %v", registrar.Tags)
+	}
+
+	login := &DomainInfo{Domain: "example-login.com"}
+	if applyScript(script, login, target, false) {
+		t.Error("A scored domain should not match")
+	}
+	if len(login.Signals) != 1 || login.Signals[0].Name != "script" || login.Signals[0].Score != 0.9 {
+		t.Errorf("Unexpected signals %+v", login.Signals)
+	}
+
+	unchanged := &DomainInfo{Domain: "example.de", Organization: "Example Inc", MatchReason: "organization"}
+	if !applyScript(script, unchanged, target, true) || unchanged.MatchReason != "organization" {
+		t.Errorf("None should keep the built-in decision, got reason %q", unchanged.MatchReason)
+	}
+
+	if err := os.WriteFile(path, []byte("x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadMatchScript(path); err == nil {
+		t.Error("Expected an error for a script without match()")
+	}
+}
+
+func TestDictDecision(t *testing.T) {
+	d := starlark.NewDict(4)
+	d.SetKey(starlark.String("match"), starlark.Bool(true))
+	d.SetKey(starlark.String("score"), starlark.MakeInt(2))
+	d.SetKey(starlark.String("tags"), starlark.NewList([]starlark.Value{starlark.String("vip")}))
+	d.SetKey(starlark.String("reason"), starlark.String("allowlisted"))
+
+	decision, err := dictDecision(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Match == nil || !*decision.Match || decision.Score != 2 || decision.Reason != "allowlisted" ||
+		!reflect.DeepEqual(decision.Tags, []string{"vip"}) {
+		t.Errorf("Unexpected decision %+v", decision)
+	}
+
+	bad := starlark.NewDict(1)
+	bad.SetKey(starlark.String("verdict"), starlark.Bool(true))
+	if _, err := dictDecision(bad); err == nil {
+		t.Error("Expected an error for an unknown key")
+	}
+}
+
+func TestToStarlark(t *testing.T) {
+	value, err := toStarlark(&DomainInfo{Domain: "example.io", NameServers: []string{"ns1.example.io"}, RiskScore: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := value.(*starlark.Dict)
+	if !ok {
+		t.Fatalf("Expected a dict, got %s", value.Type())
+	}
+	fields := map[string]starlark.Value{}
+	for _, item := range d.Items() {
+		key, _ := starlark.AsString(item[0])
+		fields[key] = item[1]
+	}
+	if domain, _ := starlark.AsString(fields["domain"]); domain != "example.io" {
+		t.Errorf("domain = %v", fields["domain"])
+	}
+	if _, ok := fields["risk_score"].(starlark.Int); !ok {
+		t.Errorf("risk_score should be an int, got %v", fields["risk_score"])
+	}
+	if list, ok := fields["name_servers"].(*starlark.List); !ok || list.Len() != 1 {
+		t.Errorf("name_servers = %v", fields["name_servers"])
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// historyIndexBucket holds the full-text index of the history snapshots.
+// Keys are <field>:<term> NUL <domain> NUL <timestamp> with empty values,
+// so the snapshots containing a term, or a term prefix, are one cursor
+// range.
+var historyIndexBucket = []byte("index")
+
+// searchFields maps the field names accepted in search queries to the
+// snapshot values they index
+var searchFields = map[string]func(Snapshot) []string{
+	"domain":    func(s Snapshot) []string { return []string{s.Info.Domain, s.Info.UnicodeDomain} },
+	"target":    func(s Snapshot) []string { return []string{s.Target} },
+	"org":       func(s Snapshot) []string { return []string{s.Info.Organization, s.Info.OrganizationLatin} },
+	"registrar": func(s Snapshot) []string { return []string{s.Info.Registrar} },
+	"ns":        func(s Snapshot) []string { return s.Info.NameServers },
+	"email":     func(s Snapshot) []string { return append([]string{s.Info.AbuseEmail}, s.Info.Emails...) },
+	"status":    func(s Snapshot) []string { return []string{s.Info.Status} },
+	"server":    func(s Snapshot) []string { return []string{s.Info.WhoisServer} },
+	"tag":       func(s Snapshot) []string { return s.Info.Tags },
+}
+
+// searchFieldAliases are the longer spellings of the search fields
+var searchFieldAliases = map[string]string{
+	"organization": "org",
+	"nameserver":   "ns",
+	"nameservers":  "ns",
+	"emails":       "email",
+	"whois_server": "server",
+	"tags":         "tag",
+}
+
+// searchTerms splits a value into lowercase words. Values that are names
+// (domains, hosts, emails) are also indexed whole so they can be searched
+// exactly.
+func searchTerms(value string) []string {
+	value = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(value), "."))
+	if value == "" {
+		return nil
+	}
+	terms := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > 1 && !strings.ContainsAny(value, " \t") {
+		terms = append(terms, value)
+	}
+	return terms
+}
+
+// indexKey builds the index key of a term found in a snapshot
+func indexKey(field, term, domain string, key []byte) []byte {
+	return []byte(field + ":" + term + "\x00" + domain + "\x00" + string(key))
+}
+
+// indexSnapshot adds a snapshot stored under key to the full-text index
+func indexSnapshot(index *bolt.Bucket, snapshot Snapshot, key []byte) error {
+	for field, values := range searchFields {
+		for _, value := range values(snapshot) {
+			for _, term := range searchTerms(value) {
+				if err := index.Put(indexKey(field, term, snapshot.Info.Domain, key), nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ensureIndex builds the full-text index of databases recorded before
+// search existed. Later snapshots are indexed as they are recorded.
+func (h *historyStore) ensureIndex() error {
+	var indexed bool
+	if err := h.db.View(func(tx *bolt.Tx) error {
+		indexed = tx.Bucket(historyIndexBucket) != nil || tx.Bucket(historyDomainsBucket) == nil
+		return nil
+	}); err != nil || indexed {
+		return err
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		index, err := tx.CreateBucketIfNotExists(historyIndexBucket)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(historyDomainsBucket).ForEachBucket(func(domain []byte) error {
+			return tx.Bucket(historyDomainsBucket).Bucket(domain).ForEach(func(k, v []byte) error {
+				var snapshot Snapshot
+				if err := json.Unmarshal(v, &snapshot); err != nil {
+					return err
+				}
+				return indexSnapshot(index, snapshot, k)
+			})
+		})
+	})
+}
+
+// searchTerm is one term of a search query: a word, a prefix ending in *,
+// or a quoted phrase, optionally restricted to a field
+type searchTerm struct {
+	field  string
+	words  []string
+	prefix bool
+	phrase string
+}
+
+// parseSearchQuery parses `field:value` terms separated by spaces. Values
+// with spaces are quoted; every term must match.
+func parseSearchQuery(query string) ([]searchTerm, error) {
+	var terms []searchTerm
+	for _, token := range splitSearchQuery(query) {
+		var term searchTerm
+		value := token
+		if field, rest, ok := strings.Cut(token, ":"); ok && !strings.HasPrefix(field, `"`) {
+			field = strings.ToLower(field)
+			if alias, ok := searchFieldAliases[field]; ok {
+				field = alias
+			}
+			if _, ok := searchFields[field]; !ok {
+				return nil, fmt.Errorf("unknown search field %q (valid: %s)", field, strings.Join(searchFieldNames(), ", "))
+			}
+			term.field, value = field, rest
+		}
+		if unquoted := strings.Trim(value, `"`); unquoted != value {
+			term.phrase = strings.ToLower(unquoted)
+			value = unquoted
+		} else if strings.HasSuffix(value, "*") {
+			term.prefix = true
+			value = strings.TrimSuffix(value, "*")
+		}
+		term.words = searchTerms(value)
+		if len(term.words) > 1 && term.phrase == "" && !strings.ContainsAny(value, " \t") {
+			// A name searched whole, like ns:a1.markmonitor.com
+			term.words = term.words[len(term.words)-1:]
+		}
+		if len(term.words) == 0 {
+			return nil, fmt.Errorf("empty search term %q", token)
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+	return terms, nil
+}
+
+// splitSearchQuery splits a query on spaces outside double quotes
+func splitSearchQuery(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// searchFieldNames returns the search fields sorted
+func searchFieldNames() []string {
+	names := make([]string, 0, len(searchFields))
+	for name := range searchFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snapshotRef identifies a stored snapshot: the domain and its snapshot key
+type snapshotRef struct {
+	domain, key string
+}
+
+// lookupTerm returns the snapshots the index lists for a word in field, or
+// in any field when field is empty
+func lookupTerm(index *bolt.Bucket, field, word string, prefix bool) map[snapshotRef]bool {
+	fields := []string{field}
+	if field == "" {
+		fields = searchFieldNames()
+	}
+	refs := make(map[snapshotRef]bool)
+	c := index.Cursor()
+	for _, field := range fields {
+		start := []byte(field + ":" + word)
+		if !prefix {
+			start = append(start, 0)
+		}
+		for k, _ := c.Seek(start); k != nil && bytes.HasPrefix(k, start); k, _ = c.Next() {
+			parts := bytes.SplitN(k, []byte{0}, 3)
+			if len(parts) == 3 {
+				refs[snapshotRef{domain: string(parts[1]), key: string(parts[2])}] = true
+			}
+		}
+	}
+	return refs
+}
+
+// search returns the snapshots matching every query term, newest first
+func (h *historyStore) search(terms []searchTerm) ([]Snapshot, error) {
+	if err := h.ensureIndex(); err != nil {
+		return nil, fmt.Errorf("failed to index history: %w", err)
+	}
+	var snapshots []Snapshot
+	err := h.db.View(func(tx *bolt.Tx) error {
+		index, domains := tx.Bucket(historyIndexBucket), tx.Bucket(historyDomainsBucket)
+		if index == nil || domains == nil {
+			return nil
+		}
+
+		var matches map[snapshotRef]bool
+		for _, term := range terms {
+			for i, word := range term.words {
+				refs := lookupTerm(index, term.field, word, term.prefix && i == len(term.words)-1)
+				if matches == nil {
+					matches = refs
+					continue
+				}
+				for ref := range matches {
+					if !refs[ref] {
+						delete(matches, ref)
+					}
+				}
+			}
+		}
+
+		for ref := range matches {
+			bucket := domains.Bucket([]byte(ref.domain))
+			if bucket == nil {
+				continue
+			}
+			var snapshot Snapshot
+			if err := json.Unmarshal(bucket.Get([]byte(ref.key)), &snapshot); err != nil {
+				return err
+			}
+			if matchesPhrases(snapshot, terms) {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+		return nil
+	})
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].ScannedAt.Equal(snapshots[j].ScannedAt) {
+			return snapshots[i].ScannedAt.After(snapshots[j].ScannedAt)
+		}
+		return snapshots[i].Info.Domain < snapshots[j].Info.Domain
+	})
+	return snapshots, err
+}
+
+// matchesPhrases checks the quoted terms of a query, whose words the index
+// only finds separately, against the snapshot's values
+func matchesPhrases(snapshot Snapshot, terms []searchTerm) bool {
+	for _, term := range terms {
+		if term.phrase == "" {
+			continue
+		}
+		fields := []string{term.field}
+		if term.field == "" {
+			fields = searchFieldNames()
+		}
+		found := false
+		for _, field := range fields {
+			for _, value := range searchFields[field](snapshot) {
+				if strings.Contains(strings.ToLower(value), term.phrase) {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// latestPerDomain keeps the newest of each domain's snapshots, which are
+// sorted newest first
+func latestPerDomain(snapshots []Snapshot) []Snapshot {
+	seen := make(map[string]bool)
+	var latest []Snapshot
+	for _, snapshot := range snapshots {
+		if !seen[snapshot.Info.Domain] {
+			seen[snapshot.Info.Domain] = true
+			latest = append(latest, snapshot)
+		}
+	}
+	return latest
+}
+
+// SearchResults is the `search` subcommand's JSON output
+type SearchResults struct {
+	Query   string     `json:"query"`
+	Total   int        `json:"total"`
+	Results []Snapshot `json:"results"`
+}
+
+// runSearch implements `tldscanner search <query>`
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultHistoryPath(), "Path to the history database")
+	jsonOutput := fs.Bool("json", false, "Output the matching snapshots as JSON")
+	allSnapshots := fs.Bool("snapshots", false, "List every matching snapshot, not only the newest per domain")
+	limit := fs.Int("limit", 50, "Maximum number of results (0 for no limit)")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s search [OPTIONS] <query>\n\n", os.Args[0])
+		fmt.Printf("Searches the WHOIS snapshots recorded by scans run with -history. Terms\n")
+		fmt.Printf("are words, prefixes ending in * or quoted phrases, optionally restricted\n")
+		fmt.Printf("to a field (%s); every term must match.\n", strings.Join(searchFieldNames(), ", "))
+		fmt.Printf("Example: search 'registrar:MarkMonitor org:\"Example Corp\" ns:cloudflare*'\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	query := strings.Join(fs.Args(), " ")
+	terms, err := parseSearchQuery(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s No history database at %s (record scans with -history)\n", ColorRed, ColorReset, *dbPath)
+		return ExitUsage
+	}
+	store, err := openHistory(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	defer store.Close()
+
+	started := time.Now()
+	snapshots, err := store.search(terms)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if !*allSnapshots {
+		snapshots = latestPerDomain(snapshots)
+	}
+	total := len(snapshots)
+	if *limit > 0 && len(snapshots) > *limit {
+		snapshots = snapshots[:*limit]
+	}
+
+	if *jsonOutput {
+		data, err := json.MarshalIndent(SearchResults{Query: query, Total: total, Results: snapshots}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(formatSearchResults(query, snapshots, total, time.Since(started)))
+	}
+	if total == 0 {
+		return ExitNoMatches
+	}
+	return ExitMatches
+}
+
+// formatSearchResults renders one line per matching snapshot
+func formatSearchResults(query string, snapshots []Snapshot, total int, took time.Duration) string {
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("%s=== SEARCH: %s ===%s\n", ColorCyan, query, ColorReset))
+	for _, snapshot := range snapshots {
+		info := snapshot.Info
+		output.WriteString(fmt.Sprintf("%s  %s%s%s\n", snapshot.ScannedAt.Local().Format("2006-01-02 15:04"), ColorGreen, info.Domain, ColorReset))
+		output.WriteString(fmt.Sprintf("    Organization: %s\n", info.Organization))
+		output.WriteString(fmt.Sprintf("    Registrar: %s\n", info.Registrar))
+		if len(info.NameServers) > 0 {
+			output.WriteString(fmt.Sprintf("    Name Servers: %s\n", strings.Join(info.NameServers, ", ")))
+		}
+	}
+	if len(snapshots) < total {
+		output.WriteString(fmt.Sprintf("%d of %d results shown (-limit)\n", len(snapshots), total))
+	} else {
+		output.WriteString(fmt.Sprintf("%d results in %s\n", total, took.Round(time.Millisecond)))
+	}
+	return output.String()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func newSearchStore(t *testing.T) (*historyStore, time.Time) {
+	t.Helper()
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	first := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	result := Result{TargetDomain: "example.com"}
+	store.record(result, []DomainInfo{
+		{Domain: "example.io", Organization: "Example Corp", Registrar: "Gandi SAS", NameServers: []string{"ns1.gandi.net"}},
+		{Domain: "example.shop", Organization: "Corp Example Holdings", Registrar: "MarkMonitor Inc.", NameServers: []string{"a1.markmonitor.com"}},
+		{Domain: "examp1e.top", Organization: "Privacy Service", Registrar: "NameSilo, LLC", Emails: []string{"abuse@namesilo.com"}},
+	}, first)
+	store.record(result, []DomainInfo{
+		{Domain: "example.io", Organization: "Example Corp", Registrar: "MarkMonitor Inc.", NameServers: []string{"a1.markmonitor.com"}},
+	}, first.Add(24*time.Hour))
+	return store, first
+}
+
+func searchDomains(t *testing.T, store *historyStore, query string) []string {
+	t.Helper()
+	terms, err := parseSearchQuery(query)
+	if err != nil {
+		t.Fatalf("parseSearchQuery(%q) failed: %v", query, err)
+	}
+	snapshots, err := store.search(terms)
+	if err != nil {
+		t.Fatalf("search(%q) failed: %v", query, err)
+	}
+	var domains []string
+	for _, snapshot := range latestPerDomain(snapshots) {
+		domains = append(domains, snapshot.Info.Domain)
+	}
+	return domains
+}
+
+func TestHistorySearch(t *testing.T) {
+	store, _ := newSearchStore(t)
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"registrar:MarkMonitor", []string{"example.io", "example.shop"}},
+		{"registrar:MarkMonitor org:Example", []string{"example.io", "example.shop"}},
+		{`org:"Example Corp"`, []string{"example.io"}},
+		{"registrar:gandi", []string{"example.io"}},
+		{"ns:a1.markmonitor.com", []string{"example.io", "example.shop"}},
+		{"ns:ns1.gan*", []string{"example.io"}},
+		{"namesilo", []string{"examp1e.top"}},
+		{"email:abuse@namesilo.com", []string{"examp1e.top"}},
+		{"domain:example.shop", []string{"example.shop"}},
+		{"target:example.com registrar:namesi*", []string{"examp1e.top"}},
+		{"registrar:markmonitor org:privacy", nil},
+	}
+	for _, tt := range tests {
+		if got := searchDomains(t, store, tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("search(%q) = %v, expected %v", tt.query, got, tt.want)
+		}
+	}
+
+	// Every snapshot matching, newest first
+	terms, _ := parseSearchQuery("domain:example.io")
+	snapshots, _ := store.search(terms)
+	if len(snapshots) != 2 || snapshots[0].Info.Registrar != "MarkMonitor Inc." {
+		t.Errorf("Expected both example.io snapshots, newest first, got %+v", snapshots)
+	}
+}
+
+func TestHistorySearchIndexesOldDatabases(t *testing.T) {
+	store, _ := newSearchStore(t)
+	// A database recorded before search existed has no index
+	if err := store.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(historyIndexBucket)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store.record(Result{TargetDomain: "example.com"}, []DomainInfo{{Domain: "example.net", Registrar: "GoDaddy"}}, time.Now())
+
+	if got := searchDomains(t, store, "registrar:markmonitor"); !reflect.DeepEqual(got, []string{"example.io", "example.shop"}) {
+		t.Errorf("Expected the old snapshots to be indexed, got %v", got)
+	}
+	if got := searchDomains(t, store, "godaddy"); !reflect.DeepEqual(got, []string{"example.net"}) {
+		t.Errorf("Expected the new snapshot to be indexed, got %v", got)
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	terms, err := parseSearchQuery(`Organization:"Example Corp" ns:cloudflare* markmonitor`)
+	if err != nil {
+		t.Fatalf("parseSearchQuery failed: %v", err)
+	}
+	expected := []searchTerm{
+		{field: "org", words: []string{"example", "corp"}, phrase: "example corp"},
+		{field: "ns", words: []string{"cloudflare"}, prefix: true},
+		{words: []string{"markmonitor"}},
+	}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("parseSearchQuery() = %+v, expected %+v", terms, expected)
+	}
+
+	for _, query := range []string{"", "country:us", "org:", `"..."`} {
+		if _, err := parseSearchQuery(query); err == nil {
+			t.Errorf("Expected an error for %q", query)
+		}
+	}
+}
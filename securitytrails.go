@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// securityTrailsBaseURL is the SecurityTrails API v1 endpoint
+var securityTrailsBaseURL = "https://api.securitytrails.com/v1"
+
+// RegistrantRecord is one historical WHOIS ownership period of a domain
+type RegistrantRecord struct {
+	Organization string `json:"organization,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Registrar    string `json:"registrar,omitempty"`
+	Started      string `json:"started,omitempty"`
+	Ended        string `json:"ended,omitempty"`
+}
+
+// DNSRecords holds the current DNS records of a domain
+type DNSRecords struct {
+	A    []string `json:"a,omitempty"`
+	AAAA []string `json:"aaaa,omitempty"`
+	MX   []string `json:"mx,omitempty"`
+	NS   []string `json:"ns,omitempty"`
+	TXT  []string `json:"txt,omitempty"`
+}
+
+// securityTrailsWhoisHistory is the /history/{domain}/whois response
+type securityTrailsWhoisHistory struct {
+	Result struct {
+		Items []struct {
+			Started       int64  `json:"started"`
+			Ended         int64  `json:"ended"`
+			RegistrarName string `json:"registrarName"`
+			Contact       []struct {
+				Type         string `json:"type"`
+				Organization string `json:"organization"`
+				Name         string `json:"name"`
+				Email        string `json:"email"`
+			} `json:"contact"`
+		} `json:"items"`
+	} `json:"result"`
+}
+
+// securityTrailsDomain is the /domain/{domain} response
+type securityTrailsDomain struct {
+	CurrentDNS map[string]struct {
+		Values []map[string]interface{} `json:"values"`
+	} `json:"current_dns"`
+}
+
+// securityTrailsGet performs an authenticated API request
+func securityTrailsGet(client *http.Client, key, path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, securityTrailsBaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("APIKEY", key)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// enrichSecurityTrails attaches registrant history and current DNS records
+// from SecurityTrails to info
+func enrichSecurityTrails(info *DomainInfo, config Config) error {
+	client := enrichmentHTTPClient(config, "securitytrails")
+	key := config.APIKeys["securitytrails"]
+
+	var history securityTrailsWhoisHistory
+	if err := securityTrailsGet(client, key, "/history/"+info.Domain+"/whois", &history); err != nil {
+		return fmt.Errorf("whois history: %w", err)
+	}
+	info.RegistrantHistory = registrantHistory(history)
+
+	var domain securityTrailsDomain
+	if err := securityTrailsGet(client, key, "/domain/"+info.Domain, &domain); err != nil {
+		return fmt.Errorf("dns: %w", err)
+	}
+	info.DNS = currentDNS(domain)
+	return nil
+}
+
+// registrantHistory converts SecurityTrails WHOIS history into registrant
+// records, newest first
+func registrantHistory(history securityTrailsWhoisHistory) []RegistrantRecord {
+	var records []RegistrantRecord
+	for _, item := range history.Result.Items {
+		record := RegistrantRecord{
+			Registrar: item.RegistrarName,
+			Started:   epochMillisDate(item.Started),
+			Ended:     epochMillisDate(item.Ended),
+		}
+		for _, contact := range item.Contact {
+			if contact.Type == "registrant" {
+				record.Organization = contact.Organization
+				record.Name = contact.Name
+				record.Email = strings.ToLower(contact.Email)
+				break
+			}
+		}
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Started > records[j].Started
+	})
+	return records
+}
+
+// currentDNS extracts record values from the SecurityTrails current_dns map
+func currentDNS(domain securityTrailsDomain) *DNSRecords {
+	values := func(recordType, field string) []string {
+		var out []string
+		for _, value := range domain.CurrentDNS[recordType].Values {
+			if s, ok := value[field].(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+
+	dns := &DNSRecords{
+		A:    values("a", "ip"),
+		AAAA: values("aaaa", "ipv6"),
+		MX:   values("mx", "hostname"),
+		NS:   values("ns", "nameserver"),
+		TXT:  values("txt", "value"),
+	}
+	if len(dns.A)+len(dns.AAAA)+len(dns.MX)+len(dns.NS)+len(dns.TXT) == 0 {
+		return nil
+	}
+	return dns
+}
+
+// epochMillisDate formats a millisecond Unix timestamp as YYYY-MM-DD
+func epochMillisDate(ms int64) string {
+	if ms <= 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format("2006-01-02")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnrichSecurityTrails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("APIKEY") != "st-key" {
+			http.Error(w, `{"message":"Invalid authentication credentials"}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/history/example.net/whois":
+			w.Write([]byte(`{"result":{"items":[
+				{"started":1546300800000,"ended":1577836800000,"registrarName":"Old Registrar","contact":[{"type":"registrant","organization":"Squatter Ltd","email":"X@squat.example"}]},
+				{"started":1577836800000,"ended":0,"registrarName":"MarkMonitor","contact":[{"type":"admin","organization":"Ignored"},{"type":"registrant","organization":"Example Corp"}]}
+			]}}`))
+		case "/domain/example.net":
+			w.Write([]byte(`{"current_dns":{"a":{"values":[{"ip":"192.0.2.10"}]},"mx":{"values":[{"hostname":"mx.example.net","priority":10}]},"ns":{"values":[{"nameserver":"ns1.example.net"}]}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldURL := securityTrailsBaseURL
+	securityTrailsBaseURL = server.URL
+	defer func() { securityTrailsBaseURL = oldURL }()
+
+	info := &DomainInfo{Domain: "example.net"}
+	config := Config{Timeout: 5, APIKeys: map[string]string{"securitytrails": "st-key"}}
+	if err := enrichSecurityTrails(info, config); err != nil {
+		t.Fatalf("enrichSecurityTrails failed: %v", err)
+	}
+
+	if len(info.RegistrantHistory) != 2 {
+		t.Fatalf("Expected 2 history records, got %d", len(info.RegistrantHistory))
+	}
+	latest := info.RegistrantHistory[0]
+	if latest.Organization != "Example Corp" || latest.Started != "2020-01-01" || latest.Ended != "" {
+		t.Errorf("Unexpected latest record: %+v", latest)
+	}
+	if info.RegistrantHistory[1].Email != "x@squat.example" {
+		t.Errorf("Unexpected previous record: %+v", info.RegistrantHistory[1])
+	}
+	if info.DNS == nil || info.DNS.A[0] != "192.0.2.10" || info.DNS.MX[0] != "mx.example.net" {
+		t.Errorf("Unexpected DNS: %+v", info.DNS)
+	}
+
+	config.APIKeys["securitytrails"] = "wrong"
+	enrichDomain(info, Config{Timeout: 5, SecurityTrails: true, APIKeys: config.APIKeys})
+	if len(info.EnrichmentErrors) != 1 {
+		t.Errorf("Expected an enrichment error with a bad key, got %v", info.EnrichmentErrors)
+	}
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Scan job states
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Stream event types
+const (
+	EventStatus = "status"
+	EventDomain = "domain"
+	EventDone   = "done"
+)
+
+// streamBuffer is the number of events a slow stream client may lag behind
+// before events are dropped for it; the scan never waits for clients
+const streamBuffer = 256
+
+// ScanRequest is the body of POST /scans
+type ScanRequest struct {
+	Domain   string `json:"domain"`
+	Wordlist string `json:"wordlist,omitempty"`
+	SaveAll  bool   `json:"save_all,omitempty"`
+}
+
+// ScanJob is the state of a submitted scan
+type ScanJob struct {
+	ID         string     `json:"id"`
+	Tenant     string     `json:"tenant,omitempty"`
+	Domain     string     `json:"domain"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Processed  int        `json:"processed"`
+	Total      int        `json:"total"`
+	Matches    int        `json:"matches"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ScanEvent is one message of a scan's live stream: a status snapshot on
+// connect, a domain event per looked-up domain, and a done event
+type ScanEvent struct {
+	Type      string      `json:"type"`
+	Job       *ScanJob    `json:"job,omitempty"`
+	Processed int         `json:"processed,omitempty"`
+	Total     int         `json:"total,omitempty"`
+	Matched   bool        `json:"matched,omitempty"`
+	Domain    *DomainInfo `json:"domain,omitempty"`
+}
+
+// scanJob is a ScanJob with its result and stream subscribers
+type scanJob struct {
+	mu          sync.Mutex
+	job         ScanJob
+	result      *Result
+	subscribers map[chan ScanEvent]bool
+}
+
+// snapshot returns a copy of the job state
+func (j *scanJob) snapshot() ScanJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.job
+}
+
+// publish sends an event to every subscriber without blocking
+func (j *scanJob) publish(event ScanEvent) {
+	for ch := range j.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// subscribe registers a stream client and returns its channel, which is
+// closed once the scan finishes
+func (j *scanJob) subscribe() chan ScanEvent {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ch := make(chan ScanEvent, streamBuffer)
+	job := j.job
+	ch <- ScanEvent{Type: EventStatus, Job: &job}
+	if job.Status == JobDone || job.Status == JobFailed {
+		close(ch)
+		return ch
+	}
+	j.subscribers[ch] = true
+	return ch
+}
+
+// unsubscribe removes a stream client that went away
+func (j *scanJob) unsubscribe(ch chan ScanEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.subscribers[ch] {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+}
+
+// scanServer runs submitted scans with the options the server was started
+// with and exposes them over HTTP
+type scanServer struct {
+	config Config
+	slots  chan struct{}
+	runner func(config Config) (Result, []DomainInfo, error)
+	// auth is nil when the API is open to anyone who can reach it
+	auth *tenantAuth
+
+	// retention is how long finished scans and their results are kept
+	retention time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*scanJob
+	// submitted holds each tenant's submission times of the last 24 hours
+	// for max_daily_scans, which outlive evicted jobs
+	submitted map[string][]time.Time
+}
+
+func newScanServer(config Config, maxScans int) *scanServer {
+	if maxScans < 1 {
+		maxScans = 1
+	}
+	return &scanServer{
+		config:    config,
+		slots:     make(chan struct{}, maxScans),
+		runner:    scan,
+		retention: 24 * time.Hour,
+		jobs:      make(map[string]*scanJob),
+		submitted: make(map[string][]time.Time),
+	}
+}
+
+// submit queues a scan for a tenant and returns its job
+func (s *scanServer) submit(req ScanRequest, tenant string) (*scanJob, error) {
+	domain := strings.ToLower(strings.TrimSpace(req.Domain))
+	if !strings.Contains(domain, ".") {
+		return nil, fmt.Errorf("domain is required")
+	}
+	config := s.config
+	config.Domain = domain
+	config.Targets = nil
+	config.SaveAll = config.SaveAll || req.SaveAll
+	if req.Wordlist != "" {
+		if !strings.HasPrefix(req.Wordlist, builtinPrefix) {
+			return nil, fmt.Errorf("only builtin: wordlists can be requested")
+		}
+		config.Wordlist = req.Wordlist
+	}
+
+	job := &scanJob{
+		job:         ScanJob{ID: newJobID(), Tenant: tenant, Domain: domain, Status: JobQueued, CreatedAt: time.Now()},
+		subscribers: make(map[chan ScanEvent]bool),
+	}
+	config.OnDomain = func(info DomainInfo, matched bool, processed, total int) {
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.job.Processed, job.job.Total = processed, total
+		if matched {
+			job.job.Matches++
+		}
+		job.publish(ScanEvent{Type: EventDomain, Processed: processed, Total: total, Matched: matched, Domain: &info})
+	}
+
+	s.mu.Lock()
+	s.evict(time.Now())
+	if err := s.checkQuota(tenant); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.jobs[job.job.ID] = job
+	s.submitted[tenant] = append(s.submitted[tenant], job.job.CreatedAt)
+	s.mu.Unlock()
+	go s.run(job, config)
+	return job, nil
+}
+
+// checkQuota reports whether the tenant may submit another scan. It is
+// called with s.mu held.
+func (s *scanServer) checkQuota(tenant string) error {
+	if s.auth == nil {
+		return nil
+	}
+	quota := s.auth.tenants[tenant]
+	active, daily := 0, len(s.submitted[tenant])
+	for _, job := range s.jobs {
+		snapshot := job.snapshot()
+		if snapshot.Tenant == tenant && (snapshot.Status == JobQueued || snapshot.Status == JobRunning) {
+			active++
+		}
+	}
+	if quota.MaxActiveScans > 0 && active >= quota.MaxActiveScans {
+		return fmt.Errorf("%w: %d scans queued or running (max_active_scans %d)", errQuotaExceeded, active, quota.MaxActiveScans)
+	}
+	if quota.MaxDailyScans > 0 && daily >= quota.MaxDailyScans {
+		return fmt.Errorf("%w: %d scans in the last 24 hours (max_daily_scans %d)", errQuotaExceeded, daily, quota.MaxDailyScans)
+	}
+	return nil
+}
+
+// evict drops scans that finished more than the retention period ago and
+// submission times older than the 24 hour quota window. It is called with
+// s.mu held.
+func (s *scanServer) evict(now time.Time) {
+	for id, job := range s.jobs {
+		snapshot := job.snapshot()
+		if snapshot.FinishedAt != nil && now.Sub(*snapshot.FinishedAt) > s.retention {
+			delete(s.jobs, id)
+		}
+	}
+	since := now.Add(-24 * time.Hour)
+	for tenant, times := range s.submitted {
+		i := 0
+		for i < len(times) && !times[i].After(since) {
+			i++
+		}
+		if i == len(times) {
+			delete(s.submitted, tenant)
+		} else {
+			s.submitted[tenant] = times[i:]
+		}
+	}
+}
+
+// run waits for a free slot and runs the scan
+func (s *scanServer) run(job *scanJob, config Config) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	job.mu.Lock()
+	job.job.Status = JobRunning
+	job.mu.Unlock()
+
+	result, _, err := s.runner(config)
+	if err == nil {
+		exportTelemetry(result, time.Time{})
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	now := time.Now()
+	job.job.FinishedAt = &now
+	if err != nil {
+		job.job.Status = JobFailed
+		job.job.Error = err.Error()
+	} else {
+		job.job.Status = JobDone
+		job.job.Matches = result.TotalMatches
+		job.result = &result
+	}
+	snapshot := job.job
+	job.publish(ScanEvent{Type: EventDone, Job: &snapshot})
+	for ch := range job.subscribers {
+		close(ch)
+	}
+	job.subscribers = nil
+}
+
+// job returns the tenant's scan with the given ID. Scans of other tenants
+// are not found, so their IDs are not confirmed either.
+func (s *scanServer) job(id, tenant string) *scanJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(time.Now())
+	job := s.jobs[id]
+	if job == nil || job.snapshot().Tenant != tenant {
+		return nil
+	}
+	return job
+}
+
+// tenant authenticates the request when tenants are configured, answering
+// 401 itself when the token is missing or unknown
+func (s *scanServer) tenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if s.auth == nil {
+		return "", true
+	}
+	name, ok := s.auth.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="tldscanner"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API token"))
+		return "", false
+	}
+	return name, true
+}
+
+// handler routes the scan API:
+//
+//	POST /scans                submit a scan
+//	GET  /scans                list scans
+//	GET  /scans/{id}           scan status
+//	GET  /scans/{id}/result    result of a finished scan, as JSON or ?format=
+//	GET  /scans/{id}/stream    WebSocket stream of ScanEvents
+//	GET  /ui/                  web dashboard
+//	GET  /openapi.json         OpenAPI description of this API
+//	GET  /healthz, /readyz     liveness and readiness probes
+func (s *scanServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	newHealthServer(s.config).register(mux)
+	mux.HandleFunc("/scans", s.handleScans)
+	mux.HandleFunc("/scans/", s.handleScan)
+	mux.Handle("/ui/", http.StripPrefix("/ui/", uiHandler()))
+	mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	return mux
+}
+
+func (s *scanServer) handleScans(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := s.tenant(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		var req ScanRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid scan request: %w", err))
+			return
+		}
+		job, err := s.submit(req, tenant)
+		if errors.Is(err, errQuotaExceeded) {
+			writeError(w, http.StatusTooManyRequests, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, job.snapshot())
+	case http.MethodGet:
+		s.mu.Lock()
+		s.evict(time.Now())
+		jobs := make([]ScanJob, 0, len(s.jobs))
+		for _, job := range s.jobs {
+			if snapshot := job.snapshot(); snapshot.Tenant == tenant {
+				jobs = append(jobs, snapshot)
+			}
+		}
+		s.mu.Unlock()
+		sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+		writeJSON(w, http.StatusOK, jobs)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *scanServer) handleScan(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := s.tenant(w, r)
+	if !ok {
+		return
+	}
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/scans/"), "/")
+	job := s.job(id, tenant)
+	if job == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no scan %q", id))
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	switch action {
+	case "":
+		writeJSON(w, http.StatusOK, job.snapshot())
+	case "result":
+		job.mu.Lock()
+		result := job.result
+		job.mu.Unlock()
+		if result == nil {
+			writeError(w, http.StatusConflict, fmt.Errorf("scan %s has no result yet", id))
+			return
+		}
+		if format := r.URL.Query().Get("format"); format != "" {
+			writeDownload(w, *result, format)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	case "stream":
+		websocket.Server{Handler: func(ws *websocket.Conn) { streamJob(ws, job) }}.ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown scan resource %q", action))
+	}
+}
+
+// streamJob sends a job's events to a WebSocket client until the scan
+// finishes or the client disconnects
+func streamJob(ws *websocket.Conn, job *scanJob) {
+	defer ws.Close()
+	events := job.subscribe()
+	defer job.unsubscribe(events)
+
+	gone := make(chan struct{})
+	go func() {
+		// Clients only listen; any read error means they went away
+		var discard string
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+		close(gone)
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// writeDownload sends a result rendered in one of the downloadFormats as a
+// file attachment
+func writeDownload(w http.ResponseWriter, result Result, format string) {
+	contentType, ok := downloadFormats[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q", format))
+		return
+	}
+	data, err := renderFormat(result, format)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	ext := format
+	switch format {
+	case "text", "grep", "list", "list-all":
+		ext = "txt"
+	case "maltego":
+		ext = "csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q",
+		fmt.Sprintf("tldscanner-%s-%s.%s", result.TargetDomain, format, ext)))
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// runServe implements `tldscanner serve`: an HTTP API to submit scans and
+// follow them live over WebSocket
+func runServe(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	listen := fs.String("listen", "127.0.0.1:8080", "Address to serve the scan API on")
+	maxScans := fs.Int("max-scans", 2, "Maximum number of scans running at once; more are queued")
+	retention := fs.Duration("retention", 24*time.Hour, "How long finished scans and their results are kept")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s serve [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Serves an HTTP API to submit scans (POST /scans), check them\n")
+		fmt.Printf("(GET /scans/{id}), fetch results (GET /scans/{id}/result) and stream\n")
+		fmt.Printf("progress and domain results live over WebSocket (/scans/{id}/stream).\n")
+		fmt.Printf("A web dashboard over the same API is served at /ui/, and liveness and\n")
+		fmt.Printf("readiness probes at /healthz and /readyz. The API is described by an\n")
+		fmt.Printf("OpenAPI document at /openapi.json.\n")
+		fmt.Printf("Scan options set here apply to every submitted scan. When tenants are\n")
+		fmt.Printf("configured (`%s auth token <tenant>`), the API requires their tokens\n", os.Args[0])
+		fmt.Printf("and each tenant only sees its own scans.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	applyImpliedFlags(&config)
+	if *retention <= 0 {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s -retention must be positive\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+	if config.Monitor {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s serve does not support -monitor\n", ColorRed, ColorReset)
+		return ExitUsage
+	}
+	// Results are served over the API, not written or printed per domain
+	config.Format = "json"
+	config.Output, config.OutputAll = "", ""
+
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := resolveCredentials(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := startDiagnostics(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	fileConfig, err := loadFileConfig(config.ConfigFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	auth, err := newTenantAuth(fileConfig.Tenants)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	printBanner()
+	scans := newScanServer(config, *maxScans)
+	scans.auth = auth
+	scans.retention = *retention
+	server := &http.Server{Addr: *listen, Handler: scans.handler()}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("%s[INFO]%s Serving the scan API on %s (dashboard at http://%s/ui/)\n", ColorBlue, ColorReset, *listen, *listen)
+	if auth != nil {
+		fmt.Printf("%s[INFO]%s API tokens required for %d tenants\n", ColorBlue, ColorReset, len(auth.tenants))
+	} else {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s No tenants configured; the scan API is open to anyone who can reach it\n", ColorYellow, ColorReset)
+	}
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	return ExitMatches
+}
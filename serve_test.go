@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/vijay922/tldscanner/client"
+)
+
+func TestScanServerStream(t *testing.T) {
+	start := make(chan struct{})
+	s := newScanServer(Config{}, 1)
+	s.runner = func(config Config) (Result, []DomainInfo, error) {
+		<-start
+		config.OnDomain(DomainInfo{Domain: "example.io", Organization: "Example Inc"}, true, 1, 2)
+		config.OnDomain(DomainInfo{Domain: "example.de"}, false, 2, 2)
+		return Result{TargetDomain: config.Domain, TotalScanned: 2, TotalMatches: 1}, nil, nil
+	}
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/scans", "application/json", strings.NewReader(`{"domain": "Example.com"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job ScanJob
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted || job.ID == "" || job.Domain != "example.com" {
+		t.Fatalf("Unexpected submit response %d %+v", resp.StatusCode, job)
+	}
+
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/scans/"+job.ID+"/stream", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var events []ScanEvent
+	var event ScanEvent
+	if err := websocket.JSON.Receive(ws, &event); err != nil || event.Type != EventStatus {
+		t.Fatalf("Expected a status event first, got %+v, %v", event, err)
+	}
+	close(start)
+	for {
+		var event ScanEvent
+		if err := websocket.JSON.Receive(ws, &event); err != nil {
+			break
+		}
+		events = append(events, event)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 2 domain events and a done event, got %+v", events)
+	}
+	if events[0].Type != EventDomain || !events[0].Matched || events[0].Domain.Domain != "example.io" || events[1].Processed != 2 {
+		t.Errorf("Unexpected domain events %+v", events[:2])
+	}
+	if events[2].Type != EventDone || events[2].Job.Status != JobDone || events[2].Job.Matches != 1 {
+		t.Errorf("Unexpected done event %+v", events[2])
+	}
+
+	resp, err = http.Get(server.URL + "/scans/" + job.ID + "/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result Result
+	json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || result.TargetDomain != "example.com" {
+		t.Errorf("Unexpected result response %d %+v", resp.StatusCode, result)
+	}
+}
+
+func TestScanServerErrors(t *testing.T) {
+	s := newScanServer(Config{}, 1)
+	s.runner = func(config Config) (Result, []DomainInfo, error) { select {} }
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodPost, "/scans", `{"domain": ""}`, http.StatusBadRequest},
+		{http.MethodPost, "/scans", `{"domain": "example.com", "wordlist": "/etc/passwd"}`, http.StatusBadRequest},
+		{http.MethodPost, "/scans", `not json`, http.StatusBadRequest},
+		{http.MethodDelete, "/scans", ``, http.StatusMethodNotAllowed},
+		{http.MethodGet, "/scans/missing", ``, http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(tc.method, server.URL+tc.path, bytes.NewBufferString(tc.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s %s: status %d; expected %d", tc.method, tc.path, tc.body, resp.StatusCode, tc.status)
+		}
+	}
+
+	job, err := s.submit(ScanRequest{Domain: "example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get(server.URL + "/scans/" + job.snapshot().ID + "/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for an unfinished scan, got %d", resp.StatusCode)
+	}
+}
+
+func TestScanServerDownloadsAndUI(t *testing.T) {
+	s := newScanServer(Config{}, 1)
+	s.runner = func(config Config) (Result, []DomainInfo, error) {
+		return Result{
+			TargetDomain:    config.Domain,
+			MatchingDomains: []DomainInfo{{Domain: "example.io", Organization: "Example Inc", MatchReason: "organization"}},
+			TotalMatches:    1,
+		}, nil, nil
+	}
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	job, err := s.submit(ScanRequest{Domain: "example.com"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for job.snapshot().Status != JobDone {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	get := func(path string) (*http.Response, string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("/scans/" + job.snapshot().ID + "/result?format=csv")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") {
+		t.Fatalf("Unexpected CSV download %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(resp.Header.Get("Content-Disposition"), `filename="tldscanner-example.com-csv.csv"`) {
+		t.Errorf("Unexpected Content-Disposition %q", resp.Header.Get("Content-Disposition"))
+	}
+	if !strings.Contains(body, "example.io,,Example Inc") {
+		t.Errorf("CSV download is missing the match:\n%s", body)
+	}
+
+	if resp, body := get("/scans/" + job.snapshot().ID + "/result?format=text"); resp.StatusCode != http.StatusOK || strings.Contains(body, "\033[") {
+		t.Errorf("Expected an uncolored text download, got %d:\n%q", resp.StatusCode, body)
+	}
+	if resp, _ := get("/scans/" + job.snapshot().ID + "/result?format=template"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported format, got %d", resp.StatusCode)
+	}
+
+	resp, body = get("/ui/")
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, `<script src="app.js">`) {
+		t.Errorf("Unexpected dashboard page %d:\n%s", resp.StatusCode, body)
+	}
+	if resp, _ := get("/ui/app.js"); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the dashboard script to be served, got %d", resp.StatusCode)
+	}
+}
+
+func TestScanServerTenants(t *testing.T) {
+	s := newScanServer(Config{}, 1)
+	s.runner = func(config Config) (Result, []DomainInfo, error) { select {} }
+	s.auth, _ = newTenantAuth(map[string]Tenant{
+		"red":  {TokenSHA256: hashToken("red-token"), MaxActiveScans: 1},
+		"blue": {TokenSHA256: hashToken("blue-token")},
+	})
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	do := func(method, path, token, body string) (*http.Response, []byte) {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, data
+	}
+
+	if resp, _ := do(http.MethodGet, "/scans", "", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(http.MethodGet, "/healthz", "", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected probes to need no token, got %d", resp.StatusCode)
+	}
+
+	resp, body := do(http.MethodPost, "/scans", "red-token", `{"domain": "example.com"}`)
+	var job ScanJob
+	json.Unmarshal(body, &job)
+	if resp.StatusCode != http.StatusAccepted || job.Tenant != "red" {
+		t.Fatalf("Unexpected submit response %d %s", resp.StatusCode, body)
+	}
+	if resp, body := do(http.MethodPost, "/scans", "red-token", `{"domain": "example.org"}`); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected the active scan quota to be enforced, got %d %s", resp.StatusCode, body)
+	}
+
+	if resp, _ := do(http.MethodGet, "/scans/"+job.ID, "blue-token", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected another tenant's scan to be hidden, got %d", resp.StatusCode)
+	}
+	if _, body := do(http.MethodGet, "/scans", "blue-token", ""); strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("Expected no scans listed for another tenant, got %s", body)
+	}
+	if resp, _ := do(http.MethodGet, "/scans/"+job.ID+"?access_token=red-token", "", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the owner to see its scan, got %d", resp.StatusCode)
+	}
+}
+
+func TestScanServerClient(t *testing.T) {
+	s := newScanServer(Config{}, 1)
+	s.runner = func(config Config) (Result, []DomainInfo, error) {
+		config.OnDomain(DomainInfo{Domain: "example.io", Organization: "Example Inc"}, true, 1, 1)
+		return Result{SchemaVersion: SchemaVersion, TargetDomain: config.Domain, TotalScanned: 1, TotalMatches: 1,
+			MatchingDomains: []DomainInfo{{Domain: "example.io", Organization: "Example Inc"}}}, nil, nil
+	}
+	s.auth, _ = newTenantAuth(map[string]Tenant{"red": {TokenSHA256: hashToken("red-token")}})
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	ctx := context.Background()
+	c := client.NewClient(server.URL, "red-token")
+	scan, err := c.StartScan(ctx, client.ScanRequest{Domain: "example.com"})
+	if err != nil || scan.Tenant != "red" {
+		t.Fatalf("Unexpected scan %+v, %v", scan, err)
+	}
+	if err := c.StreamResults(ctx, scan.ID, func(event client.Event) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if scan, err = c.GetScan(ctx, scan.ID); err != nil || scan.Status != client.StatusDone {
+		t.Fatalf("Expected the scan to be done, got %+v, %v", scan, err)
+	}
+	result, err := c.GetResult(ctx, scan.ID)
+	if err != nil || result.SchemaVersion != SchemaVersion || len(result.MatchingDomains) != 1 || result.MatchingDomains[0].Organization != "Example Inc" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+}
+
+func TestScanServerEviction(t *testing.T) {
+	s := newScanServer(Config{}, 1)
+	s.retention = time.Hour
+	s.auth, _ = newTenantAuth(map[string]Tenant{"red": {TokenSHA256: hashToken("red-token"), MaxDailyScans: 2}})
+
+	now := time.Now()
+	finished := now.Add(-2 * time.Hour)
+	s.jobs["old"] = &scanJob{job: ScanJob{ID: "old", Tenant: "red", Status: JobDone, CreatedAt: finished, FinishedAt: &finished}}
+	s.jobs["running"] = &scanJob{job: ScanJob{ID: "running", Tenant: "red", Status: JobRunning, CreatedAt: now.Add(-3 * time.Hour)}}
+	s.submitted["red"] = []time.Time{now.Add(-25 * time.Hour), finished, now.Add(-3 * time.Hour)}
+
+	s.evict(now)
+	if _, ok := s.jobs["old"]; ok {
+		t.Error("Expected the scan finished before the retention period to be evicted")
+	}
+	if _, ok := s.jobs["running"]; !ok {
+		t.Error("Expected the running scan to be kept")
+	}
+	if len(s.submitted["red"]) != 2 {
+		t.Errorf("Expected submissions older than 24 hours to be dropped, got %v", s.submitted["red"])
+	}
+
+	// Evicted scans still count toward the daily quota
+	if err := s.checkQuota("red"); !errors.Is(err, errQuotaExceeded) {
+		t.Errorf("Expected the daily quota to count evicted scans, got %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// servicePlatforms are the valid values of `service -platform`
+var servicePlatforms = []string{"systemd", "launchd", "windows"}
+
+// serviceNamePattern restricts service names to characters every platform
+// accepts in unit, label and task names
+var serviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// serviceSpec describes a monitor mode service: the scanner binary and the
+// scan arguments it runs with
+type serviceSpec struct {
+	Name        string
+	Description string
+	Executable  string
+	Args        []string
+	WorkDir     string
+	// System installs the service for all users, started at boot, rather
+	// than for the current user
+	System bool
+}
+
+// runService implements `tldscanner service install|uninstall`
+func runService(args []string) int {
+	fs := flag.NewFlagSet("service", flag.ContinueOnError)
+	name := fs.String("name", "", "Service name (default tldscanner-<domain>)")
+	platform := fs.String("platform", defaultServicePlatform(), "Service manager: systemd, launchd or windows (a scheduled task)")
+	system := fs.Bool("system", false, "Install for all users and start at boot instead of for the current user")
+	dryRun := fs.Bool("dry-run", false, "Print the service file and commands without installing")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s service install [OPTIONS] -- <scan options>\n", os.Args[0])
+		fmt.Printf("       %s service uninstall [OPTIONS] -name <name>\n\n", os.Args[0])
+		fmt.Printf("Installs a monitor mode scan as a service that starts with the system or\n")
+		fmt.Printf("the user session and restarts on failure. The scan options must enable\n")
+		fmt.Printf("monitor mode (-monitor or -schedule) and are validated before anything\n")
+		fmt.Printf("is installed.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		fs.Usage()
+		return ExitUsage
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if !containsString(servicePlatforms, *platform) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s unknown service platform %q (valid: %s)\n", ColorRed, ColorReset, *platform, strings.Join(servicePlatforms, ", "))
+		return ExitUsage
+	}
+
+	spec := serviceSpec{Name: *name, System: *system}
+	if action == "install" {
+		var err error
+		if spec, err = newServiceSpec(fs.Args(), *name, *system); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+	} else if fs.NArg() > 0 || spec.Name == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+	if !serviceNamePattern.MatchString(spec.Name) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s invalid service name %q\n", ColorRed, ColorReset, spec.Name)
+		return ExitUsage
+	}
+
+	path, err := serviceFilePath(*platform, spec.Name, spec.System)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if action == "uninstall" {
+		return uninstallService(*platform, spec, path, *dryRun)
+	}
+	return installService(*platform, spec, path, *dryRun)
+}
+
+// newServiceSpec validates the scan options of a service and resolves the
+// binary, working directory and configuration file the service runs with
+func newServiceSpec(scanArgs []string, name string, system bool) (serviceSpec, error) {
+	var config Config
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	if err := fs.Parse(scanArgs); err != nil {
+		return serviceSpec{}, fmt.Errorf("invalid scan options: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return serviceSpec{}, fmt.Errorf("unexpected argument %q in the scan options", fs.Arg(0))
+	}
+	applyImpliedFlags(&config)
+	if !config.Monitor {
+		return serviceSpec{}, fmt.Errorf("the scan options must enable monitor mode with -monitor or -schedule")
+	}
+	if config.Domain == "" {
+		return serviceSpec{}, fmt.Errorf("the scan options must include -d")
+	}
+	if err := validateConfig(config); err != nil {
+		return serviceSpec{}, err
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to locate the tldscanner binary: %w", err)
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return serviceSpec{}, err
+	}
+
+	// A system service runs as another user whose default configuration
+	// file is not the one holding the credentials, so the path is pinned
+	args := append([]string(nil), scanArgs...)
+	configSet := false
+	fs.Visit(func(f *flag.Flag) { configSet = configSet || f.Name == "config" })
+	if !configSet {
+		configFile, err := filepath.Abs(config.ConfigFile)
+		if err != nil {
+			return serviceSpec{}, err
+		}
+		args = append(args, "-config", configFile)
+	}
+
+	if name == "" {
+		name = "tldscanner-" + strings.ReplaceAll(config.Domain, ".", "-")
+	}
+	return serviceSpec{
+		Name:        name,
+		Description: "TLD Scanner monitor for " + config.Domain,
+		Executable:  executable,
+		Args:        args,
+		WorkDir:     workDir,
+		System:      system,
+	}, nil
+}
+
+// defaultServicePlatform returns the service manager of the running OS
+func defaultServicePlatform() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "launchd"
+	case "windows":
+		return "windows"
+	}
+	return "systemd"
+}
+
+// serviceFilePath returns where the service file is installed: a systemd
+// unit, a launchd property list, or the script a Windows scheduled task
+// runs
+func serviceFilePath(platform, name string, system bool) (string, error) {
+	switch {
+	case platform == "systemd" && system:
+		return filepath.Join("/etc/systemd/system", name+".service"), nil
+	case platform == "launchd" && system:
+		return filepath.Join("/Library/LaunchDaemons", name+".plist"), nil
+	case platform == "launchd":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "LaunchAgents", name+".plist"), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if platform == "systemd" {
+		return filepath.Join(dir, "systemd", "user", name+".service"), nil
+	}
+	return filepath.Join(dir, "tldscanner", "services", name+".cmd"), nil
+}
+
+// renderServiceFile renders the service file of a platform
+func renderServiceFile(platform string, spec serviceSpec) string {
+	switch platform {
+	case "launchd":
+		return launchdPlist(spec)
+	case "windows":
+		return windowsTaskScript(spec)
+	}
+	return systemdUnit(spec)
+}
+
+// systemdUnit renders a unit restarting the monitor on failure. Colors are
+// disabled since the output goes to the journal.
+func systemdUnit(spec serviceSpec) string {
+	target := "default.target"
+	if spec.System {
+		target = "multi-user.target"
+	}
+	words := []string{systemdQuote(spec.Executable)}
+	for _, arg := range spec.Args {
+		words = append(words, systemdQuote(arg))
+	}
+	var unit strings.Builder
+	fmt.Fprintf(&unit, "[Unit]\nDescription=%s\nWants=network-online.target\nAfter=network-online.target\n\n", spec.Description)
+	fmt.Fprintf(&unit, "[Service]\nType=simple\nWorkingDirectory=%s\nEnvironment=NO_COLOR=1\n", systemdQuote(spec.WorkDir))
+	fmt.Fprintf(&unit, "ExecStart=%s\nRestart=on-failure\nRestartSec=60\n\n", strings.Join(words, " "))
+	fmt.Fprintf(&unit, "[Install]\nWantedBy=%s\n", target)
+	return unit.String()
+}
+
+// systemdQuote quotes a word of a unit file command line; systemd expands
+// % specifiers and $ variables, which are escaped
+func systemdQuote(word string) string {
+	word = strings.NewReplacer("%", "%%", "$", "$$").Replace(word)
+	if word != "" && !strings.ContainsAny(word, " \t\"'\\;") {
+		return word
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+}
+
+// launchdPlist renders a launchd job started at load and restarted when it
+// exits with an error, logging to ~/Library/Logs or /Library/Logs
+func launchdPlist(spec serviceSpec) string {
+	logDir := "/Library/Logs"
+	if !spec.System {
+		if home, err := os.UserHomeDir(); err == nil {
+			logDir = filepath.Join(home, "Library", "Logs")
+		}
+	}
+	logFile := html.EscapeString(filepath.Join(logDir, spec.Name+".log"))
+
+	var plist strings.Builder
+	plist.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	plist.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	plist.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	fmt.Fprintf(&plist, "\t<key>Label</key>\n\t<string>%s</string>\n", html.EscapeString(spec.Name))
+	plist.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{spec.Executable}, spec.Args...) {
+		fmt.Fprintf(&plist, "\t\t<string>%s</string>\n", html.EscapeString(arg))
+	}
+	plist.WriteString("\t</array>\n")
+	fmt.Fprintf(&plist, "\t<key>WorkingDirectory</key>\n\t<string>%s</string>\n", html.EscapeString(spec.WorkDir))
+	plist.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n\t\t<key>NO_COLOR</key>\n\t\t<string>1</string>\n\t</dict>\n")
+	plist.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	plist.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	fmt.Fprintf(&plist, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", logFile)
+	fmt.Fprintf(&plist, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", logFile)
+	plist.WriteString("</dict>\n</plist>\n")
+	return plist.String()
+}
+
+// windowsTaskScript renders the batch script a scheduled task runs. A
+// script keeps the task command short, since schtasks limits /TR to 261
+// characters.
+func windowsTaskScript(spec serviceSpec) string {
+	words := []string{cmdQuote(spec.Executable)}
+	for _, arg := range spec.Args {
+		words = append(words, cmdQuote(arg))
+	}
+	return fmt.Sprintf("@echo off\r\nrem %s\r\nset NO_COLOR=1\r\ncd /d %s\r\n%s\r\n",
+		spec.Description, cmdQuote(spec.WorkDir), strings.Join(words, " "))
+}
+
+// cmdQuote quotes an argument of a batch script line; % is doubled so
+// cmd.exe does not expand it
+func cmdQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^()") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+}
+
+// serviceCommands returns the commands that start the installed service,
+// or with stop set the commands that stop and unregister it
+func serviceCommands(platform string, spec serviceSpec, path string, stop bool) [][]string {
+	switch platform {
+	case "launchd":
+		if stop {
+			return [][]string{{"launchctl", "unload", "-w", path}}
+		}
+		return [][]string{{"launchctl", "load", "-w", path}}
+	case "windows":
+		if stop {
+			return [][]string{{"schtasks", "/End", "/TN", spec.Name}, {"schtasks", "/Delete", "/F", "/TN", spec.Name}}
+		}
+		create := []string{"schtasks", "/Create", "/F", "/TN", spec.Name, "/TR", `"` + path + `"`}
+		if spec.System {
+			create = append(create, "/SC", "ONSTART", "/RU", "SYSTEM")
+		} else {
+			create = append(create, "/SC", "ONLOGON")
+		}
+		return [][]string{create, {"schtasks", "/Run", "/TN", spec.Name}}
+	}
+	systemctl := []string{"systemctl"}
+	if !spec.System {
+		systemctl = append(systemctl, "--user")
+	}
+	unit := spec.Name + ".service"
+	if stop {
+		return [][]string{append(systemctl, "disable", "--now", unit), append(systemctl, "daemon-reload")}
+	}
+	return [][]string{append(systemctl, "daemon-reload"), append(systemctl, "enable", "--now", unit)}
+}
+
+// installService writes the service file and starts the service
+func installService(platform string, spec serviceSpec, path string, dryRun bool) int {
+	content := renderServiceFile(platform, spec)
+	commands := serviceCommands(platform, spec, path, false)
+	if dryRun {
+		fmt.Printf("# %s\n%s\n", path, content)
+		for _, command := range commands {
+			fmt.Println(strings.Join(command, " "))
+		}
+		return ExitMatches
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s Failed to write %s: %v\n", ColorRed, ColorReset, path, err)
+		return ExitUsage
+	}
+	fmt.Printf("%s[INFO]%s Wrote %s\n", ColorBlue, ColorReset, path)
+	for _, command := range commands {
+		if err := runServiceCommand(command); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+	}
+	fmt.Printf("%s[INFO]%s Service %s installed and started\n", ColorBlue, ColorReset, spec.Name)
+	if platform == "systemd" && !spec.System {
+		fmt.Printf("%s[INFO]%s Run `loginctl enable-linger` to keep it running while you are logged out\n", ColorBlue, ColorReset)
+	}
+	return ExitMatches
+}
+
+// uninstallService stops the service and removes its file. A service that
+// is not running does not stop the removal.
+func uninstallService(platform string, spec serviceSpec, path string, dryRun bool) int {
+	commands := serviceCommands(platform, spec, path, true)
+	if dryRun {
+		for _, command := range commands {
+			fmt.Println(strings.Join(command, " "))
+		}
+		fmt.Printf("rm %s\n", path)
+		return ExitMatches
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s service %s is not installed: %v\n", ColorRed, ColorReset, spec.Name, err)
+		return ExitUsage
+	}
+	for _, command := range commands {
+		if err := runServiceCommand(command); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	fmt.Printf("%s[INFO]%s Service %s removed\n", ColorBlue, ColorReset, spec.Name)
+	return ExitMatches
+}
+
+func runServiceCommand(command []string) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w", strings.Join(command, " "), err)
+	}
+	return nil
+}
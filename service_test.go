@@ -0,0 +1,123 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewServiceSpec(t *testing.T) {
+	spec, err := newServiceSpec([]string{"-d", "example.com", "-schedule", "0 3 * * *", "-config", "/etc/tldscanner.yaml"}, "", false)
+	if err != nil {
+		t.Fatalf("newServiceSpec failed: %v", err)
+	}
+	if spec.Name != "tldscanner-example-com" || spec.Description != "TLD Scanner monitor for example.com" {
+		t.Errorf("Unexpected name %q and description %q", spec.Name, spec.Description)
+	}
+	if !reflect.DeepEqual(spec.Args, []string{"-d", "example.com", "-schedule", "0 3 * * *", "-config", "/etc/tldscanner.yaml"}) {
+		t.Errorf("Expected the scan options unchanged, got %q", spec.Args)
+	}
+	if spec.Executable == "" || spec.WorkDir == "" {
+		t.Errorf("Expected the binary and working directory, got %+v", spec)
+	}
+
+	spec, err = newServiceSpec([]string{"-d", "example.com", "-monitor"}, "brand-watch", true)
+	if err != nil {
+		t.Fatalf("newServiceSpec failed: %v", err)
+	}
+	if spec.Name != "brand-watch" || !spec.System {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+	if n := len(spec.Args); n != 5 || spec.Args[3] != "-config" {
+		t.Errorf("Expected the configuration file to be pinned, got %q", spec.Args)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-d", "example.com"}, "monitor mode"},
+		{[]string{"-monitor"}, "-d"},
+		{[]string{"-d", "example.com", "-monitor", "-interval", "10s"}, "-interval must be at least 1m"},
+		{[]string{"-d", "example.com", "-monitor", "extra"}, `unexpected argument "extra"`},
+	}
+	for _, tt := range tests {
+		if _, err := newServiceSpec(tt.args, "", false); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("newServiceSpec(%q): expected error containing %q, got %v", tt.args, tt.want, err)
+		}
+	}
+}
+
+func TestServiceFiles(t *testing.T) {
+	spec := serviceSpec{
+		Name:        "tldscanner-example-com",
+		Description: "TLD Scanner monitor for example.com",
+		Executable:  "/usr/local/bin/tldscanner",
+		Args:        []string{"-d", "example.com", "-schedule", "0 3 * * *", "-o", "50%.json"},
+		WorkDir:     "/srv/scans",
+	}
+
+	unit := systemdUnit(spec)
+	for _, want := range []string{
+		"Description=TLD Scanner monitor for example.com\n",
+		"WorkingDirectory=/srv/scans\n",
+		`ExecStart=/usr/local/bin/tldscanner -d example.com -schedule "0 3 * * *" -o 50%%.json` + "\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Expected the unit to contain %q, got:\n%s", want, unit)
+		}
+	}
+	spec.System = true
+	if unit := systemdUnit(spec); !strings.Contains(unit, "WantedBy=multi-user.target") {
+		t.Errorf("Expected a system unit to start at boot, got:\n%s", unit)
+	}
+
+	spec.Args = append(spec.Args, "-mail-to", "Sec <sec@example.com>")
+	plist := launchdPlist(spec)
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>tldscanner-example-com</string>",
+		"<string>0 3 * * *</string>",
+		"<string>Sec &lt;sec@example.com&gt;</string>",
+		"<string>/Library/Logs/tldscanner-example-com.log</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("Expected the plist to contain %q, got:\n%s", want, plist)
+		}
+	}
+
+	script := windowsTaskScript(serviceSpec{
+		Description: "TLD Scanner monitor for example.com",
+		Executable:  `C:\Program Files\tldscanner\tldscanner.exe`,
+		Args:        []string{"-d", "example.com", "-schedule", "0 3 * * *", "-o", "50%.json"},
+		WorkDir:     `C:\scans`,
+	})
+	if !strings.Contains(script, "cd /d C:\\scans\r\n\"C:\\Program Files\\tldscanner\\tldscanner.exe\" -d example.com -schedule \"0 3 * * *\" -o 50%%.json\r\n") {
+		t.Errorf("Unexpected task script:\n%q", script)
+	}
+}
+
+func TestServiceCommands(t *testing.T) {
+	spec := serviceSpec{Name: "watch"}
+	tests := []struct {
+		platform string
+		system   bool
+		stop     bool
+		want     [][]string
+	}{
+		{"systemd", false, false, [][]string{{"systemctl", "--user", "daemon-reload"}, {"systemctl", "--user", "enable", "--now", "watch.service"}}},
+		{"systemd", true, true, [][]string{{"systemctl", "disable", "--now", "watch.service"}, {"systemctl", "daemon-reload"}}},
+		{"launchd", false, false, [][]string{{"launchctl", "load", "-w", "/path"}}},
+		{"windows", true, false, [][]string{
+			{"schtasks", "/Create", "/F", "/TN", "watch", "/TR", `"/path"`, "/SC", "ONSTART", "/RU", "SYSTEM"},
+			{"schtasks", "/Run", "/TN", "watch"},
+		}},
+	}
+	for _, tt := range tests {
+		spec.System = tt.system
+		if got := serviceCommands(tt.platform, spec, "/path", tt.stop); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("serviceCommands(%s, system %v, stop %v) = %q, expected %q", tt.platform, tt.system, tt.stop, got, tt.want)
+		}
+	}
+}
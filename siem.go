@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// siemFinding is one event of CEF or LEEF output
+type siemFinding struct {
+	class    string // event ID: match, signal or lookalike
+	name     string
+	severity int // 0-10
+	info     DomainInfo
+}
+
+// siemFindings returns an event per matching domain, signal domain and
+// lookalike
+func siemFindings(result Result) []siemFinding {
+	var findings []siemFinding
+	for _, info := range result.MatchingDomains {
+		findings = append(findings, newSIEMFinding("match", info))
+	}
+	for _, info := range result.SignalDomains {
+		findings = append(findings, newSIEMFinding("signal", info))
+	}
+	for _, info := range result.Lookalikes {
+		findings = append(findings, newSIEMFinding("lookalike", info))
+	}
+	return findings
+}
+
+// newSIEMFinding returns the event of a finding of class. Matches are the
+// target's own registrations and low severity; signal domains and
+// lookalikes are scaled from their strongest signal and risk score.
+func newSIEMFinding(class string, info DomainInfo) siemFinding {
+	switch class {
+	case "signal":
+		return siemFinding{class, "Suspicious domain registration", int(math.Round(strongestSignal(info) * 10)), info}
+	case "lookalike":
+		return siemFinding{class, "Lookalike domain registered", info.RiskScore / 10, info}
+	}
+	return siemFinding{"match", "Domain registered to target organization", 3, info}
+}
+
+// siemField is one key=value pair of an event
+type siemField struct {
+	key, value string
+}
+
+// siemFields are the fields shared by CEF and LEEF events, empty ones left
+// out. CEF carries the custom ones in its cs<n>/cn1 fields.
+func siemFields(result Result, f siemFinding) []siemField {
+	info := f.info
+	reason := info.MatchReason
+	if f.class != "match" {
+		var signals []string
+		for _, signal := range info.Signals {
+			signals = append(signals, fmt.Sprintf("%s:%.2f", signal.Name, signal.Score))
+		}
+		reason = strings.Join(signals, ",")
+	}
+	fields := []siemField{
+		{"domain", info.Domain},
+		{"targetDomain", result.TargetDomain},
+		{"organization", info.Organization},
+		{"registrar", info.Registrar},
+		{"matchReason", reason},
+		{"nameServers", strings.Join(info.NameServers, ",")},
+		{"metadata", formatMetadata(info.Metadata, ";")},
+	}
+	if f.class == "lookalike" {
+		fields = append(fields, siemField{"riskScore", strconv.Itoa(info.RiskScore)})
+	}
+	var kept []siemField
+	for _, field := range fields {
+		if field.value != "" {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+// cefCustomFields maps the shared fields onto CEF's labelled custom fields
+var cefCustomFields = map[string]string{
+	"targetDomain": "cs1",
+	"organization": "cs2",
+	"registrar":    "cs3",
+	"matchReason":  "cs4",
+	"nameServers":  "cs5",
+	"metadata":     "cs6",
+	"riskScore":    "cn1",
+}
+
+// outputSIEM writes one CEF or LEEF event per finding
+func outputSIEM(result Result, outputFile string, format string, recipients []age.Recipient) {
+	saveOutput(renderSIEM(result, format, time.Now()), outputFile, recipients)
+}
+
+// renderSIEM renders the findings as CEF (ArcSight) or LEEF 1.0 (QRadar)
+// lines, ready to be forwarded by a syslog collector
+func renderSIEM(result Result, format string, now time.Time) []byte {
+	var output strings.Builder
+	for _, f := range siemFindings(result) {
+		output.WriteString(siemEvent(result, f, format, now) + "\n")
+	}
+	return []byte(output.String())
+}
+
+// siemEvent renders one finding as a CEF or LEEF event
+func siemEvent(result Result, f siemFinding, format string, now time.Time) string {
+	deviceVersion := version
+	if result.Scanner != nil {
+		deviceVersion = result.Scanner.Version
+	}
+	info := f.info
+	fields := siemFields(result, f)
+	created, hasCreated := info.created()
+
+	if format == "leef" {
+		header := []string{"LEEF:1.0", "TLDScanner", "tldscanner", deviceVersion, f.class}
+		pairs := []string{
+			"cat=" + f.class,
+			"sev=" + strconv.Itoa(f.severity),
+			"devTime=" + now.UTC().Format("2006-01-02T15:04:05-0700"),
+			"devTimeFormat=yyyy-MM-dd'T'HH:mm:ssZ",
+			"externalId=" + leefValue(pagingDedupKey(result.TargetDomain, info.Domain)),
+		}
+		for _, field := range fields {
+			pairs = append(pairs, field.key+"="+leefValue(field.value))
+		}
+		if hasCreated {
+			pairs = append(pairs, "created="+created.UTC().Format("2006-01-02T15:04:05-0700"))
+		}
+		return cefHeader(header) + "|" + strings.Join(pairs, "\t")
+	}
+
+	header := []string{"CEF:0", "TLDScanner", "tldscanner", deviceVersion, f.class, f.name, strconv.Itoa(f.severity)}
+	pairs := []string{
+		"rt=" + strconv.FormatInt(now.UnixMilli(), 10),
+		"cat=" + f.class,
+		"externalId=" + cefValue(pagingDedupKey(result.TargetDomain, info.Domain)),
+		"msg=" + cefValue(fmt.Sprintf("%s: %s (%s)", f.name, info.Domain, firstNonEmpty(info.Registrar, "unknown registrar"))),
+	}
+	for _, field := range fields {
+		if field.key == "domain" {
+			pairs = append(pairs, "dhost="+cefValue(field.value))
+			continue
+		}
+		custom := cefCustomFields[field.key]
+		pairs = append(pairs, custom+"Label="+field.key, custom+"="+cefValue(field.value))
+	}
+	if hasCreated {
+		pairs = append(pairs, "deviceCustomDate1Label=created", "deviceCustomDate1="+strconv.FormatInt(created.UnixMilli(), 10))
+	}
+	return cefHeader(header) + "|" + strings.Join(pairs, " ")
+}
+
+// cefHeader joins header fields with "|", escaping pipes and backslashes
+// in them
+func cefHeader(fields []string) string {
+	escaped := make([]string, len(fields))
+	for i, field := range fields {
+		escaped[i] = strings.NewReplacer(`\`, `\\`, "|", `\|`).Replace(field)
+	}
+	return strings.Join(escaped, "|")
+}
+
+// cefValue escapes a CEF extension value
+func cefValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\r\n", `\n`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// leefValue replaces the tabs and line breaks LEEF cannot escape
+func leefValue(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
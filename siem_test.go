@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func siemResult() Result {
+	return Result{
+		Scanner:         &BuildInfo{Version: "1.4.0"},
+		TargetDomain:    "example.com",
+		TargetOrg:       "Example Corp",
+		MatchingDomains: []DomainInfo{{Domain: "example.io", Organization: "Example Corp", Registrar: "Gandi SAS", MatchReason: "organization", CreatedDate: "2026-10-01"}},
+		SignalDomains:   []DomainInfo{{Domain: "example.shop", Registrar: "a=b\\c", Signals: []Signal{{Name: "registrar_pivot", Score: 0.86}}}},
+		Lookalikes:      []DomainInfo{{Domain: "examp1e.com", Organization: "Privacy\tGuard", RiskScore: 75}},
+	}
+}
+
+func TestRenderCEF(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	lines := strings.Split(strings.TrimSuffix(string(renderSIEM(siemResult(), "cef", now)), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected an event per finding, got:\n%s", strings.Join(lines, "\n"))
+	}
+	for i, want := range []string{
+		"CEF:0|TLDScanner|tldscanner|1.4.0|match|Domain registered to target organization|3|rt=1792143000000 cat=match externalId=tldscanner:example.com:example.io msg=Domain registered to target organization: example.io (Gandi SAS) dhost=example.io cs1Label=targetDomain cs1=example.com cs2Label=organization cs2=Example Corp cs3Label=registrar cs3=Gandi SAS cs4Label=matchReason cs4=organization deviceCustomDate1Label=created deviceCustomDate1=1790812800000",
+		"CEF:0|TLDScanner|tldscanner|1.4.0|signal|Suspicious domain registration|9|",
+		"CEF:0|TLDScanner|tldscanner|1.4.0|lookalike|Lookalike domain registered|7|",
+	} {
+		if !strings.HasPrefix(lines[i], want) {
+			t.Errorf("Event %d = %q, expected prefix %q", i, lines[i], want)
+		}
+	}
+	if !strings.Contains(lines[1], `cs3=a\=b\\c cs4Label=matchReason cs4=registrar_pivot:0.86`) {
+		t.Errorf("Expected escaped extension values, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "cn1Label=riskScore cn1=75") {
+		t.Errorf("Expected the risk score, got %q", lines[2])
+	}
+}
+
+func TestRenderLEEF(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	lines := strings.Split(strings.TrimSuffix(string(renderSIEM(siemResult(), "leef", now)), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected an event per finding, got:\n%s", strings.Join(lines, "\n"))
+	}
+	expected := "LEEF:1.0|TLDScanner|tldscanner|1.4.0|lookalike|cat=lookalike\tsev=7\tdevTime=2026-10-16T09:30:00+0000\tdevTimeFormat=yyyy-MM-dd'T'HH:mm:ssZ\texternalId=tldscanner:example.com:examp1e.com\tdomain=examp1e.com\ttargetDomain=example.com\torganization=Privacy Guard\triskScore=75"
+	if lines[2] != expected {
+		t.Errorf("renderSIEM() = %q, expected %q", lines[2], expected)
+	}
+}
+
+func TestSIEMOutputIsPiped(t *testing.T) {
+	if !(Config{Format: "cef"}).pipedOutput() || (Config{Format: "leef", Output: "events.log"}).pipedOutput() {
+		t.Error("Expected CEF and LEEF output to stdout to be piped")
+	}
+}
+
+func TestCEFHeader(t *testing.T) {
+	if got := cefHeader([]string{"CEF:0", `a|b\c`}); got != `CEF:0|a\|b\\c` {
+		t.Errorf("cefHeader() = %q", got)
+	}
+}
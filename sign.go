@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// manifestExt is appended to an output name for its -sign manifest
+	manifestExt = ".sha256"
+	// bundleManifest is the -sign manifest of a takedown bundle
+	bundleManifest = "SHA256SUMS"
+	// signatureExt is appended to a manifest name for its detached
+	// signature
+	signatureExt = ".sig"
+)
+
+// validateSign checks that -sign has output files to sign
+func validateSign(config Config) error {
+	if config.Sign != "" && config.Output == "" && config.OutputAll == "" {
+		return fmt.Errorf("-sign requires -o or -oA; output written to stdout cannot be signed")
+	}
+	return nil
+}
+
+// loadSigningKey reads the -sign private key: a PEM PKCS#8 key (Ed25519,
+// ECDSA or RSA), a SEC 1 EC key or a PKCS#1 RSA key. An empty path
+// returns nil.
+func loadSigningKey(path string) (crypto.Signer, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+	var key interface{}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s has an unsupported type %T", path, key)
+	}
+	return signer, nil
+}
+
+// loadVerifyKey reads a PEM public key, certificate or private key to
+// verify signatures with
+func loadVerifyKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", path)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	}
+	signer, err := loadSigningKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
+
+// signData signs data the way `openssl` verifies it: Ed25519 over the data
+// itself, ECDSA (ASN.1) and RSA (PKCS#1 v1.5) over its SHA-256 digest
+func signData(signer crypto.Signer, data []byte) ([]byte, error) {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return signer.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(data)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// verifyData checks a signature made by signData
+func verifyData(key crypto.PublicKey, data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	valid := false
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, data, sig)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], sig)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	if !valid {
+		return fmt.Errorf("signature does not verify")
+	}
+	return nil
+}
+
+// buildManifest hashes files into a sha256sum manifest, naming each file
+// relative to dir so `sha256sum -c` can check it from there
+func buildManifest(dir string, files []string) ([]byte, error) {
+	var manifest bytes.Buffer
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(sum[:]), filepath.ToSlash(name))
+	}
+	return manifest.Bytes(), nil
+}
+
+// writeSignedManifest writes the manifest of files and its detached
+// signature next to it
+func writeSignedManifest(manifestPath string, files []string, signer crypto.Signer) error {
+	manifest, err := buildManifest(filepath.Dir(manifestPath), files)
+	if err != nil {
+		return fmt.Errorf("failed to hash signed files: %w", err)
+	}
+	sig, err := signData(signer, manifest)
+	if err != nil {
+		return fmt.Errorf("failed to sign %s: %w", manifestPath, err)
+	}
+	if err := writeFileAtomic(manifestPath, manifest, 0644); err != nil {
+		return err
+	}
+	return writeFileAtomic(manifestPath+signatureExt, sig, 0644)
+}
+
+// outputFiles returns the files written for -o and for -oA with the given
+// extensions
+func outputFiles(config Config, extensions ...string) []string {
+	var files []string
+	if config.OutputAll != "" {
+		for _, ext := range extensions {
+			files = append(files, outputName(config.OutputAll+ext, config))
+		}
+	}
+	if config.Output != "" {
+		files = append(files, outputName(config.Output, config))
+	}
+	return files
+}
+
+// signOutputs writes the -sign manifest and signature of the output files
+// that were written. The manifest is named after the -oA base name, or the
+// -o file. Failures are reported without failing the scan.
+func signOutputs(config Config, files []string) {
+	if config.Signer == nil {
+		return
+	}
+	var written []string
+	for _, file := range files {
+		if _, err := os.Stat(file); err == nil {
+			written = append(written, file)
+		}
+	}
+	if len(written) == 0 {
+		return
+	}
+	manifest := firstNonEmpty(config.OutputAll, config.Output) + manifestExt
+	if err := writeSignedManifest(manifest, written, config.Signer); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+		return
+	}
+	fmt.Printf("%s[INFO]%s Signed %d files: %s, %s\n", ColorBlue, ColorReset, len(written), manifest, manifest+signatureExt)
+}
+
+// signBundle writes the SHA256SUMS manifest and signature of every file in
+// a takedown bundle directory
+func signBundle(dir string, signer crypto.Signer) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), bundleManifest) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return writeSignedManifest(filepath.Join(dir, bundleManifest), files, signer)
+}
+
+// verifyManifest checks the signature of a manifest and the hash of every
+// file it lists, returning the files that are missing or changed
+func verifyManifest(manifestPath string, key crypto.PublicKey) ([]string, error) {
+	manifest, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := os.ReadFile(manifestPath + signatureExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	if err := verifyData(key, manifest, sig); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestPath, err)
+	}
+
+	var failed []string
+	dir := filepath.Dir(manifestPath)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		sum, name, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("%s: malformed line %q", manifestPath, scanner.Text())
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			failed = append(failed, name+": missing")
+			continue
+		}
+		actual := sha256.Sum256(data)
+		if hex.EncodeToString(actual[:]) != sum {
+			failed = append(failed, name+": modified")
+		}
+	}
+	return failed, scanner.Err()
+}
+
+// runVerify implements `tldscanner verify -key <key.pem> <manifest>`
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "PEM public key, certificate or private key the files were signed with (required)")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s verify -key <key.pem> <manifest>\n\n", os.Args[0])
+		fmt.Printf("Checks the signature of a manifest written with -sign and the SHA-256\n")
+		fmt.Printf("hash of every file it lists.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() != 1 || *keyPath == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	key, err := loadVerifyKey(*keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	failed, err := verifyManifest(fs.Arg(0), key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	for _, failure := range failed {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %s\n", ColorRed, ColorReset, failure)
+	}
+	if len(failed) > 0 {
+		return ExitUsage
+	}
+	fmt.Printf("%s[INFO]%s %s: signature and all file hashes verified\n", ColorBlue, ColorReset, fs.Arg(0))
+	return ExitMatches
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeKeyPEM writes a private key in the given PEM encoding
+func writeKeyPEM(t *testing.T, dir, name string, key crypto.Signer, blockType string) string {
+	t.Helper()
+	var der []byte
+	var err error
+	switch blockType {
+	case "EC PRIVATE KEY":
+		der, err = x509.MarshalECPrivateKey(key.(*ecdsa.PrivateKey))
+	case "RSA PRIVATE KEY":
+		der = x509.MarshalPKCS1PrivateKey(key.(*rsa.PrivateKey))
+	default:
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keys := map[string]string{
+		"ed25519": writeKeyPEM(t, dir, "ed25519.pem", edKey, "PRIVATE KEY"),
+		"ecdsa":   writeKeyPEM(t, dir, "ec.pem", ecKey, "EC PRIVATE KEY"),
+		"rsa":     writeKeyPEM(t, dir, "rsa.pem", rsaKey, "RSA PRIVATE KEY"),
+	}
+
+	for name, keyPath := range keys {
+		signer, err := loadSigningKey(keyPath)
+		if err != nil {
+			t.Fatalf("%s: loadSigningKey failed: %v", name, err)
+		}
+		base := filepath.Join(dir, name)
+		config := Config{OutputAll: base, Signer: signer}
+		for _, ext := range []string{".json", ".txt"} {
+			os.WriteFile(base+ext, []byte(name+ext), 0644)
+		}
+		signOutputs(config, outputFiles(config, ".json", ".csv", ".txt"))
+
+		manifest, err := os.ReadFile(base + manifestExt)
+		if err != nil {
+			t.Fatalf("%s: expected a manifest: %v", name, err)
+		}
+		if lines := strings.Split(strings.TrimSpace(string(manifest)), "\n"); len(lines) != 2 || !strings.HasSuffix(lines[0], "  "+name+".json") {
+			t.Errorf("%s: expected the written files in the manifest, got %q", name, manifest)
+		}
+
+		key, err := loadVerifyKey(keyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed, err := verifyManifest(base+manifestExt, key); err != nil || len(failed) != 0 {
+			t.Errorf("%s: expected the files to verify, got %v, %v", name, failed, err)
+		}
+
+		os.WriteFile(base+".txt", []byte("tampered"), 0644)
+		os.Remove(base + ".json")
+		failed, err := verifyManifest(base+manifestExt, key)
+		if err != nil || !reflect.DeepEqual(failed, []string{name + ".json: missing", name + ".txt: modified"}) {
+			t.Errorf("%s: expected the changes to be found, got %v, %v", name, failed, err)
+		}
+	}
+
+	otherKey, _ := loadVerifyKey(keys["ecdsa"])
+	if _, err := verifyManifest(filepath.Join(dir, "ed25519"+manifestExt), otherKey); err == nil {
+		t.Error("Expected a signature error with another key")
+	}
+}
+
+func TestLoadVerifyKeyPublic(t *testing.T) {
+	dir := t.TempDir()
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(public)
+	path := filepath.Join(dir, "public.pem")
+	os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644)
+
+	key, err := loadVerifyKey(path)
+	if err != nil {
+		t.Fatalf("loadVerifyKey failed: %v", err)
+	}
+	sig, _ := signData(private, []byte("manifest"))
+	if err := verifyData(key, []byte("manifest"), sig); err != nil {
+		t.Errorf("Expected the signature to verify, got %v", err)
+	}
+	if err := verifyData(key, []byte("other"), sig); err == nil {
+		t.Error("Expected a changed manifest to fail")
+	}
+	if _, err := loadSigningKey(path); err == nil {
+		t.Error("Expected a public key to be refused for signing")
+	}
+}
+
+func TestSignBundle(t *testing.T) {
+	dir := t.TempDir()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	for _, name := range []string{"evidence.json", "whois.txt", "letter.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+	}
+	if err := signBundle(dir, key); err != nil {
+		t.Fatalf("signBundle failed: %v", err)
+	}
+	// Signing again does not list the previous manifest
+	if err := signBundle(dir, key); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := os.ReadFile(filepath.Join(dir, bundleManifest))
+	if n := strings.Count(string(manifest), "\n"); n != 3 || !strings.Contains(string(manifest), "  whois.txt\n") {
+		t.Errorf("Expected the 3 bundle files in the manifest, got %q", manifest)
+	}
+	if failed, err := verifyManifest(filepath.Join(dir, bundleManifest), key.Public()); err != nil || len(failed) != 0 {
+		t.Errorf("Expected the bundle to verify, got %v, %v", failed, err)
+	}
+}
+
+func TestValidateSign(t *testing.T) {
+	if err := validateSign(Config{Sign: "key.pem"}); err == nil {
+		t.Error("Expected -sign without an output file to be refused")
+	}
+	if err := validateSign(Config{Sign: "key.pem", Output: "results.json"}); err != nil {
+		t.Errorf("Expected -sign with -o to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"hash/fnv"
+	"html"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// shingleSize is the number of consecutive words in a shingle
+	shingleSize = 4
+	// minShingles is the least text a page needs for a similarity score;
+	// blank and placeholder pages compare as nothing
+	minShingles = 8
+	// cloneSimilarity is the similarity from which a page counts as a
+	// near-clone of the target's homepage
+	cloneSimilarity = 0.8
+)
+
+var (
+	invisiblePattern = regexp.MustCompile(`(?is)<(script|style|noscript)\b.*?</(?:script|style|noscript)\s*>|<!--.*?-->`)
+	tagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// shingleSet holds the hashes of a page's word shingles
+type shingleSet map[uint64]struct{}
+
+// pageWords splits a page's visible text into lowercased words, ignoring
+// markup and scripts
+func pageWords(body []byte) []string {
+	text := tagPattern.ReplaceAllString(invisiblePattern.ReplaceAllString(string(body), " "), " ")
+	return strings.FieldsFunc(strings.ToLower(html.UnescapeString(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// pageShingles hashes every run of shingleSize consecutive words of a
+// page's visible text, ignoring markup, scripts and case
+func pageShingles(body []byte) shingleSet {
+	words := pageWords(body)
+	shingles := shingleSet{}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+shingleSize], " ")))
+		shingles[h.Sum64()] = struct{}{}
+	}
+	return shingles
+}
+
+// similarity is the Jaccard similarity of two shingle sets, rounded to two
+// decimals; 0 when either page has too little text to compare
+func (s shingleSet) similarity(other shingleSet) float64 {
+	if len(s) < minShingles || len(other) < minShingles {
+		return 0
+	}
+	shared := 0
+	for shingle := range s {
+		if _, ok := other[shingle]; ok {
+			shared++
+		}
+	}
+	return math.Round(float64(shared)/float64(len(s)+len(other)-shared)*100) / 100
+}
+
+// compareContent scores how closely a lookalike's landing page resembles
+// the target's homepage, then drops the lookalike's shingles. Lookalikes
+// redirecting to the target's own site are not clones and are not scored.
+func compareContent(info *DomainInfo, targetPage *HTTPProbe) {
+	if !info.HTTP.Live() {
+		return
+	}
+	if targetPage.Live() && urlHost(info.HTTP.URL) != urlHost(targetPage.URL) {
+		info.ContentSimilarity = info.HTTP.shingles.similarity(targetPage.shingles)
+	}
+	info.HTTP.shingles = nil
+}
+
+// urlHost returns the lowercased host name of a URL
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+const targetHomepage = `<html><head><title>Acme Bank</title><style>body { color: red }</style></head>
+<body><h1>Welcome to Acme Bank</h1><p>Sign in to online banking to view your accounts, pay bills and
+transfer money between your Acme accounts.</p><script>var tracking = "ignored words in a script";</script>
+<p>Forgot your password? Reset it online or call our support team.</p></body></html>`
+
+func TestPageShingles(t *testing.T) {
+	shingles := pageShingles([]byte(targetHomepage))
+	if len(shingles) < minShingles {
+		t.Fatalf("Expected the visible text to be shingled, got %d shingles", len(shingles))
+	}
+	// Markup, scripts and case do not change the shingles
+	plain := pageShingles([]byte(`ACME BANK Welcome to Acme Bank. Sign in to online banking to view your accounts, pay bills and
+transfer money between your Acme accounts. Forgot your password? Reset it online or call our support team.`))
+	if similarity := shingles.similarity(plain); similarity != 1 {
+		t.Errorf("Expected identical text to be 1.0 similar, got %.2f", similarity)
+	}
+}
+
+func TestShingleSimilarity(t *testing.T) {
+	target := pageShingles([]byte(targetHomepage))
+	tests := []struct {
+		name     string
+		page     string
+		min, max float64
+	}{
+		{"clone with a changed link", targetHomepage + `<a href="https://acme-login.example/verify">Verify now</a>`, cloneSimilarity, 1},
+		{"unrelated", `<p>This domain is for sale. Contact the owner today to make an offer on this premium name.</p>`, 0, 0.1},
+		{"too short", `<p>Coming soon</p>`, 0, 0},
+	}
+	for _, tt := range tests {
+		got := pageShingles([]byte(tt.page)).similarity(target)
+		if got < tt.min || got > tt.max {
+			t.Errorf("%s: expected similarity in [%.2f, %.2f], got %.2f", tt.name, tt.min, tt.max, got)
+		}
+	}
+}
+
+func TestCompareContent(t *testing.T) {
+	targetPage := &HTTPProbe{URL: "https://www.acme.example/", StatusCode: 200, shingles: pageShingles([]byte(targetHomepage))}
+
+	clone := &DomainInfo{HTTP: &HTTPProbe{URL: "https://acme-login.example/", StatusCode: 200, shingles: pageShingles([]byte(targetHomepage))}}
+	compareContent(clone, targetPage)
+	if clone.ContentSimilarity != 1 || clone.HTTP.shingles != nil {
+		t.Errorf("Expected a scored clone with its shingles dropped, got %.2f", clone.ContentSimilarity)
+	}
+
+	redirect := &DomainInfo{HTTP: &HTTPProbe{URL: "https://WWW.acme.example/", StatusCode: 200, shingles: pageShingles([]byte(targetHomepage))}}
+	compareContent(redirect, targetPage)
+	if redirect.ContentSimilarity != 0 {
+		t.Errorf("Expected a redirect to the target's site not to be scored, got %.2f", redirect.ContentSimilarity)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// smtpNotifier emails an HTML summary through an SMTP relay. STARTTLS is
+// used whenever the server offers it.
+type smtpNotifier struct {
+	server   string
+	from     string
+	to       []string
+	username string
+	password string
+}
+
+func newSMTPNotifier(config Config) *smtpNotifier {
+	from := config.MailFrom
+	if from == "" {
+		host, _ := os.Hostname()
+		from = "tldscanner@" + firstNonEmpty(host, "localhost")
+	}
+	var to []string
+	for _, addr := range strings.Split(config.MailTo, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return &smtpNotifier{
+		server:   config.SMTPServer,
+		from:     from,
+		to:       to,
+		username: config.SMTPUser,
+		password: config.APIKeys["smtp"],
+	}
+}
+
+func (s *smtpNotifier) Name() string {
+	return "email"
+}
+
+func (s *smtpNotifier) Notify(n Notification) error {
+	body, err := renderMailSummary(n)
+	if err != nil {
+		return err
+	}
+	msg := buildMailMessage(s.from, s.to, mailSubject(n), body, time.Now())
+
+	var auth smtp.Auth
+	if s.username != "" {
+		host, _, err := net.SplitHostPort(s.server)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.username, s.password, host)
+	}
+	return smtp.SendMail(s.server, auth, s.from, s.to, msg)
+}
+
+// validateSMTP checks the email notification options
+func validateSMTP(config Config) error {
+	if config.SMTPServer == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(config.SMTPServer); err != nil {
+		return fmt.Errorf("-smtp-server must be host:port: %w", err)
+	}
+	if strings.TrimSpace(config.MailTo) == "" {
+		return fmt.Errorf("-mail-to is required with -smtp-server")
+	}
+	return nil
+}
+
+// mailSubject summarizes the notification in the subject line
+func mailSubject(n Notification) string {
+	if n.Monitor {
+		return fmt.Sprintf("[TLD Scanner] %s: %d alert(s)", n.Result.TargetDomain, len(n.Alerts))
+	}
+	return fmt.Sprintf("[TLD Scanner] %s: %d match(es)", n.Result.TargetDomain, n.Result.TotalMatches)
+}
+
+// buildMailMessage assembles an RFC 5322 message with an HTML body
+func buildMailMessage(from string, to []string, subject, htmlBody string, date time.Time) []byte {
+	var msg bytes.Buffer
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(htmlBody, "\r\n", "\n"), "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// mailSummary is the HTML email layout
+var mailSummary = htmltemplate.Must(htmltemplate.New("mail").Funcs(htmltemplate.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #222;">
+<h2>TLD Scanner: {{.Result.TargetDomain}}</h2>
+<p>Organization: {{.Result.TargetOrg}}<br>
+Scanned {{.Result.TotalScanned}} domains in {{.Result.ScanDuration}}: {{.Result.TotalMatches}} matches, {{.Result.TotalErrors}} errors.</p>
+{{if .Alerts}}<h3>Changes since the previous scan</h3>
+<ul>
+{{range .Alerts}}<li>{{if eq .Kind "new_match"}}<b>New match</b> {{.Domain}}{{else if eq .Kind "removed_match"}}<b>No longer matching</b> {{.Domain}}{{else}}<b>Changed</b> {{.Domain}}<ul>{{range .Changes}}<li>{{.Field}}: {{.Old}} &rarr; {{.New}}</li>{{end}}</ul>{{end}}</li>
+{{end}}</ul>{{end}}
+{{if .Result.MatchingDomains}}<h3>Matching domains</h3>
+<table cellpadding="4" style="border-collapse: collapse;">
+<tr><th align="left">Domain</th><th align="left">Registrar</th><th align="left">Created</th><th align="left">Name Servers</th></tr>
+{{range .Result.MatchingDomains}}<tr><td>{{.Domain}}</td><td>{{.Registrar}}</td><td>{{.CreatedDate}}</td><td>{{join .NameServers ", "}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+// renderMailSummary renders the HTML body of a notification email
+func renderMailSummary(n Notification) (string, error) {
+	var body strings.Builder
+	if err := mailSummary.Execute(&body, n); err != nil {
+		return "", err
+	}
+	return body.String(), nil
+}
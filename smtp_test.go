@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMailMessage(t *testing.T) {
+	n := Notification{
+		Monitor: true,
+		Result:  Result{TargetDomain: "example.com", TargetOrg: "Example Corp", TotalMatches: 1},
+		Alerts: []Alert{
+			{Kind: AlertNewMatch, Domain: "example.io"},
+			{Kind: AlertChanged, Domain: "example.de", Changes: []FieldChange{{Field: "registrar", Old: "A", New: "<B>"}}},
+		},
+	}
+
+	body, err := renderMailSummary(n)
+	if err != nil {
+		t.Fatalf("renderMailSummary failed: %v", err)
+	}
+	for _, want := range []string{"<b>New match</b> example.io", "registrar: A &rarr; &lt;B&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Mail body is missing %q:\n%s", want, body)
+		}
+	}
+
+	date := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	msg := string(buildMailMessage("scanner@example.com", []string{"soc@example.com", "it@example.com"}, mailSubject(n), body, date))
+	for _, want := range []string{
+		"To: soc@example.com, it@example.com\r\n",
+		"Subject: [TLD Scanner] example.com: 2 alert(s)\r\n",
+		"Date: Fri, 16 Oct 2026 03:00:00 +0000\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+		"\r\n\r\n<!DOCTYPE html>",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Message is missing %q", want)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(msg, "\r\n", ""), "\n") {
+		t.Error("Message contains bare LF line endings")
+	}
+}
+
+func TestNotificationEmpty(t *testing.T) {
+	if !(Notification{}).empty() {
+		t.Error("A scan without matches should not notify")
+	}
+	if (Notification{Result: Result{MatchingDomains: []DomainInfo{{}}}}).empty() {
+		t.Error("A scan with matches should notify")
+	}
+	if !(Notification{Monitor: true, Result: Result{MatchingDomains: []DomainInfo{{}}}}).empty() {
+		t.Error("A monitor cycle without alerts should not notify")
+	}
+}
+
+func TestValidateSMTP(t *testing.T) {
+	if err := validateSMTP(Config{}); err != nil {
+		t.Errorf("Email disabled should validate: %v", err)
+	}
+	if err := validateSMTP(Config{SMTPServer: "smtp.example.com:587", MailTo: "soc@example.com"}); err != nil {
+		t.Errorf("Valid options failed: %v", err)
+	}
+	if err := validateSMTP(Config{SMTPServer: "smtp.example.com", MailTo: "soc@example.com"}); err == nil {
+		t.Error("Expected an error for a server without port")
+	}
+	if err := validateSMTP(Config{SMTPServer: "smtp.example.com:25"}); err == nil {
+		t.Error("Expected an error without -mail-to")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stages whose per-domain latencies are collected with -v
+const (
+	statsDNSPrecheck = "dns_precheck"
+	statsWait        = "wait" // for a WHOIS worker and the rate limiter
+	statsWhois       = "whois"
+	statsEnrich      = "enrich"
+	statsScore       = "score"
+)
+
+// statsOrder is the order stages are listed in the summary
+var statsOrder = []string{statsDNSPrecheck, statsWait, statsWhois, statsEnrich, statsScore}
+
+// LatencyStats summarizes the latencies of a stage or a TLD's lookups
+type LatencyStats struct {
+	Count   int   `json:"count"`
+	TotalMs int64 `json:"total_ms"`
+	P50Ms   int64 `json:"p50_ms"`
+	P90Ms   int64 `json:"p90_ms"`
+	P99Ms   int64 `json:"p99_ms"`
+	MaxMs   int64 `json:"max_ms"`
+}
+
+// TLDStats summarizes the lookups of one TLD
+type TLDStats struct {
+	TLD     string       `json:"tld"`
+	Errors  int          `json:"errors,omitempty"`
+	Lookups LatencyStats `json:"lookups"`
+}
+
+// ScanStats breaks the scan time down by stage and by TLD, the TLDs
+// ordered by the lookup time they took in total
+type ScanStats struct {
+	Stages map[string]LatencyStats `json:"stages"`
+	TLDs   []TLDStats              `json:"tlds,omitempty"`
+}
+
+// statsCollector gathers per-domain stage latencies from the workers of a
+// scan; a nil collector ignores them
+type statsCollector struct {
+	mu     sync.Mutex
+	stages map[string][]int64
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{stages: make(map[string][]int64)}
+}
+
+// add records how long a domain spent in a stage
+func (c *statsCollector) add(stage string, elapsed time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stages[stage] = append(c.stages[stage], elapsed.Milliseconds())
+}
+
+// summary computes the stage percentiles and the per-TLD lookup
+// percentiles of the looked-up domains
+func (c *statsCollector) summary(domains []DomainInfo) *ScanStats {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := &ScanStats{Stages: make(map[string]LatencyStats)}
+	for stage, samples := range c.stages {
+		stats.Stages[stage] = latencyStats(samples)
+	}
+
+	samples := make(map[string][]int64)
+	errors := make(map[string]int)
+	for _, info := range domains {
+		tld := "." + lastLabel(info.Domain)
+		samples[tld] = append(samples[tld], info.LookupMs)
+		if info.Error != "" && info.errorCode() != ErrNXDomain {
+			errors[tld]++
+		}
+	}
+	for tld, lookups := range samples {
+		stats.TLDs = append(stats.TLDs, TLDStats{TLD: tld, Errors: errors[tld], Lookups: latencyStats(lookups)})
+	}
+	sort.Slice(stats.TLDs, func(i, j int) bool {
+		a, b := stats.TLDs[i], stats.TLDs[j]
+		if a.Lookups.TotalMs != b.Lookups.TotalMs {
+			return a.Lookups.TotalMs > b.Lookups.TotalMs
+		}
+		return a.TLD < b.TLD
+	})
+	return stats
+}
+
+// latencyStats computes nearest-rank percentiles of millisecond samples
+func latencyStats(samples []int64) LatencyStats {
+	stats := LatencyStats{Count: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, ms := range sorted {
+		stats.TotalMs += ms
+	}
+	rank := func(p float64) int64 {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	stats.P50Ms, stats.P90Ms, stats.P99Ms = rank(0.50), rank(0.90), rank(0.99)
+	stats.MaxMs = sorted[len(sorted)-1]
+	return stats
+}
+
+// printStats prints the stage and slowest TLD lines of the scan summary
+func printStats(stats *ScanStats) {
+	if stats == nil {
+		return
+	}
+	fmt.Println("Stage Latency:")
+	for _, stage := range statsOrder {
+		s, ok := stats.Stages[stage]
+		if !ok || s.Count == 0 {
+			continue
+		}
+		fmt.Printf("  %-13s %6d  p50 %s, p90 %s, p99 %s, max %s\n", stage, s.Count,
+			formatMs(s.P50Ms), formatMs(s.P90Ms), formatMs(s.P99Ms), formatMs(s.MaxMs))
+	}
+
+	var total int64
+	for _, tld := range stats.TLDs {
+		total += tld.Lookups.TotalMs
+	}
+	if total == 0 {
+		return
+	}
+	fmt.Println("Slowest TLDs:")
+	for _, tld := range stats.TLDs[:min(len(stats.TLDs), 10)] {
+		line := fmt.Sprintf("  %-13s %6d  p50 %s, p90 %s, p99 %s, %.0f%% of lookup time", tld.TLD, tld.Lookups.Count,
+			formatMs(tld.Lookups.P50Ms), formatMs(tld.Lookups.P90Ms), formatMs(tld.Lookups.P99Ms),
+			float64(tld.Lookups.TotalMs)*100/float64(total))
+		if tld.Errors > 0 {
+			line += fmt.Sprintf(", %s%d errors%s", ColorRed, tld.Errors, ColorReset)
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyStats(t *testing.T) {
+	var samples []int64
+	for ms := int64(100); ms >= 1; ms-- {
+		samples = append(samples, ms)
+	}
+	stats := latencyStats(samples)
+	if stats.Count != 100 || stats.TotalMs != 5050 || stats.P50Ms != 50 || stats.P90Ms != 90 || stats.P99Ms != 99 || stats.MaxMs != 100 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if samples[0] != 100 {
+		t.Error("Expected the samples to be left unsorted")
+	}
+	if stats := latencyStats(nil); stats != (LatencyStats{}) {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+}
+
+func TestStatsSummary(t *testing.T) {
+	var unset *statsCollector
+	unset.add(statsWhois, time.Second)
+	if unset.summary(nil) != nil {
+		t.Error("Expected no stats without a collector")
+	}
+
+	collector := newStatsCollector()
+	collector.add(statsWhois, 300*time.Millisecond)
+	collector.add(statsWhois, 100*time.Millisecond)
+	stats := collector.summary([]DomainInfo{
+		{Domain: "example.de", LookupMs: 200},
+		{Domain: "example.ru", LookupMs: 5000, Error: "i/o timeout", ErrorCode: ErrTimeout},
+		{Domain: "example.ru", LookupMs: 3000},
+		{Domain: "example.io", LookupMs: 50, Error: "no match", ErrorCode: ErrNXDomain},
+	})
+	if whois := stats.Stages[statsWhois]; whois.Count != 2 || whois.P50Ms != 100 || whois.MaxMs != 300 {
+		t.Errorf("Unexpected whois stage %+v", whois)
+	}
+	if len(stats.TLDs) != 3 || stats.TLDs[0].TLD != ".ru" || stats.TLDs[0].Errors != 1 || stats.TLDs[0].Lookups.TotalMs != 8000 {
+		t.Fatalf("Expected .ru to dominate with one error, got %+v", stats.TLDs)
+	}
+	if stats.TLDs[2].TLD != ".io" || stats.TLDs[2].Errors != 0 {
+		t.Errorf("Expected unregistered domains not to count as errors, got %+v", stats.TLDs[2])
+	}
+}
+
+func TestFinishResult(t *testing.T) {
+	config := Config{Verbose: true, SaveAll: true, KnownAssets: map[string]bool{"example.de": true}}
+	startScan(&config, newScanTiming())
+	config.Stats.add(statsWhois, 200*time.Millisecond)
+
+	all := []DomainInfo{{Domain: "example.de", Organization: "Example Corp", LookupMs: 200}}
+	result := Result{MatchingDomains: all, AllDomains: all}
+	timing := newScanTiming()
+	timing.stage("lookups")
+	finishResult(&result, all, &DomainInfo{Domain: "example.com", Error: "i/o timeout"}, timing, config)
+	if result.Stats == nil || result.Stats.Stages[statsWhois].Count != 1 || result.Timing != timing || timing.FinishedAt.IsZero() {
+		t.Errorf("Expected the timing and -v statistics, got %+v, %+v", result.Stats, result.Timing)
+	}
+	if result.TargetError != "i/o timeout" || len(result.Organizations) != 1 || result.MatchingDomains[0].Ownership != ownershipKnown {
+		t.Errorf("Unexpected target error %q, clusters %+v, ownership %q", result.TargetError, result.Organizations, result.MatchingDomains[0].Ownership)
+	}
+}
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// summaryFormats are the values of summary -format
+var summaryFormats = []string{"markdown", "html"}
+
+// summaryTopRisks is the number of lookalikes an executive summary lists
+const summaryTopRisks = 10
+
+// renewWithin is how close to its expiry the target is flagged for renewal
+const renewWithin = 90 * 24 * time.Hour
+
+// ExecutiveSummary is the brief `tldscanner summary` renders from one or
+// more results, and the data of a -template replacing the built-in layout.
+// Changes compare the oldest and the newest result of each target.
+type ExecutiveSummary struct {
+	GeneratedAt time.Time
+	From, To    time.Time // when the first and the last scan started
+	Scans       int
+	MinRisk     int
+	Targets     []TargetBrief
+	Totals      TargetBrief
+	TopRisks    []RiskBrief
+	// Compared is set when a target was scanned more than once, so the
+	// changes below are known
+	Compared          bool
+	NewMatches        []string
+	RemovedMatches    []string
+	NewLookalikes     []string
+	RemovedLookalikes []string
+	Actions           []string
+}
+
+// TargetBrief counts the findings of the newest result of a target
+type TargetBrief struct {
+	Target       string
+	Organization string
+	ScannedAt    time.Time
+	Scanned      int
+	Matches      int
+	Shadow       int
+	Lookalikes   int
+	HighRisk     int
+	Errors       int
+}
+
+// RiskBrief is a lookalike among the top risks, with the target it
+// imitates
+type RiskBrief struct {
+	Target string
+	DomainInfo
+}
+
+// scannedAt returns when the scan of result started, zero for results
+// written without timing
+func scannedAt(result Result) time.Time {
+	if result.Timing == nil {
+		return time.Time{}
+	}
+	return result.Timing.StartedAt
+}
+
+// summarize builds the executive summary of results, scanned in any order
+func summarize(results []Result, minRisk int, now time.Time) ExecutiveSummary {
+	summary := ExecutiveSummary{GeneratedAt: now, Scans: len(results), MinRisk: minRisk}
+	byTarget := make(map[string][]Result)
+	var targets []string
+	for _, result := range results {
+		target := strings.ToLower(result.TargetDomain)
+		if byTarget[target] == nil {
+			targets = append(targets, target)
+		}
+		byTarget[target] = append(byTarget[target], result)
+		if started := scannedAt(result); !started.IsZero() {
+			if summary.From.IsZero() || started.Before(summary.From) {
+				summary.From = started
+			}
+			if started.After(summary.To) {
+				summary.To = started
+			}
+		}
+	}
+	sort.Strings(targets)
+
+	var risks []RiskBrief
+	for _, target := range targets {
+		scans := byTarget[target]
+		sort.SliceStable(scans, func(i, j int) bool { return scannedAt(scans[i]).Before(scannedAt(scans[j])) })
+		oldest, newest := scans[0], scans[len(scans)-1]
+
+		brief := TargetBrief{
+			Target:       newest.TargetDomain,
+			Organization: newest.TargetOrg,
+			ScannedAt:    scannedAt(newest),
+			Scanned:      newest.TotalScanned,
+			Matches:      newest.TotalMatches,
+			Shadow:       newest.TotalShadow,
+			Lookalikes:   len(newest.Lookalikes),
+			Errors:       newest.TotalErrors,
+		}
+		for _, info := range newest.Lookalikes {
+			if info.RiskScore >= minRisk {
+				brief.HighRisk++
+			}
+			risks = append(risks, RiskBrief{Target: newest.TargetDomain, DomainInfo: info})
+		}
+		summary.Targets = append(summary.Targets, brief)
+		summary.Totals.Scanned += brief.Scanned
+		summary.Totals.Matches += brief.Matches
+		summary.Totals.Shadow += brief.Shadow
+		summary.Totals.Lookalikes += brief.Lookalikes
+		summary.Totals.HighRisk += brief.HighRisk
+		summary.Totals.Errors += brief.Errors
+
+		if len(scans) > 1 {
+			summary.Compared = true
+			added, removed := domainChanges(oldest.MatchingDomains, newest.MatchingDomains)
+			summary.NewMatches = append(summary.NewMatches, added...)
+			summary.RemovedMatches = append(summary.RemovedMatches, removed...)
+			added, removed = domainChanges(oldest.Lookalikes, newest.Lookalikes)
+			summary.NewLookalikes = append(summary.NewLookalikes, added...)
+			summary.RemovedLookalikes = append(summary.RemovedLookalikes, removed...)
+		}
+		summary.Actions = append(summary.Actions, targetActions(newest, now)...)
+	}
+
+	sort.SliceStable(risks, func(i, j int) bool {
+		if risks[i].RiskScore != risks[j].RiskScore {
+			return risks[i].RiskScore > risks[j].RiskScore
+		}
+		return risks[i].Domain < risks[j].Domain
+	})
+	if len(risks) > summaryTopRisks {
+		risks = risks[:summaryTopRisks]
+	}
+	summary.TopRisks = risks
+	summary.Actions = append(findingActions(summary), summary.Actions...)
+	if len(summary.Actions) == 0 {
+		summary.Actions = []string{"No action needed."}
+	}
+	return summary
+}
+
+// domainChanges returns the domains of newer missing from older, and those
+// of older missing from newer
+func domainChanges(older, newer []DomainInfo) (added, removed []string) {
+	seen := make(map[string]bool, len(older))
+	for _, info := range older {
+		seen[info.Domain] = true
+	}
+	current := make(map[string]bool, len(newer))
+	for _, info := range newer {
+		current[info.Domain] = true
+		if !seen[info.Domain] {
+			added = append(added, info.Domain)
+		}
+	}
+	for _, info := range older {
+		if !current[info.Domain] {
+			removed = append(removed, info.Domain)
+		}
+	}
+	return added, removed
+}
+
+// findingActions recommends what to do about the findings of every target
+func findingActions(summary ExecutiveSummary) []string {
+	var actions []string
+	if summary.Totals.HighRisk > 0 {
+		actions = append(actions, fmt.Sprintf("Request the takedown of %s scored %d or more, starting with %s (`tldscanner takedown`).",
+			counted(summary.Totals.HighRisk, "lookalike"), summary.MinRisk, summary.TopRisks[0].Domain))
+	}
+	if len(summary.NewLookalikes) > 0 {
+		actions = append(actions, fmt.Sprintf("Review %s first seen this period.", counted(len(summary.NewLookalikes), "lookalike")))
+	}
+	if summary.Totals.Shadow > 0 {
+		actions = append(actions, fmt.Sprintf("Find out who registered %s and add the legitimate ones to the known domains inventory.",
+			counted(summary.Totals.Shadow, "shadow registration")))
+	}
+	if len(summary.RemovedMatches) > 0 {
+		actions = append(actions, fmt.Sprintf("Check %s no longer matching: a defensive registration may have lapsed or been transferred.",
+			counted(len(summary.RemovedMatches), "domain")))
+	}
+	return actions
+}
+
+// counted renders n of noun, plural unless n is 1: "3 lookalikes"
+func counted(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// targetActions recommends what to do about the target of result itself
+func targetActions(result Result, now time.Time) []string {
+	var actions []string
+	target := result.TargetDomain
+	if len(result.TargetDrift) > 0 {
+		var fields []string
+		for _, change := range result.TargetDrift {
+			fields = append(fields, change.Field)
+		}
+		actions = append(actions, fmt.Sprintf("Confirm the changes to the %s of %s were authorized.", strings.Join(fields, ", "), target))
+	}
+	if result.TargetUnlocked {
+		actions = append(actions, fmt.Sprintf("Enable the registrar transfer lock of %s.", target))
+	}
+	if expires, ok := parseWhoisDateFor(result.TargetExpiry, result.TargetDomain); ok && expires.Before(now.Add(renewWithin)) {
+		actions = append(actions, fmt.Sprintf("Renew %s, which expires on %s.", target, expires.Format("2 January 2006")))
+	}
+	if len(result.Registrations) > 0 {
+		actions = append(actions, fmt.Sprintf("Consider registering %s of %s (`-suggest-registrations -format register`).",
+			counted(len(result.Registrations), "unregistered high-value variant"), target))
+	}
+	if result.TotalScanned > 0 && result.TotalErrors*10 > result.TotalScanned {
+		actions = append(actions, fmt.Sprintf("Rerun %s of the %s scan (`tldscanner retry`); its coverage is incomplete.",
+			counted(result.TotalErrors, "failed lookup"), target))
+	}
+	return actions
+}
+
+// summaryFuncs are the helpers available to executive summary templates
+var summaryFuncs = map[string]interface{}{
+	"join": strings.Join,
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return "unknown"
+		}
+		return t.Format("2 January 2006")
+	},
+	"factors": func(factors []RiskFactor) string {
+		var names []string
+		for _, factor := range factors {
+			names = append(names, factor.Name)
+		}
+		return strings.Join(names, ", ")
+	},
+	// cell escapes the pipes that would split a Markdown table cell
+	"cell": func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+}
+
+// markdownSummary is the built-in Markdown layout of the brief
+const markdownSummary = `# Domain Risk Summary
+
+{{if not .From.IsZero}}{{if .From.Equal .To}}Scan of {{date .From}}{{else}}Scans from {{date .From}} to {{date .To}}{{end}}, {{end}}{{.Scans}} result{{if ne .Scans 1}}s{{end}}, generated {{date .GeneratedAt}}.
+
+## Key Figures
+
+| Target | Scanned | Matches | Shadow | Lookalikes | Risk {{.MinRisk}}+ | Errors |
+|--------|--------:|--------:|-------:|-----------:|--------:|-------:|
+{{range .Targets}}| {{cell .Target}} | {{.Scanned}} | {{.Matches}} | {{.Shadow}} | {{.Lookalikes}} | {{.HighRisk}} | {{.Errors}} |
+{{end}}{{if gt (len .Targets) 1}}{{with .Totals}}| **Total** | {{.Scanned}} | {{.Matches}} | {{.Shadow}} | {{.Lookalikes}} | {{.HighRisk}} | {{.Errors}} |
+{{end}}{{end}}
+## Top Risks
+
+{{if .TopRisks}}| Risk | Domain | Target | Registrar | Created | Why |
+|-----:|--------|--------|-----------|---------|-----|
+{{range .TopRisks}}| {{.RiskScore}} | {{cell .Domain}} | {{cell .Target}} | {{cell .Registrar}} | {{cell .CreatedDate}} | {{cell (factors .RiskFactors)}} |
+{{end}}{{else}}No lookalikes were risk-scored; scan with -risk to rank them.
+{{end}}
+## Changes Since Last Period
+
+{{if .Compared}}- New matches: {{if .NewMatches}}{{join .NewMatches ", "}}{{else}}none{{end}}
+- Matches lost: {{if .RemovedMatches}}{{join .RemovedMatches ", "}}{{else}}none{{end}}
+- New lookalikes: {{if .NewLookalikes}}{{join .NewLookalikes ", "}}{{else}}none{{end}}
+- Lookalikes gone: {{if .RemovedLookalikes}}{{join .RemovedLookalikes ", "}}{{else}}none{{end}}
+{{else}}Only one scan per target was given; pass the results of the previous period too.
+{{end}}
+## Recommended Actions
+
+{{range .Actions}}- {{.}}
+{{end}}`
+
+// htmlSummary is the built-in HTML layout of the brief
+const htmlSummary = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Domain Risk Summary</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; max-width: 60em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; font-size: 14px; }
+th { background: #f4f4f4; }
+td.number { text-align: right; }
+tr.total td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Domain Risk Summary</h1>
+<p>{{if not .From.IsZero}}{{if .From.Equal .To}}Scan of {{date .From}}{{else}}Scans from {{date .From}} to {{date .To}}{{end}}, {{end}}{{.Scans}} result{{if ne .Scans 1}}s{{end}}, generated {{date .GeneratedAt}}.</p>
+<h2>Key Figures</h2>
+<table>
+<tr><th>Target</th><th>Scanned</th><th>Matches</th><th>Shadow</th><th>Lookalikes</th><th>Risk {{.MinRisk}}+</th><th>Errors</th></tr>
+{{range .Targets}}<tr><td>{{.Target}}</td><td class="number">{{.Scanned}}</td><td class="number">{{.Matches}}</td><td class="number">{{.Shadow}}</td><td class="number">{{.Lookalikes}}</td><td class="number">{{.HighRisk}}</td><td class="number">{{.Errors}}</td></tr>
+{{end}}{{if gt (len .Targets) 1}}{{with .Totals}}<tr class="total"><td>Total</td><td class="number">{{.Scanned}}</td><td class="number">{{.Matches}}</td><td class="number">{{.Shadow}}</td><td class="number">{{.Lookalikes}}</td><td class="number">{{.HighRisk}}</td><td class="number">{{.Errors}}</td></tr>
+{{end}}{{end}}</table>
+<h2>Top Risks</h2>
+{{if .TopRisks}}<table>
+<tr><th>Risk</th><th>Domain</th><th>Target</th><th>Registrar</th><th>Created</th><th>Why</th></tr>
+{{range .TopRisks}}<tr><td class="number">{{.RiskScore}}</td><td>{{.Domain}}</td><td>{{.Target}}</td><td>{{.Registrar}}</td><td>{{.CreatedDate}}</td><td>{{factors .RiskFactors}}</td></tr>
+{{end}}</table>
+{{else}}<p>No lookalikes were risk-scored; scan with -risk to rank them.</p>
+{{end}}<h2>Changes Since Last Period</h2>
+{{if .Compared}}<ul>
+<li>New matches: {{if .NewMatches}}{{join .NewMatches ", "}}{{else}}none{{end}}</li>
+<li>Matches lost: {{if .RemovedMatches}}{{join .RemovedMatches ", "}}{{else}}none{{end}}</li>
+<li>New lookalikes: {{if .NewLookalikes}}{{join .NewLookalikes ", "}}{{else}}none{{end}}</li>
+<li>Lookalikes gone: {{if .RemovedLookalikes}}{{join .RemovedLookalikes ", "}}{{else}}none{{end}}</li>
+</ul>
+{{else}}<p>Only one scan per target was given; pass the results of the previous period too.</p>
+{{end}}<h2>Recommended Actions</h2>
+<ul>
+{{range .Actions}}<li>{{.}}</li>
+{{end}}</ul>
+</body>
+</html>
+`
+
+// renderSummary renders the brief in format, through the template file at
+// path instead of the built-in layout when one is given
+func renderSummary(summary ExecutiveSummary, format, path string) ([]byte, error) {
+	text := markdownSummary
+	if format == "html" {
+		text = htmlSummary
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		text = string(data)
+	}
+
+	var out strings.Builder
+	if format == "html" {
+		tmpl, err := htmltemplate.New("summary").Funcs(htmltemplate.FuncMap(summaryFuncs)).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+		if err := tmpl.Execute(&out, summary); err != nil {
+			return nil, fmt.Errorf("failed to render summary: %w", err)
+		}
+		return []byte(out.String()), nil
+	}
+	tmpl, err := template.New("summary").Funcs(template.FuncMap(summaryFuncs)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	if err := tmpl.Execute(&out, summary); err != nil {
+		return nil, fmt.Errorf("failed to render summary: %w", err)
+	}
+	return []byte(out.String()), nil
+}
+
+// runSummary implements `tldscanner summary <results.json>...`: an
+// executive brief of one or more scan results
+func runSummary(args []string) int {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "Output format: "+strings.Join(summaryFormats, " or "))
+	output := fs.String("o", "", "Output file (default stdout)")
+	templatePath := fs.String("template", "", "Go template file replacing the built-in layout (html/template with -format html)")
+	minRisk := fs.Int("min-risk", 70, "Risk score (0-100) from which a lookalike counts as a high risk")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s summary [OPTIONS] <results.json>...\n\n", os.Args[0])
+		fmt.Printf("Writes a short executive brief of JSON scan results: key figures, the top\n")
+		fmt.Printf("%d lookalikes by risk, the changes between the oldest and the newest result\n", summaryTopRisks)
+		fmt.Printf("of each target and recommended actions.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return ExitUsage
+	}
+	if !containsString(summaryFormats, *format) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s unknown summary format %q (valid: %s)\n", ColorRed, ColorReset, *format, strings.Join(summaryFormats, ", "))
+		return ExitUsage
+	}
+
+	var results []Result
+	for _, path := range fs.Args() {
+		result, err := readResult(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		results = append(results, result)
+	}
+	data, err := renderSummary(summarize(results, *minRisk, time.Now()), *format, *templatePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	saveOutput(data, *output, nil)
+	return ExitMatches
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func summaryResults() []Result {
+	lastMonth := time.Date(2026, 9, 16, 3, 0, 0, 0, time.UTC)
+	thisMonth := lastMonth.AddDate(0, 1, 0)
+	return []Result{
+		{
+			TargetDomain: "example.com", TargetOrg: "Example Corp", TargetExpiry: "2026-12-01", TargetUnlocked: true,
+			Timing: &ScanTiming{StartedAt: thisMonth}, TotalScanned: 100, TotalMatches: 1, TotalErrors: 2,
+			MatchingDomains: []DomainInfo{{Domain: "example.de"}},
+			Lookalikes: []DomainInfo{
+				{Domain: "examp1e.com", RiskScore: 85, Registrar: "Name|Cheap", RiskFactors: []RiskFactor{{Name: "recent_registration"}, {Name: "cloned_content"}}},
+				{Domain: "exarnple.com", RiskScore: 40},
+			},
+		},
+		{
+			TargetDomain: "example.com", TargetOrg: "Example Corp", Timing: &ScanTiming{StartedAt: lastMonth}, TotalScanned: 100, TotalMatches: 2,
+			MatchingDomains: []DomainInfo{{Domain: "example.de"}, {Domain: "example.fr"}},
+			Lookalikes:      []DomainInfo{{Domain: "examp1e.com", RiskScore: 85}},
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	summary := summarize(summaryResults(), 70, now)
+
+	if summary.Scans != 2 || !summary.From.Equal(time.Date(2026, 9, 16, 3, 0, 0, 0, time.UTC)) || !summary.To.Equal(time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected period %s - %s of %d scans", summary.From, summary.To, summary.Scans)
+	}
+	if len(summary.Targets) != 1 {
+		t.Fatalf("Expected one target, got %+v", summary.Targets)
+	}
+	if brief := summary.Targets[0]; brief.Matches != 1 || brief.Lookalikes != 2 || brief.HighRisk != 1 || brief.Errors != 2 {
+		t.Errorf("Expected the newest result's figures, got %+v", brief)
+	}
+	if len(summary.TopRisks) != 2 || summary.TopRisks[0].Domain != "examp1e.com" || summary.TopRisks[0].Target != "example.com" {
+		t.Errorf("Unexpected top risks %+v", summary.TopRisks)
+	}
+	if !summary.Compared || strings.Join(summary.RemovedMatches, ",") != "example.fr" || strings.Join(summary.NewLookalikes, ",") != "exarnple.com" ||
+		len(summary.NewMatches) != 0 || len(summary.RemovedLookalikes) != 0 {
+		t.Errorf("Unexpected changes %+v", summary)
+	}
+
+	actions := strings.Join(summary.Actions, "\n")
+	for _, want := range []string{
+		"takedown of 1 lookalike scored 70 or more, starting with examp1e.com",
+		"Review 1 lookalike first seen",
+		"Check 1 domain no longer matching",
+		"transfer lock of example.com",
+		"Renew example.com, which expires on 1 December 2026",
+	} {
+		if !strings.Contains(actions, want) {
+			t.Errorf("Expected an action containing %q, got:\n%s", want, actions)
+		}
+	}
+	if strings.Contains(actions, "retry") {
+		t.Errorf("A 2%% error rate should not ask for a retry:\n%s", actions)
+	}
+}
+
+func TestSummarizeSingleQuietResult(t *testing.T) {
+	summary := summarize([]Result{{TargetDomain: "example.com", TotalScanned: 10}}, 70, time.Now())
+	if summary.Compared || len(summary.TopRisks) != 0 {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if len(summary.Actions) != 1 || summary.Actions[0] != "No action needed." {
+		t.Errorf("Unexpected actions %v", summary.Actions)
+	}
+}
+
+func TestRenderSummary(t *testing.T) {
+	summary := summarize(summaryResults(), 70, time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+
+	markdown, err := renderSummary(summary, "markdown", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Scans from 16 September 2026 to 16 October 2026, 2 results",
+		"| 85 | examp1e.com | example.com | Name\\|Cheap |  | recent_registration, cloned_content |",
+		"- Matches lost: example.fr",
+		"## Recommended Actions",
+	} {
+		if !strings.Contains(string(markdown), want) {
+			t.Errorf("Expected %q in the Markdown summary:\n%s", want, markdown)
+		}
+	}
+
+	html, err := renderSummary(summary, "html", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(html), "<td>Name|Cheap</td>") || !strings.Contains(string(html), "<li>New lookalikes: exarnple.com</li>") {
+		t.Errorf("Unexpected HTML summary:\n%s", html)
+	}
+}
+
+func TestRenderSummaryTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brief.tmpl")
+	if err := os.WriteFile(path, []byte("{{range .Targets}}{{.Target}}: {{.Matches}} matches{{end}}, {{len .Actions}} actions"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := renderSummary(summarize(summaryResults(), 70, time.Now()), "markdown", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "example.com: 1 matches, ") {
+		t.Errorf("Unexpected templated summary %q", data)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogLocalSockets are the local syslog daemon sockets tried in order
+var syslogLocalSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogFacility is the facility of every message: user-level messages
+const syslogFacility = 1
+
+// Syslog severities
+const (
+	syslogWarning = 4
+	syslogNotice  = 5
+	syslogInfo    = 6
+)
+
+// syslogTarget is a parsed -syslog destination
+type syslogTarget struct {
+	network string // udp, tcp, tls or local
+	addr    string
+}
+
+// parseSyslogTarget parses -syslog: "local" for the local syslog daemon or
+// a udp://, tcp:// or tls:// URL whose port defaults to 514 (6514 for tls)
+func parseSyslogTarget(spec string) (syslogTarget, error) {
+	if spec == "local" {
+		return syslogTarget{network: "local"}, nil
+	}
+	u, err := url.Parse(spec)
+	if err != nil || u.Host == "" {
+		return syslogTarget{}, fmt.Errorf("invalid syslog destination %q (expected local or udp://, tcp:// or tls://host[:port])", spec)
+	}
+	port := "514"
+	switch u.Scheme {
+	case "udp", "tcp":
+	case "tls":
+		port = "6514"
+	default:
+		return syslogTarget{}, fmt.Errorf("unsupported syslog transport %q (valid: udp, tcp, tls, local)", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return syslogTarget{network: u.Scheme, addr: net.JoinHostPort(u.Hostname(), port)}, nil
+}
+
+// validateSyslog checks the syslog options
+func validateSyslog(config Config) error {
+	if config.SyslogAddr == "" {
+		return nil
+	}
+	if config.SyslogFormat != "cef" && config.SyslogFormat != "leef" {
+		return fmt.Errorf("unknown syslog format %q (valid: cef, leef)", config.SyslogFormat)
+	}
+	_, err := parseSyslogTarget(config.SyslogAddr)
+	return err
+}
+
+// syslogSender streams findings and scan summaries to a syslog collector as
+// CEF or LEEF events. Remote collectors get RFC 5424 messages, octet-counted
+// over TCP and TLS; the local daemon gets the traditional RFC 3164 format
+// it expects. A nil sender sends nothing.
+type syslogSender struct {
+	target   syslogTarget
+	format   string
+	timeout  time.Duration
+	hostname string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	warned bool
+}
+
+// newSyslogSender connects to the -syslog destination, or returns nil
+// without one
+func newSyslogSender(spec, format string, timeout time.Duration) (*syslogSender, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	target, err := parseSyslogTarget(spec)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	s := &syslogSender{target: target, format: format, timeout: timeout, hostname: firstNonEmpty(hostname, "-")}
+	if s.conn, err = s.dial(); err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return s, nil
+}
+
+func (s *syslogSender) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	switch s.target.network {
+	case "local":
+		var lastErr error
+		for _, path := range syslogLocalSockets {
+			for _, network := range []string{"unixgram", "unix"} {
+				conn, err := dialer.Dial(network, path)
+				if err == nil {
+					return conn, nil
+				}
+				lastErr = err
+			}
+		}
+		return nil, fmt.Errorf("no local syslog daemon: %w", lastErr)
+	case "tls":
+		return tls.DialWithDialer(dialer, "tcp", s.target.addr, &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: rootCAs})
+	}
+	return dialer.Dial(s.target.network, s.target.addr)
+}
+
+// frame renders a message with its syslog header and framing
+func (s *syslogSender) frame(severity int, msgID, msg string, now time.Time) string {
+	pri := syslogFacility*8 + severity
+	if s.target.network == "local" {
+		return fmt.Sprintf("<%d>%s tldscanner[%d]: %s", pri, now.Format(time.Stamp), os.Getpid(), msg)
+	}
+	line := fmt.Sprintf("<%d>1 %s %s tldscanner %d %s - %s",
+		pri, now.UTC().Format("2006-01-02T15:04:05.000Z"), s.hostname, os.Getpid(), msgID, msg)
+	if s.target.network == "udp" {
+		return line
+	}
+	return strconv.Itoa(len(line)) + " " + line
+}
+
+// send writes a message, reconnecting once over stream transports. Failures
+// are reported once and do not fail the scan.
+func (s *syslogSender) send(severity int, msgID, msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := []byte(s.frame(severity, msgID, msg, time.Now()))
+	s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	_, err := s.conn.Write(data)
+	if err != nil && s.target.network != "udp" {
+		s.conn.Close()
+		var conn net.Conn
+		if conn, err = s.dial(); err == nil {
+			s.conn = conn
+			s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+			_, err = s.conn.Write(data)
+		}
+	}
+	if err != nil && !s.warned {
+		s.warned = true
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to send syslog events: %v\n", ColorYellow, ColorReset, err)
+	}
+}
+
+// finding streams one finding as soon as it is found
+func (s *syslogSender) finding(result Result, f siemFinding) {
+	if s == nil {
+		return
+	}
+	severity := syslogNotice
+	if f.severity >= 7 {
+		severity = syslogWarning
+	}
+	s.send(severity, f.class, siemEvent(result, f, s.format, time.Now()))
+}
+
+// summary sends the findings only known once the scan is over, the
+// lookalikes, followed by the scan summary
+func (s *syslogSender) summary(result Result) {
+	if s == nil {
+		return
+	}
+	for _, info := range result.Lookalikes {
+		s.finding(result, newSIEMFinding("lookalike", info))
+	}
+	s.send(syslogInfo, "summary", siemSummary(result, s.format, time.Now()))
+}
+
+// Close closes the connection
+func (s *syslogSender) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// siemSummary renders the scan summary as a CEF or LEEF event
+func siemSummary(result Result, format string, now time.Time) string {
+	deviceVersion := version
+	if result.Scanner != nil {
+		deviceVersion = result.Scanner.Version
+	}
+	counts := []siemField{
+		{"scanned", strconv.Itoa(result.TotalScanned)},
+		{"matches", strconv.Itoa(result.TotalMatches)},
+		{"signals", strconv.Itoa(result.TotalSignals)},
+		{"lookalikes", strconv.Itoa(result.TotalLookalikes)},
+		{"errors", strconv.Itoa(result.TotalErrors)},
+	}
+
+	if format == "leef" {
+		pairs := []string{
+			"cat=summary",
+			"sev=1",
+			"devTime=" + now.UTC().Format("2006-01-02T15:04:05-0700"),
+			"devTimeFormat=yyyy-MM-dd'T'HH:mm:ssZ",
+			"targetDomain=" + leefValue(result.TargetDomain),
+		}
+		for _, count := range counts {
+			pairs = append(pairs, count.key+"="+count.value)
+		}
+		pairs = append(pairs, "duration="+leefValue(result.ScanDuration))
+		return cefHeader([]string{"LEEF:1.0", "TLDScanner", "tldscanner", deviceVersion, "summary"}) + "|" + strings.Join(pairs, "\t")
+	}
+
+	pairs := []string{
+		"rt=" + strconv.FormatInt(now.UnixMilli(), 10),
+		"cat=summary",
+		"msg=" + cefValue(fmt.Sprintf("Scanned %d domains in %s: %d matches, %d signals, %d lookalikes, %d errors",
+			result.TotalScanned, result.ScanDuration, result.TotalMatches, result.TotalSignals, result.TotalLookalikes, result.TotalErrors)),
+		"cs1Label=targetDomain", "cs1=" + cefValue(result.TargetDomain),
+		"cnt=" + strconv.Itoa(result.TotalScanned),
+	}
+	// CEF has three custom number fields; the signal count is in msg
+	for i, count := range []siemField{counts[1], counts[3], counts[4]} {
+		n := strconv.Itoa(i + 1)
+		pairs = append(pairs, "cn"+n+"Label="+count.key, "cn"+n+"="+count.value)
+	}
+	return cefHeader([]string{"CEF:0", "TLDScanner", "tldscanner", deviceVersion, "summary", "Scan finished", "1"}) + "|" + strings.Join(pairs, " ")
+}
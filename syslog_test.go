@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSyslogTarget(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected syslogTarget
+	}{
+		{"local", syslogTarget{network: "local"}},
+		{"udp://collector", syslogTarget{network: "udp", addr: "collector:514"}},
+		{"tcp://collector:1514", syslogTarget{network: "tcp", addr: "collector:1514"}},
+		{"tls://[::1]", syslogTarget{network: "tls", addr: "[::1]:6514"}},
+	}
+	for _, tt := range tests {
+		target, err := parseSyslogTarget(tt.spec)
+		if err != nil || target != tt.expected {
+			t.Errorf("parseSyslogTarget(%q) = %+v, %v; expected %+v", tt.spec, target, err, tt.expected)
+		}
+	}
+	for _, spec := range []string{"collector:514", "http://collector", "udp://"} {
+		if _, err := parseSyslogTarget(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestValidateSyslog(t *testing.T) {
+	if err := validateSyslog(Config{SyslogAddr: "udp://collector", SyslogFormat: "leef"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := validateSyslog(Config{SyslogAddr: "udp://collector", SyslogFormat: "json"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestSyslogSenderUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+	defer conn.Close()
+
+	sender, err := newSyslogSender("udp://"+conn.LocalAddr().String(), "cef", 5*time.Second)
+	if err != nil {
+		t.Fatalf("newSyslogSender failed: %v", err)
+	}
+	defer sender.Close()
+	sender.finding(Result{TargetDomain: "example.com"}, newSIEMFinding("lookalike", DomainInfo{Domain: "examp1e.com", RiskScore: 80}))
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	// user.warning, RFC 5424 header, then the CEF event
+	pattern := regexp.MustCompile(`^<12>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{3}Z \S+ tldscanner \d+ lookalike - CEF:0\|TLDScanner\|tldscanner\|[^|]+\|lookalike\|Lookalike domain registered\|8\|.*dhost=examp1e\.com`)
+	if msg := string(buf[:n]); !pattern.MatchString(msg) {
+		t.Errorf("Unexpected message %q", msg)
+	}
+}
+
+func TestSyslogSenderTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		var messages []string
+		for len(messages) < 2 {
+			// Octet counting: the message length, a space, the message
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				break
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(reader, msg); err != nil {
+				break
+			}
+			messages = append(messages, string(msg))
+		}
+		received <- messages
+	}()
+
+	sender, err := newSyslogSender("tcp://"+ln.Addr().String(), "leef", 5*time.Second)
+	if err != nil {
+		t.Fatalf("newSyslogSender failed: %v", err)
+	}
+	defer sender.Close()
+	sender.summary(Result{
+		TargetDomain:    "example.com",
+		Lookalikes:      []DomainInfo{{Domain: "examp1e.com", RiskScore: 40}},
+		TotalScanned:    1500,
+		TotalMatches:    3,
+		TotalLookalikes: 1,
+		ScanDuration:    "2m0s",
+	})
+
+	select {
+	case messages := <-received:
+		if len(messages) != 2 {
+			t.Fatalf("Expected the lookalike and the summary, got %q", messages)
+		}
+		if !strings.Contains(messages[0], " lookalike - LEEF:1.0|") || !strings.HasPrefix(messages[0], "<13>1 ") {
+			t.Errorf("Unexpected lookalike message %q", messages[0])
+		}
+		if !strings.HasPrefix(messages[1], "<14>1 ") || !strings.Contains(messages[1], "|summary|cat=summary\tsev=1\t") ||
+			!strings.Contains(messages[1], "\tscanned=1500\tmatches=3\tsignals=0\tlookalikes=1\terrors=0\tduration=2m0s") {
+			t.Errorf("Unexpected summary message %q", messages[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for messages")
+	}
+}
+
+func TestSIEMSummaryCEF(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	event := siemSummary(Result{Scanner: &BuildInfo{Version: "1.4.0"}, TargetDomain: "example.com", TotalScanned: 10, TotalMatches: 2, TotalErrors: 1, ScanDuration: "5s"}, "cef", now)
+	expected := "CEF:0|TLDScanner|tldscanner|1.4.0|summary|Scan finished|1|rt=1792143000000 cat=summary msg=Scanned 10 domains in 5s: 2 matches, 0 signals, 0 lookalikes, 1 errors cs1Label=targetDomain cs1=example.com cnt=10 cn1Label=matches cn1=2 cn2Label=lookalikes cn2=0 cn3Label=errors cn3=1"
+	if event != expected {
+		t.Errorf("siemSummary() = %q, expected %q", event, expected)
+	}
+}
+
+func TestNilSyslogSender(t *testing.T) {
+	var sender *syslogSender
+	sender.finding(Result{}, newSIEMFinding("match", DomainInfo{Domain: "example.net"}))
+	sender.summary(Result{})
+	if err := sender.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
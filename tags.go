@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// tagsBucket holds a DomainTag per annotated domain
+var tagsBucket = []byte("tags")
+
+// suppressTag marks a domain as a false positive: scans drop it from the
+// matches, signals and lookalikes and raise no alert for it
+const suppressTag = "false-positive"
+
+// DomainTag is the user annotation of a domain, kept in the history
+// database across runs
+type DomainTag struct {
+	Tags      []string  `json:"tags,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// tagDomain adds tags to a domain and replaces its note when one is given
+func (h *historyStore) tagDomain(domain string, tags []string, note string, now time.Time) (DomainTag, error) {
+	var tag DomainTag
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(tagsBucket)
+		if err != nil {
+			return err
+		}
+		if data := bucket.Get([]byte(domain)); data != nil {
+			if err := json.Unmarshal(data, &tag); err != nil {
+				return err
+			}
+		}
+		for _, t := range tags {
+			if !containsString(tag.Tags, t) {
+				tag.Tags = append(tag.Tags, t)
+			}
+		}
+		if note != "" {
+			tag.Note = note
+		}
+		tag.UpdatedAt = now.UTC()
+		data, err := json.Marshal(tag)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(domain), data)
+	})
+	return tag, err
+}
+
+// untagDomain removes tags from a domain, or its whole annotation when no
+// tags are given. A domain left without tags or note is forgotten.
+func (h *historyStore) untagDomain(domain string, tags []string, now time.Time) (DomainTag, error) {
+	var tag DomainTag
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(tagsBucket)
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(domain))
+		if data == nil {
+			return nil
+		}
+		if len(tags) == 0 {
+			return bucket.Delete([]byte(domain))
+		}
+		if err := json.Unmarshal(data, &tag); err != nil {
+			return err
+		}
+		var kept []string
+		for _, t := range tag.Tags {
+			if !containsString(tags, t) {
+				kept = append(kept, t)
+			}
+		}
+		tag.Tags = kept
+		if len(tag.Tags) == 0 && tag.Note == "" {
+			return bucket.Delete([]byte(domain))
+		}
+		tag.UpdatedAt = now.UTC()
+		data, err := json.Marshal(tag)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(domain), data)
+	})
+	return tag, err
+}
+
+// domainTags returns every annotated domain
+func (h *historyStore) domainTags() (map[string]DomainTag, error) {
+	tags := make(map[string]DomainTag)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(tagsBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var tag DomainTag
+			if err := json.Unmarshal(v, &tag); err != nil {
+				return err
+			}
+			tags[string(k)] = tag
+			return nil
+		})
+	})
+	return tags, err
+}
+
+// loadDomainTags reads the annotations of the history database at path.
+// A missing database has none and is not created.
+func loadDomainTags(path string) (map[string]DomainTag, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+	store, err := openHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	return store.domainTags()
+}
+
+// tagResult applies the annotations of the history database to a scan
+// result. An unreadable database is reported without failing the scan.
+func tagResult(result *Result, config Config) {
+	tags, err := loadDomainTags(config.HistoryDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Domain tags not applied: %v\n", ColorYellow, ColorReset, err)
+		return
+	}
+	applyDomainTags(result, tags)
+}
+
+// applyDomainTags labels the annotated domains of a result with their tags
+// and note, and drops the ones tagged false-positive from the matches,
+// signals and lookalikes, listing them in SuppressedDomains instead
+func applyDomainTags(result *Result, tags map[string]DomainTag) {
+	if len(tags) == 0 {
+		return
+	}
+	suppressed := make(map[string]bool)
+	label := func(domains []DomainInfo) []DomainInfo {
+		if domains == nil {
+			return nil
+		}
+		kept := make([]DomainInfo, 0, len(domains))
+		for _, info := range domains {
+			tag, ok := tags[info.Domain]
+			if !ok {
+				kept = append(kept, info)
+				continue
+			}
+			if containsString(tag.Tags, suppressTag) {
+				suppressed[info.Domain] = true
+				continue
+			}
+			info.Tags = mergeTags(info.Tags, tag.Tags)
+			info.Note = tag.Note
+			kept = append(kept, info)
+		}
+		return kept
+	}
+	result.MatchingDomains = label(result.MatchingDomains)
+	result.SignalDomains = label(result.SignalDomains)
+	result.Lookalikes = label(result.Lookalikes)
+	// all_domains is the raw record of the scan: labeled, never filtered
+	for i, info := range result.AllDomains {
+		if tag, ok := tags[info.Domain]; ok {
+			result.AllDomains[i].Tags = mergeTags(info.Tags, tag.Tags)
+			result.AllDomains[i].Note = tag.Note
+		}
+	}
+
+	result.TotalMatches = len(result.MatchingDomains)
+	result.TotalSignals = len(result.SignalDomains)
+	result.TotalLookalikes = len(result.Lookalikes)
+	for domain := range suppressed {
+		if !containsString(result.SuppressedDomains, domain) {
+			result.SuppressedDomains = append(result.SuppressedDomains, domain)
+		}
+	}
+	sort.Strings(result.SuppressedDomains)
+}
+
+// mergeTags appends the tags missing from existing
+func mergeTags(existing, tags []string) []string {
+	merged := append([]string(nil), existing...)
+	for _, tag := range tags {
+		if !containsString(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// suppressAlerts drops the alerts about suppressed domains
+func suppressAlerts(alerts []Alert, suppressed []string) []Alert {
+	if len(suppressed) == 0 {
+		return alerts
+	}
+	var kept []Alert
+	for _, alert := range alerts {
+		if !containsString(suppressed, alert.Domain) {
+			kept = append(kept, alert)
+		}
+	}
+	return kept
+}
+
+// runTag implements `tldscanner tag <domain> -tag <tag> -note <note>`
+func runTag(args []string) int {
+	fs := flag.NewFlagSet("tag", flag.ContinueOnError)
+	dbPath := fs.String("db", defaultHistoryPath(), "Path to the history database")
+	var tags stringList
+	fs.Var(&tags, "tag", "Tag to add or remove (repeatable or comma-separated); \""+suppressTag+"\" suppresses the domain")
+	note := fs.String("note", "", "Free-text note replacing the domain's note")
+	remove := fs.Bool("remove", false, "Remove the -tag tags, or the whole annotation without -tag")
+	list := fs.Bool("list", false, "List every annotated domain")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s tag <domain> [-tag <tag>]... [-note <note>] [-remove]\n", os.Args[0])
+		fmt.Printf("       %s tag -list\n\n", os.Args[0])
+		fmt.Printf("Annotates a domain in the history database. Later scans label it with\n")
+		fmt.Printf("its tags and note; a domain tagged %s is left out of the\n", suppressTag)
+		fmt.Printf("matches, signals and lookalikes and raises no monitor alert. Without\n")
+		fmt.Printf("-tag, -note or -remove the domain's annotation is printed.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	// Options may come before or after the domain
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	domain := strings.ToLower(strings.TrimSpace(fs.Arg(0)))
+	if fs.NArg() > 0 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return ExitUsage
+		}
+	}
+	if fs.NArg() != 0 || (domain == "") != *list {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	store, err := openHistory(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	defer store.Close()
+
+	if *list {
+		all, err := store.domainTags()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		domains := make([]string, 0, len(all))
+		for domain := range all {
+			domains = append(domains, domain)
+		}
+		sort.Strings(domains)
+		for _, domain := range domains {
+			fmt.Print(formatDomainTag(domain, all[domain]))
+		}
+		if len(domains) == 0 {
+			return ExitNoMatches
+		}
+		return ExitMatches
+	}
+
+	var tag DomainTag
+	switch {
+	case *remove:
+		tag, err = store.untagDomain(domain, tags, time.Now())
+	case len(tags) > 0 || *note != "":
+		tag, err = store.tagDomain(domain, tags, *note, time.Now())
+	default:
+		var all map[string]DomainTag
+		if all, err = store.domainTags(); err == nil {
+			var ok bool
+			if tag, ok = all[domain]; !ok {
+				fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s is not annotated\n", ColorYellow, ColorReset, domain)
+				return ExitNoMatches
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if len(tag.Tags) == 0 && tag.Note == "" {
+		fmt.Printf("%s[INFO]%s %s is no longer annotated\n", ColorBlue, ColorReset, domain)
+		return ExitMatches
+	}
+	fmt.Print(formatDomainTag(domain, tag))
+	return ExitMatches
+}
+
+// formatDomainTag renders a domain's annotation
+func formatDomainTag(domain string, tag DomainTag) string {
+	line := fmt.Sprintf("%s%s%s", ColorGreen, domain, ColorReset)
+	if len(tag.Tags) > 0 {
+		line += " [" + strings.Join(tag.Tags, ", ") + "]"
+	}
+	if tag.Note != "" {
+		line += fmt.Sprintf(" %q", tag.Note)
+	}
+	return line + "\n"
+}
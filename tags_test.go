@@ -0,0 +1,80 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDomainTagStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	store, err := openHistory(path)
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	if _, err := store.tagDomain("example.shop", []string{"false-positive"}, "partner site", now); err != nil {
+		t.Fatalf("tagDomain failed: %v", err)
+	}
+	tag, err := store.tagDomain("example.shop", []string{"partner", "false-positive"}, "", now)
+	if err != nil || !reflect.DeepEqual(tag.Tags, []string{"false-positive", "partner"}) || tag.Note != "partner site" {
+		t.Fatalf("Expected merged tags keeping the note, got %+v, %v", tag, err)
+	}
+	store.tagDomain("examp1e.com", []string{"takedown-sent"}, "", now)
+
+	if tag, _ = store.untagDomain("example.shop", []string{"false-positive"}, now); !reflect.DeepEqual(tag.Tags, []string{"partner"}) {
+		t.Errorf("Expected only the partner tag left, got %+v", tag)
+	}
+	if _, err := store.untagDomain("examp1e.com", nil, now); err != nil {
+		t.Fatalf("untagDomain failed: %v", err)
+	}
+	store.Close()
+
+	// Scans read the annotations from the database file
+	tags, err := loadDomainTags(path)
+	if err != nil {
+		t.Fatalf("loadDomainTags failed: %v", err)
+	}
+	if len(tags) != 1 || tags["example.shop"].Note != "partner site" {
+		t.Errorf("Unexpected annotations %+v", tags)
+	}
+	if tags, err := loadDomainTags(filepath.Join(t.TempDir(), "missing.db")); tags != nil || err != nil {
+		t.Errorf("Expected no annotations without a database, got %v, %v", tags, err)
+	}
+}
+
+func TestApplyDomainTags(t *testing.T) {
+	tags := map[string]DomainTag{
+		"example.shop": {Tags: []string{"false-positive"}, Note: "partner site"},
+		"example.net":  {Tags: []string{"reviewed"}, Note: "owned by the EU subsidiary"},
+	}
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.net", Tags: []string{"script"}}, {Domain: "example.shop"}},
+		Lookalikes:      []DomainInfo{{Domain: "example.shop"}, {Domain: "examp1e.com"}},
+		AllDomains:      []DomainInfo{{Domain: "example.shop"}},
+		TotalMatches:    2,
+		TotalLookalikes: 2,
+	}
+	applyDomainTags(&result, tags)
+
+	if len(result.MatchingDomains) != 1 || result.TotalMatches != 1 || len(result.Lookalikes) != 1 || result.TotalLookalikes != 1 {
+		t.Fatalf("Expected example.shop to be suppressed, got %+v", result)
+	}
+	match := result.MatchingDomains[0]
+	if !reflect.DeepEqual(match.Tags, []string{"script", "reviewed"}) || match.Note != "owned by the EU subsidiary" {
+		t.Errorf("Expected the match to be labeled, got %+v", match)
+	}
+	if !reflect.DeepEqual(result.SuppressedDomains, []string{"example.shop"}) {
+		t.Errorf("Unexpected suppressed domains %v", result.SuppressedDomains)
+	}
+	if len(result.AllDomains) != 1 || result.AllDomains[0].Note != "partner site" {
+		t.Errorf("Expected all_domains to be labeled but kept, got %+v", result.AllDomains)
+	}
+
+	alerts := suppressAlerts([]Alert{{Kind: AlertNewMatch, Domain: "example.shop"}, {Kind: AlertNewMatch, Domain: "example.net"}}, result.SuppressedDomains)
+	if len(alerts) != 1 || alerts[0].Domain != "example.net" {
+		t.Errorf("Expected the suppressed domain's alert to be dropped, got %v", alerts)
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// crtshURL is the crt.sh certificate transparency search endpoint
+var crtshURL = "https://crt.sh/"
+
+// maxCTEntries caps the certificates kept as evidence, newest first
+const maxCTEntries = 50
+
+// CTEntry is a certificate logged for a domain in certificate transparency
+type CTEntry struct {
+	ID        int64    `json:"id"`
+	LoggedAt  string   `json:"logged_at"`
+	NotBefore string   `json:"not_before"`
+	NotAfter  string   `json:"not_after"`
+	Issuer    string   `json:"issuer"`
+	Names     []string `json:"names"`
+}
+
+// TakedownEvidence is the evidence bundle of a takedown request, written
+// as evidence.json next to the raw WHOIS text, the screenshot and the
+// letter
+type TakedownEvidence struct {
+	Domain        string      `json:"domain"`
+	TargetDomain  string      `json:"target_domain"`
+	TargetOrg     string      `json:"target_organization,omitempty"`
+	Brand         string      `json:"brand,omitempty"`
+	CollectedAt   time.Time   `json:"collected_at"`
+	Record        DomainInfo  `json:"record"`
+	WhoisServer   string      `json:"whois_server,omitempty"`
+	DNS           *DNSRecords `json:"dns,omitempty"`
+	Certificates  []CTEntry   `json:"certificates,omitempty"`
+	Screenshot    string      `json:"screenshot,omitempty"`
+	ScreenshotURL string      `json:"screenshot_url,omitempty"`
+	AbuseEmail    string      `json:"abuse_email,omitempty"`
+	AbusePhone    string      `json:"abuse_phone,omitempty"`
+	// Errors lists the evidence that could not be collected
+	Errors []string `json:"errors,omitempty"`
+
+	// Whois is the raw WHOIS response, saved as whois.txt
+	Whois string `json:"-"`
+}
+
+// takedownLetter is the data a letter template is rendered with
+type takedownLetter struct {
+	*TakedownEvidence
+	Reporter string
+	Date     string
+}
+
+// defaultTakedownLetter is the built-in letter template
+const defaultTakedownLetter = `To: {{if .AbuseEmail}}{{.AbuseEmail}}{{else}}{{.Record.Registrar}} abuse desk{{end}}
+Subject: Abuse report: {{.Domain}} impersonates {{or .Brand .TargetOrg .TargetDomain}}
+
+Dear {{or .Record.Registrar "registrar"}} abuse team,
+
+We are writing to report the domain {{.Domain}}, registered through your
+service{{with .Record.CreatedDate}} on {{.}}{{end}}, which impersonates {{or .Brand .TargetOrg .TargetDomain}}, the
+holder of {{.TargetDomain}}. The domain is not owned or authorized by us.
+{{with .Record.RiskFactors}}
+Our assessment found:
+{{range .}}  - {{.Detail}}
+{{end}}{{end}}
+Evidence collected on {{.Date}}:
+  - WHOIS record{{with .WhoisServer}} from {{.}}{{end}} (whois.txt)
+{{- with .DNS}}
+  - DNS records:{{range .A}} A {{.}}{{end}}{{range .MX}} MX {{.}}{{end}}{{range .NS}} NS {{.}}{{end}}
+{{- end}}
+{{- if .Certificates}}
+  - {{len .Certificates}} certificates in certificate transparency logs, latest issued by {{(index .Certificates 0).Issuer}}
+{{- end}}
+{{- with .Record.HTTP}}{{if .StatusCode}}
+  - Live website at {{.URL}}{{with .Title}} titled "{{.}}"{{end}}
+{{- end}}{{end}}
+{{- with .Screenshot}}
+  - Screenshot of the website ({{.}})
+{{- end}}
+
+We ask that you suspend the domain in accordance with your acceptable use
+policy and the ICANN Registrar Accreditation Agreement. The full evidence
+bundle is attached.
+
+Regards,
+{{or .Reporter "The security team"}}
+`
+
+// findTakedownDomain returns the record of domain in a scan result. Domains
+// owned by the target organization are refused.
+func findTakedownDomain(result Result, domain string) (DomainInfo, error) {
+	for _, info := range result.MatchingDomains {
+		if info.Domain == domain {
+			return DomainInfo{}, fmt.Errorf("%s belongs to the target organization (%s)", domain, info.MatchReason)
+		}
+	}
+	for _, list := range [][]DomainInfo{result.Lookalikes, result.SignalDomains, result.AllDomains} {
+		for _, info := range list {
+			if info.Domain == domain {
+				return info, nil
+			}
+		}
+	}
+	return DomainInfo{}, fmt.Errorf("%s is not in the scan result", domain)
+}
+
+// lookupDNSRecords resolves the current A, AAAA, MX, NS and TXT records of
+// domain
+func lookupDNSRecords(domain string, timeout time.Duration) (*DNSRecords, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resolver := net.DefaultResolver
+	records := &DNSRecords{}
+
+	addrs, err := resolver.LookupIPAddr(ctx, domain)
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			records.A = append(records.A, addr.IP.String())
+		} else {
+			records.AAAA = append(records.AAAA, addr.IP.String())
+		}
+	}
+	if mxs, err := resolver.LookupMX(ctx, domain); err == nil {
+		for _, mx := range mxs {
+			records.MX = append(records.MX, strings.TrimSuffix(mx.Host, "."))
+		}
+	}
+	if nss, err := resolver.LookupNS(ctx, domain); err == nil {
+		for _, ns := range nss {
+			records.NS = append(records.NS, strings.TrimSuffix(ns.Host, "."))
+		}
+	}
+	if txts, err := resolver.LookupTXT(ctx, domain); err == nil {
+		records.TXT = txts
+	}
+	return records, nil
+}
+
+// lookupCTEntries searches crt.sh for the certificates logged for domain
+// and its subdomains, newest first
+func lookupCTEntries(client *http.Client, domain string) ([]CTEntry, error) {
+	resp, err := client.Get(crtshURL + "?output=json&q=" + url.QueryEscape("%."+domain))
+	if err != nil {
+		return nil, fmt.Errorf("crt.sh: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("crt.sh returned HTTP %d", resp.StatusCode)
+	}
+
+	var logged []struct {
+		ID             int64  `json:"id"`
+		EntryTimestamp string `json:"entry_timestamp"`
+		NotBefore      string `json:"not_before"`
+		NotAfter       string `json:"not_after"`
+		IssuerName     string `json:"issuer_name"`
+		NameValue      string `json:"name_value"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&logged); err != nil {
+		return nil, fmt.Errorf("crt.sh: %w", err)
+	}
+	sort.Slice(logged, func(i, j int) bool { return logged[i].EntryTimestamp > logged[j].EntryTimestamp })
+
+	var entries []CTEntry
+	for _, cert := range logged {
+		if len(entries) == maxCTEntries {
+			break
+		}
+		entries = append(entries, CTEntry{
+			ID:        cert.ID,
+			LoggedAt:  cert.EntryTimestamp,
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+			Issuer:    cert.IssuerName,
+			Names:     strings.Fields(cert.NameValue),
+		})
+	}
+	return entries, nil
+}
+
+// downloadFile saves the body of a GET request to path
+func downloadFile(client *http.Client, src, path string) error {
+	resp, err := client.Get(src)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", src, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// collectEvidence gathers a fresh WHOIS snapshot, the DNS records, the
+// certificate transparency entries and the screenshot of a domain. Evidence
+// that cannot be collected is noted in Errors rather than failing the
+// bundle.
+func collectEvidence(result Result, info DomainInfo, dir string, config Config) *TakedownEvidence {
+	evidence := &TakedownEvidence{
+		Domain:       info.Domain,
+		TargetDomain: result.TargetDomain,
+		TargetOrg:    result.TargetOrg,
+		Brand:        result.Brand,
+		CollectedAt:  time.Now().UTC(),
+		Record:       info,
+		AbuseEmail:   info.AbuseEmail,
+		AbusePhone:   info.AbusePhone,
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	client := &http.Client{Timeout: timeout}
+	note := func(what string, err error) {
+		evidence.Errors = append(evidence.Errors, fmt.Sprintf("%s: %v", what, err))
+	}
+
+	raw, server, err := queryWhois(newWhoisClient(config), info.Domain, config.WhoisQueries.query(info.Domain), nil, nil)
+	if err != nil {
+		note("whois", err)
+	} else {
+		evidence.Whois, evidence.WhoisServer = raw, server
+		if evidence.AbuseEmail == "" && evidence.AbusePhone == "" {
+			evidence.AbuseEmail, evidence.AbusePhone = parseAbuseContact(raw)
+		}
+	}
+
+	if evidence.DNS, err = lookupDNSRecords(info.Domain, timeout); err != nil {
+		note("dns", err)
+	}
+	if evidence.Certificates, err = lookupCTEntries(client, info.Domain); err != nil {
+		note("certificate transparency", err)
+	}
+
+	if info.URLScan != nil && info.URLScan.ScreenshotURL != "" {
+		evidence.ScreenshotURL = info.URLScan.ScreenshotURL
+		if err := downloadFile(client, info.URLScan.ScreenshotURL, filepath.Join(dir, "screenshot.png")); err != nil {
+			note("screenshot", err)
+		} else {
+			evidence.Screenshot = "screenshot.png"
+		}
+	}
+	return evidence
+}
+
+// renderTakedownLetter renders the letter template, the built-in one when
+// path is empty
+func renderTakedownLetter(evidence *TakedownEvidence, path, reporter string) ([]byte, error) {
+	text := defaultTakedownLetter
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read letter template: %w", err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("letter").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse letter template: %w", err)
+	}
+	var out strings.Builder
+	letter := takedownLetter{TakedownEvidence: evidence, Reporter: reporter, Date: evidence.CollectedAt.Format("2 January 2006")}
+	if err := tmpl.Execute(&out, letter); err != nil {
+		return nil, fmt.Errorf("failed to render letter: %w", err)
+	}
+	return []byte(out.String()), nil
+}
+
+// writeTakedownBundle writes the evidence, the raw WHOIS text and the
+// letter into dir
+func writeTakedownBundle(dir string, evidence *TakedownEvidence, letter []byte) error {
+	data, err := json.MarshalIndent(evidence, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{"evidence.json": data, "letter.txt": letter}
+	if evidence.Whois != "" {
+		files["whois.txt"] = []byte(evidence.Whois)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// runTakedown implements `tldscanner takedown <results.json> -domain <d>`:
+// an evidence bundle and a takedown letter for one lookalike of a scan
+func runTakedown(args []string) int {
+	var config Config
+	fs := flag.NewFlagSet("takedown", flag.ContinueOnError)
+	registerFlags(fs, &config)
+	domainFlag := fs.String("domain", "", "Domain to report (required)")
+	letterPath := fs.String("letter", "", "Go text/template file for the letter (default built-in)")
+	reporter := fs.String("reporter", "", "Name and contact details signing the letter")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s takedown <results.json> -domain <domain> [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Collects the evidence for a takedown request against a domain of a scan\n")
+		fmt.Printf("result (WHOIS snapshot, DNS records, certificate transparency entries,\n")
+		fmt.Printf("screenshot and registrar abuse contact) and renders a takedown letter.\n")
+		fmt.Printf("The bundle is written to the -o directory, takedown-<domain> by default,\n")
+		fmt.Printf("and signed with -sign.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	// Options may come before or after the result file
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+	path := fs.Arg(0)
+	if fs.NArg() > 0 {
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return ExitUsage
+		}
+	}
+	if path == "" || fs.NArg() != 0 || *domainFlag == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+
+	result, err := readResult(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	domain := strings.ToLower(strings.TrimSpace(*domainFlag))
+	info, err := findTakedownDomain(result, domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if config.Output == "" {
+		config.Output = "takedown-" + domain
+	}
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	dir := config.Output
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s failed to create bundle directory: %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	fmt.Printf("%s[INFO]%s Collecting takedown evidence for %s...\n", ColorBlue, ColorReset, domain)
+	evidence := collectEvidence(result, info, dir, config)
+	for _, problem := range evidence.Errors {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s %s\n", ColorYellow, ColorReset, problem)
+	}
+	letter, err := renderTakedownLetter(evidence, *letterPath, *reporter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := writeTakedownBundle(dir, evidence, letter); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if config.Signer != nil {
+		if err := signBundle(dir, config.Signer); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s failed to sign the bundle: %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+		fmt.Printf("%s[INFO]%s Bundle signed: %s\n", ColorBlue, ColorReset, filepath.Join(dir, bundleManifest+signatureExt))
+	}
+
+	fmt.Printf("%s[INFO]%s Takedown bundle written to %s\n", ColorBlue, ColorReset, dir)
+	if evidence.AbuseEmail != "" {
+		fmt.Printf("Send %s to %s%s%s\n", filepath.Join(dir, "letter.txt"), ColorGreen, evidence.AbuseEmail, ColorReset)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s No abuse contact found for %s; look up the registrar's abuse desk\n", ColorYellow, ColorReset, domain)
+	}
+	return ExitMatches
+}
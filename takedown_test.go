@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindTakedownDomain(t *testing.T) {
+	result := Result{
+		MatchingDomains: []DomainInfo{{Domain: "example.net", MatchReason: "organization"}},
+		Lookalikes:      []DomainInfo{{Domain: "examp1e.com", RiskScore: 80}},
+		AllDomains:      []DomainInfo{{Domain: "examp1e.com"}, {Domain: "example.org"}},
+	}
+
+	if info, err := findTakedownDomain(result, "examp1e.com"); err != nil || info.RiskScore != 80 {
+		t.Errorf("Expected the scored lookalike record, got %+v, %v", info, err)
+	}
+	if info, err := findTakedownDomain(result, "example.org"); err != nil || info.Domain != "example.org" {
+		t.Errorf("Expected the all_domains record, got %+v, %v", info, err)
+	}
+	if _, err := findTakedownDomain(result, "example.net"); err == nil || !strings.Contains(err.Error(), "target organization") {
+		t.Errorf("Expected owned domains to be refused, got %v", err)
+	}
+	if _, err := findTakedownDomain(result, "other.com"); err == nil {
+		t.Error("Expected an error for a domain missing from the result")
+	}
+}
+
+func TestLookupCTEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "%.examp1e.com" || r.URL.Query().Get("output") != "json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`[
+			{"id": 1, "entry_timestamp": "2026-01-02T10:00:00", "issuer_name": "C=US, O=Old CA", "name_value": "examp1e.com"},
+			{"id": 2, "entry_timestamp": "2026-03-04T10:00:00", "issuer_name": "C=US, O=Let's Encrypt, CN=R3", "name_value": "examp1e.com\nwww.examp1e.com"}
+		]`))
+	}))
+	defer server.Close()
+	defer func(old string) { crtshURL = old }(crtshURL)
+	crtshURL = server.URL + "/"
+
+	entries, err := lookupCTEntries(server.Client(), "examp1e.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != 2 {
+		t.Fatalf("Expected 2 entries newest first, got %+v", entries)
+	}
+	if len(entries[0].Names) != 2 || entries[0].Names[1] != "www.examp1e.com" {
+		t.Errorf("Expected both certificate names, got %v", entries[0].Names)
+	}
+}
+
+func TestRenderTakedownLetter(t *testing.T) {
+	evidence := &TakedownEvidence{
+		Domain:       "examp1e.com",
+		TargetDomain: "example.com",
+		TargetOrg:    "Example Corp",
+		CollectedAt:  time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC),
+		Record: DomainInfo{
+			Registrar:   "Example Registrar, Inc.",
+			CreatedDate: "2026-02-28",
+			RiskFactors: []RiskFactor{{Name: "brand_keywords", Points: 25, Detail: "page mentions example"}},
+			HTTP:        &HTTPProbe{URL: "https://examp1e.com", StatusCode: 200, Title: "Example Login"},
+		},
+		WhoisServer:  "whois.registrar.example",
+		DNS:          &DNSRecords{A: []string{"192.0.2.10"}},
+		Certificates: []CTEntry{{Issuer: "C=US, O=Let's Encrypt, CN=R3"}},
+		Screenshot:   "screenshot.png",
+		AbuseEmail:   "abuse@registrar.example",
+	}
+
+	letter, err := renderTakedownLetter(evidence, "", "Jo Analyst, security@example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"To: abuse@registrar.example",
+		"examp1e.com impersonates Example Corp",
+		"Dear Example Registrar, Inc. abuse team",
+		"on 2026-02-28",
+		"  - page mentions example",
+		"Evidence collected on 4 March 2026",
+		"WHOIS record from whois.registrar.example",
+		"A 192.0.2.10",
+		"1 certificates",
+		`titled "Example Login"`,
+		"Screenshot of the website (screenshot.png)",
+		"Jo Analyst, security@example.com",
+	} {
+		if !strings.Contains(string(letter), want) {
+			t.Errorf("Expected the letter to contain %q, got:\n%s", want, letter)
+		}
+	}
+
+	custom := filepath.Join(t.TempDir(), "letter.tmpl")
+	os.WriteFile(custom, []byte("{{.Domain}} via {{.AbuseEmail}} by {{.Reporter}}"), 0644)
+	letter, err = renderTakedownLetter(evidence, custom, "Jo")
+	if err != nil || string(letter) != "examp1e.com via abuse@registrar.example by Jo" {
+		t.Errorf("Unexpected custom letter %q, %v", letter, err)
+	}
+}
+
+func TestWriteTakedownBundle(t *testing.T) {
+	dir := t.TempDir()
+	evidence := &TakedownEvidence{Domain: "examp1e.com", Whois: "Domain Name: EXAMP1E.COM\n", Errors: []string{"dns: timeout"}}
+	if err := writeTakedownBundle(dir, evidence, []byte("letter")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "evidence.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode evidence.json: %v", err)
+	}
+	if decoded["domain"] != "examp1e.com" || decoded["whois"] != nil {
+		t.Errorf("Unexpected evidence.json %s", data)
+	}
+	if whois, _ := os.ReadFile(filepath.Join(dir, "whois.txt")); string(whois) != evidence.Whois {
+		t.Errorf("Expected the raw WHOIS text in whois.txt, got %q", whois)
+	}
+}
+
+func TestRunTakedownUsage(t *testing.T) {
+	for _, args := range [][]string{{}, {"results.json"}, {"-domain", "examp1e.com"}, {"results.json", "extra", "-domain", "examp1e.com"}} {
+		if code := runTakedown(args); code != ExitUsage {
+			t.Errorf("runTakedown(%q) = %d; expected %d", args, code, ExitUsage)
+		}
+	}
+}
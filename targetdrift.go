@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// baselineBucket holds a targetBaseline per monitored target
+var baselineBucket = []byte("baseline")
+
+// targetState is the part of the target's own registration and delegation
+// that should only change on purpose: a hijacked domain shows up as a new
+// registrar, new name servers or dropped locks
+type targetState struct {
+	Registrar      string `json:"registrar"`
+	Organization   string `json:"organization"`
+	NameServers    string `json:"name_servers"`
+	Status         string `json:"status"`
+	DNSNameServers string `json:"dns_name_servers,omitempty"`
+}
+
+// targetBaseline is the state of the target recorded by its first monitor
+// cycle, with the drift alerted last so an unchanged drift is not raised
+// again every cycle
+type targetBaseline struct {
+	RecordedAt time.Time     `json:"recorded_at"`
+	State      targetState   `json:"state"`
+	Alerted    []FieldChange `json:"alerted,omitempty"`
+}
+
+// captureTarget reads the target's state from its WHOIS record and the
+// name servers its zone is delegated to. A failed NS lookup leaves the
+// delegation empty, which is not compared.
+func captureTarget(domain string, info *DomainInfo, timeout time.Duration) targetState {
+	state := targetState{
+		Registrar:    info.Registrar,
+		Organization: info.Organization,
+		NameServers:  sortedJoin(info.NameServers),
+	}
+	if info.Status != "" {
+		state.Status = sortedJoin(strings.Split(info.Status, ", "))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if records, err := net.DefaultResolver.LookupNS(ctx, domain); err == nil {
+		hosts := make([]string, len(records))
+		for i, ns := range records {
+			hosts[i] = ns.Host
+		}
+		state.DNSNameServers = sortedJoin(hosts)
+	}
+	return state
+}
+
+// targetDrift lists the fields of the target that differ from its baseline
+func targetDrift(baseline, current targetState) []FieldChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"registrar", baseline.Registrar, current.Registrar},
+		{"organization", baseline.Organization, current.Organization},
+		{"name_servers", baseline.NameServers, current.NameServers},
+		{"status", baseline.Status, current.Status},
+		{"dns_name_servers", baseline.DNSNameServers, current.DNSNameServers},
+	}
+
+	var changes []FieldChange
+	for _, field := range fields {
+		// an unanswered NS lookup is no evidence of a new delegation
+		if field.name == "dns_name_servers" && (field.old == "" || field.new == "") {
+			continue
+		}
+		if field.old != field.new {
+			changes = append(changes, FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+	return changes
+}
+
+// checkTargetBaseline compares the target with its recorded baseline,
+// recording the state as the baseline on the first cycle or when rebaseline
+// is set. It returns the current drift and whether it differs from the
+// drift alerted last.
+func (h *historyStore) checkTargetBaseline(domain string, state targetState, rebaseline bool, now time.Time) ([]FieldChange, bool, error) {
+	var drift []FieldChange
+	var alert bool
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(baselineBucket)
+		if err != nil {
+			return err
+		}
+		baseline := targetBaseline{RecordedAt: now.UTC(), State: state}
+		if data := bucket.Get([]byte(domain)); data != nil && !rebaseline {
+			if err := json.Unmarshal(data, &baseline); err != nil {
+				return err
+			}
+			drift = targetDrift(baseline.State, state)
+			alert = len(drift) > 0 && !reflect.DeepEqual(drift, baseline.Alerted)
+			baseline.Alerted = drift
+		}
+		data, err := json.Marshal(baseline)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(domain), data)
+	})
+	return drift, alert, err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckTargetBaseline(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	baseline := targetState{Registrar: "MarkMonitor Inc.", NameServers: "a.iana-servers.net, b.iana-servers.net", Status: "clienttransferprohibited", DNSNameServers: "a.iana-servers.net, b.iana-servers.net"}
+	if drift, alert, err := store.checkTargetBaseline("example.com", baseline, false, now); err != nil || drift != nil || alert {
+		t.Fatalf("Expected the first cycle to record the baseline, got %v, %v, %v", drift, alert, err)
+	}
+
+	hijacked := baseline
+	hijacked.Registrar = "Cheap Names LLC"
+	hijacked.Status = ""
+	drift, alert, err := store.checkTargetBaseline("example.com", hijacked, false, now.Add(24*time.Hour))
+	if err != nil || !alert || len(drift) != 2 || drift[0].Field != "registrar" || drift[1].Field != "status" {
+		t.Fatalf("Expected registrar and status drift to alert, got %v, %v, %v", drift, alert, err)
+	}
+	if _, alert, _ := store.checkTargetBaseline("example.com", hijacked, false, now.Add(48*time.Hour)); alert {
+		t.Error("Expected an unchanged drift to be alerted once")
+	}
+
+	// a failed NS lookup is not a delegation change
+	hijacked.DNSNameServers = ""
+	if drift, alert, _ := store.checkTargetBaseline("example.com", hijacked, false, now.Add(72*time.Hour)); alert || len(drift) != 2 {
+		t.Errorf("Expected the missing delegation to be ignored, got %v, %v", drift, alert)
+	}
+
+	if drift, alert, _ := store.checkTargetBaseline("example.com", hijacked, true, now.Add(96*time.Hour)); drift != nil || alert {
+		t.Errorf("Expected -rebaseline to accept the current state, got %v, %v", drift, alert)
+	}
+	if drift, _, _ := store.checkTargetBaseline("example.com", hijacked, false, now.Add(120*time.Hour)); drift != nil {
+		t.Errorf("Expected no drift from the new baseline, got %v", drift)
+	}
+}
+
+func TestTargetDriftAlert(t *testing.T) {
+	alert := Alert{Kind: AlertTargetDrift, Domain: "example.com", Changes: []FieldChange{{Field: "name_servers", Old: "a.iana-servers.net", New: "ns1.evil-dns.test"}}}
+	if got := alert.String(); !strings.HasPrefix(got, "target example.com drifted from its baseline: name_servers") {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// degradedTarget stands in for the target's WHOIS record when its lookup
+// failed under -continue-on-target-error. Only the name servers its zone is
+// delegated to in DNS are known, so the scan matches by -org or by shared
+// name servers. The lookup error is kept in Error.
+func degradedTarget(config Config, lookupErr error) *DomainInfo {
+	fmt.Fprintf(os.Stderr, "%s[WARNING]%s WHOIS lookup of target %s failed: %v; continuing with degraded matching (-continue-on-target-error)\n",
+		ColorYellow, ColorReset, config.Domain, lookupErr)
+	target := &DomainInfo{Domain: config.Domain, Error: lookupErr.Error(), Timestamp: time.Now()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+	records, err := net.DefaultResolver.LookupNS(ctx, config.Domain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to look up the name servers of %s: %v\n", ColorYellow, ColorReset, config.Domain, err)
+		return target
+	}
+	for _, ns := range records {
+		target.NameServers = append(target.NameServers, ns.Host)
+	}
+	return target
+}
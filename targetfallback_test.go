@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupTargetSoftFail(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	config := Config{Domain: "example.invalid", Timeout: 1, WhoisClient: mock}
+
+	if _, err := lookupTarget(&config); err == nil || !strings.Contains(err.Error(), "failed to get WHOIS info") {
+		t.Fatalf("Expected a failed target lookup to abort the scan, got %v", err)
+	}
+
+	config.SoftFailTarget = true
+	if _, err := lookupTarget(&config); err == nil {
+		t.Error("Expected an error without -org or name servers to match by")
+	}
+
+	config.Org = "Example Corp"
+	target, err := lookupTarget(&config)
+	if err != nil {
+		t.Fatalf("lookupTarget failed: %v", err)
+	}
+	if target.Organization != "Example Corp" || target.Error == "" {
+		t.Errorf("Expected the -org organization and the lookup error, got %+v", target)
+	}
+}
+
+func TestLookupTargetOrg(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	config := Config{Domain: "example.com", Timeout: 5, WhoisClient: mock, Org: "Other Corp"}
+	target, err := lookupTarget(&config)
+	if err != nil {
+		t.Fatalf("lookupTarget failed: %v", err)
+	}
+	if target.Organization != "Example Corp" {
+		t.Errorf("-org should not override a usable organization, got %q", target.Organization)
+	}
+
+	// example.net is registered behind a privacy service
+	config.Domain = "example.net"
+	if target, err = lookupTarget(&config); err != nil {
+		t.Fatalf("lookupTarget failed: %v", err)
+	}
+	if target.Organization != "Other Corp" {
+		t.Errorf("-org should replace a redacted organization, got %q", target.Organization)
+	}
+}
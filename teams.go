@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxCardDomains bounds the matches listed in a chat card
+const maxCardDomains = 20
+
+// teamsNotifier posts an adaptive card to a Microsoft Teams incoming
+// webhook (or a Workflows "post to a channel when a webhook request is
+// received" URL)
+type teamsNotifier struct {
+	webhook string
+	timeout time.Duration
+}
+
+func (t *teamsNotifier) Name() string {
+	return "Teams"
+}
+
+func (t *teamsNotifier) Notify(n Notification) error {
+	message := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     teamsCard(n),
+		}},
+	}
+	return postJSON(&http.Client{Timeout: t.timeout}, t.webhook, nil, message)
+}
+
+// teamsCard builds the adaptive card: a scan summary, the monitor alerts
+// and the matching domains
+func teamsCard(n Notification) map[string]interface{} {
+	result := n.Result
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "size": "Large", "weight": "Bolder", "text": "TLD Scanner: " + result.TargetDomain},
+		{"type": "FactSet", "facts": []map[string]string{
+			{"title": "Organization", "value": result.TargetOrg},
+			{"title": "Scanned", "value": fmt.Sprintf("%d domains in %s", result.TotalScanned, result.ScanDuration)},
+			{"title": "Matches", "value": fmt.Sprint(result.TotalMatches)},
+			{"title": "Errors", "value": fmt.Sprint(result.TotalErrors)},
+		}},
+	}
+
+	if len(n.Alerts) > 0 {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "weight": "Bolder", "text": "Changes since the previous scan", "separator": true})
+		for _, alert := range n.Alerts {
+			color := "Warning"
+			if alert.Kind == AlertChanged {
+				color = "Attention"
+			}
+			body = append(body, map[string]interface{}{"type": "TextBlock", "wrap": true, "color": color, "text": alert.String()})
+		}
+	}
+
+	if len(result.MatchingDomains) > 0 {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "weight": "Bolder", "text": "Matching domains", "separator": true})
+		var facts []map[string]string
+		for i, info := range result.MatchingDomains {
+			if i == maxCardDomains {
+				facts = append(facts, map[string]string{"title": "...", "value": fmt.Sprintf("%d more", len(result.MatchingDomains)-i)})
+				break
+			}
+			facts = append(facts, map[string]string{"title": info.displayName(), "value": firstNonEmpty(info.Registrar, "unknown registrar")})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+
+	return map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+}
+
+// validateTeams checks the -teams-webhook URL
+func validateTeams(config Config) error {
+	if config.TeamsWebhook == "" {
+		return nil
+	}
+	u, err := url.Parse(config.TeamsWebhook)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("-teams-webhook must be an https URL")
+	}
+	return nil
+}
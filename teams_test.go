@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeamsNotifier(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var matches []DomainInfo
+	for i := 0; i < maxCardDomains+5; i++ {
+		matches = append(matches, DomainInfo{Domain: "example.test", Registrar: "MarkMonitor"})
+	}
+	n := Notification{
+		Result: Result{TargetDomain: "example.com", TotalMatches: len(matches), MatchingDomains: matches},
+		Alerts: []Alert{{Kind: AlertNewMatch, Domain: "example.io"}},
+	}
+
+	notifier := &teamsNotifier{webhook: server.URL, timeout: 5 * time.Second}
+	if err := notifier.Notify(n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	data, _ := json.Marshal(received)
+	for _, want := range []string{`"application/vnd.microsoft.card.adaptive"`, `"AdaptiveCard"`, "new match example.io", `"5 more"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Teams message is missing %s:\n%s", want, data)
+		}
+	}
+}
+
+func TestValidateTeams(t *testing.T) {
+	if err := validateTeams(Config{TeamsWebhook: "https://example.webhook.office.com/webhookb2/abc"}); err != nil {
+		t.Errorf("Valid webhook failed: %v", err)
+	}
+	if err := validateTeams(Config{TeamsWebhook: "http://example.com/hook"}); err == nil {
+		t.Error("Expected an error for a non-https webhook")
+	}
+}
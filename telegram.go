@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// telegramAPIURL is the Bot API base, overridable in tests
+var telegramAPIURL = "https://api.telegram.org"
+
+// telegramMaxMessage is the Bot API limit on message text
+const telegramMaxMessage = 4096
+
+// telegramNotifier sends match alerts through a Telegram bot
+type telegramNotifier struct {
+	token   string
+	chat    string
+	timeout time.Duration
+}
+
+func (t *telegramNotifier) Name() string {
+	return "Telegram"
+}
+
+func (t *telegramNotifier) Notify(n Notification) error {
+	message := map[string]interface{}{
+		"chat_id":                  t.chat,
+		"text":                     telegramMessage(n),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	err := postJSON(&http.Client{Timeout: t.timeout}, telegramAPIURL+"/bot"+t.token+"/sendMessage", nil, message)
+	if err != nil {
+		// net/http errors quote the URL, which contains the bot token
+		return fmt.Errorf("%s", strings.ReplaceAll(err.Error(), t.token, "<token>"))
+	}
+	return nil
+}
+
+// telegramMessage renders the alerts, or the matches outside monitor mode,
+// as Telegram HTML
+func telegramMessage(n Notification) string {
+	result := n.Result
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>TLD Scanner: %s</b>\n", html.EscapeString(result.TargetDomain))
+	fmt.Fprintf(&b, "%d matches, %d scanned, %d errors\n", result.TotalMatches, result.TotalScanned, result.TotalErrors)
+
+	if n.Monitor {
+		for _, alert := range n.Alerts {
+			fmt.Fprintf(&b, "\n• %s", html.EscapeString(alert.String()))
+		}
+	} else {
+		for i, info := range result.MatchingDomains {
+			if i == maxCardDomains {
+				fmt.Fprintf(&b, "\n… %d more", len(result.MatchingDomains)-i)
+				break
+			}
+			fmt.Fprintf(&b, "\n• <code>%s</code> (%s)", html.EscapeString(info.displayName()),
+				html.EscapeString(firstNonEmpty(info.Registrar, "unknown registrar")))
+		}
+	}
+
+	text := b.String()
+	if len(text) > telegramMaxMessage {
+		// cut at a line boundary so no HTML tag is left open
+		text = text[:strings.LastIndex(text[:telegramMaxMessage-4], "\n")] + "\n…"
+	}
+	return text
+}
+
+// validateTelegram requires the bot token and chat to be set together
+func validateTelegram(config Config) error {
+	if (config.TelegramToken == "") != (config.TelegramChat == "") {
+		return fmt.Errorf("-telegram-token and -telegram-chat must be used together")
+	}
+	return nil
+}
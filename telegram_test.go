@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramNotifier(t *testing.T) {
+	var path string
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+	defer func(old string) { telegramAPIURL = old }(telegramAPIURL)
+	telegramAPIURL = server.URL
+
+	n := Notification{Result: Result{
+		TargetDomain:    "example.com",
+		TotalMatches:    1,
+		MatchingDomains: []DomainInfo{{Domain: "example.io", Registrar: "A & B"}},
+	}}
+	notifier := &telegramNotifier{token: "123:abc", chat: "-100", timeout: 5 * time.Second}
+	if err := notifier.Notify(n); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("Unexpected path %s", path)
+	}
+	if received["chat_id"] != "-100" || received["parse_mode"] != "HTML" {
+		t.Errorf("Unexpected message %v", received)
+	}
+	if text, _ := received["text"].(string); !strings.Contains(text, "<code>example.io</code> (A &amp; B)") {
+		t.Errorf("Unexpected text %q", text)
+	}
+}
+
+func TestTelegramErrorHidesToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	defer func(old string) { telegramAPIURL = old }(telegramAPIURL)
+	telegramAPIURL = server.URL
+
+	notifier := &telegramNotifier{token: "123:secret", chat: "1", timeout: time.Second}
+	err := notifier.Notify(Notification{})
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected an error without the token, got %v", err)
+	}
+}
+
+func TestTelegramMessageTruncated(t *testing.T) {
+	var alerts []Alert
+	for i := 0; i < 500; i++ {
+		alerts = append(alerts, Alert{Kind: AlertNewMatch, Domain: "lookalike-example.test"})
+	}
+	text := telegramMessage(Notification{Monitor: true, Alerts: alerts})
+	if len(text) > telegramMaxMessage || !strings.HasSuffix(text, "…") {
+		t.Errorf("Message not truncated: %d bytes", len(text))
+	}
+}
+
+func TestValidateTelegram(t *testing.T) {
+	if err := validateTelegram(Config{TelegramToken: "123:abc"}); err == nil {
+		t.Error("Expected an error for a token without a chat")
+	}
+	if err := validateTelegram(Config{TelegramToken: "123:abc", TelegramChat: "@channel"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
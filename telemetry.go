@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// otlpExporter sends scan traces and metrics to an OpenTelemetry collector
+// over OTLP/HTTP with JSON encoding, so no OpenTelemetry SDK is needed
+type otlpExporter struct {
+	// endpoint is the collector base URL; /v1/traces and /v1/metrics are
+	// appended to it
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+}
+
+// newOTLPExporter returns an exporter for endpoint, or nil without one. The
+// standard OTEL_EXPORTER_OTLP_HEADERS and OTEL_SERVICE_NAME variables are
+// honoured.
+func newOTLPExporter(endpoint string, timeout time.Duration) (*otlpExporter, error) {
+	if endpoint == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q: must be an http:// or https:// URL", endpoint)
+	}
+	headers, err := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "tldscanner"
+	}
+	return &otlpExporter{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// parseOTLPHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format:
+// comma-separated key=value pairs with URL-encoded values
+func parseOTLPHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS entry %q: expected key=value", pair)
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS value for %s: %w", key, err)
+		}
+		headers[key] = decoded
+	}
+	return headers, nil
+}
+
+// OTLP JSON payloads (opentelemetry-proto, JSON encoding). IDs are hex and
+// 64-bit integers are strings, as the encoding requires.
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// OTLP span kind and status codes
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+	// otlpDelta is AGGREGATION_TEMPORALITY_DELTA: every scan reports its
+	// own counts
+	otlpDelta = 1
+)
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsInt             *string         `json:"asInt,omitempty"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetrics struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+func otlpString(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int) otlpAttribute {
+	s := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// scanTelemetry collects the spans of one scan: a root "scan" span with a
+// child per stage and per enrichment. A nil scanTelemetry records nothing,
+// so the pipeline calls it unconditionally.
+type scanTelemetry struct {
+	exporter *otlpExporter
+	traceID  string
+	rootID   string
+	target   string
+	start    time.Time
+
+	mu    sync.Mutex
+	spans []otlpSpan
+}
+
+// startScan begins the telemetry of a scan of target timed by timing; nil
+// without an exporter
+func (e *otlpExporter) startScan(target string, timing *ScanTiming) *scanTelemetry {
+	if e == nil {
+		return nil
+	}
+	t := &scanTelemetry{exporter: e, traceID: otlpID(16), rootID: otlpID(8), target: target, start: timing.StartedAt}
+	timing.telemetry = t
+	return t
+}
+
+// span records a finished child span of the scan, failed when err is set
+func (t *scanTelemetry) span(name string, start, end time.Time, err error, attrs ...otlpAttribute) {
+	if t == nil {
+		return
+	}
+	span := otlpSpan{
+		TraceID:           t.traceID,
+		SpanID:            otlpID(8),
+		ParentSpanID:      t.rootID,
+		Name:              name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: otlpTime(start),
+		EndTimeUnixNano:   otlpTime(end),
+		Attributes:        attrs,
+		Status:            otlpStatus{Code: otlpStatusOK},
+	}
+	if err != nil {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+}
+
+// export ends the root span and sends the scan's spans and metrics
+func (t *scanTelemetry) export(result Result) error {
+	if t == nil {
+		return nil
+	}
+	end := time.Now()
+	root := otlpSpan{
+		TraceID:           t.traceID,
+		SpanID:            t.rootID,
+		Name:              "scan",
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: otlpTime(t.start),
+		EndTimeUnixNano:   otlpTime(end),
+		Attributes: []otlpAttribute{
+			otlpString("tldscanner.target", t.target),
+			otlpInt("tldscanner.scanned", result.TotalScanned),
+			otlpInt("tldscanner.matches", result.TotalMatches),
+			otlpInt("tldscanner.errors", result.TotalErrors),
+		},
+		Status: otlpStatus{Code: otlpStatusOK},
+	}
+	t.mu.Lock()
+	spans := append([]otlpSpan{root}, t.spans...)
+	t.mu.Unlock()
+
+	scope := otlpScope{Name: "tldscanner"}
+	traces := otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource:   t.exporter.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: scope, Spans: spans}},
+	}}}
+	if err := t.exporter.post("/v1/traces", traces); err != nil {
+		return err
+	}
+
+	metrics := otlpMetrics{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     t.exporter.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: scope, Metrics: scanMetrics(result, t.target, t.start, end)}},
+	}}}
+	return t.exporter.post("/v1/metrics", metrics)
+}
+
+// scanMetrics turns a scan result into OTLP metrics: delta counters of the
+// domains looked up, matched, skipped and failed by error code, and gauges
+// of the scan duration, lookup rate and lookup latencies
+func scanMetrics(result Result, target string, start, end time.Time) []otlpMetric {
+	targetAttr := otlpString("tldscanner.target", target)
+	counter := func(value int, attrs ...otlpAttribute) otlpDataPoint {
+		s := strconv.Itoa(value)
+		return otlpDataPoint{
+			Attributes:        append([]otlpAttribute{targetAttr}, attrs...),
+			StartTimeUnixNano: otlpTime(start),
+			TimeUnixNano:      otlpTime(end),
+			AsInt:             &s,
+		}
+	}
+	sum := func(name, description string, points ...otlpDataPoint) otlpMetric {
+		return otlpMetric{Name: name, Description: description, Unit: "{domain}", Sum: &otlpSum{AggregationTemporality: otlpDelta, IsMonotonic: true, DataPoints: points}}
+	}
+	gauge := func(name, description, unit string, value float64) otlpMetric {
+		point := otlpDataPoint{Attributes: []otlpAttribute{targetAttr}, TimeUnixNano: otlpTime(end), AsDouble: &value}
+		return otlpMetric{Name: name, Description: description, Unit: unit, Gauge: &otlpGauge{DataPoints: []otlpDataPoint{point}}}
+	}
+
+	metrics := []otlpMetric{
+		sum("tldscanner.domains.scanned", "Domains looked up", counter(result.TotalScanned)),
+		sum("tldscanner.domains.matched", "Domains matching the target", counter(result.TotalMatches)),
+		sum("tldscanner.domains.skipped", "Domains skipped by the query budget or runtime limit", counter(result.TotalSkipped)),
+	}
+
+	codes := make([]string, 0, len(result.ErrorsByType))
+	for code := range result.ErrorsByType {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var errorPoints []otlpDataPoint
+	for _, code := range codes {
+		errorPoints = append(errorPoints, counter(result.ErrorsByType[code], otlpString("tldscanner.error_code", code)))
+	}
+	if len(errorPoints) > 0 {
+		metrics = append(metrics, sum("tldscanner.lookup.errors", "Failed lookups by error code", errorPoints...))
+	}
+
+	metrics = append(metrics, gauge("tldscanner.scan.duration", "Scan duration", "s", end.Sub(start).Seconds()))
+	if timing := result.Timing; timing != nil {
+		metrics = append(metrics,
+			gauge("tldscanner.lookup.rate", "Lookups per second over the lookups stage", "{domain}/s", timing.DomainsPerSecond),
+			gauge("tldscanner.lookup.latency.avg", "Average lookup latency", "ms", float64(timing.AvgLookupMs)),
+			gauge("tldscanner.lookup.latency.p95", "95th percentile lookup latency", "ms", float64(timing.P95LookupMs)),
+		)
+	}
+	return metrics
+}
+
+// resource describes this process to the collector
+func (e *otlpExporter) resource() otlpResource {
+	return otlpResource{Attributes: []otlpAttribute{
+		otlpString("service.name", e.service),
+	}}
+}
+
+// post sends one OTLP/HTTP JSON export request
+func (e *otlpExporter) post(path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OTLP export to %s failed: %w", e.endpoint+path, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP export to %s returned HTTP %d", e.endpoint+path, resp.StatusCode)
+	}
+	return nil
+}
+
+// exportTelemetry records the output stage, which began at outputStarted
+// (zero when the result is not written), and exports the scan's telemetry.
+// A collector that is down costs a warning, never the scan.
+func exportTelemetry(result Result, outputStarted time.Time) {
+	if result.Timing == nil || result.Timing.telemetry == nil {
+		return
+	}
+	telemetry := result.Timing.telemetry
+	if !outputStarted.IsZero() {
+		telemetry.span("output", outputStarted, time.Now(), nil)
+	}
+	if err := telemetry.export(result); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+	}
+}
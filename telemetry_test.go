@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseOTLPHeaders(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{"", map[string]string{}, false},
+		{"api-key=secret", map[string]string{"api-key": "secret"}, false},
+		{"a=1, b = two%20words ,", map[string]string{"a": "1", "b": "two words"}, false},
+		{"Authorization=Basic%20abc=", map[string]string{"Authorization": "Basic abc="}, false},
+		{"novalue", nil, true},
+		{"=value", nil, true},
+	}
+
+	for _, tt := range tests {
+		headers, err := parseOTLPHeaders(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOTLPHeaders(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(headers) != len(tt.expected) {
+			t.Errorf("parseOTLPHeaders(%q): Expected %v, got %v", tt.input, tt.expected, headers)
+			continue
+		}
+		for key, value := range tt.expected {
+			if headers[key] != value {
+				t.Errorf("parseOTLPHeaders(%q): Expected %s=%q, got %q", tt.input, key, value, headers[key])
+			}
+		}
+	}
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	exporter, err := newOTLPExporter("", time.Second)
+	if err != nil || exporter != nil {
+		t.Errorf("Expected no exporter without an endpoint, got %v, %v", exporter, err)
+	}
+	for _, endpoint := range []string{"localhost:4318", "ftp://collector", "http://"} {
+		if _, err := newOTLPExporter(endpoint, time.Second); err == nil {
+			t.Errorf("Expected an error for endpoint %q", endpoint)
+		}
+	}
+
+	t.Setenv("OTEL_SERVICE_NAME", "brand-monitor")
+	exporter, err = newOTLPExporter("http://collector:4318/", time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if exporter.endpoint != "http://collector:4318" || exporter.service != "brand-monitor" {
+		t.Errorf("Expected endpoint http://collector:4318 and service brand-monitor, got %s and %s", exporter.endpoint, exporter.service)
+	}
+}
+
+func TestScanTelemetryNil(t *testing.T) {
+	var exporter *otlpExporter
+	timing := newScanTiming()
+	telemetry := exporter.startScan("example.com", timing)
+	if telemetry != nil || timing.telemetry != nil {
+		t.Fatal("Expected no telemetry without an exporter")
+	}
+	timing.stage("lookups")
+	telemetry.span("enrich.rdap", time.Now(), time.Now(), nil)
+	if err := telemetry.export(Result{}); err != nil {
+		t.Errorf("Expected a nil telemetry to export nothing, got %v", err)
+	}
+	exportTelemetry(Result{Timing: timing}, time.Now())
+}
+
+func TestExportTelemetry(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string][]byte{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "X-Api-Key=secret")
+	exporter, err := newOTLPExporter(collector.URL, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	timing := newScanTiming()
+	telemetry := exporter.startScan("example.com", timing)
+	timing.stage("candidates")
+	telemetry.span("enrich.virustotal", time.Now(), time.Now(), errors.New("quota exceeded"))
+	timing.stage("lookups")
+	timing.finish(nil)
+	result := Result{
+		TargetDomain: "example.com",
+		Timing:       timing,
+		TotalScanned: 10,
+		TotalMatches: 2,
+		TotalErrors:  3,
+		ErrorsByType: map[string]int{"timeout": 2, "rate_limited": 1},
+	}
+	if err := telemetry.export(result); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var traces otlpTraces
+	if err := json.Unmarshal(requests["/v1/traces"], &traces); err != nil {
+		t.Fatalf("Failed to decode traces: %v", err)
+	}
+	if len(traces.ResourceSpans) != 1 || len(traces.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Expected one resource and scope, got %+v", traces)
+	}
+	spans := map[string]otlpSpan{}
+	for _, span := range traces.ResourceSpans[0].ScopeSpans[0].Spans {
+		if span.TraceID != telemetry.traceID {
+			t.Errorf("Expected span %s in trace %s, got %s", span.Name, telemetry.traceID, span.TraceID)
+		}
+		spans[span.Name] = span
+	}
+	for _, name := range []string{"scan", "candidates", "lookups", "enrich.virustotal"} {
+		if _, ok := spans[name]; !ok {
+			t.Errorf("Expected a %s span, got %v", name, spans)
+		}
+	}
+	if spans["lookups"].ParentSpanID != spans["scan"].SpanID {
+		t.Errorf("Expected stage spans to be children of the scan span")
+	}
+	if status := spans["enrich.virustotal"].Status; status.Code != otlpStatusError || status.Message != "quota exceeded" {
+		t.Errorf("Expected a failed enrichment span, got %+v", status)
+	}
+
+	var metrics otlpMetrics
+	if err := json.Unmarshal(requests["/v1/metrics"], &metrics); err != nil {
+		t.Fatalf("Failed to decode metrics: %v", err)
+	}
+	byName := map[string]otlpMetric{}
+	for _, metric := range metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		byName[metric.Name] = metric
+	}
+	if scanned := byName["tldscanner.domains.scanned"]; scanned.Sum == nil || *scanned.Sum.DataPoints[0].AsInt != "10" {
+		t.Errorf("Expected 10 scanned domains, got %+v", scanned)
+	}
+	errorsMetric := byName["tldscanner.lookup.errors"]
+	if errorsMetric.Sum == nil || len(errorsMetric.Sum.DataPoints) != 2 {
+		t.Fatalf("Expected error counts for 2 codes, got %+v", errorsMetric)
+	}
+	if first := errorsMetric.Sum.DataPoints[0]; *first.Attributes[1].Value.StringValue != "rate_limited" || *first.AsInt != "1" {
+		t.Errorf("Expected rate_limited=1 first, got %+v", first)
+	}
+	if _, ok := byName["tldscanner.lookup.latency.p95"]; !ok {
+		t.Error("Expected a lookup latency gauge")
+	}
+}
+
+func TestExportTelemetryCollectorDown(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	exporter, err := newOTLPExporter(collector.URL, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	telemetry := exporter.startScan("example.com", newScanTiming())
+	if err := telemetry.export(Result{}); err == nil {
+		t.Error("Expected an error when the collector rejects the export")
+	}
+}
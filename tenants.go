@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// errQuotaExceeded is returned when a tenant submits more scans than its
+// quota allows
+var errQuotaExceeded = errors.New("scan quota exceeded")
+
+// Tenant is a user or team sharing a serve mode instance. Its API token is
+// stored only as a SHA-256 hash; tenants see only their own scans.
+type Tenant struct {
+	TokenSHA256 string `yaml:"token_sha256"`
+	// MaxActiveScans limits the tenant's queued and running scans
+	MaxActiveScans int `yaml:"max_active_scans,omitempty"`
+	// MaxDailyScans limits the scans submitted in any 24 hours
+	MaxDailyScans int `yaml:"max_daily_scans,omitempty"`
+}
+
+// tenantAuth resolves API tokens to tenants
+type tenantAuth struct {
+	tenants map[string]Tenant
+	byHash  map[string]string
+}
+
+// newTenantAuth indexes the configured tenants by token hash. It returns
+// nil when no tenants are configured, which leaves the API open.
+func newTenantAuth(tenants map[string]Tenant) (*tenantAuth, error) {
+	if len(tenants) == 0 {
+		return nil, nil
+	}
+	auth := &tenantAuth{tenants: tenants, byHash: make(map[string]string)}
+	for name, tenant := range tenants {
+		hash := strings.ToLower(tenant.TokenSHA256)
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("tenant %q: token_sha256 must be a hex SHA-256 hash", name)
+		}
+		if other, taken := auth.byHash[hash]; taken {
+			return nil, fmt.Errorf("tenants %q and %q share a token", other, name)
+		}
+		auth.byHash[hash] = name
+	}
+	return auth, nil
+}
+
+// authenticate returns the tenant of the request's token, taken from a
+// bearer Authorization header or, for WebSocket streams and download links
+// that cannot set headers, the access_token query parameter
+func (a *tenantAuth) authenticate(r *http.Request) (string, bool) {
+	token := r.URL.Query().Get("access_token")
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, value, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			return "", false
+		}
+		token = strings.TrimSpace(value)
+	}
+	if token == "" {
+		return "", false
+	}
+	name, ok := a.byHash[hashToken(token)]
+	return name, ok
+}
+
+// hashToken returns the hex SHA-256 hash stored for a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken generates a random API token
+func newToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return "tlds_" + hex.EncodeToString(b)
+}
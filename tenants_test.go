@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewTenantAuth(t *testing.T) {
+	if auth, err := newTenantAuth(nil); auth != nil || err != nil {
+		t.Errorf("Expected no auth without tenants, got %v, %v", auth, err)
+	}
+	if _, err := newTenantAuth(map[string]Tenant{"red": {TokenSHA256: "secret"}}); err == nil {
+		t.Error("Expected a plain token to be rejected")
+	}
+	hash := hashToken("t1")
+	if _, err := newTenantAuth(map[string]Tenant{"red": {TokenSHA256: hash}, "blue": {TokenSHA256: strings.ToUpper(hash)}}); err == nil {
+		t.Error("Expected a shared token to be rejected")
+	}
+}
+
+func TestTenantAuthenticate(t *testing.T) {
+	auth, err := newTenantAuth(map[string]Tenant{"red": {TokenSHA256: hashToken("t1")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		header, query string
+		tenant        string
+		ok            bool
+	}{
+		{"Bearer t1", "", "red", true},
+		{"bearer t1", "", "red", true},
+		{"", "t1", "red", true},
+		{"Bearer t2", "t1", "", false},
+		{"Basic t1", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/scans?access_token="+tc.query, nil)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		tenant, ok := auth.authenticate(r)
+		if tenant != tc.tenant || ok != tc.ok {
+			t.Errorf("authenticate(%q, %q) = %q, %v; expected %q, %v", tc.header, tc.query, tenant, ok, tc.tenant, tc.ok)
+		}
+	}
+}
+
+func TestAuthToken(t *testing.T) {
+	cfg := &FileConfig{Tenants: map[string]Tenant{"red": {TokenSHA256: hashToken("old"), MaxDailyScans: 10}}}
+	token := authToken(cfg, "red", 2, 0)
+	tenant := cfg.Tenants["red"]
+	if !strings.HasPrefix(token, "tlds_") || tenant.TokenSHA256 != hashToken(token) {
+		t.Errorf("Expected the new token's hash to be stored, got %+v", tenant)
+	}
+	if tenant.MaxActiveScans != 2 || tenant.MaxDailyScans != 10 {
+		t.Errorf("Expected the daily quota to be kept, got %+v", tenant)
+	}
+	if err := authRevoke(cfg, "red"); err != nil || len(cfg.Tenants) != 0 {
+		t.Errorf("Expected the tenant to be removed, got %v, %+v", err, cfg.Tenants)
+	}
+	if err := authRevoke(cfg, "red"); err == nil {
+		t.Error("Expected revoking an unknown tenant to fail")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// thinRegistries are the TLDs whose registry keeps no registrant contacts
+// and leaves them to the sponsoring registrar
+var thinRegistries = map[string]bool{
+	"com":  true,
+	"net":  true,
+	"cc":   true,
+	"tv":   true,
+	"jobs": true,
+}
+
+// isThinRecord reports whether a record of a thin registry lacks the
+// registrant data the registrar holds: neither an organization nor a
+// contact email. That happens when the registry WHOIS names no registrar
+// WHOIS server, the registrar no longer answers WHOIS, or the record came
+// from the registry's RDAP server.
+func isThinRecord(info *DomainInfo) bool {
+	return thinRegistries[lastLabel(info.Domain)] && info.Organization == "" && len(info.Emails) == 0
+}
+
+// completeThinRecord asks the sponsoring registrar's RDAP server for the
+// registrant of a thin record and merges what it finds into info. The
+// registrar's WHOIS server is already asked by queryWhois when the registry
+// refers to it. Failures are only traced: the registry record stands.
+func completeThinRecord(info *DomainInfo, config Config) {
+	if info == nil || !isThinRecord(info) {
+		return
+	}
+	config.Trace.logf("thin registry record for %s; asking the sponsoring registrar", info.Domain)
+	registrant, err := lookupRegistrarRDAP(info.Domain, config)
+	if err != nil {
+		config.Trace.logf("registrar RDAP lookup failed: %v", err)
+		return
+	}
+	mergeRegistrant(info, registrant)
+}
+
+// lookupRegistrarRDAP follows the registry RDAP record of a domain to the
+// registrar's RDAP record, which registries link with rel "related"
+func lookupRegistrarRDAP(domain string, config Config) (*DomainInfo, error) {
+	client := rdapHTTPClient(config)
+	servers, err := loadRDAPServers(client)
+	if err != nil {
+		return nil, fmt.Errorf("rdap bootstrap failed: %w", err)
+	}
+	base, ok := servers[lastLabel(domain)]
+	if !ok {
+		return nil, fmt.Errorf("no rdap server for .%s", lastLabel(domain))
+	}
+
+	ctx := context.Background()
+	if !config.Budget.take(lastLabel(domain)) {
+		return nil, fmt.Errorf("registry rdap query failed: %w", errQueryBudget)
+	}
+	var record rdapDomain
+	if err := getJSON(ctx, client, base+"domain/"+domain, &record); err != nil {
+		return nil, fmt.Errorf("registry rdap query failed: %w", err)
+	}
+
+	link := registrarRDAPLink(record.Links)
+	if link == "" {
+		return nil, fmt.Errorf("registry rdap record of %s links no registrar", domain)
+	}
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid registrar rdap link %q", link)
+	}
+	if !config.Budget.take(u.Hostname()) {
+		return nil, fmt.Errorf("registrar rdap query failed: %w", errQueryBudget)
+	}
+	config.Trace.logf("following the registrar RDAP link %s", link)
+	var registrar rdapDomain
+	if err := getJSON(ctx, client, link, &registrar); err != nil {
+		return nil, fmt.Errorf("registrar rdap query failed: %w", err)
+	}
+
+	info := domainInfoFromRDAP(domain, registrar)
+	info.WhoisServer = link
+	return info, nil
+}
+
+// registrarRDAPLink returns the RDAP URL of the registrar's domain object
+// among the links of a registry record
+func registrarRDAPLink(links []rdapLink) string {
+	for _, link := range links {
+		if link.Rel != "related" || link.Href == "" {
+			continue
+		}
+		if link.Type == "application/rdap+json" || strings.Contains(strings.ToLower(link.Href), "/domain/") {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// mergeRegistrant fills the registrant fields of a thin record from the
+// registrar's record, keeping what the registry already had
+func mergeRegistrant(info, registrant *DomainInfo) {
+	merged := false
+	if info.Organization == "" && registrant.Organization != "" {
+		info.Organization = registrant.Organization
+		merged = true
+	}
+	for _, email := range registrant.Emails {
+		if !containsString(info.Emails, email) {
+			info.Emails = append(info.Emails, email)
+			merged = true
+		}
+	}
+	if info.Registrar == "" {
+		info.Registrar = registrant.Registrar
+	}
+	if info.Country == "" {
+		info.Country = registrant.Country
+	}
+	if info.AbuseEmail == "" && info.AbusePhone == "" {
+		info.AbuseEmail, info.AbusePhone = registrant.AbuseEmail, registrant.AbusePhone
+	}
+	if merged {
+		info.RegistrantServer = registrant.WhoisServer
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestIsThinRecord(t *testing.T) {
+	tests := []struct {
+		info DomainInfo
+		want bool
+	}{
+		{DomainInfo{Domain: "example.com"}, true},
+		{DomainInfo{Domain: "example.tv", Registrar: "Example Registrar LLC"}, true},
+		{DomainInfo{Domain: "example.com", Organization: "Example Corp"}, false},
+		{DomainInfo{Domain: "example.net", Emails: []string{"hostmaster@example.net"}}, false},
+		{DomainInfo{Domain: "example.org"}, false},
+	}
+	for _, tt := range tests {
+		if got := isThinRecord(&tt.info); got != tt.want {
+			t.Errorf("isThinRecord(%+v) = %t, expected %t", tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrarRDAPLink(t *testing.T) {
+	links := []rdapLink{
+		{Rel: "self", Href: "https://rdap.verisign.com/com/v1/domain/EXAMPLE.COM", Type: "application/rdap+json"},
+		{Rel: "related", Href: "https://www.registrar.example/", Type: "text/html"},
+		{Rel: "related", Href: "https://rdap.registrar.example/domain/EXAMPLE.COM", Type: "application/rdap+json"},
+	}
+	if got := registrarRDAPLink(links); got != "https://rdap.registrar.example/domain/EXAMPLE.COM" {
+		t.Errorf("Expected the registrar's RDAP link, got %q", got)
+	}
+	if got := registrarRDAPLink(links[:2]); got != "" {
+		t.Errorf("Expected no link, got %q", got)
+	}
+}
+
+func TestGetWhoisInfoCompletesThinRecord(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dns.json":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"services": [][][]string{{{"com"}, {server.URL + "/registry"}}},
+			})
+		case "/registry/domain/examp1e.com":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"ldhName": "EXAMP1E.COM",
+				"links": []map[string]string{
+					{"rel": "related", "href": server.URL + "/registrar/domain/EXAMP1E.COM", "type": "application/rdap+json"},
+				},
+			})
+		case "/registrar/domain/EXAMP1E.COM":
+			w.Write([]byte(rdapFixture))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldURL := rdapBootstrapURL
+	rdapBootstrapURL = server.URL + "/dns.json"
+	resetRDAPBootstrap()
+	defer func() {
+		rdapBootstrapURL = oldURL
+		resetRDAPBootstrap()
+	}()
+
+	info, err := getWhoisInfo("examp1e.com", Config{Timeout: 5, WhoisClient: mock})
+	if err != nil {
+		t.Fatalf("getWhoisInfo failed: %v", err)
+	}
+	if info.Organization != "Example Corp" || info.RegistrantServer != server.URL+"/registrar/domain/EXAMP1E.COM" {
+		t.Errorf("Expected the registrant from the registrar, got %q from %q", info.Organization, info.RegistrantServer)
+	}
+	// The registry's own fields stand
+	if info.WhoisServer != "whois.verisign-grs.com" || info.CreatedDate != "2026-05-01T07:45:10Z" {
+		t.Errorf("Expected the registry record to be kept, got %+v", info)
+	}
+	if !reflect.DeepEqual(info.Emails, []string{"hostmaster@example.com", "noc@example.com"}) || info.AbuseEmail != "abuse@registrar.example" {
+		t.Errorf("Expected the registrar's contacts, got %v and %q", info.Emails, info.AbuseEmail)
+	}
+
+	// A budget without room for the registrar query keeps the thin record
+	budget := newQueryBudget(0, 1)
+	budget.take("com")
+	thin := &DomainInfo{Domain: "examp1e.com"}
+	completeThinRecord(thin, Config{Timeout: 5, Budget: budget})
+	if thin.Organization != "" || thin.RegistrantServer != "" {
+		t.Errorf("Expected the exhausted budget to stop the lookup, got %+v", thin)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sliceTLDs returns slice (from 0) of the TLDs cut in slices nearly equal
+// parts. The TLDs are sorted first, so a TLD added to the wordlist only
+// moves the cut points next to it.
+func sliceTLDs(tlds []string, slice, slices int) []string {
+	sorted := append([]string(nil), tlds...)
+	sort.Strings(sorted)
+	return sorted[slice*len(sorted)/slices : (slice+1)*len(sorted)/slices]
+}
+
+// nextSlice returns the slice the cycle after last scans: the first one
+// when no cycle was sliced the same way yet
+func nextSlice(last *tldSet, slices int) int {
+	if last == nil || last.Slices != slices {
+		return 0
+	}
+	return (last.Slice + 1) % slices
+}
+
+// upcomingSlice returns the slice the target's next monitor cycle scans
+func upcomingSlice(config Config) (int, error) {
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+	last, err := store.lastTLDSet(config.Domain)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the last TLD set: %w", err)
+	}
+	return nextSlice(last, config.Slices), nil
+}
+
+// fullCoverage returns how long sched takes to run slices scans from
+// start, the time every TLD takes to be scanned once
+func fullCoverage(sched schedule, start time.Time, slices int) time.Duration {
+	end := start
+	for i := 0; i < slices; i++ {
+		if end = sched.next(end); end.IsZero() {
+			return 0
+		}
+	}
+	return end.Sub(start)
+}
+
+// validateSlices checks -slices
+func validateSlices(config Config) error {
+	if config.Slices < 0 {
+		return fmt.Errorf("-slices must not be negative")
+	}
+	if config.Slices <= 1 {
+		return nil
+	}
+	if !config.Monitor {
+		return fmt.Errorf("-slices requires -monitor")
+	}
+	if config.DomainsFile != "" {
+		return fmt.Errorf("-slices cannot be combined with -domains-file, which has no TLD list")
+	}
+	if config.OnlyNewTLDs {
+		return fmt.Errorf("-slices cannot be combined with -only-new-tlds")
+	}
+	return nil
+}
+
+// MonitorStatus is the body of /status in monitor mode
+type MonitorStatus struct {
+	Target   string     `json:"target"`
+	Schedule string     `json:"schedule"`
+	LastScan *time.Time `json:"last_scan,omitempty"`
+	NextScan *time.Time `json:"next_scan,omitempty"`
+	// Slice is the slice of the TLDs the next scan covers, from 1 to
+	// Slices, with -slices
+	Slice  int `json:"slice,omitempty"`
+	Slices int `json:"slices,omitempty"`
+	// FullCoverage is how long every TLD takes to be scanned once
+	FullCoverage string `json:"full_coverage,omitempty"`
+}
+
+// monitorState holds the MonitorStatus runMonitor keeps up to date for
+// /status
+type monitorState struct {
+	mu     sync.Mutex
+	status MonitorStatus
+}
+
+func (m *monitorState) update(status MonitorStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = status
+}
+
+func (m *monitorState) snapshot() MonitorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// handleStatus reports the monitor schedule
+func (m *monitorState) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, m.snapshot())
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSliceTLDs(t *testing.T) {
+	tlds := []string{".net", ".com", ".de", ".org", ".io", ".fr", ".uk"}
+	var covered []string
+	for slice := 0; slice < 3; slice++ {
+		part := sliceTLDs(tlds, slice, 3)
+		if len(part) < 2 || len(part) > 3 {
+			t.Errorf("Slice %d is unbalanced: %v", slice, part)
+		}
+		covered = append(covered, part...)
+	}
+	if strings.Join(covered, ",") != ".com,.de,.fr,.io,.net,.org,.uk" {
+		t.Errorf("Slices should cover every TLD once, got %v", covered)
+	}
+	if tlds[0] != ".net" {
+		t.Error("sliceTLDs modified its input")
+	}
+}
+
+func TestNextSlice(t *testing.T) {
+	if slice := nextSlice(nil, 24); slice != 0 {
+		t.Errorf("Expected the first slice before any cycle, got %d", slice)
+	}
+	if slice := nextSlice(&tldSet{Slice: 5, Slices: 24}, 24); slice != 6 {
+		t.Errorf("Expected slice 6, got %d", slice)
+	}
+	if slice := nextSlice(&tldSet{Slice: 23, Slices: 24}, 24); slice != 0 {
+		t.Errorf("Expected the slices to wrap around, got %d", slice)
+	}
+	if slice := nextSlice(&tldSet{Slice: 5, Slices: 12}, 24); slice != 0 {
+		t.Errorf("Expected a changed -slices to start over, got %d", slice)
+	}
+}
+
+func TestFullCoverage(t *testing.T) {
+	start := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	if coverage := fullCoverage(intervalSchedule(time.Hour), start, 24); coverage != 24*time.Hour {
+		t.Errorf("Expected daily coverage, got %s", coverage)
+	}
+}
+
+func TestValidateSlices(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"unset", Config{}, false},
+		{"monitor", Config{Monitor: true, Slices: 24}, false},
+		{"negative", Config{Monitor: true, Slices: -1}, true},
+		{"without monitor", Config{Slices: 24}, true},
+		{"domains file", Config{Monitor: true, Slices: 24, DomainsFile: "domains.txt"}, true},
+		{"only new TLDs", Config{Monitor: true, Slices: 24, OnlyNewTLDs: true}, true},
+	}
+	for _, tt := range tests {
+		if err := validateSlices(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateSlices() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMonitorStatus(t *testing.T) {
+	next := time.Date(2026, 10, 16, 4, 0, 0, 0, time.UTC)
+	state := &monitorState{}
+	state.update(MonitorStatus{Target: "example.com", Schedule: "every 1h0m0s", NextScan: &next, Slice: 5, Slices: 24, FullCoverage: "24h0m0s"})
+
+	recorder := httptest.NewRecorder()
+	state.handleStatus(recorder, httptest.NewRequest("GET", "/status", nil))
+	var status MonitorStatus
+	if err := json.NewDecoder(recorder.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Slice != 5 || status.Slices != 24 || !status.NextScan.Equal(next) || status.LastScan != nil {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ScanTiming records when a scan ran, how long each stage took and how fast
+// the lookups were
+type ScanTiming struct {
+	StartedAt        time.Time          `json:"started_at"`
+	FinishedAt       time.Time          `json:"finished_at"`
+	StageSeconds     map[string]float64 `json:"stage_seconds"`
+	DomainsPerSecond float64            `json:"domains_per_second"`
+	AvgLookupMs      int64              `json:"avg_lookup_ms"`
+	P95LookupMs      int64              `json:"p95_lookup_ms"`
+
+	last time.Time
+	// telemetry receives a span per stage; nil without -otlp-endpoint
+	telemetry *scanTelemetry
+}
+
+// newScanTiming starts timing a scan
+func newScanTiming() *ScanTiming {
+	now := time.Now()
+	return &ScanTiming{StartedAt: now, StageSeconds: make(map[string]float64), last: now}
+}
+
+// stage records the time since the previous stage ended and returns it
+func (t *ScanTiming) stage(name string) time.Duration {
+	now := time.Now()
+	elapsed := now.Sub(t.last)
+	t.StageSeconds[name] = math.Round(elapsed.Seconds()*1000) / 1000
+	t.telemetry.span(name, t.last, now, nil)
+	t.last = now
+	return elapsed
+}
+
+// finish stops the clock and computes the lookup throughput and latencies
+// of the looked-up domains over the "lookups" stage
+func (t *ScanTiming) finish(domains []DomainInfo) {
+	t.FinishedAt = time.Now()
+	if seconds := t.StageSeconds["lookups"]; seconds > 0 {
+		t.DomainsPerSecond = math.Round(float64(len(domains))/seconds*100) / 100
+	}
+
+	var latencies []int64
+	var total int64
+	for _, info := range domains {
+		if info.LookupMs > 0 {
+			latencies = append(latencies, info.LookupMs)
+			total += info.LookupMs
+		}
+	}
+	if len(latencies) == 0 {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	t.AvgLookupMs = total / int64(len(latencies))
+	t.P95LookupMs = latencies[int(math.Ceil(0.95*float64(len(latencies))))-1]
+}
+
+// formatMs renders a millisecond count like a time.Duration, e.g. "1.2s"
+func formatMs(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).String()
+}
+
+// progressETA estimates the time left to look up the remaining domains at
+// the rate achieved so far, rounded to the second; 0 before the first
+// domain is done
+func progressETA(processed, total int, elapsed time.Duration) time.Duration {
+	if processed == 0 || processed >= total {
+		return 0
+	}
+	eta := time.Duration(float64(elapsed) / float64(processed) * float64(total-processed))
+	return eta.Round(time.Second)
+}
+
+// printTiming prints the throughput and latency lines of the scan summary
+func printTiming(timing *ScanTiming) {
+	if timing == nil {
+		return
+	}
+	fmt.Printf("Rate: %s%.2f domains/second%s\n", ColorPurple, timing.DomainsPerSecond, ColorReset)
+	if timing.AvgLookupMs > 0 {
+		fmt.Printf("Lookup Time: avg %s, p95 %s\n", formatMs(timing.AvgLookupMs), formatMs(timing.P95LookupMs))
+	}
+}
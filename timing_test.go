@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScanTimingFinish(t *testing.T) {
+	timing := newScanTiming()
+	timing.StageSeconds["lookups"] = 2
+	domains := make([]DomainInfo, 20)
+	for i := range domains {
+		domains[i].LookupMs = int64(i+1) * 100
+	}
+	timing.finish(domains)
+
+	if timing.DomainsPerSecond != 10 {
+		t.Errorf("DomainsPerSecond = %v; expected 10", timing.DomainsPerSecond)
+	}
+	if timing.AvgLookupMs != 1050 || timing.P95LookupMs != 1900 {
+		t.Errorf("Lookup times avg %d, p95 %d; expected 1050 and 1900", timing.AvgLookupMs, timing.P95LookupMs)
+	}
+	if timing.FinishedAt.Before(timing.StartedAt) {
+		t.Error("FinishedAt is before StartedAt")
+	}
+	if got := formatMs(timing.P95LookupMs); got != "1.9s" {
+		t.Errorf("formatMs = %q", got)
+	}
+}
+
+func TestScanTimingStage(t *testing.T) {
+	timing := newScanTiming()
+	timing.last = timing.last.Add(-1500 * time.Millisecond)
+	if elapsed := timing.stage("lookups"); elapsed < 1500*time.Millisecond {
+		t.Errorf("stage returned %v", elapsed)
+	}
+	if seconds := timing.StageSeconds["lookups"]; seconds < 1.5 || seconds > 2 {
+		t.Errorf("StageSeconds = %v", seconds)
+	}
+}
+
+func TestProgressETA(t *testing.T) {
+	testCases := []struct {
+		processed, total int
+		elapsed          time.Duration
+		expected         time.Duration
+	}{
+		{0, 100, time.Second, 0},
+		{25, 100, 10 * time.Second, 30 * time.Second},
+		{3, 10, 1500 * time.Millisecond, 4 * time.Second},
+		{100, 100, time.Minute, 0},
+	}
+	for _, tc := range testCases {
+		if got := progressETA(tc.processed, tc.total, tc.elapsed); got != tc.expected {
+			t.Errorf("progressETA(%d, %d, %s) = %s; expected %s", tc.processed, tc.total, tc.elapsed, got, tc.expected)
+		}
+	}
+}
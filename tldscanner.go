@@ -1,170 +1,1010 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"crypto"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
 	"sort"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/likexian/whois"
+	"filippo.io/age"
 	whoisparser "github.com/likexian/whois-parser"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Domain      string
-	Wordlist    string
-	Output      string
-	Threads     int
-	Timeout     int
-	Verbose     bool
-	JSONOutput  bool
-	SaveAll     bool
-	RateLimit   int
+	Domain            string
+	Targets           []string // other -d domains of the target's group
+	Wordlist          string
+	Countries         string
+	Watchlist         bool
+	WatchlistURL      string
+	WatchedTLDs       []string
+	OnlyNewTLDs       bool
+	Slices            int
+	ScanTLDs          []string // TLDs a monitor cycle scans instead of the wordlist's
+	DomainsFile       string
+	ImportDomains     []string // domains `tldscanner import` read from other tools
+	ErrorsFile        string
+	Prioritize        bool
+	History           bool
+	Monitor           bool
+	Rebaseline        bool
+	Interval          time.Duration
+	Schedule          string
+	HealthListen      string
+	SMTPServer        string
+	SMTPUser          string
+	MailTo            string
+	MailFrom          string
+	TeamsWebhook      string
+	TelegramToken     string
+	TelegramChat      string
+	PagerDuty         bool
+	Opsgenie          bool
+	OpsgenieRegion    string
+	PageMinRisk       int
+	PageMinSignal     float64
+	JiraProject       string
+	JiraURL           string
+	JiraIssueType     string
+	GitHubRepo        string
+	IssueLabels       string
+	SyslogAddr        string
+	SyslogFormat      string
+	Neo4j             string
+	Neo4jDB           string
+	Pprof             string
+	PprofSnapshot     time.Duration
+	PprofDir          string
+	Debug             bool
+	OTLPEndpoint      string
+	Tor               bool
+	TorAddr           string
+	TorIsolate        int
+	CABundle          string
+	DNSSEC            bool
+	DNSSECResolver    string
+	CAA               bool
+	SMTPProbe         bool
+	HistoryDB         string
+	Shuffle           bool
+	Jitter            string
+	Output            string
+	Threads           int
+	DNSThreads        int
+	EnrichThreads     int
+	ScoreThreads      int
+	QueueSize         int
+	DNSPrecheck       bool
+	Checkpoint        string
+	ChunkSize         int
+	Timeout           int
+	SourceIPs         stringList
+	RDAPFallback      bool
+	RaceRDAP          bool
+	Verbose           bool
+	JSONOutput        bool
+	Compress          bool
+	SaveAll           bool
+	Risk              bool
+	SuggestRegister   bool
+	RateLimit         int
+	Burst             int
+	AutoTune          bool
+	AutoTuneMax       int
+	RateScope         string
+	NoRegistryPolicy  bool
+	MaxQueries        int
+	MaxServerQueries  int
+	MaxRuntime        time.Duration
+	MaxErrorRate      float64
+	MaxErrors         int
+	FlushEvery        time.Duration
+	Cache             string
+	CacheTTL          time.Duration
+	RegistrarPivot    bool
+	PivotWindow       int
+	EmailMatch        bool
+	NSMatch           bool
+	Org               string
+	SoftFailTarget    bool
+	ExactOrg          bool
+	Transliterate     bool
+	MailDomains       string
+	FlagCountries     string
+	Keywords          string
+	KnownDomains      string
+	RegistrarAccounts string
+	IgnoreFile        string
+	Format            string
+	OutputAll         string
+	OutputDir         string
+	Template          string
+	ICSReminders      string
+	FeedFields        string
+	Filter            string
+	Sort              string
+	Sign              string
+	Encrypt           stringList
+	NoColor           bool
+	ProgressJSON      bool
+	ErrorThreshold    float64
+	ConfigFile        string
+	SecurityTrails    bool
+	VirusTotal        bool
+	Exposure          string
+	PassiveDNS        string
+	Portfolio         string
+	URLScan           bool
+	URLScanVisibility string
+	Enrich            string
+	Script            string
+
+	// APIKeys and APIUsernames hold integration credentials resolved at startup
+	APIKeys      map[string]string
+	APIUsernames map[string]string
+
+	// OrgNormalizer applies the config file's organization rules
+	OrgNormalizer *orgNormalizer
+	// WhoisPatterns extract fields from WHOIS text whoisparser rejects
+	WhoisPatterns whoisPatterns
+	// WhoisQueries are the per-TLD registry query strings
+	WhoisQueries whoisQueries
+	// WhoisDisclaimers match disclaimer paragraphs stripped before parsing
+	WhoisDisclaimers disclaimerPatterns
+	// WhoisLabels translate local-language field labels before parsing
+	WhoisLabels whoisLabels
+	// Enrichers are the custom enrichers selected with -enrich
+	Enrichers []Enricher
+	// MatchScript is the -script hook deciding matches
+	MatchScript *matchScript
+	// LookupCache is the -cache backend, nil without one
+	LookupCache lookupCache
+	// KnownAssets is the -known-domains inventory, nil without one
+	KnownAssets map[string]bool
+	// IgnoreList is the -ignore-file list, nil without one
+	IgnoreList *ignoreList
+	// ResultFilter is the compiled -filter expression, nil without one
+	ResultFilter *resultFilter
+	// Signer signs the output files with -sign; nil without it
+	Signer crypto.Signer
+	// Recipients are the -encrypt age recipients output files are
+	// encrypted to
+	Recipients []age.Recipient
+	// WhoisClient answers the WHOIS queries; nil uses a network client
+	// built from -timeout, -source-ip and -tor
+	WhoisClient WhoisClient
+	// APIClient rate limits, caches and counts the requests of the API
+	// integrations; nil leaves them unthrottled
+	APIClient *apiClient
+	// Budget is the scan's query budget, charged by every query a lookup
+	// sends; nil outside scans
+	Budget *queryBudget
+	// Trace is the trace of the lookup a Config copy is passed to; nil
+	// outside scans
+	Trace *lookupTrace
+	// TorDialer connects through Tor with -tor; nil otherwise
+	TorDialer *torDialer
+	// OTLP exports scan telemetry to -otlp-endpoint; nil without one
+	OTLP *otlpExporter
+	// Telemetry collects the spans of the scan a Config copy is passed to;
+	// nil outside scans or without an exporter
+	Telemetry *scanTelemetry
+	// Syslog streams findings to -syslog; nil without one
+	Syslog *syslogSender
+	// Neo4jSink upserts each scan's graph into -neo4j; nil without one
+	Neo4jSink *neo4jSink
+	// Stats collects the stage latencies of the scan a Config copy is
+	// passed to with -v; nil otherwise
+	Stats *statsCollector
+	// OnDomain is called after each lookup with the scan progress; it must
+	// not block
+	OnDomain func(info DomainInfo, matched bool, processed, total int)
+	// OnFlush is called every FlushEvery with the lookups so far, from the
+	// goroutine collecting them
+	OnFlush func(all, matching, signals []DomainInfo)
+}
+
+// liveOutput reports whether per-domain progress should be printed to the
+// terminal; machine-readable formats keep stdout clean
+func (c Config) liveOutput() bool {
+	return c.Format == "text"
 }
 
 // DomainInfo represents domain information
 type DomainInfo struct {
-	Domain       string    `json:"domain"`
-	Organization string    `json:"organization"`
-	Registrar    string    `json:"registrar"`
-	CreatedDate  string    `json:"created_date"`
-	ExpiryDate   string    `json:"expiry_date"`
-	Status       string    `json:"status"`
-	NameServers  []string  `json:"name_servers"`
-	Error        string    `json:"error,omitempty"`
-	Timestamp    time.Time `json:"timestamp"`
+	Domain            string             `json:"domain"`
+	UnicodeDomain     string             `json:"unicode_domain,omitempty"`
+	Organization      string             `json:"organization"`
+	OrganizationLatin string             `json:"organization_transliterated,omitempty"`
+	Country           string             `json:"country,omitempty"`
+	Registrar         string             `json:"registrar"`
+	CreatedDate       string             `json:"created_date"`
+	ExpiryDate        string             `json:"expiry_date"`
+	CreatedAt         *time.Time         `json:"created_at,omitempty"`
+	ExpiresAt         *time.Time         `json:"expires_at,omitempty"`
+	Status            string             `json:"status"`
+	EPPStatus         []string           `json:"epp_status,omitempty"`
+	TransferUnlocked  bool               `json:"transfer_unlocked,omitempty"`
+	DropCatch         bool               `json:"drop_catch,omitempty"`
+	NameServers       []string           `json:"name_servers"`
+	NSProvider        string             `json:"ns_provider,omitempty"`
+	NSProviderType    string             `json:"ns_provider_type,omitempty"`
+	Emails            []string           `json:"emails,omitempty"`
+	AbuseEmail        string             `json:"abuse_email,omitempty"`
+	AbusePhone        string             `json:"abuse_phone,omitempty"`
+	DNSSEC            string             `json:"dnssec,omitempty"`
+	CAA               []string           `json:"caa,omitempty"`
+	CertIssuanceRisk  string             `json:"cert_issuance_risk,omitempty"`
+	MailCapable       bool               `json:"mail_capable,omitempty"`
+	SMTP              *MailProbe         `json:"smtp,omitempty"`
+	FaviconMatch      bool               `json:"favicon_match,omitempty"`
+	ContentSimilarity float64            `json:"content_similarity,omitempty"`
+	Parked            bool               `json:"parked,omitempty"`
+	ParkingSignals    []string           `json:"parking_signals,omitempty"`
+	Phishing          []string           `json:"phishing_indicators,omitempty"`
+	Language          string             `json:"language,omitempty"`
+	MatchReason       string             `json:"match_reason,omitempty"`
+	MatchedEmail      string             `json:"matched_email,omitempty"`
+	Ownership         string             `json:"ownership,omitempty"`
+	WhoisServer       string             `json:"whois_server,omitempty"`
+	RegistrantServer  string             `json:"registrant_server,omitempty"`
+	Source            string             `json:"source,omitempty"`
+	Completeness      int                `json:"completeness,omitempty"`
+	MissingFields     []string           `json:"missing_fields,omitempty"`
+	Technique         string             `json:"technique,omitempty"`
+	ImportedFrom      []string           `json:"imported_from,omitempty"`
+	RegistrantHistory []RegistrantRecord `json:"registrant_history,omitempty"`
+	DNS               *DNSRecords        `json:"dns,omitempty"`
+	VirusTotal        *VirusTotalReport  `json:"virustotal,omitempty"`
+	Exposure          *Exposure          `json:"exposure,omitempty"`
+	PassiveDNS        []PassiveDNSRecord `json:"passive_dns,omitempty"`
+	URLScan           *URLScanSubmission `json:"urlscan,omitempty"`
+	HTTP              *HTTPProbe         `json:"http,omitempty"`
+	Extra             EnrichmentData     `json:"extra,omitempty"`
+	EnrichmentErrors  []string           `json:"enrichment_errors,omitempty"`
+	Signals           []Signal           `json:"signals,omitempty"`
+	Tags              []string           `json:"tags,omitempty"`
+	Note              string             `json:"note,omitempty"`
+	Metadata          Metadata           `json:"metadata,omitempty"`
+	Ignored           string             `json:"ignored,omitempty"`
+	RiskScore         int                `json:"risk_score,omitempty"`
+	RiskFactors       []RiskFactor       `json:"risk_factors,omitempty"`
+	ErrorCode         ErrorCode          `json:"error_code,omitempty"`
+	Error             string             `json:"error,omitempty"`
+	LookupMs          int64              `json:"lookup_ms,omitempty"`
+	TraceID           string             `json:"trace_id,omitempty"`
+	Timestamp         time.Time          `json:"timestamp"`
+
+	// Candidate organizations of a record without one, for the prompt
+	orgHints []string
 }
 
 // Result holds the scan results
 type Result struct {
-	TargetDomain     string       `json:"target_domain"`
-	TargetOrg        string       `json:"target_organization"`
-	MatchingDomains  []DomainInfo `json:"matching_domains"`
-	AllDomains       []DomainInfo `json:"all_domains,omitempty"`
-	ScanDuration     string       `json:"scan_duration"`
-	TotalScanned     int          `json:"total_scanned"`
-	TotalMatches     int          `json:"total_matches"`
-	TotalErrors      int          `json:"total_errors"`
+	SchemaVersion     string         `json:"schema_version"`
+	Scanner           *BuildInfo     `json:"scanner,omitempty"`
+	Brand             string         `json:"brand,omitempty"`
+	Tags              []string       `json:"tags,omitempty"`
+	Metadata          Metadata       `json:"metadata,omitempty"`
+	TargetDomain      string         `json:"target_domain"`
+	TargetOrg         string         `json:"target_organization"`
+	TargetDNSSEC      string         `json:"target_dnssec,omitempty"`
+	TargetExpiry      string         `json:"target_expiry,omitempty"`
+	TargetUnlocked    bool           `json:"target_transfer_unlocked,omitempty"`
+	TargetError       string         `json:"target_error,omitempty"`
+	GroupTargets      []GroupTarget  `json:"group_targets,omitempty"`
+	TargetDrift       []FieldChange  `json:"target_drift,omitempty"`
+	Filter            string         `json:"filter,omitempty"`
+	MatchingDomains   []DomainInfo   `json:"matching_domains"`
+	SignalDomains     []DomainInfo   `json:"signal_domains,omitempty"`
+	Lookalikes        []DomainInfo   `json:"lookalikes,omitempty"`
+	AllDomains        []DomainInfo   `json:"all_domains,omitempty"`
+	Organizations     []OrgCluster   `json:"organizations,omitempty"`
+	SkippedDomains    []string       `json:"skipped_domains,omitempty"`
+	SuppressedDomains []string       `json:"suppressed_domains,omitempty"`
+	Truncated         bool           `json:"truncated,omitempty"`
+	Partial           bool           `json:"partial,omitempty"`
+	Aborted           string         `json:"aborted,omitempty"`
+	ScanDuration      string         `json:"scan_duration"`
+	Timing            *ScanTiming    `json:"timing,omitempty"`
+	Stats             *ScanStats     `json:"stats,omitempty"`
+	APIUsage          []APIUsage     `json:"api_usage,omitempty"`
+	TotalScanned      int            `json:"total_scanned"`
+	TotalMatches      int            `json:"total_matches"`
+	TotalShadow       int            `json:"total_shadow,omitempty"`
+	TotalSignals      int            `json:"total_signals,omitempty"`
+	TotalLookalikes   int            `json:"total_lookalikes,omitempty"`
+	TotalSkipped      int            `json:"total_skipped,omitempty"`
+	TotalErrors       int            `json:"total_errors"`
+	ErrorsByType      map[string]int `json:"errors_by_type,omitempty"`
+	ErrorsByTLD       map[string]int `json:"errors_by_tld,omitempty"`
+	WhoisQuality      []TLDQuality   `json:"whois_quality,omitempty"`
+	Registrations     []Registration `json:"registrations,omitempty"`
+
+	// target is the target's own WHOIS record, compared with its baseline
+	// in monitor mode
+	target *DomainInfo
 }
 
-// Colors for terminal output
+// Exit codes let CI jobs and cron wrappers branch on the scan outcome
 const (
-	ColorReset  = "\033[0m"
-	ColorRed    = "\033[31m"
-	ColorGreen  = "\033[32m"
-	ColorYellow = "\033[33m"
-	ColorBlue   = "\033[34m"
-	ColorPurple = "\033[35m"
-	ColorCyan   = "\033[36m"
-	ColorWhite  = "\033[37m"
+	ExitMatches    = 0 // scan completed with at least one match
+	ExitUsage      = 1 // usage, configuration or setup error
+	ExitNoMatches  = 2 // scan completed without matches
+	ExitHighErrors = 3 // the error rate exceeded -error-threshold, or the error breaker aborted the scan
 )
 
 func main() {
-	config := parseFlags()
-	
-	if config.Domain == "" {
+	os.Exit(run())
+}
+
+func run() int {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := trustCABundle(os.Getenv(caBundleEnvVar)); err != nil {
+				fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+				return ExitUsage
+			}
+			return command(os.Args[2:])
+		}
+	}
+
+	return runScanConfig(parseFlags())
+}
+
+// runScanConfig runs a scan, a monitor or a portfolio scan with parsed
+// options. The scan subcommand shares it with the main command.
+func runScanConfig(config Config) int {
+	if !colorsEnabled(config.NoColor) {
+		disableColors()
+	}
+
+	if config.Domain == "" && config.Portfolio == "" {
 		fmt.Fprintf(os.Stderr, "%s[ERROR]%s Domain is required. Use -h for help.\n", ColorRed, ColorReset)
-		os.Exit(1)
+		return ExitUsage
+	}
+
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if err := resolveCredentials(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if err := applyFileConfig(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if err := startDiagnostics(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	if config.pipedOutput() {
+		os.Stdout = os.Stderr
 	}
 
 	// Print banner
 	printBanner()
 
-	// Get target domain organization
-	fmt.Printf("%s[INFO]%s Analyzing target domain: %s\n", ColorBlue, ColorReset, config.Domain)
-	targetInfo, err := getWhoisInfo(config.Domain, config.Timeout)
+	if config.Monitor {
+		return runMonitor(config)
+	}
+	if config.Portfolio != "" {
+		return runPortfolio(config)
+	}
+
+	startTime := time.Now()
+	config, err := withOutputRun(config, config.Domain, startTime)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s[ERROR]%s Failed to get WHOIS info for %s: %v\n", ColorRed, ColorReset, config.Domain, err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	result, allResults, err := scan(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
 	}
 
-	if targetInfo.Organization == "" {
-		fmt.Fprintf(os.Stderr, "%s[WARNING]%s No organization found for %s\n", ColorYellow, ColorReset, config.Domain)
-		os.Exit(1)
+	if config.History {
+		recordHistory(result, allResults, startTime, config)
 	}
 
-	fmt.Printf("%s[INFO]%s Target organization: %s%s%s\n", ColorBlue, ColorReset, ColorGreen, targetInfo.Organization, ColorReset)
+	// Output results
+	outputStarted := time.Now()
+	writeOutput(result, config)
+	linkLatestRun(config)
+	exportTelemetry(result, outputStarted)
+
+	// Print summary
+	printSummary(result)
+
+	sendNotifications(config, Notification{Result: result})
+	config.Syslog.summary(result)
+	config.Neo4jSink.export(result)
 
-	// Load TLD wordlist
-	tlds, err := loadWordlist(config.Wordlist)
+	return exitCode(result, config.ErrorThreshold)
+}
+
+// validateConfig checks every option before any scanning starts and
+// reports all the problems found at once
+func validateConfig(config Config) error {
+	validators := []func() error{
+		func() error { return validateInputs(config) },
+		func() error { return validateOutputPaths(config) },
+		func() error { return validateFormat(config) },
+		func() error { return validateICS(config) },
+		func() error { return validateFeed(config) },
+		func() error { return validateFilter(config.Filter) },
+		func() error { return validateSort(config.Sort) },
+		func() error { return validateSign(config) },
+		func() error { return validateEncrypt(config) },
+		func() error { return validateTimeout(config) },
+		func() error { return validateRateLimit(config) },
+		func() error { return validateAutoTune(config) },
+		func() error { return validatePipeline(config) },
+		func() error { return validateCheckpoint(config) },
+		func() error { return validateFlushEvery(config) },
+		func() error { return validateOutputDir(config) },
+		func() error { return validateTargets(config) },
+		func() error { return validateBudget(config) },
+		func() error { return validateCache(config) },
+		func() error { return validateSourceIPs(config.SourceIPs) },
+		func() error { return validateExposure(config.Exposure) },
+		func() error { return validatePassiveDNS(config.PassiveDNS) },
+		func() error { return validatePortfolio(config) },
+		func() error { return validateCountries(config) },
+		func() error { return validateFlagCountries(config.FlagCountries) },
+		func() error { return validateURLScanVisibility(config.URLScanVisibility) },
+		func() error { return validateSMTP(config) },
+		func() error { return validateTeams(config) },
+		func() error { return validateTelegram(config) },
+		func() error { return validatePaging(config) },
+		func() error { return validateIssues(config) },
+		func() error { return validateSyslog(config) },
+		func() error { return validateNeo4j(config) },
+		func() error { return validateMonitor(config) },
+		func() error { return validateWatchlist(config) },
+		func() error { return validateOnlyNewTLDs(config) },
+		func() error { return validateSlices(config) },
+		func() error { return validateErrorBreaker(config) },
+		func() error { return validateRegistrarAccounts(config) },
+		func() error { return validateDiagnostics(config) },
+		func() error { return validateTor(config) },
+		func() error { return validateCABundle(config.CABundle) },
+		func() error { return validateDNSSEC(config) },
+	}
+	var problems configErrors
+	for _, validate := range validators {
+		problems.add(validate())
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// scan looks up the target, scans every candidate domain from the wordlist
+// and returns the result along with every looked-up domain
+func scan(config Config) (Result, []DomainInfo, error) {
+	timing := newScanTiming()
+	startScan(&config, timing)
+	group := lookupGroup(&config)
+	targetInfo, err := lookupTarget(&config)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s[ERROR]%s Failed to load wordlist: %v\n", ColorRed, ColorReset, err)
-		os.Exit(1)
+		return Result{}, nil, err
 	}
+	timing.stage("target_lookup")
 
-	fmt.Printf("%s[INFO]%s Loaded %d TLDs from wordlist\n", ColorBlue, ColorReset, len(tlds))
+	domains, err := candidateDomains(config)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	timing.stage("candidates")
 
-	// Generate domain list
-	baseDomain := extractBaseDomain(config.Domain)
-	domains := generateDomains(baseDomain, tlds)
+	if config.AutoTune {
+		fmt.Printf("%s[INFO]%s Starting scan of %d domains with auto-tuned concurrency (max %d)...\n", ColorBlue, ColorReset, domains.total, config.AutoTuneMax)
+	} else {
+		fmt.Printf("%s[INFO]%s Starting scan of %d domains with %d threads...\n", ColorBlue, ColorReset, domains.total, config.Threads)
+	}
+	if config.DNSPrecheck {
+		fmt.Printf("%s[INFO]%s Prechecking DNS with %d threads\n", ColorBlue, ColorReset, config.DNSThreads)
+	}
 
-	fmt.Printf("%s[INFO]%s Starting scan of %d domains with %d threads...\n", ColorBlue, ColorReset, len(domains), config.Threads)
+	cp, err := openCheckpoint(config)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	defer cp.Close()
+
+	result := Result{
+		SchemaVersion:  SchemaVersion,
+		Scanner:        currentBuildInfo(),
+		TargetDomain:   config.Domain,
+		TargetOrg:      targetInfo.Organization,
+		TargetDNSSEC:   targetDNSSEC(config),
+		TargetExpiry:   targetInfo.ExpiryDate,
+		TargetUnlocked: targetTransferUnlocked(targetInfo),
+		TargetError:    targetInfo.Error,
+		GroupTargets:   group,
+		target:         targetInfo,
+	}
+	config.OnFlush = partialFlusher(result, config)
 
 	// Perform scan
-	startTime := time.Now()
-	allResults, matchingResults := scanDomains(domains, targetInfo.Organization, config)
-	scanDuration := time.Since(startTime)
+	allResults, matchingResults, signalResults, skipped := scanCandidates(domains, targetInfo, cp, config)
+	scanDuration := timing.stage("lookups")
 
 	// Prepare results
-	result := Result{
-		TargetDomain:    config.Domain,
-		TargetOrg:       targetInfo.Organization,
-		MatchingDomains: matchingResults,
-		ScanDuration:    scanDuration.String(),
-		TotalScanned:    len(domains),
-		TotalMatches:    len(matchingResults),
-		TotalErrors:     countErrors(allResults),
+	result.MatchingDomains = matchingResults
+	result.SignalDomains = signalResults
+	result.SkippedDomains = skipped.domains
+	result.Truncated = skipped.truncated
+	result.Aborted = skipped.aborted
+	result.ScanDuration = scanDuration.String()
+	result.TotalScanned = len(allResults)
+	result.TotalMatches = len(matchingResults)
+	result.TotalSignals = len(signalResults)
+	result.TotalSkipped = len(skipped.domains)
+	summarizeErrors(&result, allResults)
+	summarizeQuality(&result, allResults)
+	result.SuppressedDomains = ignoredDomains(allResults)
+	if config.SuggestRegister {
+		result.Registrations = suggestRegistrations(config.Domain, unregisteredDomains(allResults, skipped), func(string) string {
+			if config.DomainsFile != "" || config.ImportDomains != nil {
+				return ""
+			}
+			return TechniqueTLD
+		})
 	}
 
-	if config.SaveAll {
+	if config.Risk {
+		lookalikes := lookalikesOf(allResults, matchingResults)
+		fmt.Printf("%s[INFO]%s Scoring %d registered lookalikes...\n", ColorBlue, ColorReset, len(lookalikes))
+		scoreLookalikes(lookalikes, targetInfo, brandKeywords(config), false, config)
+		result.Lookalikes = lookalikes
+		result.TotalLookalikes = len(lookalikes)
+		timing.stage("risk_scoring")
+	}
+	if config.SaveAll || config.Format == "list-all" {
 		result.AllDomains = allResults
 	}
+	finishResult(&result, allResults, targetInfo, timing, config)
 
-	// Output results
-	if config.JSONOutput {
-		outputJSON(result, config.Output)
-	} else {
-		outputText(result, config.Output, config.Verbose)
+	return result, allResults, nil
+}
+
+// startScan sets up what a scan collects as it runs: the telemetry spans of
+// its stages, the -v latency statistics and the API usage
+func startScan(config *Config, timing *ScanTiming) {
+	config.Telemetry = config.OTLP.startScan(config.Domain, timing)
+	if config.Verbose {
+		config.Stats = newStatsCollector()
 	}
+	config.APIClient.reset()
+}
 
-	// Print summary
-	printSummary(result)
+// finishResult completes a result once its domains were looked up, the same
+// way for a scan, a brand sweep and a retry: the lookup timing and -v
+// statistics of allResults, the API usage, the target's lookup error, the
+// organization clusters of -all, the domain tags and the ownership labels
+func finishResult(result *Result, allResults []DomainInfo, targetInfo *DomainInfo, timing *ScanTiming, config Config) {
+	timing.finish(allResults)
+	result.Timing = timing
+	result.Stats = config.Stats.summary(allResults)
+	result.APIUsage = config.APIClient.usage()
+	result.TargetError = targetInfo.Error
+	result.target = targetInfo
+	if config.SaveAll || len(result.Organizations) > 0 {
+		result.Organizations = clusterOrganizations(result.AllDomains, targetInfo.Organization, config)
+	}
+	tagResult(result, config)
+	classifyOwnership(result, config.KnownAssets)
+}
+
+// lookupTarget looks up the target domain's organization, turning off the
+// registrar pivot when the target record lacks the fields it needs. -org
+// stands in for a missing or redacted organization.
+func lookupTarget(config *Config) (*DomainInfo, error) {
+	fmt.Printf("%s[INFO]%s Analyzing target domain: %s\n", ColorBlue, ColorReset, config.Domain)
+	targetInfo, err := getWhoisInfo(config.Domain, *config)
+	if err != nil {
+		if !config.SoftFailTarget {
+			return nil, fmt.Errorf("failed to get WHOIS info for %s: %w", config.Domain, err)
+		}
+		targetInfo = degradedTarget(*config, err)
+	}
+	normalizeDates(targetInfo)
+	classifyNameServers(targetInfo)
+
+	if config.RegistrarPivot {
+		if _, ok := targetInfo.created(); !ok || targetInfo.Registrar == "" {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s Registrar pivot disabled: target registrar or creation date unavailable\n", ColorYellow, ColorReset)
+			config.RegistrarPivot = false
+		}
+	}
+
+	if targetInfo.Organization != "" && !privacyPattern.MatchString(targetInfo.Organization) {
+		fmt.Printf("%s[INFO]%s Target organization: %s%s%s\n", ColorBlue, ColorReset, ColorGreen, targetInfo.Organization, ColorReset)
+		return targetInfo, nil
+	}
+
+	// A redacted organization would match every other redacted record, so
+	// the scan relies on the remaining pivots instead
+	redacted := targetInfo.Organization
+	targetInfo.Organization = ""
+	if config.Org != "" {
+		targetInfo.Organization = config.Org
+		fmt.Printf("%s[INFO]%s Target organization: %s%s%s (-org)\n", ColorBlue, ColorReset, ColorGreen, config.Org, ColorReset)
+		return targetInfo, nil
+	}
+	pivots := targetPivots(config, targetInfo)
+	if len(pivots) == 0 && config.canPrompt() {
+		if org := promptOrganization(os.Stdin, os.Stdout, config.Domain, targetInfo.orgHints); org != "" {
+			targetInfo.Organization = org
+			fmt.Printf("%s[INFO]%s Target organization: %s%s%s\n", ColorBlue, ColorReset, ColorGreen, org, ColorReset)
+			return targetInfo, nil
+		}
+	}
+	if len(pivots) == 0 && usableNameServers(targetInfo) {
+		config.NSMatch = true
+		pivots = append(pivots, "shared name servers")
+	}
+	if len(pivots) == 0 {
+		if redacted != "" {
+			return nil, fmt.Errorf("organization of %s is redacted (%q) and no other pivot is available; use -org, -email-match, -registrar-pivot or org aliases", config.Domain, redacted)
+		}
+		return nil, fmt.Errorf("no organization found for %s and no other pivot is available; use -org, -email-match, -registrar-pivot or org aliases", config.Domain)
+	}
+	fmt.Fprintf(os.Stderr, "%s[WARNING]%s No usable organization for %s; matching by %s only\n",
+		ColorYellow, ColorReset, config.Domain, strings.Join(pivots, ", "))
+	return targetInfo, nil
+}
+
+// targetPivots returns the match signals usable without the target's
+// organization: organization aliases, contact email domains and the
+// registrar pivot. Email matching is turned on when -mail-domains is given
+// or the target's own record lists a contact on its domain, and the
+// registrar pivot when
+// the target's registrar and creation date are known.
+func targetPivots(config *Config, target *DomainInfo) []string {
+	var pivots []string
+	if config.OrgNormalizer != nil && len(config.OrgNormalizer.rules.Aliases) > 0 {
+		pivots = append(pivots, "organization aliases")
+	}
+	if !config.EmailMatch && config.MailDomains != "" {
+		config.EmailMatch = true
+	}
+	if !config.EmailMatch {
+		if _, ok := emailDomainMatch(target, mailDomainSet(target.Domain, "")); ok {
+			config.EmailMatch = true
+		}
+	}
+	if config.EmailMatch {
+		pivots = append(pivots, "contact email domain")
+	}
+	if !config.RegistrarPivot && config.PivotWindow > 0 && target.Registrar != "" {
+		if _, ok := target.created(); ok {
+			config.RegistrarPivot = true
+		}
+	}
+	if config.RegistrarPivot {
+		pivots = append(pivots, "registrar pivot")
+	}
+	if config.NSMatch {
+		pivots = append(pivots, "shared name servers")
+	}
+	return pivots
+}
+
+// candidateDomains returns the domains to scan: the -domains-file list as
+// given, or the target's base name combined with every wordlist TLD
+func candidateDomains(config Config) (candidateSeq, error) {
+	if config.ImportDomains != nil {
+		return sliceCandidates(config.ImportDomains), nil
+	}
+	if config.DomainsFile != "" {
+		domains, metadata, skipped, err := readDomainRecords(config.DomainsFile)
+		if err != nil {
+			return candidateSeq{}, fmt.Errorf("failed to load domain list: %w", err)
+		}
+		fmt.Printf("%s[INFO]%s Loaded %d domains from %s\n", ColorBlue, ColorReset, len(domains), config.DomainsFile)
+		if skipped.total() > 0 {
+			fmt.Fprintf(os.Stderr, "%s[WARNING]%s Skipped %d domain list entries: %s\n", ColorYellow, ColorReset, skipped.total(), skipped)
+		}
+
+		// The targets themselves are never lookalikes
+		candidates := domains[:0]
+		for _, domain := range domains {
+			if !config.isTarget(domain) {
+				candidates = append(candidates, domain)
+			}
+		}
+		if config.Shuffle {
+			rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		}
+		seq := sliceCandidates(candidates)
+		seq.metadata = metadata
+		return seq, nil
+	}
+
+	tlds := config.ScanTLDs
+	if tlds == nil {
+		var err error
+		if tlds, err = loadTLDs(config); err != nil {
+			return candidateSeq{}, err
+		}
+		if len(config.WatchedTLDs) > 0 {
+			tlds = withWatchedTLDs(tlds, config.WatchedTLDs)
+		}
+	}
+
+	if config.Shuffle {
+		rand.Shuffle(len(tlds), func(i, j int) { tlds[i], tlds[j] = tlds[j], tlds[i] })
+	}
+	if config.Prioritize {
+		tlds = prioritizeTLDs(tlds)
+	}
+
+	var names []string
+	for _, domain := range config.targetDomains() {
+		if name := extractBaseDomain(domain); !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	return productCandidates(names, tlds), nil
+}
+
+// loadTLDs returns the TLDs of the -countries list or of the wordlist,
+// falling back to the embedded lists when the default wordlist.txt is not
+// next to the binary
+func loadTLDs(config Config) ([]string, error) {
+	if config.Countries != "" {
+		tlds, err := expandCountries(config.Countries)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("%s[INFO]%s Scanning %d TLDs of %s\n", ColorBlue, ColorReset, len(tlds), config.Countries)
+		return tlds, nil
+	}
+
+	if config.Wordlist == defaultWordlist {
+		if _, err := os.Stat(config.Wordlist); os.IsNotExist(err) {
+			config.Wordlist = builtinPrefix + "all"
+			fmt.Printf("%s[INFO]%s %s not found, using %s\n", ColorBlue, ColorReset, defaultWordlist, config.Wordlist)
+		}
+	}
+	tlds, skipped, err := readWordlist(config.Wordlist)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load wordlist: %w", err)
+	}
+
+	fmt.Printf("%s[INFO]%s Loaded %d TLDs from wordlist\n", ColorBlue, ColorReset, len(tlds))
+	if skipped.total() > 0 {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Skipped %d wordlist entries: %s\n", ColorYellow, ColorReset, skipped.total(), skipped)
+	}
+	return tlds, nil
+}
+
+// exitCode maps a completed scan to its process exit code. Only failed
+// lookups count toward the error threshold, not unregistered domains.
+func exitCode(result Result, errorThreshold float64) int {
+	if result.Aborted != "" {
+		return ExitHighErrors
+	}
+	if result.TotalScanned > 0 && errorThreshold > 0 &&
+		float64(result.failedLookups())/float64(result.TotalScanned) > errorThreshold {
+		return ExitHighErrors
+	}
+	if result.TotalMatches == 0 {
+		return ExitNoMatches
+	}
+	return ExitMatches
 }
 
 func parseFlags() Config {
 	var config Config
-
-	flag.StringVar(&config.Domain, "d", "", "Target domain to analyze (required)")
-	flag.StringVar(&config.Wordlist, "w", "wordlist.txt", "Path to TLD wordlist file")
-	flag.StringVar(&config.Output, "o", "", "Output file path (optional)")
-	flag.IntVar(&config.Threads, "t", 10, "Number of concurrent threads")
-	flag.IntVar(&config.Timeout, "timeout", 30, "WHOIS timeout in seconds")
-	flag.BoolVar(&config.Verbose, "v", false, "Verbose output")
-	flag.BoolVar(&config.JSONOutput, "json", false, "Output in JSON format")
-	flag.BoolVar(&config.SaveAll, "all", false, "Save all domain results (not just matches)")
-	flag.IntVar(&config.RateLimit, "r", 100, "Rate limit in milliseconds between requests")
+	registerFlags(flag.CommandLine, &config)
 
 	flag.Usage = func() {
 		fmt.Printf("%sTLD Scanner - Domain Enumeration Tool%s\n\n", ColorCyan, ColorReset)
-		fmt.Printf("Usage: %s [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Usage: %s [OPTIONS]\n", os.Args[0])
+		fmt.Printf("       %s auth      Manage integration API keys and serve mode tokens (set, delete, token, revoke, list)\n", os.Args[0])
+		fmt.Printf("       %s brand     Run a brand-protection sweep from a YAML profile\n", os.Args[0])
+		fmt.Printf("       %s completion Print a bash, zsh, fish or PowerShell completion script\n", os.Args[0])
+		fmt.Printf("       %s dropwatch Watch expiring matches and lookalikes of a JSON result for their drop\n", os.Args[0])
+		fmt.Printf("       %s evidence  Package the recorded and live evidence on a domain into a dated ZIP\n", os.Args[0])
+		fmt.Printf("       %s history   Show the recorded WHOIS timeline of a domain\n", os.Args[0])
+		fmt.Printf("       %s import    Look up and merge the domains found by amass, subfinder or dnstwist\n", os.Args[0])
+		fmt.Printf("       %s profile   Save, list, show or delete named sets of scan options\n", os.Args[0])
+		fmt.Printf("       %s retry     Re-scan the failed domains of a JSON result\n", os.Args[0])
+		fmt.Printf("       %s scan      Run a scan, optionally from a saved profile (-profile <name>)\n", os.Args[0])
+		fmt.Printf("       %s serve     Serve an HTTP API to run scans and stream results\n", os.Args[0])
+		fmt.Printf("       %s service   Install or uninstall monitor mode as a systemd, launchd or Windows service\n", os.Args[0])
+		fmt.Printf("       %s schema    Print the JSON Schema of the JSON output\n", os.Args[0])
+		fmt.Printf("       %s search    Search the recorded WHOIS history by field, e.g. registrant email\n", os.Args[0])
+		fmt.Printf("       %s summary   Write an executive brief of one or more JSON results\n", os.Args[0])
+		fmt.Printf("       %s tag       Tag or annotate a domain for later scans and reports\n", os.Args[0])
+		fmt.Printf("       %s takedown  Write an evidence bundle and takedown letter for a lookalike of a JSON result\n", os.Args[0])
+		fmt.Printf("       %s update    Replace this binary with the latest verified release\n", os.Args[0])
+		fmt.Printf("       %s verify    Check a manifest written with -sign and the files it lists\n", os.Args[0])
+		fmt.Printf("       %s version   Print the version, commit, build date and TLD list snapshot\n", os.Args[0])
+		fmt.Printf("       %s wordlist  Update a wordlist from the IANA TLD list\n\n", os.Args[0])
 		fmt.Printf("Options:\n")
 		flag.PrintDefaults()
 		fmt.Printf("\nExample:\n")
 		fmt.Printf("  %s -d example.com -w wordlist.txt -o results.txt -t 20 -v\n", os.Args[0])
 		fmt.Printf("  %s -d example.com -json -o results.json -all\n", os.Args[0])
+		fmt.Printf("  %s -d example.com -format template -template report.tmpl\n", os.Args[0])
+		fmt.Printf("\nExit codes:\n")
+		fmt.Printf("  0 matches found, 1 usage/config error, 2 no matches, 3 error rate above -error-threshold or scan aborted by -max-error-rate/-max-errors\n")
 	}
 
-	flag.Parse()
+	// Report bad flags as a usage error instead of the flag package's exit 2,
+	// which is reserved for "no matches"
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(ExitMatches)
+		}
+		os.Exit(ExitUsage)
+	}
+	applyImpliedFlags(&config)
 	return config
 }
 
+// registerFlags defines the scan options on fs. The brand subcommand shares
+// them with the main scan.
+func registerFlags(fs *flag.FlagSet, config *Config) {
+	fs.Var(targetFlag{config}, "d", "Target domain to analyze (required); repeat for the other domains of a group registered by subsidiaries")
+	fs.StringVar(&config.Portfolio, "portfolio", "", "Scan every brand of a CSV (domain, org aliases, tags) in one run instead of -d")
+	fs.StringVar(&config.Wordlist, "w", defaultWordlist, "Path to TLD wordlist file, - for stdin, or builtin:all|popular|cctld|newgtld")
+	fs.StringVar(&config.Countries, "countries", "", "Scan only the ccTLDs and regional gTLDs of these countries instead of a wordlist, e.g. de,fr,nl,EU")
+	fs.StringVar(&config.DomainsFile, "domains-file", "", "Scan the domains listed in this file (- for stdin) instead of generating them from the wordlist; a .csv file with a header row may add metadata columns")
+	fs.StringVar(&config.Output, "o", "", "Output file path (optional)")
+	fs.StringVar(&config.ErrorsFile, "errors-file", "", "Append each failed domain and its error code to this file as the scan runs")
+	fs.BoolVar(&config.Prioritize, "prioritize", false, "Scan high-value TLDs (.com, .net, .org, major ccTLDs) first")
+	fs.IntVar(&config.Threads, "t", 10, "Number of concurrent threads")
+	fs.IntVar(&config.Threads, "whois-threads", 10, "Number of concurrent WHOIS/RDAP lookups (same as -t)")
+	fs.IntVar(&config.DNSThreads, "dns-threads", 100, "Number of concurrent DNS lookups of the -dns-precheck stage")
+	fs.IntVar(&config.EnrichThreads, "enrich-threads", 0, "Number of matches enriched concurrently (default -t)")
+	fs.IntVar(&config.ScoreThreads, "score-threads", 0, "Number of lookalikes probed and scored concurrently (default -t)")
+	fs.IntVar(&config.QueueSize, "queue-size", 1000, "Capacity of the queues between scan stages")
+	fs.BoolVar(&config.DNSPrecheck, "dns-precheck", false, "Skip the WHOIS lookup of domains that do not exist in DNS (NXDOMAIN)")
+	fs.StringVar(&config.Checkpoint, "checkpoint", "", "Record finished domains in this file and resume an interrupted scan from it")
+	fs.IntVar(&config.ChunkSize, "chunk-size", 10000, "Number of candidates per -checkpoint chunk; an interrupted chunk is scanned again on resume")
+	fs.IntVar(&config.Timeout, "timeout", 30, "WHOIS timeout in seconds")
+	fs.BoolVar(&config.RDAPFallback, "rdap-fallback", true, "Retry over RDAP when a WHOIS query or parse fails")
+	fs.BoolVar(&config.RaceRDAP, "race-rdap", false, "Query WHOIS and RDAP concurrently and keep the first successful answer")
+	fs.Var(&config.SourceIPs, "source-ip", "Local IPv4/IPv6 address to send WHOIS queries from (repeatable, rotated)")
+	fs.BoolVar(&config.Verbose, "v", false, "Verbose output")
+	fs.BoolVar(&config.Compress, "compress", false, "Gzip output files, adding a .gz extension")
+	fs.Var(&config.Encrypt, "encrypt", "Encrypt output files to this age recipient (age1..., repeatable), adding a .age extension")
+	fs.StringVar(&config.Sign, "sign", "", "Sign output files with this PEM private key, writing a SHA-256 manifest and detached signature")
+	fs.BoolVar(&config.JSONOutput, "json", false, "Output in JSON format (same as -format json)")
+	fs.StringVar(&config.Format, "format", "text", "Output format: text, json, csv, html, ics, cef, leef, template, grep, list, list-all, graphml, dot, maltego, feed, register")
+	fs.StringVar(&config.OutputAll, "oA", "", "Write json, csv, txt and html results using this base name")
+	fs.StringVar(&config.OutputDir, "out-dir", "", "Write each scan's json, csv, txt and html results to <dir>/<target>/<timestamp>/ and link <dir>/<target>/latest to the newest")
+	fs.StringVar(&config.Template, "template", "", "Go text/template file used with -format template")
+	fs.StringVar(&config.ICSReminders, "ics-reminders", defaultICSReminders, "Comma-separated reminder lead times before each expiry for -format ics, e.g. 30d,7d,1d")
+	fs.StringVar(&config.FeedFields, "feed-fields", "", "Comma-separated extra fields to share with -format feed: "+strings.Join(feedFields, ", "))
+	fs.StringVar(&config.Filter, "filter", "", "Only output domains matching an expression, e.g. 'registrar contains \"GoDaddy\" && created_after \"2024-01-01\"'")
+	fs.StringVar(&config.Sort, "sort", "", "Order output domains by created, expiry, risk, tld or org, optionally with :asc or :desc (e.g. created:desc)")
+	fs.BoolVar(&config.NoColor, "no-color", false, "Disable colored output (also honors NO_COLOR)")
+	fs.BoolVar(&config.ProgressJSON, "progress-json", false, "Write JSON progress events (done, total, matches, errors, eta_s) to stderr every second")
+	fs.Float64Var(&config.ErrorThreshold, "error-threshold", 0.5, "Rate (0-1) of failed lookups, not counting unregistered domains, above which the scan exits with code 3")
+	fs.BoolVar(&config.Monitor, "monitor", false, "Rescan repeatedly and alert on new, removed and changed matches (implies -history)")
+	fs.BoolVar(&config.Rebaseline, "rebaseline", false, "In monitor mode, accept the target's current registrar, name servers and status as its new baseline")
+	fs.DurationVar(&config.Interval, "interval", 24*time.Hour, "Time between scans in monitor mode")
+	fs.StringVar(&config.Schedule, "schedule", "", "Cron expression for monitor mode scans, e.g. \"0 3 * * *\" (implies -monitor, overrides -interval)")
+	fs.BoolVar(&config.Watchlist, "watchlist", false, "In monitor mode, add TLDs newly delegated in the ICANN new gTLD feed to the scan and alert when the brand is registered under them")
+	fs.StringVar(&config.WatchlistURL, "watchlist-url", newGTLDFeedURL, "URL of the new gTLD delegation feed (CSV) for -watchlist")
+	fs.BoolVar(&config.OnlyNewTLDs, "only-new-tlds", false, "In monitor mode, scan only the TLDs added to the wordlist or watchlist since the last cycle")
+	fs.IntVar(&config.Slices, "slices", 0, "In monitor mode, split the TLDs in this many slices and scan one per cycle, e.g. 24 with -interval 1h for daily coverage")
+	fs.StringVar(&config.HealthListen, "health-listen", "", "Address to serve /healthz, /readyz and /status on in monitor mode, e.g. :8081")
+	fs.StringVar(&config.SMTPServer, "smtp-server", "", "SMTP relay (host:port) for emailing a summary after each scan")
+	fs.StringVar(&config.SMTPUser, "smtp-user", "", "SMTP username; the password is read from the smtp credential")
+	fs.StringVar(&config.MailTo, "mail-to", "", "Comma-separated notification email recipients")
+	fs.StringVar(&config.MailFrom, "mail-from", "", "Notification sender address (default tldscanner@<hostname>)")
+	fs.StringVar(&config.TeamsWebhook, "teams-webhook", "", "Microsoft Teams webhook URL for match and scan summary cards")
+	fs.StringVar(&config.TelegramToken, "telegram-token", "", "Telegram bot token for match alerts")
+	fs.StringVar(&config.TelegramChat, "telegram-chat", "", "Telegram chat ID or @channel to send match alerts to")
+	fs.BoolVar(&config.PagerDuty, "pagerduty", false, "Page through PagerDuty for newly registered live lookalikes")
+	fs.BoolVar(&config.Opsgenie, "opsgenie", false, "Create Opsgenie alerts for newly registered live lookalikes")
+	fs.StringVar(&config.OpsgenieRegion, "opsgenie-region", "us", "Opsgenie API region: us or eu")
+	fs.IntVar(&config.PageMinRisk, "page-min-risk", 70, "Risk score (0-100) at which new live lookalikes page; 0 to page matches only")
+	fs.Float64Var(&config.PageMinSignal, "page-min-signal", 0.8, "Signal score (0-1) at which new live signal domains page; 0 to page matches only")
+	fs.StringVar(&config.JiraProject, "jira", "", "Jira project key to open a ticket in per new high-risk finding, e.g. SEC")
+	fs.StringVar(&config.JiraURL, "jira-url", "", "Base URL of the Jira site for -jira, e.g. https://example.atlassian.net")
+	fs.StringVar(&config.JiraIssueType, "jira-issue-type", "Task", "Issue type of the tickets -jira opens")
+	fs.StringVar(&config.GitHubRepo, "github-issues", "", "GitHub repository (owner/repo) to open an issue in per new high-risk finding")
+	fs.StringVar(&config.IssueLabels, "issue-labels", "", "Comma-separated labels added to opened tickets and issues besides \"tldscanner\"")
+	fs.StringVar(&config.SyslogAddr, "syslog", "", "Stream findings and scan summaries to syslog: local or udp://, tcp:// or tls://host[:port]")
+	fs.StringVar(&config.SyslogFormat, "syslog-format", "cef", "Event format of -syslog messages: cef or leef")
+	fs.StringVar(&config.Neo4j, "neo4j", "", "Upsert the domains, organizations, registrars, name servers and IPs of each scan into Neo4j: bolt://[user@]host[:port] or bolt+s://; the password is read from the neo4j credential")
+	fs.StringVar(&config.Neo4jDB, "neo4j-db", "", "Neo4j database for -neo4j (default the server's default database)")
+	fs.BoolVar(&config.History, "history", false, "Record every scanned domain's WHOIS state in the history database")
+	fs.StringVar(&config.HistoryDB, "history-db", defaultHistoryPath(), "Path to the history database")
+	fs.BoolVar(&config.SaveAll, "all", false, "Save all domain results (not just matches)")
+	fs.BoolVar(&config.Risk, "risk", false, "Probe and risk-score every registered candidate not owned by the target")
+	fs.BoolVar(&config.SuggestRegister, "suggest-registrations", false, "Suggest the unregistered variants under popular TLDs and the unregistered homoglyphs for defensive registration")
+	fs.StringVar(&config.Keywords, "keywords", "", "Comma-separated brand keywords looked for on lookalike pages besides the target's name (used with -risk)")
+	fs.IntVar(&config.RateLimit, "r", 100, "Rate limit in milliseconds between requests")
+	fs.BoolVar(&config.Shuffle, "shuffle", false, "Scan TLDs in random order instead of wordlist order")
+	fs.StringVar(&config.Jitter, "jitter", "", "Random delay added after each rate limit token, e.g. 50-250ms")
+	fs.IntVar(&config.Burst, "burst", 1, "Maximum burst of requests allowed by the rate limiter")
+	fs.BoolVar(&config.AutoTune, "auto-tune", false, "Adapt concurrency to observed error and rate-limit rates (ignores -t)")
+	fs.IntVar(&config.AutoTuneMax, "auto-tune-max", 50, "Maximum concurrency -auto-tune may reach")
+	fs.IntVar(&config.MaxQueries, "max-queries", 0, "Stop sending queries after this many and report the domains not looked up (0 for no limit)")
+	fs.IntVar(&config.MaxServerQueries, "max-queries-per-server", 0, "Maximum queries sent to each registry's WHOIS/RDAP servers and to each registrar server (0 for no limit)")
+	fs.DurationVar(&config.MaxRuntime, "max-runtime", 0, "Stop dispatching lookups after this long, e.g. 2h, and report partial results marked truncated (0 for no limit)")
+	fs.Float64Var(&config.MaxErrorRate, "max-error-rate", 0, "Abort the scan when more than this rate (0-1) of the last 50 lookups failed, e.g. 0.5 (0 for no limit)")
+	fs.IntVar(&config.MaxErrors, "max-errors", 0, "Abort the scan after this many failed lookups (0 for no limit)")
+	fs.DurationVar(&config.FlushEvery, "flush-every", 0, "Rewrite the output files with the results so far this often during the scan, e.g. 5m, marked partial (0 to write them once at the end)")
+	fs.StringVar(&config.Cache, "cache", "", "Cache lookups in memory or in Redis shared by several instances (memory or redis://[:password@]host:port/db)")
+	fs.DurationVar(&config.CacheTTL, "cache-ttl", 24*time.Hour, "How long cached lookups are reused")
+	fs.StringVar(&config.RateScope, "rate-scope", "global", "Rate limit scope: global or tld (one bucket per TLD/registry)")
+	fs.BoolVar(&config.NoRegistryPolicy, "ignore-registry-policy", false, "Do not slow queries down to the bundled per-registry limits of registries known to ban aggressive clients")
+	fs.BoolVar(&config.RegistrarPivot, "registrar-pivot", false, "Score candidates sharing the target's registrar and a close creation date")
+	fs.IntVar(&config.PivotWindow, "pivot-window", 180, "Maximum creation date distance in days for registrar pivot")
+	fs.StringVar(&config.ConfigFile, "config", defaultConfigPath(), "Path to the configuration file holding integration credentials")
+	fs.BoolVar(&config.SecurityTrails, "securitytrails", false, "Enrich matches with SecurityTrails WHOIS history and DNS")
+	fs.BoolVar(&config.VirusTotal, "virustotal", false, "Check matches against VirusTotal and rank known-malicious ones first")
+	fs.StringVar(&config.Exposure, "exposure", "", "Look up exposed ports, banners and certificates of matches: shodan or censys")
+	fs.StringVar(&config.PassiveDNS, "passive-dns", "", "Look up historical resolutions of matches: circl, farsight or securitytrails")
+	fs.BoolVar(&config.URLScan, "urlscan", false, "Submit live matches to urlscan.io and link the scan results")
+	fs.StringVar(&config.URLScanVisibility, "urlscan-visibility", "unlisted", "urlscan.io scan visibility: public, unlisted or private")
+	fs.StringVar(&config.Enrich, "enrich", "", "Comma-separated custom enrichers to run on matches, compiled in or defined under enrichers in config.yaml")
+	fs.StringVar(&config.Script, "script", "", "Starlark script whose match(domain, target) function decides matches, scores and tags")
+	fs.BoolVar(&config.ExactOrg, "exact-org", false, "Compare organizations case-insensitively only, without normalizing legal suffixes, punctuation and diacritics")
+	fs.BoolVar(&config.Transliterate, "transliterate", false, "Also compare Cyrillic and Greek organizations by their Latin transliteration")
+	fs.BoolVar(&config.EmailMatch, "email-match", false, "Match candidates whose contact email domain belongs to the target")
+	fs.BoolVar(&config.NSMatch, "ns-match", false, "Match candidates served by the target's own name servers")
+	fs.StringVar(&config.MailDomains, "mail-domains", "", "Comma-separated additional mail domains of the target (used with -email-match)")
+	fs.StringVar(&config.Org, "org", "", "Organization to match when the target's WHOIS record has none, redacts it or cannot be looked up")
+	fs.BoolVar(&config.SoftFailTarget, "continue-on-target-error", false, "Keep scanning when the target's WHOIS lookup fails, matching by -org or the target's DNS name servers")
+	fs.StringVar(&config.FlagCountries, "flag-countries", "", "Comma-separated registrant countries to flag lookalikes from, e.g. ru,kp,ir")
+	fs.StringVar(&config.IgnoreFile, "ignore-file", "", "File of domains and org:<pattern> organizations never reported as matches, signals or lookalikes")
+	fs.StringVar(&config.KnownDomains, "known-domains", "", "File listing the organization's official domains; matches missing from it are reported as shadow registrations")
+	fs.StringVar(&config.RegistrarAccounts, "registrar-accounts", "", "Comma-separated registrar accounts whose domains are known owned: cloudflare, namecheap, markmonitor")
+	fs.StringVar(&config.Pprof, "pprof", "", "Serve net/http/pprof on this address for debugging, e.g. localhost:6060")
+	fs.DurationVar(&config.PprofSnapshot, "pprof-snapshot", 0, "Write goroutine and heap snapshots to -pprof-dir at this interval, e.g. 1m (0 for none)")
+	fs.StringVar(&config.PprofDir, "pprof-dir", "pprof", "Directory for -pprof-snapshot files")
+	fs.StringVar(&config.OTLPEndpoint, "otlp-endpoint", "", "Export scan traces and metrics over OTLP/HTTP to this collector URL, e.g. http://localhost:4318 (default $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.BoolVar(&config.Tor, "tor", false, "Route all lookups, probes and DNS queries through Tor so the scan does not reveal your IP address")
+	fs.StringVar(&config.TorAddr, "tor-addr", "127.0.0.1:9050", "Tor SOCKS proxy address for -tor")
+	fs.IntVar(&config.TorIsolate, "tor-isolate", 10, "With -tor, switch to a new Tor circuit every N connections (0 for one circuit)")
+	fs.StringVar(&config.CABundle, "ca-bundle", "", "PEM file of extra certificate authorities to trust for HTTPS, e.g. a TLS-inspecting proxy's (default $TLDSCANNER_CA_BUNDLE)")
+	fs.BoolVar(&config.DNSSEC, "dnssec", false, "Record the DNSSEC status (signed, unsigned, broken) of the target, matches and lookalikes")
+	fs.StringVar(&config.DNSSECResolver, "dnssec-resolver", "1.1.1.1:53", "Validating DNS resolver queried over TCP for -dnssec and -caa")
+	fs.BoolVar(&config.CAA, "caa", false, "Record the CAA records and certificate issuance risk of matches and lookalikes")
+	fs.BoolVar(&config.SMTPProbe, "smtp-probe", false, "Read the SMTP greeting of the mail exchangers of matches and lookalikes to flag those able to receive mail (no mail is sent)")
+	fs.BoolVar(&config.Debug, "debug", false, "Log a transcript of every lookup (servers, connect times, bytes, parse and fallback decisions) to stderr, tagged with its trace ID")
+}
+
+// applyImpliedFlags sets the options implied by other flags
+func applyImpliedFlags(config *Config) {
+	if config.JSONOutput {
+		config.Format = "json"
+	}
+	if config.Schedule != "" {
+		config.Monitor = true
+	}
+}
+
 func printBanner() {
 	banner := `
 ████████╗██╗     ██████╗     ███████╗ ██████╗ █████╗ ███╗   ██╗███╗   ██╗███████╗██████╗ 
@@ -179,58 +1019,120 @@ func printBanner() {
 	fmt.Printf("%s                    github.com/vijay922/tldscanner%s\n\n", ColorPurple, ColorReset)
 }
 
-func getWhoisInfo(domain string, timeout int) (*DomainInfo, error) {
-	whoisRaw, err := whois.Whois(domain)
+// getWhoisInfo looks up a domain over WHOIS, falling back to RDAP when the
+// WHOIS query or parse fails for a registered domain. Thin registry records
+// are completed from the sponsoring registrar.
+func getWhoisInfo(domain string, config Config) (*DomainInfo, error) {
+	info, err := lookupWhois(domain, config)
+	if err == nil || !config.RDAPFallback || errors.Is(err, whoisparser.ErrNotFoundDomain) {
+		if err != nil {
+			config.Trace.logf("no RDAP fallback: %v", err)
+			return info, err
+		}
+		completeThinRecord(info, config)
+		return info, nil
+	}
+
+	config.Trace.logf("falling back to RDAP after: %v", err)
+	rdapInfo, rdapErr := getRDAPInfo(domain, config)
+	if rdapErr != nil {
+		return nil, fmt.Errorf("%w; rdap fallback: %v", err, rdapErr)
+	}
+	completeThinRecord(rdapInfo, config)
+	return rdapInfo, nil
+}
+
+func lookupWhois(domain string, config Config) (*DomainInfo, error) {
+	whoisRaw, server, err := queryWhois(newWhoisClient(config), domain, config.WhoisQueries.query(domain), config.Budget, config.Trace)
 	if err != nil {
 		return nil, fmt.Errorf("whois query failed: %w", err)
 	}
+	return parseWhoisResponse(domain, whoisRaw, server, config)
+}
 
-	result, err := whoisparser.Parse(whoisRaw)
-	if err != nil {
-		return nil, fmt.Errorf("whois parsing failed: %w", err)
+// parseWhoisResponse turns the raw WHOIS answer of a server into DomainInfo:
+// disclaimers are stripped and local labels translated before parsing, and
+// formats the parser does not know fall back to the raw text patterns
+func parseWhoisResponse(domain, whoisRaw, server string, config Config) (*DomainInfo, error) {
+	if stripped := stripDisclaimers(whoisRaw, config.WhoisDisclaimers); len(stripped) < len(whoisRaw) {
+		config.Trace.logf("stripped %d bytes of disclaimers", len(whoisRaw)-len(stripped))
+		whoisRaw = stripped
+	}
+	if translated, n := config.WhoisLabels.translate(domain, whoisRaw); n > 0 {
+		config.Trace.logf("translated %d field labels", n)
+		whoisRaw = translated
 	}
 
-	var nameServers []string
-	for _, ns := range result.Domain.NameServers {
-		nameServers = append(nameServers, ns)
+	result, err := whoisparser.Parse(whoisRaw)
+	if errors.Is(err, whoisparser.ErrDomainDataInvalid) {
+		// A format whoisparser does not know: extract what the raw text
+		// patterns can find
+		if info, ok := extractWhoisFields(domain, whoisRaw, config.WhoisPatterns); ok {
+			config.Trace.logf("parser did not recognize the format; extracted fields with patterns")
+			info.WhoisServer = server
+			info.Source = "whois_regex"
+			info.AbuseEmail, info.AbusePhone = parseAbuseContact(whoisRaw)
+			info.orgHints = organizationHints(result, whoisRaw)
+			return info, nil
+		}
 	}
+	if err != nil {
+		config.Trace.logf("parse failed: %v", err)
+		return nil, fmt.Errorf("whois parsing failed (server %s): %w", server, err)
+	}
+	config.Trace.logf("parsed %d bytes from %s", len(whoisRaw), server)
 
-	return &DomainInfo{
-		Domain:       domain,
-		Organization: result.Registrant.Organization,
-		Registrar:    result.Registrar.Name,
-		CreatedDate:  result.Domain.CreatedDate,
-		ExpiryDate:   result.Domain.ExpirationDate,
-		Status:       strings.Join(result.Domain.Status, ", "),
-		NameServers:  nameServers,
-		Timestamp:    time.Now(),
-	}, nil
+	info := domainInfoFromWhois(domain, result)
+	info.WhoisServer = server
+	info.Source = "whois"
+	info.AbuseEmail, info.AbusePhone = parseAbuseContact(whoisRaw)
+	info.orgHints = organizationHints(result, whoisRaw)
+	return info, nil
 }
 
-func loadWordlist(filename string) ([]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open wordlist file: %w", err)
+// domainInfoFromWhois maps a parsed WHOIS record onto DomainInfo, tolerating
+// records where the parser could not find a section
+func domainInfoFromWhois(domain string, result whoisparser.WhoisInfo) *DomainInfo {
+	info := &DomainInfo{
+		Domain:    domain,
+		Timestamp: time.Now(),
 	}
-	defer file.Close()
 
-	var tlds []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		tld := strings.TrimSpace(scanner.Text())
-		if tld != "" && !strings.HasPrefix(tld, "#") {
-			if !strings.HasPrefix(tld, ".") {
-				tld = "." + tld
-			}
-			tlds = append(tlds, tld)
-		}
+	if result.Domain != nil {
+		info.CreatedDate = result.Domain.CreatedDate
+		info.ExpiryDate = result.Domain.ExpirationDate
+		info.Status = strings.Join(result.Domain.Status, ", ")
+		info.NameServers = append(info.NameServers, result.Domain.NameServers...)
+	}
+	if result.Registrar != nil {
+		info.Registrar = result.Registrar.Name
+	}
+	if result.Registrant != nil {
+		info.Organization = result.Registrant.Organization
+		info.Country = normalizeCountry(result.Registrant.Country)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading wordlist: %w", err)
+	// Contact emails in registrant, admin, tech order
+	for _, contact := range []*whoisparser.Contact{result.Registrant, result.Administrative, result.Technical} {
+		if contact == nil || contact.Email == "" {
+			continue
+		}
+		email := strings.ToLower(strings.TrimSpace(contact.Email))
+		if !containsString(info.Emails, email) {
+			info.Emails = append(info.Emails, email)
+		}
 	}
 
-	return tlds, nil
+	return info
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
 }
 
 func extractBaseDomain(domain string) string {
@@ -242,96 +1144,377 @@ func extractBaseDomain(domain string) string {
 }
 
 func generateDomains(baseDomain string, tlds []string) []string {
-	var domains []string
-	for _, tld := range tlds {
-		domains = append(domains, baseDomain+tld)
-	}
-	return domains
+	return productCandidates([]string{baseDomain}, tlds).slice()
+}
+
+// scanDomains looks up every domain and returns all results, the matches,
+// the signal domains and the domains skipped once the query budget or
+// -max-runtime ran out. At the deadline no new lookups are dispatched and
+// the in-flight ones are drained.
+func scanDomains(domains []string, target *DomainInfo, config Config) ([]DomainInfo, []DomainInfo, []DomainInfo, scanSkips) {
+	return scanCandidates(sliceCandidates(domains), target, nil, config)
 }
 
-func scanDomains(domains []string, targetOrg string, config Config) ([]DomainInfo, []DomainInfo) {
+// scanCandidates scans a candidate stream like scanDomains. The domains
+// flow through the DNS precheck, WHOIS and enrichment stages (see
+// runStage), and are recorded in the checkpoint when one is given.
+func scanCandidates(candidates candidateSeq, target *DomainInfo, cp *checkpoint, config Config) ([]DomainInfo, []DomainInfo, []DomainInfo, scanSkips) {
 	var allResults []DomainInfo
 	var matchingResults []DomainInfo
-	var mu sync.Mutex
-	var wg sync.WaitGroup
+	var signalResults []DomainInfo
+
+	processed, failed := 0, 0
+	total := candidates.total
+	scanStart := time.Now()
+	progress := newProgressReporter(config.ProgressJSON, total, scanStart)
+	var console *liveConsole
+	if config.liveOutput() {
+		console = newLiveConsole(os.Stdout, !config.Verbose, total, scanStart)
+	}
+
+	// Limit concurrency, adaptively when auto-tuning
+	var workers *concurrencyLimiter
+	if config.AutoTune {
+		workers = newConcurrencyLimiter(config.AutoTuneMax, true)
+		if config.Verbose {
+			workers.onAdjust = func(from, to int) {
+				console.printf("%s[TUNE]%s Concurrency %d -> %d\n", ColorCyan, ColorReset, from, to)
+			}
+		}
+	} else {
+		workers = newConcurrencyLimiter(config.Threads, false)
+	}
 
-	// Create a channel to limit concurrency
-	semaphore := make(chan struct{}, config.Threads)
-	
 	// Rate limiting
-	rateLimiter := time.NewTicker(time.Duration(config.RateLimit) * time.Millisecond)
-	defer rateLimiter.Stop()
-
-	processed := 0
-	total := len(domains)
-
-	for _, domain := range domains {
-		wg.Add(1)
-		
-		go func(d string) {
-			defer wg.Done()
-			
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-			
-			// Rate limiting
-			<-rateLimiter.C
-			
-			info, err := getWhoisInfo(d, config.Timeout)
-			if err != nil {
-				info = &DomainInfo{
-					Domain:    d,
-					Error:     err.Error(),
-					Timestamp: time.Now(),
-				}
+	limiter := newRateLimiter(config.RateLimit, config.Burst, config.RateScope)
+	limiter.jitter, _ = parseJitter(config.Jitter)
+	limiter.policies = !config.NoRegistryPolicy
+	if limiter.policies && config.Verbose && config.liveOutput() {
+		for _, line := range describeRegistryPolicies(candidates) {
+			fmt.Printf("%s[INFO]%s Registry policy %s\n", ColorBlue, ColorReset, line)
+		}
+	}
+
+	budget := newQueryBudget(config.MaxQueries, config.MaxServerQueries)
+	config.Budget = budget
+	var skipped scanSkips
+	ctx := context.Background()
+	if config.MaxRuntime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.MaxRuntime)
+		defer cancel()
+	}
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	breaker := newErrorBreaker(config.MaxErrorRate, config.MaxErrors)
+
+	var mailDomains map[string]bool
+	if config.EmailMatch {
+		mailDomains = mailDomainSet(target.Domain, config.MailDomains)
+	}
+	flagged := flaggedCountries(config.FlagCountries)
+
+	failures, err := openErrorsLog(config.ErrorsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+	}
+	defer failures.Close()
+
+	lookup := getWhoisInfo
+	if config.RaceRDAP {
+		lookup = raceLookup
+	}
+	if config.LookupCache != nil {
+		lookup = cacheResults(config.LookupCache, lookup)
+		if redis, ok := config.LookupCache.(*redisCache); ok {
+			limiter.shared = redis.client
+		}
+	}
+
+	queue := config.QueueSize
+	timeout := time.Duration(config.Timeout) * time.Second
+	items := generateStage(candidates, budget, cp, queue)
+	if config.DNSPrecheck {
+		items = runStage(items, config.DNSThreads, queue, func(item *scanItem) {
+			started := time.Now()
+			if !existsInDNS(item.domain, timeout) {
+				item.dropped = dropNXDomain
+			}
+			config.Stats.add(statsDNSPrecheck, time.Since(started))
+		})
+	}
+
+	whoisWorkers := config.Threads
+	if config.AutoTune {
+		whoisWorkers = config.AutoTuneMax
+	}
+	items = runStage(items, whoisWorkers, queue, func(item *scanItem) {
+		d := item.domain
+		trace := newLookupTrace(d, config.Debug)
+		lookupConfig := config
+		lookupConfig.Trace = trace
+
+		// Acquire a worker slot
+		workers.acquire()
+
+		// Rate limiting; past -max-runtime the domain is skipped. Cached
+		// outcomes send no query and are not rate limited.
+		cached, hit := cachedLookup(config.LookupCache, d)
+		if !hit && (ctx.Err() != nil || limiter.Wait(ctx, d) != nil) {
+			workers.release("")
+			var open *breakerError
+			if errors.As(context.Cause(ctx), &open) {
+				trace.logf("skipped: %v", open)
+				item.dropped = dropBreaker
+			} else {
+				trace.logf("skipped: -max-runtime reached")
+				item.dropped = dropRuntime
+			}
+			return
+		}
+
+		trace.logf("lookup started after %s waiting for a worker and the rate limiter", time.Since(trace.start).Round(time.Millisecond))
+		config.Stats.add(statsWait, time.Since(trace.start))
+		started := time.Now()
+		var info *DomainInfo
+		var err error
+		if hit {
+			trace.logf("served from the lookup cache")
+			info, err = cached.lookup()
+		} else {
+			info, err = lookup(d, lookupConfig)
+		}
+		if errors.Is(err, errQueryBudget) {
+			// The budget ran out before the lookup got an answer
+			trace.logf("skipped: query budget exhausted")
+			workers.release("")
+			item.dropped = dropBudget
+			return
+		}
+		if err != nil {
+			info = &DomainInfo{
+				Domain:    d,
+				Error:     err.Error(),
+				ErrorCode: errorCodeOf(err),
+				Timestamp: time.Now(),
+			}
+		}
+		info.LookupMs = time.Since(started).Milliseconds()
+		info.TraceID = trace.id
+		config.Stats.add(statsWhois, time.Since(started))
+		if info.Error != "" {
+			trace.logf("failed in %s: %s (%s, retryable %t)", formatMs(info.LookupMs), info.Error, info.errorCode(), info.errorCode().Retryable())
+		} else {
+			trace.logf("answered in %s by %s via %s", formatMs(info.LookupMs), firstNonEmpty(info.WhoisServer, "cache"), firstNonEmpty(info.Source, "whois"))
+		}
+		workers.release(info.transientError())
+		info.UnicodeDomain = unicodeDomain(d)
+		normalizeDates(info)
+		info.Metadata = candidates.metadata[d]
+		if info.Error == "" {
+			classifyParking(info, parkingEvidence{})
+			classifyNameServers(info)
+			scoreCompleteness(info)
+		}
+
+		if config.OrgNormalizer != nil && config.OrgNormalizer.rules.Transliterate && hasTransliterableLetters(info.Organization) {
+			info.OrganizationLatin = transliterate(info.Organization)
+		}
+
+		matched := false
+		if reason := orgMatchReason(info.Organization, target.Organization, config); reason != "" {
+			matched = true
+			info.MatchReason = reason
+		} else if email, ok := emailDomainMatch(info, mailDomains); ok {
+			matched = true
+			info.MatchReason = "email_domain"
+			info.MatchedEmail = email
+		} else if config.NSMatch && nameServerMatch(target, info) {
+			matched = true
+			info.MatchReason = "name_servers"
+		}
+		if !matched && config.RegistrarPivot {
+			if signal, ok := registrarPivotSignal(target, info, config.PivotWindow); ok {
+				info.Signals = append(info.Signals, signal)
 			}
-			
-			mu.Lock()
-			allResults = append(allResults, *info)
+		}
+		if !matched {
+			if signal, ok := countrySignal(info, flagged); ok {
+				info.Signals = append(info.Signals, signal)
+			}
+		}
+		if config.MatchScript != nil {
+			matched = applyScript(config.MatchScript, info, target, matched)
+		}
+		if entry := config.IgnoreList.match(info); entry != "" {
+			matched = false
+			info.MatchReason, info.MatchedEmail, info.Signals = "", "", nil
+			info.Ignored = entry
+		}
+		if info.Error == "" {
+			assessEPPStatus(info, matched)
+		}
+		item.info, item.matched = info, matched
+	})
+	items = runStage(items, config.stageThreads(config.EnrichThreads), queue, func(item *scanItem) {
+		if item.matched {
+			started := time.Now()
+			enrichDomain(item.info, config)
+			config.Stats.add(statsEnrich, time.Since(started))
+		}
+	})
+
+	// Results are collected by a single goroutine in completion order,
+	// which leaves the terminal to the console
+	precheckDropped := 0
+	lastFlush := time.Now()
+	for item := range items {
+		if !item.restored {
+			skippedItem := item.dropped == dropBudget || item.dropped == dropRuntime || item.dropped == dropBreaker
+			if err := cp.finish(item.index, item.info, item.matched, skippedItem); err != nil {
+				fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to write checkpoint: %v\n", ColorYellow, ColorReset, err)
+			}
+		}
+		switch item.dropped {
+		case dropBudget, dropBreaker:
+			skipped.domains = append(skipped.domains, item.domain)
+			continue
+		case dropRuntime:
+			skipped.domains = append(skipped.domains, item.domain)
+			skipped.truncated = true
+			continue
+		case dropNXDomain:
+			if config.SuggestRegister {
+				skipped.nxdomains = append(skipped.nxdomains, item.domain)
+			}
+			precheckDropped++
 			processed++
-			
-			// Check if organization matches
-			if info.Organization != "" && strings.EqualFold(info.Organization, targetOrg) {
-				matchingResults = append(matchingResults, *info)
-				if !config.JSONOutput {
-					fmt.Printf("%s[+] MATCH:%s %s -> %s%s%s\n", 
-						ColorGreen, ColorReset, info.Domain, ColorYellow, info.Organization, ColorReset)
-				}
+			console.progress(processed, len(matchingResults))
+			progress.update(processed, len(matchingResults), failed)
+			continue
+		}
+		info, matched := item.info, item.matched
+
+		allResults = append(allResults, *info)
+		processed++
+		if config.OnDomain != nil {
+			config.OnDomain(*info, matched, processed, total)
+		}
+		if info.Error != "" {
+			failed++
+			if err := failures.record(*info); err != nil && config.Verbose {
+				fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to write errors file: %v\n", ColorYellow, ColorReset, err)
 			}
-			
-			if config.Verbose && !config.JSONOutput {
-				if info.Error != "" {
-					fmt.Printf("%s[!] ERROR:%s %s -> %s\n", ColorRed, ColorReset, info.Domain, info.Error)
-				} else if info.Organization != "" {
-					fmt.Printf("%s[-] CHECKED:%s %s -> %s\n", ColorWhite, ColorReset, info.Domain, info.Organization)
-				}
+		}
+		if !item.restored && skipped.aborted == "" {
+			if reason := breaker.record(*info); reason != "" {
+				skipped.aborted = reason
+				abort(&breakerError{reason: reason})
 			}
-			
-			// Progress indicator
-			if !config.JSONOutput && !config.Verbose {
-				fmt.Printf("\r%s[INFO]%s Progress: %d/%d domains scanned (%d matches)", 
-					ColorBlue, ColorReset, processed, total, len(matchingResults))
+		}
+
+		// Check if organization matches
+		if matched {
+			matchingResults = append(matchingResults, *info)
+			evidence := info.Organization
+			if info.MatchReason == "email_domain" {
+				evidence = info.MatchedEmail
+			} else if info.MatchReason == "name_servers" {
+				evidence = strings.Join(info.NameServers, ", ")
+			}
+			console.printf("%s[+] MATCH:%s %s -> %s%s%s\n",
+				ColorGreen, ColorReset, info.displayName(), ColorYellow, evidence, ColorReset)
+		} else if len(info.Signals) > 0 {
+			signalResults = append(signalResults, *info)
+			console.printf("%s[~] SIGNAL:%s %s -> %s (%s)\n",
+				ColorPurple, ColorReset, info.displayName(), info.Signals[0].Name, info.Signals[0].Detail)
+		}
+
+		if config.Verbose {
+			if info.Error != "" {
+				console.printf("%s[!] ERROR:%s %s -> %s\n", ColorRed, ColorReset, info.displayName(), info.Error)
+			} else if info.Organization != "" {
+				console.printf("%s[-] CHECKED:%s %s -> %s\n", ColorWhite, ColorReset, info.displayName(), info.Organization)
 			}
-			mu.Unlock()
-		}(domain)
+		}
+
+		console.progress(processed, len(matchingResults))
+		progress.update(processed, len(matchingResults), failed)
+
+		if matched {
+			config.Syslog.finding(Result{TargetDomain: target.Domain}, newSIEMFinding("match", *info))
+		} else if len(info.Signals) > 0 {
+			config.Syslog.finding(Result{TargetDomain: target.Domain}, newSIEMFinding("signal", *info))
+		}
+
+		if config.OnFlush != nil && time.Since(lastFlush) >= config.FlushEvery {
+			config.OnFlush(allResults, matchingResults, signalResults)
+			lastFlush = time.Now()
+		}
 	}
 
-	wg.Wait()
-	
-	if !config.JSONOutput && !config.Verbose {
-		fmt.Println() // New line after progress
+	progress.finish(processed, len(matchingResults), failed)
+	console.close()
+
+	if precheckDropped > 0 && config.liveOutput() {
+		fmt.Printf("%s[INFO]%s DNS precheck: %d domains do not exist and were not looked up\n", ColorBlue, ColorReset, precheckDropped)
+	}
+	if config.AutoTune && config.liveOutput() {
+		fmt.Printf("%s[INFO]%s Auto-tune settled at %d concurrent lookups\n", ColorBlue, ColorReset, workers.Limit())
 	}
+	if skipped.aborted != "" {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Scan aborted, %s: %d domains skipped, results are partial\n", ColorYellow, ColorReset, skipped.aborted, len(skipped.domains))
+	} else if skipped.truncated {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s -max-runtime reached: %d domains skipped, results are partial\n", ColorYellow, ColorReset, len(skipped.domains))
+	} else if len(skipped.domains) > 0 {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Query budget exhausted: %d domains skipped\n", ColorYellow, ColorReset, len(skipped.domains))
+	}
+	sort.Strings(skipped.domains)
+
+	sortResults(allResults, matchingResults, signalResults)
+	return allResults, matchingResults, signalResults, skipped
+}
 
+// sortResults orders scanned domains by name, matches by reputation and
+// signal domains by signal strength
+func sortResults(allResults, matchingResults, signalResults []DomainInfo) {
 	// Sort results by domain name
 	sort.Slice(allResults, func(i, j int) bool {
 		return allResults[i].Domain < allResults[j].Domain
 	})
+	// Known-malicious matches first when reputation data is available
 	sort.Slice(matchingResults, func(i, j int) bool {
+		a, b := matchingResults[i].VirusTotal.Detections(), matchingResults[j].VirusTotal.Detections()
+		if a != b {
+			return a > b
+		}
 		return matchingResults[i].Domain < matchingResults[j].Domain
 	})
+	// Strongest signals first
+	sort.Slice(signalResults, func(i, j int) bool {
+		return signalResults[i].Signals[0].Score > signalResults[j].Signals[0].Score
+	})
+}
 
-	return allResults, matchingResults
+// recordHistory stores the scan in the history database. Failures are
+// reported but do not fail the scan.
+func recordHistory(result Result, domains []DomainInfo, scannedAt time.Time, config Config) {
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s %v\n", ColorYellow, ColorReset, err)
+		return
+	}
+	defer store.Close()
+
+	changed, err := store.record(result, domains, scannedAt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to record history: %v\n", ColorYellow, ColorReset, err)
+		return
+	}
+	if config.liveOutput() {
+		fmt.Printf("%s[INFO]%s History recorded in %s (%d domains changed since their last snapshot)\n",
+			ColorBlue, ColorReset, config.HistoryDB, changed)
+	}
 }
 
 func countErrors(results []DomainInfo) int {
@@ -344,85 +1527,400 @@ func countErrors(results []DomainInfo) int {
 	return count
 }
 
-func outputJSON(result Result, outputFile string) {
-	data, err := json.MarshalIndent(result, "", "  ")
+func outputJSON(result Result, outputFile string, recipients []age.Recipient) {
+	data, err := renderJSON(result)
 	if err != nil {
 		log.Printf("Error marshaling JSON: %v", err)
 		return
 	}
 
+	saveOutput(data, outputFile, recipients)
+}
+
+func renderJSON(result Result) ([]byte, error) {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func outputText(result Result, outputFile string, verbose bool, recipients []age.Recipient) {
+	text := renderText(result, verbose)
 	if outputFile != "" {
-		err := os.WriteFile(outputFile, data, 0644)
-		if err != nil {
-			log.Printf("Error writing to file: %v", err)
-			return
-		}
-		fmt.Printf("%s[INFO]%s Results saved to %s\n", ColorBlue, ColorReset, outputFile)
-	} else {
-		fmt.Println(string(data))
+		text = stripANSI(text)
 	}
+	saveOutput([]byte(text), outputFile, recipients)
 }
 
-func outputText(result Result, outputFile string, verbose bool) {
+// renderText renders the human-readable report, with colors
+func renderText(result Result, verbose bool) string {
 	var output strings.Builder
-	
+
 	output.WriteString(fmt.Sprintf("\n%s=== TLD SCANNER RESULTS ===%s\n", ColorCyan, ColorReset))
 	output.WriteString(fmt.Sprintf("Target Domain: %s\n", result.TargetDomain))
 	output.WriteString(fmt.Sprintf("Target Organization: %s\n", result.TargetOrg))
+	if len(result.Metadata) > 0 {
+		output.WriteString(fmt.Sprintf("Target Metadata: %s\n", formatMetadata(result.Metadata, ", ")))
+	}
+	if result.TargetDNSSEC != "" {
+		output.WriteString(fmt.Sprintf("Target DNSSEC: %s\n", result.TargetDNSSEC))
+	}
+	if result.TargetUnlocked {
+		output.WriteString(fmt.Sprintf("%sTarget Transfer Lock: missing%s\n", ColorRed, ColorReset))
+	}
+	if result.TargetError != "" {
+		output.WriteString(fmt.Sprintf("%sTarget WHOIS: %s (degraded matching)%s\n", ColorYellow, result.TargetError, ColorReset))
+	}
+	for _, target := range result.GroupTargets {
+		output.WriteString(fmt.Sprintf("Group Target: %s (%s)\n", target.Domain, firstNonEmpty(target.Organization, "no organization")))
+	}
+	for _, change := range result.TargetDrift {
+		output.WriteString(fmt.Sprintf("%sTarget Drift: %s %q -> %q%s\n", ColorRed, change.Field, change.Old, change.New, ColorReset))
+	}
 	output.WriteString(fmt.Sprintf("Scan Duration: %s\n", result.ScanDuration))
 	output.WriteString(fmt.Sprintf("Total Scanned: %d\n", result.TotalScanned))
+	if result.TotalSkipped > 0 {
+		output.WriteString(fmt.Sprintf("Skipped: %d\n", result.TotalSkipped))
+	}
+	if len(result.SuppressedDomains) > 0 {
+		if verbose {
+			output.WriteString(fmt.Sprintf("Suppressed: %d (%s)\n", len(result.SuppressedDomains), strings.Join(result.SuppressedDomains, ", ")))
+		} else {
+			output.WriteString(fmt.Sprintf("Suppressed: %d\n", len(result.SuppressedDomains)))
+		}
+	}
+	if result.Truncated {
+		output.WriteString("Truncated: -max-runtime reached, results are partial\n")
+	}
+	if result.Aborted != "" {
+		output.WriteString(fmt.Sprintf("Aborted: %s, results are partial\n", result.Aborted))
+	}
+	if result.Partial {
+		output.WriteString("Partial: scan still running, written by -flush-every\n")
+	}
 	output.WriteString(fmt.Sprintf("Total Matches: %d\n", result.TotalMatches))
+	if result.TotalShadow > 0 {
+		output.WriteString(fmt.Sprintf("%sShadow Registrations: %d%s\n", ColorRed, result.TotalShadow, ColorReset))
+	}
 	output.WriteString(fmt.Sprintf("Total Errors: %d\n\n", result.TotalErrors))
 
 	if len(result.MatchingDomains) > 0 {
 		output.WriteString(fmt.Sprintf("%s=== MATCHING DOMAINS ===%s\n", ColorGreen, ColorReset))
 		for _, domain := range result.MatchingDomains {
-			output.WriteString(fmt.Sprintf("[+] %s\n", domain.Domain))
-			output.WriteString(fmt.Sprintf("    Organization: %s\n", domain.Organization))
+			output.WriteString(fmt.Sprintf("[+] %s\n", domain.displayName()))
+			if domain.OrganizationLatin != "" {
+				output.WriteString(fmt.Sprintf("    Organization: %s (%s)\n", domain.Organization, domain.OrganizationLatin))
+			} else {
+				output.WriteString(fmt.Sprintf("    Organization: %s\n", domain.Organization))
+			}
+			if domain.MatchedEmail != "" {
+				output.WriteString(fmt.Sprintf("    Matched Email: %s\n", domain.MatchedEmail))
+			}
+			switch domain.Ownership {
+			case ownershipKnown:
+				output.WriteString("    Ownership: known, listed in the known-domains inventory\n")
+			case ownershipShadow:
+				output.WriteString(fmt.Sprintf("    %sOwnership: shadow registration, missing from the known-domains inventory%s\n", ColorRed, ColorReset))
+			}
+			if len(domain.Tags) > 0 {
+				output.WriteString(fmt.Sprintf("    Tags: %s\n", strings.Join(domain.Tags, ", ")))
+			}
+			if domain.Note != "" {
+				output.WriteString(fmt.Sprintf("    Note: %s\n", domain.Note))
+			}
+			if len(domain.Metadata) > 0 {
+				output.WriteString(fmt.Sprintf("    Metadata: %s\n", formatMetadata(domain.Metadata, ", ")))
+			}
 			output.WriteString(fmt.Sprintf("    Registrar: %s\n", domain.Registrar))
+			if domain.Country != "" {
+				output.WriteString(fmt.Sprintf("    Country: %s\n", domain.Country))
+			}
 			output.WriteString(fmt.Sprintf("    Created: %s\n", domain.CreatedDate))
 			output.WriteString(fmt.Sprintf("    Expires: %s\n", domain.ExpiryDate))
+			if len(domain.EPPStatus) > 0 {
+				output.WriteString(fmt.Sprintf("    EPP Status: %s\n", strings.Join(domain.EPPStatus, ", ")))
+			}
+			if domain.TransferUnlocked {
+				output.WriteString(fmt.Sprintf("    %sTransfer Lock: missing, the domain can be transferred away%s\n", ColorRed, ColorReset))
+			}
 			if len(domain.NameServers) > 0 {
 				output.WriteString(fmt.Sprintf("    Name Servers: %s\n", strings.Join(domain.NameServers, ", ")))
 			}
+			if provider := domain.nsProviderLabel(); provider != "" {
+				output.WriteString(fmt.Sprintf("    DNS Provider: %s\n", provider))
+			}
+			if domain.Parked {
+				output.WriteString(fmt.Sprintf("    Parked: %s\n", strings.Join(domain.ParkingSignals, ", ")))
+			}
+			if domain.DNSSEC != "" {
+				output.WriteString(fmt.Sprintf("    DNSSEC: %s\n", domain.DNSSEC))
+			}
+			if domain.SMTP != nil {
+				output.WriteString(fmt.Sprintf("    Mail: %s\n", domain.mailReachability()))
+			}
+			if domain.CertIssuanceRisk != "" {
+				output.WriteString(fmt.Sprintf("    Certificate Issuance: %s\n", domain.certIssuance()))
+			}
+			if verbose && domain.WhoisServer != "" {
+				output.WriteString(fmt.Sprintf("    WHOIS Server: %s\n", domain.WhoisServer))
+			}
+			if domain.DNS != nil {
+				output.WriteString(fmt.Sprintf("    DNS: A=%s MX=%s NS=%s\n", strings.Join(domain.DNS.A, ","),
+					strings.Join(domain.DNS.MX, ","), strings.Join(domain.DNS.NS, ",")))
+			}
+			if len(domain.RegistrantHistory) > 0 {
+				output.WriteString("    Registrant History:\n")
+				for _, record := range domain.RegistrantHistory {
+					output.WriteString(fmt.Sprintf("      %s..%s %s (%s) via %s\n", record.Started, record.Ended,
+						firstNonEmpty(record.Organization, record.Name, "unknown"), record.Email, record.Registrar))
+				}
+			}
+			if len(domain.PassiveDNS) > 0 {
+				output.WriteString("    Passive DNS:\n")
+				for i, record := range domain.PassiveDNS {
+					if i == maxPassiveDNSShown && !verbose {
+						output.WriteString(fmt.Sprintf("      ... %d more (-v to show all)\n", len(domain.PassiveDNS)-i))
+						break
+					}
+					output.WriteString(fmt.Sprintf("      %s\n", record))
+				}
+			}
+			if vt := domain.VirusTotal; vt != nil {
+				color := ColorGreen
+				if vt.Detections() > 0 {
+					color = ColorRed
+				}
+				output.WriteString(fmt.Sprintf("    VirusTotal: %s%d malicious, %d suspicious%s (reputation %d)", color,
+					vt.Malicious, vt.Suspicious, ColorReset, vt.Reputation))
+				if len(vt.Categories) > 0 {
+					output.WriteString(fmt.Sprintf(" [%s]", strings.Join(vt.Categories, ", ")))
+				}
+				output.WriteString("\n")
+			}
+			if exposure := domain.Exposure; exposure != nil {
+				output.WriteString(fmt.Sprintf("    Exposure (%s): %s\n", exposure.Provider, strings.Join(exposure.IPs, ", ")))
+				for _, service := range exposure.Services {
+					output.WriteString(fmt.Sprintf("      %s\n", service))
+					if verbose && service.Banner != "" {
+						output.WriteString(fmt.Sprintf("        %s\n", service.Banner))
+					}
+				}
+			}
+			if scan := domain.URLScan; scan != nil {
+				output.WriteString(fmt.Sprintf("    urlscan.io: %s\n", scan.ResultURL))
+				output.WriteString(fmt.Sprintf("    Screenshot: %s\n", scan.ScreenshotURL))
+			}
+			for _, enrichErr := range domain.EnrichmentErrors {
+				output.WriteString(fmt.Sprintf("    Enrichment Error: %s\n", enrichErr))
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	if len(result.Lookalikes) > 0 {
+		output.WriteString(fmt.Sprintf("%s=== LOOKALIKES BY RISK ===%s\n", ColorRed, ColorReset))
+		for _, domain := range result.Lookalikes {
+			if domain.Technique != "" {
+				output.WriteString(fmt.Sprintf("[%3d] %s (%s)\n", domain.RiskScore, domain.displayName(), domain.Technique))
+			} else {
+				output.WriteString(fmt.Sprintf("[%3d] %s\n", domain.RiskScore, domain.displayName()))
+			}
+			output.WriteString(fmt.Sprintf("    Registrar: %s\n", domain.Registrar))
+			if domain.Country != "" {
+				output.WriteString(fmt.Sprintf("    Country: %s\n", domain.Country))
+			}
+			output.WriteString(fmt.Sprintf("    Created: %s\n", domain.CreatedDate))
+			if provider := domain.nsProviderLabel(); provider != "" {
+				output.WriteString(fmt.Sprintf("    DNS Provider: %s\n", provider))
+			}
+			if domain.Ownership == ownershipKnown {
+				output.WriteString("    Ownership: known, listed in the known-domains inventory\n")
+			}
+			if len(domain.Tags) > 0 {
+				output.WriteString(fmt.Sprintf("    Tags: %s\n", strings.Join(domain.Tags, ", ")))
+			}
+			if domain.Note != "" {
+				output.WriteString(fmt.Sprintf("    Note: %s\n", domain.Note))
+			}
+			if len(domain.Metadata) > 0 {
+				output.WriteString(fmt.Sprintf("    Metadata: %s\n", formatMetadata(domain.Metadata, ", ")))
+			}
+			if domain.Parked {
+				output.WriteString(fmt.Sprintf("    Parked: %s\n", strings.Join(domain.ParkingSignals, ", ")))
+			}
+			if domain.DropCatch {
+				output.WriteString(fmt.Sprintf("    %sDrop Catch: pendingDelete, the domain is about to be released%s\n", ColorYellow, ColorReset))
+			}
+			if abuse := domain.abuseContact(); abuse != "" {
+				output.WriteString(fmt.Sprintf("    Abuse Contact: %s\n", abuse))
+			}
+			if domain.DNSSEC != "" {
+				output.WriteString(fmt.Sprintf("    DNSSEC: %s\n", domain.DNSSEC))
+			}
+			if domain.SMTP != nil {
+				output.WriteString(fmt.Sprintf("    Mail: %s\n", domain.mailReachability()))
+			}
+			if domain.CertIssuanceRisk != "" {
+				output.WriteString(fmt.Sprintf("    Certificate Issuance: %s\n", domain.certIssuance()))
+			}
+			if domain.HTTP.Live() {
+				output.WriteString(fmt.Sprintf("    HTTP: %d %s %q\n", domain.HTTP.StatusCode, domain.HTTP.URL, domain.HTTP.Title))
+				if domain.Language != "" {
+					output.WriteString(fmt.Sprintf("    Language: %s\n", domain.Language))
+				}
+				if domain.FaviconMatch {
+					output.WriteString(fmt.Sprintf("    %sFavicon: identical to the target's (hash %d)%s\n", ColorRed, domain.HTTP.FaviconHash, ColorReset))
+				}
+				if domain.ContentSimilarity > 0 {
+					color := ColorReset
+					if domain.ContentSimilarity >= cloneSimilarity {
+						color = ColorRed
+					}
+					output.WriteString(fmt.Sprintf("    Content Similarity: %s%.0f%%%s\n", color, domain.ContentSimilarity*100, ColorReset))
+				}
+				if len(domain.HTTP.KeywordHits) > 0 {
+					output.WriteString(fmt.Sprintf("    Brand Keywords: %s\n", strings.Join(domain.HTTP.KeywordHits, ", ")))
+				}
+				if len(domain.Phishing) > 0 {
+					output.WriteString(fmt.Sprintf("    %sPhishing Indicators: %s%s\n", ColorRed, strings.Join(domain.Phishing, ", "), ColorReset))
+				}
+			}
+			if scan := domain.URLScan; scan != nil {
+				output.WriteString(fmt.Sprintf("    Screenshot: %s\n", scan.ScreenshotURL))
+			}
+			for _, factor := range domain.RiskFactors {
+				output.WriteString(fmt.Sprintf("    Risk: +%d %s (%s)\n", factor.Points, factor.Name, factor.Detail))
+			}
 			output.WriteString("\n")
 		}
 	}
 
+	if len(result.SignalDomains) > 0 {
+		output.WriteString(fmt.Sprintf("%s=== SCORED SIGNALS ===%s\n", ColorPurple, ColorReset))
+		for _, domain := range result.SignalDomains {
+			output.WriteString(fmt.Sprintf("[~] %s\n", domain.displayName()))
+			output.WriteString(fmt.Sprintf("    Registrar: %s\n", domain.Registrar))
+			if domain.Country != "" {
+				output.WriteString(fmt.Sprintf("    Country: %s\n", domain.Country))
+			}
+			output.WriteString(fmt.Sprintf("    Created: %s\n", domain.CreatedDate))
+			if provider := domain.nsProviderLabel(); provider != "" {
+				output.WriteString(fmt.Sprintf("    DNS Provider: %s\n", provider))
+			}
+			if domain.Ownership == ownershipKnown {
+				output.WriteString("    Ownership: known, listed in the known-domains inventory\n")
+			}
+			if len(domain.Tags) > 0 {
+				output.WriteString(fmt.Sprintf("    Tags: %s\n", strings.Join(domain.Tags, ", ")))
+			}
+			if domain.Note != "" {
+				output.WriteString(fmt.Sprintf("    Note: %s\n", domain.Note))
+			}
+			if len(domain.Metadata) > 0 {
+				output.WriteString(fmt.Sprintf("    Metadata: %s\n", formatMetadata(domain.Metadata, ", ")))
+			}
+			if domain.Parked {
+				output.WriteString(fmt.Sprintf("    Parked: %s\n", strings.Join(domain.ParkingSignals, ", ")))
+			}
+			if domain.DropCatch {
+				output.WriteString(fmt.Sprintf("    %sDrop Catch: pendingDelete, the domain is about to be released%s\n", ColorYellow, ColorReset))
+			}
+			if abuse := domain.abuseContact(); abuse != "" {
+				output.WriteString(fmt.Sprintf("    Abuse Contact: %s\n", abuse))
+			}
+			if domain.DNSSEC != "" {
+				output.WriteString(fmt.Sprintf("    DNSSEC: %s\n", domain.DNSSEC))
+			}
+			if domain.SMTP != nil {
+				output.WriteString(fmt.Sprintf("    Mail: %s\n", domain.mailReachability()))
+			}
+			if domain.CertIssuanceRisk != "" {
+				output.WriteString(fmt.Sprintf("    Certificate Issuance: %s\n", domain.certIssuance()))
+			}
+			for _, signal := range domain.Signals {
+				output.WriteString(fmt.Sprintf("    Signal: %s (score %.2f) %s\n", signal.Name, signal.Score, signal.Detail))
+			}
+			output.WriteString("\n")
+		}
+	}
+
+	if len(result.Organizations) > 0 {
+		output.WriteString(fmt.Sprintf("%s=== ORGANIZATIONS ===%s\n", ColorCyan, ColorReset))
+		for _, cluster := range result.Organizations {
+			switch {
+			case cluster.Target:
+				output.WriteString(fmt.Sprintf("%s%s%s (target)\n", ColorGreen, cluster, ColorReset))
+			case cluster.Privacy:
+				output.WriteString(fmt.Sprintf("%s%s%s\n", ColorYellow, cluster, ColorReset))
+			default:
+				output.WriteString(fmt.Sprintf("%s\n", cluster))
+			}
+			if verbose && len(cluster.Variants) > 0 {
+				output.WriteString(fmt.Sprintf("    Spellings: %s\n", strings.Join(cluster.Variants, "; ")))
+			}
+			if verbose {
+				output.WriteString(fmt.Sprintf("    Domains: %s\n", strings.Join(cluster.Domains, ", ")))
+			}
+		}
+		output.WriteString("\n")
+	}
+
 	if verbose && len(result.AllDomains) > 0 {
 		output.WriteString(fmt.Sprintf("%s=== ALL SCANNED DOMAINS ===%s\n", ColorYellow, ColorReset))
 		for _, domain := range result.AllDomains {
 			if domain.Error != "" {
-				output.WriteString(fmt.Sprintf("[!] %s -> ERROR: %s\n", domain.Domain, domain.Error))
+				output.WriteString(fmt.Sprintf("[!] %s -> ERROR: %s\n", domain.displayName(), domain.Error))
 			} else {
-				output.WriteString(fmt.Sprintf("[-] %s -> %s\n", domain.Domain, domain.Organization))
+				output.WriteString(fmt.Sprintf("[-] %s -> %s\n", domain.displayName(), domain.Organization))
 			}
 		}
 	}
 
-	if outputFile != "" {
-		err := os.WriteFile(outputFile, []byte(output.String()), 0644)
-		if err != nil {
-			log.Printf("Error writing to file: %v", err)
-			return
-		}
-		fmt.Printf("%s[INFO]%s Results saved to %s\n", ColorBlue, ColorReset, outputFile)
-	} else {
-		fmt.Print(output.String())
-	}
+	return output.String()
 }
 
 func printSummary(result Result) {
 	fmt.Printf("\n%s=== SCAN SUMMARY ===%s\n", ColorCyan, ColorReset)
 	fmt.Printf("Domains Scanned: %s%d%s\n", ColorWhite, result.TotalScanned, ColorReset)
 	fmt.Printf("Matches Found: %s%d%s\n", ColorGreen, result.TotalMatches, ColorReset)
+	if result.TotalShadow > 0 {
+		fmt.Printf("Shadow Registrations: %s%d%s\n", ColorRed, result.TotalShadow, ColorReset)
+	}
+	if result.TotalSignals > 0 {
+		fmt.Printf("Scored Signals: %s%d%s\n", ColorPurple, result.TotalSignals, ColorReset)
+	}
+	if result.TotalLookalikes > 0 {
+		fmt.Printf("Lookalikes: %s%d%s\n", ColorRed, result.TotalLookalikes, ColorReset)
+	}
+	if len(result.Registrations) > 0 {
+		fmt.Printf("Suggested Registrations: %s%d%s\n", ColorGreen, len(result.Registrations), ColorReset)
+	}
+	if len(result.Organizations) > 0 {
+		fmt.Printf("Holders: %s\n", holdersSummary(result.Organizations))
+	}
+	if result.TotalSkipped > 0 {
+		fmt.Printf("Skipped: %s%d%s\n", ColorYellow, result.TotalSkipped, ColorReset)
+	}
+	if len(result.SuppressedDomains) > 0 {
+		fmt.Printf("Suppressed: %d\n", len(result.SuppressedDomains))
+	}
+	if result.Truncated {
+		fmt.Printf("%sTruncated: -max-runtime reached, results are partial%s\n", ColorYellow, ColorReset)
+	}
+	if result.Aborted != "" {
+		fmt.Printf("%sAborted: %s, results are partial%s\n", ColorYellow, result.Aborted, ColorReset)
+	}
 	fmt.Printf("Errors: %s%d%s\n", ColorRed, result.TotalErrors, ColorReset)
+	if result.TotalErrors > 0 {
+		fmt.Printf("  By type: %s\n", formatCounts(result.ErrorsByType, 0))
+		fmt.Printf("  By TLD: %s\n", formatCounts(result.ErrorsByTLD, 10))
+	}
+	printQuality(result.WhoisQuality)
+	if len(result.APIUsage) > 0 {
+		fmt.Printf("API Usage: %s\n", formatAPIUsage(result.APIUsage))
+	}
 	fmt.Printf("Duration: %s%s%s\n", ColorYellow, result.ScanDuration, ColorReset)
-	fmt.Printf("Rate: %s%.2f domains/second%s\n", ColorPurple, 
-		float64(result.TotalScanned)/time.Since(time.Now().Add(-parseDuration(result.ScanDuration))).Seconds(), ColorReset)
-}
-
-func parseDuration(s string) time.Duration {
-	d, _ := time.ParseDuration(s)
-	return d
+	printTiming(result.Timing)
+	printStats(result.Stats)
 }
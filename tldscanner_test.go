@@ -3,9 +3,12 @@ package main
 import (
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	whoisparser "github.com/likexian/whois-parser"
 )
 
 func TestExtractBaseDomain(t *testing.T) {
@@ -282,7 +285,7 @@ func TestLargeWordlistPerformance(t *testing.T) {
 	var content strings.Builder
 	for i := 0; i < 1000; i++ {
 		content.WriteString("tld")
-		content.WriteString(string(rune(i)))
+		content.WriteString(strconv.Itoa(i))
 		content.WriteString("\n")
 	}
 
@@ -337,3 +340,80 @@ func TestConcurrentSafety(t *testing.T) {
 		<-done
 	}
 }
+
+func TestDomainInfoFromWhois(t *testing.T) {
+	record := whoisparser.WhoisInfo{
+		Domain:         &whoisparser.Domain{CreatedDate: "2020-01-01", Status: []string{"ok", "clientHold"}},
+		Registrant:     &whoisparser.Contact{Organization: "Example Corp", Email: "Admin@Example.com"},
+		Administrative: &whoisparser.Contact{Email: "admin@example.com"},
+		Technical:      &whoisparser.Contact{Email: "tech@example.net"},
+	}
+
+	info := domainInfoFromWhois("example.org", record)
+
+	if info.Organization != "Example Corp" {
+		t.Errorf("Organization = %q; expected %q", info.Organization, "Example Corp")
+	}
+	if info.Status != "ok, clientHold" {
+		t.Errorf("Status = %q; expected %q", info.Status, "ok, clientHold")
+	}
+	expected := []string{"admin@example.com", "tech@example.net"}
+	if !reflect.DeepEqual(info.Emails, expected) {
+		t.Errorf("Emails = %v; expected %v", info.Emails, expected)
+	}
+	if info.Registrar != "" {
+		t.Errorf("Registrar should be empty when the section is missing, got %q", info.Registrar)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		result   Result
+		expected int
+	}{
+		{"Matches", Result{TotalScanned: 100, TotalMatches: 3, TotalErrors: 10}, ExitMatches},
+		{"No matches", Result{TotalScanned: 100, TotalErrors: 10}, ExitNoMatches},
+		{"High error rate", Result{TotalScanned: 100, TotalMatches: 3, TotalErrors: 60}, ExitHighErrors},
+		{"Error rate at threshold", Result{TotalScanned: 100, TotalMatches: 1, TotalErrors: 50}, ExitMatches},
+		{"Nothing scanned", Result{}, ExitNoMatches},
+		{"Mostly unregistered", Result{TotalScanned: 100, TotalMatches: 1, TotalErrors: 90, ErrorsByType: map[string]int{"nxdomain": 85, "timeout": 5}}, ExitMatches},
+		{"Unregistered without matches", Result{TotalScanned: 100, TotalErrors: 90, ErrorsByType: map[string]int{"nxdomain": 90}}, ExitNoMatches},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := exitCode(tc.result, 0.5); code != tc.expected {
+				t.Errorf("exitCode() = %d; expected %d", code, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTargetPivots(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   Config
+		target   DomainInfo
+		expected []string
+	}{
+		{"nothing to pivot on", Config{PivotWindow: 180}, DomainInfo{Domain: "example.com"}, nil},
+		{"contact on the target domain", Config{}, DomainInfo{Domain: "example.com", Emails: []string{"hostmaster@example.com"}},
+			[]string{"contact email domain"}},
+		{"privacy service contact", Config{}, DomainInfo{Domain: "example.com", Emails: []string{"abuse@privacy.example"}}, nil},
+		{"email matching requested", Config{EmailMatch: true}, DomainInfo{Domain: "example.com"}, []string{"contact email domain"}},
+		{"mail domains given", Config{MailDomains: "example-mail.com"}, DomainInfo{Domain: "example.com"}, []string{"contact email domain"}},
+		{"registrar and creation date", Config{PivotWindow: 180}, DomainInfo{Domain: "example.com", Registrar: "Example Registrar", CreatedDate: "2019-03-04"},
+			[]string{"registrar pivot"}},
+		{"registrar without creation date", Config{PivotWindow: 180}, DomainInfo{Domain: "example.com", Registrar: "Example Registrar"}, nil},
+		{"organization aliases", Config{OrgNormalizer: newOrgNormalizer(OrgRules{Aliases: []string{"Example Inc"}})}, DomainInfo{Domain: "example.com"},
+			[]string{"organization aliases"}},
+	}
+	for _, tc := range testCases {
+		config := tc.config
+		pivots := targetPivots(&config, &tc.target)
+		if !reflect.DeepEqual(pivots, tc.expected) {
+			t.Errorf("%s: expected pivots %v, got %v", tc.name, tc.expected, pivots)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// torDNSServer answers DNS queries sent through Tor. Queries go over TCP
+// since Tor only carries streams.
+const torDNSServer = "1.1.1.1:53"
+
+// torDialer connects through a Tor SOCKS proxy. Tor builds a separate
+// circuit for each SOCKS username and password (IsolateSOCKSAuth, on by
+// default), so fresh credentials every isolate connections move the scan to
+// a new circuit and exit relay.
+type torDialer struct {
+	addr    string
+	isolate int
+	timeout time.Duration
+
+	mu    sync.Mutex
+	dials int
+	auth  *proxy.Auth
+}
+
+func newTorDialer(addr string, isolate int, timeout time.Duration) *torDialer {
+	return &torDialer{addr: addr, isolate: isolate, timeout: timeout}
+}
+
+// credentials returns the SOCKS credentials of the next connection,
+// switching to new ones every isolate connections; with isolate 0 the
+// whole run shares one circuit
+func (d *torDialer) credentials() *proxy.Auth {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.auth == nil || (d.isolate > 0 && d.dials%d.isolate == 0) {
+		b := make([]byte, 8)
+		rand.Read(b)
+		d.auth = &proxy.Auth{User: "tldscanner-" + hex.EncodeToString(b), Password: "tldscanner"}
+	}
+	d.dials++
+	return d.auth
+}
+
+// DialContext connects to addr through Tor. Host names are resolved by the
+// exit relay, never locally.
+func (d *torDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	socks, err := proxy.SOCKS5("tcp", d.addr, d.credentials(), &net.Dialer{Timeout: d.timeout})
+	if err != nil {
+		return nil, err
+	}
+	conn, err := socks.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("tor: %w", err)
+	}
+	return conn, nil
+}
+
+// Dial implements proxy.Dialer for the WHOIS client
+func (d *torDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// validateTor checks the -tor options
+func validateTor(config Config) error {
+	if config.TorIsolate < 0 {
+		return fmt.Errorf("-tor-isolate must not be negative")
+	}
+	if config.Tor && len(config.SourceIPs) > 0 {
+		return fmt.Errorf("-tor cannot be combined with -source-ip")
+	}
+	return nil
+}
+
+// routeThroughTor sends every outbound connection of the process through
+// Tor: WHOIS via config.TorDialer, HTTP (RDAP, probes, enrichment,
+// webhooks) via the default and probe transports, and DNS over TCP to
+// torDNSServer. Only the -cache Redis server and the SMTP server are
+// connected to directly.
+func routeThroughTor(config *Config) error {
+	conn, err := net.DialTimeout("tcp", config.TorAddr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("Tor is not reachable at %s (is it running?): %w", config.TorAddr, err)
+	}
+	conn.Close()
+
+	d := newTorDialer(config.TorAddr, config.TorIsolate, time.Duration(config.Timeout)*time.Second)
+	config.TorDialer = d
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = d.DialContext
+	http.DefaultTransport = transport
+	probeTransport.Proxy = nil
+	probeTransport.DialContext = d.DialContext
+
+	net.DefaultResolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return d.DialContext(ctx, "tcp", torDNSServer)
+		},
+	}
+
+	if config.TorIsolate > 0 {
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Routing traffic through Tor at %s, new circuit every %d connections\n", ColorBlue, ColorReset, config.TorAddr, config.TorIsolate)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s[INFO]%s Routing traffic through Tor at %s\n", ColorBlue, ColorReset, config.TorAddr)
+	}
+	return nil
+}
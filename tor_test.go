@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSOCKS5 accepts SOCKS5 connections with username/password auth,
+// records the usernames and connect targets and answers "ok"
+type fakeSOCKS5 struct {
+	addr    string
+	mu      sync.Mutex
+	users   []string
+	targets []string
+}
+
+func newFakeSOCKS5(t *testing.T) *fakeSOCKS5 {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeSOCKS5{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSOCKS5) serve(conn net.Conn) {
+	defer conn.Close()
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		io.ReadFull(conn, b)
+		return b
+	}
+
+	// Greeting: pick username/password auth
+	greeting := read(2)
+	read(int(greeting[1]))
+	conn.Write([]byte{5, 2})
+	// RFC 1929 auth
+	header := read(2)
+	user := string(read(int(header[1])))
+	read(int(read(1)[0]))
+	conn.Write([]byte{1, 0})
+	// CONNECT to a host name
+	request := read(4)
+	var target string
+	if request[3] == 3 {
+		target = string(read(int(read(1)[0])))
+	}
+	read(2)
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	s.mu.Lock()
+	s.users = append(s.users, user)
+	s.targets = append(s.targets, target)
+	s.mu.Unlock()
+	io.WriteString(conn, "ok")
+}
+
+func TestTorDialerIsolation(t *testing.T) {
+	socks := newFakeSOCKS5(t)
+	dialer := newTorDialer(socks.addr, 2, 2*time.Second)
+
+	for i := 0; i < 5; i++ {
+		conn, err := dialer.Dial("tcp", "whois.nic.example:43")
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		if b, _ := io.ReadAll(conn); string(b) != "ok" {
+			t.Errorf("Expected the proxied answer, got %q", b)
+		}
+		conn.Close()
+	}
+
+	socks.mu.Lock()
+	defer socks.mu.Unlock()
+	if len(socks.users) != 5 {
+		t.Fatalf("Expected 5 proxied connections, got %d", len(socks.users))
+	}
+	// Circuits change every 2 connections
+	if socks.users[0] != socks.users[1] || socks.users[1] == socks.users[2] || socks.users[2] != socks.users[3] || socks.users[3] == socks.users[4] {
+		t.Errorf("Expected credentials to rotate every 2 connections, got %v", socks.users)
+	}
+	// The host name is resolved by Tor, not locally
+	if socks.targets[0] != "whois.nic.example" {
+		t.Errorf("Expected the host name to be sent to the proxy, got %q", socks.targets[0])
+	}
+}
+
+func TestTorDialerSingleCircuit(t *testing.T) {
+	dialer := newTorDialer("127.0.0.1:9050", 0, time.Second)
+	first := dialer.credentials()
+	for i := 0; i < 20; i++ {
+		if dialer.credentials() != first {
+			t.Fatal("Expected -tor-isolate 0 to keep one circuit")
+		}
+	}
+}
+
+func TestValidateTor(t *testing.T) {
+	tests := []struct {
+		config  Config
+		wantErr bool
+	}{
+		{Config{Tor: true, TorIsolate: 10}, false},
+		{Config{TorIsolate: -1}, true},
+		{Config{Tor: true, SourceIPs: stringList{"192.0.2.1"}}, true},
+		{Config{SourceIPs: stringList{"192.0.2.1"}}, false},
+	}
+	for _, tt := range tests {
+		if err := validateTor(tt.config); (err != nil) != tt.wantErr {
+			t.Errorf("validateTor(%+v) error = %v, wantErr %v", tt.config, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// traceOutput serializes debug transcript lines of concurrent lookups
+var traceOutput = struct {
+	sync.Mutex
+	w io.Writer
+}{w: os.Stderr}
+
+// lookupTrace identifies one domain lookup. Under -debug it logs what the
+// lookup did, each line tagged with the ID that is also recorded as the
+// domain's trace_id, so the transcript of any result can be found.
+type lookupTrace struct {
+	id     string
+	domain string
+	start  time.Time
+	debug  bool
+}
+
+// newLookupTrace starts the trace of a lookup of domain
+func newLookupTrace(domain string, debug bool) *lookupTrace {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return &lookupTrace{id: hex.EncodeToString(b), domain: domain, start: time.Now(), debug: debug}
+}
+
+// logf writes one transcript line when debugging; a nil trace logs nothing
+func (t *lookupTrace) logf(format string, args ...interface{}) {
+	if t == nil || !t.debug {
+		return
+	}
+	traceOutput.Lock()
+	defer traceOutput.Unlock()
+	fmt.Fprintf(traceOutput.w, "%s[DEBUG]%s %s +%s %s: %s\n", ColorCyan, ColorReset,
+		t.id, time.Since(t.start).Round(time.Millisecond), t.domain, fmt.Sprintf(format, args...))
+}
+
+// debugging reports whether the trace logs anything
+func (t *lookupTrace) debugging() bool {
+	return t != nil && t.debug
+}
+
+// tracingDialer logs the connect time of every WHOIS connection and the
+// bytes received on it
+type tracingDialer struct {
+	dialer proxy.Dialer
+	trace  *lookupTrace
+}
+
+func (d *tracingDialer) Dial(network, addr string) (net.Conn, error) {
+	started := time.Now()
+	conn, err := d.dialer.Dial(network, addr)
+	if err != nil {
+		d.trace.logf("connect %s failed after %s: %v", addr, time.Since(started).Round(time.Millisecond), err)
+		return nil, err
+	}
+	d.trace.logf("connected to %s in %s", addr, time.Since(started).Round(time.Millisecond))
+	return &countingConn{Conn: conn, addr: addr, trace: d.trace}, nil
+}
+
+// countingConn counts the bytes read from a connection and logs the total
+// when it is closed
+type countingConn struct {
+	net.Conn
+	addr  string
+	trace *lookupTrace
+	read  int
+	once  sync.Once
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read += n
+	return n, err
+}
+
+func (c *countingConn) Close() error {
+	c.once.Do(func() { c.trace.logf("received %d bytes from %s", c.read, c.addr) })
+	return c.Conn.Close()
+}
+
+// tracingTransport logs every RDAP request with its status, duration and
+// response size
+type tracingTransport struct {
+	base  http.RoundTripper
+	trace *lookupTrace
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	t.trace.logf("GET %s", req.URL)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.trace.logf("GET %s failed after %s: %v", req.URL, time.Since(started).Round(time.Millisecond), err)
+		return nil, err
+	}
+	t.trace.logf("HTTP %d from %s in %s", resp.StatusCode, req.URL.Host, time.Since(started).Round(time.Millisecond))
+	resp.Body = &countingBody{ReadCloser: resp.Body, host: req.URL.Host, trace: t.trace}
+	return resp, nil
+}
+
+// countingBody counts the bytes of a response body and logs the total when
+// it is closed
+type countingBody struct {
+	io.ReadCloser
+	host  string
+	trace *lookupTrace
+	read  int
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += n
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.trace.logf("received %d bytes from %s", b.read, b.host)
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/likexian/whois"
+)
+
+func TestLookupTrace(t *testing.T) {
+	var out bytes.Buffer
+	traceOutput.w = &out
+	defer func() { traceOutput.w = os.Stderr }()
+
+	first, second := newLookupTrace("example.io", true), newLookupTrace("example.io", false)
+	if len(first.id) != 12 || first.id == second.id {
+		t.Errorf("Expected distinct 12 character IDs, got %q and %q", first.id, second.id)
+	}
+	first.logf("querying %s", "whois.nic.io")
+	second.logf("not logged")
+	var none *lookupTrace
+	none.logf("not logged either")
+
+	if got := out.String(); !strings.Contains(got, first.id) || !strings.Contains(got, "example.io: querying whois.nic.io") || strings.Count(got, "\n") != 1 {
+		t.Errorf("Unexpected transcript %q", got)
+	}
+}
+
+func TestTracingDialer(t *testing.T) {
+	var out bytes.Buffer
+	traceOutput.w = &out
+	defer func() { traceOutput.w = os.Stderr }()
+
+	trace := newLookupTrace("example.test", true)
+	inner := fakeWhoisDialer(t, "Domain Name: EXAMPLE.TEST\n")
+	client := whois.NewClient().SetDialer(&tracingDialer{dialer: inner, trace: trace}).SetTimeout(2 * time.Second).SetDisableReferral(true)
+	ianaServers.Store("test", "whois.nic.test")
+	defer ianaServers.Delete("test")
+
+	if _, _, err := queryWhois(client, "example.test", "example.test", nil, trace); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"WHOIS servers for .test: whois.nic.test", "querying whois.nic.test", "connected to whois.nic.test:43", "received 26 bytes from whois.nic.test:43"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in the transcript %q", line, out.String())
+		}
+	}
+}
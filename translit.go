@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// latinLetters transliterates Cyrillic and Greek letters to Latin. The
+// Cyrillic mapping follows the Russian passport (ICAO) scheme with the
+// Ukrainian and Belarusian letters added.
+var latinLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "ie", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+	'є': "ie", 'і': "i", 'ї': "i", 'ґ': "g", 'ў': "u",
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// transliterate converts Cyrillic and Greek text to Latin letters, dropping
+// accents. Other scripts, such as Japanese, are left unchanged and need an
+// alias instead.
+func transliterate(s string) string {
+	var b strings.Builder
+	mapped := false
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			if !mapped {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		latin, ok := latinLetters[unicode.ToLower(r)]
+		mapped = ok
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		if unicode.IsUpper(r) && latin != "" {
+			latin = strings.ToUpper(latin[:1]) + latin[1:]
+		}
+		b.WriteString(latin)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// hasTransliterableLetters reports whether s contains letters that
+// transliterate changes
+func hasTransliterableLetters(s string) bool {
+	for _, r := range s {
+		if _, ok := latinLetters[unicode.ToLower(r)]; ok {
+			return true
+		}
+	}
+	return false
+}
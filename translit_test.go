@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"Эксампл ООО", "Eksampl OOO"},
+		{"Щука", "Shchuka"},
+		{"Παράδειγμα", "Paradeigma"},
+		{"株式会社エクサンプル", "株式会社エクサンプル"},
+		{"Example Inc", "Example Inc"},
+	}
+	for _, tc := range testCases {
+		if got := transliterate(tc.input); got != tc.expected {
+			t.Errorf("transliterate(%q) = %q; expected %q", tc.input, got, tc.expected)
+		}
+	}
+}
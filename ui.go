@@ -0,0 +1,21 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// uiFiles is the serve mode web dashboard, a static page over the scan API
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// uiHandler serves the dashboard assets
+func uiHandler() http.Handler {
+	assets, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(assets))
+}
@@ -0,0 +1,212 @@
+// TLD Scanner dashboard: a client of the serve mode REST and WebSocket API.
+// Paths are resolved against the page so the dashboard also works behind a
+// proxy that serves it under a prefix.
+"use strict";
+
+const api = new URL("../", location.href);
+const $ = (id) => document.getElementById(id);
+
+let scans = [];
+let selected = null;
+let stream = null;
+let rows = [];
+let token = localStorage.getItem("tldscanner-token") || "";
+
+// request calls the API with the tenant token, if one was entered
+async function request(path, options = {}) {
+  options.headers = Object.assign({}, options.headers);
+  if (token) options.headers.Authorization = "Bearer " + token;
+  const resp = await fetch(new URL(path, api), options);
+  $("token-form").hidden = resp.status !== 401 && !token;
+  return resp;
+}
+
+// tokenURL adds the token for links and streams, which cannot send headers
+function tokenURL(path) {
+  const url = new URL(path, api);
+  if (token) url.searchParams.set("access_token", token);
+  return url;
+}
+
+function text(value) {
+  const span = document.createElement("span");
+  span.textContent = value == null ? "" : String(value);
+  return span.innerHTML;
+}
+
+function progress(job) {
+  if (job.status === "done") return 100;
+  return job.total ? Math.round(job.processed / job.total * 100) : 0;
+}
+
+async function refreshScans() {
+  try {
+    const resp = await request("scans");
+    if (!resp.ok) return;
+    scans = await resp.json();
+  } catch (err) {
+    return;
+  }
+  renderScans();
+}
+
+function renderScans() {
+  const body = $("scan-rows");
+  if (!scans.length) {
+    body.innerHTML = '<tr><td colspan="5" class="empty">No scans yet.</td></tr>';
+    return;
+  }
+  body.innerHTML = scans.map((job) => `
+<tr data-id="${text(job.id)}" class="${job.id === selected ? "selected" : ""}">
+<td>${text(job.domain)}</td>
+<td class="status-${text(job.status)}">${text(job.status)}${job.error ? ": " + text(job.error) : ""}</td>
+<td><div class="progress"><div style="width: ${progress(job)}%"></div></div></td>
+<td>${job.matches}</td>
+<td>${new Date(job.created_at).toLocaleString()}</td>
+</tr>`).join("");
+}
+
+function showJob(job) {
+  $("detail-title").textContent = job.domain;
+  $("detail-bar").style.width = progress(job) + "%";
+  let status = `${job.status}: ${job.processed} of ${job.total} domains looked up, ${job.matches} matches`;
+  if (job.error) status += ` (${job.error})`;
+  $("detail-status").textContent = status;
+
+  const downloads = $("downloads");
+  downloads.hidden = job.status !== "done";
+  for (const link of downloads.querySelectorAll("a")) {
+    link.href = tokenURL(`scans/${job.id}/result?format=${link.dataset.format}`);
+  }
+
+  const index = scans.findIndex((s) => s.id === job.id);
+  if (index >= 0) {
+    scans[index] = job;
+    renderScans();
+  }
+}
+
+function select(id) {
+  if (stream) stream.close();
+  selected = id;
+  rows = [];
+  renderRows();
+  renderScans();
+  $("detail").hidden = false;
+
+  const url = tokenURL(`scans/${id}/stream`);
+  url.protocol = url.protocol === "https:" ? "wss:" : "ws:";
+  stream = new WebSocket(url);
+  stream.onmessage = (message) => {
+    const event = JSON.parse(message.data);
+    if (event.job) showJob(event.job);
+    if (event.type === "domain") {
+      const job = scans.find((s) => s.id === id);
+      if (job) {
+        job.processed = event.processed;
+        job.total = event.total;
+        if (event.matched) job.matches++;
+        showJob(job);
+      }
+      if (event.matched) {
+        addRow(event.domain, "match");
+      } else if (event.domain.signals && event.domain.signals.length) {
+        addRow(event.domain, "signal");
+      }
+    }
+    if (event.job && event.job.status === "done") loadResult(id);
+  };
+}
+
+// loadResult replaces the streamed rows with the final result, which also
+// holds the lookalikes ranked after the lookups
+async function loadResult(id) {
+  const resp = await request(`scans/${id}/result`);
+  if (!resp.ok || id !== selected) return;
+  const result = await resp.json();
+  rows = [];
+  for (const domain of result.matching_domains || []) addRow(domain, "match", false);
+  for (const domain of result.signal_domains || []) addRow(domain, "signal", false);
+  for (const domain of result.lookalikes || []) addRow(domain, "lookalike", false);
+  renderRows();
+}
+
+function addRow(domain, kind, render = true) {
+  rows.push({ domain, kind });
+  if (render) renderRows();
+}
+
+function reason(domain, kind) {
+  if (kind === "lookalike") return `risk ${domain.risk_score}${domain.technique ? " (" + domain.technique + ")" : ""}`;
+  if (domain.match_reason) return domain.match_reason;
+  return (domain.signals || []).map((s) => `${s.name} ${s.score.toFixed(2)}`).join(", ");
+}
+
+function renderRows() {
+  const filter = $("filter").value.trim().toLowerCase();
+  const kind = $("kind").value;
+  const visible = rows.filter((row) => {
+    if (kind ? row.kind !== kind : row.kind === "lookalike") return false;
+    if (!filter) return true;
+    const d = row.domain;
+    return [d.domain, d.unicode_domain, d.organization, d.registrar, reason(d, row.kind), ...(d.tags || [])]
+      .some((value) => value && value.toLowerCase().includes(filter));
+  });
+
+  const body = $("match-rows");
+  if (!visible.length) {
+    body.innerHTML = '<tr><td colspan="7" class="empty">Nothing to show.</td></tr>';
+    return;
+  }
+  body.innerHTML = visible.map(({ domain: d, kind }) => `
+<tr class="${kind}">
+<td>${text(d.domain)}${d.unicode_domain ? " (" + text(d.unicode_domain) + ")" : ""}</td>
+<td>${kind}</td>
+<td>${text(d.organization)}</td>
+<td>${text(d.registrar)}</td>
+<td>${text(d.created_date)}</td>
+<td>${text(reason(d, kind))}</td>
+<td>${(d.tags || []).map((tag) => `<span class="tag">${text(tag)}</span>`).join("")}</td>
+</tr>`).join("");
+}
+
+$("scan-rows").addEventListener("click", (event) => {
+  const row = event.target.closest("tr[data-id]");
+  if (row) select(row.dataset.id);
+});
+$("filter").addEventListener("input", renderRows);
+$("kind").addEventListener("change", renderRows);
+
+$("submit").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  $("submit-error").textContent = "";
+  const req = { domain: $("domain").value, save_all: $("save-all").checked };
+  if ($("wordlist").value) req.wordlist = $("wordlist").value;
+  const resp = await request("scans", {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(req),
+  });
+  const body = await resp.json();
+  if (!resp.ok) {
+    $("submit-error").textContent = body.error;
+    return;
+  }
+  $("domain").value = "";
+  await refreshScans();
+  select(body.id);
+});
+
+$("token").value = token;
+$("token-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  token = $("token").value.trim();
+  localStorage.setItem("tldscanner-token", token);
+  scans = [];
+  selected = null;
+  $("detail").hidden = true;
+  refreshScans();
+});
+
+refreshScans();
+setInterval(refreshScans, 3000);
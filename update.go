@@ -0,0 +1,374 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// releasePublicKey is the project's base64 Ed25519 key release checksums
+// are signed with; forks override it at build time with -ldflags "-X
+// main.releasePublicKey=...". Updates require a checksums.txt.sig that
+// verifies against it unless -allow-unsigned is given.
+var releasePublicKey = "N0PuW8paoepcmXKhb/SRxwSUqGb3i2QF6/ruN3IgLEc="
+
+// githubReleasesURL is the GitHub API endpoint of the project's releases, a
+// variable so tests can point it at a local server
+var githubReleasesURL = "https://api.github.com/repos/vijay922/TLDScanner/releases"
+
+const (
+	// releaseChecksums is the sha256sum manifest attached to every release
+	releaseChecksums = "checksums.txt"
+	// maxReleaseAsset bounds the size of a downloaded release asset
+	maxReleaseAsset = 256 << 20
+)
+
+// githubRelease is the part of a GitHub release the updater reads
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// assetURL returns the download URL of a release asset
+func (r githubRelease) assetURL(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
+// runUpdate implements `tldscanner update`
+func runUpdate(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	check := fs.Bool("check", false, "Only report whether a newer release is available")
+	tag := fs.String("version", "", "Install this release tag instead of the latest, e.g. v2.1.0")
+	force := fs.Bool("force", false, "Install even when the release is not newer, or this is a development build")
+	allowUnsigned := fs.Bool("allow-unsigned", false, "Install a release without a signature, checking only its SHA-256 checksums (not recommended)")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s update [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Downloads the latest release for this platform from GitHub, verifies it\n")
+		fmt.Printf("against the release's signed SHA-256 checksums and replaces the running\n")
+		fmt.Printf("binary.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	release, err := fetchRelease(client, *tag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	newer := compareVersions(release.TagName, version) > 0
+	switch {
+	case *check && newer:
+		fmt.Printf("%s[INFO]%s %s is available (running %s)\n", ColorBlue, ColorReset, release.TagName, version)
+		return ExitMatches
+	case *check:
+		fmt.Printf("%s[INFO]%s %s is up to date\n", ColorBlue, ColorReset, version)
+		return ExitMatches
+	case version == "dev" && !*force:
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s this is a development build; use -force to replace it with %s\n", ColorRed, ColorReset, release.TagName)
+		return ExitUsage
+	case !newer && !*force && *tag == "":
+		fmt.Printf("%s[INFO]%s %s is up to date\n", ColorBlue, ColorReset, version)
+		return ExitMatches
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s failed to locate the tldscanner binary: %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	fmt.Printf("%s[INFO]%s Downloading %s for %s/%s\n", ColorBlue, ColorReset, release.TagName, runtime.GOOS, runtime.GOARCH)
+	binary, err := downloadRelease(client, release, runtime.GOOS, runtime.GOARCH, releasePublicKey, *allowUnsigned)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+	if err := replaceExecutable(executable, binary); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s failed to replace %s: %v\n", ColorRed, ColorReset, executable, err)
+		return ExitUsage
+	}
+	fmt.Printf("%s[INFO]%s Updated %s from %s to %s\n", ColorBlue, ColorReset, executable, version, release.TagName)
+	return ExitMatches
+}
+
+// fetchRelease reads the latest release, or the release of a tag
+func fetchRelease(client *http.Client, tag string) (githubRelease, error) {
+	endpoint := githubReleasesURL + "/latest"
+	if tag != "" {
+		endpoint = githubReleasesURL + "/tags/" + tag
+	}
+	data, err := downloadAsset(client, endpoint)
+	if err != nil {
+		return githubRelease{}, fmt.Errorf("failed to look up the release: %w", err)
+	}
+	var release githubRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return githubRelease{}, fmt.Errorf("failed to parse the release: %w", err)
+	}
+	if release.TagName == "" {
+		return githubRelease{}, fmt.Errorf("the release has no tag")
+	}
+	return release, nil
+}
+
+// releaseArchive returns the name of a release's archive for a platform and
+// the name of the binary inside it, following the Makefile's release
+// target
+func releaseArchive(tag, goos, goarch string) (archive, binary string) {
+	binary = fmt.Sprintf("tldscanner-%s-%s", goos, goarch)
+	if goos == "windows" {
+		return fmt.Sprintf("tldscanner-%s-%s-%s.zip", tag, goos, goarch), binary + ".exe"
+	}
+	return fmt.Sprintf("tldscanner-%s-%s-%s.tar.gz", tag, goos, goarch), binary
+}
+
+// downloadRelease downloads the platform's archive of a release, verifies
+// it against the release checksums and returns the binary it contains.
+// The checksums must carry a signature that verifies against publicKey;
+// allowUnsigned accepts a release without one, or a build without a key.
+func downloadRelease(client *http.Client, release githubRelease, goos, goarch, publicKey string, allowUnsigned bool) ([]byte, error) {
+	archiveName, binaryName := releaseArchive(release.TagName, goos, goarch)
+	archiveURL, ok := release.assetURL(archiveName)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s (%s)", release.TagName, goos, goarch, archiveName)
+	}
+	checksumsURL, ok := release.assetURL(releaseChecksums)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s; refusing to install an unverified binary", release.TagName, releaseChecksums)
+	}
+	checksums, err := downloadAsset(client, checksumsURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", releaseChecksums, err)
+	}
+
+	sigURL, signed := release.assetURL(releaseChecksums + ".sig")
+	switch {
+	case publicKey == "" && !allowUnsigned:
+		return nil, fmt.Errorf("this build has no release public key; refusing to install an unverified binary without -allow-unsigned")
+	case !signed && !allowUnsigned:
+		return nil, fmt.Errorf("release %s has no %s.sig; refusing to install an unsigned binary without -allow-unsigned", release.TagName, releaseChecksums)
+	case signed && publicKey != "":
+		sig, err := downloadAsset(client, sigURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s.sig: %w", releaseChecksums, err)
+		}
+		if err := verifyReleaseSignature(checksums, sig, publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	archive, err := downloadAsset(client, archiveURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", archiveName, err)
+	}
+	if err := verifyChecksum(checksums, archiveName, archive); err != nil {
+		return nil, err
+	}
+	return extractBinary(archive, archiveName, binaryName)
+}
+
+// downloadAsset fetches a URL, bounded to maxReleaseAsset bytes
+func downloadAsset(client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "tldscanner/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReleaseAsset+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxReleaseAsset {
+		return nil, fmt.Errorf("%s is larger than %d MB", url, maxReleaseAsset>>20)
+	}
+	return data, nil
+}
+
+// verifyReleaseSignature checks the base64 Ed25519 signature of the
+// checksums file
+func verifyReleaseSignature(checksums, sig []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(key, checksums, signature) {
+		return fmt.Errorf("signature of %s does not verify; refusing to update", releaseChecksums)
+	}
+	return nil
+}
+
+// verifyChecksum checks data against its entry in a sha256sum manifest
+func verifyChecksum(checksums []byte, name string, data []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s; refusing to update", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s is not listed in %s", name, releaseChecksums)
+}
+
+// extractBinary reads the named binary out of a .tar.gz or .zip archive
+func extractBinary(archive []byte, archiveName, binaryName string) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range reader.File {
+			if filepath.Base(file.Name) != binaryName {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxReleaseAsset))
+		}
+		return nil, fmt.Errorf("%s does not contain %s", archiveName, binaryName)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s does not contain %s", archiveName, binaryName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == binaryName {
+			return io.ReadAll(io.LimitReader(tr, maxReleaseAsset))
+		}
+	}
+}
+
+// replaceExecutable swaps the binary at path for data. The new binary is
+// written next to the old one and renamed over it; Windows cannot replace
+// a running executable, so there the old one is moved aside first and left
+// as path.old.
+func replaceExecutable(path string, data []byte) error {
+	mode := os.FileMode(0755)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := path + ".new"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// compareVersions compares two versions of the form v1.2.3, ignoring
+// pre-release and build suffixes. Versions that do not parse, such as dev,
+// are older than any release.
+func compareVersions(a, b string) int {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	switch {
+	case !okA || !okB:
+		if okA == okB {
+			return 0
+		}
+		if okA {
+			return 1
+		}
+		return -1
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] > pb[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
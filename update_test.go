@@ -0,0 +1,194 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func tarGz(t *testing.T, name string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{"README.md", []byte("readme")}, {name, data}} {
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0755, Size: int64(len(file.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(file.data)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// newReleaseServer serves a GitHub release with the given assets
+func newReleaseServer(t *testing.T, tag string, assets map[string][]byte) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/releases/latest" || r.URL.Path == "/releases/tags/"+tag {
+			release := map[string]interface{}{"tag_name": tag}
+			var list []map[string]string
+			for name := range assets {
+				list = append(list, map[string]string{"name": name, "browser_download_url": srv.URL + "/download/" + name})
+			}
+			release["assets"] = list
+			json.NewEncoder(w).Encode(release)
+			return
+		}
+		data, ok := assets[strings.TrimPrefix(r.URL.Path, "/download/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	original := githubReleasesURL
+	t.Cleanup(func() { githubReleasesURL = original })
+	githubReleasesURL = srv.URL + "/releases"
+	return srv
+}
+
+func TestDownloadRelease(t *testing.T) {
+	binary := []byte("new tldscanner binary")
+	archive := tarGz(t, "tldscanner-linux-amd64", binary)
+	sum := sha256.Sum256(archive)
+	checksums := []byte(fmt.Sprintf("%s  tldscanner-v2.1.0-linux-amd64.tar.gz\n%s  other.zip\n", hex.EncodeToString(sum[:]), strings.Repeat("0", 64)))
+	public, private, _ := ed25519.GenerateKey(nil)
+	publicKey := base64.StdEncoding.EncodeToString(public)
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, checksums)) + "\n")
+
+	newReleaseServer(t, "v2.1.0", map[string][]byte{
+		"tldscanner-v2.1.0-linux-amd64.tar.gz": archive,
+		"checksums.txt":                        checksums,
+		"checksums.txt.sig":                    sig,
+	})
+	client := http.DefaultClient
+	release, err := fetchRelease(client, "")
+	if err != nil {
+		t.Fatalf("fetchRelease failed: %v", err)
+	}
+	if release.TagName != "v2.1.0" {
+		t.Errorf("Expected tag v2.1.0, got %q", release.TagName)
+	}
+
+	for _, key := range []string{"", publicKey} {
+		got, err := downloadRelease(client, release, "linux", "amd64", key, key == "")
+		if err != nil {
+			t.Fatalf("downloadRelease failed: %v", err)
+		}
+		if !bytes.Equal(got, binary) {
+			t.Errorf("Expected the binary from the archive, got %q", got)
+		}
+	}
+
+	if _, err := downloadRelease(client, release, "linux", "amd64", "", false); err == nil || !strings.Contains(err.Error(), "no release public key") {
+		t.Errorf("Expected a missing key error, got %v", err)
+	}
+	otherPublic, _, _ := ed25519.GenerateKey(nil)
+	if _, err := downloadRelease(client, release, "linux", "amd64", base64.StdEncoding.EncodeToString(otherPublic), true); err == nil || !strings.Contains(err.Error(), "does not verify") {
+		t.Errorf("Expected a signature error with another key, got %v", err)
+	}
+	if _, err := downloadRelease(client, release, "darwin", "arm64", publicKey, false); err == nil || !strings.Contains(err.Error(), "no build for darwin/arm64") {
+		t.Errorf("Expected a missing build error, got %v", err)
+	}
+
+	tampered := tarGz(t, "tldscanner-linux-amd64", []byte("tampered"))
+	newReleaseServer(t, "v2.1.0", map[string][]byte{
+		"tldscanner-v2.1.0-linux-amd64.tar.gz": tampered,
+		"checksums.txt":                        checksums,
+	})
+	if release, err = fetchRelease(client, "v2.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := downloadRelease(client, release, "linux", "amd64", publicKey, true); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+	if _, err := downloadRelease(client, release, "linux", "amd64", publicKey, false); err == nil || !strings.Contains(err.Error(), "no checksums.txt.sig") {
+		t.Errorf("Expected a missing signature error, got %v", err)
+	}
+}
+
+func TestReleasePublicKey(t *testing.T) {
+	key, err := base64.StdEncoding.DecodeString(releasePublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		t.Errorf("The default release public key is not a base64 Ed25519 key: %q", releasePublicKey)
+	}
+}
+
+func TestExtractBinaryZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("tldscanner-windows-amd64.exe")
+	w.Write([]byte("MZ"))
+	zw.Close()
+
+	archive, binary := releaseArchive("v2.1.0", "windows", "amd64")
+	if archive != "tldscanner-v2.1.0-windows-amd64.zip" || binary != "tldscanner-windows-amd64.exe" {
+		t.Errorf("Unexpected release names %q, %q", archive, binary)
+	}
+	got, err := extractBinary(buf.Bytes(), archive, binary)
+	if err != nil || string(got) != "MZ" {
+		t.Errorf("extractBinary = %q, %v", got, err)
+	}
+	if _, err := extractBinary(buf.Bytes(), archive, "missing.exe"); err == nil {
+		t.Error("Expected an error for a missing binary")
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tldscanner")
+	if err := os.WriteFile(path, []byte("old"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := replaceExecutable(path, []byte("new")); err != nil {
+		t.Fatalf("replaceExecutable failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	info, _ := os.Stat(path)
+	if string(data) != "new" || info.Mode().Perm() != 0750 {
+		t.Errorf("Expected the new binary with the old mode, got %q %v", data, info.Mode().Perm())
+	}
+	if _, err := os.Stat(path + ".new"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be gone, got %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v2.1.0", "2.0.0", 1},
+		{"v2.0.0", "v2.0.0", 0},
+		{"v2.0.10", "v2.0.9", 1},
+		{"v2", "v2.0.1", -1},
+		{"v2.1.0-rc1", "v2.1.0", 0},
+		{"v2.0.0", "dev", 1},
+		{"dev", "v1.0.0", -1},
+		{"dev", "dev", 0},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
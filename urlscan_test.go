@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnrichURLScan(t *testing.T) {
+	var submitted map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/scan/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("API-Key") != "us-key" {
+			http.Error(w, `{"message":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&submitted)
+		w.Write([]byte(`{"uuid":"0e37e828-a9d9-45c0-ac50-1ca579b86c72","visibility":"unlisted"}`))
+	}))
+	defer server.Close()
+
+	oldURL := urlscanBaseURL
+	urlscanBaseURL = server.URL
+	defer func() { urlscanBaseURL = oldURL }()
+
+	// localhost always resolves, standing in for a live match
+	info := &DomainInfo{Domain: "localhost"}
+	config := Config{Timeout: 5, URLScanVisibility: "unlisted", APIKeys: map[string]string{"urlscan": "us-key"}}
+	if err := enrichURLScan(info, config); err != nil {
+		t.Fatalf("enrichURLScan failed: %v", err)
+	}
+
+	if submitted["url"] != "http://localhost" || submitted["visibility"] != "unlisted" {
+		t.Errorf("Unexpected submission: %v", submitted)
+	}
+	if info.URLScan == nil {
+		t.Fatal("Expected scan links to be recorded")
+	}
+	if info.URLScan.ResultURL != server.URL+"/result/0e37e828-a9d9-45c0-ac50-1ca579b86c72/" {
+		t.Errorf("ResultURL = %q", info.URLScan.ResultURL)
+	}
+	if info.URLScan.ScreenshotURL != server.URL+"/screenshots/0e37e828-a9d9-45c0-ac50-1ca579b86c72.png" {
+		t.Errorf("ScreenshotURL = %q", info.URLScan.ScreenshotURL)
+	}
+
+	config.APIKeys["urlscan"] = "wrong"
+	if err := enrichURLScan(&DomainInfo{Domain: "localhost"}, config); err == nil {
+		t.Error("Expected an error with a bad API key")
+	}
+}
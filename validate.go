@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Upper bounds of the worker pools, beyond which a scan gets its source
+// addresses banned by registries or exhausts file descriptors
+const (
+	maxThreads    = 1000
+	maxDNSThreads = 10000
+)
+
+// configErrors is every problem validateConfig found, reported together
+// so a command line can be fixed in one go
+type configErrors []error
+
+func (e configErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration problems:", len(e))
+	for _, err := range e {
+		b.WriteString("\n  - " + err.Error())
+	}
+	return b.String()
+}
+
+// add appends err, flattening the problems of a nested validation
+func (e *configErrors) add(err error) {
+	var nested configErrors
+	switch {
+	case err == nil:
+	case errors.As(err, &nested):
+		*e = append(*e, nested...)
+	default:
+		*e = append(*e, err)
+	}
+}
+
+// validateInputs checks that the wordlist and domain list exist before the
+// target is looked up
+func validateInputs(config Config) error {
+	var problems configErrors
+	if config.DomainsFile != "" && config.DomainsFile != stdinWordlist {
+		if err := checkReadable(config.DomainsFile); err != nil {
+			problems.add(fmt.Errorf("-domains-file %s: %v", config.DomainsFile, err))
+		}
+	}
+	// A missing default wordlist falls back to the embedded one
+	wordlist := config.Wordlist
+	if config.DomainsFile == "" && config.Countries == "" && wordlist != defaultWordlist &&
+		wordlist != stdinWordlist && !strings.HasPrefix(wordlist, builtinPrefix) {
+		if err := checkReadable(wordlist); err != nil {
+			problems.add(fmt.Errorf("-w %s: %v; use a TLD list file or builtin:all, builtin:popular, builtin:cctld or builtin:newgtld", wordlist, err))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// validateTimeout checks the per-lookup timeout
+func validateTimeout(config Config) error {
+	if config.Timeout < 1 {
+		return fmt.Errorf("-timeout must be at least 1 second")
+	}
+	return nil
+}
+
+// validateOutputPaths checks that every file the scan writes can be
+// created, so a long scan does not fail when saving its results
+func validateOutputPaths(config Config) error {
+	var problems configErrors
+	outputAll := ""
+	if config.OutputAll != "" {
+		outputAll = config.OutputAll + ".json"
+	}
+	paths := []struct{ flag, path string }{
+		{"-o", outputName(config.Output, config)},
+		{"-oA", outputAll},
+		{"-errors-file", config.ErrorsFile},
+		{"-checkpoint", config.Checkpoint},
+	}
+	for _, p := range paths {
+		if p.path == "" {
+			continue
+		}
+		if err := checkWritable(p.path); err != nil {
+			problems.add(fmt.Errorf("%s %s: %v", p.flag, p.path, err))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// checkReadable reports why a file cannot be read
+func checkReadable(path string) error {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("no such file")
+	case err != nil:
+		return err
+	case info.IsDir():
+		return fmt.Errorf("is a directory, not a file")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot be read (%v)", errors.Unwrap(err))
+	}
+	return file.Close()
+}
+
+// checkWritable reports why a file cannot be created or replaced, probing
+// its directory with a temporary file
+func checkWritable(path string) error {
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("is a directory; give a file name")
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return fmt.Errorf("exists and cannot be overwritten")
+		}
+		return file.Close()
+	}
+	dir := filepath.Dir(path)
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("directory %s does not exist; create it first", dir)
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	probe, err := os.CreateTemp(dir, ".tldscanner-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable", dir)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigErrors(t *testing.T) {
+	var problems configErrors
+	problems.add(nil)
+	problems.add(errors.New("-t must be at least 1"))
+	if problems.Error() != "-t must be at least 1" {
+		t.Errorf("A single problem should be reported as is, got %q", problems.Error())
+	}
+
+	problems.add(configErrors{errors.New("-w a: no such file"), errors.New("-o b: is a directory")})
+	if len(problems) != 3 {
+		t.Fatalf("Nested problems should be flattened, got %d", len(problems))
+	}
+	if msg := problems.Error(); !strings.HasPrefix(msg, "3 configuration problems:\n  - -t must be at least 1\n") {
+		t.Errorf("Unexpected message %q", msg)
+	}
+}
+
+func TestValidateInputs(t *testing.T) {
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "tlds.txt")
+	os.WriteFile(wordlist, []byte(".com\n"), 0644)
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"existing wordlist", Config{Wordlist: wordlist}, false},
+		{"missing default wordlist", Config{Wordlist: defaultWordlist}, false},
+		{"builtin list", Config{Wordlist: builtinPrefix + "popular"}, false},
+		{"stdin", Config{Wordlist: stdinWordlist}, false},
+		{"missing wordlist", Config{Wordlist: filepath.Join(dir, "missing.txt")}, true},
+		{"wordlist is a directory", Config{Wordlist: dir}, true},
+		{"unused wordlist", Config{Wordlist: filepath.Join(dir, "missing.txt"), Countries: "de"}, false},
+		{"missing domains file", Config{Wordlist: defaultWordlist, DomainsFile: filepath.Join(dir, "domains.txt")}, true},
+	}
+	for _, test := range tests {
+		err := validateInputs(test.config)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: validateInputs() error = %v, wantErr %v", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestValidateOutputPaths(t *testing.T) {
+	dir := t.TempDir()
+	if err := validateOutputPaths(Config{Output: filepath.Join(dir, "out.json"), ErrorsFile: filepath.Join(dir, "errors.txt")}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("The writability probe should leave nothing behind, found %d files", len(entries))
+	}
+
+	err := validateOutputPaths(Config{
+		Output:     filepath.Join(dir, "missing", "out.json"),
+		OutputAll:  dir,
+		Checkpoint: dir,
+	})
+	var problems configErrors
+	if !errors.As(err, &problems) || len(problems) != 2 {
+		t.Fatalf("Expected the missing directory and the directory checkpoint, got %v", err)
+	}
+	if !strings.Contains(problems[0].Error(), "does not exist; create it first") {
+		t.Errorf("Unexpected message %q", problems[0])
+	}
+}
+
+func TestValidateConfigReportsAllProblems(t *testing.T) {
+	var config Config
+	registerFlags(flag.NewFlagSet("tldscanner", flag.ContinueOnError), &config)
+	config.Threads = 0
+	config.Timeout = 0
+	config.Wordlist = filepath.Join(t.TempDir(), "missing.txt")
+	err := validateConfig(config)
+	var problems configErrors
+	if !errors.As(err, &problems) || len(problems) < 3 {
+		t.Fatalf("Expected every problem at once, got %v", err)
+	}
+}
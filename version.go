@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Build metadata, set at build time with -ldflags "-X main.version=...
+// -X main.commit=... -X main.buildDate=...". Without them the VCS details
+// the Go toolchain records are used.
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// tldSnapshotPrefix marks the comment line of an embedded wordlist naming
+// the IANA TLD list snapshot it was built from
+const tldSnapshotPrefix = "# IANA TLD list snapshot:"
+
+// BuildInfo identifies the scanner build a result was produced with, so
+// findings can be reproduced with the same binary and TLD list
+type BuildInfo struct {
+	Version     string `json:"version"`
+	Commit      string `json:"commit,omitempty"`
+	BuildDate   string `json:"build_date,omitempty"`
+	GoVersion   string `json:"go_version"`
+	TLDSnapshot string `json:"tld_snapshot,omitempty"`
+}
+
+// currentBuildInfo returns the running binary's build metadata
+func currentBuildInfo() *BuildInfo {
+	info := &BuildInfo{
+		Version:     version,
+		Commit:      commit,
+		BuildDate:   buildDate,
+		GoVersion:   runtime.Version(),
+		TLDSnapshot: builtinTLDSnapshot(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			// Installed with go install module@version
+			info.Version = bi.Main.Version
+		}
+		settings := make(map[string]string)
+		for _, setting := range bi.Settings {
+			settings[setting.Key] = setting.Value
+		}
+		if revision := settings["vcs.revision"]; info.Commit == "" && revision != "" {
+			info.Commit = revision[:min(len(revision), 12)]
+			if settings["vcs.modified"] == "true" {
+				info.Commit += "-dirty"
+			}
+		}
+		if info.BuildDate == "" {
+			info.BuildDate = settings["vcs.time"]
+		}
+	}
+	return info
+}
+
+// builtinTLDSnapshot returns the IANA TLD list snapshot the embedded
+// wordlists were built from, empty when none names one
+func builtinTLDSnapshot() string {
+	for _, name := range builtinWordlistNames()[1:] {
+		data, err := builtinWordlists.ReadFile("wordlists/" + name + ".txt")
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "#") {
+				break
+			}
+			if strings.HasPrefix(line, tldSnapshotPrefix) {
+				return strings.TrimSpace(strings.TrimPrefix(line, tldSnapshotPrefix))
+			}
+		}
+	}
+	return ""
+}
+
+// runVersion implements `tldscanner version`
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the build information as JSON")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s version [-json]\n\n", os.Args[0])
+		fmt.Printf("Prints the version, commit, build date, Go version and the IANA TLD\n")
+		fmt.Printf("list snapshot of the embedded wordlists.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitMatches
+		}
+		return ExitUsage
+	}
+
+	info := currentBuildInfo()
+	if *asJSON {
+		data, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Println(string(data))
+		return ExitMatches
+	}
+	fmt.Print(info.String())
+	return ExitMatches
+}
+
+// String renders the build information one field per line
+func (b BuildInfo) String() string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "tldscanner %s\n", b.Version)
+	fmt.Fprintf(&out, "Commit:       %s\n", firstNonEmpty(b.Commit, "unknown"))
+	fmt.Fprintf(&out, "Built:        %s\n", firstNonEmpty(b.BuildDate, "unknown"))
+	fmt.Fprintf(&out, "Go:           %s %s/%s\n", b.GoVersion, runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&out, "TLD snapshot: %s\n", firstNonEmpty(b.TLDSnapshot, "unknown"))
+	fmt.Fprintf(&out, "Schema:       %s\n", SchemaVersion)
+	return out.String()
+}
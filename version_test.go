@@ -0,0 +1,37 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCurrentBuildInfo(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "2.1.0", "abc123def456", "2024-01-10T08:00:00Z"
+
+	info := currentBuildInfo()
+	if info.Version != "2.1.0" || info.Commit != "abc123def456" || info.BuildDate != "2024-01-10T08:00:00Z" {
+		t.Errorf("Expected the linked build metadata, got %+v", info)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Expected Go version %s, got %s", runtime.Version(), info.GoVersion)
+	}
+	if info.TLDSnapshot == "" || info.TLDSnapshot != builtinTLDSnapshot() {
+		t.Errorf("Expected the embedded TLD snapshot, got %q", info.TLDSnapshot)
+	}
+
+	text := info.String()
+	for _, want := range []string{"tldscanner 2.1.0\n", "Commit:       abc123def456\n", "TLD snapshot: " + info.TLDSnapshot + "\n", "Schema:       " + SchemaVersion} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestBuiltinTLDSnapshot(t *testing.T) {
+	snapshot := builtinTLDSnapshot()
+	if _, ok := parseWhoisDate(snapshot); !ok {
+		t.Errorf("Expected the snapshot to be a date, got %q", snapshot)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// virusTotalBaseURL is the VirusTotal API v3 endpoint
+var virusTotalBaseURL = "https://www.virustotal.com/api/v3"
+
+// VirusTotalReport summarizes the VirusTotal reputation of a domain
+type VirusTotalReport struct {
+	Malicious  int      `json:"malicious"`
+	Suspicious int      `json:"suspicious"`
+	Harmless   int      `json:"harmless"`
+	Undetected int      `json:"undetected"`
+	Reputation int      `json:"reputation"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// Detections returns the number of engines flagging the domain as malicious
+// or suspicious. A nil report has no detections.
+func (r *VirusTotalReport) Detections() int {
+	if r == nil {
+		return 0
+	}
+	return r.Malicious + r.Suspicious
+}
+
+// virusTotalDomain is the /domains/{domain} response
+type virusTotalDomain struct {
+	Data struct {
+		Attributes struct {
+			LastAnalysisStats struct {
+				Harmless   int `json:"harmless"`
+				Malicious  int `json:"malicious"`
+				Suspicious int `json:"suspicious"`
+				Undetected int `json:"undetected"`
+			} `json:"last_analysis_stats"`
+			Reputation int               `json:"reputation"`
+			Categories map[string]string `json:"categories"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// enrichVirusTotal attaches the VirusTotal detection counts and categories
+// of info's domain
+func enrichVirusTotal(info *DomainInfo, config Config) error {
+	req, err := http.NewRequest(http.MethodGet, virusTotalBaseURL+"/domains/"+info.Domain, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-apikey", config.APIKeys["virustotal"])
+
+	resp, err := enrichmentHTTPClient(config, "virustotal").Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// Never seen by VirusTotal: nothing known, nothing to record
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var domain virusTotalDomain
+	if err := json.NewDecoder(resp.Body).Decode(&domain); err != nil {
+		return err
+	}
+	info.VirusTotal = virusTotalReport(domain)
+	return nil
+}
+
+// virusTotalReport converts a VirusTotal domain object into a report with
+// the distinct vendor categories sorted
+func virusTotalReport(domain virusTotalDomain) *VirusTotalReport {
+	attrs := domain.Data.Attributes
+	report := &VirusTotalReport{
+		Malicious:  attrs.LastAnalysisStats.Malicious,
+		Suspicious: attrs.LastAnalysisStats.Suspicious,
+		Harmless:   attrs.LastAnalysisStats.Harmless,
+		Undetected: attrs.LastAnalysisStats.Undetected,
+		Reputation: attrs.Reputation,
+	}
+	for _, category := range attrs.Categories {
+		if !containsString(report.Categories, category) {
+			report.Categories = append(report.Categories, category)
+		}
+	}
+	sort.Strings(report.Categories)
+	return report
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEnrichVirusTotal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-apikey") != "vt-key" {
+			http.Error(w, `{"error":{"code":"WrongCredentialsError"}}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/domains/example.net":
+			w.Write([]byte(`{"data":{"attributes":{
+				"last_analysis_stats":{"harmless":60,"malicious":4,"suspicious":1,"undetected":20},
+				"reputation":-12,
+				"categories":{"Forcepoint ThreatSeeker":"phishing","Sophos":"phishing and fraud","BitDefender":"phishing"}
+			}}}`))
+		default:
+			http.Error(w, `{"error":{"code":"NotFoundError"}}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	oldURL := virusTotalBaseURL
+	virusTotalBaseURL = server.URL
+	defer func() { virusTotalBaseURL = oldURL }()
+
+	config := Config{Timeout: 5, APIKeys: map[string]string{"virustotal": "vt-key"}}
+
+	info := &DomainInfo{Domain: "example.net"}
+	if err := enrichVirusTotal(info, config); err != nil {
+		t.Fatalf("enrichVirusTotal failed: %v", err)
+	}
+	if info.VirusTotal.Detections() != 5 || info.VirusTotal.Reputation != -12 {
+		t.Errorf("Unexpected report: %+v", info.VirusTotal)
+	}
+	expected := []string{"phishing", "phishing and fraud"}
+	if !reflect.DeepEqual(info.VirusTotal.Categories, expected) {
+		t.Errorf("Categories = %v; expected %v", info.VirusTotal.Categories, expected)
+	}
+
+	unknown := &DomainInfo{Domain: "example.org"}
+	if err := enrichVirusTotal(unknown, config); err != nil || unknown.VirusTotal != nil {
+		t.Errorf("Unknown domain: report %+v, err %v; expected none", unknown.VirusTotal, err)
+	}
+
+	config.APIKeys["virustotal"] = "wrong"
+	if err := enrichVirusTotal(info, config); err == nil {
+		t.Error("Expected an error with a bad API key")
+	}
+}
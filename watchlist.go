@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// newGTLDFeedURL is ICANN's list of new gTLDs with their delegation dates
+var newGTLDFeedURL = "https://newgtlds.icann.org/newgtlds.csv"
+
+// watchlistLaunchWindow is how recently a TLD must have been delegated to
+// be watched when the watchlist starts: sunrise and landrush run in the
+// first months after delegation
+const watchlistLaunchWindow = 90 * 24 * time.Hour
+
+// watchlistBucket holds a watchedTLD per TLD seen in the feed
+var watchlistBucket = []byte("watchlist")
+
+// delegatedTLD is one delegated TLD of the feed
+type delegatedTLD struct {
+	TLD       string
+	Delegated time.Time
+}
+
+// watchedTLD is the watchlist state of a TLD. Watched TLDs are added to
+// the scan set of every monitor cycle.
+type watchedTLD struct {
+	Delegated time.Time `json:"delegated,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	Watched   bool      `json:"watched"`
+}
+
+// feedDateLayouts are the date formats seen in the feed
+var feedDateLayouts = []string{"2006-01-02", "1/2/2006", time.RFC3339}
+
+// fetchDelegatedTLDs downloads the new gTLD feed
+func fetchDelegatedTLDs(url string) ([]delegatedTLD, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return parseDelegatedTLDs(resp.Body)
+}
+
+// parseDelegatedTLDs reads the feed CSV, finding its columns by header:
+// the ASCII TLD, the delegation date and the contract termination date.
+// TLDs not delegated yet or whose contract was terminated are left out.
+func parseDelegatedTLDs(r io.Reader) ([]delegatedTLD, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed header: %w", err)
+	}
+	tldCol, delegatedCol, terminatedCol := -1, -1, -1
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case strings.Contains(name, "ascii") && tldCol < 0:
+			tldCol = i
+		case strings.Contains(name, "delegation date"):
+			delegatedCol = i
+		case strings.Contains(name, "termination date"):
+			terminatedCol = i
+		}
+	}
+	if tldCol < 0 || delegatedCol < 0 {
+		return nil, fmt.Errorf("feed header lacks the TLD and delegation date columns")
+	}
+
+	var tlds []delegatedTLD
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read feed: %w", err)
+		}
+		if len(record) <= tldCol || len(record) <= delegatedCol {
+			continue
+		}
+		if terminatedCol >= 0 && terminatedCol < len(record) && strings.TrimSpace(record[terminatedCol]) != "" {
+			continue
+		}
+		delegated, ok := parseFeedDate(record[delegatedCol])
+		if !ok {
+			continue
+		}
+		tld, ok := normalizeTLD(record[tldCol])
+		if !ok {
+			continue
+		}
+		tlds = append(tlds, delegatedTLD{TLD: strings.TrimPrefix(tld, "."), Delegated: delegated})
+	}
+	if len(tlds) == 0 {
+		return nil, fmt.Errorf("no delegated TLDs in feed")
+	}
+	return tlds, nil
+}
+
+func parseFeedDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// updateWatchlist merges the feed into the watchlist. On the first update
+// the feed is the baseline and only TLDs delegated within
+// watchlistLaunchWindow are watched; afterwards every TLD new to the feed
+// is. It returns the TLDs watched from this update on and every watched TLD,
+// both sorted.
+func (h *historyStore) updateWatchlist(feed []delegatedTLD, now time.Time) ([]string, []string, error) {
+	var added, watched []string
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(watchlistBucket)
+		baseline := bucket == nil
+		if baseline {
+			// Without a feed there is no baseline to take yet
+			if len(feed) == 0 {
+				return nil
+			}
+			var err error
+			if bucket, err = tx.CreateBucket(watchlistBucket); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range feed {
+			if bucket.Get([]byte(entry.TLD)) != nil {
+				continue
+			}
+			state := watchedTLD{
+				Delegated: entry.Delegated.UTC(),
+				FirstSeen: now.UTC(),
+				Watched:   !baseline || now.Sub(entry.Delegated) <= watchlistLaunchWindow,
+			}
+			data, err := json.Marshal(state)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(entry.TLD), data); err != nil {
+				return err
+			}
+			if state.Watched {
+				added = append(added, entry.TLD)
+			}
+		}
+
+		return bucket.ForEach(func(k, v []byte) error {
+			var state watchedTLD
+			if err := json.Unmarshal(v, &state); err != nil {
+				return err
+			}
+			if state.Watched {
+				watched = append(watched, string(k))
+			}
+			return nil
+		})
+	})
+	sort.Strings(added)
+	return added, watched, err
+}
+
+// refreshWatchlist fetches the feed and updates the watchlist of the
+// history database, returning the watched TLDs. When the feed cannot be
+// fetched, the TLDs watched so far are kept.
+func refreshWatchlist(config Config) ([]string, error) {
+	store, err := openHistory(config.HistoryDB)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	feed, err := fetchDelegatedTLDs(config.WatchlistURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[WARNING]%s Failed to fetch the new gTLD feed, keeping the current watchlist: %v\n", ColorYellow, ColorReset, err)
+		feed = nil
+	}
+	added, watched, err := store.updateWatchlist(feed, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update the watchlist: %w", err)
+	}
+	for _, tld := range added {
+		fmt.Printf("%s[INFO]%s Watching newly delegated TLD .%s\n", ColorBlue, ColorReset, tld)
+	}
+	return watched, nil
+}
+
+// withWatchedTLDs appends the watched TLDs missing from tlds
+func withWatchedTLDs(tlds, watched []string) []string {
+	present := make(map[string]bool, len(tlds))
+	for _, tld := range tlds {
+		present[tld] = true
+	}
+	for _, tld := range watched {
+		if !present["."+tld] {
+			present["."+tld] = true
+			tlds = append(tlds, "."+tld)
+		}
+	}
+	return tlds
+}
+
+// launchAlerts flags domains under watched TLDs that are registered for the
+// first time: the brand has been taken in a launching TLD
+func launchAlerts(store *historyStore, domains []DomainInfo, watched []string) ([]Alert, error) {
+	isWatched := make(map[string]bool, len(watched))
+	for _, tld := range watched {
+		isWatched[tld] = true
+	}
+
+	var alerts []Alert
+	for _, info := range domains {
+		if info.Error != "" || !isWatched[lastLabel(info.Domain)] {
+			continue
+		}
+		previous, err := store.latest(info.Domain)
+		if err != nil {
+			return nil, err
+		}
+		if previous == nil {
+			alerts = append(alerts, Alert{Kind: AlertTLDLaunch, Domain: info.Domain})
+		}
+	}
+	return alerts, nil
+}
+
+// validateWatchlist checks the watchlist options
+func validateWatchlist(config Config) error {
+	if !config.Watchlist {
+		return nil
+	}
+	if !config.Monitor {
+		return fmt.Errorf("-watchlist requires -monitor")
+	}
+	if config.DomainsFile != "" {
+		return fmt.Errorf("-watchlist cannot be combined with -domains-file")
+	}
+	return nil
+}
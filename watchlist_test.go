@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const newGTLDFeed = `# New gTLD delegations, generated 2026-10-16
+"Top Level Domain in Unicode","Top Level Domain in ASCII","Registry Operator","Date Contract Signed","Delegation Date","Contract Termination Date"
+"shop","shop","GMO Registry, Inc.","2016-05-12","2016-06-01",""
+"москва","xn--80adxhks","Foundation for Assistance","2014-04-24","2014-04-25",""
+"brandnew","brandnew","Example Registry","2026-08-20","2026-09-10",""
+"pending","pending","Example Registry","2026-09-01","",""
+"gone","gone","Example Registry","2015-01-01","2015-02-01","2019-03-01"
+`
+
+func TestParseDelegatedTLDs(t *testing.T) {
+	tlds, err := parseDelegatedTLDs(strings.NewReader(newGTLDFeed))
+	if err != nil {
+		t.Fatalf("parseDelegatedTLDs failed: %v", err)
+	}
+	expected := []delegatedTLD{
+		{TLD: "shop", Delegated: time.Date(2016, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{TLD: "xn--80adxhks", Delegated: time.Date(2014, 4, 25, 0, 0, 0, 0, time.UTC)},
+		{TLD: "brandnew", Delegated: time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(tlds, expected) {
+		t.Errorf("parseDelegatedTLDs() = %+v, expected %+v", tlds, expected)
+	}
+
+	for _, feed := range []string{"", "tld,operator\nshop,GMO\n", newGTLDFeed[:strings.Index(newGTLDFeed, "\"shop\"")]} {
+		if _, err := parseDelegatedTLDs(strings.NewReader(feed)); err == nil {
+			t.Errorf("Expected an error for feed %q", feed)
+		}
+	}
+}
+
+func TestUpdateWatchlist(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+	feed, _ := parseDelegatedTLDs(strings.NewReader(newGTLDFeed))
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	// A failed fetch before the first update takes no baseline
+	if added, watched, err := store.updateWatchlist(nil, now); err != nil || added != nil || watched != nil {
+		t.Fatalf("Expected nothing without a feed, got %v %v: %v", added, watched, err)
+	}
+
+	// The baseline watches only TLDs still in their launch window
+	added, watched, err := store.updateWatchlist(feed, now)
+	if err != nil || !reflect.DeepEqual(added, []string{"brandnew"}) || !reflect.DeepEqual(watched, []string{"brandnew"}) {
+		t.Fatalf("Expected the baseline to watch brandnew, got %v %v: %v", added, watched, err)
+	}
+
+	// Later, every TLD new to the feed is watched, however old its delegation
+	feed = append(feed, delegatedTLD{TLD: "later", Delegated: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)})
+	added, watched, err = store.updateWatchlist(feed, now.Add(24*time.Hour))
+	if err != nil || !reflect.DeepEqual(added, []string{"later"}) || !reflect.DeepEqual(watched, []string{"brandnew", "later"}) {
+		t.Errorf("Expected later to be added, got %v %v: %v", added, watched, err)
+	}
+
+	// A failed fetch keeps the watchlist
+	if added, watched, err = store.updateWatchlist(nil, now.Add(48*time.Hour)); err != nil || added != nil || len(watched) != 2 {
+		t.Errorf("Expected the watchlist to be kept, got %v %v: %v", added, watched, err)
+	}
+}
+
+func TestWithWatchedTLDs(t *testing.T) {
+	got := withWatchedTLDs([]string{".com", ".shop"}, []string{"shop", "brandnew"})
+	if !reflect.DeepEqual(got, []string{".com", ".shop", ".brandnew"}) {
+		t.Errorf("withWatchedTLDs() = %v", got)
+	}
+}
+
+func TestLaunchAlerts(t *testing.T) {
+	store, err := openHistory(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("openHistory failed: %v", err)
+	}
+	defer store.Close()
+	store.record(Result{TargetDomain: "example.com"}, []DomainInfo{{Domain: "example.later", Organization: "Example Corp"}}, time.Now())
+
+	domains := []DomainInfo{
+		{Domain: "example.brandnew", Organization: "Someone Else"},
+		{Domain: "example.later", Organization: "Example Corp"},
+		{Domain: "example.pending", Error: "no match", ErrorCode: ErrNXDomain},
+		{Domain: "example.shop", Organization: "Someone Else"},
+	}
+	alerts, err := launchAlerts(store, domains, []string{"brandnew", "later", "pending"})
+	if err != nil {
+		t.Fatalf("launchAlerts failed: %v", err)
+	}
+	expected := []Alert{{Kind: AlertTLDLaunch, Domain: "example.brandnew"}}
+	if !reflect.DeepEqual(alerts, expected) {
+		t.Errorf("launchAlerts() = %+v, expected %+v", alerts, expected)
+	}
+	if got := alerts[0].String(); got != "example.brandnew registered under newly delegated .brandnew" {
+		t.Errorf("Unexpected alert text %q", got)
+	}
+}
+
+func TestValidateWatchlist(t *testing.T) {
+	if err := validateWatchlist(Config{Watchlist: true}); err == nil {
+		t.Error("Expected -watchlist to require -monitor")
+	}
+	if err := validateWatchlist(Config{Watchlist: true, Monitor: true, DomainsFile: "domains.txt"}); err == nil {
+		t.Error("Expected -watchlist to reject -domains-file")
+	}
+	if err := validateWatchlist(Config{Watchlist: true, Monitor: true}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/likexian/whois"
+	"golang.org/x/net/proxy"
+)
+
+// ianaWhoisServer is queried to discover the authoritative server of a TLD
+const ianaWhoisServer = "whois.iana.org"
+
+// knownWhoisServers are registry WHOIS servers tried when the server listed
+// by IANA fails or IANA itself cannot be reached
+var knownWhoisServers = map[string][]string{
+	"com": {"whois.verisign-grs.com"},
+	"net": {"whois.verisign-grs.com"},
+	"org": {"whois.publicinterestregistry.org", "whois.pir.org"},
+	"io":  {"whois.nic.io"},
+	"co":  {"whois.nic.co"},
+	"uk":  {"whois.nic.uk"},
+	"de":  {"whois.denic.de"},
+	"eu":  {"whois.eu"},
+	"fr":  {"whois.nic.fr"},
+	"nl":  {"whois.domain-registry.nl"},
+	"au":  {"whois.auda.org.au"},
+	"ca":  {"whois.cira.ca"},
+	"jp":  {"whois.jprs.jp"},
+	"cn":  {"whois.cnnic.cn"},
+	"ru":  {"whois.tcinet.ru"},
+}
+
+var (
+	ianaServerPattern     = regexp.MustCompile(`(?im)^\s*whois:\s*(\S+)`)
+	referralServerPattern = regexp.MustCompile(`(?im)^\s*(?:Registrar WHOIS Server|ReferralServer|Whois Server):\s*(?:r?whois://)?([a-z0-9.-]+\.[a-z]{2,})`)
+)
+
+// ianaServers caches the WHOIS server IANA lists for each TLD
+var ianaServers sync.Map
+
+// WhoisClient sends a WHOIS query to a server and returns the raw response.
+// *whois.Client implements it; Config.WhoisClient replaces it, e.g. with the
+// in-process mock server the tests use.
+type WhoisClient interface {
+	Whois(query string, servers ...string) (string, error)
+}
+
+// newWhoisClient returns the injected Config.WhoisClient, or a WHOIS client
+// honoring the configured timeout and source addresses, or connecting
+// through Tor with -tor. Referrals are followed by queryWhois itself so the
+// answering server is known.
+func newWhoisClient(config Config) WhoisClient {
+	if config.WhoisClient != nil {
+		return config.WhoisClient
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	client := whois.NewClient()
+	if timeout > 0 {
+		client.SetTimeout(timeout)
+	}
+	var dialer proxy.Dialer = newDialer(config.SourceIPs, timeout)
+	if config.TorDialer != nil {
+		dialer = config.TorDialer
+	}
+	if config.Trace.debugging() {
+		dialer = &tracingDialer{dialer: dialer, trace: config.Trace}
+	}
+	client.SetDialer(dialer)
+	client.SetDisableReferral(true)
+	return client
+}
+
+// lastLabel returns the top-level label of domain, e.g. "uk" for "example.co.uk"
+func lastLabel(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if i := strings.LastIndex(domain, "."); i >= 0 {
+		return domain[i+1:]
+	}
+	return domain
+}
+
+// lookupIANAServer asks IANA for the WHOIS server of a TLD, caching the answer
+func lookupIANAServer(client WhoisClient, tld string, budget *queryBudget) string {
+	if server, ok := ianaServers.Load(tld); ok {
+		return server.(string)
+	}
+	if !budget.take(ianaWhoisServer) {
+		return ""
+	}
+	raw, err := client.Whois(tld, ianaWhoisServer)
+	if err != nil {
+		return ""
+	}
+	server := parseIANAServer(raw)
+	ianaServers.Store(tld, server)
+	return server
+}
+
+// parseIANAServer extracts the "whois:" server from an IANA TLD record
+func parseIANAServer(raw string) string {
+	if m := ianaServerPattern.FindStringSubmatch(raw); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// parseReferralServer extracts the registrar WHOIS server a thin registry
+// response refers to
+func parseReferralServer(raw string) string {
+	if m := referralServerPattern.FindStringSubmatch(raw); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
+
+// whoisServerCandidates returns the servers to try for a TLD in order: the
+// IANA-listed server, known alternates and the common whois.nic.<tld> name
+func whoisServerCandidates(tld, ianaServer string) []string {
+	var servers []string
+	add := func(server string) {
+		if server != "" && !containsString(servers, server) {
+			servers = append(servers, server)
+		}
+	}
+	add(ianaServer)
+	for _, server := range knownWhoisServers[tld] {
+		add(server)
+	}
+	add("whois.nic." + tld)
+	return servers
+}
+
+// queryWhois performs a WHOIS lookup with server failover: each candidate
+// server is tried until one answers. If the answer refers to a registrar
+// WHOIS server, that server is queried as well and its response appended.
+// Registry servers are sent query, which may carry options around the
+// domain; registrar servers are sent the bare domain. It returns the raw
+// response and the server that ultimately answered.
+// Each query is charged to budget; none is sent once it is exhausted. The
+// servers chosen and failover decisions are logged to trace.
+func queryWhois(client WhoisClient, domain, query string, budget *queryBudget, trace *lookupTrace) (string, string, error) {
+	tld := lastLabel(domain)
+	candidates := whoisServerCandidates(tld, lookupIANAServer(client, tld, budget))
+	trace.logf("WHOIS servers for .%s: %s", tld, strings.Join(candidates, ", "))
+
+	var errs []error
+	for _, server := range candidates {
+		if !budget.take(tld) {
+			trace.logf("query budget exhausted before asking %s", server)
+			errs = append(errs, fmt.Errorf("%s: %w", server, errQueryBudget))
+			break
+		}
+		trace.logf("querying %s for %q", server, query)
+		raw, err := client.Whois(query, server)
+		if err != nil {
+			trace.logf("%s failed, trying the next server: %v", server, err)
+			errs = append(errs, fmt.Errorf("%s: %w", server, err))
+			continue
+		}
+
+		referral := parseReferralServer(raw)
+		if referral == "" || referral == server {
+			return raw, server, nil
+		}
+		if !budget.take(referral) {
+			trace.logf("not following the referral to %s: query budget exhausted", referral)
+			return raw, server, nil
+		}
+		trace.logf("following the referral to %s", referral)
+		referred, err := client.Whois(domain, referral)
+		if err != nil || strings.TrimSpace(referred) == "" {
+			trace.logf("referral to %s gave no answer, keeping the registry response: %v", referral, err)
+			return raw, server, nil
+		}
+		return raw + "\n" + referred, referral, nil
+	}
+
+	if len(errs) == 0 {
+		return "", "", fmt.Errorf("%w: %s", whois.ErrWhoisServerNotFound, domain)
+	}
+	return "", "", errors.Join(errs...)
+}
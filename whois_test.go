@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIANAServer(t *testing.T) {
+	raw := "% IANA WHOIS server\n\ndomain:       COM\n\nwhois:        whois.verisign-grs.com\n\nstatus:       ACTIVE\n"
+	if server := parseIANAServer(raw); server != "whois.verisign-grs.com" {
+		t.Errorf("parseIANAServer() = %q; expected whois.verisign-grs.com", server)
+	}
+	if server := parseIANAServer("domain: EXAMPLE\n"); server != "" {
+		t.Errorf("parseIANAServer() = %q; expected empty", server)
+	}
+}
+
+func TestParseReferralServer(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"   Domain Name: EXAMPLE.COM\n   Registrar WHOIS Server: whois.markmonitor.com\n", "whois.markmonitor.com"},
+		{"ReferralServer: rwhois://rwhois.example.net:4321\n", "rwhois.example.net"},
+		{"Registrar WHOIS Server: \n", ""},
+		{"Domain Name: EXAMPLE.DE\n", ""},
+	}
+
+	for _, test := range tests {
+		if server := parseReferralServer(test.raw); server != test.expected {
+			t.Errorf("parseReferralServer(%q) = %q; expected %q", test.raw, server, test.expected)
+		}
+	}
+}
+
+func TestWhoisServerCandidates(t *testing.T) {
+	candidates := whoisServerCandidates("org", "whois.publicinterestregistry.org")
+	expected := []string{"whois.publicinterestregistry.org", "whois.pir.org", "whois.nic.org"}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("whoisServerCandidates() = %v; expected %v", candidates, expected)
+	}
+
+	candidates = whoisServerCandidates("shop", "")
+	if !reflect.DeepEqual(candidates, []string{"whois.nic.shop"}) {
+		t.Errorf("whoisServerCandidates() without IANA = %v", candidates)
+	}
+}
+
+func TestLastLabel(t *testing.T) {
+	if label := lastLabel("example.co.uk"); label != "uk" {
+		t.Errorf("lastLabel() = %q; expected uk", label)
+	}
+	if label := lastLabel("COM."); label != "com" {
+		t.Errorf("lastLabel() = %q; expected com", label)
+	}
+}
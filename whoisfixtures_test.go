@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// updateFixtures rewrites the expected outputs of the WHOIS fixtures from
+// the current parser instead of comparing them:
+//
+//	go test -run TestWhoisFixtures -update-fixtures
+var updateFixtures = flag.Bool("update-fixtures", false, "rewrite the expected outputs of the testdata/whois fixtures")
+
+// A recorded response, testdata/whois/<server>/<domain>.txt, is a parser
+// fixture when the record expected from parsing it, <domain>.json, sits
+// next to it. The responses are real registry and registrar answers
+// anonymized to the Example brand; mockWhoisServer serves the same files.
+
+// fixtureTarget is the organization every fixture is matched against
+const fixtureTarget = "Example Corp"
+
+// fixtureRecord is the expected outcome of a fixture: the fields parsing
+// and its fallbacks fill in, and the match against fixtureTarget
+type fixtureRecord struct {
+	Domain       string   `json:"domain"`
+	Source       string   `json:"source,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	Registrar    string   `json:"registrar,omitempty"`
+	CreatedDate  string   `json:"created_date,omitempty"`
+	ExpiryDate   string   `json:"expiry_date,omitempty"`
+	CreatedAt    string   `json:"created_at,omitempty"`
+	ExpiresAt    string   `json:"expires_at,omitempty"`
+	Status       string   `json:"status,omitempty"`
+	NameServers  []string `json:"name_servers,omitempty"`
+	Emails       []string `json:"emails,omitempty"`
+	AbuseEmail   string   `json:"abuse_email,omitempty"`
+	AbusePhone   string   `json:"abuse_phone,omitempty"`
+	MatchReason  string   `json:"match_reason,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// whoisFixture is a recorded response with an expected record
+type whoisFixture struct {
+	server, domain string
+}
+
+// path returns the path of the fixture's file with the given extension
+func (f whoisFixture) path(ext string) string {
+	return filepath.Join("testdata", "whois", f.server, f.domain+ext)
+}
+
+// whoisFixtures returns the recorded responses that have an expected record
+func whoisFixtures(tb testing.TB) []whoisFixture {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "whois", "*", "*.json"))
+	if err != nil || len(paths) == 0 {
+		tb.Fatalf("No WHOIS fixtures in testdata/whois: %v", err)
+	}
+	fixtures := make([]whoisFixture, len(paths))
+	for i, path := range paths {
+		fixtures[i] = whoisFixture{
+			server: filepath.Base(filepath.Dir(path)),
+			domain: strings.TrimSuffix(filepath.Base(path), ".json"),
+		}
+	}
+	return fixtures
+}
+
+// replayFixture parses a recorded response the way a live lookup from its
+// server would
+func replayFixture(fixture whoisFixture, raw string) fixtureRecord {
+	config := Config{}
+	domain := fixture.domain
+	record := fixtureRecord{Domain: domain}
+	info, err := parseWhoisResponse(domain, raw, fixture.server, config)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+	record.Source = info.Source
+	record.Organization = info.Organization
+	record.Country = info.Country
+	record.Registrar = info.Registrar
+	record.CreatedDate = info.CreatedDate
+	record.ExpiryDate = info.ExpiryDate
+	normalizeDates(info)
+	if info.CreatedAt != nil {
+		record.CreatedAt = info.CreatedAt.Format(time.RFC3339)
+	}
+	if info.ExpiresAt != nil {
+		record.ExpiresAt = info.ExpiresAt.Format(time.RFC3339)
+	}
+	record.Status = info.Status
+	record.NameServers = info.NameServers
+	record.Emails = info.Emails
+	record.AbuseEmail = info.AbuseEmail
+	record.AbusePhone = info.AbusePhone
+	record.MatchReason = orgMatchReason(info.Organization, fixtureTarget, config)
+	return record
+}
+
+func TestWhoisFixtures(t *testing.T) {
+	for _, fixture := range whoisFixtures(t) {
+		fixture := fixture
+		t.Run(fixture.server+"/"+fixture.domain, func(t *testing.T) {
+			raw, err := os.ReadFile(fixture.path(".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(replayFixture(fixture, string(raw)), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			expectedPath := fixture.path(".json")
+			if *updateFixtures {
+				if err := os.WriteFile(expectedPath, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatalf("No expected record (run with -update-fixtures to record it): %v", err)
+			}
+			if string(got) != string(expected) {
+				t.Errorf("Parsed record changed:\n%s\nexpected:\n%s", got, expected)
+			}
+		})
+	}
+}
+
+func BenchmarkWhoisFixtures(b *testing.B) {
+	raws := make(map[whoisFixture]string)
+	for _, fixture := range whoisFixtures(b) {
+		raw, err := os.ReadFile(fixture.path(".txt"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		raws[fixture] = string(raw)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for fixture, raw := range raws {
+			replayFixture(fixture, raw)
+		}
+	}
+}
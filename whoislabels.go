@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultWhoisLabels translate the field labels of registries answering in
+// a local language, or with labels the parser files under the wrong field,
+// to English labels it knows, by TLD. A label is matched at the start of a
+// line, bare ("Titular:") or in brackets ("[登録者名]").
+var defaultWhoisLabels = map[string]map[string]string{
+	// JPRS names the holding organization "Registrant" (or "Organization"
+	// under co.jp), which the parser takes for a person's name
+	"jp": {
+		"Registrant":   "Registrant Organization",
+		"Organization": "Registrant Organization",
+		"ドメイン名":        "Domain Name",
+		"登録者名":         "Registrant Organization",
+		"組織名":          "Registrant Organization",
+		"ネームサーバ":       "Name Server",
+		"登録年月日":        "Created on",
+		"有効期限":         "Expires on",
+		"状態":           "Status",
+		"最終更新":         "Last Updated",
+	},
+	// KISA answers in Korean first, English after "# ENGLISH"
+	"kr": {
+		"Registrant": "Registrant Organization",
+		"도메인이름":      "Domain Name",
+		"등록인":        "Registrant Organization",
+		"등록대행자":      "Registrar Name",
+		"등록일":        "Registered Date",
+		"최근 정보 변경일":  "Last Updated Date",
+		"사용 종료일":     "Expiration Date",
+		"호스트이름":      "Host Name",
+	},
+}
+
+// whoisLabelLanguages are the label tables shared by the registries that
+// answer in the same language
+var whoisLabelLanguages = []struct {
+	tlds   []string
+	labels map[string]string
+}{
+	{
+		// Spanish
+		tlds: []string{"ar", "bo", "cl", "co", "cr", "cu", "do", "ec", "es", "gt", "hn", "mx", "ni", "pa", "pe", "py", "sv", "uy", "ve"},
+		labels: map[string]string{
+			"Titular":              "Registrant Organization",
+			"Nombre del titular":   "Registrant Organization",
+			"Registrante":          "Registrant Organization",
+			"Organización":         "Registrant Organization",
+			"Agente registrador":   "Registrar Name",
+			"Registrador":          "Registrar Name",
+			"Fecha de creación":    "Creation Date",
+			"Fecha de registro":    "Creation Date",
+			"Fecha de alta":        "Creation Date",
+			"Fecha de expiración":  "Expiration Date",
+			"Fecha de vencimiento": "Expiration Date",
+			"Fecha de caducidad":   "Expiration Date",
+			"Servidores de nombre": "Name Servers",
+			"Servidor de nombres":  "Name Server",
+		},
+	},
+	{
+		// Portuguese
+		tlds: []string{"ao", "br", "cv", "mz", "pt", "st", "tl"},
+		labels: map[string]string{
+			"Titular":           "Registrant Organization",
+			"Nome do titular":   "Registrant Organization",
+			"Entidade gestora":  "Registrar Name",
+			"Data de criação":   "Creation Date",
+			"Data de registo":   "Creation Date",
+			"Data de registro":  "Creation Date",
+			"Data de expiração": "Expiration Date",
+			"Data de validade":  "Expiration Date",
+			"Servidor de nomes": "Name Server",
+		},
+	},
+	{
+		// French
+		tlds: []string{"bf", "bj", "cd", "cg", "ci", "cm", "dz", "ga", "gn", "ht", "ma", "mg", "ml", "ne", "sn", "td", "tg", "tn"},
+		labels: map[string]string{
+			"Titulaire":               "Registrant Organization",
+			"Nom du titulaire":        "Registrant Organization",
+			"Bureau d'enregistrement": "Registrar Name",
+			"Registraire":             "Registrar Name",
+			"Date de création":        "Creation Date",
+			"Date d'enregistrement":   "Creation Date",
+			"Date d'expiration":       "Expiration Date",
+			"Serveurs de noms":        "Name Servers",
+			"Serveur de noms":         "Name Server",
+			"Statut":                  "Status",
+		},
+	},
+}
+
+// labelTable rewrites the labels of one registry's WHOIS text
+type labelTable struct {
+	pattern *regexp.Regexp
+	english map[string]string // by lowercased local label
+}
+
+// whoisLabels are the label translations by TLD
+type whoisLabels map[string]*labelTable
+
+// builtinWhoisLabels are the compiled default translations
+var builtinWhoisLabels, _ = newWhoisLabels(nil)
+
+// newWhoisLabels merges the configured label translations over the
+// defaults, label by label
+func newWhoisLabels(configured map[string]map[string]string) (whoisLabels, error) {
+	merged := make(map[string]map[string]string)
+	add := func(tld string, labels map[string]string) {
+		if merged[tld] == nil {
+			merged[tld] = make(map[string]string)
+		}
+		for local, english := range labels {
+			merged[tld][local] = english
+		}
+	}
+	for tld, labels := range defaultWhoisLabels {
+		add(tld, labels)
+	}
+	for _, language := range whoisLabelLanguages {
+		for _, tld := range language.tlds {
+			add(tld, language.labels)
+		}
+	}
+	for tld, labels := range configured {
+		tld = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tld), "."))
+		if tld == "" {
+			return nil, fmt.Errorf("whois_labels: empty TLD")
+		}
+		for local, english := range labels {
+			if strings.TrimSpace(local) == "" || strings.TrimSpace(english) == "" {
+				return nil, fmt.Errorf("whois_labels.%s: empty label", tld)
+			}
+		}
+		add(tld, labels)
+	}
+
+	translations := whoisLabels{}
+	for tld, labels := range merged {
+		translations[tld] = newLabelTable(labels)
+	}
+	return translations, nil
+}
+
+// newLabelTable compiles the labels into one pattern, longest first so
+// "Nombre del titular" is not taken for a "Titular" line
+func newLabelTable(labels map[string]string) *labelTable {
+	table := &labelTable{english: make(map[string]string)}
+	var alternatives []string
+	for local, english := range labels {
+		local = strings.TrimSpace(local)
+		table.english[strings.ToLower(local)] = strings.TrimSpace(english)
+		alternatives = append(alternatives, regexp.QuoteMeta(local))
+	}
+	sort.Slice(alternatives, func(i, j int) bool {
+		if len(alternatives[i]) != len(alternatives[j]) {
+			return len(alternatives[i]) > len(alternatives[j])
+		}
+		return alternatives[i] < alternatives[j]
+	})
+	table.pattern = regexp.MustCompile(`(?im)^([ \t]*(?:[a-z]\.[ \t]*)?\[?)(` + strings.Join(alternatives, "|") + `)([ \t]*[\]:])`)
+	return table
+}
+
+// translate rewrites the local labels of a domain's WHOIS text and returns
+// how many it rewrote. Nil labels use the defaults.
+func (l whoisLabels) translate(domain, raw string) (string, int) {
+	if l == nil {
+		l = builtinWhoisLabels
+	}
+	table, ok := l[lastLabel(domain)]
+	if !ok {
+		return raw, 0
+	}
+	count := 0
+	translated := table.pattern.ReplaceAllStringFunc(raw, func(line string) string {
+		m := table.pattern.FindStringSubmatch(line)
+		count++
+		return m[1] + table.english[strings.ToLower(m[2])] + m[3]
+	})
+	return translated, count
+}
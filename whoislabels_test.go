@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	whoisparser "github.com/likexian/whois-parser"
+)
+
+func TestWhoisLabelsJPRS(t *testing.T) {
+	raw := strings.Join([]string{
+		"Domain Information:",
+		"[Domain Name]                   GOOGLE.JP",
+		"",
+		"[Registrant]                    Google Inc.",
+		"",
+		"[Name Server]                   ns1.google.com",
+		"[Created on]                    2005/05/30",
+		"[Expires on]                    2018/05/31",
+		"[Status]                        Active",
+	}, "\n")
+	var labels whoisLabels
+	translated, n := labels.translate("google.jp", raw)
+	if n != 1 {
+		t.Fatalf("Expected 1 translated label, got %d:\n%s", n, translated)
+	}
+	result, err := whoisparser.Parse(translated)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if info := domainInfoFromWhois("google.jp", result); info.Organization != "Google Inc." {
+		t.Errorf("Expected the registrant as organization, got %q", info.Organization)
+	}
+}
+
+func TestWhoisLabelsJapanese(t *testing.T) {
+	raw := strings.Join([]string{
+		"Domain Information: [ドメイン情報]",
+		"a. [ドメイン名]                 EXAMPLE.CO.JP",
+		"g. [組織名]                     株式会社エクサンプル",
+		"l. [組織種別]                   株式会社",
+		"p. [ネームサーバ]               ns1.example.co.jp",
+		"[状態]                          Connected (2025/03/31)",
+		"[登録年月日]                    2001/01/01",
+	}, "\n")
+	translated, n := whoisLabels(nil).translate("example.co.jp", raw)
+	if n != 5 {
+		t.Errorf("Expected 5 translated labels, got %d:\n%s", n, translated)
+	}
+	for _, line := range []string{"g. [Registrant Organization]", "l. [組織種別]", "p. [Name Server]", "[Created on]"} {
+		if !strings.Contains(translated, line) {
+			t.Errorf("Expected %q in:\n%s", line, translated)
+		}
+	}
+}
+
+func TestWhoisLabelsSpanish(t *testing.T) {
+	raw := "Nombre de dominio: ejemplo.cl\nTitular: Ejemplo S.A.\nAgente Registrador: NIC Chile\nFecha de creación: 2001-01-01\nFecha de expiración: 2030-01-01\nServidores de nombre: ns1.ejemplo.cl\n"
+	translated, _ := whoisLabels(nil).translate("ejemplo.cl", raw)
+	info, ok := extractWhoisFields("ejemplo.cl", translated, nil)
+	if !ok {
+		t.Fatalf("Nothing extracted from:\n%s", translated)
+	}
+	if info.Organization != "Ejemplo S.A." || info.Registrar != "NIC Chile" || info.CreatedDate != "2001-01-01" || info.ExpiryDate != "2030-01-01" {
+		t.Errorf("Unexpected fields %+v from:\n%s", info, translated)
+	}
+
+	// Other registries keep their labels
+	if _, n := whoisLabels(nil).translate("ejemplo.com", raw); n != 0 {
+		t.Errorf("Expected no translation for .com, got %d labels", n)
+	}
+}
+
+func TestNewWhoisLabels(t *testing.T) {
+	labels, err := newWhoisLabels(map[string]map[string]string{".IS": {"Eigandi": "Registrant Organization"}, "jp": {"Registrant": "Registrant Name"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if translated, _ := labels.translate("example.is", "eigandi: Dæmi ehf."); translated != "Registrant Organization: Dæmi ehf." {
+		t.Errorf("Configured label not translated: %q", translated)
+	}
+	if translated, _ := labels.translate("example.jp", "[Registrant] Example"); translated != "[Registrant Name] Example" {
+		t.Errorf("Configured label should override the default: %q", translated)
+	}
+	if translated, _ := labels.translate("example.jp", "[有効期限] 2030/01/01"); translated != "[Expires on] 2030/01/01" {
+		t.Errorf("Default labels of a configured TLD should be kept: %q", translated)
+	}
+	if _, err := newWhoisLabels(map[string]map[string]string{"": {"a": "b"}}); err == nil {
+		t.Error("Expected an error for an empty TLD")
+	}
+}
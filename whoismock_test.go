@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockWhoisServer is an in-process WHOIS server answering from the fixtures
+// in testdata/whois, where <server>/<query>.txt is the response a server
+// gives to a query, the same recordings TestWhoisFixtures replays. Servers without fixtures refuse the connection and
+// unknown queries get a registry "No match" answer. It is injected with
+// Config.WhoisClient, so lookups send no network traffic.
+type mockWhoisServer struct {
+	dir string
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// newMockWhoisServer serves the testdata/whois fixtures and clears the
+// IANA server cache so every test discovers servers from them
+func newMockWhoisServer(t *testing.T) *mockWhoisServer {
+	t.Helper()
+	clearIANAServers := func() {
+		ianaServers.Range(func(key, _ interface{}) bool {
+			ianaServers.Delete(key)
+			return true
+		})
+	}
+	clearIANAServers()
+	t.Cleanup(clearIANAServers)
+	return &mockWhoisServer{dir: filepath.Join("testdata", "whois")}
+}
+
+// Whois implements WhoisClient
+func (m *mockWhoisServer) Whois(query string, servers ...string) (string, error) {
+	if len(servers) == 0 {
+		return "", fmt.Errorf("no WHOIS server for %s", query)
+	}
+	server := strings.ToLower(servers[0])
+	query = strings.ToLower(strings.TrimSpace(query))
+	m.mu.Lock()
+	m.queries = append(m.queries, server+" "+query)
+	m.mu.Unlock()
+
+	if _, err := os.Stat(filepath.Join(m.dir, server)); err != nil {
+		return "", fmt.Errorf("dial tcp %s:43: connection refused", server)
+	}
+	data, err := os.ReadFile(filepath.Join(m.dir, server, query+".txt"))
+	if os.IsNotExist(err) {
+		return fmt.Sprintf("No match for %q.\n>>> Last update of whois database: 2026-10-16T10:00:00Z <<<\n", strings.ToUpper(query)), nil
+	}
+	return string(data), err
+}
+
+// sent returns the "<server> <query>" pairs received, sorted
+func (m *mockWhoisServer) sent() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queries := append([]string{}, m.queries...)
+	sort.Strings(queries)
+	return queries
+}
+
+func TestQueryWhoisWithMockServer(t *testing.T) {
+	mock := newMockWhoisServer(t)
+
+	// The thin registry refers to the registrar, whose answer is appended
+	raw, server, err := queryWhois(mock, "example.com", "example.com", nil, nil)
+	if err != nil || server != "whois.markmonitor.com" || !strings.Contains(raw, "Registrant Organization: Example Corp") {
+		t.Fatalf("Expected the registrar answer, got %s: %v", server, err)
+	}
+	// A referral to a server that refuses keeps the registry answer
+	if _, server, err := queryWhois(mock, "example.io", "example.io", nil, nil); err != nil || server != "whois.nic.io" {
+		t.Errorf("Expected the registry answer, got %s: %v", server, err)
+	}
+	// No IANA server for .org: the known registry servers are tried
+	if _, server, err := queryWhois(mock, "example.org", "example.org", nil, nil); err != nil || server != "whois.publicinterestregistry.org" {
+		t.Errorf("Expected the known registry server's answer, got %s: %v", server, err)
+	}
+	// No IANA server and no fixtures for .dev: every candidate fails
+	if _, _, err := queryWhois(mock, "example.dev", "example.dev", nil, nil); err == nil {
+		t.Error("Expected an error when every server refuses")
+	}
+
+	expected := []string{
+		"whois.gandi.net example.io",
+		"whois.iana.org com",
+		"whois.iana.org dev",
+		"whois.iana.org io",
+		"whois.iana.org org",
+		"whois.markmonitor.com example.com",
+		"whois.nic.dev example.dev",
+		"whois.nic.io example.io",
+		"whois.publicinterestregistry.org example.org",
+		"whois.verisign-grs.com example.com",
+	}
+	if got := mock.sent(); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected queries:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestScanDomainsWithMockServer(t *testing.T) {
+	mock := newMockWhoisServer(t)
+	config := Config{Threads: 4, Format: "json", WhoisClient: mock}
+
+	target, err := getWhoisInfo("example.com", config)
+	if err != nil {
+		t.Fatalf("Target lookup failed: %v", err)
+	}
+	if target.Organization != "Example Corp" || target.WhoisServer != "whois.markmonitor.com" {
+		t.Fatalf("Unexpected target record: %+v", target)
+	}
+
+	all, matching, _, skipped := scanDomains([]string{"example.io", "example.net", "examp1e.io", "example.org", "example.dev"}, target, config)
+	if len(all) != 5 || len(skipped.domains) != 0 {
+		t.Fatalf("Expected 5 results, got %d (%d skipped)", len(all), len(skipped.domains))
+	}
+
+	byDomain := make(map[string]DomainInfo)
+	for _, info := range all {
+		byDomain[info.Domain] = info
+	}
+	if io := byDomain["example.io"]; io.Organization != "Example Corp." || io.Registrar != "Gandi SAS" || io.AbuseEmail != "abuse@support.gandi.net" {
+		t.Errorf("Unexpected example.io record: %+v", io)
+	}
+	if net := byDomain["example.net"]; net.Error != "" || !strings.HasPrefix(net.Organization, "Privacy service") {
+		t.Errorf("Unexpected example.net record: %+v", net)
+	}
+	if code := byDomain["examp1e.io"].ErrorCode; code != ErrNXDomain {
+		t.Errorf("Expected examp1e.io to be unregistered, got %q (%s)", code, byDomain["examp1e.io"].Error)
+	}
+	if byDomain["example.dev"].Error == "" {
+		t.Error("Expected example.dev to fail")
+	}
+
+	if len(matching) != 2 || matching[0].Domain != "example.io" || matching[1].Domain != "example.org" || matching[0].MatchReason == "" {
+		t.Errorf("Expected example.io and example.org to match Example Corp, got %+v", matching)
+	}
+}
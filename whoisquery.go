@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultWhoisQueries are the query strings sent to registries that want
+// options in front of the domain, by TLD. {domain} is replaced with the
+// domain looked up.
+var defaultWhoisQueries = map[string]string{
+	// DENIC answers with the domain record, IDNs in ACE form, for -T dn,ace
+	"de": "-T dn,ace {domain}",
+	// JPRS answers in Japanese unless /e asks for English
+	"jp": "{domain}/e",
+}
+
+// whoisQueries are the query templates by TLD
+type whoisQueries map[string]string
+
+// newWhoisQueries merges the configured query templates over the defaults;
+// a template of just "{domain}" turns a default off
+func newWhoisQueries(configured map[string]string) (whoisQueries, error) {
+	queries := whoisQueries{}
+	for tld, template := range defaultWhoisQueries {
+		queries[tld] = template
+	}
+	for tld, template := range configured {
+		tld = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tld), "."))
+		if tld == "" {
+			return nil, fmt.Errorf("whois_queries: empty TLD")
+		}
+		if !strings.Contains(template, "{domain}") {
+			return nil, fmt.Errorf("whois_queries.%s: %q has no {domain} placeholder", tld, template)
+		}
+		queries[tld] = template
+	}
+	return queries, nil
+}
+
+// query returns the query string to send a registry server for domain. Nil
+// queries use the defaults.
+func (q whoisQueries) query(domain string) string {
+	if q == nil {
+		q = defaultWhoisQueries
+	}
+	template, ok := q[lastLabel(domain)]
+	if !ok {
+		return domain
+	}
+	return strings.ReplaceAll(template, "{domain}", domain)
+}
+
+// defaultWhoisDisclaimers match the first line of the legal notices
+// registries wrap around records. Their free text mentions registrants,
+// registrars and dates, which the raw text patterns would otherwise pick up.
+var defaultWhoisDisclaimers = []string{
+	`NOTICE: The expiration date displayed in this record`,
+	`TERMS OF USE:`,
+	`The Registrar of Record identified in this output`,
+	`For more information on Whois status codes`,
+	`Access to .+ WHOIS information is provided`,
+	`The data in this record is provided by`,
+	`% Restricted rights\.`,
+	`% The WHOIS service offered by`,
+	`This WHOIS information is provided for free by Nominet`,
+	`\[ JPRS database provides information`,
+}
+
+// disclaimerPatterns match the start of disclaimer paragraphs
+type disclaimerPatterns []*regexp.Regexp
+
+// compileDisclaimers compiles the configured disclaimer patterns along with
+// the defaults. Each is matched case-insensitively at the start of a
+// paragraph.
+func compileDisclaimers(extra []string) (disclaimerPatterns, error) {
+	var patterns disclaimerPatterns
+	for _, expr := range append(append([]string{}, extra...), defaultWhoisDisclaimers...) {
+		re, err := regexp.Compile(`(?i)^\s*(?:` + expr + `)`)
+		if err != nil {
+			return nil, fmt.Errorf("whois_disclaimers: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
+
+// stripDisclaimers removes the paragraphs of raw WHOIS text that start like
+// a disclaimer; paragraphs are separated by blank lines. Nil patterns use
+// the defaults.
+func stripDisclaimers(raw string, patterns disclaimerPatterns) string {
+	if patterns == nil {
+		patterns, _ = compileDisclaimers(nil)
+	}
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	var kept, paragraph []string
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		text := strings.Join(paragraph, "\n")
+		for _, re := range patterns {
+			if re.MatchString(text) {
+				paragraph = nil
+				return
+			}
+		}
+		kept = append(kept, paragraph...)
+		paragraph = nil
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			kept = append(kept, line)
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/likexian/whois"
+)
+
+func TestWhoisQueries(t *testing.T) {
+	queries, err := newWhoisQueries(map[string]string{".JP": "{domain}", "example": "-C UTF-8 {domain}"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tests := []struct {
+		domain   string
+		expected string
+	}{
+		{"example.de", "-T dn,ace example.de"},
+		{"example.jp", "example.jp"},
+		{"brand.example", "-C UTF-8 brand.example"},
+		{"example.com", "example.com"},
+	}
+	for _, tt := range tests {
+		if got := queries.query(tt.domain); got != tt.expected {
+			t.Errorf("query(%s) = %q; expected %q", tt.domain, got, tt.expected)
+		}
+	}
+
+	var defaults whoisQueries
+	if got := defaults.query("example.jp"); got != "example.jp/e" {
+		t.Errorf("Expected the default .jp query, got %q", got)
+	}
+	if _, err := newWhoisQueries(map[string]string{"de": "-T dn"}); err == nil {
+		t.Error("Expected an error for a query without {domain}")
+	}
+}
+
+func TestStripDisclaimers(t *testing.T) {
+	raw := strings.Join([]string{
+		"   Domain Name: EXAMPLE.COM",
+		"   Registrar: Example Registrar, Inc.",
+		"",
+		">>> Last update of whois database: 2026-03-04T10:00:00Z <<<",
+		"",
+		"For more information on Whois status codes, please visit https://icann.org/epp",
+		"",
+		"NOTICE: The expiration date displayed in this record is the date the",
+		"registrar's sponsorship of the domain name registration in the registry is",
+		"currently set to expire.",
+		"",
+		"TERMS OF USE: You are not authorized to access or query our Whois",
+		"database through the use of electronic processes. The Registrant",
+		"Organization: field is provided by the registrar.",
+		"",
+		"% Internal Comment: keep me",
+	}, "\r\n")
+
+	stripped := stripDisclaimers(raw, nil)
+	for _, gone := range []string{"NOTICE:", "TERMS OF USE", "status codes", "Organization: field"} {
+		if strings.Contains(stripped, gone) {
+			t.Errorf("Expected %q to be stripped, got:\n%s", gone, stripped)
+		}
+	}
+	for _, kept := range []string{"Domain Name: EXAMPLE.COM", "Registrar: Example Registrar, Inc.", ">>> Last update", "% Internal Comment: keep me"} {
+		if !strings.Contains(stripped, kept) {
+			t.Errorf("Expected %q to be kept, got:\n%s", kept, stripped)
+		}
+	}
+
+	patterns, err := compileDisclaimers([]string{`% Internal Comment`})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(stripDisclaimers(raw, patterns), "keep me") {
+		t.Error("Expected a configured disclaimer to be stripped")
+	}
+	if _, err := compileDisclaimers([]string{`(`}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestQueryWhoisSendsRegistryQuery(t *testing.T) {
+	dialer := fakeWhoisDialer(t, "Domain Name: EXAMPLE.TEST\nRegistrar WHOIS Server: whois.registrar.test\n")
+	client := whois.NewClient().SetDialer(dialer).SetTimeout(2 * time.Second).SetDisableReferral(true)
+	ianaServers.Store("test", "whois.nic.test")
+	defer ianaServers.Delete("test")
+
+	if _, _, err := queryWhois(client, "example.test", "-T dn example.test", nil, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The registrar is sent the bare domain
+	if expected := []string{"-T dn example.test", "example.test"}; !reflect.DeepEqual(dialer.queries, expected) {
+		t.Errorf("Expected queries %q, got %q", expected, dialer.queries)
+	}
+}
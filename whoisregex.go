@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultWhoisPatterns extract fields from raw WHOIS text when whoisparser
+// cannot handle a registry's format. Each pattern captures the value in its
+// first group; the first pattern that matches wins.
+var defaultWhoisPatterns = map[string][]string{
+	"organization": {
+		`(?im)^\s*(?:registrant\s+)?(?:organi[sz]ation|org)(?:\s+name)?\s*:\s*(.+?)\s*$`,
+		`(?im)^\s*(?:registrant|holder|owner|domain\s+holder|titular|titulaire)(?:\s+name)?\s*:\s*(.+?)\s*$`,
+	},
+	"registrar": {
+		`(?im)^\s*(?:sponsoring\s+)?registrar(?:\s+name)?\s*:\s*(.+?)\s*$`,
+	},
+	"created_date": {
+		`(?im)^\s*(?:creation\s+date|created(?:\s+on|\s+date)?|registered(?:\s+on)?|registration\s+(?:date|time)|domain\s+registration\s+date)\s*:\s*(.+?)\s*$`,
+	},
+	"expiry_date": {
+		`(?im)^\s*(?:expir(?:y|ation|es)(?:\s+date|\s+on)?|registry\s+expiry\s+date|paid-till|valid\s+until|renewal\s+date)\s*:\s*(.+?)\s*$`,
+	},
+	"name_server": {
+		`(?im)^\s*(?:name\s*servers?|nserver|nameserver|dns)\s*:\s*([a-z0-9.-]+\.[a-z0-9-]+)\.?\s*$`,
+	},
+	"status": {
+		`(?im)^\s*(?:domain\s+)?status\s*:\s*(.+?)\s*$`,
+	},
+	"country": {
+		`(?im)^\s*(?:registrant|holder|owner)\s+country(?:\s+code)?\s*:\s*(.+?)\s*$`,
+	},
+}
+
+// whoisPatterns are the compiled raw-text extraction patterns by field
+type whoisPatterns map[string][]*regexp.Regexp
+
+// compileWhoisPatterns compiles the configured patterns, which are tried
+// before the defaults for their field
+func compileWhoisPatterns(extra map[string][]string) (whoisPatterns, error) {
+	patterns := whoisPatterns{}
+	for field := range extra {
+		if _, ok := defaultWhoisPatterns[field]; !ok {
+			fields := make([]string, 0, len(defaultWhoisPatterns))
+			for name := range defaultWhoisPatterns {
+				fields = append(fields, name)
+			}
+			sort.Strings(fields)
+			return nil, fmt.Errorf("whois_patterns: unknown field %q (valid: %s)", field, strings.Join(fields, ", "))
+		}
+	}
+	for field, defaults := range defaultWhoisPatterns {
+		for _, expr := range append(append([]string{}, extra[field]...), defaults...) {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("whois_patterns.%s: %w", field, err)
+			}
+			if re.NumSubexp() < 1 {
+				return nil, fmt.Errorf("whois_patterns.%s: %q has no capture group", field, expr)
+			}
+			patterns[field] = append(patterns[field], re)
+		}
+	}
+	return patterns, nil
+}
+
+// first returns the first captured value of field
+func (p whoisPatterns) first(field, raw string) string {
+	for _, re := range p[field] {
+		if m := re.FindStringSubmatch(raw); m != nil {
+			if value := strings.TrimSpace(m[1]); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// all returns every distinct captured value of field, from the first
+// pattern that matches
+func (p whoisPatterns) all(field, raw string) []string {
+	for _, re := range p[field] {
+		var values []string
+		seen := make(map[string]bool)
+		for _, m := range re.FindAllStringSubmatch(raw, -1) {
+			value := strings.ToLower(strings.TrimSpace(m[1]))
+			if value != "" && !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+		if len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+// extractWhoisFields builds DomainInfo from raw WHOIS text with the regex
+// patterns. It reports false when no field could be extracted.
+func extractWhoisFields(domain, raw string, patterns whoisPatterns) (*DomainInfo, bool) {
+	if patterns == nil {
+		patterns, _ = compileWhoisPatterns(nil)
+	}
+	info := &DomainInfo{
+		Domain:       domain,
+		Organization: patterns.first("organization", raw),
+		Country:      normalizeCountry(patterns.first("country", raw)),
+		Registrar:    patterns.first("registrar", raw),
+		CreatedDate:  patterns.first("created_date", raw),
+		ExpiryDate:   patterns.first("expiry_date", raw),
+		Status:       strings.Join(patterns.all("status", raw), ", "),
+		NameServers:  patterns.all("name_server", raw),
+		Timestamp:    time.Now(),
+	}
+	found := info.Organization != "" || info.Registrar != "" || info.CreatedDate != "" || len(info.NameServers) > 0
+	return info, found
+}
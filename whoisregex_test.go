@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const holderWhois = `% Registry WHOIS server
+
+domain:        example.tld
+holder:        Example Holdings Ltd
+registrar:     Example Registrar
+created:       2019-04-01
+paid-till:     2025-04-01
+nserver:       NS1.EXAMPLE.NET.
+nserver:       ns2.example.net.
+nserver:       ns1.example.net
+status:        REGISTERED, DELEGATED
+`
+
+func TestExtractWhoisFields(t *testing.T) {
+	patterns, err := compileWhoisPatterns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, ok := extractWhoisFields("example.tld", holderWhois, patterns)
+	if !ok {
+		t.Fatal("Expected fields to be extracted")
+	}
+	if info.Organization != "Example Holdings Ltd" || info.Registrar != "Example Registrar" {
+		t.Errorf("Unexpected organization/registrar: %q / %q", info.Organization, info.Registrar)
+	}
+	if info.CreatedDate != "2019-04-01" || info.ExpiryDate != "2025-04-01" {
+		t.Errorf("Unexpected dates: %q / %q", info.CreatedDate, info.ExpiryDate)
+	}
+	if !reflect.DeepEqual(info.NameServers, []string{"ns1.example.net", "ns2.example.net"}) {
+		t.Errorf("Unexpected name servers: %v", info.NameServers)
+	}
+
+	if _, ok := extractWhoisFields("example.tld", "No match for domain", patterns); ok {
+		t.Error("Expected nothing to be extracted from a not-found response")
+	}
+}
+
+func TestCompileWhoisPatterns(t *testing.T) {
+	patterns, err := compileWhoisPatterns(map[string][]string{
+		"organization": {`(?m)^\s*Organization Using Domain Name\s*\n\s*Name\.*:\s*(.+)$`},
+	})
+	if err != nil {
+		t.Fatalf("compileWhoisPatterns failed: %v", err)
+	}
+	raw := "Organization Using Domain Name\nName...................: Example LLP\n"
+	if org := patterns.first("organization", raw); org != "Example LLP" {
+		t.Errorf("Expected the configured pattern to match first, got %q", org)
+	}
+
+	if _, err := compileWhoisPatterns(map[string][]string{"owner": {`(.+)`}}); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+	if _, err := compileWhoisPatterns(map[string][]string{"registrar": {`registrar:`}}); err == nil {
+		t.Error("Expected an error for a pattern without a capture group")
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// builtinWordlists are the curated wordlists shipped inside the binary,
+// selected with -w builtin:<name>
+//
+//go:embed wordlists/*.txt
+var builtinWordlists embed.FS
+
+// defaultWordlist is the -w default, replaced by builtin:all when missing
+const defaultWordlist = "wordlist.txt"
+
+// stdinWordlist is the -w value that reads TLDs from stdin
+const stdinWordlist = "-"
+
+// builtinPrefix marks a -w value naming an embedded wordlist
+const builtinPrefix = "builtin:"
+
+// tldPattern matches a normalized wordlist entry: one or more dot-prefixed
+// DNS labels, e.g. ".com" or ".co.uk"
+var tldPattern = regexp.MustCompile(`^(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)+$`)
+
+// Reasons a wordlist entry is skipped
+const (
+	skipDuplicate = "duplicate"
+	skipInvalid   = "invalid"
+)
+
+// wordlistSkips counts skipped wordlist entries by reason
+type wordlistSkips map[string]int
+
+func (s wordlistSkips) total() int {
+	total := 0
+	for _, n := range s {
+		total += n
+	}
+	return total
+}
+
+// String renders the counts as "2 duplicate, 1 invalid"
+func (s wordlistSkips) String() string {
+	var reasons []string
+	for reason := range s {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	var parts []string
+	for _, reason := range reasons {
+		parts = append(parts, fmt.Sprintf("%d %s", s[reason], reason))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// normalizeTLD lowercases an entry, strips trailing dots, converts native
+// script (IDN) labels to punycode and adds the leading dot. It reports false
+// when the result is not valid TLD syntax.
+func normalizeTLD(entry string) (string, bool) {
+	tld := strings.Trim(strings.ToLower(strings.TrimSpace(entry)), ".")
+	if !isASCII(tld) {
+		ascii, err := idna.Lookup.ToASCII(tld)
+		if err != nil {
+			return "." + tld, false
+		}
+		tld = ascii
+	}
+	tld = "." + tld
+	if !tldPattern.MatchString(tld) {
+		return tld, false
+	}
+	// The rightmost label is never all-numeric
+	last := tld[strings.LastIndex(tld, ".")+1:]
+	if strings.Trim(last, "0123456789") == "" {
+		return tld, false
+	}
+	return tld, true
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// unicodeDomain returns the native script form of a punycode domain, or ""
+// when the domain has no IDN labels
+func unicodeDomain(domain string) string {
+	if !strings.Contains(domain, "xn--") {
+		return ""
+	}
+	unicode, err := idna.Lookup.ToUnicode(domain)
+	if err != nil || unicode == domain {
+		return ""
+	}
+	return unicode
+}
+
+// displayName renders a domain for humans, adding the native script form of
+// IDN domains: "example.xn--p1ai (example.рф)"
+func (d DomainInfo) displayName() string {
+	if d.UnicodeDomain == "" {
+		return d.Domain
+	}
+	return fmt.Sprintf("%s (%s)", d.Domain, d.UnicodeDomain)
+}
+
+// parseWordlist reads one TLD per line, ignoring blank lines and # comments.
+// Entries are normalized and deduplicated; invalid and duplicate entries
+// are counted in the returned skips.
+func parseWordlist(r io.Reader) ([]string, wordlistSkips, error) {
+	return parseEntries(r, normalizeTLD)
+}
+
+// parseDomainList reads one fully qualified domain per line, with the same
+// comment, normalization and deduplication rules as wordlists
+func parseDomainList(r io.Reader) ([]string, wordlistSkips, error) {
+	return parseEntries(r, normalizeDomain)
+}
+
+// normalizeDomain normalizes a domain list entry like a TLD, additionally
+// requiring at least two labels
+func normalizeDomain(entry string) (string, bool) {
+	tld, ok := normalizeTLD(entry)
+	domain := strings.TrimPrefix(tld, ".")
+	return domain, ok && strings.Contains(domain, ".")
+}
+
+func parseEntries(r io.Reader, normalize func(string) (string, bool)) ([]string, wordlistSkips, error) {
+	entries := []string{}
+	skipped := wordlistSkips{}
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Only the first column is read, so -errors-file output can be
+		// scanned again as is
+		entry, ok := normalize(strings.Fields(line)[0])
+		if !ok {
+			skipped[skipInvalid]++
+			continue
+		}
+		if seen[entry] {
+			skipped[skipDuplicate]++
+			continue
+		}
+		seen[entry] = true
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading list: %w", err)
+	}
+	return entries, skipped, nil
+}
+
+// builtinWordlistNames returns the embedded wordlist names, including "all"
+func builtinWordlistNames() []string {
+	entries, _ := builtinWordlists.ReadDir("wordlists")
+	names := []string{"all"}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".txt"))
+	}
+	return names
+}
+
+// builtinWordlist returns the contents of an embedded wordlist. "all" is the
+// concatenation of every embedded list; duplicates are dropped when parsed.
+func builtinWordlist(name string) ([]byte, error) {
+	if name != "all" {
+		data, err := builtinWordlists.ReadFile("wordlists/" + name + ".txt")
+		if err != nil {
+			return nil, fmt.Errorf("unknown builtin wordlist %q (valid: %s)", name, strings.Join(builtinWordlistNames(), ", "))
+		}
+		return data, nil
+	}
+
+	var all bytes.Buffer
+	for _, listName := range builtinWordlistNames()[1:] {
+		data, err := builtinWordlists.ReadFile("wordlists/" + listName + ".txt")
+		if err != nil {
+			return nil, err
+		}
+		all.Write(data)
+		all.WriteByte('\n')
+	}
+	return all.Bytes(), nil
+}
+
+// readWordlist loads a wordlist file, or an embedded one named
+// builtin:<name>, or stdin when filename is "-", along with its skipped
+// entry counts
+func readWordlist(filename string) ([]string, wordlistSkips, error) {
+	if name, ok := strings.CutPrefix(filename, builtinPrefix); ok {
+		data, err := builtinWordlist(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return parseWordlist(bytes.NewReader(data))
+	}
+	return readList(filename, parseWordlist)
+}
+
+// readDomainList loads a -domains-file list, or stdin when filename is "-"
+func readDomainList(filename string) ([]string, wordlistSkips, error) {
+	return readList(filename, parseDomainList)
+}
+
+// readList parses a list file, decompressing .gz files, or stdin when
+// filename is "-"
+func readList(filename string, parse func(io.Reader) ([]string, wordlistSkips, error)) ([]string, wordlistSkips, error) {
+	if filename == stdinWordlist {
+		if isTerminal(os.Stdin) {
+			return nil, nil, fmt.Errorf("- expects a list piped on stdin")
+		}
+		return parse(os.Stdin)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open list file: %w", err)
+	}
+	defer file.Close()
+
+	if strings.HasSuffix(filename, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read compressed list: %w", err)
+		}
+		defer zr.Close()
+		return parse(zr)
+	}
+	return parse(file)
+}
+
+func loadWordlist(filename string) ([]string, error) {
+	tlds, _, err := readWordlist(filename)
+	return tlds, err
+}
+
+// popularTLDs returns the TLDs of the embedded popular list, most valuable
+// first
+func popularTLDs() []string {
+	data, _ := builtinWordlist("popular")
+	popular, _, _ := parseWordlist(bytes.NewReader(data))
+	return popular
+}
+
+// prioritizeTLDs moves high-value TLDs to the front so the most important
+// matches surface early in long scans. Priority follows the order of the
+// embedded popular list; the remaining TLDs keep their wordlist order.
+func prioritizeTLDs(tlds []string) []string {
+	popular := popularTLDs()
+	rank := make(map[string]int, len(popular))
+	for i, tld := range popular {
+		rank[tld] = i
+	}
+
+	ordered := append([]string(nil), tlds...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, iok := rank[ordered[i]]
+		rj, jok := rank[ordered[j]]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+	return ordered
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTLD(t *testing.T) {
+	testCases := []struct {
+		entry    string
+		expected string
+		valid    bool
+	}{
+		{"com", ".com", true},
+		{".COM", ".com", true},
+		{"com.", ".com", true},
+		{" Co.UK ", ".co.uk", true},
+		{"xn--p1ai", ".xn--p1ai", true},
+		{"рф", ".xn--p1ai", true},
+		{".中国", ".xn--fiqs8s", true},
+		{"РФ.", ".xn--p1ai", true},
+		{"-com", ".-com", false},
+		{"co..uk", ".co..uk", false},
+		{"c_m", ".c_m", false},
+		{"123", ".123", false},
+		{".", ".", false},
+	}
+
+	for _, tc := range testCases {
+		tld, valid := normalizeTLD(tc.entry)
+		if tld != tc.expected || valid != tc.valid {
+			t.Errorf("normalizeTLD(%q) = %q, %v; expected %q, %v", tc.entry, tld, valid, tc.expected, tc.valid)
+		}
+	}
+}
+
+func TestParseWordlistDeduplicates(t *testing.T) {
+	content := "com\n.COM\n.com.\nnet\n# comment\nbad_tld\nNET\n"
+	tlds, skipped, err := parseWordlist(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parseWordlist failed: %v", err)
+	}
+
+	expected := []string{".com", ".net"}
+	if !reflect.DeepEqual(tlds, expected) {
+		t.Errorf("parseWordlist() = %v; expected %v", tlds, expected)
+	}
+	if skipped[skipDuplicate] != 3 || skipped[skipInvalid] != 1 {
+		t.Errorf("skipped = %v; expected 3 duplicate, 1 invalid", skipped)
+	}
+	if skipped.String() != "3 duplicate, 1 invalid" {
+		t.Errorf("skipped.String() = %q", skipped.String())
+	}
+}
+
+func TestUnicodeDomain(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+	}{
+		{"example.xn--p1ai", "example.рф"},
+		{"example.xn--fiqs8s", "example.中国"},
+		{"example.com", ""},
+	}
+
+	for _, tc := range testCases {
+		if got := unicodeDomain(tc.domain); got != tc.expected {
+			t.Errorf("unicodeDomain(%q) = %q; expected %q", tc.domain, got, tc.expected)
+		}
+	}
+
+	info := DomainInfo{Domain: "example.xn--p1ai", UnicodeDomain: "example.рф"}
+	if got := info.displayName(); got != "example.xn--p1ai (example.рф)" {
+		t.Errorf("displayName() = %q", got)
+	}
+}
+
+func TestReadCompressedWordlist(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tlds.txt.gz")
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := gzip.NewWriter(file)
+	zw.Write([]byte("com\n# comment\nIO\n"))
+	zw.Close()
+	file.Close()
+
+	tlds, err := loadWordlist(name)
+	if err != nil {
+		t.Fatalf("loadWordlist failed: %v", err)
+	}
+	if strings.Join(tlds, ",") != ".com,.io" {
+		t.Errorf("Expected [.com .io], got %v", tlds)
+	}
+}
+
+func TestCandidateDomainsFromFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "domains.txt")
+	data := "# from subfinder\nExample.IO\nexample.com\nexample-login.net.\nexample.io\nlocalhost\n"
+	if err := os.WriteFile(name, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := candidateDomains(Config{Domain: "example.com", DomainsFile: name})
+	if err != nil {
+		t.Fatalf("candidateDomains failed: %v", err)
+	}
+	expected := []string{"example.io", "example-login.net"}
+	if !reflect.DeepEqual(domains.slice(), expected) {
+		t.Errorf("Expected %v, got %v", expected, domains.slice())
+	}
+}
+
+func TestBuiltinWordlists(t *testing.T) {
+	total := 0
+	for _, name := range []string{"popular", "cctld", "newgtld"} {
+		tlds, skipped, err := readWordlist(builtinPrefix + name)
+		if err != nil {
+			t.Fatalf("readWordlist(builtin:%s) failed: %v", name, err)
+		}
+		if len(tlds) == 0 || skipped.total() != 0 {
+			t.Errorf("builtin:%s has %d TLDs and skipped %v", name, len(tlds), skipped)
+		}
+		total += len(tlds)
+	}
+
+	all, _, err := readWordlist(builtinPrefix + "all")
+	if err != nil {
+		t.Fatalf("readWordlist(builtin:all) failed: %v", err)
+	}
+	if len(all) == 0 || len(all) > total {
+		t.Errorf("builtin:all has %d TLDs; expected the deduplicated union of %d", len(all), total)
+	}
+
+	if _, _, err := readWordlist(builtinPrefix + "nope"); err == nil {
+		t.Error("Expected an error for an unknown builtin wordlist")
+	}
+}
+
+func TestPrioritizeTLDs(t *testing.T) {
+	tlds := []string{".zone", ".de", ".academy", ".net", ".com"}
+	expected := []string{".com", ".net", ".de", ".zone", ".academy"}
+	if got := prioritizeTLDs(tlds); !reflect.DeepEqual(got, expected) {
+		t.Errorf("prioritizeTLDs() = %v; expected %v", got, expected)
+	}
+	if tlds[0] != ".zone" {
+		t.Error("prioritizeTLDs() modified its input")
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ianaTLDListURL is the authoritative list of delegated TLDs
+var ianaTLDListURL = "https://data.iana.org/TLD/tlds-alpha-by-domain.txt"
+
+// runWordlist implements `tldscanner wordlist update`
+func runWordlist(args []string) int {
+	fs := flag.NewFlagSet("wordlist", flag.ContinueOnError)
+	file := fs.String("file", defaultWordlist, "Wordlist file to update")
+	source := fs.String("url", ianaTLDListURL, "URL of the IANA TLD list")
+	dryRun := fs.Bool("dry-run", false, "Report additions and removals without writing the file")
+	fs.Usage = func() {
+		fmt.Printf("Usage: %s wordlist update [OPTIONS]\n\n", os.Args[0])
+		fmt.Printf("Merges the latest IANA TLD list into a wordlist, keeping comments and\n")
+		fmt.Printf("multi-label entries such as co.uk.\n\nOptions:\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "update" {
+		fs.Usage()
+		return ExitUsage
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return ExitUsage
+	}
+
+	version, iana, err := fetchIANATLDs(*source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s Failed to fetch IANA TLD list: %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	var local []byte
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(*file); err == nil {
+		perm = info.Mode().Perm()
+		if local, err = os.ReadFile(*file); err != nil {
+			fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+			return ExitUsage
+		}
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s %v\n", ColorRed, ColorReset, err)
+		return ExitUsage
+	}
+
+	merged, added, removed := mergeWordlist(string(local), iana, version)
+	for _, tld := range added {
+		fmt.Printf("%s[+]%s %s\n", ColorGreen, ColorReset, tld)
+	}
+	for _, tld := range removed {
+		fmt.Printf("%s[-]%s %s\n", ColorRed, ColorReset, tld)
+	}
+	fmt.Printf("%s[INFO]%s %s: %d added, %d removed (%s)\n", ColorBlue, ColorReset, *file, len(added), len(removed), version)
+
+	if *dryRun || (len(added) == 0 && len(removed) == 0) {
+		return ExitMatches
+	}
+	if err := writeFileAtomic(*file, []byte(merged), perm); err != nil {
+		fmt.Fprintf(os.Stderr, "%s[ERROR]%s Failed to write %s: %v\n", ColorRed, ColorReset, *file, err)
+		return ExitUsage
+	}
+	return ExitMatches
+}
+
+// fetchIANATLDs downloads the IANA TLD list and returns its version line
+// and the lowercase TLDs it contains
+func fetchIANATLDs(url string) (string, []string, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return parseIANATLDs(resp.Body)
+}
+
+// parseIANATLDs parses tlds-alpha-by-domain.txt: a "# Version ..." header
+// followed by one uppercase TLD per line
+func parseIANATLDs(r io.Reader) (string, []string, error) {
+	var version string
+	var tlds []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			if version == "" {
+				version = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			}
+			continue
+		}
+		if tld, ok := normalizeTLD(line); ok && line != "" {
+			tlds = append(tlds, strings.TrimPrefix(tld, "."))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+	if len(tlds) == 0 {
+		return "", nil, fmt.Errorf("no TLDs in list")
+	}
+	return version, tlds, nil
+}
+
+// mergeWordlist brings a wordlist in line with the IANA list. Comments,
+// blank lines and multi-label entries are kept as written; single-label
+// entries no longer delegated are dropped, and new TLDs are appended under
+// a comment naming the list version.
+func mergeWordlist(local string, iana []string, version string) (string, []string, []string) {
+	delegated := make(map[string]bool, len(iana))
+	for _, tld := range iana {
+		delegated[tld] = true
+	}
+
+	var out strings.Builder
+	var removed []string
+	present := make(map[string]bool)
+	if local != "" {
+		for _, line := range strings.Split(strings.TrimRight(local, "\n"), "\n") {
+			entry := strings.TrimSpace(line)
+			if entry == "" || strings.HasPrefix(entry, "#") {
+				out.WriteString(line + "\n")
+				continue
+			}
+			tld, ok := normalizeTLD(entry)
+			name := strings.TrimPrefix(tld, ".")
+			if ok && !strings.Contains(name, ".") && !delegated[name] {
+				removed = append(removed, name)
+				continue
+			}
+			present[name] = true
+			out.WriteString(line + "\n")
+		}
+	}
+
+	var added []string
+	for _, tld := range iana {
+		if !present[tld] {
+			added = append(added, tld)
+		}
+	}
+	sort.Strings(added)
+
+	if len(added) > 0 {
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(fmt.Sprintf("# Added from IANA list (%s)\n", version))
+		for _, tld := range added {
+			out.WriteString(tld + "\n")
+		}
+	}
+	return out.String(), added, removed
+}
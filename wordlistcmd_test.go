@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIANATLDs(t *testing.T) {
+	list := "# Version 2026101600, Last Updated Fri Oct 16 07:07:01 2026 UTC\nAAA\nCOM\nXN--P1AI\n"
+	version, tlds, err := parseIANATLDs(strings.NewReader(list))
+	if err != nil {
+		t.Fatalf("parseIANATLDs failed: %v", err)
+	}
+	if version != "Version 2026101600, Last Updated Fri Oct 16 07:07:01 2026 UTC" {
+		t.Errorf("version = %q", version)
+	}
+	expected := []string{"aaa", "com", "xn--p1ai"}
+	if !reflect.DeepEqual(tlds, expected) {
+		t.Errorf("parseIANATLDs() = %v; expected %v", tlds, expected)
+	}
+}
+
+func TestMergeWordlist(t *testing.T) {
+	local := "# Common TLDs\ncom\nNET\n\n# Second level\nco.uk\n# Retired\nbrokenretired\n"
+	iana := []string{"com", "net", "org", "app"}
+
+	merged, added, removed := mergeWordlist(local, iana, "Version 1")
+
+	expectedMerged := "# Common TLDs\ncom\nNET\n\n# Second level\nco.uk\n# Retired\n\n# Added from IANA list (Version 1)\napp\norg\n"
+	if merged != expectedMerged {
+		t.Errorf("merged =\n%s\nexpected\n%s", merged, expectedMerged)
+	}
+	if !reflect.DeepEqual(added, []string{"app", "org"}) {
+		t.Errorf("added = %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"brokenretired"}) {
+		t.Errorf("removed = %v", removed)
+	}
+
+	// Already up to date: nothing changes
+	again, added, removed := mergeWordlist(merged, iana, "Version 2")
+	if again != merged || len(added) != 0 || len(removed) != 0 {
+		t.Errorf("Second merge changed the wordlist: +%v -%v", added, removed)
+	}
+}